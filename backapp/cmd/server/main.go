@@ -2,15 +2,23 @@ package main
 
 import (
 	"backapp/internal/config"
+	"backapp/internal/handler"
 	"backapp/internal/middleware"
 	"backapp/internal/models"
 	"backapp/internal/repository"
 	"backapp/internal/router"
 	"backapp/internal/websocket"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -52,13 +60,36 @@ func main() {
 
 	hubManager := websocket.NewHubManager()
 
+	// 予約通知のスケジューラー。起動時に未送信の予約を読み直して送る
+	notificationScheduler := handler.NewNotificationScheduler(repository.NewScheduledNotificationRepository(db))
+
 	// ルーターをセットアップ
-	r := router.SetupRouter(db, cfg, hubManager)
+	r, workers := router.SetupRouter(db, cfg, hubManager, notificationScheduler)
+
+	// バックグラウンド処理はサーバーの終了シグナルで止める
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var wg sync.WaitGroup
+	wg.Go(func() { notificationScheduler.Run(ctx) })
+	wg.Go(func() { workers.Run(ctx) })
+
+	server := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Failed to shut down server: %v", err)
+		}
+	}()
 
 	log.Println("Starting server on :8080")
-	if err := r.Run(":8080"); err != nil {
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("Failed to start server: %v", err)
 	}
+	stop()
+	wg.Wait()
+	log.Println("Server stopped.")
 }
 
 // initializeEvent は初期イベントと関連クラスを登録する
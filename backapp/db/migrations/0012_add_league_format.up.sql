@@ -0,0 +1,102 @@
+-- リーグ戦（総当たり）形式を event_sports ごとに選べるようにする。
+ALTER TABLE event_sports
+    ADD COLUMN format ENUM('tournament', 'league') NOT NULL DEFAULT 'tournament' COMMENT '試合形式' AFTER location,
+    ADD COLUMN league_group_count INT NULL DEFAULT NULL COMMENT 'リーグのグループ数' AFTER format,
+    ADD COLUMN league_advance_count INT NULL DEFAULT NULL COMMENT '各グループから決勝トーナメントへ進むチーム数' AFTER league_group_count;
+
+ALTER TABLE matches
+    ADD COLUMN is_league_match BOOLEAN NOT NULL DEFAULT FALSE AFTER loser_bracket_block,
+    ADD COLUMN league_group VARCHAR(8) NULL DEFAULT NULL COMMENT 'リーグのグループ識別: A, B, ...' AFTER is_league_match;
+
+ALTER TABLE score_logs
+    DROP CHECK chk_score_logs_reason;
+
+ALTER TABLE score_logs
+    ADD CONSTRAINT chk_score_logs_reason CHECK (reason IN (
+        'attendance_points',
+        'initial_points',
+        'survey_points',
+        'mic_points',
+        'gym1_win1_points',
+        'gym1_win2_points',
+        'gym1_win3_points',
+        'gym1_champion_points',
+        'gym1_league_points',
+        'gym2_win1_points',
+        'gym2_win2_points',
+        'gym2_win3_points',
+        'gym2_champion_points',
+        'gym2_loser_bracket_champion_points',
+        'gym2_league_points',
+        'ground_win1_points',
+        'ground_win2_points',
+        'ground_win3_points',
+        'ground_champion_points',
+        'ground_league_points',
+        'noon_game_points'
+    ));
+
+DROP VIEW IF EXISTS class_scores;
+
+CREATE VIEW class_scores AS
+WITH aggregated_scores AS (
+    SELECT 
+        c.id AS class_id,
+        c.event_id AS event_id,
+        COALESCE(SUM(CASE WHEN sl.reason = 'initial_points' THEN sl.points ELSE 0 END), 0) AS initial_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'survey_points' THEN sl.points ELSE 0 END), 0) AS survey_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'attendance_points' THEN sl.points ELSE 0 END), 0) AS attendance_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'gym1_win1_points' THEN sl.points ELSE 0 END), 0) AS gym1_win1_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'gym1_win2_points' THEN sl.points ELSE 0 END), 0) AS gym1_win2_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'gym1_win3_points' THEN sl.points ELSE 0 END), 0) AS gym1_win3_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'gym1_champion_points' THEN sl.points ELSE 0 END), 0) AS gym1_champion_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'gym1_league_points' THEN sl.points ELSE 0 END), 0) AS gym1_league_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'gym2_win1_points' THEN sl.points ELSE 0 END), 0) AS gym2_win1_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'gym2_win2_points' THEN sl.points ELSE 0 END), 0) AS gym2_win2_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'gym2_win3_points' THEN sl.points ELSE 0 END), 0) AS gym2_win3_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'gym2_champion_points' THEN sl.points ELSE 0 END), 0) AS gym2_champion_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'gym2_loser_bracket_champion_points' THEN sl.points ELSE 0 END), 0) AS gym2_loser_bracket_champion_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'gym2_league_points' THEN sl.points ELSE 0 END), 0) AS gym2_league_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'ground_win1_points' THEN sl.points ELSE 0 END), 0) AS ground_win1_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'ground_win2_points' THEN sl.points ELSE 0 END), 0) AS ground_win2_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'ground_win3_points' THEN sl.points ELSE 0 END), 0) AS ground_win3_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'ground_champion_points' THEN sl.points ELSE 0 END), 0) AS ground_champion_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'ground_league_points' THEN sl.points ELSE 0 END), 0) AS ground_league_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'noon_game_points' THEN sl.points ELSE 0 END), 0) AS noon_game_points,
+        COALESCE(SUM(CASE WHEN sl.reason = 'mic_points' THEN sl.points ELSE 0 END), 0) AS mic_points,
+        COALESCE(SUM(CASE WHEN sl.reason != 'initial_points' THEN sl.points ELSE 0 END), 0) AS total_points_current_event,
+        COALESCE(SUM(sl.points), 0) AS total_points_overall
+    FROM classes c
+    LEFT JOIN score_logs sl ON c.id = sl.class_id
+    GROUP BY c.id, c.event_id
+)
+SELECT 
+    class_id AS id, -- Goモデル用のエイリアス
+    class_id,
+    event_id,
+    initial_points,
+    survey_points,
+    attendance_points,
+    gym1_win1_points,
+    gym1_win2_points,
+    gym1_win3_points,
+    gym1_champion_points,
+    gym1_league_points,
+    gym2_win1_points,
+    gym2_win2_points,
+    gym2_win3_points,
+    gym2_champion_points,
+    gym2_loser_bracket_champion_points,
+    gym2_league_points,
+    ground_win1_points,
+    ground_win2_points,
+    ground_win3_points,
+    ground_champion_points,
+    ground_league_points,
+    noon_game_points,
+    mic_points,
+    total_points_current_event,
+    IF(total_points_current_event = 0, 0, RANK() OVER (PARTITION BY event_id ORDER BY total_points_current_event DESC)) AS rank_current_event,
+    total_points_overall,
+    IF(total_points_overall = 0, 0, RANK() OVER (PARTITION BY event_id ORDER BY total_points_overall DESC)) AS rank_overall
+FROM aggregated_scores;
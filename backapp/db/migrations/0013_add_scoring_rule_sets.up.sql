@@ -0,0 +1,12 @@
+-- イベントごとの配点ルール。更新のたびに新しいバージョンを追加し、最新のバージョンを適用する。
+CREATE TABLE scoring_rule_sets (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    event_id INT NOT NULL,
+    version INT NOT NULL,
+    rules JSON NOT NULL,
+    created_by CHAR(36) NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_scoring_rule_sets_event FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+    CONSTRAINT fk_scoring_rule_sets_created_by FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE KEY uq_scoring_rule_sets_event_version (event_id, version)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,10 @@
+ALTER TABLE score_logs
+    DROP FOREIGN KEY fk_score_logs_actor,
+    DROP FOREIGN KEY fk_score_logs_reverses;
+
+ALTER TABLE score_logs
+    DROP INDEX uq_score_logs_reverses,
+    DROP INDEX idx_score_logs_event_class,
+    DROP COLUMN reverses_log_id,
+    DROP COLUMN actor_user_id,
+    DROP COLUMN operation;
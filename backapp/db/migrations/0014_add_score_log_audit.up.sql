@@ -0,0 +1,21 @@
+-- score_logs を追記のみの台帳にする。誰がどの操作で記録したかを残し、
+-- 取り消しは削除ではなく元の記録を指す打ち消しの記録で表す。
+ALTER TABLE score_logs
+    ADD COLUMN operation ENUM('match_result', 'match_correction', 'attendance', 'survey', 'mic', 'noon_game', 'manual', 'initial', 'recalculation') NOT NULL DEFAULT 'manual' COMMENT '得点を記録した操作' AFTER source_match_id,
+    ADD COLUMN actor_user_id CHAR(36) NULL DEFAULT NULL COMMENT '操作したユーザー' AFTER operation,
+    ADD COLUMN reverses_log_id INT NULL DEFAULT NULL COMMENT '打ち消した記録' AFTER actor_user_id,
+    ADD CONSTRAINT fk_score_logs_actor FOREIGN KEY (actor_user_id) REFERENCES users(id) ON DELETE SET NULL,
+    ADD CONSTRAINT fk_score_logs_reverses FOREIGN KEY (reverses_log_id) REFERENCES score_logs(id),
+    ADD UNIQUE KEY uq_score_logs_reverses (reverses_log_id),
+    ADD INDEX idx_score_logs_event_class (event_id, class_id, created_at);
+
+UPDATE score_logs
+SET operation = CASE
+    WHEN reason = 'attendance_points' THEN 'attendance'
+    WHEN reason = 'survey_points' THEN 'survey'
+    WHEN reason = 'mic_points' THEN 'mic'
+    WHEN reason = 'noon_game_points' THEN 'noon_game'
+    WHEN reason = 'initial_points' THEN 'initial'
+    WHEN source_match_id IS NOT NULL THEN 'match_result'
+    ELSE 'manual'
+END;
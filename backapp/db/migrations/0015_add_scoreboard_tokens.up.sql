@@ -0,0 +1,10 @@
+-- 会場の掲示用スコアボードがログインなしで購読するためのイベントごとのトークン。
+-- トークンそのものは保存せず、SHA-256 のハッシュだけを持つ。
+CREATE TABLE scoreboard_tokens (
+    event_id INT PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL,
+    created_by CHAR(36) NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_scoreboard_tokens_event FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+    CONSTRAINT fk_scoreboard_tokens_created_by FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,9 @@
+-- イベントごとの試合日程の設定。会場ごとのコートと競技ごとの1試合の枠の長さを JSON で持つ。
+CREATE TABLE match_schedule_configs (
+    event_id INT PRIMARY KEY,
+    config JSON NOT NULL,
+    updated_by CHAR(36) NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_match_schedule_configs_event FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+    CONSTRAINT fk_match_schedule_configs_updated_by FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
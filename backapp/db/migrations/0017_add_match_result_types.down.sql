@@ -0,0 +1,6 @@
+ALTER TABLE matches
+    DROP FOREIGN KEY fk_matches_forfeiting_team;
+
+ALTER TABLE matches
+    DROP COLUMN forfeiting_team_id,
+    DROP COLUMN result_type;
@@ -0,0 +1,6 @@
+-- 不戦勝・棄権・両者不戦敗・失格をスコアの代わりに記録できるようにする。
+-- 勝者は forfeiting_team_id があればその相手チーム、なければスコアから決まる。
+ALTER TABLE matches
+    ADD COLUMN result_type ENUM('normal', 'walkover', 'forfeit', 'double_forfeit', 'disqualification') NOT NULL DEFAULT 'normal' COMMENT '試合結果の種別' AFTER team2_score,
+    ADD COLUMN forfeiting_team_id INT NULL DEFAULT NULL COMMENT '不出場・棄権・失格となったチーム' AFTER result_type,
+    ADD CONSTRAINT fk_matches_forfeiting_team FOREIGN KEY (forfeiting_team_id) REFERENCES teams(id);
@@ -0,0 +1,6 @@
+UPDATE score_logs SET operation = 'match_correction' WHERE operation = 'match_restore';
+
+ALTER TABLE score_logs
+    MODIFY COLUMN operation ENUM('match_result', 'match_correction', 'attendance', 'survey', 'mic', 'noon_game', 'manual', 'initial', 'recalculation') NOT NULL DEFAULT 'manual' COMMENT '得点を記録した操作';
+
+DROP TABLE IF EXISTS match_revisions;
@@ -0,0 +1,28 @@
+-- 試合の変更履歴。試合を変更するたびに変更後の状態を1行ずつ残し、同じ操作で変更した試合は change_id でまとめる。
+-- 最初の変更の前には変更前の状態を operation = 'initial' として残す。
+CREATE TABLE match_revisions (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    match_id INT NOT NULL,
+    change_id CHAR(36) NOT NULL COMMENT '同じ操作で記録したリビジョンに共通のID',
+    operation ENUM('initial', 'match_result', 'match_correction', 'match_restore', 'start_time', 'schedule', 'league_knockout') NOT NULL COMMENT '試合を変更した操作',
+    team1_id INT NULL,
+    team2_id INT NULL,
+    team1_score INT NULL,
+    team2_score INT NULL,
+    result_type ENUM('normal', 'walkover', 'forfeit', 'double_forfeit', 'disqualification') NOT NULL DEFAULT 'normal',
+    forfeiting_team_id INT NULL,
+    status VARCHAR(50) NULL,
+    match_start_time TIMESTAMP NULL,
+    rainy_mode_start_time VARCHAR(255) NULL,
+    court_number VARCHAR(255) NULL,
+    actor_user_id CHAR(36) NULL COMMENT '操作したユーザー',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_match_revisions_match FOREIGN KEY (match_id) REFERENCES matches(id) ON DELETE CASCADE,
+    CONSTRAINT fk_match_revisions_actor FOREIGN KEY (actor_user_id) REFERENCES users(id) ON DELETE SET NULL,
+    INDEX idx_match_revisions_match (match_id, id),
+    INDEX idx_match_revisions_change (change_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 履歴から復元したときに付け直した得点を区別できるようにする
+ALTER TABLE score_logs
+    MODIFY COLUMN operation ENUM('match_result', 'match_correction', 'attendance', 'survey', 'mic', 'noon_game', 'manual', 'initial', 'recalculation', 'match_restore') NOT NULL DEFAULT 'manual' COMMENT '得点を記録した操作';
@@ -0,0 +1,7 @@
+-- 試合開始前の自動 Push 通知の送信記録。開始時刻ごとに1回だけ送り、開始時刻が変われば送り直す。
+CREATE TABLE match_start_notifications (
+    match_id INT PRIMARY KEY,
+    start_time DATETIME NOT NULL,
+    notified_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_match_start_notifications_match FOREIGN KEY (match_id) REFERENCES matches(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
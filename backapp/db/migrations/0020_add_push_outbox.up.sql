@@ -0,0 +1,30 @@
+-- Push 通知の送信キュー。送る内容を push_messages に、購読ごとの配信状況を push_deliveries に保存し、
+-- ワーカーが未送信・再送待ちの配信を取り出して送る。
+CREATE TABLE push_messages (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    notification_id INT NULL,
+    source VARCHAR(50) NOT NULL,
+    payload TEXT NOT NULL,
+    ttl INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_push_messages_notification_id (notification_id),
+    CONSTRAINT fk_push_messages_notification FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE push_deliveries (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    message_id INT NOT NULL,
+    subscription_id INT NULL,
+    user_id CHAR(36) NOT NULL,
+    status ENUM('pending', 'sent', 'failed', 'expired') NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_status_code INT NULL,
+    last_error VARCHAR(255) NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    KEY idx_push_deliveries_status_next_attempt (status, next_attempt_at),
+    KEY idx_push_deliveries_message_status (message_id, status),
+    CONSTRAINT fk_push_deliveries_message FOREIGN KEY (message_id) REFERENCES push_messages(id) ON DELETE CASCADE,
+    CONSTRAINT fk_push_deliveries_subscription FOREIGN KEY (subscription_id) REFERENCES push_subscriptions(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
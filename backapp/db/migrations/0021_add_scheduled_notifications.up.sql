@@ -0,0 +1,21 @@
+-- 送信日時を指定した予約通知。サーバーが再起動しても未送信の予約を読み直して送る。
+CREATE TABLE scheduled_notifications (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    type VARCHAR(50) NOT NULL DEFAULT 'general',
+    target_roles JSON NOT NULL,
+    send_at DATETIME NOT NULL,
+    repeat_interval_minutes INT NULL,
+    repeat_until DATETIME NULL,
+    status ENUM('pending', 'sent', 'cancelled', 'missed') NOT NULL DEFAULT 'pending',
+    sent_count INT NOT NULL DEFAULT 0,
+    last_notification_id INT NULL,
+    created_by CHAR(36) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    KEY idx_scheduled_notifications_status_send_at (status, send_at),
+    CONSTRAINT fk_scheduled_notifications_last_notification FOREIGN KEY (last_notification_id) REFERENCES notifications(id) ON DELETE SET NULL,
+    CONSTRAINT fk_scheduled_notifications_created_by FOREIGN KEY (created_by) REFERENCES users(id),
+    CONSTRAINT chk_scheduled_notifications_type CHECK (type IN ('general', 'match_my_class', 'finals', 'all_matches'))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,15 @@
+-- ロール以外の通知の宛先。クラス・チーム・競技のチームメンバー・試合にチェックインした生徒・個別のユーザーを指定できる。
+-- target_id はクラス・チーム・競技・試合の ID、user の場合は user_id を使う。
+CREATE TABLE notification_audiences (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    notification_id INT NOT NULL,
+    target_type ENUM('class', 'team', 'sport', 'match', 'user') NOT NULL,
+    target_id INT NULL,
+    user_id CHAR(36) NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_notification_audiences_notification (notification_id),
+    KEY idx_notification_audiences_target (target_type, target_id),
+    KEY idx_notification_audiences_user (user_id),
+    CONSTRAINT fk_notification_audiences_notification FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE CASCADE,
+    CONSTRAINT fk_notification_audiences_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,10 @@
+-- 通知の既読状態。ユーザーが通知を開いた（既読にした）ときに1行追加する。
+CREATE TABLE notification_reads (
+    notification_id INT NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    read_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (notification_id, user_id),
+    KEY idx_notification_reads_user (user_id),
+    CONSTRAINT fk_notification_reads_notification FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE CASCADE,
+    CONSTRAINT fk_notification_reads_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
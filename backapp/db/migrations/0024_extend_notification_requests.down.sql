@@ -0,0 +1,7 @@
+ALTER TABLE notification_requests
+    DROP FOREIGN KEY fk_notification_requests_notification,
+    DROP FOREIGN KEY fk_notification_requests_scheduled_notification,
+    DROP KEY idx_notification_requests_status_created,
+    DROP COLUMN escalated_at,
+    DROP COLUMN scheduled_notification_id,
+    DROP COLUMN notification_id;
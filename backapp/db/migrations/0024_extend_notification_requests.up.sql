@@ -0,0 +1,8 @@
+-- 通知申請の承認で作成した通知・予約通知へのリンクと、承認待ちが長引いた申請の催促日時
+ALTER TABLE notification_requests
+    ADD COLUMN notification_id INT NULL AFTER resolved_at,
+    ADD COLUMN scheduled_notification_id INT NULL AFTER notification_id,
+    ADD COLUMN escalated_at TIMESTAMP NULL AFTER scheduled_notification_id,
+    ADD KEY idx_notification_requests_status_created (status, created_at),
+    ADD CONSTRAINT fk_notification_requests_notification FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE SET NULL,
+    ADD CONSTRAINT fk_notification_requests_scheduled_notification FOREIGN KEY (scheduled_notification_id) REFERENCES scheduled_notifications(id) ON DELETE SET NULL;
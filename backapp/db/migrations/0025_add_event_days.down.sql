@@ -0,0 +1,16 @@
+ALTER TABLE check_ins
+    DROP FOREIGN KEY fk_check_ins_event_day,
+    DROP INDEX idx_check_ins_event_day,
+    DROP COLUMN event_day_id;
+
+ALTER TABLE noon_game_sessions
+    DROP FOREIGN KEY fk_noon_game_sessions_event_day,
+    DROP INDEX idx_noon_game_sessions_event_day,
+    DROP COLUMN event_day_id;
+
+ALTER TABLE matches
+    DROP FOREIGN KEY fk_matches_event_day,
+    DROP INDEX idx_matches_event_day,
+    DROP COLUMN event_day_id;
+
+DROP TABLE IF EXISTS event_days;
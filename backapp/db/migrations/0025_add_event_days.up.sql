@@ -0,0 +1,27 @@
+-- 複数日にわたる大会の開催日。試合・昼競技・出席チェックインを開催日に結び付け、雨天時モードも日ごとに切り替える。
+CREATE TABLE event_days (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    event_id INT NOT NULL,
+    day_number INT NOT NULL,
+    date DATE NOT NULL,
+    is_rainy_mode BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_event_days_event_date (event_id, date),
+    CONSTRAINT fk_event_days_event FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE matches
+    ADD COLUMN event_day_id INT NULL,
+    ADD INDEX idx_matches_event_day (event_day_id),
+    ADD CONSTRAINT fk_matches_event_day FOREIGN KEY (event_day_id) REFERENCES event_days(id) ON DELETE SET NULL;
+
+ALTER TABLE noon_game_sessions
+    ADD COLUMN event_day_id INT NULL AFTER event_id,
+    ADD INDEX idx_noon_game_sessions_event_day (event_day_id),
+    ADD CONSTRAINT fk_noon_game_sessions_event_day FOREIGN KEY (event_day_id) REFERENCES event_days(id) ON DELETE SET NULL;
+
+ALTER TABLE check_ins
+    ADD COLUMN event_day_id INT NULL AFTER event_id,
+    ADD INDEX idx_check_ins_event_day (event_day_id),
+    ADD CONSTRAINT fk_check_ins_event_day FOREIGN KEY (event_day_id) REFERENCES event_days(id) ON DELETE SET NULL;
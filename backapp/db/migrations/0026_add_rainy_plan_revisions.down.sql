@@ -0,0 +1,4 @@
+DELETE FROM match_revisions WHERE operation = 'rainy_plan';
+
+ALTER TABLE match_revisions
+    MODIFY COLUMN operation ENUM('initial', 'match_result', 'match_correction', 'match_restore', 'start_time', 'schedule', 'league_knockout') NOT NULL COMMENT '試合を変更した操作';
@@ -0,0 +1,3 @@
+-- 雨天時計画の適用で中止にした試合・組み直した対戦表を試合の変更履歴で区別できるようにする
+ALTER TABLE match_revisions
+    MODIFY COLUMN operation ENUM('initial', 'match_result', 'match_correction', 'match_restore', 'start_time', 'schedule', 'league_knockout', 'rainy_plan') NOT NULL COMMENT '試合を変更した操作';
@@ -0,0 +1,4 @@
+ALTER TABLE check_ins
+    DROP FOREIGN KEY fk_check_ins_checked_in_by,
+    DROP INDEX idx_check_ins_event_user,
+    DROP COLUMN checked_in_by;
@@ -0,0 +1,5 @@
+-- MyID バーコードでの個人チェックイン。読み取った管理者を残し、同じ生徒の重複チェックインを引けるようにする。
+ALTER TABLE check_ins
+    ADD COLUMN checked_in_by CHAR(36) NULL AFTER checked_in_at,
+    ADD INDEX idx_check_ins_event_user (event_id, user_id, purpose),
+    ADD CONSTRAINT fk_check_ins_checked_in_by FOREIGN KEY (checked_in_by) REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,12 @@
+-- オフラインで読み取った試合チェックインの一括送信。端末が発行した冪等キーごとに処理結果を残し、再送されても同じ結果を返す。
+CREATE TABLE round_check_in_scans (
+    idempotency_key VARCHAR(64) NOT NULL PRIMARY KEY,
+    scanned_by CHAR(36) NULL,
+    barcode_data VARCHAR(255) NOT NULL,
+    scanned_at DATETIME NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    http_status INT NOT NULL,
+    body JSON NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_round_check_in_scans_scanned_by FOREIGN KEY (scanned_by) REFERENCES users(id) ON DELETE SET NULL
+);
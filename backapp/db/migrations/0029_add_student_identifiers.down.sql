@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS id_passes;
+
+ALTER TABLE users
+    DROP INDEX uq_users_student_number,
+    DROP COLUMN student_number;
@@ -0,0 +1,17 @@
+-- 学籍番号をメールアドレスから推測せずに持てるようにし、MyID を持たない人には SportEase が QR コードの本人確認用パスを発行する。
+ALTER TABLE users
+    ADD COLUMN student_number VARCHAR(32) NULL AFTER email,
+    ADD UNIQUE KEY uq_users_student_number (student_number);
+
+CREATE TABLE id_passes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id CHAR(36) NOT NULL,
+    token VARCHAR(64) NOT NULL,
+    issued_by CHAR(36) NULL,
+    issued_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP NULL,
+    UNIQUE KEY uq_id_passes_token (token),
+    INDEX idx_id_passes_user (user_id, revoked_at),
+    CONSTRAINT fk_id_passes_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_id_passes_issued_by FOREIGN KEY (issued_by) REFERENCES users(id) ON DELETE SET NULL
+);
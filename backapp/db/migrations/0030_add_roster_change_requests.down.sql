@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS roster_change_requests;
+
+ALTER TABLE events
+DROP COLUMN roster_phase;
@@ -0,0 +1,28 @@
+-- 大会ごとにメンバー登録の受付段階を持たせ、締め切った後の変更は root が承認する変更申請にする。
+ALTER TABLE events
+ADD COLUMN roster_phase VARCHAR(16) NOT NULL DEFAULT 'open'
+COMMENT 'メンバー登録の段階（open: 受付中, locked: 締切後, event_day: 大会当日）'
+AFTER duplicate_registration_threshold;
+
+CREATE TABLE roster_change_requests (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id INT NOT NULL,
+    class_id INT NOT NULL,
+    sport_id INT NOT NULL,
+    user_id CHAR(36) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    reason TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    requested_by CHAR(36) NULL,
+    reviewed_by CHAR(36) NULL,
+    review_note TEXT NULL,
+    reviewed_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_roster_change_requests_event_status (event_id, status),
+    CONSTRAINT fk_roster_change_requests_event_id FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE,
+    CONSTRAINT fk_roster_change_requests_class_id FOREIGN KEY (class_id) REFERENCES classes(id) ON DELETE CASCADE,
+    CONSTRAINT fk_roster_change_requests_sport_id FOREIGN KEY (sport_id) REFERENCES sports(id) ON DELETE CASCADE,
+    CONSTRAINT fk_roster_change_requests_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_roster_change_requests_requested_by FOREIGN KEY (requested_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_roster_change_requests_reviewed_by FOREIGN KEY (reviewed_by) REFERENCES users(id) ON DELETE SET NULL
+);
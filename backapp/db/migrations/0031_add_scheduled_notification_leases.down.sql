@@ -0,0 +1,4 @@
+UPDATE scheduled_notifications SET status = 'pending' WHERE status = 'sending';
+ALTER TABLE scheduled_notifications
+    DROP COLUMN claimed_until,
+    MODIFY status ENUM('pending', 'sent', 'cancelled', 'missed') NOT NULL DEFAULT 'pending';
@@ -0,0 +1,5 @@
+-- 予約通知は送信中として取り出し、通知を作り終えてから次の回に進める。
+-- 送信中に止まったサーバーが取り出した予約は claimed_until を過ぎれば取り出し直す。
+ALTER TABLE scheduled_notifications
+    MODIFY status ENUM('pending', 'sending', 'sent', 'cancelled', 'missed') NOT NULL DEFAULT 'pending',
+    ADD COLUMN claimed_until DATETIME NULL AFTER status;
@@ -0,0 +1,3 @@
+-- 予約通知にもクラス・チーム・競技・試合・個人の宛先を持たせる。宛先の利用者は送信時に求める。
+ALTER TABLE scheduled_notifications
+    ADD COLUMN targets JSON NULL AFTER target_roles;
@@ -0,0 +1,2 @@
+ALTER TABLE scheduled_notifications
+    DROP COLUMN channel;
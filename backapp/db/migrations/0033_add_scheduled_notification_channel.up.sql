@@ -0,0 +1,3 @@
+-- 予約通知にも送信チャネルを持たせ、Push 購読のない利用者へのメール送信を予約できるようにする。
+ALTER TABLE scheduled_notifications
+    ADD COLUMN channel ENUM('push', 'push_email_fallback') NOT NULL DEFAULT 'push' AFTER targets;
@@ -0,0 +1,2 @@
+ALTER TABLE tournaments
+    DROP COLUMN is_league_knockout;
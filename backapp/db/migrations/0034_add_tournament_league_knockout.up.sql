@@ -0,0 +1,8 @@
+-- リーグ戦に続く決勝トーナメントを名前ではなく列で見分ける。
+ALTER TABLE tournaments
+    ADD COLUMN is_league_knockout BOOLEAN NOT NULL DEFAULT FALSE AFTER sport_id;
+
+-- 既存の決勝トーナメントは生成時の名前で見分けて移す
+UPDATE tournaments
+SET is_league_knockout = TRUE
+WHERE name LIKE '%決勝トーナメント%';
@@ -0,0 +1,4 @@
+ALTER TABLE check_ins
+    DROP INDEX uq_check_ins_event_user_day,
+    ADD INDEX idx_check_ins_event_user (event_id, user_id, purpose),
+    DROP COLUMN event_day_key;
@@ -0,0 +1,14 @@
+-- 個人チェックインの重複を一意キーで防ぐ。event_day_id は NULL になりうるため、0 に置き換えた生成列をキーに含める。
+DELETE newer
+FROM check_ins newer
+JOIN check_ins older
+  ON older.event_id = newer.event_id
+ AND older.user_id = newer.user_id
+ AND older.purpose = newer.purpose
+ AND older.event_day_id <=> newer.event_day_id
+ AND older.id < newer.id;
+
+ALTER TABLE check_ins
+    ADD COLUMN event_day_key INT GENERATED ALWAYS AS (COALESCE(event_day_id, 0)) VIRTUAL NOT NULL AFTER event_day_id,
+    DROP INDEX idx_check_ins_event_user,
+    ADD UNIQUE KEY uq_check_ins_event_user_day (event_id, user_id, purpose, event_day_key);
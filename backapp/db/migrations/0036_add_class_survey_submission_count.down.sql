@@ -0,0 +1,2 @@
+ALTER TABLE classes
+    DROP COLUMN survey_submission_count;
@@ -0,0 +1,3 @@
+-- 配点ルールを変えたときにアンケート得点を計算し直せるよう、取り込んだアンケートの提出数をクラスごとに残す。
+ALTER TABLE classes
+    ADD COLUMN survey_submission_count INT NULL AFTER attend_count;
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -21,6 +22,14 @@ type Config struct {
 	WebPushAllowedHosts                                                  []string
 	TrustedProxyCIDRs                                                    []string
 	RedisAddr                                                            string
+	SMTPHost, SMTPPort, SMTPUsername, SMTPPassword, SMTPFrom             string
+	SMTPBatchSize, SMTPRatePerMinute                                     int
+	RequestSLAMinutes                                                    int      // 通知申請を root に催促するまでの分数
+	StudentIDFormats                                                     []string // 読み取るバーコード・QRコードの形式（試す順）
+	MyIDBarcodePrefixes                                                  []string // MyID バーコードの学籍番号の前に付く文字列
+	StudentNumberLength                                                  int      // 学籍番号の桁数
+	QRPassSecret                                                         string   // 署名付きパスの HMAC 鍵
+	QRPassRotationSeconds                                                int      // 署名付きパスを切り替える秒数
 }
 
 func Load() (*Config, error) {
@@ -32,31 +41,53 @@ func Load() (*Config, error) {
 	}
 
 	cfg := &Config{
-		DBHost:              os.Getenv("DB_HOST"),
-		DBPort:              os.Getenv("DB_PORT"),
-		DBUser:              os.Getenv("DB_USER"),
-		DBPassword:          os.Getenv("DB_PASSWORD"),
-		DBName:              os.Getenv("DB_DATABASE"),
-		GoogleClientID:      os.Getenv("GOOGLE_CLIENT_ID"),
-		GoogleClientSecret:  os.Getenv("GOOGLE_CLIENT_SECRET"),
-		GoogleRedirectURL:   os.Getenv("GOOGLE_REDIRECT_URL"),
-		FrontendURL:         os.Getenv("FRONTEND_URL"),
-		AppEnv:              os.Getenv("APP_ENV"),
-		InitRootUser:        os.Getenv("INIT_ROOT_USER"),
-		InitEventName:       os.Getenv("INIT_EVENT_NAME"),
-		InitEventYear:       os.Getenv("INIT_EVENT_YEAR"),
-		InitEventSeason:     os.Getenv("INIT_EVENT_SEASON"),
-		InitEventStartDate:  os.Getenv("INIT_EVENT_START_DATE"),
-		InitEventEndDate:    os.Getenv("INIT_EVENT_END_DATE"),
-		WebPushPublicKey:    os.Getenv("WEBPUSH_PUBLIC_KEY"),
-		WebPushPrivateKey:   os.Getenv("WEBPUSH_PRIVATE_KEY"),
-		WebPushAllowedHosts: splitCommaSeparated(os.Getenv("WEBPUSH_ALLOWED_HOSTS")),
-		TrustedProxyCIDRs:   trustedProxyCIDRs,
-		RedisAddr:           os.Getenv("REDIS_ADDR"),
+		DBHost:                os.Getenv("DB_HOST"),
+		DBPort:                os.Getenv("DB_PORT"),
+		DBUser:                os.Getenv("DB_USER"),
+		DBPassword:            os.Getenv("DB_PASSWORD"),
+		DBName:                os.Getenv("DB_DATABASE"),
+		GoogleClientID:        os.Getenv("GOOGLE_CLIENT_ID"),
+		GoogleClientSecret:    os.Getenv("GOOGLE_CLIENT_SECRET"),
+		GoogleRedirectURL:     os.Getenv("GOOGLE_REDIRECT_URL"),
+		FrontendURL:           os.Getenv("FRONTEND_URL"),
+		AppEnv:                os.Getenv("APP_ENV"),
+		InitRootUser:          os.Getenv("INIT_ROOT_USER"),
+		InitEventName:         os.Getenv("INIT_EVENT_NAME"),
+		InitEventYear:         os.Getenv("INIT_EVENT_YEAR"),
+		InitEventSeason:       os.Getenv("INIT_EVENT_SEASON"),
+		InitEventStartDate:    os.Getenv("INIT_EVENT_START_DATE"),
+		InitEventEndDate:      os.Getenv("INIT_EVENT_END_DATE"),
+		WebPushPublicKey:      os.Getenv("WEBPUSH_PUBLIC_KEY"),
+		WebPushPrivateKey:     os.Getenv("WEBPUSH_PRIVATE_KEY"),
+		WebPushAllowedHosts:   splitCommaSeparated(os.Getenv("WEBPUSH_ALLOWED_HOSTS")),
+		TrustedProxyCIDRs:     trustedProxyCIDRs,
+		RedisAddr:             os.Getenv("REDIS_ADDR"),
+		SMTPHost:              os.Getenv("SMTP_HOST"),
+		SMTPPort:              os.Getenv("SMTP_PORT"),
+		SMTPUsername:          os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:          os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:              os.Getenv("SMTP_FROM"),
+		SMTPBatchSize:         atoiOrZero(os.Getenv("SMTP_BATCH_SIZE")),
+		SMTPRatePerMinute:     atoiOrZero(os.Getenv("SMTP_RATE_PER_MINUTE")),
+		RequestSLAMinutes:     atoiOrZero(os.Getenv("NOTIFICATION_REQUEST_SLA_MINUTES")),
+		StudentIDFormats:      splitCommaSeparated(os.Getenv("STUDENT_ID_FORMATS")),
+		MyIDBarcodePrefixes:   splitCommaSeparated(os.Getenv("MYID_BARCODE_PREFIXES")),
+		StudentNumberLength:   atoiOrZero(os.Getenv("STUDENT_NUMBER_LENGTH")),
+		QRPassSecret:          os.Getenv("QR_PASS_SECRET"),
+		QRPassRotationSeconds: atoiOrZero(os.Getenv("QR_PASS_ROTATION_SECONDS")),
 	}
 	return cfg, nil
 }
 
+// atoiOrZero は空や数値でない値を0（既定値を使う）として扱う
+func atoiOrZero(value string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
 func splitCommaSeparated(value string) []string {
 	var result []string
 	for _, item := range strings.Split(value, ",") {
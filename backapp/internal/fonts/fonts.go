@@ -0,0 +1,11 @@
+// Package fonts はサーバーに埋め込むフォント。PDF 出力で日本語を表示するために使う。
+package fonts
+
+import (
+	_ "embed"
+)
+
+// MPlus1pRegular は M+ 1p Regular（ライセンスは LICENSE_mplus.txt）
+//
+//go:embed mplus-1p-regular.ttf
+var MPlus1pRegular []byte
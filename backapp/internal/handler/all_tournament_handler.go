@@ -3,6 +3,7 @@ package handler
 import (
 	"backapp/internal/models"
 	"backapp/internal/repository"
+	"backapp/internal/scoreboard"
 	"backapp/internal/websocket"
 	"crypto/rand"
 	"fmt"
@@ -17,12 +18,15 @@ import (
 )
 
 type TournamentHandler struct {
-	tournRepo  repository.TournamentRepository
-	sportRepo  repository.SportRepository
-	teamRepo   repository.TeamRepository
-	classRepo  repository.ClassRepository
-	eventRepo  repository.EventRepository
-	hubManager *websocket.HubManager
+	tournRepo    repository.TournamentRepository
+	sportRepo    repository.SportRepository
+	teamRepo     repository.TeamRepository
+	classRepo    repository.ClassRepository
+	eventRepo    repository.EventRepository
+	hubManager   *websocket.HubManager
+	scoreboard   *scoreboard.Feed
+	scheduleRepo repository.ScheduleRepository
+	notifier     *MatchNotifier
 }
 
 func NewTournamentHandler(tournRepo repository.TournamentRepository, sportRepo repository.SportRepository, teamRepo repository.TeamRepository, classRepo repository.ClassRepository, eventRepo repository.EventRepository, hubManager *websocket.HubManager) *TournamentHandler {
@@ -36,6 +40,24 @@ func NewTournamentHandler(tournRepo repository.TournamentRepository, sportRepo r
 	}
 }
 
+// WithScoreboard は試合結果を会場のスコアボードにも配信する
+func (h *TournamentHandler) WithScoreboard(feed *scoreboard.Feed) *TournamentHandler {
+	h.scoreboard = feed
+	return h
+}
+
+// WithMatchNotifier は試合結果の入力時にクラスと決勝の通知を受け取る利用者へ Push 通知を送る
+func (h *TournamentHandler) WithMatchNotifier(notifier *MatchNotifier) *TournamentHandler {
+	h.notifier = notifier
+	return h
+}
+
+// WithScheduleCheck は開始時刻の変更時に、クラスや生徒が同じ時間に2試合に出ることにならないかを調べる
+func (h *TournamentHandler) WithScheduleCheck(scheduleRepo repository.ScheduleRepository) *TournamentHandler {
+	h.scheduleRepo = scheduleRepo
+	return h
+}
+
 func (h *TournamentHandler) GetTournamentsByEventHandler(c *gin.Context) {
 	eventIDStr := c.Param("event_id")
 	if eventIDStr == "" {
@@ -105,7 +127,6 @@ func (h *TournamentHandler) generateTournamentsPreviewForSport(eventID int, even
 	if eventSport.Location == "noon_game" {
 		return generatedTournaments
 	}
-	roundBusyClasses := make(map[int]map[int]bool)
 	sport, err := h.sportRepo.GetSportByID(eventSport.SportID)
 	if err != nil {
 		return generatedTournaments
@@ -116,6 +137,24 @@ func (h *TournamentHandler) generateTournamentsPreviewForSport(eventID int, even
 		return generatedTournaments
 	}
 
+	return generateSportTournaments(eventID, eventSport, sport, teams)
+}
+
+// generateSportTournaments は競技の形式と会場に合わせて、teams の対戦表（リーグ戦・敗者戦を含む）を生成する
+func generateSportTournaments(eventID int, eventSport *models.EventSport, sport *models.Sport, teams []*models.Team) []models.GeneratedTournament {
+	generatedTournaments := make([]models.GeneratedTournament, 0, 3)
+	roundBusyClasses := make(map[int]map[int]bool)
+
+	// リーグ形式の競技はグループごとの総当たり戦（と任意の決勝トーナメント）を生成する
+	if eventSport.Format == models.SportFormatLeague {
+		leagueTournaments, err := generateLeagueTournaments(eventID, sport, teams, roundBusyClasses, eventSport.LeagueGroupCount, eventSport.LeagueAdvanceCount)
+		if err != nil {
+			log.Printf("[GenerateLeague] skipped sport %s: %v", sport.Name, err)
+			return generatedTournaments
+		}
+		return append(generatedTournaments, leagueTournaments...)
+	}
+
 	tournamentData, shuffledTeams, err := generateTournamentStructure(teams, roundBusyClasses)
 	if err != nil {
 		return generatedTournaments
@@ -148,8 +187,16 @@ func (h *TournamentHandler) generateTournamentsPreviewForSport(eventID int, even
 			}
 		}
 
-		// 一回戦は8試合（16チーム）である必要がある
-		if len(firstRoundMatches) == 8 {
+		// 一回戦は8試合（16チーム）である必要がある。
+		// 不戦勝を含む場合は敗者が揃わないため敗者戦は生成しない
+		hasBye := false
+		for _, match := range firstRoundMatches {
+			if match.IsBye {
+				hasBye = true
+				break
+			}
+		}
+		if len(firstRoundMatches) == 8 && !hasBye {
 			// 敗者戦Aブロックトーナメント
 			loserBracketA := generateLoserBracketTournament("A")
 			generatedTournaments = append(generatedTournaments, models.GeneratedTournament{
@@ -296,7 +343,8 @@ func (h *TournamentHandler) GenerateAllTournamentsHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Tournaments generated successfully for all eligible sports."})
 }
 
-func generateTournamentStructure(teams []*models.Team, roundBusyClasses map[int]map[int]bool) (*models.TournamentData, []*models.Team, error) {
+// filterAvailableTeams は一回戦の時間帯に別の対戦表で試合があるクラスのチームを除く
+func filterAvailableTeams(teams []*models.Team, roundBusyClasses map[int]map[int]bool) []*models.Team {
 	availableTeams := make([]*models.Team, 0)
 	if roundBusyClasses[0] == nil {
 		roundBusyClasses[0] = make(map[int]bool)
@@ -307,14 +355,12 @@ func generateTournamentStructure(teams []*models.Team, roundBusyClasses map[int]
 			availableTeams = append(availableTeams, team)
 		}
 	}
+	return availableTeams
+}
 
-	if len(availableTeams) <= 1 || (len(availableTeams)&(len(availableTeams)-1)) != 0 {
-		return nil, nil, fmt.Errorf("invalid number of available teams to form a tournament: %d", len(availableTeams))
-	}
-
-	numRounds := int(math.Log2(float64(len(availableTeams))))
-
-	for _, team := range availableTeams {
+// markClassesBusy は teams のクラスを numRounds 回戦ぶん試合がある扱いにする
+func markClassesBusy(teams []*models.Team, numRounds int, roundBusyClasses map[int]map[int]bool) {
+	for _, team := range teams {
 		for i := 0; i < numRounds; i++ {
 			if roundBusyClasses[i] == nil {
 				roundBusyClasses[i] = make(map[int]bool)
@@ -322,17 +368,27 @@ func generateTournamentStructure(teams []*models.Team, roundBusyClasses map[int]
 			roundBusyClasses[i][team.ClassID] = true
 		}
 	}
+}
 
-	shuffledTeams := make([]*models.Team, len(availableTeams))
-	copy(shuffledTeams, availableTeams)
+func generateTournamentStructure(teams []*models.Team, roundBusyClasses map[int]map[int]bool) (*models.TournamentData, []*models.Team, error) {
+	availableTeams := filterAvailableTeams(teams, roundBusyClasses)
+	if len(availableTeams) <= 1 {
+		return nil, nil, fmt.Errorf("invalid number of available teams to form a tournament: %d", len(availableTeams))
+	}
 
-	for i := len(shuffledTeams) - 1; i > 0; i-- {
-		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
-		if err != nil {
-			return nil, nil, fmt.Errorf("generate secure tournament shuffle index: %w", err)
-		}
-		shuffleIndex := int(j.Int64())
-		shuffledTeams[i], shuffledTeams[shuffleIndex] = shuffledTeams[shuffleIndex], shuffledTeams[i]
+	// チーム数が2の累乗でない場合は、次の2の累乗の枠に不戦勝(BYE)を補う
+	bracketSize := 1
+	for bracketSize < len(availableTeams) {
+		bracketSize <<= 1
+	}
+	numByes := bracketSize - len(availableTeams)
+	numRounds := int(math.Log2(float64(bracketSize)))
+
+	markClassesBusy(availableTeams, numRounds, roundBusyClasses)
+
+	shuffledTeams, err := shuffleTeams(availableTeams)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	contestants := make(map[string]models.Contestant)
@@ -344,40 +400,38 @@ func generateTournamentStructure(teams []*models.Team, roundBusyClasses map[int]
 	}
 
 	rounds := make([]models.Round, numRounds)
-
-	var roundNames []string
-	switch numRounds {
-	case 1:
-		roundNames = []string{"決勝"}
-	case 2:
-		roundNames = []string{"準決勝", "決勝"}
-	case 3:
-		roundNames = []string{"一回戦", "準決勝", "決勝"}
-	case 4:
-		roundNames = []string{"一回戦", "二回戦", "準決勝", "決勝"}
-	default:
-		roundNames = make([]string, numRounds)
-		for i := 0; i < numRounds-2; i++ {
-			roundNames[i] = strconv.Itoa(i+1) + "回戦"
-		}
-		if numRounds > 1 {
-			roundNames[numRounds-2] = "準決勝"
-		}
-		if numRounds > 0 {
-			roundNames[numRounds-1] = "決勝"
-		}
-	}
-
+	roundNames := knockoutRoundNames(numRounds)
 	for i := 0; i < numRounds; i++ {
 		rounds[i] = models.Round{Name: roundNames[i]}
 	}
 
 	matches := []models.Match{}
 
-	matchOrder := 0
-	for i := 0; i < len(shuffledTeams); i += 2 {
-		contestant1ID := "c" + strconv.Itoa(i)
-		contestant2ID := "c" + strconv.Itoa(i+1)
+	firstRoundMatchCount := bracketSize / 2
+	byeMatchOrders := make(map[int]bool, numByes)
+	for _, order := range spreadByeMatchOrders(firstRoundMatchCount, numByes) {
+		byeMatchOrders[order] = true
+	}
+
+	contestantIndex := 0
+	for matchOrder := 0; matchOrder < firstRoundMatchCount; matchOrder++ {
+		contestant1ID := "c" + strconv.Itoa(contestantIndex)
+		contestantIndex++
+		if byeMatchOrders[matchOrder] {
+			// 不戦勝の試合は片側のみ。SaveTournament で勝ち上がり先に自動配置される
+			matches = append(matches, models.Match{
+				RoundIndex: 0,
+				Order:      matchOrder,
+				IsBye:      true,
+				Sides: []models.Side{
+					{ContestantID: contestant1ID},
+					{},
+				},
+			})
+			continue
+		}
+		contestant2ID := "c" + strconv.Itoa(contestantIndex)
+		contestantIndex++
 		matches = append(matches, models.Match{
 			RoundIndex: 0,
 			Order:      matchOrder,
@@ -386,12 +440,11 @@ func generateTournamentStructure(teams []*models.Team, roundBusyClasses map[int]
 				{ContestantID: contestant2ID},
 			},
 		})
-		matchOrder++
 	}
 
-	numMatchesInRound := len(shuffledTeams) / 2
+	numMatchesInRound := firstRoundMatchCount
 	for roundIndex := 1; roundIndex < numRounds; roundIndex++ {
-		matchOrder = 0
+		matchOrder := 0
 		numMatchesInRound /= 2
 		for i := 0; i < numMatchesInRound; i++ {
 			matches = append(matches, models.Match{
@@ -403,7 +456,8 @@ func generateTournamentStructure(teams []*models.Team, roundBusyClasses map[int]
 		}
 	}
 
-	if numRounds >= 2 {
+	// 準決勝に不戦勝がある場合（3チーム）は敗者が1チームしか出ないため3位決定戦を作らない
+	if numRounds > 2 || (numRounds == 2 && numByes == 0) {
 		matches = append(matches, models.Match{
 			RoundIndex:    numRounds - 1,
 			Order:         1,
@@ -421,6 +475,73 @@ func generateTournamentStructure(teams []*models.Team, roundBusyClasses map[int]
 	return &tournamentData, shuffledTeams, nil
 }
 
+// shuffleTeams はチームの並びを暗号論的乱数でシャッフルしたコピーを返す。
+func shuffleTeams(teams []*models.Team) ([]*models.Team, error) {
+	shuffledTeams := make([]*models.Team, len(teams))
+	copy(shuffledTeams, teams)
+
+	for i := len(shuffledTeams) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, fmt.Errorf("generate secure tournament shuffle index: %w", err)
+		}
+		shuffleIndex := int(j.Int64())
+		shuffledTeams[i], shuffledTeams[shuffleIndex] = shuffledTeams[shuffleIndex], shuffledTeams[i]
+	}
+	return shuffledTeams, nil
+}
+
+// knockoutRoundNames はラウンド数に応じた「一回戦」「準決勝」「決勝」などの名前を返す。
+func knockoutRoundNames(numRounds int) []string {
+	switch numRounds {
+	case 1:
+		return []string{"決勝"}
+	case 2:
+		return []string{"準決勝", "決勝"}
+	case 3:
+		return []string{"一回戦", "準決勝", "決勝"}
+	case 4:
+		return []string{"一回戦", "二回戦", "準決勝", "決勝"}
+	default:
+		roundNames := make([]string, numRounds)
+		for i := 0; i < numRounds-2; i++ {
+			roundNames[i] = strconv.Itoa(i+1) + "回戦"
+		}
+		if numRounds > 1 {
+			roundNames[numRounds-2] = "準決勝"
+		}
+		if numRounds > 0 {
+			roundNames[numRounds-1] = "決勝"
+		}
+		return roundNames
+	}
+}
+
+// spreadByeMatchOrders は不戦勝を割り当てる一回戦の試合番号を返す。
+// ビット反転順に選ぶことで、不戦勝がブラケットの上下に偏らないようにする。
+func spreadByeMatchOrders(matchCount int, numByes int) []int {
+	if numByes <= 0 || matchCount <= 0 {
+		return nil
+	}
+
+	bits := 0
+	for (1 << bits) < matchCount {
+		bits++
+	}
+
+	orders := make([]int, 0, numByes)
+	for i := 0; i < matchCount && len(orders) < numByes; i++ {
+		reversed := 0
+		for b := 0; b < bits; b++ {
+			if i&(1<<b) != 0 {
+				reversed |= 1 << (bits - 1 - b)
+			}
+		}
+		orders = append(orders, reversed)
+	}
+	return orders
+}
+
 // generateLoserBracketTournament は敗者戦トーナメントを生成します
 // block: "A" または "B"
 // Aブロック: 本戦1-4試合の敗者
@@ -1,18 +1,27 @@
 package handler
 
 import (
+	"backapp/internal/identity"
 	"backapp/internal/models"
 	"backapp/internal/repository"
+	"backapp/internal/scoreboard"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AttendanceHandler struct {
-	classRepo repository.ClassRepository
-	eventRepo repository.EventRepository
+	classRepo   repository.ClassRepository
+	eventRepo   repository.EventRepository
+	checkInRepo repository.CheckInRepository
+	userRepo    repository.UserRepository
+	scoreboard  *scoreboard.Feed
+
+	idParser     *identity.Parser
+	identityRepo repository.IdentityRepository
 }
 
 func NewAttendanceHandler(classRepo repository.ClassRepository, eventRepo repository.EventRepository) *AttendanceHandler {
@@ -22,6 +31,26 @@ func NewAttendanceHandler(classRepo repository.ClassRepository, eventRepo reposi
 	}
 }
 
+// WithScoreboard は出席点の変更を会場のスコアボードにも配信する
+func (h *AttendanceHandler) WithScoreboard(feed *scoreboard.Feed) *AttendanceHandler {
+	h.scoreboard = feed
+	return h
+}
+
+// WithCheckIns は MyID バーコードでの個人チェックインを有効にする
+func (h *AttendanceHandler) WithCheckIns(checkInRepo repository.CheckInRepository, userRepo repository.UserRepository) *AttendanceHandler {
+	h.checkInRepo = checkInRepo
+	h.userRepo = userRepo
+	return h
+}
+
+// WithIdentity は読み取れるバーコード・QRコードの形式を設定し、登録済みの学籍番号と本人確認用 QR コードで本人を探せるようにする
+func (h *AttendanceHandler) WithIdentity(idParser *identity.Parser, identityRepo repository.IdentityRepository) *AttendanceHandler {
+	h.idParser = idParser
+	h.identityRepo = identityRepo
+	return h
+}
+
 func getAttendanceScope(user *models.User) (isRoot bool, isAdmin bool) {
 	for _, role := range user.Roles {
 		switch role.Name {
@@ -135,11 +164,186 @@ func (h *AttendanceHandler) RegisterAttendanceHandler(c *gin.Context) {
 		return
 	}
 
-	points, err := h.classRepo.UpdateAttendance(req.ClassID, activeEventID, req.AttendanceCount)
+	points, err := h.classRepo.UpdateAttendance(req.ClassID, activeEventID, req.AttendanceCount, user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to register attendance and calculate points: %v", err)})
 		return
 	}
+	if h.scoreboard != nil {
+		h.scoreboard.ClassScoresChanged(activeEventID)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Successfully registered attendance for class %s. Points awarded: %d", class.Name, points)})
 }
+
+// CheckInHandler は MyID バーコードを読み取って生徒の出席を記録し、
+// クラスのチェックイン人数から出席人数と出席点を更新する。同じ生徒を読み取り直しても記録は増えない。
+func (h *AttendanceHandler) CheckInHandler(c *gin.Context) {
+	var req models.AttendanceCheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.Purpose == "" {
+		req.Purpose = models.CheckInPurposeEventParticipation
+	}
+	if !models.IsValidCheckInPurpose(req.Purpose) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check-in purpose"})
+		return
+	}
+
+	activeEventID, user, ok := h.attendanceOperator(c)
+	if !ok {
+		return
+	}
+
+	trimmedBarcode := strings.TrimSpace(req.BarcodeData)
+	student, identifier, err := resolveStudent(h.idParser, h.identityRepo, h.userRepo, trimmedBarcode, req.UserID, activeEventID)
+	if message, ok := scanErrorMessage(err); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find user by student number"})
+		return
+	}
+	if student == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "該当する学生が見つかりません"})
+		return
+	}
+	if student.ClassID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "この学生はクラスに所属していません"})
+		return
+	}
+
+	class, err := h.classRepo.GetClassByID(*student.ClassID)
+	if err != nil || class == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get class info"})
+		return
+	}
+	if class.EventID == nil || *class.EventID != activeEventID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Class does not belong to the active event"})
+		return
+	}
+
+	checkIn, created, err := h.checkInRepo.CheckIn(activeEventID, student.ID, req.Purpose, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record check-in"})
+		return
+	}
+
+	attendCount, err := h.checkInRepo.CountClassCheckIns(activeEventID, class.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count check-ins"})
+		return
+	}
+	// 在籍人数の登録が古くても、出席人数が在籍人数を超えないようにする
+	if attendCount > class.StudentCount {
+		attendCount = class.StudentCount
+	}
+
+	points, err := h.classRepo.UpdateAttendance(class.ID, activeEventID, attendCount, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to register attendance and calculate points: %v", err)})
+		return
+	}
+	if h.scoreboard != nil {
+		h.scoreboard.ClassScoresChanged(activeEventID)
+	}
+
+	displayName := ""
+	if student.DisplayName != nil {
+		displayName = *student.DisplayName
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"checked_in":         true,
+		"already_checked_in": !created,
+		"check_in":           checkIn,
+		"user_id":            student.ID,
+		"display_name":       displayName,
+		"student_number":     identifiedStudentNumber(identifier),
+		"identified_by":      identifier.Format,
+		"class_id":           class.ID,
+		"class_name":         class.Name,
+		"attend_count":       attendCount,
+		"student_count":      class.StudentCount,
+		"points":             points,
+	})
+}
+
+// GetUncheckedMembersHandler はクラスでまだチェックインしていない生徒の一覧を返す
+func (h *AttendanceHandler) GetUncheckedMembersHandler(c *gin.Context) {
+	classID, err := strconv.Atoi(c.Param("classID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID format"})
+		return
+	}
+
+	activeEventID, _, ok := h.attendanceOperator(c)
+	if !ok {
+		return
+	}
+
+	class, err := h.classRepo.GetClassByID(classID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get class info"})
+		return
+	}
+	if class == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+		return
+	}
+	if class.EventID == nil || *class.EventID != activeEventID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Class does not belong to the active event"})
+		return
+	}
+
+	checkedInCount, err := h.checkInRepo.CountClassCheckIns(activeEventID, classID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count check-ins"})
+		return
+	}
+	members, err := h.checkInRepo.GetUncheckedClassMembers(activeEventID, classID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get unchecked members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"class_id":          class.ID,
+		"class_name":        class.Name,
+		"student_count":     class.StudentCount,
+		"checked_in_count":  checkedInCount,
+		"unchecked_members": members,
+		"unchecked_count":   len(members),
+	})
+}
+
+// attendanceOperator は開催中の大会と、出席を扱える root・admin の利用者を返す。
+// 扱えないときはレスポンスを書いて false を返す。
+func (h *AttendanceHandler) attendanceOperator(c *gin.Context) (int, *models.User, bool) {
+	activeEventID, err := h.eventRepo.GetActiveEvent()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get active event"})
+		return 0, nil, false
+	}
+	if activeEventID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active event found"})
+		return 0, nil, false
+	}
+
+	userCtx, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return 0, nil, false
+	}
+	user := userCtx.(*models.User)
+
+	isRoot, isAdmin := getAttendanceScope(user)
+	if !isRoot && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not authorized to access this resource"})
+		return 0, nil, false
+	}
+	return activeEventID, user, true
+}
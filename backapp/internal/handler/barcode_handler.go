@@ -1,13 +1,16 @@
 package handler
 
 import (
+	"backapp/internal/identity"
 	"backapp/internal/models"
 	"backapp/internal/repository"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,10 +23,15 @@ type BarcodeHandler struct {
 	eventRepo repository.EventRepository
 	classRepo repository.ClassRepository
 	tournRepo repository.TournamentRepository
+	scanRepo  repository.RoundCheckInScanRepository
+
+	idParser     *identity.Parser
+	identityRepo repository.IdentityRepository
 }
 
-const myIDBarcodePrefix = "H10"
-const studentNumberLength = 7
+// maxRoundCheckInBatchSize limits how many queued scans a device can flush in one request.
+const maxRoundCheckInBatchSize = 200
+const maxIdempotencyKeyLength = 64
 
 // NewBarcodeHandler creates a new instance of BarcodeHandler.
 func NewBarcodeHandler(teamRepo repository.TeamRepository, sportRepo repository.SportRepository, userRepo repository.UserRepository, eventRepo repository.EventRepository, classRepo repository.ClassRepository, tournRepo repository.TournamentRepository) *BarcodeHandler {
@@ -34,9 +42,23 @@ func NewBarcodeHandler(teamRepo repository.TeamRepository, sportRepo repository.
 		eventRepo: eventRepo,
 		classRepo: classRepo,
 		tournRepo: tournRepo,
+		idParser:  identity.DefaultParser(),
 	}
 }
 
+// WithIdentity sets the barcode/QR formats to accept and enables lookup by registered student numbers and ID passes.
+func (h *BarcodeHandler) WithIdentity(idParser *identity.Parser, identityRepo repository.IdentityRepository) *BarcodeHandler {
+	h.idParser = idParser
+	h.identityRepo = identityRepo
+	return h
+}
+
+// WithRoundCheckInScans enables batch sync of scans taken offline.
+func (h *BarcodeHandler) WithRoundCheckInScans(scanRepo repository.RoundCheckInScanRepository) *BarcodeHandler {
+	h.scanRepo = scanRepo
+	return h
+}
+
 // GetUserTeamsHandler returns all teams that the current user is a member of.
 func (h *BarcodeHandler) GetUserTeamsHandler(c *gin.Context) {
 	userCtx, exists := c.Get("user")
@@ -64,100 +86,93 @@ func (h *BarcodeHandler) CheckInRoundHandler(c *gin.Context) {
 		return
 	}
 
+	status, response := h.checkInRound(req, nil)
+	c.JSON(status, response)
+}
+
+// checkInRound validates a single scan and records the round check-in.
+// checkedInAt is the time the scan was taken on the device; nil records the current time.
+func (h *BarcodeHandler) checkInRound(req models.BarcodeCheckInRequest, checkedInAt *time.Time) (int, gin.H) {
 	if req.EventID == 0 || req.SportID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "イベントIDと競技IDが必要です"})
-		return
+		return http.StatusBadRequest, gin.H{"error": "イベントIDと競技IDが必要です"}
 	}
 
 	selectedMatchIDs := normalizeMatchIDs(req.MatchID, req.MatchIDs)
 	if len(selectedMatchIDs) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "試合を選択してください"})
-		return
+		return http.StatusBadRequest, gin.H{"error": "試合を選択してください"}
 	}
 
 	trimmedBarcode := strings.TrimSpace(req.BarcodeData)
-	studentNumber, err := parseMyIDBarcode(trimmedBarcode)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "バーコード形式が不正です"})
-		return
+	user, identifier, err := resolveStudent(h.idParser, h.identityRepo, h.userRepo, trimmedBarcode, req.UserID, req.EventID)
+	if message, ok := scanErrorMessage(err); ok {
+		return http.StatusBadRequest, gin.H{"error": message}
 	}
-
-	user, err := h.findUserByStudentNumber(studentNumber)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find user by student number"})
-		return
+		return http.StatusInternalServerError, gin.H{"error": "Failed to find user by student number"}
 	}
 	if user == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "該当する学生が見つかりません"})
-		return
+		return http.StatusNotFound, gin.H{"error": "該当する学生が見つかりません"}
 	}
 
 	team, err := h.findPreEnteredTeam(user.ID, req.EventID, req.SportID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify user assignment"})
-		return
+		return http.StatusInternalServerError, gin.H{"error": "Failed to verify user assignment"}
 	}
 	if team == nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "このユーザーはこの競技に事前エントリーされていません"})
-		return
+		return http.StatusForbidden, gin.H{"error": "このユーザーはこの競技に事前エントリーされていません"}
 	}
 
 	match, selectionValid, err := h.findMatchingSelectedMatch(selectedMatchIDs, req.EventID, req.SportID, team.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify selected match"})
-		return
+		return http.StatusInternalServerError, gin.H{"error": "Failed to verify selected match"}
 	}
 	if !selectionValid {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "選択した試合がこの競技に存在しません"})
-		return
+		return http.StatusBadRequest, gin.H{"error": "選択した試合がこの競技に存在しません"}
 	}
 	if match == nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "まだあなたのクラスはこの試合にチェックインできません"})
-		return
+		return http.StatusForbidden, gin.H{"error": "まだあなたのクラスはこの試合にチェックインできません"}
 	}
 	round := match.Round + 1
 
 	teamDetails, err := h.teamRepo.GetTeamByClassAndSport(team.ClassID, req.SportID, req.EventID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get team details"})
-		return
+		return http.StatusInternalServerError, gin.H{"error": "Failed to get team details"}
 	}
 	if teamDetails == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Team not found"})
-		return
+		return http.StatusInternalServerError, gin.H{"error": "Team not found"}
 	}
 
 	if err := h.teamRepo.ConfirmTeamMember(teamDetails.ID, user.ID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm team member"})
-		return
+		return http.StatusInternalServerError, gin.H{"error": "Failed to confirm team member"}
 	}
 
-	if err := h.teamRepo.CheckInRound(team.ID, user.ID, req.EventID, req.SportID, match.ID, round); err != nil {
+	if checkedInAt != nil {
+		err = h.teamRepo.CheckInRoundAt(team.ID, user.ID, req.EventID, req.SportID, match.ID, round, *checkedInAt)
+	} else {
+		err = h.teamRepo.CheckInRound(team.ID, user.ID, req.EventID, req.SportID, match.ID, round)
+	}
+	if err != nil {
 		if errors.Is(err, repository.ErrRoundAlreadyCheckedIn) {
-			c.JSON(http.StatusConflict, gin.H{
+			return http.StatusConflict, gin.H{
 				"error":              "チェックイン済みです",
 				"already_checked_in": true,
-			})
-			return
+			}
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check in round"})
-		return
+		return http.StatusInternalServerError, gin.H{"error": "Failed to check in round"}
 	}
 
 	var capacityWarning *string
 	if teamDetails.MinCapacity != nil {
 		confirmedCount, err := h.teamRepo.GetConfirmedTeamMembersCount(teamDetails.ID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check team capacity"})
-			return
+			return http.StatusInternalServerError, gin.H{"error": "Failed to check team capacity"}
 		}
 
 		if confirmedCount < *teamDetails.MinCapacity {
 			className := "不明"
 			class, err := h.classRepo.GetClassByID(team.ClassID)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get class information"})
-				return
+				return http.StatusInternalServerError, gin.H{"error": "Failed to get class information"}
 			}
 			if class != nil {
 				className = class.Name
@@ -187,14 +202,133 @@ func (h *BarcodeHandler) CheckInRoundHandler(c *gin.Context) {
 		"team_id":        team.ID,
 		"user_id":        user.ID,
 		"display_name":   displayName,
-		"student_number": studentNumber,
+		"student_number": identifiedStudentNumber(identifier),
+		"identified_by":  identifier.Format,
 		"barcode_data":   trimmedBarcode,
 	}
 	if capacityWarning != nil {
 		response["capacity_warning"] = *capacityWarning
 	}
 
-	c.JSON(http.StatusOK, response)
+	return http.StatusOK, response
+}
+
+// CheckInRoundBatchHandler records round check-ins for scans that a device queued while offline.
+// Each scan is validated with the same rules as CheckInRoundHandler and gets its own result.
+// Results are kept per idempotency key, so flushing the same scans again returns the first result.
+func (h *BarcodeHandler) CheckInRoundBatchHandler(c *gin.Context) {
+	userCtx, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	user := userCtx.(*models.User)
+
+	var req models.RoundCheckInBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if len(req.Scans) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "読み取りデータがありません"})
+		return
+	}
+	if len(req.Scans) > maxRoundCheckInBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("一度に送信できる読み取りは%d件までです", maxRoundCheckInBatchSize)})
+		return
+	}
+
+	results := make([]*models.RoundCheckInScanResult, 0, len(req.Scans))
+	summary := map[string]int{
+		models.RoundCheckInScanStatusCheckedIn:        0,
+		models.RoundCheckInScanStatusAlreadyCheckedIn: 0,
+		models.RoundCheckInScanStatusRejected:         0,
+		models.RoundCheckInScanStatusFailed:           0,
+	}
+	// 同じリクエストに同じキーが複数あれば最初の結果を使う
+	processed := make(map[string]*models.RoundCheckInScanResult)
+	for i := range req.Scans {
+		result := h.checkInRoundScan(user.ID, &req.Scans[i], processed)
+		summary[result.Status]++
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"summary": summary,
+	})
+}
+
+// checkInRoundScan processes one queued scan, replaying the stored result when its idempotency key was seen before.
+func (h *BarcodeHandler) checkInRoundScan(scannedBy string, scan *models.RoundCheckInScan, processed map[string]*models.RoundCheckInScanResult) *models.RoundCheckInScanResult {
+	key := strings.TrimSpace(scan.IdempotencyKey)
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return &models.RoundCheckInScanResult{
+			IdempotencyKey: scan.IdempotencyKey,
+			Status:         models.RoundCheckInScanStatusRejected,
+			HTTPStatus:     http.StatusBadRequest,
+			Body:           gin.H{"error": fmt.Sprintf("冪等キーは1〜%d文字で指定してください", maxIdempotencyKeyLength)},
+		}
+	}
+	scan.IdempotencyKey = key
+
+	if previous, ok := processed[key]; ok {
+		replayed := *previous
+		replayed.Replayed = true
+		return &replayed
+	}
+	stored, err := h.scanRepo.GetScanResult(key)
+	if err != nil {
+		log.Printf("GetScanResult error: %v", err)
+		return &models.RoundCheckInScanResult{
+			IdempotencyKey: key,
+			Status:         models.RoundCheckInScanStatusFailed,
+			HTTPStatus:     http.StatusInternalServerError,
+			Body:           gin.H{"error": "Failed to get scan result"},
+		}
+	}
+	if stored != nil {
+		processed[key] = stored
+		replayed := *stored
+		replayed.Replayed = true
+		return &replayed
+	}
+
+	now := time.Now()
+	scannedAt := now
+	if scan.ScannedAt != nil && !scan.ScannedAt.After(now) {
+		scannedAt = *scan.ScannedAt
+	}
+
+	status, body := h.checkInRound(scan.BarcodeCheckInRequest, &scannedAt)
+	result := &models.RoundCheckInScanResult{
+		IdempotencyKey: key,
+		Status:         roundCheckInScanStatus(status, body),
+		HTTPStatus:     status,
+		Body:           body,
+	}
+	// サーバー側の失敗は結果を残さず、端末から同じキーで再送してもらう
+	if result.Status == models.RoundCheckInScanStatusFailed {
+		return result
+	}
+	if err := h.scanRepo.SaveScanResult(scannedBy, scan, scannedAt, result); err != nil {
+		log.Printf("SaveScanResult error: %v", err)
+	}
+	processed[key] = result
+	return result
+}
+
+func roundCheckInScanStatus(status int, body gin.H) string {
+	switch {
+	case status == http.StatusOK:
+		return models.RoundCheckInScanStatusCheckedIn
+	case status == http.StatusConflict && body["already_checked_in"] == true:
+		return models.RoundCheckInScanStatusAlreadyCheckedIn
+	case status >= http.StatusInternalServerError:
+		return models.RoundCheckInScanStatusFailed
+	default:
+		return models.RoundCheckInScanStatusRejected
+	}
 }
 
 // GetMatchCheckInsHandler returns students checked in for a selected match.
@@ -332,48 +466,6 @@ func parseMatchIDsQuery(primaryMatchID int, rawMatchIDs string) []int {
 	return normalizeMatchIDs(primaryMatchID, matchIDs)
 }
 
-func parseMyIDBarcode(barcodeData string) (string, error) {
-	barcode := strings.TrimSpace(barcodeData)
-	if len(barcode) >= 2 && strings.HasPrefix(barcode, "*") && strings.HasSuffix(barcode, "*") {
-		barcode = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(barcode, "*"), "*"))
-	}
-	barcode = strings.ToUpper(barcode)
-
-	if !strings.HasPrefix(barcode, myIDBarcodePrefix) {
-		return "", fmt.Errorf("invalid MyID barcode prefix")
-	}
-
-	studentNumberWithCheckDigit := strings.TrimPrefix(barcode, myIDBarcodePrefix)
-	if len(studentNumberWithCheckDigit) != studentNumberLength+1 {
-		return "", fmt.Errorf("student number must be %d digits plus a check digit", studentNumberLength)
-	}
-
-	for _, char := range studentNumberWithCheckDigit {
-		if char < '0' || char > '9' {
-			return "", fmt.Errorf("student number must be numeric")
-		}
-	}
-
-	return studentNumberWithCheckDigit[:studentNumberLength], nil
-}
-
-func (h *BarcodeHandler) findUserByStudentNumber(studentNumber string) (*models.User, error) {
-	emailLocalPartForStudentNumber := "s" + studentNumber
-	users, err := h.userRepo.FindUsers(emailLocalPartForStudentNumber, "email")
-	if err != nil {
-		return nil, err
-	}
-
-	for _, user := range users {
-		emailLocalPart := strings.SplitN(user.Email, "@", 2)[0]
-		if strings.EqualFold(emailLocalPart, emailLocalPartForStudentNumber) {
-			return user, nil
-		}
-	}
-
-	return nil, nil
-}
-
 func (h *BarcodeHandler) findPreEnteredTeam(userID string, eventID int, sportID int) (*models.TeamWithSport, error) {
 	teams, err := h.teamRepo.GetTeamsByUserID(userID)
 	if err != nil {
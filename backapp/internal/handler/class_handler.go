@@ -255,6 +255,12 @@ func (h *ClassHandler) GetClassProgress(c *gin.Context) {
 		return
 	}
 
+	// ?day_id= を付けると、その開催日の試合だけで進捗を組み立てる
+	dayID, ok := dayIDQuery(c)
+	if !ok {
+		return
+	}
+
 	activeEventID, err := h.eventRepo.GetActiveEvent()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get active event"})
@@ -364,6 +370,14 @@ func (h *ClassHandler) GetClassProgress(c *gin.Context) {
 			})
 		}
 
+		if dayID != nil {
+			matchDetails = matchesOnDay(matchDetails, *dayID)
+			// 指定した日に試合のないチームは進捗に含めない
+			if len(matchDetails) == 0 {
+				continue
+			}
+		}
+
 		entry := buildClassProgress(team, matchDetails)
 		progress = append(progress, entry)
 	}
@@ -428,6 +442,17 @@ func (h *ClassHandler) GetClassProgress(c *gin.Context) {
 	})
 }
 
+// matchesOnDay は開催日に割り当てられた試合だけを返す
+func matchesOnDay(matches []*models.MatchDetail, dayID int) []*models.MatchDetail {
+	filtered := make([]*models.MatchDetail, 0, len(matches))
+	for _, m := range matches {
+		if m.EventDayID.Valid && int(m.EventDayID.Int64) == dayID {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
 func buildClassProgress(team *models.TeamWithSport, matches []*models.MatchDetail) models.ClassProgress {
 	entry := models.ClassProgress{
 		SportName:      team.SportName,
@@ -39,6 +39,8 @@ type ClassTeamHandler struct {
 	userRepo  repository.UserRepository
 	eventRepo repository.EventRepository
 	sportRepo repository.SportRepository
+	// rosterRepo is optional. Without it rosters stay open for the whole event.
+	rosterRepo repository.RosterRepository
 }
 
 // NewClassTeamHandler creates a new instance of ClassTeamHandler
@@ -210,6 +212,7 @@ func (h *ClassTeamHandler) AssignTeamMembersHandler(c *gin.Context) {
 		ClassID *int     `json:"class_id"`
 		SportID int      `json:"sport_id"`
 		UserIDs []string `json:"user_ids"`
+		Reason  string   `json:"reason"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -247,6 +250,16 @@ func (h *ClassTeamHandler) AssignTeamMembersHandler(c *gin.Context) {
 		return
 	}
 
+	// After the roster is locked, changes go through root approval instead.
+	phase, ok := h.rosterPhase(c, activeEventID)
+	if !ok {
+		return
+	}
+	if phase != models.RosterPhaseOpen {
+		h.requestRosterChanges(c, currentUser, activeEventID, managedClass, req.SportID, models.RosterChangeActionAdd, req.UserIDs, req.Reason)
+		return
+	}
+
 	// Get or create team
 	team, err := h.teamRepo.GetTeamByClassAndSport(managedClass.ID, req.SportID, activeEventID)
 	if err != nil {
@@ -277,18 +290,7 @@ func (h *ClassTeamHandler) AssignTeamMembersHandler(c *gin.Context) {
 	}
 
 	// --- Capacity Check ---
-	var maxCapacity *int
-
-	// 1. Check team specific capacity
-	if team.MaxCapacity != nil {
-		maxCapacity = team.MaxCapacity
-	} else {
-		// 2. Check event sport default capacity
-		eventSport, err := h.sportRepo.GetSportDetails(activeEventID, req.SportID)
-		if err == nil && eventSport != nil {
-			maxCapacity = eventSport.MaxCapacity
-		}
-	}
+	maxCapacity := h.teamMaxCapacity(team, activeEventID, req.SportID)
 
 	if maxCapacity != nil {
 		// Get current members
@@ -395,6 +397,7 @@ func (h *ClassTeamHandler) RemoveTeamMemberHandler(c *gin.Context) {
 		ClassID *int   `json:"class_id"`
 		SportID int    `json:"sport_id"`
 		UserID  string `json:"user_id"`
+		Reason  string `json:"reason"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -427,6 +430,16 @@ func (h *ClassTeamHandler) RemoveTeamMemberHandler(c *gin.Context) {
 		return
 	}
 
+	// After the roster is locked, changes go through root approval instead.
+	phase, ok := h.rosterPhase(c, activeEventID)
+	if !ok {
+		return
+	}
+	if phase != models.RosterPhaseOpen {
+		h.requestRosterChanges(c, currentUser, activeEventID, managedClass, req.SportID, models.RosterChangeActionRemove, []string{req.UserID}, req.Reason)
+		return
+	}
+
 	// Get team
 	team, err := h.teamRepo.GetTeamByClassAndSport(managedClass.ID, req.SportID, activeEventID)
 	if err != nil {
@@ -0,0 +1,419 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WithRosterLock enables per-event roster phases. Once an event's roster is locked,
+// member changes become change requests that root approves or rejects.
+func (h *ClassTeamHandler) WithRosterLock(rosterRepo repository.RosterRepository) *ClassTeamHandler {
+	h.rosterRepo = rosterRepo
+	return h
+}
+
+// rosterPhase returns the roster phase of the event. It writes the error response itself and returns false on failure.
+func (h *ClassTeamHandler) rosterPhase(c *gin.Context, eventID int) (models.RosterPhase, bool) {
+	if h.rosterRepo == nil {
+		return models.RosterPhaseOpen, true
+	}
+	phase, err := h.rosterRepo.GetRosterPhase(eventID)
+	if err != nil {
+		log.Printf("GetRosterPhase error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get roster phase"})
+		return "", false
+	}
+	if phase == "" {
+		return models.RosterPhaseOpen, true
+	}
+	return phase, true
+}
+
+// createTeam creates the class team for a sport, named after the class.
+func (h *ClassTeamHandler) createTeam(class *models.Class, sportID int, eventID int) (*models.Team, error) {
+	newTeam := &models.Team{
+		Name:    class.Name,
+		ClassID: class.ID,
+		SportID: sportID,
+		EventID: eventID,
+	}
+	teamID, err := h.teamRepo.CreateTeam(newTeam)
+	if err != nil {
+		return nil, err
+	}
+	newTeam.ID = int(teamID)
+	return newTeam, nil
+}
+
+// teamMaxCapacity returns the team's own maximum, falling back to the event sport default.
+func (h *ClassTeamHandler) teamMaxCapacity(team *models.Team, eventID int, sportID int) *int {
+	if team != nil && team.MaxCapacity != nil {
+		return team.MaxCapacity
+	}
+	eventSport, err := h.sportRepo.GetSportDetails(eventID, sportID)
+	if err == nil && eventSport != nil {
+		return eventSport.MaxCapacity
+	}
+	return nil
+}
+
+// teamMinCapacity returns the team's own minimum, falling back to the event sport default.
+func (h *ClassTeamHandler) teamMinCapacity(team *models.Team, eventID int, sportID int) *int {
+	if team != nil && team.MinCapacity != nil {
+		return team.MinCapacity
+	}
+	eventSport, err := h.sportRepo.GetSportDetails(eventID, sportID)
+	if err == nil && eventSport != nil {
+		return eventSport.MinCapacity
+	}
+	return nil
+}
+
+// requestRosterChanges records one change request per user instead of changing the roster.
+func (h *ClassTeamHandler) requestRosterChanges(c *gin.Context, currentUser *models.User, eventID int, class *models.Class, sportID int, action models.RosterChangeAction, userIDs []string, reason string) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "The roster is locked. Provide a reason to request this change"})
+		return
+	}
+
+	requestedBy := currentUser.ID
+	requests := make([]*models.RosterChangeRequest, 0, len(userIDs))
+	seenUserIDs := make(map[string]struct{}, len(userIDs))
+	for _, userID := range userIDs {
+		if _, exists := seenUserIDs[userID]; exists || userID == "" {
+			continue
+		}
+		seenUserIDs[userID] = struct{}{}
+
+		user, err := h.userRepo.GetUserWithRoles(userID)
+		if err != nil || user == nil || user.ClassID == nil || *user.ClassID != class.ID {
+			continue
+		}
+		requests = append(requests, &models.RosterChangeRequest{
+			EventID:     eventID,
+			ClassID:     class.ID,
+			SportID:     sportID,
+			UserID:      userID,
+			Action:      action,
+			Reason:      reason,
+			RequestedBy: &requestedBy,
+		})
+	}
+	if len(requests) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No members of this class to request changes for"})
+		return
+	}
+
+	if err := h.rosterRepo.CreateChangeRequests(requests); err != nil {
+		log.Printf("CreateChangeRequests error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create change requests"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":         fmt.Sprintf("The roster is locked. %d change requests are waiting for approval.", len(requests)),
+		"change_requests": requests,
+	})
+}
+
+// GetRosterPhaseHandler returns the roster phase of the active event.
+func (h *ClassTeamHandler) GetRosterPhaseHandler(c *gin.Context) {
+	activeEventID, err := h.eventRepo.GetActiveEvent()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get active event"})
+		return
+	}
+	if activeEventID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active event found"})
+		return
+	}
+
+	phase, ok := h.rosterPhase(c, activeEventID)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"event_id": activeEventID, "phase": phase})
+}
+
+// SetRosterPhaseHandler moves an event between the open, locked and event day roster phases.
+func (h *ClassTeamHandler) SetRosterPhaseHandler(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req struct {
+		Phase models.RosterPhase `json:"phase"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !req.Phase.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "phase must be one of open, locked or event_day"})
+		return
+	}
+
+	found, err := h.rosterRepo.SetRosterPhase(eventID, req.Phase)
+	if err != nil {
+		log.Printf("SetRosterPhase error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update roster phase"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"event_id": eventID, "phase": req.Phase})
+}
+
+// ListRosterChangeRequestsHandler returns the change requests of the active event, including reviewed ones.
+func (h *ClassTeamHandler) ListRosterChangeRequestsHandler(c *gin.Context) {
+	status := models.RosterChangeRequestStatus(c.Query("status"))
+	switch status {
+	case "", models.RosterChangeRequestStatusPending, models.RosterChangeRequestStatusApproved, models.RosterChangeRequestStatusRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	activeEventID, err := h.eventRepo.GetActiveEvent()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get active event"})
+		return
+	}
+	if activeEventID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active event found"})
+		return
+	}
+
+	requests, err := h.rosterRepo.ListChangeRequests(activeEventID, status)
+	if err != nil {
+		log.Printf("ListChangeRequests error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get change requests"})
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+type rosterReviewRequest struct {
+	Note string `json:"note"`
+}
+
+// ApproveRosterChangeRequestHandler re-validates a pending change request against the current roster and applies it.
+func (h *ClassTeamHandler) ApproveRosterChangeRequestHandler(c *gin.Context) {
+	reviewer, request, note, ok := h.pendingRosterChangeRequest(c)
+	if !ok {
+		return
+	}
+
+	team, statusCode, errMsg := h.validateRosterChange(request)
+	if statusCode != 0 {
+		c.JSON(statusCode, gin.H{"error": errMsg})
+		return
+	}
+
+	resolved, err := h.rosterRepo.ResolvePendingChangeRequest(request.ID, models.RosterChangeRequestStatusApproved, reviewer.ID, note)
+	if err != nil {
+		log.Printf("ResolvePendingChangeRequest error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update change request"})
+		return
+	}
+	if !resolved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Change request has already been reviewed"})
+		return
+	}
+
+	if err := h.applyRosterChange(request, team); err != nil {
+		log.Printf("applyRosterChange error: requestID=%d, error=%v", request.ID, err)
+		if err := h.rosterRepo.ReopenChangeRequest(request.ID); err != nil {
+			log.Printf("ReopenChangeRequest error: requestID=%d, error=%v", request.ID, err)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply change request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Change request approved"})
+}
+
+// RejectRosterChangeRequestHandler rejects a pending change request without touching the roster.
+func (h *ClassTeamHandler) RejectRosterChangeRequestHandler(c *gin.Context) {
+	reviewer, request, note, ok := h.pendingRosterChangeRequest(c)
+	if !ok {
+		return
+	}
+
+	resolved, err := h.rosterRepo.ResolvePendingChangeRequest(request.ID, models.RosterChangeRequestStatusRejected, reviewer.ID, note)
+	if err != nil {
+		log.Printf("ResolvePendingChangeRequest error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update change request"})
+		return
+	}
+	if !resolved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Change request has already been reviewed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Change request rejected"})
+}
+
+func (h *ClassTeamHandler) pendingRosterChangeRequest(c *gin.Context) (*models.User, *models.RosterChangeRequest, *string, bool) {
+	reviewer := currentUser(c)
+	if reviewer == nil {
+		return nil, nil, nil, false
+	}
+	requestID, ok := parseIDParam(c, "request_id")
+	if !ok {
+		return nil, nil, nil, false
+	}
+
+	var review rosterReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&review); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return nil, nil, nil, false
+		}
+	}
+	var note *string
+	if trimmed := strings.TrimSpace(review.Note); trimmed != "" {
+		note = &trimmed
+	}
+
+	request, err := h.rosterRepo.GetChangeRequest(requestID)
+	if err != nil {
+		log.Printf("GetChangeRequest error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get change request"})
+		return nil, nil, nil, false
+	}
+	if request == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Change request not found"})
+		return nil, nil, nil, false
+	}
+	if request.Status != models.RosterChangeRequestStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Change request has already been reviewed"})
+		return nil, nil, nil, false
+	}
+	return reviewer, request, note, true
+}
+
+// validateRosterChange checks the registration limit and the min/max capacity against the roster as it is now,
+// since other changes may have been applied after the request was made. It returns the team when it already exists.
+func (h *ClassTeamHandler) validateRosterChange(request *models.RosterChangeRequest) (*models.Team, int, string) {
+	event, err := h.eventRepo.GetEventByID(request.EventID)
+	if err != nil || event == nil {
+		return nil, http.StatusInternalServerError, "Failed to get event settings"
+	}
+	class, err := h.classRepo.GetClassByID(request.ClassID)
+	if err != nil || class == nil {
+		return nil, http.StatusInternalServerError, "Failed to get class"
+	}
+	team, err := h.teamRepo.GetTeamByClassAndSport(request.ClassID, request.SportID, request.EventID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "Failed to get team"
+	}
+
+	isMember := false
+	memberCount := 0
+	if team != nil {
+		members, err := h.teamRepo.GetTeamMembers(team.ID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, "Failed to get current team members"
+		}
+		memberCount = len(members)
+		for _, member := range members {
+			if member.ID == request.UserID {
+				isMember = true
+				break
+			}
+		}
+	}
+
+	switch request.Action {
+	case models.RosterChangeActionAdd:
+		if isMember {
+			return nil, http.StatusConflict, "The user is already a member of this team"
+		}
+		user, err := h.userRepo.GetUserWithRoles(request.UserID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, "Failed to get user"
+		}
+		if user == nil || user.ClassID == nil || *user.ClassID != class.ID {
+			return nil, http.StatusConflict, "The user no longer belongs to this class"
+		}
+
+		if maxCapacity := h.teamMaxCapacity(team, request.EventID, request.SportID); maxCapacity != nil && memberCount+1 > *maxCapacity {
+			return nil, http.StatusConflict, fmt.Sprintf("定員オーバーです。現在のメンバー数: %d, 追加人数: %d, 定員: %d", memberCount, 1, *maxCapacity)
+		}
+
+		if registrationLimit := teamRegistrationLimit(class, event.DuplicateRegistrationThreshold); registrationLimit > 0 {
+			registeredTeams, err := h.teamRepo.GetTeamsByUserID(request.UserID)
+			if err != nil {
+				return nil, http.StatusInternalServerError, "Failed to check duplicate registrations"
+			}
+			if registeredSportCountForEvent(registeredTeams, request.EventID, request.SportID) > registrationLimit {
+				return nil, http.StatusConflict, fmt.Sprintf("%s は登録可能な競技数（%d競技）を超えています", request.UserID, registrationLimit)
+			}
+		}
+	case models.RosterChangeActionRemove:
+		if !isMember {
+			return nil, http.StatusConflict, "The user is not a member of this team"
+		}
+
+		if minCapacity := h.teamMinCapacity(team, request.EventID, request.SportID); minCapacity != nil && memberCount-1 < *minCapacity {
+			return nil, http.StatusConflict, fmt.Sprintf("最低人数を下回ります。現在のメンバー数: %d, 最低人数: %d", memberCount, *minCapacity)
+		}
+
+		phase, err := h.rosterRepo.GetRosterPhase(request.EventID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, "Failed to get roster phase"
+		}
+		if phase == models.RosterPhaseEventDay {
+			confirmed, err := h.teamRepo.GetConfirmedTeamMembers(team.ID)
+			if err != nil {
+				return nil, http.StatusInternalServerError, "Failed to get confirmed team members"
+			}
+			for _, member := range confirmed {
+				if member.ID == request.UserID {
+					return nil, http.StatusConflict, "Members who have already checked in cannot be removed on the event day"
+				}
+			}
+		}
+	default:
+		return nil, http.StatusBadRequest, "Unknown change request action"
+	}
+
+	return team, 0, ""
+}
+
+// applyRosterChange changes the roster and the class_name_sport_name role the same way the open phase handlers do.
+func (h *ClassTeamHandler) applyRosterChange(request *models.RosterChangeRequest, team *models.Team) error {
+	roleName := fmt.Sprintf("%s_%s", request.ClassName, request.SportName)
+
+	switch request.Action {
+	case models.RosterChangeActionAdd:
+		if team == nil {
+			class, err := h.classRepo.GetClassByID(request.ClassID)
+			if err != nil || class == nil {
+				return fmt.Errorf("get class %d: %v", request.ClassID, err)
+			}
+			if team, err = h.createTeam(class, request.SportID, request.EventID); err != nil {
+				return err
+			}
+		}
+		if err := h.teamRepo.AddTeamMember(team.ID, request.UserID); err != nil {
+			return err
+		}
+		eventID := request.EventID
+		return h.userRepo.UpdateUserRole(request.UserID, roleName, &eventID)
+	case models.RosterChangeActionRemove:
+		if err := h.teamRepo.RemoveTeamMember(team.ID, request.UserID); err != nil {
+			return err
+		}
+		return h.userRepo.DeleteUserRole(request.UserID, roleName)
+	}
+	return fmt.Errorf("unknown roster change action %q", request.Action)
+}
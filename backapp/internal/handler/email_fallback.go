@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"backapp/internal/mail"
+	"backapp/internal/models"
+	"backapp/internal/repository"
+)
+
+const (
+	NotificationChannelPush              = "push"
+	NotificationChannelPushEmailFallback = "push_email_fallback"
+)
+
+// mailBuilder は宛先アドレスから送るメールを組み立てる
+type mailBuilder func(to string) (mail.Message, error)
+
+func normalizeNotificationChannel(channel string) (string, error) {
+	switch strings.TrimSpace(channel) {
+	case "", NotificationChannelPush:
+		return NotificationChannelPush, nil
+	case NotificationChannelPushEmailFallback:
+		return NotificationChannelPushEmailFallback, nil
+	default:
+		return "", errors.New("無効な通知チャネルです")
+	}
+}
+
+// usersWithoutPush は Push 購読を1件も持たないユーザーを返す
+func usersWithoutPush(userIDs []string, subs []models.PushSubscription) []string {
+	subscribed := make(map[string]bool, len(subs))
+	for _, sub := range subs {
+		subscribed[sub.UserID] = true
+	}
+	var result []string
+	for _, userID := range userIDs {
+		if !subscribed[userID] {
+			result = append(result, userID)
+		}
+	}
+	return result
+}
+
+// sendEmailFallback は userIDs にメールを送り、送れた件数を返す。
+// ログにはユーザーIDと宛先ドメインだけを残す。
+func sendEmailFallback(sender mail.Sender, repo repository.NotificationRepository, userIDs []string, build mailBuilder, logPrefix string) int {
+	if sender == nil || !sender.Enabled() || build == nil || len(userIDs) == 0 {
+		return 0
+	}
+
+	emails, err := repo.GetUserEmailsByIDs(userIDs)
+	if err != nil {
+		log.Printf("[%s] メールアドレスの取得に失敗しました: %v\n", logPrefix, err)
+		return 0
+	}
+
+	messages := make([]mail.Message, 0, len(emails))
+	recipients := make([]string, 0, len(emails))
+	for _, userID := range userIDs {
+		address, ok := emails[userID]
+		if !ok {
+			continue
+		}
+		message, err := build(address)
+		if err != nil {
+			log.Printf("[%s] メールの組み立てに失敗しました: userID=%s, err=%v\n", logPrefix, userID, err)
+			continue
+		}
+		messages = append(messages, message)
+		recipients = append(recipients, userID)
+	}
+
+	sent := 0
+	for i, result := range sender.SendBatch(context.Background(), messages) {
+		if result.Err != nil {
+			// SMTP の応答にはアドレスが含まれることがあるので伏せる
+			reason := strings.ReplaceAll(result.Err.Error(), result.Message.To, "<redacted>")
+			log.Printf("[%s] メール送信に失敗しました: userID=%s, domain=%s, err=%s\n", logPrefix, recipients[i], mail.RecipientLogID(result.Message.To), reason)
+			continue
+		}
+		sent++
+	}
+	log.Printf("[%s] メール送信完了: %d/%d件\n", logPrefix, sent, len(messages))
+	return sent
+}
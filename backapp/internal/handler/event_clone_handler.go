@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"backapp/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PreviewEventClone は前回の大会を複製したときに作られる大会と引き継ぐ内容を返す
+func (h *EventHandler) PreviewEventClone(c *gin.Context) {
+	plan, ok := h.planEventClone(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"plan": plan})
+}
+
+// CloneEvent は前回の大会から新しい大会を作り、選んだ項目を引き継ぐ
+func (h *EventHandler) CloneEvent(c *gin.Context) {
+	plan, ok := h.planEventClone(c)
+	if !ok {
+		return
+	}
+	if len(plan.Conflicts) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Event cannot be cloned", "conflicts": plan.Conflicts})
+		return
+	}
+
+	id, err := h.eventRepo.CloneEvent(plan)
+	if err != nil {
+		log.Printf("CloneEvent error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	plan.Event.ID = int(id)
+
+	c.JSON(http.StatusCreated, gin.H{"event": plan.Event, "plan": plan})
+}
+
+// planEventClone はリクエストを検証して複製の計画を作る。失敗したときはレスポンスを書いて false を返す。
+func (h *EventHandler) planEventClone(c *gin.Context) (*models.EventClonePlan, bool) {
+	sourceEventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return nil, false
+	}
+
+	var req models.EventCloneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if strings.TrimSpace(req.Name) == "" || req.Year <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and year are required"})
+		return nil, false
+	}
+	if req.Season != "spring" && req.Season != "autumn" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid season"})
+		return nil, false
+	}
+	if req.Status == "" {
+		req.Status = models.EventStatusUpcoming
+	}
+	if !models.IsValidEventStatus(req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event status"})
+		return nil, false
+	}
+	startDate, err := parseOptionalDate(req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD."})
+		return nil, false
+	}
+	endDate, err := parseOptionalDate(req.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD."})
+		return nil, false
+	}
+
+	parts := req.Parts
+	if len(parts) == 0 {
+		parts = models.EventClonePartsAll
+	}
+	selected := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		switch part {
+		case models.EventClonePartSports, models.EventClonePartClasses, models.EventClonePartRainyModeSettings,
+			models.EventClonePartGuideDocuments, models.EventClonePartNoonGameDefaultGroups:
+			selected[part] = true
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid clone part: %s", part)})
+			return nil, false
+		}
+	}
+	// 雨天時設定は競技とクラスに結び付くため、単独では引き継げない
+	if selected[models.EventClonePartRainyModeSettings] && (!selected[models.EventClonePartSports] || !selected[models.EventClonePartClasses]) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rainy_mode_settings requires sports and classes"})
+		return nil, false
+	}
+
+	source, err := h.eventRepo.GetCloneSource(sourceEventID)
+	if err != nil {
+		log.Printf("GetCloneSource error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return nil, false
+	}
+	if source == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return nil, false
+	}
+
+	plan := &models.EventClonePlan{
+		SourceEventID: sourceEventID,
+		Event: &models.Event{
+			Name:                           strings.TrimSpace(req.Name),
+			Year:                           req.Year,
+			Season:                         req.Season,
+			Start_date:                     startDate,
+			End_date:                       endDate,
+			Status:                         req.Status,
+			HideScores:                     source.Event.HideScores,
+			DuplicateRegistrationThreshold: source.Event.DuplicateRegistrationThreshold,
+		},
+		Sports:                make([]*models.EventSport, 0),
+		Classes:               make([]*models.EventCloneClass, 0),
+		SkippedClasses:        make([]string, 0),
+		RainyModeSettings:     make([]*models.EventCloneRainySetting, 0),
+		GuideDocuments:        make([]*models.GuideDocument, 0),
+		NoonGameDefaultGroups: make([]*models.EventCloneDefaultGroup, 0),
+		Conflicts:             make([]string, 0),
+	}
+	for _, part := range models.EventClonePartsAll {
+		if selected[part] {
+			plan.Parts = append(plan.Parts, part)
+		}
+	}
+
+	existing, err := h.eventRepo.GetEventByYearAndSeason(req.Year, req.Season)
+	if err != nil {
+		log.Printf("GetEventByYearAndSeason error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return nil, false
+	}
+	if existing != nil {
+		plan.Conflicts = append(plan.Conflicts, fmt.Sprintf("An event for %d %s already exists", req.Year, req.Season))
+	}
+
+	fillEventClonePlan(plan, source, req.ClassNameMap)
+	return plan, true
+}
+
+// fillEventClonePlan は複製元の大会の内容を、クラス名の対応表で付け替えながら plan に積む
+func fillEventClonePlan(plan *models.EventClonePlan, source *models.EventCloneSource, classNameMap map[string]string) {
+	renamed := func(name string) string {
+		if mapped, ok := classNameMap[name]; ok {
+			return strings.TrimSpace(mapped)
+		}
+		return name
+	}
+
+	sourceClassNames := make(map[string]bool, len(source.Classes))
+	for _, class := range source.Classes {
+		sourceClassNames[class.Name] = true
+	}
+	unknown := make([]string, 0)
+	for name := range classNameMap {
+		if !sourceClassNames[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		plan.Conflicts = append(plan.Conflicts, fmt.Sprintf("Class %s in class_name_map does not exist in the source event", name))
+	}
+
+	if plan.HasPart(models.EventClonePartSports) {
+		for _, sport := range source.Sports {
+			cloned := *sport
+			cloned.EventID = 0
+			plan.Sports = append(plan.Sports, &cloned)
+		}
+	}
+
+	// 複製元のクラスIDから新しいクラス名への対応。引き継がないクラスは含めない
+	clonedClassNames := make(map[int]string)
+	if plan.HasPart(models.EventClonePartClasses) {
+		sourceByName := make(map[string]string)
+		for _, class := range source.Classes {
+			name := renamed(class.Name)
+			if name == "" {
+				plan.SkippedClasses = append(plan.SkippedClasses, class.Name)
+				continue
+			}
+			if previous, ok := sourceByName[name]; ok {
+				plan.Conflicts = append(plan.Conflicts, fmt.Sprintf("Classes %s and %s would both become %s", previous, class.Name, name))
+				continue
+			}
+			sourceByName[name] = class.Name
+			clonedClassNames[class.ID] = name
+			plan.Classes = append(plan.Classes, &models.EventCloneClass{
+				SourceClassID: class.ID,
+				SourceName:    class.Name,
+				Name:          name,
+				StudentCount:  class.StudentCount,
+			})
+		}
+	}
+
+	if plan.HasPart(models.EventClonePartRainyModeSettings) {
+		sourceClassNamesByID := make(map[int]string, len(source.Classes))
+		for _, class := range source.Classes {
+			sourceClassNamesByID[class.ID] = class.Name
+		}
+		for _, setting := range source.RainyModeSettings {
+			name, ok := clonedClassNames[setting.ClassID]
+			if !ok {
+				continue
+			}
+			plan.RainyModeSettings = append(plan.RainyModeSettings, &models.EventCloneRainySetting{
+				SportID:         setting.SportID,
+				SourceClassName: sourceClassNamesByID[setting.ClassID],
+				ClassName:       name,
+				MinCapacity:     setting.MinCapacity,
+				MaxCapacity:     setting.MaxCapacity,
+				MatchStartTime:  setting.MatchStartTime,
+			})
+		}
+	}
+
+	if plan.HasPart(models.EventClonePartGuideDocuments) {
+		for _, doc := range source.GuideDocuments {
+			plan.GuideDocuments = append(plan.GuideDocuments, &models.GuideDocument{
+				Title:       doc.Title,
+				Description: doc.Description,
+				PdfURL:      doc.PdfURL,
+			})
+		}
+	}
+
+	// デフォルトグループは大会をまたいで共有されるため、クラス名の変わるグループだけを書き換える
+	if plan.HasPart(models.EventClonePartNoonGameDefaultGroups) {
+		for _, group := range source.NoonGameDefaultGroups {
+			classNames := make([]string, 0, len(group.ClassNames))
+			changed := false
+			for _, name := range group.ClassNames {
+				next := renamed(name)
+				if next != name {
+					changed = true
+				}
+				if next != "" {
+					classNames = append(classNames, next)
+				}
+			}
+			if !changed {
+				continue
+			}
+			plan.NoonGameDefaultGroups = append(plan.NoonGameDefaultGroups, &models.EventCloneDefaultGroup{
+				ID:                 group.ID,
+				TemplateKey:        group.TemplateKey,
+				GroupIndex:         group.GroupIndex,
+				GroupName:          group.GroupName,
+				PreviousClassNames: group.ClassNames,
+				ClassNames:         classNames,
+			})
+		}
+	}
+}
+
+func parseOptionalDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"backapp/internal/models"
+	"backapp/internal/repository"
+	"backapp/internal/scoreboard"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventDayHandler は複数日にわたる大会の開催日と、開催日ごとの雨天時モードを扱う
+type EventDayHandler struct {
+	eventRepo  repository.EventRepository
+	dayRepo    repository.EventDayRepository
+	scoreboard *scoreboard.Feed
+}
+
+func NewEventDayHandler(eventRepo repository.EventRepository, dayRepo repository.EventDayRepository) *EventDayHandler {
+	return &EventDayHandler{
+		eventRepo: eventRepo,
+		dayRepo:   dayRepo,
+	}
+}
+
+// WithScoreboard は開催日の雨天時モードの切り替えを会場のスコアボードにも反映する
+func (h *EventDayHandler) WithScoreboard(feed *scoreboard.Feed) *EventDayHandler {
+	h.scoreboard = feed
+	return h
+}
+
+func (h *EventDayHandler) GetEventDays(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	days, err := h.dayRepo.GetDaysByEventID(eventID)
+	if err != nil {
+		log.Printf("GetEventDays error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get event days"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"days": days})
+}
+
+// SyncEventDays は大会の開始日から終了日までを開催日として登録し直す。期間外になった開催日は削除する。
+func (h *EventDayHandler) SyncEventDays(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := h.eventRepo.GetEventByID(eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+	if event.Start_date == nil || event.End_date == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "開始日と終了日を設定してください"})
+		return
+	}
+
+	dates, err := models.EventDayDates(*event.Start_date, *event.End_date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	days, err := h.dayRepo.SyncDays(eventID, dates)
+	if err != nil {
+		log.Printf("SyncEventDays error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event days"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"days": days})
+}
+
+// eventDayFromParams は URL の大会IDと開催日IDから、その大会の開催日を取り出す
+func (h *EventDayHandler) eventDayFromParams(c *gin.Context) (*models.EventDay, bool) {
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return nil, false
+	}
+	dayID, err := strconv.Atoi(c.Param("day_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event day ID"})
+		return nil, false
+	}
+
+	day, err := h.dayRepo.GetDayByID(dayID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get event day"})
+		return nil, false
+	}
+	if day == nil || day.EventID != eventID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event day not found"})
+		return nil, false
+	}
+	return day, true
+}
+
+// SetDayRainyMode は開催日ごとに雨天時モードを切り替える。有効にするとその日の試合だけ雨天用の開始時刻になる。
+func (h *EventDayHandler) SetDayRainyMode(c *gin.Context) {
+	day, ok := h.eventDayFromParams(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		IsRainyMode bool `json:"is_rainy_mode"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.dayRepo.SetRainyMode(day.ID, req.IsRainyMode, actorUserID(c)); err != nil {
+		log.Printf("SetDayRainyMode error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rainy mode"})
+		return
+	}
+	if h.scoreboard != nil {
+		h.scoreboard.Resync(day.EventID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rainy mode updated successfully", "event_day_id": day.ID, "is_rainy_mode": req.IsRainyMode})
+}
+
+// AssignEventDay は試合と昼競技のセッションを開催日に割り当てる
+func (h *EventDayHandler) AssignEventDay(c *gin.Context) {
+	day, ok := h.eventDayFromParams(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		MatchIDs       []int `json:"match_ids"`
+		NoonSessionIDs []int `json:"noon_session_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.MatchIDs) == 0 && len(req.NoonSessionIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "match_ids または noon_session_ids を指定してください"})
+		return
+	}
+
+	matchCount, err := h.dayRepo.AssignMatches(day.ID, req.MatchIDs)
+	if err != nil {
+		log.Printf("AssignEventDay error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign matches"})
+		return
+	}
+	sessionCount, err := h.dayRepo.AssignNoonSessions(day.ID, req.NoonSessionIDs)
+	if err != nil {
+		log.Printf("AssignEventDay error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign noon game sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event_day_id": day.ID, "match_count": matchCount, "noon_session_count": sessionCount})
+}
+
+// dayIDQuery は ?day_id= で開催日を指定されたときにその値を返す。未指定なら nil。
+func dayIDQuery(c *gin.Context) (*int, bool) {
+	value := c.Query("day_id")
+	if value == "" {
+		return nil, true
+	}
+	dayID, err := strconv.Atoi(value)
+	if err != nil || dayID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid day_id"})
+		return nil, false
+	}
+	return &dayID, true
+}
@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"backapp/internal/mail"
 	"backapp/internal/models"
 	"backapp/internal/push"
 	"backapp/internal/repository"
+	"backapp/internal/scoreboard"
 	"encoding/csv"
 	"encoding/json"
 	"log"
@@ -21,7 +23,11 @@ type EventHandler struct {
 	classRepo        repository.ClassRepository
 	notificationRepo repository.NotificationRepository
 	userRepo         repository.UserRepository
+	scoringRuleRepo  repository.ScoringRuleRepository
 	pushSender       push.Sender
+	pushOutbox       *PushOutbox
+	mailSender       mail.Sender
+	scoreboard       *scoreboard.Feed
 }
 
 func NewEventHandler(eventRepo repository.EventRepository, tournamentRepo repository.TournamentRepository, classRepo repository.ClassRepository, notificationRepo repository.NotificationRepository, userRepo repository.UserRepository, vapidPublicKey string, vapidPrivateKey string) *EventHandler {
@@ -43,6 +49,30 @@ func (h *EventHandler) WithPushSender(sender push.Sender) *EventHandler {
 	return h
 }
 
+// WithPushOutbox は Push 通知を送信キュー経由で送るようにする
+func (h *EventHandler) WithPushOutbox(outbox *PushOutbox) *EventHandler {
+	h.pushOutbox = outbox
+	return h
+}
+
+// WithMailSender は Push 購読のない利用者へのメール送信に使う Sender を設定する
+func (h *EventHandler) WithMailSender(sender mail.Sender) *EventHandler {
+	h.mailSender = sender
+	return h
+}
+
+// WithScoringRules はアンケート得点の計算にイベントの配点ルールを使うようにする
+func (h *EventHandler) WithScoringRules(scoringRuleRepo repository.ScoringRuleRepository) *EventHandler {
+	h.scoringRuleRepo = scoringRuleRepo
+	return h
+}
+
+// WithScoreboard はアンケート得点や表示設定の変更を会場のスコアボードにも配信する
+func (h *EventHandler) WithScoreboard(feed *scoreboard.Feed) *EventHandler {
+	h.scoreboard = feed
+	return h
+}
+
 func (h *EventHandler) CreateEvent(c *gin.Context) {
 	var req struct {
 		Name                           string  `json:"name"`
@@ -230,6 +260,10 @@ func (h *EventHandler) UpdateEvent(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
+	if h.scoreboard != nil {
+		// 得点の非表示設定が変わった可能性があるため、全体を送り直す
+		h.scoreboard.Resync(id)
+	}
 
 	c.JSON(http.StatusOK, existingEvent)
 }
@@ -319,12 +353,16 @@ func (h *EventHandler) SetRainyMode(c *gin.Context) {
 
 	// If rainy mode is being enabled, apply rainy mode start times to tournaments
 	if req.IsRainyMode {
-		err = h.tournamentRepo.ApplyRainyModeStartTimes(eventID)
+		err = h.tournamentRepo.ApplyRainyModeStartTimes(eventID, actorUserID(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply rainy mode start times"})
 			return
 		}
 	}
+	if h.scoreboard != nil {
+		// 試合の開始時刻が雨天用に切り替わるため、全体を送り直す
+		h.scoreboard.Resync(eventID)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Rainy mode updated successfully", "is_rainy_mode": req.IsRainyMode})
 }
@@ -452,96 +490,127 @@ func (h *EventHandler) NotifySurvey(c *gin.Context) {
 		return
 	}
 
-	// 1. Create a notification record
-	title := "大会アンケートのお願い"
-	body := "大会に関するアンケート機能が公開されました。「" + event.Name + "」についてダッシュボードの一番上のリンクからアンケートにご協力ください。"
-	createdBy := "" // System notification
-	userIDVal, exists := c.Get("user_id")
-	if exists {
-		createdBy = userIDVal.(string)
+	// 本文は省略可能。channel で Push 購読のない利用者へのメール送信を選べる
+	var req struct {
+		Channel string `json:"channel"`
 	}
-
-	notifID, err := h.notificationRepo.CreateNotification(title, body, "general", createdBy, &event.ID)
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	channel, err := normalizeNotificationChannel(req.Channel)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification channel"})
 		return
 	}
 
-	// Target all basic roles
-	targetRoles := []string{"student", "admin", "root"}
-	if err := h.notificationRepo.AddNotificationTargets(notifID, targetRoles); err != nil {
-		// Log error but don't fail the request completely
-	}
-
 	// Publish the survey
 	if err := h.eventRepo.PublishSurvey(eventID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish survey"})
 		return
 	}
 
+	var emailFallback mailBuilder
+	if channel == NotificationChannelPushEmailFallback {
+		eventName := event.Name
+		emailFallback = func(to string) (mail.Message, error) {
+			return mail.SurveyNoticeMessage(to, eventName, "")
+		}
+	}
+
+	// Target all basic roles
+	targetRoles := []string{"student", "admin", "root"}
+	subs, mailUserIDs, err := h.resolvePushRecipients(targetRoles, "general", emailFallback)
+	if err != nil {
+		log.Printf("[event-notification] 通知の対象者の取得に失敗しました: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve notification recipients"})
+		return
+	}
+	message := newPushMessage("event-notification", subs, 60, func(int) ([]byte, error) {
+		return json.Marshal(gin.H{
+			"title": "アンケート回答のお願い",
+			"body":  "アンケートページが公開されました。期間内に回答してください。",
+			"data": gin.H{
+				"url": "/dashboard",
+			},
+		})
+	})
+
+	// Create a notification record together with its push deliveries
+	createdBy := "" // System notification
+	userIDVal, exists := c.Get("user_id")
+	if exists {
+		createdBy = userIDVal.(string)
+	}
+	notifID, err := h.notificationRepo.PublishNotification(models.NewNotification{
+		Title:       "大会アンケートのお願い",
+		Body:        "大会に関するアンケート機能が公開されました。「" + event.Name + "」についてダッシュボードの一番上のリンクからアンケートにご協力ください。",
+		Type:        "general",
+		CreatedBy:   createdBy,
+		EventID:     &event.ID,
+		TargetRoles: targetRoles,
+	}, queuedPushMessage(h.pushOutbox, message))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification"})
+		return
+	}
+
 	// Send push notifications
-	go h.dispatchPushNotifications(int(notifID), "アンケート回答のお願い", "アンケートページが公開されました。期間内に回答してください。", "general", targetRoles)
+	publishPush(h.pushOutbox, h.pushSender, h.notificationRepo, message, int(notifID))
+	if len(mailUserIDs) > 0 {
+		go sendEmailFallback(h.mailSender, h.notificationRepo, mailUserIDs, emailFallback, "event-notification")
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Survey notification sent successfully"})
 }
 
-func (h *EventHandler) dispatchPushNotifications(notificationID int, title, body, notificationType string, targetRoles []string) {
-	log.Printf("[event-notification] 通知送信開始: notificationID=%d, title=%s, targetRoles=%v\n", notificationID, title, targetRoles)
-
-	if h.pushSender == nil || !h.pushSender.Enabled() {
+// resolvePushRecipients は通知を受け取る利用者の Push 購読を返す。
+// emailFallback があれば、購読のない利用者をメールで送る相手として返す。
+func (h *EventHandler) resolvePushRecipients(targetRoles []string, notificationType string, emailFallback mailBuilder) ([]models.PushSubscription, []string, error) {
+	pushEnabled := h.pushSender != nil && h.pushSender.Enabled()
+	mailEnabled := emailFallback != nil && h.mailSender != nil && h.mailSender.Enabled()
+	if !pushEnabled && !mailEnabled {
 		log.Println("[event-notification] VAPIDキーが設定されていないためPush通知をスキップします")
-		return
+		return nil, nil, nil
 	}
 
 	userIDs, err := h.notificationRepo.GetUserIDsByRoles(targetRoles)
 	if err != nil {
-		log.Printf("[event-notification] ユーザー抽出に失敗しました: %v\n", err)
-		return
+		return nil, nil, err
 	}
 	log.Printf("[event-notification] 対象ユーザー数: %d, userIDs=%v\n", len(userIDs), userIDs)
 	if len(userIDs) == 0 {
 		log.Println("[event-notification] 対象ユーザーが0人のためPush通知をスキップします")
-		return
+		return nil, nil, nil
 	}
 
 	// Apply notification filters
 	filteredUserIDs, err := h.filterUsersByNotificationType(userIDs, notificationType)
 	if err != nil {
-		log.Printf("[event-notification] 通知フィルタ適用に失敗しました: %v\n", err)
-		return
+		return nil, nil, err
 	}
 	log.Printf("[event-notification] フィルタ適用後ユーザー数: %d, filteredUserIDs=%v\n", len(filteredUserIDs), filteredUserIDs)
 	if len(filteredUserIDs) == 0 {
 		log.Println("[event-notification] フィルタ適用後対象ユーザーが0人のためPush通知をスキップします")
-		return
+		return nil, nil, nil
 	}
 
-	subs, err := h.notificationRepo.GetPushSubscriptionsByUserIDs(filteredUserIDs)
-	if err != nil {
-		log.Printf("[event-notification] 購読情報の取得に失敗しました: %v\n", err)
-		return
+	var subs []models.PushSubscription
+	if pushEnabled {
+		subs, err = h.notificationRepo.GetPushSubscriptionsByUserIDs(filteredUserIDs)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
-
 	log.Printf("[event-notification] 購読情報数: %d\n", len(subs))
-	if len(subs) == 0 {
-		log.Println("[event-notification] 購読情報が0件のためPush通知をスキップします")
-		return
-	}
 
-	payload, err := json.Marshal(gin.H{
-		"title": title,
-		"body":  body,
-		"data": gin.H{
-			"url": "/dashboard",
-		},
-	})
-	if err != nil {
-		log.Printf("[event-notification] Pushペイロード生成に失敗しました: %v\n", err)
-		return
+	var mailUserIDs []string
+	if mailEnabled {
+		mailUserIDs = usersWithoutPush(filteredUserIDs, subs)
 	}
-
-	dispatchPushBatch(h.pushSender, h.notificationRepo, payload, subs, 60, "event-notification")
-	log.Printf("[event-notification] 通知送信完了: notificationID=%d\n", notificationID)
+	return subs, mailUserIDs, nil
 }
 
 func (h *EventHandler) ImportSurveyScores(c *gin.Context) {
@@ -623,27 +692,23 @@ func (h *EventHandler) ImportSurveyScores(c *gin.Context) {
 		return
 	}
 
+	rules := models.DefaultScoringRules()
+	if h.scoringRuleRepo != nil {
+		rules, err = h.scoringRuleRepo.GetScoringRules(eventID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scoring rules"})
+			return
+		}
+	}
+
+	surveySubmissions := make(map[int]int)
 	surveyPointsData := make(map[int]int)
 	for _, class := range classes {
+		submissions := submissionCounts[class.Name]
+		surveySubmissions[class.ID] = submissions
 		if class.StudentCount > 0 {
-			submissions := submissionCounts[class.Name]
 			rate := float64(submissions) / float64(class.StudentCount)
-			var points int
-			switch {
-			case rate >= 0.9:
-				points = 10
-			case rate >= 0.8:
-				points = 9
-			case rate >= 0.7:
-				points = 8
-			case rate >= 0.6:
-				points = 7
-			case rate >= 0.5:
-				points = 6
-			default:
-				points = 5
-			}
-			surveyPointsData[class.ID] = points
+			surveyPointsData[class.ID] = rules.Survey.PointsFor(rate)
 		}
 	}
 
@@ -652,10 +717,13 @@ func (h *EventHandler) ImportSurveyScores(c *gin.Context) {
 	// For simplicity, we can reuse SetNoonGamePoints logic or require a new method `SetSurveyPoints`.
 	// Let's create `SetSurveyPoints` in ClassRepository just to be clean, or execute DB queries directly
 
-	if err := h.classRepo.SetSurveyPoints(eventID, surveyPointsData); err != nil {
+	if err := h.classRepo.SetSurveyPoints(eventID, surveySubmissions, surveyPointsData, actorUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update survey points"})
 		return
 	}
+	if h.scoreboard != nil {
+		h.scoreboard.ClassScoresChanged(eventID)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Survey scores imported successfully", "imported_classes_count": len(surveyPointsData)})
 }
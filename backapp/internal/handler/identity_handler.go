@@ -0,0 +1,408 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"backapp/internal/identity"
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	identityLookupLimit    = 20
+	maxStudentNumberLength = 32
+	idPassQRCodeScale      = 8
+)
+
+// IdentityHandler は学籍番号の登録、本人確認用 QR コードの発行、手動照合を扱う
+type IdentityHandler struct {
+	identityRepo repository.IdentityRepository
+	userRepo     repository.UserRepository
+	passSigner   *identity.PassSigner
+	eventRepo    repository.EventRepository
+}
+
+func NewIdentityHandler(identityRepo repository.IdentityRepository, userRepo repository.UserRepository) *IdentityHandler {
+	return &IdentityHandler{
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// WithPassSigner は生徒が自分のスマートフォンに表示する署名付きパスの発行を有効にする
+func (h *IdentityHandler) WithPassSigner(passSigner *identity.PassSigner, eventRepo repository.EventRepository) *IdentityHandler {
+	h.passSigner = passSigner
+	h.eventRepo = eventRepo
+	return h
+}
+
+// LookupHandler はバーコードを読めないときに学籍番号・メールアドレス・名前で本人の候補を探す
+func (h *IdentityHandler) LookupHandler(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if len([]rune(query)) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "検索語は2文字以上で指定してください"})
+		return
+	}
+
+	candidates, err := h.identityRepo.SearchCandidates(query, identityLookupLimit)
+	if err != nil {
+		log.Printf("SearchCandidates error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"candidates": candidates})
+}
+
+// ImportStudentNumbersCSVHandler は「メールアドレス,学籍番号」のCSVで学籍番号を登録する。1行目はヘッダーとして読み飛ばす
+func (h *IdentityHandler) ImportStudentNumbersCSVHandler(c *gin.Context) {
+	file, _, err := c.Request.FormFile("csv")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file not provided"})
+		return
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	if _, err := reader.Read(); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read CSV header"})
+		return
+	}
+
+	entries := make([]models.StudentNumberEntry, 0)
+	invalid := make([]models.StudentNumberImportError, 0)
+	seen := make(map[string]int)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read CSV"})
+			return
+		}
+		if len(record) < 2 {
+			continue
+		}
+
+		entry := models.StudentNumberEntry{
+			Line:          line,
+			Email:         strings.TrimSpace(record[0]),
+			StudentNumber: strings.TrimSpace(record[1]),
+		}
+		importError := models.StudentNumberImportError{Line: entry.Line, Email: entry.Email, StudentNumber: entry.StudentNumber}
+		switch {
+		case entry.Email == "" || entry.StudentNumber == "":
+			importError.Reason = "email and student number are required"
+		case len(entry.StudentNumber) > maxStudentNumberLength || !isStudentNumberText(entry.StudentNumber):
+			importError.Reason = "student number must be alphanumeric"
+		case seen[entry.StudentNumber] != 0:
+			importError.Reason = "student number appears more than once in the file"
+		}
+		if importError.Reason != "" {
+			invalid = append(invalid, importError)
+			continue
+		}
+		seen[entry.StudentNumber] = line
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 && len(invalid) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid data found in CSV file"})
+		return
+	}
+
+	result := &models.StudentNumberImportResult{Errors: make([]models.StudentNumberImportError, 0)}
+	if len(entries) > 0 {
+		result, err = h.identityRepo.ImportStudentNumbers(entries)
+		if err != nil {
+			log.Printf("ImportStudentNumbers error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import student numbers"})
+			return
+		}
+	}
+	result.Errors = append(invalid, result.Errors...)
+	c.JSON(http.StatusOK, result)
+}
+
+// IssueIDPassHandler は MyID を持たない人に本人確認用 QR コードを発行する。前に発行したものは使えなくなる
+func (h *IdentityHandler) IssueIDPassHandler(c *gin.Context) {
+	operator, ok := identityOperator(c)
+	if !ok {
+		return
+	}
+	user, ok := h.passOwner(c)
+	if !ok {
+		return
+	}
+
+	token, err := identity.NewPassToken()
+	if err != nil {
+		log.Printf("NewPassToken error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue ID pass"})
+		return
+	}
+	pass, err := h.identityRepo.IssueIDPass(user.ID, token, operator.ID)
+	if err != nil {
+		log.Printf("IssueIDPass error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue ID pass"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"pass":    pass,
+		"payload": identity.PassPayload(pass.Token),
+	})
+}
+
+// RevokeIDPassHandler はユーザーの本人確認用 QR コードを使えなくする
+func (h *IdentityHandler) RevokeIDPassHandler(c *gin.Context) {
+	revoked, err := h.identityRepo.RevokeIDPasses(c.Param("userID"))
+	if err != nil {
+		log.Printf("RevokeIDPasses error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke ID pass"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+// GetIDPassQRCodeHandler は指定したユーザーの本人確認用 QR コードを PNG で返す（印刷して配る用）
+func (h *IdentityHandler) GetIDPassQRCodeHandler(c *gin.Context) {
+	h.writeIDPassQRCode(c, c.Param("userID"))
+}
+
+// GetMyIDPassQRCodeHandler はログイン中のユーザー自身の本人確認用 QR コードを PNG で返す
+func (h *IdentityHandler) GetMyIDPassQRCodeHandler(c *gin.Context) {
+	user, ok := identityOperator(c)
+	if !ok {
+		return
+	}
+	h.writeIDPassQRCode(c, user.ID)
+}
+
+func (h *IdentityHandler) writeIDPassQRCode(c *gin.Context, userID string) {
+	pass, err := h.identityRepo.GetActiveIDPass(userID)
+	if err != nil {
+		log.Printf("GetActiveIDPass error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get ID pass"})
+		return
+	}
+	if pass == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "本人確認用QRコードが発行されていません"})
+		return
+	}
+
+	qr, err := identity.EncodeQR(identity.PassPayload(pass.Token))
+	if err != nil {
+		log.Printf("EncodeQR error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+		return
+	}
+	image, err := qr.PNG(idPassQRCodeScale)
+	if err != nil {
+		log.Printf("QR code PNG error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", image)
+}
+
+// GetMySignedPassHandler はログイン中のユーザーと開催中の大会に結び付けた署名付きパスを返す。
+// パスは切り替え周期ごとに変わるので、クライアントは refresh_at を過ぎたら取り直す
+func (h *IdentityHandler) GetMySignedPassHandler(c *gin.Context) {
+	pass, ok := h.issueSignedPass(c)
+	if !ok {
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"payload":          pass.Payload,
+		"event_id":         pass.EventID,
+		"expires_at":       pass.ExpiresAt,
+		"refresh_at":       pass.RefreshAt,
+		"rotation_seconds": int(h.passSigner.Rotation() / time.Second),
+	})
+}
+
+// GetMySignedPassQRCodeHandler はログイン中のユーザーの署名付きパスを QR コードの PNG で返す
+func (h *IdentityHandler) GetMySignedPassQRCodeHandler(c *gin.Context) {
+	pass, ok := h.issueSignedPass(c)
+	if !ok {
+		return
+	}
+
+	qr, err := identity.EncodeQR(pass.Payload)
+	if err != nil {
+		log.Printf("EncodeQR error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+		return
+	}
+	image, err := qr.PNG(idPassQRCodeScale)
+	if err != nil {
+		log.Printf("QR code PNG error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate QR code"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Pass-Refresh-At", pass.RefreshAt.UTC().Format(time.RFC3339))
+	c.Data(http.StatusOK, "image/png", image)
+}
+
+func (h *IdentityHandler) issueSignedPass(c *gin.Context) (*identity.SignedPass, bool) {
+	if h.passSigner == nil || h.eventRepo == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "署名付きパスは利用できません"})
+		return nil, false
+	}
+	user, ok := identityOperator(c)
+	if !ok {
+		return nil, false
+	}
+
+	activeEventID, err := h.eventRepo.GetActiveEvent()
+	if err != nil {
+		log.Printf("GetActiveEvent error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get active event"})
+		return nil, false
+	}
+	if activeEventID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No active event found"})
+		return nil, false
+	}
+	return h.passSigner.Issue(user.ID, activeEventID), true
+}
+
+func (h *IdentityHandler) passOwner(c *gin.Context) (*models.User, bool) {
+	user, err := h.userRepo.GetUserWithRoles(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return nil, false
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return user, true
+}
+
+func identityOperator(c *gin.Context) (*models.User, bool) {
+	userCtx, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return nil, false
+	}
+	return userCtx.(*models.User), true
+}
+
+func isStudentNumberText(value string) bool {
+	for _, char := range value {
+		isAlphanumeric := char >= 'A' && char <= 'Z' || char >= 'a' && char <= 'z' || char >= '0' && char <= '9'
+		if !isAlphanumeric {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveStudent は barcode_data があれば読み取って本人を探し、なければ手動照合で選んだ user_id のユーザーを返す。
+// どちらもないとき・読めない形式のときは identity.ErrUnrecognized を返す。
+// 署名付きパスは eventID の大会のものでなければ identity.ErrPassEventMismatch を返す
+func resolveStudent(idParser *identity.Parser, identityRepo repository.IdentityRepository, userRepo repository.UserRepository, barcodeData string, userID string, eventID int) (*models.User, identity.Identifier, error) {
+	if barcodeData == "" && userID != "" {
+		user, err := userRepo.GetUserWithRoles(userID)
+		return user, identity.Identifier{Format: identity.FormatManual}, err
+	}
+
+	if idParser == nil {
+		idParser = identity.DefaultParser()
+	}
+	identifier, err := idParser.Parse(barcodeData)
+	if err != nil {
+		return nil, identifier, err
+	}
+
+	switch identifier.Kind {
+	case identity.KindUserID:
+		if identifier.EventID != eventID {
+			return nil, identifier, identity.ErrPassEventMismatch
+		}
+		user, err := userRepo.GetUserWithRoles(identifier.Value)
+		return user, identifier, err
+	case identity.KindStudentNumber:
+		if identityRepo != nil {
+			registeredUserID, err := identityRepo.FindUserIDByStudentNumber(identifier.Value)
+			if err != nil {
+				return nil, identifier, err
+			}
+			if registeredUserID != "" {
+				user, err := userRepo.GetUserWithRoles(registeredUserID)
+				return user, identifier, err
+			}
+		}
+		user, err := lookupUserByStudentNumber(userRepo, identifier.Value)
+		return user, identifier, err
+	case identity.KindPassToken:
+		if identityRepo == nil {
+			return nil, identifier, nil
+		}
+		passUserID, err := identityRepo.FindUserIDByPassToken(identifier.Value)
+		if err != nil || passUserID == "" {
+			return nil, identifier, err
+		}
+		user, err := userRepo.GetUserWithRoles(passUserID)
+		return user, identifier, err
+	default:
+		return nil, identifier, errors.New("unsupported identifier kind")
+	}
+}
+
+// scanErrorMessage は読み取ったデータを使えなかった理由を読み取り画面に出す文言にする。
+// 読み取りのエラーでなければ false を返す
+func scanErrorMessage(err error) (string, bool) {
+	switch {
+	case errors.Is(err, identity.ErrUnrecognized):
+		return "バーコード形式が不正です", true
+	case errors.Is(err, identity.ErrPassExpired):
+		return "QRコードの有効期限が切れています。画面を更新してもう一度読み取ってください", true
+	case errors.Is(err, identity.ErrPassInvalidSignature):
+		return "QRコードが正しくありません", true
+	case errors.Is(err, identity.ErrPassEventMismatch):
+		return "この大会のQRコードではありません", true
+	default:
+		return "", false
+	}
+}
+
+// identifiedStudentNumber は学籍番号で本人を特定したときだけ学籍番号を返す
+func identifiedStudentNumber(identifier identity.Identifier) string {
+	if identifier.Kind == identity.KindStudentNumber {
+		return identifier.Value
+	}
+	return ""
+}
+
+// lookupUserByStudentNumber は学籍番号からメールアドレスが s<学籍番号>@ の生徒を探す
+func lookupUserByStudentNumber(userRepo repository.UserRepository, studentNumber string) (*models.User, error) {
+	emailLocalPartForStudentNumber := "s" + studentNumber
+	users, err := userRepo.FindUsers(emailLocalPartForStudentNumber, "email")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		emailLocalPart := strings.SplitN(user.Email, "@", 2)[0]
+		if strings.EqualFold(emailLocalPart, emailLocalPartForStudentNumber) {
+			return user, nil
+		}
+	}
+
+	return nil, nil
+}
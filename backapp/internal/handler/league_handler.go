@@ -0,0 +1,352 @@
+package handler
+
+import (
+	"backapp/internal/models"
+	"backapp/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLeagueStandingsHandler は競技のリーグ戦順位表をグループごとに返す
+func (h *TournamentHandler) GetLeagueStandingsHandler(c *gin.Context) {
+	eventIDStr := c.Param("event_id")
+	if eventIDStr == "" {
+		eventIDStr = c.Param("id")
+	}
+	eventID, err := strconv.Atoi(eventIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	sportID, err := strconv.Atoi(c.Param("sport_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sport ID"})
+		return
+	}
+
+	standings, err := h.tournRepo.GetLeagueStandings(eventID, sportID)
+	if err != nil {
+		log.Printf("GetLeagueStandings error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve league standings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, standings)
+}
+
+// AdvanceLeagueToKnockoutHandler は全グループの試合終了後、各グループの上位チームを決勝トーナメントへ配置する
+func (h *TournamentHandler) AdvanceLeagueToKnockoutHandler(c *gin.Context) {
+	eventIDStr := c.Param("event_id")
+	if eventIDStr == "" {
+		eventIDStr = c.Param("id")
+	}
+	eventID, err := strconv.Atoi(eventIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	sportID, err := strconv.Atoi(c.Param("sport_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sport ID"})
+		return
+	}
+
+	eventSport, err := h.sportRepo.GetSportDetails(eventID, sportID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sport details"})
+		return
+	}
+	if eventSport.Format != models.SportFormatLeague || eventSport.LeagueAdvanceCount == nil || *eventSport.LeagueAdvanceCount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "この競技には決勝トーナメントが設定されていません"})
+		return
+	}
+	advanceCount := *eventSport.LeagueAdvanceCount
+
+	groups, err := h.tournRepo.GetLeagueStandings(eventID, sportID)
+	if err != nil {
+		log.Printf("GetLeagueStandings error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve league standings"})
+		return
+	}
+	if len(groups) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "リーグ戦の試合がありません"})
+		return
+	}
+
+	// 各グループの1位、2位…の順に並べたものをシードとする
+	seeds := make([]int, 0, len(groups)*advanceCount)
+	for rank := 0; rank < advanceCount; rank++ {
+		for _, group := range groups {
+			if !group.Completed {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リーグ%sグループの試合が終了していません", group.Group)})
+				return
+			}
+			if rank >= len(group.Standings) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("リーグ%sグループのチーム数が進出枠より少ないです", group.Group)})
+				return
+			}
+			seeds = append(seeds, group.Standings[rank].TeamID)
+		}
+	}
+	if len(seeds) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "決勝トーナメントには2チーム以上の進出が必要です"})
+		return
+	}
+
+	if err := h.tournRepo.AssignLeagueKnockoutTeams(eventID, sportID, seededKnockoutPairings(seeds), actorUserID(c)); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrLeagueKnockoutNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "決勝トーナメントが生成されていません"})
+		case errors.Is(err, repository.ErrLeagueKnockoutStarted):
+			c.JSON(http.StatusConflict, gin.H{"error": "決勝トーナメントは既に開始されています"})
+		case errors.Is(err, repository.ErrLeagueKnockoutSizeMismatch):
+			c.JSON(http.StatusConflict, gin.H{"error": "決勝トーナメントの枠が進出チーム数と合いません。トーナメントを生成し直してください"})
+		default:
+			log.Printf("AssignLeagueKnockoutTeams error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign knockout teams"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "League top teams advanced to the knockout successfully"})
+}
+
+// generateLeagueTournaments はリーグ形式の競技について、グループごとの総当たり戦と
+// 進出枠が設定されている場合は空の決勝トーナメントを生成する。
+// トーナメント形式と同じく、一回戦の時間帯に試合があるクラスのチームは除く。
+// チームはシャッフル後、グループへ蛇行順に振り分ける。
+func generateLeagueTournaments(eventID int, sport *models.Sport, teams []*models.Team, roundBusyClasses map[int]map[int]bool, groupCountSetting *int, advanceCountSetting *int) ([]models.GeneratedTournament, error) {
+	groupCount := 1
+	if groupCountSetting != nil && *groupCountSetting > 0 {
+		groupCount = *groupCountSetting
+	}
+	availableTeams := filterAvailableTeams(teams, roundBusyClasses)
+	if len(availableTeams) < groupCount*2 {
+		return nil, fmt.Errorf("not enough teams for %d league groups: %d", groupCount, len(availableTeams))
+	}
+
+	shuffledTeams, err := shuffleTeams(availableTeams)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([][]*models.Team, groupCount)
+	for i, team := range shuffledTeams {
+		pos := i % groupCount
+		if (i/groupCount)%2 == 1 {
+			pos = groupCount - 1 - pos
+		}
+		groups[pos] = append(groups[pos], team)
+	}
+
+	// 節の数はいちばん大きいグループに合わせる
+	numRounds := 0
+	for _, groupTeams := range groups {
+		numRounds = max(numRounds, len(groupTeams)+len(groupTeams)%2-1)
+	}
+	markClassesBusy(availableTeams, numRounds, roundBusyClasses)
+
+	generated := make([]models.GeneratedTournament, 0, groupCount+1)
+	for i, groupTeams := range groups {
+		group := string(rune('A' + i))
+		teamsSlice := make([]models.Team, len(groupTeams))
+		for j, t := range groupTeams {
+			teamsSlice[j] = *t
+		}
+		generated = append(generated, models.GeneratedTournament{
+			EventID:        eventID,
+			SportID:        sport.ID,
+			SportName:      fmt.Sprintf("%s Tournament - リーグ%sグループ", sport.Name, group),
+			TournamentData: *generateRoundRobinStructure(groupTeams, group),
+			ShuffledTeams:  teamsSlice,
+		})
+	}
+
+	if advanceCountSetting != nil && *advanceCountSetting > 0 {
+		knockoutSize := groupCount * *advanceCountSetting
+		if knockoutSize < 2 {
+			return nil, fmt.Errorf("knockout needs at least two teams: %d", knockoutSize)
+		}
+		for _, groupTeams := range groups {
+			if len(groupTeams) < *advanceCountSetting {
+				return nil, fmt.Errorf("league group has fewer teams than the advance count: %d", len(groupTeams))
+			}
+		}
+		knockout := generateEmptyKnockoutStructure(knockoutSize)
+		knockout.IsLeagueKnockout = true
+		generated = append(generated, models.GeneratedTournament{
+			EventID:        eventID,
+			SportID:        sport.ID,
+			SportName:      fmt.Sprintf("%s Tournament - 決勝トーナメント", sport.Name),
+			TournamentData: *knockout,
+			ShuffledTeams:  []models.Team{}, // 決勝トーナメントはリーグ戦終了後に決定
+		})
+	}
+
+	return generated, nil
+}
+
+// generateRoundRobinStructure はサークル方式で1グループ分の総当たり戦を生成する。
+// チーム数が奇数の場合は各節で1チームが休みになる。
+func generateRoundRobinStructure(teams []*models.Team, group string) *models.TournamentData {
+	contestants := make(map[string]models.Contestant, len(teams))
+	slots := make([]int, 0, len(teams)+1)
+	for i, team := range teams {
+		contestants["c"+strconv.Itoa(i)] = models.Contestant{
+			Players: []models.Player{{Title: team.Name}},
+		}
+		slots = append(slots, i)
+	}
+	if len(slots)%2 == 1 {
+		slots = append(slots, -1) // 休み
+	}
+
+	numRounds := len(slots) - 1
+	rounds := make([]models.Round, numRounds)
+	matches := make([]models.Match, 0, numRounds*len(slots)/2)
+	for roundIndex := 0; roundIndex < numRounds; roundIndex++ {
+		rounds[roundIndex] = models.Round{Name: fmt.Sprintf("第%d節", roundIndex+1)}
+
+		order := 0
+		for k := 0; k < len(slots)/2; k++ {
+			home, away := slots[k], slots[len(slots)-1-k]
+			if home < 0 || away < 0 {
+				continue
+			}
+			matches = append(matches, models.Match{
+				RoundIndex:    roundIndex,
+				Order:         order,
+				IsLeagueMatch: true,
+				LeagueGroup:   group,
+				Sides: []models.Side{
+					{ContestantID: "c" + strconv.Itoa(home)},
+					{ContestantID: "c" + strconv.Itoa(away)},
+				},
+			})
+			order++
+		}
+
+		// 先頭を固定して残りを1つずつ回転させる
+		last := slots[len(slots)-1]
+		copy(slots[2:], slots[1:len(slots)-1])
+		slots[1] = last
+	}
+
+	return &models.TournamentData{
+		Rounds:      rounds,
+		Matches:     matches,
+		Contestants: contestants,
+	}
+}
+
+// generateEmptyKnockoutStructure は出場チーム未定の決勝トーナメントを生成する。
+// 進出チーム数が2の累乗でない場合はトーナメント形式と同じく次の2の累乗の枠に不戦勝(BYE)を補う。
+// 不戦勝は上位シードに割り当てるため、seededKnockoutPairings で空き枠と当たる試合を不戦勝にする。
+func generateEmptyKnockoutStructure(size int) *models.TournamentData {
+	bracketSize := knockoutBracketSize(size)
+	numByes := bracketSize - size
+	numRounds := 0
+	for (1 << numRounds) < bracketSize {
+		numRounds++
+	}
+
+	rounds := make([]models.Round, numRounds)
+	for i, name := range knockoutRoundNames(numRounds) {
+		rounds[i] = models.Round{Name: name}
+	}
+
+	byeMatchOrders := make(map[int]bool, numByes)
+	for _, order := range knockoutByeMatchOrders(size) {
+		byeMatchOrders[order] = true
+	}
+
+	matches := []models.Match{}
+	numMatchesInRound := bracketSize
+	for roundIndex := 0; roundIndex < numRounds; roundIndex++ {
+		numMatchesInRound /= 2
+		for order := 0; order < numMatchesInRound; order++ {
+			matches = append(matches, models.Match{
+				RoundIndex: roundIndex,
+				Order:      order,
+				IsBye:      roundIndex == 0 && byeMatchOrders[order],
+				Sides:      []models.Side{{}, {}},
+			})
+		}
+	}
+	// 準決勝に不戦勝がある場合（3チーム）は敗者が1チームしか出ないため3位決定戦を作らない
+	if numRounds > 2 || (numRounds == 2 && numByes == 0) {
+		matches = append(matches, models.Match{
+			RoundIndex:    numRounds - 1,
+			Order:         1,
+			IsBronzeMatch: true,
+			Sides:         []models.Side{{}, {}},
+		})
+	}
+
+	return &models.TournamentData{
+		Rounds:      rounds,
+		Matches:     matches,
+		Contestants: map[string]models.Contestant{},
+	}
+}
+
+// seededKnockoutPairings はシード順のチームIDを一回戦の組み合わせに変換する。
+// 上位シード同士が決勝まで当たらないよう、標準的なシード配置で並べる。
+// 枠に満たないシードと当たる上位シードは不戦勝とし、相手のチームIDを0にする。
+func seededKnockoutPairings(seeds []int) [][2]int {
+	positions := knockoutSeedPositions(knockoutBracketSize(len(seeds)))
+
+	pairings := make([][2]int, 0, len(positions)/2)
+	for i := 0; i+1 < len(positions); i += 2 {
+		first, second := positions[i], positions[i+1]
+		switch {
+		case second >= len(seeds):
+			pairings = append(pairings, [2]int{seeds[first], 0})
+		case first >= len(seeds):
+			pairings = append(pairings, [2]int{seeds[second], 0})
+		default:
+			pairings = append(pairings, [2]int{seeds[first], seeds[second]})
+		}
+	}
+	return pairings
+}
+
+// knockoutByeMatchOrders は teamCount チームの決勝トーナメントで不戦勝になる一回戦の試合番号を返す
+func knockoutByeMatchOrders(teamCount int) []int {
+	positions := knockoutSeedPositions(knockoutBracketSize(teamCount))
+
+	orders := []int{}
+	for i := 0; i+1 < len(positions); i += 2 {
+		if positions[i] >= teamCount || positions[i+1] >= teamCount {
+			orders = append(orders, i/2)
+		}
+	}
+	return orders
+}
+
+// knockoutSeedPositions は bracketSize の枠に並べるシード番号（0始まり）を一回戦の順に返す
+func knockoutSeedPositions(bracketSize int) []int {
+	positions := []int{0}
+	for len(positions) < bracketSize {
+		next := make([]int, 0, len(positions)*2)
+		for _, p := range positions {
+			next = append(next, p, len(positions)*2-1-p)
+		}
+		positions = next
+	}
+	return positions
+}
+
+// knockoutBracketSize は teamCount チームが収まる2の累乗の枠の大きさを返す
+func knockoutBracketSize(teamCount int) int {
+	bracketSize := 1
+	for bracketSize < teamCount {
+		bracketSize <<= 1
+	}
+	return bracketSize
+}
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"backapp/internal/models"
+	"backapp/internal/push"
+	"backapp/internal/repository"
+
+	"github.com/bytedance/sonic"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	matchNotifierInterval      = time.Minute
+	matchStartNotificationTTL  = 10 * 60
+	matchResultNotificationTTL = 60 * 60
+)
+
+// MatchNotifier は試合データをもとに Push 通知を自動で送る。
+// 試合開始の少し前に両チームのクラスへ知らせ、結果が入ったらクラスと決勝の通知を受け取る利用者へ知らせる。
+type MatchNotifier struct {
+	eventRepo        repository.EventRepository
+	matchRepo        repository.MatchNotificationRepository
+	notificationRepo repository.NotificationRepository
+	pushSender       push.Sender
+	pushOutbox       *PushOutbox
+	now              func() time.Time
+}
+
+func NewMatchNotifier(eventRepo repository.EventRepository, matchRepo repository.MatchNotificationRepository, notificationRepo repository.NotificationRepository, pushSender push.Sender) *MatchNotifier {
+	return &MatchNotifier{
+		eventRepo:        eventRepo,
+		matchRepo:        matchRepo,
+		notificationRepo: notificationRepo,
+		pushSender:       pushSender,
+		now:              time.Now,
+	}
+}
+
+// WithPushOutbox は Push 通知を送信キュー経由で送るようにする
+func (n *MatchNotifier) WithPushOutbox(outbox *PushOutbox) *MatchNotifier {
+	n.pushOutbox = outbox
+	return n
+}
+
+// WithClock は現在時刻の取得方法を差し替える
+func (n *MatchNotifier) WithClock(now func() time.Time) *MatchNotifier {
+	n.now = now
+	return n
+}
+
+// Run は ctx が終わるまで1分ごとに開始が近い試合を調べる
+func (n *MatchNotifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(matchNotifierInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := n.CheckUpcomingMatches(); err != nil {
+				log.Printf("[match-notification] 試合開始通知の確認に失敗しました: %v\n", err)
+			}
+		}
+	}
+}
+
+// CheckUpcomingMatches はアクティブなイベントで MatchStartReminderLead 以内に始まる試合を、開始時刻ごとに1回だけ通知する。
+// 雨天時は雨天用の開始時刻を使う。
+func (n *MatchNotifier) CheckUpcomingMatches() error {
+	if n.pushSender == nil || !n.pushSender.Enabled() {
+		return nil
+	}
+
+	eventID, err := n.eventRepo.GetActiveEvent()
+	if err != nil || eventID == 0 {
+		return err
+	}
+	event, err := n.eventRepo.GetEventByID(eventID)
+	if err != nil || event == nil {
+		return err
+	}
+
+	matches, err := n.matchRepo.GetUpcomingMatchStarts(eventID, event.IsRainyMode)
+	if err != nil {
+		return err
+	}
+
+	now := n.now().In(jstLocation)
+	for _, m := range matches {
+		// 試合の開始時刻は日本時間の時刻として保存されている
+		startsAt := time.Date(m.StartTime.Year(), m.StartTime.Month(), m.StartTime.Day(), m.StartTime.Hour(), m.StartTime.Minute(), m.StartTime.Second(), 0, jstLocation)
+		untilStart := startsAt.Sub(now)
+		if untilStart <= 0 || untilStart > models.MatchStartReminderLead {
+			continue
+		}
+
+		claimed, err := n.matchRepo.ClaimMatchStartNotification(m.MatchID, *m.StartTime)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		n.notifyMatchStart(m, int(math.Ceil(untilStart.Minutes())))
+	}
+	return nil
+}
+
+// MatchFinished は結果が入った試合を両チームのクラスへ知らせる。決勝なら決勝の通知を受け取る利用者にも知らせる。
+func (n *MatchNotifier) MatchFinished(matchID int) {
+	if n.pushSender == nil || !n.pushSender.Enabled() {
+		return
+	}
+
+	m, err := n.matchRepo.GetMatchNotification(matchID)
+	if err != nil {
+		log.Printf("[match-notification] 試合情報の取得に失敗しました: matchID=%d, error=%v\n", matchID, err)
+		return
+	}
+	if m == nil || m.Status != "finished" {
+		return
+	}
+
+	body := fmt.Sprintf("%s %s", m.SportName, matchResultText(m))
+	classRecipients := n.send(m, m.ClassIDs, models.NotificationTypeMatchMyClass, "試合結果", body, matchResultNotificationTTL, nil)
+	if m.IsFinal {
+		finalsBody := fmt.Sprintf("%s 決勝 %s", m.SportName, matchResultText(m))
+		if m.WinnerName != "" {
+			finalsBody = fmt.Sprintf("%s は %s が優勝しました（%s）", m.SportName, m.WinnerName, matchScoreText(m))
+		}
+		n.send(m, nil, models.NotificationTypeFinals, "決勝の結果", finalsBody, matchResultNotificationTTL, classRecipients)
+	}
+}
+
+func (n *MatchNotifier) notifyMatchStart(m *models.MatchNotification, minutes int) {
+	place := m.Location
+	if m.Court != "" {
+		place = fmt.Sprintf("%s コート%s", m.Location, m.Court)
+	}
+	matchup := fmt.Sprintf("%s vs %s", matchTeamName(m.Team1Name), matchTeamName(m.Team2Name))
+
+	body := fmt.Sprintf("%s %s は%d分後に%sで始まります", m.SportName, matchup, minutes, place)
+	classRecipients := n.send(m, m.ClassIDs, models.NotificationTypeMatchMyClass, "まもなくクラスの試合です", body, matchStartNotificationTTL, nil)
+	if m.IsFinal {
+		finalsBody := fmt.Sprintf("%s 決勝 %s は%d分後に%sで始まります", m.SportName, matchup, minutes, place)
+		n.send(m, nil, models.NotificationTypeFinals, "まもなく決勝です", finalsBody, matchStartNotificationTTL, classRecipients)
+	}
+}
+
+// send は通知の種類を受け取る設定の利用者へ Push を送り、送った利用者を返す。
+// classIDs を指定した場合はそのクラスの利用者だけに送る。exclude の利用者には送らない。
+func (n *MatchNotifier) send(m *models.MatchNotification, classIDs []int, notificationType, title, body string, ttl int, exclude map[string]bool) map[string]bool {
+	recipients := make(map[string]bool)
+	if notificationType == models.NotificationTypeMatchMyClass && len(classIDs) == 0 {
+		return recipients
+	}
+
+	userIDs, err := n.matchRepo.GetUserIDsByNotificationType(classIDs, notificationType)
+	if err != nil {
+		log.Printf("[match-notification] ユーザー抽出に失敗しました: matchID=%d, type=%s, error=%v\n", m.MatchID, notificationType, err)
+		return recipients
+	}
+	targetIDs := make([]string, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if exclude[userID] {
+			continue
+		}
+		recipients[userID] = true
+		targetIDs = append(targetIDs, userID)
+	}
+	if len(targetIDs) == 0 {
+		return recipients
+	}
+
+	subs, err := n.notificationRepo.GetPushSubscriptionsByUserIDs(targetIDs)
+	if err != nil {
+		log.Printf("[match-notification] 購読情報の取得に失敗しました: matchID=%d, error=%v\n", m.MatchID, err)
+		return recipients
+	}
+
+	payload, err := sonic.Marshal(gin.H{
+		"title": title,
+		"body":  body,
+		"data": gin.H{
+			"matchId": m.MatchID,
+			"type":    notificationType,
+		},
+	})
+	if err != nil {
+		log.Printf("[match-notification] Pushペイロード生成に失敗しました: %v\n", err)
+		return recipients
+	}
+
+	deliverPush(n.pushOutbox, n.pushSender, n.notificationRepo, nil, payload, subs, ttl, "match-notification")
+	return recipients
+}
+
+// matchResultText は「1A 3 - 1 2B（1A の勝ち）」の形で結果を返す
+func matchResultText(m *models.MatchNotification) string {
+	text := matchScoreText(m)
+	if m.WinnerName != "" {
+		text += fmt.Sprintf("（%s の勝ち）", m.WinnerName)
+	}
+	return text
+}
+
+// matchScoreText は対戦とスコアを返す。不戦勝や棄権は種別名を付ける。
+func matchScoreText(m *models.MatchNotification) string {
+	team1, team2 := matchTeamName(m.Team1Name), matchTeamName(m.Team2Name)
+	text := fmt.Sprintf("%s vs %s", team1, team2)
+	if m.Team1Score != nil && m.Team2Score != nil && models.MatchResultKeepsScores(m.ResultType) {
+		text = fmt.Sprintf("%s %d - %d %s", team1, *m.Team1Score, *m.Team2Score, team2)
+	}
+	if label := models.MatchResultLabel(m.ResultType); label != "" {
+		text += " " + label
+	}
+	return text
+}
+
+func matchTeamName(name string) string {
+	if name == "" {
+		return "未定"
+	}
+	return name
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"backapp/internal/repository"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMatchRevisionsHandler は試合の変更履歴を、誰がどの操作で変更したかを含めて古い順に返す
+func (h *TournamentHandler) GetMatchRevisionsHandler(c *gin.Context) {
+	matchID, err := strconv.Atoi(c.Param("match_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	revisions, err := h.tournRepo.GetMatchRevisions(matchID)
+	if err != nil {
+		log.Printf("GetMatchRevisions error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get match revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"match_id": matchID, "revisions": revisions})
+}
+
+// RestoreMatchRevisionHandler は試合をリビジョンの時点の結果に戻す。
+// 後の変更で書き換わった後続の試合と得点も合わせて戻るため、戻した試合をすべて配信し直す。
+func (h *TournamentHandler) RestoreMatchRevisionHandler(c *gin.Context) {
+	matchID, err := strconv.Atoi(c.Param("match_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+	revisionID, err := strconv.Atoi(c.Param("revision_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision ID"})
+		return
+	}
+
+	result, err := h.tournRepo.RestoreMatchRevision(matchID, revisionID, actorUserID(c))
+	if err != nil {
+		if errors.Is(err, repository.ErrMatchRevisionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Match revision not found"})
+			return
+		}
+		log.Printf("RestoreMatchRevision error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore match revision"})
+		return
+	}
+
+	broadcasted := make(map[int]bool)
+	for _, id := range result.RestoredMatchIDs {
+		tournamentID, err := h.tournRepo.GetTournamentIDByMatchID(id)
+		if err == nil && h.hubManager != nil && !broadcasted[tournamentID] {
+			broadcasted[tournamentID] = true
+			h.hubManager.BroadcastTo("tournament:"+strconv.Itoa(tournamentID), gin.H{"type": "update"})
+		}
+		if h.scoreboard != nil {
+			h.scoreboard.MatchUpdated(id)
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -7,13 +7,15 @@ import (
 
 	"backapp/internal/models"
 	"backapp/internal/repository"
+	"backapp/internal/scoreboard"
 
 	"github.com/gin-gonic/gin"
 )
 
 type MICHandler struct {
-	micRepo   repository.MICRepository
-	eventRepo repository.EventRepository
+	micRepo    repository.MICRepository
+	eventRepo  repository.EventRepository
+	scoreboard *scoreboard.Feed
 }
 
 func NewMICHandler(micRepo repository.MICRepository, eventRepo repository.EventRepository) *MICHandler {
@@ -23,6 +25,12 @@ func NewMICHandler(micRepo repository.MICRepository, eventRepo repository.EventR
 	}
 }
 
+// WithScoreboard はMIC投票による得点の変更を会場のスコアボードにも配信する
+func (h *MICHandler) WithScoreboard(feed *scoreboard.Feed) *MICHandler {
+	h.scoreboard = feed
+	return h
+}
+
 func (h *MICHandler) ensureMICVotingEnabled(c *gin.Context, eventID int) bool {
 	event, err := h.eventRepo.GetEventByID(eventID)
 	if err != nil {
@@ -97,6 +105,9 @@ func (h *MICHandler) VoteMIC(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 		return
 	}
+	if h.scoreboard != nil {
+		h.scoreboard.ClassScoresChanged(req.EventID)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "vote successful"})
 }
@@ -3,6 +3,7 @@ package handler
 import (
 	"backapp/internal/models"
 	"backapp/internal/repository"
+	"backapp/internal/scoreboard"
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
@@ -23,10 +24,12 @@ import (
 )
 
 type NoonGameHandler struct {
-	noonRepo  repository.NoonGameRepository
-	classRepo repository.ClassRepository
-	eventRepo repository.EventRepository
-	sportRepo repository.SportRepository
+	noonRepo   repository.NoonGameRepository
+	classRepo  repository.ClassRepository
+	eventRepo  repository.EventRepository
+	sportRepo  repository.SportRepository
+	eventDays  repository.EventDayRepository
+	scoreboard *scoreboard.Feed
 }
 
 var jstLocation = func() *time.Location {
@@ -93,6 +96,18 @@ func (h *NoonGameHandler) WithSportSync(sportRepo repository.SportRepository) *N
 	return h
 }
 
+// WithScoreboard は昼競技の得点を会場のスコアボードにも配信する
+func (h *NoonGameHandler) WithScoreboard(feed *scoreboard.Feed) *NoonGameHandler {
+	h.scoreboard = feed
+	return h
+}
+
+// WithEventDays はセッションの開催日が雨天時モードのときも試合結果の記録を止める
+func (h *NoonGameHandler) WithEventDays(eventDays repository.EventDayRepository) *NoonGameHandler {
+	h.eventDays = eventDays
+	return h
+}
+
 func (h *NoonGameHandler) syncNoonGameSport(eventID int, sessionName string) error {
 	if h == nil || h.sportRepo == nil {
 		return nil
@@ -1298,7 +1313,7 @@ func (h *NoonGameHandler) DeleteSession(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete noon game session"})
 		return
 	}
-	if err := h.rebuildNoonGameScores(session.EventID); err != nil {
+	if err := h.rebuildNoonGameScores(session.EventID, actorUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rebuild class scores"})
 		return
 	}
@@ -1402,7 +1417,7 @@ func (h *NoonGameHandler) UpsertSession(c *gin.Context) {
 	// A newly created draft has no official score impact. State transitions to
 	// finalized/published are the point at which it becomes part of the event total.
 	if updated.Status == "finalized" || updated.Status == "published" {
-		if err := h.rebuildNoonGameScores(eventID); err != nil {
+		if err := h.rebuildNoonGameScores(eventID, actorUserID(c)); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rebuild class scores"})
 			return
 		}
@@ -1435,14 +1450,18 @@ func isStudentRequest(c *gin.Context) bool {
 	return false
 }
 
-func (h *NoonGameHandler) rebuildNoonGameScores(eventID int) error {
+func (h *NoonGameHandler) rebuildNoonGameScores(eventID int, actorUserID string) error {
 	points, err := h.noonRepo.SumConfirmedPointsByEvent(eventID)
 	if err != nil {
 		return fmt.Errorf("failed to aggregate confirmed points: %w", err)
 	}
-	if err := h.classRepo.SetNoonGamePoints(eventID, points); err != nil {
+	if err := h.classRepo.SetNoonGamePoints(eventID, points, actorUserID); err != nil {
 		return fmt.Errorf("failed to update class scores: %w", err)
 	}
+	if h.scoreboard != nil {
+		h.scoreboard.NoonGamePointsChanged(eventID, points)
+		h.scoreboard.ClassScoresChanged(eventID)
+	}
 	return nil
 }
 
@@ -2022,6 +2041,12 @@ func (h *NoonGameHandler) RecordMatchResult(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "雨天時モードでは、昼競技の試合結果を記録できません"})
 		return
 	}
+	if h.eventDays != nil {
+		if rainy, err := h.eventDays.IsNoonSessionRainy(session.ID); err == nil && rainy {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "雨天時モードでは、昼競技の試合結果を記録できません"})
+			return
+		}
+	}
 
 	var req recordNoonMatchResultRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -2211,7 +2236,7 @@ func (h *NoonGameHandler) RecordMatchResult(c *gin.Context) {
 		return
 	}
 
-	if err := h.rebuildNoonGameScores(session.EventID); err != nil {
+	if err := h.rebuildNoonGameScores(session.EventID, actorUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update class scores"})
 		return
 	}
@@ -2298,7 +2323,7 @@ func (h *NoonGameHandler) AddManualPoint(c *gin.Context) {
 		return
 	}
 
-	if err := h.rebuildNoonGameScores(session.EventID); err != nil {
+	if err := h.rebuildNoonGameScores(session.EventID, actorUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update class scores"})
 		return
 	}
@@ -2644,7 +2669,7 @@ func (h *NoonGameHandler) ImportTypingSystemResults(c *gin.Context) {
 		return
 	}
 
-	if err := h.rebuildNoonGameScores(session.EventID); err != nil {
+	if err := h.rebuildNoonGameScores(session.EventID, actorUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update class scores"})
 		return
 	}
@@ -3395,7 +3420,7 @@ func (h *NoonGameHandler) applyYearRelayRankingsToMatch(
 	}
 
 	// クラス得点へ反映
-	if err := h.rebuildNoonGameScores(session.EventID); err != nil {
+	if err := h.rebuildNoonGameScores(session.EventID, actorUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update class scores"})
 		return
 	}
@@ -3851,7 +3876,7 @@ func (h *NoonGameHandler) calculateAndRecordYearRelayOverallBonus(runID int, use
 	}
 
 	// クラス得点へ反映
-	if err := h.rebuildNoonGameScores(session.EventID); err != nil {
+	if err := h.rebuildNoonGameScores(session.EventID, user.ID); err != nil {
 		return err
 	}
 
@@ -4148,7 +4173,7 @@ func (h *NoonGameHandler) applyCourseRelayRankingsToMatch(
 	}
 
 	// クラス得点へ反映
-	if err := h.rebuildNoonGameScores(session.EventID); err != nil {
+	if err := h.rebuildNoonGameScores(session.EventID, actorUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update class scores"})
 		return
 	}
@@ -4429,7 +4454,7 @@ func (h *NoonGameHandler) applyTugOfWarRankingsToMatch(
 	}
 
 	// クラス得点へ反映
-	if err := h.rebuildNoonGameScores(session.EventID); err != nil {
+	if err := h.rebuildNoonGameScores(session.EventID, actorUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update class scores"})
 		return
 	}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"backapp/internal/mail"
 	"backapp/internal/models"
 	"backapp/internal/push"
 	"backapp/internal/repository"
@@ -24,6 +25,10 @@ type NotificationHandler struct {
 	RoleRepo         repository.RoleRepository
 	UserRepo         repository.UserRepository
 	PushSender       push.Sender
+	PushOutbox       *PushOutbox
+	MailSender       mail.Sender
+	Scheduler        *NotificationScheduler
+	ScheduledRepo    repository.ScheduledNotificationRepository
 }
 
 func NewNotificationHandler(notificationRepo repository.NotificationRepository, eventRepo repository.EventRepository, roleRepo repository.RoleRepository, userRepo repository.UserRepository, vapidPublicKey, vapidPrivateKey string) *NotificationHandler {
@@ -44,11 +49,25 @@ func (h *NotificationHandler) WithPushSender(sender push.Sender) *NotificationHa
 	return h
 }
 
+func (h *NotificationHandler) WithPushOutbox(outbox *PushOutbox) *NotificationHandler {
+	h.PushOutbox = outbox
+	return h
+}
+
+// WithMailSender は Push 購読のない利用者へのメール送信に使う Sender を設定する
+func (h *NotificationHandler) WithMailSender(sender mail.Sender) *NotificationHandler {
+	h.MailSender = sender
+	return h
+}
+
 type createNotificationRequest struct {
-	Title       string   `json:"title"`
-	Body        string   `json:"body"`
-	Type        string   `json:"type"`
-	TargetRoles []string `json:"target_roles"`
+	Title       string                      `json:"title"`
+	Body        string                      `json:"body"`
+	Type        string                      `json:"type"`
+	TargetRoles []string                    `json:"target_roles"`
+	Targets     []models.NotificationTarget `json:"targets"`
+	// Channel は "push"（既定）または "push_email_fallback"
+	Channel string `json:"channel"`
 }
 
 func (h *NotificationHandler) CreateNotification(c *gin.Context) {
@@ -58,12 +77,72 @@ func (h *NotificationHandler) CreateNotification(c *gin.Context) {
 		return
 	}
 
+	userValue, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザー情報を取得できませんでした"})
+		return
+	}
+
+	user, ok := userValue.(*models.User)
+	if !ok || user == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザー情報の解析に失敗しました"})
+		return
+	}
+
+	draft, err := h.prepareNotification(req)
+	if err != nil {
+		writeNotificationError(c, err)
+		return
+	}
+
+	notificationID, err := h.publishNotification(draft, user.ID)
+	if err != nil {
+		writeNotificationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":        "通知を作成しました。Push通知は通知を有効化済みのユーザーに送信されます",
+		"notificationId": notificationID,
+	})
+}
+
+// notificationError は通知の作成に失敗したときに返すステータスとメッセージ
+type notificationError struct {
+	status  int
+	message string
+}
+
+func (e *notificationError) Error() string {
+	return e.message
+}
+
+func writeNotificationError(c *gin.Context, err error) {
+	var nerr *notificationError
+	if errors.As(err, &nerr) {
+		c.JSON(nerr.status, gin.H{"error": nerr.message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "通知の作成に失敗しました"})
+}
+
+// notificationDraft は検証・正規化済みの通知の内容
+type notificationDraft struct {
+	Title       string
+	Body        string
+	Type        string
+	TargetRoles []string
+	Targets     []models.NotificationTarget
+	Channel     string
+}
+
+// prepareNotification は通知の内容を検証・正規化する。CreateNotification と通知申請の承認で共通に使う。
+func (h *NotificationHandler) prepareNotification(req createNotificationRequest) (*notificationDraft, error) {
 	req.Title = strings.TrimSpace(req.Title)
 	req.Body = strings.TrimSpace(req.Body)
 
 	if req.Title == "" || req.Body == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "タイトルと本文は必須です"})
-		return
+		return nil, &notificationError{http.StatusBadRequest, "タイトルと本文は必須です"}
 	}
 
 	// Validate notification type
@@ -79,38 +158,49 @@ func (h *NotificationHandler) CreateNotification(c *gin.Context) {
 		}
 	}
 	if !isValidType {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な通知タイプです"})
-		return
-	}
-
-	userValue, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザー情報を取得できませんでした"})
-		return
+		return nil, &notificationError{http.StatusBadRequest, "無効な通知タイプです"}
 	}
 
-	user, ok := userValue.(*models.User)
-	if !ok || user == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザー情報の解析に失敗しました"})
-		return
+	channel, err := normalizeNotificationChannel(req.Channel)
+	if err != nil {
+		return nil, &notificationError{http.StatusBadRequest, err.Error()}
 	}
 
-	availableRoles, err := h.RoleRepo.GetAllRoles()
+	targets, err := normalizeNotificationTargets(req.Targets)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "ロール情報の取得に失敗しました"})
-		return
+		return nil, &notificationError{http.StatusBadRequest, err.Error()}
 	}
 
-	targetRoles, err := normalizeTargetRoles(req.TargetRoles, availableRoles)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	// クラスやチームなどの宛先だけを指定した場合、ロールは指定しなくてよい
+	var targetRoles []string
+	if len(req.TargetRoles) > 0 || len(targets) == 0 {
+		availableRoles, err := h.RoleRepo.GetAllRoles()
+		if err != nil {
+			return nil, &notificationError{http.StatusInternalServerError, "ロール情報の取得に失敗しました"}
+		}
+
+		targetRoles, err = normalizeTargetRoles(req.TargetRoles, availableRoles)
+		if err != nil {
+			return nil, &notificationError{http.StatusBadRequest, err.Error()}
+		}
 	}
 
+	return &notificationDraft{
+		Title:       req.Title,
+		Body:        req.Body,
+		Type:        req.Type,
+		TargetRoles: targetRoles,
+		Targets:     targets,
+		Channel:     channel,
+	}, nil
+}
+
+// publishNotification は通知を作成して対象ロール・宛先を登録し、Push 通知を送る。
+// Push 通知の配信は通知と同じトランザクションで送信キューに登録するので、返った時点で取りこぼしはない。
+func (h *NotificationHandler) publishNotification(draft *notificationDraft, createdBy string) (int64, error) {
 	activeEventID, err := h.EventRepo.GetActiveEvent()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "アクティブな大会情報の取得に失敗しました"})
-		return
+		return 0, &notificationError{http.StatusInternalServerError, "アクティブな大会情報の取得に失敗しました"}
 	}
 
 	var eventIDPtr *int
@@ -118,23 +208,49 @@ func (h *NotificationHandler) CreateNotification(c *gin.Context) {
 		eventIDPtr = &activeEventID
 	}
 
-	notificationID, err := h.NotificationRepo.CreateNotification(req.Title, req.Body, req.Type, user.ID, eventIDPtr)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "通知の作成に失敗しました"})
-		return
+	var emailFallback mailBuilder
+	if draft.Channel == NotificationChannelPushEmailFallback {
+		title, body := draft.Title, draft.Body
+		emailFallback = func(to string) (mail.Message, error) {
+			return mail.NotificationMessage(to, title, body, "")
+		}
 	}
 
-	if err := h.NotificationRepo.AddNotificationTargets(notificationID, targetRoles); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "通知の対象ロール登録に失敗しました"})
-		return
-	}
+	subs, mailUserIDs, err := h.resolvePushRecipients(draft.TargetRoles, draft.Targets, eventIDPtr, draft.Type, emailFallback)
+	if err != nil {
+		log.Printf("[notification] 通知の対象者の取得に失敗しました: %v\n", err)
+		return 0, &notificationError{http.StatusInternalServerError, "通知の対象者の取得に失敗しました"}
+	}
+
+	title, body := draft.Title, draft.Body
+	message := newPushMessage("notification", subs, 60, func(notificationID int) ([]byte, error) {
+		return sonic.Marshal(gin.H{
+			"title": title,
+			"body":  body,
+			"data": gin.H{
+				"notificationId": notificationID,
+			},
+		})
+	})
 
-	go h.dispatchPushNotifications(int(notificationID), req.Title, req.Body, req.Type, targetRoles)
+	notificationID, err := h.NotificationRepo.PublishNotification(models.NewNotification{
+		Title:       draft.Title,
+		Body:        draft.Body,
+		Type:        draft.Type,
+		CreatedBy:   createdBy,
+		EventID:     eventIDPtr,
+		TargetRoles: draft.TargetRoles,
+		Targets:     draft.Targets,
+	}, queuedPushMessage(h.PushOutbox, message))
+	if err != nil {
+		return 0, &notificationError{http.StatusInternalServerError, "通知の作成に失敗しました"}
+	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message":        "通知を作成しました。Push通知は通知を有効化済みのユーザーに送信されます",
-		"notificationId": notificationID,
-	})
+	publishPush(h.PushOutbox, h.PushSender, h.NotificationRepo, message, int(notificationID))
+	if len(mailUserIDs) > 0 {
+		go sendEmailFallback(h.MailSender, h.NotificationRepo, mailUserIDs, emailFallback, "notification")
+	}
+	return notificationID, nil
 }
 
 func (h *NotificationHandler) ListNotifications(c *gin.Context) {
@@ -212,6 +328,28 @@ func (h *NotificationHandler) GetPushSubscriptionStats(c *gin.Context) {
 	})
 }
 
+// GetPushDeliveryStats は通知の Push 配信状況（送信済み・失敗・失効・送信待ち）を返す
+func (h *NotificationHandler) GetPushDeliveryStats(c *gin.Context) {
+	notificationID, err := strconv.Atoi(c.Param("notification_id"))
+	if err != nil || notificationID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な通知IDです"})
+		return
+	}
+	if h.PushOutbox == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Push通知の配信記録を利用できません"})
+		return
+	}
+
+	stats, err := h.PushOutbox.DeliveryStats(notificationID)
+	if err != nil {
+		log.Printf("GetPushDeliveryStats error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "配信状況の取得に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
+
 type updateNotificationFiltersRequest struct {
 	Filters []string `json:"filters"`
 }
@@ -406,63 +544,109 @@ func (h *NotificationHandler) GetSubscription(c *gin.Context) {
 	})
 }
 
-func (h *NotificationHandler) dispatchPushNotifications(notificationID int, title, body, notificationType string, targetRoles []string) {
-	log.Printf("[notification] 通知送信開始: notificationID=%d, title=%s, type=%s, targetRoles=%v\n", notificationID, title, notificationType, targetRoles)
-
-	if h.PushSender == nil || !h.PushSender.Enabled() {
+// resolvePushRecipients は通知を受け取る利用者の Push 購読を返す。
+// emailFallback があれば、購読のない利用者をメールで送る相手として返す。
+func (h *NotificationHandler) resolvePushRecipients(targetRoles []string, targets []models.NotificationTarget, eventID *int, notificationType string, emailFallback mailBuilder) ([]models.PushSubscription, []string, error) {
+	pushEnabled := h.PushSender != nil && h.PushSender.Enabled()
+	mailEnabled := emailFallback != nil && h.MailSender != nil && h.MailSender.Enabled()
+	if !pushEnabled && !mailEnabled {
 		log.Println("[notification] VAPIDキーが設定されていないためPush通知をスキップします")
-		return
+		return nil, nil, nil
 	}
 
 	userIDs, err := h.NotificationRepo.GetUserIDsByRoles(targetRoles)
 	if err != nil {
-		log.Printf("[notification] ユーザー抽出に失敗しました: %v\n", err)
-		return
+		return nil, nil, err
+	}
+	if len(targets) > 0 {
+		targetUserIDs, err := h.NotificationRepo.GetUserIDsByTargets(eventID, targets)
+		if err != nil {
+			return nil, nil, err
+		}
+		userIDs = mergeUserIDs(userIDs, targetUserIDs)
 	}
 	log.Printf("[notification] 対象ユーザー数: %d, userIDs=%v\n", len(userIDs), userIDs)
 	if len(userIDs) == 0 {
 		log.Println("[notification] 対象ユーザーが0人のためPush通知をスキップします")
-		return
+		return nil, nil, nil
 	}
 
 	// Apply notification filters
 	filteredUserIDs, err := h.filterUsersByNotificationType(userIDs, notificationType)
 	if err != nil {
-		log.Printf("[notification] 通知フィルタ適用に失敗しました: %v\n", err)
-		return
+		return nil, nil, err
 	}
 	log.Printf("[notification] フィルタ適用後ユーザー数: %d, filteredUserIDs=%v\n", len(filteredUserIDs), filteredUserIDs)
 	if len(filteredUserIDs) == 0 {
 		log.Println("[notification] フィルタ適用後対象ユーザーが0人のためPush通知をスキップします")
-		return
+		return nil, nil, nil
 	}
 
-	subs, err := h.NotificationRepo.GetPushSubscriptionsByUserIDs(filteredUserIDs)
-	if err != nil {
-		log.Printf("[notification] 購読情報の取得に失敗しました: %v\n", err)
-		return
+	var subs []models.PushSubscription
+	if pushEnabled {
+		subs, err = h.NotificationRepo.GetPushSubscriptionsByUserIDs(filteredUserIDs)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
-
 	log.Printf("[notification] 購読情報数: %d\n", len(subs))
-	if len(subs) == 0 {
-		log.Println("[notification] 購読情報が0件のためPush通知をスキップします")
-		return
+
+	var mailUserIDs []string
+	if mailEnabled {
+		mailUserIDs = usersWithoutPush(filteredUserIDs, subs)
 	}
+	return subs, mailUserIDs, nil
+}
 
-	payload, err := sonic.Marshal(gin.H{
-		"title": title,
-		"body":  body,
-		"data": gin.H{
-			"notificationId": notificationID,
-		},
-	})
-	if err != nil {
-		log.Printf("[notification] Pushペイロード生成に失敗しました: %v\n", err)
-		return
+// normalizeNotificationTargets はロール以外の宛先を検証し、重複を除く
+func normalizeNotificationTargets(requested []models.NotificationTarget) ([]models.NotificationTarget, error) {
+	result := make([]models.NotificationTarget, 0, len(requested))
+	seen := make(map[string]struct{}, len(requested))
+	for _, target := range requested {
+		target.Type = strings.ToLower(strings.TrimSpace(target.Type))
+		var key string
+		switch target.Type {
+		case models.NotificationTargetClass, models.NotificationTargetTeam, models.NotificationTargetSport, models.NotificationTargetMatch:
+			if target.ID == nil || *target.ID <= 0 {
+				return nil, errors.New("宛先のIDが指定されていません")
+			}
+			target.UserID = nil
+			key = target.Type + ":" + strconv.Itoa(*target.ID)
+		case models.NotificationTargetUser:
+			if target.UserID == nil || strings.TrimSpace(*target.UserID) == "" {
+				return nil, errors.New("宛先のユーザーIDが指定されていません")
+			}
+			userID := strings.TrimSpace(*target.UserID)
+			target.UserID = &userID
+			target.ID = nil
+			key = target.Type + ":" + userID
+		default:
+			return nil, errors.New("無効な宛先の種類です")
+		}
+
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, target)
 	}
+	return result, nil
+}
 
-	dispatchPushBatch(h.PushSender, h.NotificationRepo, payload, subs, 60, "notification")
-	log.Printf("[notification] 通知送信完了: notificationID=%d\n", notificationID)
+// mergeUserIDs は順序を保ったまま重複を除いて結合する
+func mergeUserIDs(lists ...[]string) []string {
+	seen := make(map[string]struct{})
+	merged := make([]string, 0)
+	for _, list := range lists {
+		for _, userID := range list {
+			if _, ok := seen[userID]; ok {
+				continue
+			}
+			seen[userID] = struct{}{}
+			merged = append(merged, userID)
+		}
+	}
+	return merged
 }
 
 func normalizeTargetRoles(requested []string, available []models.Role) ([]string, error) {
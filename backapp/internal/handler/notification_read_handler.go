@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"backapp/internal/models"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notificationReader は既読操作をするユーザーとそのロール名を返す
+func notificationReader(c *gin.Context) (*models.User, []string, bool) {
+	userValue, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザー情報を取得できませんでした"})
+		return nil, nil, false
+	}
+
+	user, ok := userValue.(*models.User)
+	if !ok || user == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザー情報の解析に失敗しました"})
+		return nil, nil, false
+	}
+
+	var roleNames []string
+	for _, role := range user.Roles {
+		roleNames = append(roleNames, role.Name)
+	}
+	return user, roleNames, true
+}
+
+// MarkNotificationRead は自分に届いた通知を既読にする。既に既読でも成功を返す。
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	notificationID, err := strconv.Atoi(c.Param("notification_id"))
+	if err != nil || notificationID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な通知IDです"})
+		return
+	}
+
+	user, roleNames, ok := notificationReader(c)
+	if !ok {
+		return
+	}
+
+	found, err := h.NotificationRepo.MarkNotificationRead(notificationID, roleNames, user.ID)
+	if err != nil {
+		log.Printf("MarkNotificationRead error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "既読の登録に失敗しました"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "通知が見つかりません"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "既読にしました"})
+}
+
+// MarkAllNotificationsRead は自分に届いた通知をすべて既読にする
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	user, roleNames, ok := notificationReader(c)
+	if !ok {
+		return
+	}
+
+	marked, err := h.NotificationRepo.MarkAllNotificationsRead(roleNames, user.ID)
+	if err != nil {
+		log.Printf("MarkAllNotificationsRead error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "既読の登録に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"marked_count": marked})
+}
+
+// GetUnreadNotificationCount は未読バッジ用に未読の通知の件数を返す
+func (h *NotificationHandler) GetUnreadNotificationCount(c *gin.Context) {
+	user, roleNames, ok := notificationReader(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.NotificationRepo.CountUnreadNotifications(roleNames, user.ID)
+	if err != nil {
+		log.Printf("GetUnreadNotificationCount error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "未読件数の取得に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread_count": count})
+}
+
+// GetNotificationReadStats は通知の対象者のうち既読にした割合を、ロール別・クラス別にも返す
+func (h *NotificationHandler) GetNotificationReadStats(c *gin.Context) {
+	notificationID, err := strconv.Atoi(c.Param("notification_id"))
+	if err != nil || notificationID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な通知IDです"})
+		return
+	}
+
+	notification, err := h.NotificationRepo.GetNotificationByID(notificationID)
+	if err != nil {
+		log.Printf("GetNotificationReadStats error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "通知の取得に失敗しました"})
+		return
+	}
+	if notification == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "通知が見つかりません"})
+		return
+	}
+
+	userIDs, err := h.NotificationRepo.GetUserIDsByRoles(notification.TargetRoles)
+	if err != nil {
+		log.Printf("GetNotificationReadStats error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "対象ユーザーの取得に失敗しました"})
+		return
+	}
+	if len(notification.Targets) > 0 {
+		targetUserIDs, err := h.NotificationRepo.GetUserIDsByTargets(notification.EventID, notification.Targets)
+		if err != nil {
+			log.Printf("GetNotificationReadStats error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "対象ユーザーの取得に失敗しました"})
+			return
+		}
+		userIDs = mergeUserIDs(userIDs, targetUserIDs)
+	}
+
+	stats, err := h.NotificationRepo.GetNotificationReadStats(notificationID, userIDs)
+	if err != nil {
+		log.Printf("GetNotificationReadStats error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "既読状況の取得に失敗しました"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"backapp/internal/mail"
 	"backapp/internal/models"
 	"backapp/internal/push"
 	"backapp/internal/repository"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,6 +20,11 @@ type NotificationRequestHandler struct {
 	NotificationRepo repository.NotificationRepository
 	RoleRepo         repository.RoleRepository
 	PushSender       push.Sender
+	PushOutbox       *PushOutbox
+	MailSender       mail.Sender
+	Notifications    *NotificationHandler
+	// EscalationThreshold を過ぎても承認待ちの申請は root に催促する。0 なら1時間。
+	EscalationThreshold time.Duration
 }
 
 func NewNotificationRequestHandler(
@@ -43,6 +50,17 @@ func (h *NotificationRequestHandler) WithPushSender(sender push.Sender) *Notific
 	return h
 }
 
+func (h *NotificationRequestHandler) WithPushOutbox(outbox *PushOutbox) *NotificationRequestHandler {
+	h.PushOutbox = outbox
+	return h
+}
+
+// WithMailSender は Push 購読のない申請者へ結果をメールで知らせるようにする
+func (h *NotificationRequestHandler) WithMailSender(sender mail.Sender) *NotificationRequestHandler {
+	h.MailSender = sender
+	return h
+}
+
 type createNotificationRequestPayload struct {
 	Title      string `json:"title"`
 	Body       string `json:"body"`
@@ -205,6 +223,14 @@ func (h *NotificationRequestHandler) AddMessage(c *gin.Context) {
 
 type decisionPayload struct {
 	Status string `json:"status"`
+	// 承認時は対象ロールか宛先が必須で、申請の内容（編集も可）で通知を作成する。send_at があれば予約する。
+	Title       *string                     `json:"title"`
+	Body        *string                     `json:"body"`
+	Type        string                      `json:"type"`
+	TargetRoles []string                    `json:"target_roles"`
+	Targets     []models.NotificationTarget `json:"targets"`
+	Channel     string                      `json:"channel"`
+	SendAt      *time.Time                  `json:"send_at"`
 }
 
 func (h *NotificationRequestHandler) DecideRequest(c *gin.Context) {
@@ -240,6 +266,16 @@ func (h *NotificationRequestHandler) DecideRequest(c *gin.Context) {
 		return
 	}
 
+	// 承認した申請は必ず通知にする。宛先がなければ通知を送らずに承認してしまうため受け付けない
+	if status == models.NotificationRequestStatusApproved {
+		if len(payload.TargetRoles) == 0 && len(payload.Targets) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "承認するには通知の対象ロールか宛先を指定してください"})
+			return
+		}
+		h.approveWithNotification(c, req, payload, user)
+		return
+	}
+
 	resolverID := user.ID
 	if err := h.RequestRepo.UpdateRequestStatus(requestID, status, &resolverID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ステータス更新に失敗しました"})
@@ -305,7 +341,9 @@ func (h *NotificationRequestHandler) notifyParticipantsOfMessage(req *models.Not
 }
 
 func (h *NotificationRequestHandler) notifyRequesterDecision(req *models.NotificationRequest, status models.NotificationRequestStatus, resolver *models.User) {
-	if h.PushSender == nil || !h.PushSender.Enabled() {
+	pushEnabled := h.PushSender != nil && h.PushSender.Enabled()
+	mailEnabled := h.MailSender != nil && h.MailSender.Enabled()
+	if !pushEnabled && !mailEnabled {
 		return
 	}
 
@@ -323,7 +361,16 @@ func (h *NotificationRequestHandler) notifyRequesterDecision(req *models.Notific
 			"status":    status,
 		},
 	}
-	h.sendPushToUsers([]string{req.RequesterID}, payload)
+	var subs []models.PushSubscription
+	if pushEnabled {
+		subs = h.sendPushToUsers([]string{req.RequesterID}, payload)
+	}
+	if mailEnabled {
+		approved := status == models.NotificationRequestStatusApproved
+		sendEmailFallback(h.MailSender, h.NotificationRepo, usersWithoutPush([]string{req.RequesterID}, subs), func(to string) (mail.Message, error) {
+			return mail.NotificationRequestDecisionMessage(to, req.Title, approved, "")
+		}, "notification-request")
+	}
 
 	resultText := "承認"
 	if status == models.NotificationRequestStatusRejected {
@@ -334,22 +381,24 @@ func (h *NotificationRequestHandler) notifyRequesterDecision(req *models.Notific
 	_, _ = h.RequestRepo.AddMessage(req.ID, resolver.ID, systemMessage)
 }
 
-func (h *NotificationRequestHandler) sendPushToUsers(userIDs []string, payload gin.H) {
+// sendPushToUsers は Push 通知を送り、送信先にした購読情報を返す
+func (h *NotificationRequestHandler) sendPushToUsers(userIDs []string, payload gin.H) []models.PushSubscription {
 	if len(userIDs) == 0 {
-		return
+		return nil
 	}
 
 	subscriptions, err := h.NotificationRepo.GetPushSubscriptionsByUserIDs(userIDs)
 	if err != nil || len(subscriptions) == 0 {
-		return
+		return nil
 	}
 
 	bodyBytes, err := jsonMarshal(payload)
 	if err != nil {
-		return
+		return subscriptions
 	}
 
-	dispatchPushBatch(h.PushSender, h.NotificationRepo, bodyBytes, subscriptions, 60, "notification-request")
+	deliverPush(h.PushOutbox, h.PushSender, h.NotificationRepo, nil, bodyBytes, subscriptions, 60, "notification-request")
+	return subscriptions
 }
 
 func userDisplayName(user *models.User) string {
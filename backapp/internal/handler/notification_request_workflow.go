@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"backapp/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultNotificationRequestSLA を過ぎても承認待ちの申請は root に催促する
+	defaultNotificationRequestSLA         = time.Hour
+	notificationRequestEscalationInterval = 5 * time.Minute
+)
+
+// WithNotificationHandler は申請の承認時に、編集した内容で通知を作成・予約できるようにする
+func (h *NotificationRequestHandler) WithNotificationHandler(notifications *NotificationHandler) *NotificationRequestHandler {
+	h.Notifications = notifications
+	return h
+}
+
+// WithEscalation は承認待ちの申請を root に催促するまでの時間を設定する
+func (h *NotificationRequestHandler) WithEscalation(threshold time.Duration) *NotificationRequestHandler {
+	h.EscalationThreshold = threshold
+	return h
+}
+
+func (h *NotificationRequestHandler) escalationThreshold() time.Duration {
+	if h.EscalationThreshold <= 0 {
+		return defaultNotificationRequestSLA
+	}
+	return h.EscalationThreshold
+}
+
+// approveWithNotification は申請を承認し、root が編集した内容で通知を作成する。send_at があれば予約する。
+func (h *NotificationRequestHandler) approveWithNotification(c *gin.Context, req *models.NotificationRequest, payload decisionPayload, user *models.User) {
+	if h.Notifications == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "通知を作成できません"})
+		return
+	}
+	if req.Status != models.NotificationRequestStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "処理済みの申請です"})
+		return
+	}
+
+	title, body := req.Title, req.Body
+	if payload.Title != nil {
+		title = *payload.Title
+	}
+	if payload.Body != nil {
+		body = *payload.Body
+	}
+	draft, err := h.Notifications.prepareNotification(createNotificationRequest{
+		Title:       title,
+		Body:        body,
+		Type:        payload.Type,
+		TargetRoles: payload.TargetRoles,
+		Targets:     payload.Targets,
+		Channel:     payload.Channel,
+	})
+	if err != nil {
+		writeNotificationError(c, err)
+		return
+	}
+
+	if payload.SendAt != nil {
+		scheduler := h.Notifications.Scheduler
+		switch {
+		case scheduler == nil:
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "予約通知を利用できません"})
+			return
+		case !payload.SendAt.After(scheduler.now()):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "送信日時は現在より後にしてください"})
+			return
+		}
+	}
+
+	resolved, err := h.RequestRepo.ResolvePendingRequest(req.ID, models.NotificationRequestStatusApproved, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ステータス更新に失敗しました"})
+		return
+	}
+	if !resolved {
+		c.JSON(http.StatusConflict, gin.H{"error": "処理済みの申請です"})
+		return
+	}
+
+	var notificationID, scheduledID *int
+	if payload.SendAt != nil {
+		id, err := h.Notifications.ScheduledRepo.CreateScheduledNotification(&models.ScheduledNotification{
+			Title:       draft.Title,
+			Body:        draft.Body,
+			Type:        draft.Type,
+			TargetRoles: draft.TargetRoles,
+			Targets:     draft.Targets,
+			Channel:     draft.Channel,
+			SendAt:      *payload.SendAt,
+			CreatedBy:   user.ID,
+		})
+		if err != nil {
+			log.Printf("approveWithNotification error: %v", err)
+			h.reopenRequest(req.ID)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "予約通知の作成に失敗しました"})
+			return
+		}
+		value := int(id)
+		scheduledID = &value
+	} else {
+		id, err := h.Notifications.publishNotification(draft, user.ID)
+		if err != nil {
+			h.reopenRequest(req.ID)
+			writeNotificationError(c, err)
+			return
+		}
+		value := int(id)
+		notificationID = &value
+	}
+
+	if err := h.RequestRepo.LinkRequestNotification(req.ID, notificationID, scheduledID); err != nil {
+		log.Printf("LinkRequestNotification error: requestID=%d, error=%v", req.ID, err)
+	}
+
+	go h.notifyRequesterDecision(req, models.NotificationRequestStatusApproved, user)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                   "申請を承認し、通知を作成しました",
+		"notification_id":           notificationID,
+		"scheduled_notification_id": scheduledID,
+	})
+}
+
+// reopenRequest は通知の作成に失敗した申請を承認待ちに戻す
+func (h *NotificationRequestHandler) reopenRequest(requestID int) {
+	if err := h.RequestRepo.UpdateRequestStatus(requestID, models.NotificationRequestStatusPending, nil); err != nil {
+		log.Printf("reopenRequest error: requestID=%d, error=%v", requestID, err)
+	}
+}
+
+// GetRequestStats は状態ごとの申請件数、承認・否認までの時間、承認待ちのまま期限を過ぎた件数を返す
+func (h *NotificationRequestHandler) GetRequestStats(c *gin.Context) {
+	stats, err := h.RequestRepo.GetRequestStats(time.Now(), h.escalationThreshold())
+	if err != nil {
+		log.Printf("GetRequestStats error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "申請の集計に失敗しました"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
+
+// RunEscalation は ctx が終わるまで一定間隔で、承認待ちが長引いた申請を root に催促する
+func (h *NotificationRequestHandler) RunEscalation(ctx context.Context) {
+	ticker := time.NewTicker(notificationRequestEscalationInterval)
+	defer ticker.Stop()
+	for {
+		if err := h.EscalateStaleRequests(time.Now()); err != nil {
+			log.Printf("[notification-request] 承認待ちの申請の催促に失敗しました: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EscalateStaleRequests は承認待ちのまま期限を過ぎた申請をまとめて root に Push 通知で催促する。
+// 同じ申請は期限と同じ間隔をあけて繰り返し催促する。送信キューがあれば催促は送信キューから送る。
+func (h *NotificationRequestHandler) EscalateStaleRequests(now time.Time) error {
+	if h.PushSender == nil || !h.PushSender.Enabled() {
+		return nil
+	}
+
+	// 送り先を先に確かめ、誰にも届かない催促で申請を催促済みにしない
+	rootIDs, err := h.NotificationRepo.GetUserIDsByRoles([]string{"root"})
+	if err != nil {
+		return err
+	}
+	if len(rootIDs) == 0 {
+		return nil
+	}
+	subscriptions, err := h.NotificationRepo.GetPushSubscriptionsByUserIDs(rootIDs)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	threshold := h.escalationThreshold()
+	requests, err := h.RequestRepo.EscalateStaleRequests(now, threshold)
+	if err != nil {
+		return err
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	requestIDs := make([]int, len(requests))
+	for i, req := range requests {
+		requestIDs[i] = req.ID
+	}
+	body := fmt.Sprintf("%d件の通知申請が%d分以上承認待ちです（最も古い申請: %s）", len(requests), int(threshold/time.Minute), requests[0].Title)
+	payload, err := jsonMarshal(gin.H{
+		"title": "承認待ちの通知申請があります",
+		"body":  body,
+		"data": gin.H{
+			"type":       "notification_request_reminder",
+			"requestIds": requestIDs,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	deliverPush(h.PushOutbox, h.PushSender, h.NotificationRepo, nil, payload, subscriptions, 60, "notification-request-reminder")
+	return nil
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+)
+
+const (
+	notificationSchedulerInterval = 15 * time.Second
+	// scheduledNotificationLateLimit を超えて遅れた予約は送らない（サーバー停止中に過ぎた「15分後に開会式」など）
+	scheduledNotificationLateLimit = 30 * time.Minute
+	// scheduledNotificationLease を過ぎても送信中の予約は、送る途中で止まったものとして取り出し直す
+	scheduledNotificationLease = 5 * time.Minute
+)
+
+// NotificationScheduler は送信日時を過ぎた予約通知を通常の通知として作成し、Push 通知を送る。
+// 予約は DB に残るため、サーバーを再起動しても未送信の予約や送信中のまま止まった予約を読み直して送る。
+type NotificationScheduler struct {
+	repo    repository.ScheduledNotificationRepository
+	publish func(n *models.ScheduledNotification) (int64, error)
+	now     func() time.Time
+}
+
+func NewNotificationScheduler(repo repository.ScheduledNotificationRepository) *NotificationScheduler {
+	return &NotificationScheduler{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// WithClock は現在時刻の取得方法を差し替える
+func (s *NotificationScheduler) WithClock(now func() time.Time) *NotificationScheduler {
+	s.now = now
+	return s
+}
+
+// Run は起動直後と、その後 ctx が終わるまで一定間隔で送信日時を過ぎた予約を送る
+func (s *NotificationScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(notificationSchedulerInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.DispatchDue(); err != nil {
+			log.Printf("[scheduled-notification] 予約通知の確認に失敗しました: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue は送信日時を過ぎた予約を取り出して送る。
+// 予約は通知を作成してから次の回に進めるので、途中で止まっても回を取りこぼさない。
+func (s *NotificationScheduler) DispatchDue() error {
+	if s.publish == nil {
+		return nil
+	}
+
+	now := s.now()
+	due, err := s.repo.ClaimDueScheduledNotifications(now, scheduledNotificationLateLimit, scheduledNotificationLease)
+	if err != nil {
+		return err
+	}
+	for _, n := range due {
+		notificationID, err := s.publish(n)
+		if err != nil {
+			log.Printf("[scheduled-notification] 予約通知の送信に失敗しました: scheduledID=%d, error=%v\n", n.ID, err)
+			if err := s.repo.ReleaseScheduledNotification(n.ID); err != nil {
+				log.Printf("[scheduled-notification] 予約を送信前に戻せませんでした: scheduledID=%d, error=%v\n", n.ID, err)
+			}
+			continue
+		}
+
+		next := *n
+		next.Status = models.ScheduledNotificationStatusPending
+		next.Advance(now, scheduledNotificationLateLimit)
+		if err := s.repo.CompleteScheduledNotification(&next, int(notificationID)); err != nil {
+			log.Printf("[scheduled-notification] 送信した予約の更新に失敗しました: scheduledID=%d, notificationID=%d, error=%v\n", n.ID, notificationID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"backapp/internal/models"
+	"backapp/internal/push"
+	"backapp/internal/repository"
+)
+
+const (
+	pushOutboxWorkers       = 2
+	pushOutboxBatchSize     = 100
+	pushOutboxPollInterval  = 5 * time.Second
+	pushOutboxLease         = 2 * time.Minute
+	pushRetryBaseDelay      = 30 * time.Second
+	pushRetryMaxDelay       = time.Hour
+	pushDeliveryMaxAttempts = 6
+)
+
+// PushOutbox は Push 通知を DB の送信キューに積み、ワーカーが購読ごとに送る。
+// 一時的な失敗は間隔を空けて再送し、購読ごとの結果を通知の配信状況として残す。
+type PushOutbox struct {
+	repo             repository.PushDeliveryRepository
+	notificationRepo repository.NotificationRepository
+	sender           push.Sender
+	wake             chan struct{}
+}
+
+func NewPushOutbox(repo repository.PushDeliveryRepository, notificationRepo repository.NotificationRepository, sender push.Sender) *PushOutbox {
+	return &PushOutbox{
+		repo:             repo,
+		notificationRepo: notificationRepo,
+		sender:           sender,
+		wake:             make(chan struct{}, 1),
+	}
+}
+
+// Enqueue は購読ごとの配信を送信キューに登録し、ワーカーを起こす
+func (o *PushOutbox) Enqueue(notificationID *int, source string, payload []byte, subscriptions []models.PushSubscription, ttl int) error {
+	if o.sender == nil || !o.sender.Enabled() || len(subscriptions) == 0 {
+		return nil
+	}
+	messageID, err := o.repo.EnqueuePushMessage(notificationID, source, payload, ttl, subscriptions)
+	if err != nil {
+		return err
+	}
+	log.Printf("[%s] %d件の購読に対するPush通知を送信キューに登録しました: messageID=%d\n", source, len(subscriptions), messageID)
+	o.Wake()
+	return nil
+}
+
+// Wake は送信キューに配信が増えたことをワーカーに知らせる
+func (o *PushOutbox) Wake() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// DeliveryStats は通知の配信状況を返す
+func (o *PushOutbox) DeliveryStats(notificationID int) (models.PushDeliveryStats, error) {
+	return o.repo.GetPushDeliveryStats(notificationID)
+}
+
+// Run は ctx が終わるまでワーカーで送信キューを処理する
+func (o *PushOutbox) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < pushOutboxWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (o *PushOutbox) work(ctx context.Context) {
+	ticker := time.NewTicker(pushOutboxPollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			processed, err := o.ProcessPending(ctx)
+			if err != nil {
+				log.Printf("[push-outbox] 送信キューの処理に失敗しました: %v\n", err)
+				break
+			}
+			if processed < pushOutboxBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessPending は送信時刻を過ぎた配信を1回分取り出して送り、処理した件数を返す
+func (o *PushOutbox) ProcessPending(ctx context.Context) (int, error) {
+	if o.sender == nil || !o.sender.Enabled() {
+		return 0, nil
+	}
+
+	deliveries, err := o.repo.ClaimPushDeliveries(pushOutboxBatchSize, pushOutboxLease)
+	if err != nil {
+		return 0, err
+	}
+
+	// 同じ内容の配信はまとめて送る
+	order := make([]int, 0)
+	byMessage := make(map[int][]*models.PushDelivery)
+	for _, d := range deliveries {
+		if d.Subscription == nil {
+			// 配信を待つ間に購読が削除された
+			o.complete(d, models.PushDeliveryStatusExpired, 0, "subscription_deleted")
+			continue
+		}
+		if _, ok := byMessage[d.MessageID]; !ok {
+			order = append(order, d.MessageID)
+		}
+		byMessage[d.MessageID] = append(byMessage[d.MessageID], d)
+	}
+
+	for _, messageID := range order {
+		group := byMessage[messageID]
+		subscriptions := make([]models.PushSubscription, len(group))
+		for i, d := range group {
+			subscriptions[i] = *d.Subscription
+		}
+
+		batchContext, cancel := context.WithTimeout(ctx, 30*time.Second)
+		results := o.sender.SendBatch(batchContext, group[0].Payload, subscriptions, group[0].TTL)
+		cancel()
+		for i, result := range results {
+			o.record(group[i], result)
+		}
+	}
+	return len(deliveries), nil
+}
+
+// record は送信結果から配信の状態を決める。失効した購読は削除し、一時的な失敗は再送する。
+func (o *PushOutbox) record(d *models.PushDelivery, result push.Result) {
+	sub := *d.Subscription
+	endpointID := push.EndpointLogID(sub.Endpoint)
+	errorType := ""
+	if result.Err != nil {
+		// Network errors can embed the capability URL, so keep only the type.
+		errorType = fmt.Sprintf("%T", result.Err)
+	}
+
+	switch {
+	case result.InvalidSubscription:
+		log.Printf("[%s] 不正な購読情報を削除します: deliveryID=%d, userID=%s, endpointID=%s\n", d.Source, d.ID, sub.UserID, endpointID)
+		o.complete(d, models.PushDeliveryStatusExpired, result.StatusCode, "invalid_subscription")
+		if err := o.notificationRepo.DeletePushSubscription(sub.UserID, sub.Endpoint); err != nil {
+			log.Printf("[%s] 不正な購読情報の削除に失敗しました: userID=%s, endpointID=%s, errorType=%T\n", d.Source, sub.UserID, endpointID, err)
+		}
+	case result.Err == nil && result.StatusCode >= 200 && result.StatusCode < 300:
+		o.complete(d, models.PushDeliveryStatusSent, result.StatusCode, "")
+	case result.StatusCode == http.StatusNotFound || result.StatusCode == http.StatusGone:
+		o.complete(d, models.PushDeliveryStatusExpired, result.StatusCode, "")
+		cleanupExpiredPushSubscription(o.notificationRepo, sub, result.StatusCode, d.Source)
+	case result.Retryable() && d.Attempts < pushDeliveryMaxAttempts:
+		delay := pushRetryDelay(d.Attempts, result.RetryAfter)
+		log.Printf("[%s] Push送信に失敗したため再送します: deliveryID=%d, userID=%s, endpointID=%s, status=%d, errorType=%s, attempts=%d, retryIn=%s\n", d.Source, d.ID, sub.UserID, endpointID, result.StatusCode, errorType, d.Attempts, delay)
+		if err := o.repo.RetryPushDelivery(d.ID, delay, result.StatusCode, errorType); err != nil {
+			log.Printf("[%s] 再送の登録に失敗しました: deliveryID=%d, error=%v\n", d.Source, d.ID, err)
+		}
+	default:
+		log.Printf("[%s] Push送信に失敗しました: deliveryID=%d, userID=%s, endpointID=%s, status=%d, errorType=%s, attempts=%d\n", d.Source, d.ID, sub.UserID, endpointID, result.StatusCode, errorType, d.Attempts)
+		o.complete(d, models.PushDeliveryStatusFailed, result.StatusCode, errorType)
+	}
+}
+
+func (o *PushOutbox) complete(d *models.PushDelivery, status string, statusCode int, lastError string) {
+	if err := o.repo.CompletePushDelivery(d.ID, status, statusCode, lastError); err != nil {
+		log.Printf("[%s] 配信状況の更新に失敗しました: deliveryID=%d, status=%s, error=%v\n", d.Source, d.ID, status, err)
+	}
+}
+
+// pushRetryDelay は attempts 回目の失敗の後に再送するまでの時間。
+// 30秒から倍々に延ばして1時間で頭打ちにし、Push サービスが Retry-After を指定していればそれより早くは送らない。
+func pushRetryDelay(attempts int, retryAfter time.Duration) time.Duration {
+	delay := pushRetryBaseDelay
+	for i := 1; i < attempts && delay < pushRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > pushRetryMaxDelay {
+		delay = pushRetryMaxDelay
+	}
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	return delay
+}
+
+// deliverPush は送信キューがあれば Push 通知を登録し、なければその場で送る。
+// 登録に失敗した場合もその場で送る。
+func deliverPush(outbox *PushOutbox, sender push.Sender, repo repository.NotificationRepository, notificationID *int, payload []byte, subscriptions []models.PushSubscription, ttl int, logPrefix string) {
+	if outbox != nil {
+		err := outbox.Enqueue(notificationID, logPrefix, payload, subscriptions, ttl)
+		if err == nil {
+			return
+		}
+		log.Printf("[%s] 送信キューへの登録に失敗したため直接送信します: %v\n", logPrefix, err)
+	}
+	dispatchPushBatch(sender, repo, payload, subscriptions, ttl, logPrefix)
+}
+
+// newPushMessage は通知と一緒に送る Push 通知を作る。送る購読がなければ nil を返す。
+func newPushMessage(source string, subscriptions []models.PushSubscription, ttl int, payload func(notificationID int) ([]byte, error)) *models.PushMessage {
+	if len(subscriptions) == 0 {
+		return nil
+	}
+	return &models.PushMessage{Source: source, TTL: ttl, Subscriptions: subscriptions, Payload: payload}
+}
+
+// queuedPushMessage は送信キューがあるときだけ message を返す。
+// 返した Push 通知は通知と同じトランザクションで送信キューに登録する。
+func queuedPushMessage(outbox *PushOutbox, message *models.PushMessage) *models.PushMessage {
+	if outbox == nil {
+		return nil
+	}
+	return message
+}
+
+// publishPush は通知の登録後に Push 通知を送り出す。
+// 送信キューに登録済みならワーカーを起こし、送信キューがなければその場で送る。
+func publishPush(outbox *PushOutbox, sender push.Sender, repo repository.NotificationRepository, message *models.PushMessage, notificationID int) {
+	if message == nil {
+		return
+	}
+	if outbox != nil {
+		log.Printf("[%s] %d件の購読に対するPush通知を送信キューに登録しました: notificationID=%d\n", message.Source, len(message.Subscriptions), notificationID)
+		outbox.Wake()
+		return
+	}
+	payload, err := message.Payload(notificationID)
+	if err != nil {
+		log.Printf("[%s] Pushペイロード生成に失敗しました: %v\n", message.Source, err)
+		return
+	}
+	go dispatchPushBatch(sender, repo, payload, message.Subscriptions, message.TTL, message.Source)
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"backapp/internal/models"
 	"backapp/internal/repository"
+	"backapp/internal/scoreboard"
 	"net/http"
 	"strconv"
 
@@ -12,6 +13,9 @@ import (
 type RainyModeHandler struct {
 	rainyModeRepo repository.RainyModeRepository
 	eventRepo     repository.EventRepository
+	sportRepo     repository.SportRepository
+	teamRepo      repository.TeamRepository
+	scoreboard    *scoreboard.Feed
 }
 
 func NewRainyModeHandler(rainyModeRepo repository.RainyModeRepository, eventRepo repository.EventRepository) *RainyModeHandler {
@@ -21,6 +25,19 @@ func NewRainyModeHandler(rainyModeRepo repository.RainyModeRepository, eventRepo
 	}
 }
 
+// WithPlanner は雨天時計画の確認と適用に使う競技とチームのリポジトリを設定する
+func (h *RainyModeHandler) WithPlanner(sportRepo repository.SportRepository, teamRepo repository.TeamRepository) *RainyModeHandler {
+	h.sportRepo = sportRepo
+	h.teamRepo = teamRepo
+	return h
+}
+
+// WithScoreboard は雨天時計画の適用を会場のスコアボードにも反映する
+func (h *RainyModeHandler) WithScoreboard(feed *scoreboard.Feed) *RainyModeHandler {
+	h.scoreboard = feed
+	return h
+}
+
 // GetRainyModeSettingsHandler は指定されたイベントの雨天時設定一覧を取得します
 func (h *RainyModeHandler) GetRainyModeSettingsHandler(c *gin.Context) {
 	eventID, err := strconv.Atoi(c.Param("id"))
@@ -0,0 +1,462 @@
+package handler
+
+import (
+	"backapp/internal/models"
+	"backapp/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PreviewRainyPlanHandler は雨天時の想定を適用した場合に影響を受ける試合・チーム・メンバーを返す。DBは変更しない。
+func (h *RainyModeHandler) PreviewRainyPlanHandler(c *gin.Context) {
+	eventID, scenario, ok := h.bindRainyScenario(c)
+	if !ok {
+		return
+	}
+
+	plan, _, ok := h.buildRainyPlan(c, eventID, scenario)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"plan": plan})
+}
+
+// ApplyRainyPlanHandler は雨天時の想定を1つのトランザクションで適用する。
+// 未実施の試合を中止し、統合先のチームにメンバーを移し、定員を変え、統合でチームが増えた競技の対戦表を組み直す。
+func (h *RainyModeHandler) ApplyRainyPlanHandler(c *gin.Context) {
+	eventID, scenario, ok := h.bindRainyScenario(c)
+	if !ok {
+		return
+	}
+
+	plan, tournaments, ok := h.buildRainyPlan(c, eventID, scenario)
+	if !ok {
+		return
+	}
+	if len(plan.Conflicts) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "雨天時計画を適用できません", "conflicts": plan.Conflicts})
+		return
+	}
+
+	if err := h.rainyModeRepo.ApplyRainyPlan(eventID, plan, tournaments, actorUserID(c)); err != nil {
+		if errors.Is(err, repository.ErrRainyPlanOutdated) {
+			c.JSON(http.StatusConflict, gin.H{"error": "計画の確認後に試合結果が入力されました。計画を確認し直してください"})
+			return
+		}
+		log.Printf("ApplyRainyPlan error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply rainy plan"})
+		return
+	}
+	if h.scoreboard != nil {
+		h.scoreboard.Resync(eventID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "雨天時計画を適用しました", "plan": plan})
+}
+
+func (h *RainyModeHandler) bindRainyScenario(c *gin.Context) (int, models.RainyScenario, bool) {
+	var scenario models.RainyScenario
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return 0, scenario, false
+	}
+	if err := c.ShouldBindJSON(&scenario); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return 0, scenario, false
+	}
+
+	event, err := h.eventRepo.GetEventByID(eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get event"})
+		return 0, scenario, false
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return 0, scenario, false
+	}
+	return eventID, scenario, true
+}
+
+// validateRainyScenario は想定の競技が大会の競技で、統合先がそのまま実施する競技になっているかを調べる
+func validateRainyScenario(scenario models.RainyScenario, sportsByID map[int]*models.EventSport) (map[int]models.RainySportChange, error) {
+	changes := make(map[int]models.RainySportChange, len(scenario.Sports))
+	for _, change := range scenario.Sports {
+		sport, ok := sportsByID[change.SportID]
+		if !ok {
+			return nil, fmt.Errorf("競技 %d はこの大会の競技ではありません", change.SportID)
+		}
+		if sport.Location == "noon_game" {
+			return nil, fmt.Errorf("昼競技（%s）は雨天時計画の対象外です", sport.SportName)
+		}
+		if _, dup := changes[change.SportID]; dup {
+			return nil, fmt.Errorf("競技 %d が重複しています", change.SportID)
+		}
+		switch change.Action {
+		case models.RainySportKeep, models.RainySportCancel:
+		case models.RainySportMerge:
+			if change.MergeIntoSportID == nil {
+				return nil, fmt.Errorf("%sの統合先の競技を指定してください", sport.SportName)
+			}
+			target, ok := sportsByID[*change.MergeIntoSportID]
+			if !ok || target.Location == "noon_game" || target.SportID == change.SportID {
+				return nil, fmt.Errorf("%sの統合先の競技が正しくありません", sport.SportName)
+			}
+		default:
+			return nil, fmt.Errorf("action は keep, cancel, merge のいずれかを指定してください")
+		}
+		changes[change.SportID] = change
+	}
+
+	for _, change := range changes {
+		if change.Action != models.RainySportMerge {
+			continue
+		}
+		if target, ok := changes[*change.MergeIntoSportID]; ok && target.Action != models.RainySportKeep {
+			return nil, fmt.Errorf("%sは中止・統合する競技のため統合先にできません", sportsByID[target.SportID].SportName)
+		}
+	}
+	return changes, nil
+}
+
+// buildRainyPlan は想定から計画と、組み直す競技の新しい対戦表を作る。失敗したときはレスポンスを書いて false を返す。
+func (h *RainyModeHandler) buildRainyPlan(c *gin.Context, eventID int, scenario models.RainyScenario) (*models.RainyPlan, []models.GeneratedTournament, bool) {
+	sports, err := h.sportRepo.GetSportsByEventID(eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sports for the event"})
+		return nil, nil, false
+	}
+	sportsByID := make(map[int]*models.EventSport, len(sports))
+	for _, sport := range sports {
+		sportsByID[sport.SportID] = sport
+	}
+
+	changes, err := validateRainyScenario(scenario, sportsByID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	matches, err := h.rainyModeRepo.GetPlanMatches(eventID)
+	if err != nil {
+		log.Printf("GetPlanMatches error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get matches"})
+		return nil, nil, false
+	}
+	teams, err := h.rainyModeRepo.GetPlanTeams(eventID)
+	if err != nil {
+		log.Printf("GetPlanTeams error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get teams"})
+		return nil, nil, false
+	}
+	teamIDs := make([]int, 0, len(teams))
+	for _, team := range teams {
+		teamIDs = append(teamIDs, team.ID)
+	}
+	members, err := h.teamRepo.GetTeamMembersByTeamIDs(teamIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get team members"})
+		return nil, nil, false
+	}
+	var settings []*models.RainyModeSetting
+	if scenario.ApplyCapacities {
+		settings, err = h.rainyModeRepo.GetSettingsByEventID(eventID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve rainy mode settings"})
+			return nil, nil, false
+		}
+	}
+
+	plan, tournaments := planRainyScenario(eventID, sports, changes, matches, teams, members, settings)
+	return plan, tournaments, true
+}
+
+type sportClassKey struct {
+	sportID int
+	classID int
+}
+
+// planRainyScenario は検証済みの想定から計画を組み立てる
+func planRainyScenario(eventID int, sports []*models.EventSport, changes map[int]models.RainySportChange, matches []*models.RainyPlanMatch, teams []*models.Team, members map[int][]*models.User, settings []*models.RainyModeSetting) (*models.RainyPlan, []models.GeneratedTournament) {
+	matchesBySport := make(map[int][]*models.RainyPlanMatch)
+	for _, match := range matches {
+		matchesBySport[match.SportID] = append(matchesBySport[match.SportID], match)
+	}
+	teamsBySport := make(map[int][]*models.Team)
+	for _, team := range teams {
+		teamsBySport[team.SportID] = append(teamsBySport[team.SportID], team)
+	}
+	settingByKey := make(map[sportClassKey]*models.RainyModeSetting, len(settings))
+	for _, setting := range settings {
+		settingByKey[sportClassKey{setting.SportID, setting.ClassID}] = setting
+	}
+
+	// 統合先の競技・クラスごとに、移ってくるメンバーと移動元のチーム名を集める
+	incoming := make(map[sportClassKey][]models.RainyPlanMember)
+	incomingName := make(map[sportClassKey]string)
+	for _, sport := range sports {
+		change, ok := changes[sport.SportID]
+		if !ok || change.Action != models.RainySportMerge {
+			continue
+		}
+		for _, team := range teamsBySport[sport.SportID] {
+			key := sportClassKey{*change.MergeIntoSportID, team.ClassID}
+			incoming[key] = appendPlanMembers(incoming[key], members[team.ID])
+			if _, ok := incomingName[key]; !ok {
+				incomingName[key] = team.Name
+			}
+		}
+	}
+
+	plan := &models.RainyPlan{EventID: eventID, Sports: make([]*models.RainySportPlan, 0), Conflicts: make([]string, 0)}
+	tournaments := make([]models.GeneratedTournament, 0)
+	affectedMembers := make(map[string]bool)
+
+	for _, sport := range sports {
+		if sport.Location == "noon_game" {
+			continue
+		}
+		sportPlan := &models.RainySportPlan{
+			SportID:          sport.SportID,
+			SportName:        sport.SportName,
+			Location:         sport.Location,
+			Action:           models.RainySportKeep,
+			CancelledMatches: make([]*models.RainyPlanMatch, 0),
+			PreservedMatches: make([]*models.RainyPlanMatch, 0),
+			Teams:            make([]*models.RainyPlanTeam, 0),
+			ByeSeeds:         make([]*models.RainyByeSeed, 0),
+		}
+
+		if change, ok := changes[sport.SportID]; ok && change.Action != models.RainySportKeep {
+			sportPlan.Action = change.Action
+			sportPlan.MergeIntoSportID = change.MergeIntoSportID
+			for _, match := range matchesBySport[sport.SportID] {
+				switch {
+				case match.Status == models.MatchStatusBye || match.Status == models.MatchStatusCancelled:
+				case match.HasResult:
+					sportPlan.PreservedMatches = append(sportPlan.PreservedMatches, match)
+				default:
+					sportPlan.CancelledMatches = append(sportPlan.CancelledMatches, match)
+				}
+			}
+			for _, team := range teamsBySport[sport.SportID] {
+				planTeam := &models.RainyPlanTeam{
+					TeamID:          team.ID,
+					TeamName:        team.Name,
+					ClassID:         team.ClassID,
+					SportID:         team.SportID,
+					Change:          models.RainyTeamCancelled,
+					MinCapacity:     team.MinCapacity,
+					MaxCapacity:     team.MaxCapacity,
+					Members:         appendPlanMembers(nil, members[team.ID]),
+					ReceivedMembers: make([]models.RainyPlanMember, 0),
+				}
+				if change.Action == models.RainySportMerge {
+					planTeam.Change = models.RainyTeamMerged
+					planTeam.TargetSportID = change.MergeIntoSportID
+				}
+				markAffected(affectedMembers, planTeam.Members)
+				sportPlan.Teams = append(sportPlan.Teams, planTeam)
+			}
+			plan.AffectedMatchCount += len(sportPlan.CancelledMatches)
+			plan.Sports = append(plan.Sports, sportPlan)
+			continue
+		}
+
+		// そのまま実施する競技は、定員の変更と統合で移ってくるメンバーを反映する
+		existingClasses := make(map[int]bool)
+		for _, team := range teamsBySport[sport.SportID] {
+			existingClasses[team.ClassID] = true
+			key := sportClassKey{sport.SportID, team.ClassID}
+			planTeam := &models.RainyPlanTeam{
+				TeamID:      team.ID,
+				TeamName:    team.Name,
+				ClassID:     team.ClassID,
+				SportID:     team.SportID,
+				Change:      models.RainyTeamAdjusted,
+				MinCapacity: team.MinCapacity,
+				MaxCapacity: team.MaxCapacity,
+				Members:     appendPlanMembers(nil, members[team.ID]),
+			}
+			if setting, ok := settingByKey[key]; ok && (setting.MinCapacity != nil || setting.MaxCapacity != nil) {
+				planTeam.MinCapacity = setting.MinCapacity
+				planTeam.MaxCapacity = setting.MaxCapacity
+				planTeam.CapacityChanged = !sameCapacity(team.MinCapacity, setting.MinCapacity) || !sameCapacity(team.MaxCapacity, setting.MaxCapacity)
+			}
+			planTeam.ReceivedMembers = withoutPlanMembers(incoming[key], planTeam.Members)
+			planTeam.OverCapacity = overCapacity(len(planTeam.Members)+len(planTeam.ReceivedMembers), planTeam.MaxCapacity)
+			if !planTeam.CapacityChanged && len(planTeam.ReceivedMembers) == 0 && planTeam.OverCapacity == 0 {
+				continue
+			}
+			markAffected(affectedMembers, planTeam.ReceivedMembers)
+			if planTeam.OverCapacity > 0 {
+				markAffected(affectedMembers, planTeam.Members)
+			}
+			sportPlan.Teams = append(sportPlan.Teams, planTeam)
+		}
+
+		// 統合先にチームのないクラスは、移動元のチーム名でチームを作る
+		createdClasses := make([]int, 0)
+		for key := range incoming {
+			if key.sportID == sport.SportID && !existingClasses[key.classID] {
+				createdClasses = append(createdClasses, key.classID)
+			}
+		}
+		sort.Ints(createdClasses)
+		newTeams := make([]*models.Team, 0, len(createdClasses))
+		for _, classID := range createdClasses {
+			key := sportClassKey{sport.SportID, classID}
+			planTeam := &models.RainyPlanTeam{
+				TeamName:        incomingName[key],
+				ClassID:         classID,
+				SportID:         sport.SportID,
+				Change:          models.RainyTeamCreated,
+				Members:         make([]models.RainyPlanMember, 0),
+				ReceivedMembers: incoming[key],
+			}
+			if setting, ok := settingByKey[key]; ok {
+				planTeam.MinCapacity = setting.MinCapacity
+				planTeam.MaxCapacity = setting.MaxCapacity
+			}
+			planTeam.OverCapacity = overCapacity(len(planTeam.ReceivedMembers), planTeam.MaxCapacity)
+			markAffected(affectedMembers, planTeam.ReceivedMembers)
+			sportPlan.Teams = append(sportPlan.Teams, planTeam)
+			newTeams = append(newTeams, &models.Team{Name: planTeam.TeamName, ClassID: classID, SportID: sport.SportID, EventID: eventID})
+		}
+
+		// 対戦表が既にある競技にチームが増える場合は組み直す。結果入力済みの試合があれば
+		// 実施済みの試合は残し、まだ勝ち上がり先の試合が行われていない不戦勝の枠に新しいチームを入れる。
+		if len(newTeams) > 0 && len(matchesBySport[sport.SportID]) > 0 {
+			hasResult := false
+			for _, match := range matchesBySport[sport.SportID] {
+				if match.HasResult {
+					hasResult = true
+					break
+				}
+			}
+			switch {
+			case hasResult && sport.Format == models.SportFormatLeague:
+				plan.Conflicts = append(plan.Conflicts, fmt.Sprintf("%sはリーグ戦の結果入力済みの試合があるため、統合したチームを対戦表に加えられません", sport.SportName))
+			case hasResult:
+				byes := openByeMatches(matchesBySport[sport.SportID])
+				if len(byes) < len(newTeams) {
+					plan.Conflicts = append(plan.Conflicts, fmt.Sprintf("%sは結果入力済みの試合があり、統合したチームを入れる不戦勝の枠が足りないため対戦表に加えられません", sport.SportName))
+					break
+				}
+				for i, team := range newTeams {
+					sportPlan.ByeSeeds = append(sportPlan.ByeSeeds, &models.RainyByeSeed{
+						MatchID:      byes[i].MatchID,
+						ClassID:      team.ClassID,
+						TeamName:     team.Name,
+						OpponentName: byes[i].Team1Name,
+					})
+				}
+				plan.AffectedMatchCount += len(sportPlan.ByeSeeds)
+			default:
+				bracketTeams := append(append([]*models.Team{}, teamsBySport[sport.SportID]...), newTeams...)
+				generated := generateSportTournaments(eventID, sport, &models.Sport{ID: sport.SportID, Name: sport.SportName}, bracketTeams)
+				if len(generated) == 0 {
+					plan.Conflicts = append(plan.Conflicts, fmt.Sprintf("%sの対戦表を生成できません", sport.SportName))
+					break
+				}
+				sportPlan.RegenerateTournament = true
+				plan.AffectedMatchCount += len(matchesBySport[sport.SportID])
+				tournaments = append(tournaments, generated...)
+			}
+		}
+
+		if len(sportPlan.Teams) > 0 || sportPlan.RegenerateTournament {
+			plan.Sports = append(plan.Sports, sportPlan)
+		}
+	}
+
+	for _, sportPlan := range plan.Sports {
+		plan.AffectedTeamCount += len(sportPlan.Teams)
+	}
+	plan.AffectedMemberCount = len(affectedMembers)
+	return plan, tournaments
+}
+
+// openByeMatches はチームの入った不戦勝の一回戦のうち、勝ち上がり先の試合にまだ結果がないものを返す
+func openByeMatches(matches []*models.RainyPlanMatch) []*models.RainyPlanMatch {
+	byID := make(map[int]*models.RainyPlanMatch, len(matches))
+	for _, match := range matches {
+		byID[match.MatchID] = match
+	}
+
+	byes := make([]*models.RainyPlanMatch, 0)
+	for _, match := range matches {
+		if match.Status != models.MatchStatusBye || match.Round != 0 || match.Team1ID == 0 {
+			continue
+		}
+		next, ok := byID[match.NextMatchID]
+		if !ok || next.HasResult || next.Status == models.MatchStatusCancelled {
+			continue
+		}
+		byes = append(byes, match)
+	}
+	return byes
+}
+
+func appendPlanMembers(dst []models.RainyPlanMember, users []*models.User) []models.RainyPlanMember {
+	if dst == nil {
+		dst = make([]models.RainyPlanMember, 0, len(users))
+	}
+	for _, user := range users {
+		duplicate := false
+		for _, member := range dst {
+			if member.UserID == user.ID {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			dst = append(dst, models.RainyPlanMember{UserID: user.ID, Email: user.Email, DisplayName: user.DisplayName})
+		}
+	}
+	return dst
+}
+
+// withoutPlanMembers は members のうち existing にいない生徒を返す
+func withoutPlanMembers(members []models.RainyPlanMember, existing []models.RainyPlanMember) []models.RainyPlanMember {
+	result := make([]models.RainyPlanMember, 0, len(members))
+	for _, member := range members {
+		found := false
+		for _, e := range existing {
+			if e.UserID == member.UserID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, member)
+		}
+	}
+	return result
+}
+
+func markAffected(affected map[string]bool, members []models.RainyPlanMember) {
+	for _, member := range members {
+		affected[member.UserID] = true
+	}
+}
+
+func sameCapacity(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func overCapacity(count int, maxCapacity *int) int {
+	if maxCapacity == nil || count <= *maxCapacity {
+		return 0
+	}
+	return count - *maxCapacity
+}
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"backapp/internal/models"
+	"backapp/internal/repository"
+	"backapp/internal/schedule"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduleHandler struct {
+	scheduleRepo repository.ScheduleRepository
+	eventRepo    repository.EventRepository
+}
+
+func NewScheduleHandler(scheduleRepo repository.ScheduleRepository, eventRepo repository.EventRepository) *ScheduleHandler {
+	return &ScheduleHandler{
+		scheduleRepo: scheduleRepo,
+		eventRepo:    eventRepo,
+	}
+}
+
+// GetScheduleConfigHandler はイベントの日程設定を返す
+func (h *ScheduleHandler) GetScheduleConfigHandler(c *gin.Context) {
+	eventID, ok := h.parseEventID(c)
+	if !ok {
+		return
+	}
+
+	config, ok := h.loadConfig(c, eventID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// UpdateScheduleConfigHandler は会場ごとのコートと競技ごとの枠の長さを保存する
+func (h *ScheduleHandler) UpdateScheduleConfigHandler(c *gin.Context) {
+	eventID, ok := h.parseEventID(c)
+	if !ok {
+		return
+	}
+
+	var config models.ScheduleConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := config.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	config.EventID = eventID
+
+	if err := h.scheduleRepo.SaveConfig(config, actorUserID(c)); err != nil {
+		log.Printf("SaveConfig error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save schedule config"})
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// GenerateScheduleHandler はイベントの全試合に開始時刻とコートを割り当てる。
+// dry_run=true の場合は保存せずに結果だけを返す。雨天時の開始時刻は変更しない。
+func (h *ScheduleHandler) GenerateScheduleHandler(c *gin.Context) {
+	eventID, ok := h.parseEventID(c)
+	if !ok {
+		return
+	}
+
+	config, ok := h.loadConfig(c, eventID)
+	if !ok {
+		return
+	}
+	matches, ok := h.loadMatches(c, eventID)
+	if !ok {
+		return
+	}
+
+	result, err := schedule.Generate(*config, matches)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	result.EventID = eventID
+
+	if c.Query("dry_run") != "true" {
+		if err := h.scheduleRepo.ApplyAssignments(result.Assignments, actorUserID(c)); err != nil {
+			log.Printf("ApplyAssignments error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save schedule"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetScheduleConflictsHandler は現在の日程で、コート・クラス・生徒の重複と回戦の順序の誤りを返す。
+// rainy_mode=true の場合は雨天時の開始時刻で調べる。日程設定がなければ1試合を標準の長さとみなす。
+func (h *ScheduleHandler) GetScheduleConflictsHandler(c *gin.Context) {
+	eventID, ok := h.parseEventID(c)
+	if !ok {
+		return
+	}
+
+	config, err := h.scheduleRepo.GetConfig(eventID)
+	if err != nil {
+		log.Printf("GetConfig error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule config"})
+		return
+	}
+	if config == nil {
+		defaultConfig := models.DefaultScheduleConfig(eventID)
+		config = &defaultConfig
+	}
+	matches, ok := h.loadMatches(c, eventID)
+	if !ok {
+		return
+	}
+	if c.Query("rainy_mode") == "true" {
+		matches = schedule.RainyModeSchedule(matches)
+	}
+
+	c.JSON(http.StatusOK, models.ScheduleResult{
+		EventID:     eventID,
+		Assignments: []models.ScheduleAssignment{},
+		Conflicts:   schedule.DetectConflicts(*config, matches),
+	})
+}
+
+type ReflowScheduleRequest struct {
+	// EndsAt は延びた試合が終わる見込みの時刻。指定がなければ DelayMinutes を枠の終わりに足す。
+	EndsAt       string `json:"ends_at"`
+	DelayMinutes int    `json:"delay_minutes"`
+}
+
+// ReflowScheduleHandler は試合が延びたときに、それ以降の試合の開始時刻とコートを組み直す
+func (h *ScheduleHandler) ReflowScheduleHandler(c *gin.Context) {
+	matchID, err := strconv.Atoi(c.Param("match_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	var req ReflowScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.EndsAt == "" && req.DelayMinutes <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at or a positive delay_minutes is required"})
+		return
+	}
+
+	eventID, err := h.scheduleRepo.GetEventIDByMatchID(matchID)
+	if err != nil {
+		log.Printf("GetEventIDByMatchID error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve match"})
+		return
+	}
+	if eventID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return
+	}
+
+	config, ok := h.loadConfig(c, eventID)
+	if !ok {
+		return
+	}
+	matches, ok := h.loadMatches(c, eventID)
+	if !ok {
+		return
+	}
+
+	var endsAt time.Time
+	if req.EndsAt != "" {
+		endsAt, err = time.Parse(models.ScheduleTimeLayout, req.EndsAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at must be in YYYY-MM-DD HH:MM:SS format"})
+			return
+		}
+	} else {
+		for _, m := range matches {
+			if m.MatchID == matchID && m.StartTime != nil {
+				endsAt = m.StartTime.Add(config.SlotFor(m.SportID) + time.Duration(req.DelayMinutes)*time.Minute)
+			}
+		}
+	}
+
+	result, err := schedule.Reflow(*config, matches, matchID, endsAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, schedule.ErrMatchNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		case errors.Is(err, schedule.ErrMatchNotScheduled), errors.Is(err, schedule.ErrInvalidEndTime):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			log.Printf("Reflow error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reflow schedule"})
+		}
+		return
+	}
+	result.EventID = eventID
+
+	if err := h.scheduleRepo.ApplyAssignments(result.Assignments, actorUserID(c)); err != nil {
+		log.Printf("ApplyAssignments error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *ScheduleHandler) loadConfig(c *gin.Context, eventID int) (*models.ScheduleConfig, bool) {
+	config, err := h.scheduleRepo.GetConfig(eventID)
+	if err != nil {
+		log.Printf("GetConfig error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule config"})
+		return nil, false
+	}
+	if config == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule config is not set for this event"})
+		return nil, false
+	}
+	return config, true
+}
+
+func (h *ScheduleHandler) loadMatches(c *gin.Context, eventID int) ([]models.ScheduleMatch, bool) {
+	matches, err := h.scheduleRepo.GetMatches(eventID)
+	if err != nil {
+		log.Printf("GetMatches error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve matches"})
+		return nil, false
+	}
+	return matches, true
+}
+
+func (h *ScheduleHandler) parseEventID(c *gin.Context) (int, bool) {
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return 0, false
+	}
+
+	event, err := h.eventRepo.GetEventByID(eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return 0, false
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return 0, false
+	}
+
+	return eventID, true
+}
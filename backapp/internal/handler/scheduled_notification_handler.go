@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WithScheduler は予約通知を有効にし、送信日時を過ぎた予約をこのハンドラーの通知作成と Push 送信で送るようにする
+func (h *NotificationHandler) WithScheduler(scheduler *NotificationScheduler, repo repository.ScheduledNotificationRepository) *NotificationHandler {
+	h.Scheduler = scheduler
+	h.ScheduledRepo = repo
+	scheduler.publish = h.publishScheduledNotification
+	return h
+}
+
+type scheduledNotificationRequest struct {
+	Title                 string                      `json:"title"`
+	Body                  string                      `json:"body"`
+	Type                  string                      `json:"type"`
+	TargetRoles           []string                    `json:"target_roles"`
+	Targets               []models.NotificationTarget `json:"targets"`
+	Channel               string                      `json:"channel"`
+	SendAt                time.Time                   `json:"send_at"`
+	RepeatIntervalMinutes *int                        `json:"repeat_interval_minutes"`
+	RepeatUntil           *time.Time                  `json:"repeat_until"`
+}
+
+func (h *NotificationHandler) CreateScheduledNotification(c *gin.Context) {
+	if h.Scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "予約通知を利用できません"})
+		return
+	}
+
+	userValue, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "ユーザー情報を取得できませんでした"})
+		return
+	}
+	user, ok := userValue.(*models.User)
+	if !ok || user == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ユーザー情報の解析に失敗しました"})
+		return
+	}
+
+	n, ok := h.bindScheduledNotification(c)
+	if !ok {
+		return
+	}
+	n.CreatedBy = user.ID
+
+	id, err := h.ScheduledRepo.CreateScheduledNotification(n)
+	if err != nil {
+		log.Printf("CreateScheduledNotification error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "予約通知の作成に失敗しました"})
+		return
+	}
+
+	n.ID = int(id)
+	n.Status = models.ScheduledNotificationStatusPending
+	c.JSON(http.StatusCreated, gin.H{"message": "通知を予約しました", "scheduled_notification": n})
+}
+
+func (h *NotificationHandler) ListScheduledNotifications(c *gin.Context) {
+	if h.Scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "予約通知を利用できません"})
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.ScheduledNotificationStatusPending, models.ScheduledNotificationStatusSent,
+		models.ScheduledNotificationStatusCancelled, models.ScheduledNotificationStatusMissed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な状態です"})
+		return
+	}
+
+	notifications, err := h.ScheduledRepo.ListScheduledNotifications(status)
+	if err != nil {
+		log.Printf("ListScheduledNotifications error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "予約通知の取得に失敗しました"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"scheduled_notifications": notifications})
+}
+
+// UpdateScheduledNotification は送信前の予約通知の内容と送信日時を変更する
+func (h *NotificationHandler) UpdateScheduledNotification(c *gin.Context) {
+	existing, ok := h.pendingScheduledNotification(c)
+	if !ok {
+		return
+	}
+
+	n, ok := h.bindScheduledNotification(c)
+	if !ok {
+		return
+	}
+	n.ID = existing.ID
+
+	updated, err := h.ScheduledRepo.UpdateScheduledNotification(n)
+	if err != nil {
+		log.Printf("UpdateScheduledNotification error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "予約通知の更新に失敗しました"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusConflict, gin.H{"error": "送信済みまたは取り消し済みの予約通知は変更できません"})
+		return
+	}
+
+	n.Status = existing.Status
+	n.SentCount = existing.SentCount
+	n.CreatedBy = existing.CreatedBy
+	n.CreatedAt = existing.CreatedAt
+	c.JSON(http.StatusOK, gin.H{"message": "予約通知を更新しました", "scheduled_notification": n})
+}
+
+// CancelScheduledNotification は送信前の予約通知を取り消す
+func (h *NotificationHandler) CancelScheduledNotification(c *gin.Context) {
+	existing, ok := h.pendingScheduledNotification(c)
+	if !ok {
+		return
+	}
+
+	cancelled, err := h.ScheduledRepo.CancelScheduledNotification(existing.ID)
+	if err != nil {
+		log.Printf("CancelScheduledNotification error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "予約通知の取り消しに失敗しました"})
+		return
+	}
+	if !cancelled {
+		c.JSON(http.StatusConflict, gin.H{"error": "送信済みまたは取り消し済みの予約通知は取り消せません"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "予約通知を取り消しました"})
+}
+
+// pendingScheduledNotification はパスの予約通知を読み、送信前でなければエラーを返す
+func (h *NotificationHandler) pendingScheduledNotification(c *gin.Context) (*models.ScheduledNotification, bool) {
+	if h.Scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "予約通知を利用できません"})
+		return nil, false
+	}
+
+	id, err := strconv.Atoi(c.Param("scheduled_id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な予約通知IDです"})
+		return nil, false
+	}
+
+	n, err := h.ScheduledRepo.GetScheduledNotification(id)
+	if err != nil {
+		log.Printf("GetScheduledNotification error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "予約通知の取得に失敗しました"})
+		return nil, false
+	}
+	if n == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "予約通知が見つかりません"})
+		return nil, false
+	}
+	if n.Status != models.ScheduledNotificationStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "送信済みまたは取り消し済みの予約通知は変更できません"})
+		return nil, false
+	}
+	return n, true
+}
+
+// bindScheduledNotification はリクエストを検証して予約通知にする。通知の種類・対象ロール・宛先は即時の通知と同じ規則で検証する。
+func (h *NotificationHandler) bindScheduledNotification(c *gin.Context) (*models.ScheduledNotification, bool) {
+	var req scheduledNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不正なリクエスト形式です"})
+		return nil, false
+	}
+
+	req.Title = strings.TrimSpace(req.Title)
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Title == "" || req.Body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "タイトルと本文は必須です"})
+		return nil, false
+	}
+
+	if req.Type == "" {
+		req.Type = models.NotificationTypeGeneral
+	}
+	switch req.Type {
+	case models.NotificationTypeGeneral, models.NotificationTypeMatchMyClass, models.NotificationTypeFinals, models.NotificationTypeAllMatches:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無効な通知タイプです"})
+		return nil, false
+	}
+
+	if req.SendAt.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "送信日時は必須です"})
+		return nil, false
+	}
+	if !req.SendAt.After(h.Scheduler.now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "送信日時は現在より後にしてください"})
+		return nil, false
+	}
+	if req.RepeatIntervalMinutes != nil && *req.RepeatIntervalMinutes <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "繰り返しの間隔は1分以上にしてください"})
+		return nil, false
+	}
+	if req.RepeatUntil != nil {
+		if req.RepeatIntervalMinutes == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "繰り返しの終了日時には繰り返しの間隔が必要です"})
+			return nil, false
+		}
+		if req.RepeatUntil.Before(req.SendAt) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "繰り返しの終了日時は送信日時より後にしてください"})
+			return nil, false
+		}
+	}
+
+	channel, err := normalizeNotificationChannel(req.Channel)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	targets, err := normalizeNotificationTargets(req.Targets)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	// クラスやチームなどの宛先だけを指定した場合、ロールは指定しなくてよい
+	var targetRoles []string
+	if len(req.TargetRoles) > 0 || len(targets) == 0 {
+		availableRoles, err := h.RoleRepo.GetAllRoles()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "ロール情報の取得に失敗しました"})
+			return nil, false
+		}
+		targetRoles, err = normalizeTargetRoles(req.TargetRoles, availableRoles)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+	}
+
+	return &models.ScheduledNotification{
+		Title:                 req.Title,
+		Body:                  req.Body,
+		Type:                  req.Type,
+		TargetRoles:           targetRoles,
+		Targets:               targets,
+		Channel:               channel,
+		SendAt:                req.SendAt,
+		RepeatIntervalMinutes: req.RepeatIntervalMinutes,
+		RepeatUntil:           req.RepeatUntil,
+	}, true
+}
+
+// publishScheduledNotification は予約通知を即時の通知と同じ手順で作成し、予約したチャネルで送る
+func (h *NotificationHandler) publishScheduledNotification(n *models.ScheduledNotification) (int64, error) {
+	channel, err := normalizeNotificationChannel(n.Channel)
+	if err != nil {
+		return 0, err
+	}
+	return h.publishNotification(&notificationDraft{
+		Title:       n.Title,
+		Body:        n.Body,
+		Type:        n.Type,
+		TargetRoles: n.TargetRoles,
+		Targets:     n.Targets,
+		Channel:     channel,
+	}, n.CreatedBy)
+}
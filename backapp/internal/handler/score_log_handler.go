@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"backapp/internal/models"
+	"backapp/internal/repository"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var scoreOperations = map[string]bool{
+	models.ScoreOperationMatchResult:     true,
+	models.ScoreOperationMatchCorrection: true,
+	models.ScoreOperationAttendance:      true,
+	models.ScoreOperationSurvey:          true,
+	models.ScoreOperationMIC:             true,
+	models.ScoreOperationNoonGame:        true,
+	models.ScoreOperationManual:          true,
+	models.ScoreOperationInitial:         true,
+	models.ScoreOperationRecalculation:   true,
+	models.ScoreOperationMatchRestore:    true,
+}
+
+type ScoreLogHandler struct {
+	scoreLogRepo repository.ScoreLogRepository
+	classRepo    repository.ClassRepository
+}
+
+func NewScoreLogHandler(scoreLogRepo repository.ScoreLogRepository, classRepo repository.ClassRepository) *ScoreLogHandler {
+	return &ScoreLogHandler{
+		scoreLogRepo: scoreLogRepo,
+		classRepo:    classRepo,
+	}
+}
+
+// GetClassScoreLogsHandler はクラスの得点履歴を、誰がどの操作で記録したかと打ち消しの対応を含めて返す。
+// reason, operation, match_id, from, to（RFC3339）で絞り込める。
+func (h *ScoreLogHandler) GetClassScoreLogsHandler(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	classID, err := strconv.Atoi(c.Param("class_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid class ID"})
+		return
+	}
+
+	filter := models.ScoreLogFilter{
+		Reason:    c.Query("reason"),
+		Operation: c.Query("operation"),
+	}
+	if filter.Operation != "" && !scoreOperations[filter.Operation] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid operation"})
+		return
+	}
+	if matchIDStr := c.Query("match_id"); matchIDStr != "" {
+		matchID, err := strconv.Atoi(matchIDStr)
+		if err != nil || matchID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+			return
+		}
+		filter.SourceMatchID = matchID
+	}
+	for _, param := range []struct {
+		key  string
+		dest **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		value := c.Query(param.key)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param.key + " (RFC3339 is required)"})
+			return
+		}
+		t = t.UTC()
+		*param.dest = &t
+	}
+
+	class, err := h.classRepo.GetClassByID(classID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get class info"})
+		return
+	}
+	if class == nil || class.EventID == nil || *class.EventID != eventID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class not found in this event"})
+		return
+	}
+
+	logs, err := h.scoreLogRepo.GetClassScoreLogs(eventID, classID, filter)
+	if err != nil {
+		log.Printf("GetClassScoreLogs error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve score logs"})
+		return
+	}
+
+	total := 0
+	for _, entry := range logs {
+		total += entry.Points
+	}
+
+	c.JSON(http.StatusOK, models.ClassScoreHistory{
+		EventID: eventID,
+		ClassID: classID,
+		Total:   total,
+		Logs:    logs,
+	})
+}
+
+// actorUserID は得点台帳に記録する操作ユーザーのIDを返す。認証情報がない場合は空文字列。
+func actorUserID(c *gin.Context) string {
+	userValue, exists := c.Get("user")
+	if !exists {
+		return ""
+	}
+	user, ok := userValue.(*models.User)
+	if !ok || user == nil {
+		return ""
+	}
+	return user.ID
+}
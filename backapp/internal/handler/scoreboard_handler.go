@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"backapp/internal/models"
+	"backapp/internal/repository"
+	"backapp/internal/scoreboard"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scoreboardHeartbeatInterval はプロキシに接続を切られないよう空のコメントを送る間隔
+const scoreboardHeartbeatInterval = 25 * time.Second
+
+type ScoreboardHandler struct {
+	scoreboardRepo repository.ScoreboardRepository
+	eventRepo      repository.EventRepository
+	feed           *scoreboard.Feed
+}
+
+func NewScoreboardHandler(scoreboardRepo repository.ScoreboardRepository, eventRepo repository.EventRepository, feed *scoreboard.Feed) *ScoreboardHandler {
+	return &ScoreboardHandler{
+		scoreboardRepo: scoreboardRepo,
+		eventRepo:      eventRepo,
+		feed:           feed,
+	}
+}
+
+// StreamScoreboardHandler は会場の掲示用に、スコアボードを Server-Sent Events で配信する。
+// ログインは不要で、イベントごとに発行したトークンを token クエリで受け取る。
+// 接続直後に snapshot を送り、その後は試合結果・次の試合・クラス得点・昼競技得点の変更を送る。
+func (h *ScoreboardHandler) StreamScoreboardHandler(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	tokenHash, err := h.scoreboardRepo.GetTokenHash(eventID)
+	if err != nil {
+		log.Printf("GetTokenHash error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify scoreboard token"})
+		return
+	}
+	if tokenHash == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scoreboard feed is not enabled for this event"})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(hashScoreboardToken(c.Query("token"))), []byte(tokenHash)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid scoreboard token"})
+		return
+	}
+
+	// 購読してからスナップショットを作り、スナップショットに含まれる変更は読み飛ばす
+	sub := h.feed.Subscribe(eventID)
+	defer sub.Close()
+
+	snapshot, seq, err := h.feed.Snapshot(eventID)
+	if err != nil {
+		if errors.Is(err, scoreboard.ErrEventNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+			return
+		}
+		log.Printf("Scoreboard snapshot error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build scoreboard"})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if err := writeScoreboardEvent(c.Writer, scoreboard.Message{Seq: seq, Type: models.ScoreboardMessageSnapshot, Data: snapshot}); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(scoreboardHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-sub.C:
+			if !ok {
+				// 配信が追いつかず切断された。クライアントは再接続してスナップショットから取り直す。
+				return
+			}
+			if msg.Seq <= seq {
+				continue
+			}
+			if err := writeScoreboardEvent(c.Writer, msg); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// IssueScoreboardTokenHandler はスコアボード用のトークンを発行する。
+// 既存のトークンは無効になり、接続中の画面は切断される。トークンはこのレスポンスでしか返さない。
+func (h *ScoreboardHandler) IssueScoreboardTokenHandler(c *gin.Context) {
+	eventID, ok := h.parseEventID(c)
+	if !ok {
+		return
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		log.Printf("Scoreboard token generation error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate scoreboard token"})
+		return
+	}
+	token := hex.EncodeToString(raw)
+
+	if err := h.scoreboardRepo.SetTokenHash(eventID, hashScoreboardToken(token), actorUserID(c)); err != nil {
+		log.Printf("SetTokenHash error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save scoreboard token"})
+		return
+	}
+	h.feed.Disconnect(eventID)
+
+	c.JSON(http.StatusOK, models.ScoreboardToken{
+		EventID:    eventID,
+		Token:      token,
+		StreamPath: fmt.Sprintf("/api/scoreboard/events/%d/stream?token=%s", eventID, token),
+	})
+}
+
+// RevokeScoreboardTokenHandler はトークンを失効させ、接続中の画面を切断する
+func (h *ScoreboardHandler) RevokeScoreboardTokenHandler(c *gin.Context) {
+	eventID, ok := h.parseEventID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.scoreboardRepo.DeleteToken(eventID); err != nil {
+		log.Printf("DeleteToken error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke scoreboard token"})
+		return
+	}
+	h.feed.Disconnect(eventID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Scoreboard token revoked"})
+}
+
+func (h *ScoreboardHandler) parseEventID(c *gin.Context) (int, bool) {
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return 0, false
+	}
+
+	event, err := h.eventRepo.GetEventByID(eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return 0, false
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return 0, false
+	}
+
+	return eventID, true
+}
+
+func hashScoreboardToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeScoreboardEvent(w io.Writer, msg scoreboard.Message) error {
+	data, err := json.Marshal(msg.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.Seq, msg.Type, data)
+	return err
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"backapp/internal/models"
+	"backapp/internal/repository"
+	"backapp/internal/scoreboard"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ScoringRuleHandler struct {
+	scoringRuleRepo repository.ScoringRuleRepository
+	tournRepo       repository.TournamentRepository
+	eventRepo       repository.EventRepository
+	scoreboard      *scoreboard.Feed
+}
+
+func NewScoringRuleHandler(scoringRuleRepo repository.ScoringRuleRepository, tournRepo repository.TournamentRepository, eventRepo repository.EventRepository) *ScoringRuleHandler {
+	return &ScoringRuleHandler{
+		scoringRuleRepo: scoringRuleRepo,
+		tournRepo:       tournRepo,
+		eventRepo:       eventRepo,
+	}
+}
+
+// WithScoreboard は再計算による得点の変更を会場のスコアボードにも配信する
+func (h *ScoringRuleHandler) WithScoreboard(feed *scoreboard.Feed) *ScoringRuleHandler {
+	h.scoreboard = feed
+	return h
+}
+
+// scoringRulesResponse は現在適用されている配点ルール。Version が0の場合は保存されたルールがなく従来の配点を使っている。
+type scoringRulesResponse struct {
+	EventID int                 `json:"eventId"`
+	Version int                 `json:"version"`
+	Rules   models.ScoringRules `json:"rules"`
+}
+
+// GetScoringRulesHandler はイベントに現在適用されている配点ルールを返す
+func (h *ScoringRuleHandler) GetScoringRulesHandler(c *gin.Context) {
+	eventID, ok := h.parseEventID(c)
+	if !ok {
+		return
+	}
+
+	set, err := h.scoringRuleRepo.GetLatestRuleSet(eventID)
+	if err != nil {
+		log.Printf("GetLatestRuleSet error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve scoring rules"})
+		return
+	}
+	if set == nil {
+		c.JSON(http.StatusOK, scoringRulesResponse{EventID: eventID, Version: 0, Rules: models.DefaultScoringRules()})
+		return
+	}
+
+	c.JSON(http.StatusOK, scoringRulesResponse{EventID: eventID, Version: set.Version, Rules: set.Rules})
+}
+
+// ListScoringRuleVersionsHandler は保存された配点ルールを新しいバージョン順に返す
+func (h *ScoringRuleHandler) ListScoringRuleVersionsHandler(c *gin.Context) {
+	eventID, ok := h.parseEventID(c)
+	if !ok {
+		return
+	}
+
+	sets, err := h.scoringRuleRepo.ListRuleSets(eventID)
+	if err != nil {
+		log.Printf("ListRuleSets error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve scoring rule versions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sets)
+}
+
+// UpdateScoringRulesHandler は配点ルールを新しいバージョンとして保存する。
+// 既に付与済みの得点は変わらないため、反映するには再計算を実行する。
+func (h *ScoringRuleHandler) UpdateScoringRulesHandler(c *gin.Context) {
+	eventID, ok := h.parseEventID(c)
+	if !ok {
+		return
+	}
+
+	var rules models.ScoringRules
+	if err := c.ShouldBindJSON(&rules); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := rules.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	set, err := h.scoringRuleRepo.CreateRuleSet(eventID, rules, actorUserID(c))
+	if err != nil {
+		log.Printf("CreateRuleSet error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save scoring rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, set)
+}
+
+// RecalculateScoresHandler は現在の配点ルールでイベントの全試合の得点と出席点・アンケート得点・MIC得点を付け直す
+func (h *ScoringRuleHandler) RecalculateScoresHandler(c *gin.Context) {
+	eventID, ok := h.parseEventID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.tournRepo.RecalculateScores(eventID, actorUserID(c))
+	if errors.Is(err, repository.ErrSurveySubmissionsMissing) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Survey submissions are not recorded; re-import the survey before recalculating"})
+		return
+	}
+	if err != nil {
+		log.Printf("RecalculateScores error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recalculate scores"})
+		return
+	}
+	if h.scoreboard != nil {
+		h.scoreboard.ClassScoresChanged(eventID)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *ScoringRuleHandler) parseEventID(c *gin.Context) (int, bool) {
+	eventID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return 0, false
+	}
+
+	event, err := h.eventRepo.GetEventByID(eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return 0, false
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return 0, false
+	}
+
+	return eventID, true
+}
@@ -361,6 +361,59 @@ func (h *SportHandler) UpdateCapacityHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Capacity updated successfully"})
 }
 
+// UpdateSportFormatHandler は競技の試合形式（トーナメント/リーグ）とリーグ設定を更新する。
+func (h *SportHandler) UpdateSportFormatHandler(c *gin.Context) {
+	eventID, err := strconv.Atoi(c.Param("event_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	sportID, err := strconv.Atoi(c.Param("sport_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sport ID"})
+		return
+	}
+
+	var req struct {
+		Format             string `json:"format"`
+		LeagueGroupCount   *int   `json:"league_group_count"`
+		LeagueAdvanceCount *int   `json:"league_advance_count"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	switch req.Format {
+	case models.SportFormatTournament:
+		// トーナメント形式ではリーグ設定を持たない
+		req.LeagueGroupCount = nil
+		req.LeagueAdvanceCount = nil
+	case models.SportFormatLeague:
+		if req.LeagueGroupCount == nil || *req.LeagueGroupCount < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "リーグのグループ数は1以上である必要があります"})
+			return
+		}
+		if req.LeagueAdvanceCount != nil {
+			knockoutSize := *req.LeagueGroupCount * *req.LeagueAdvanceCount
+			if *req.LeagueAdvanceCount < 1 || knockoutSize < 2 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "決勝トーナメントの進出チーム数（グループ数×進出数）は2以上である必要があります"})
+				return
+			}
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format は tournament または league を指定してください"})
+		return
+	}
+
+	if err := h.sportRepo.UpdateSportFormat(eventID, sportID, req.Format, req.LeagueGroupCount, req.LeagueAdvanceCount); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update sport format"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sport format updated successfully"})
+}
+
 // UpdateClassCapacityHandler handles the request to update capacity settings for a specific class in a sport.
 func (h *SportHandler) UpdateClassCapacityHandler(c *gin.Context) {
 	eventID, err := strconv.Atoi(c.Param("event_id"))
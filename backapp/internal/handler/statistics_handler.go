@@ -13,6 +13,7 @@ type StatisticsHandler struct {
 	eventRepo repository.EventRepository
 	sportRepo repository.SportRepository
 	tournRepo repository.TournamentRepository
+	eventDays repository.EventDayRepository
 }
 
 func NewStatisticsHandler(classRepo repository.ClassRepository, eventRepo repository.EventRepository, sportRepo repository.SportRepository, tournRepo repository.TournamentRepository) *StatisticsHandler {
@@ -24,6 +25,31 @@ func NewStatisticsHandler(classRepo repository.ClassRepository, eventRepo reposi
 	}
 }
 
+// WithEventDays は ?day_id= で開催日ごとに絞り込めるようにする
+func (h *StatisticsHandler) WithEventDays(eventDays repository.EventDayRepository) *StatisticsHandler {
+	h.eventDays = eventDays
+	return h
+}
+
+// eventDay は ?day_id= で指定された、アクティブな大会の開催日を返す。指定がなければ nil。
+func (h *StatisticsHandler) eventDay(c *gin.Context, eventID int) (*models.EventDay, bool) {
+	dayID, ok := dayIDQuery(c)
+	if !ok || dayID == nil || h.eventDays == nil {
+		return nil, ok
+	}
+
+	day, err := h.eventDays.GetDayByID(*dayID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get event day"})
+		return nil, false
+	}
+	if day == nil || day.EventID != eventID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event day not found"})
+		return nil, false
+	}
+	return day, true
+}
+
 func (h *StatisticsHandler) GetOverallAttendanceRate(c *gin.Context) {
 	eventID, err := h.eventRepo.GetActiveEvent()
 	if err != nil {
@@ -31,6 +57,11 @@ func (h *StatisticsHandler) GetOverallAttendanceRate(c *gin.Context) {
 		return
 	}
 
+	day, ok := h.eventDay(c, eventID)
+	if !ok {
+		return
+	}
+
 	classes, err := h.classRepo.GetAllClasses(eventID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get classes"})
@@ -43,6 +74,14 @@ func (h *StatisticsHandler) GetOverallAttendanceRate(c *gin.Context) {
 		totalAttendance += class.AttendCount
 		totalStudents += class.StudentCount
 	}
+	// 開催日を指定したときは、その日に出席チェックインした人数で出す
+	if day != nil {
+		totalAttendance, err = h.eventDays.CountCheckedInUsers(day.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get check-ins"})
+			return
+		}
+	}
 
 	rate := 0.0
 	if totalStudents > 0 {
@@ -146,8 +185,18 @@ func (h *StatisticsHandler) GetRealtimeEventProgress(c *gin.Context) {
 		return
 	}
 
+	day, ok := h.eventDay(c, eventID)
+	if !ok {
+		return
+	}
+
 	// 進捗表示ではトーナメント詳細の組み立ては不要なので、sport名だけJOINで軽く取得する。
-	sportNames, err := h.tournRepo.GetTournamentSportNamesByEventID(eventID)
+	var sportNames []string
+	if day != nil {
+		sportNames, err = h.eventDays.GetSportNamesByDay(day.ID)
+	} else {
+		sportNames, err = h.tournRepo.GetTournamentSportNamesByEventID(eventID)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tournaments"})
 		return
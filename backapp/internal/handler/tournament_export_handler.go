@@ -249,21 +249,9 @@ func newTournamentExportStyles(file *excelize.File) (tournamentExportStyles, err
 }
 
 func renderTournamentExportSheet(file *excelize.File, sheetName string, eventName string, tournament *models.Tournament, styles tournamentExportStyles) error {
-	maxRoundIndex := 0
-	data := models.TournamentData{}
-	if len(tournament.Data) > 0 {
-		if err := json.Unmarshal(tournament.Data, &data); err != nil {
-			return err
-		}
-	}
-
-	for _, match := range data.Matches {
-		if match.RoundIndex > maxRoundIndex {
-			maxRoundIndex = match.RoundIndex
-		}
-	}
-	if len(data.Rounds) > 0 && len(data.Rounds)-1 > maxRoundIndex {
-		maxRoundIndex = len(data.Rounds) - 1
+	data, maxRoundIndex, groupedMatches, err := parseTournamentExportData(tournament)
+	if err != nil {
+		return err
 	}
 
 	totalCols := (maxRoundIndex + 1) * 4
@@ -316,23 +304,12 @@ func renderTournamentExportSheet(file *excelize.File, sheetName string, eventNam
 		return nil
 	}
 
-	groupedMatches := make(map[int][]models.Match)
-	for _, match := range data.Matches {
-		groupedMatches[match.RoundIndex] = append(groupedMatches[match.RoundIndex], match)
-	}
-	for roundIndex := range groupedMatches {
-		sort.Slice(groupedMatches[roundIndex], func(i, j int) bool {
-			return groupedMatches[roundIndex][i].Order < groupedMatches[roundIndex][j].Order
-		})
-	}
-
 	for roundIndex := 0; roundIndex <= maxRoundIndex; roundIndex++ {
 		baseCol := 1 + roundIndex*4
 		for _, match := range groupedMatches[roundIndex] {
-			centerRow := 5 + ((1 << (roundIndex + 1)) - 1) + match.Order*(1<<(roundIndex+2))
-			offset := 1 << roundIndex
-			topRow := centerRow - offset
-			bottomRow := centerRow + offset
+			topRow, bottomRow := tournamentSlotRows(roundIndex, match.Order)
+			topRow += 5
+			bottomRow += 5
 
 			if err := writeTournamentSide(file, sheetName, baseCol, topRow, match, 0, data.Contestants, styles); err != nil {
 				return err
@@ -349,6 +326,46 @@ func renderTournamentExportSheet(file *excelize.File, sheetName string, eventNam
 	return nil
 }
 
+// parseTournamentExportData は保存済みトーナメントの対戦データを読み、最終回戦の番号と回戦ごとの試合を返す
+func parseTournamentExportData(tournament *models.Tournament) (models.TournamentData, int, map[int][]models.Match, error) {
+	maxRoundIndex := 0
+	data := models.TournamentData{}
+	if len(tournament.Data) > 0 {
+		if err := json.Unmarshal(tournament.Data, &data); err != nil {
+			return data, 0, nil, err
+		}
+	}
+
+	for _, match := range data.Matches {
+		if match.RoundIndex > maxRoundIndex {
+			maxRoundIndex = match.RoundIndex
+		}
+	}
+	if len(data.Rounds) > 0 && len(data.Rounds)-1 > maxRoundIndex {
+		maxRoundIndex = len(data.Rounds) - 1
+	}
+
+	groupedMatches := make(map[int][]models.Match)
+	for _, match := range data.Matches {
+		groupedMatches[match.RoundIndex] = append(groupedMatches[match.RoundIndex], match)
+	}
+	for roundIndex := range groupedMatches {
+		sort.Slice(groupedMatches[roundIndex], func(i, j int) bool {
+			return groupedMatches[roundIndex][i].Order < groupedMatches[roundIndex][j].Order
+		})
+	}
+
+	return data, maxRoundIndex, groupedMatches, nil
+}
+
+// tournamentSlotRows は試合の上下の枠を置く行を、対戦表の先頭を0として返す。
+// 回戦が進むごとに間隔を倍にし、前の回戦の2試合の中央に次の試合が来るようにする。
+func tournamentSlotRows(roundIndex int, order int) (int, int) {
+	centerRow := ((1 << (roundIndex + 1)) - 1) + order*(1<<(roundIndex+2))
+	offset := 1 << roundIndex
+	return centerRow - offset, centerRow + offset
+}
+
 func setExportColumnWidths(file *excelize.File, sheetName string, baseCol int) error {
 	teamCol, err := excelize.ColumnNumberToName(baseCol)
 	if err != nil {
@@ -420,6 +437,9 @@ func writeTournamentSide(file *excelize.File, sheetName string, baseCol int, row
 func exportSideDisplay(match models.Match, sideIndex int, contestants map[string]models.Contestant) (*models.Side, string, string, bool) {
 	if sideIndex < len(match.Sides) {
 		side := &match.Sides[sideIndex]
+		if match.IsBye && side.ContestantID == "" && side.TeamID == 0 {
+			return nil, "BYE", "", false
+		}
 		label := side.Title
 		if label == "" && side.ContestantID != "" {
 			if contestant, ok := contestants[side.ContestantID]; ok && len(contestant.Players) > 0 {
@@ -1,17 +1,22 @@
 package handler
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 
 	"backapp/internal/models"
+	"backapp/internal/repository"
+	"backapp/internal/schedule"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UpdateMatchStartTimeRequest struct {
 	StartTime string `json:"start_time"`
+	// Force が true ならクラスや生徒の重複があっても保存する
+	Force bool `json:"force"`
 }
 
 func (h *TournamentHandler) UpdateMatchStartTimeHandler(c *gin.Context) {
@@ -26,8 +31,11 @@ func (h *TournamentHandler) UpdateMatchStartTimeHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
+	if !req.Force && !h.checkStartTimeConflicts(c, matchID, req.StartTime, false) {
+		return
+	}
 
-	if err := h.tournRepo.UpdateMatchStartTime(matchID, req.StartTime); err != nil {
+	if err := h.tournRepo.UpdateMatchStartTime(matchID, req.StartTime, actorUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update match start time"})
 		return
 	}
@@ -37,6 +45,7 @@ func (h *TournamentHandler) UpdateMatchStartTimeHandler(c *gin.Context) {
 
 type UpdateMatchRainyModeStartTimeRequest struct {
 	RainyModeStartTime string `json:"rainy_mode_start_time"`
+	Force              bool   `json:"force"`
 }
 
 func (h *TournamentHandler) UpdateMatchRainyModeStartTimeHandler(c *gin.Context) {
@@ -51,8 +60,11 @@ func (h *TournamentHandler) UpdateMatchRainyModeStartTimeHandler(c *gin.Context)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
+	if !req.Force && !h.checkStartTimeConflicts(c, matchID, req.RainyModeStartTime, true) {
+		return
+	}
 
-	if err := h.tournRepo.UpdateMatchRainyModeStartTime(matchID, req.RainyModeStartTime); err != nil {
+	if err := h.tournRepo.UpdateMatchRainyModeStartTime(matchID, req.RainyModeStartTime, actorUserID(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update match rainy mode start time"})
 		return
 	}
@@ -60,11 +72,67 @@ func (h *TournamentHandler) UpdateMatchRainyModeStartTimeHandler(c *gin.Context)
 	c.JSON(http.StatusOK, gin.H{"message": "Match rainy mode start time updated successfully"})
 }
 
+// checkStartTimeConflicts は開始時刻を変えたときに、クラスや生徒が同じ時間に2試合に出ることにならないかを調べる。
+// 重複があれば 409 で重複の一覧を返し、false を返す。
+func (h *TournamentHandler) checkStartTimeConflicts(c *gin.Context, matchID int, startTime string, rainyMode bool) bool {
+	if h.scheduleRepo == nil || startTime == "" {
+		return true
+	}
+
+	start, err := models.ParseScheduleTime(startTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Start time must be in YYYY-MM-DD HH:MM:SS format"})
+		return false
+	}
+
+	eventID, err := h.scheduleRepo.GetEventIDByMatchID(matchID)
+	if err != nil {
+		log.Printf("GetEventIDByMatchID error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve match"})
+		return false
+	}
+	if eventID == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return false
+	}
+
+	config, err := h.scheduleRepo.GetConfig(eventID)
+	if err != nil {
+		log.Printf("GetConfig error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule config"})
+		return false
+	}
+	if config == nil {
+		defaultConfig := models.DefaultScheduleConfig(eventID)
+		config = &defaultConfig
+	}
+
+	matches, err := h.scheduleRepo.GetMatches(eventID)
+	if err != nil {
+		log.Printf("GetMatches error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve matches"})
+		return false
+	}
+
+	if conflicts := schedule.CheckMatchTime(*config, matches, matchID, start, rainyMode); len(conflicts) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     "A class or student would play two matches at the same time",
+			"conflicts": conflicts,
+		})
+		return false
+	}
+	return true
+}
+
 
 type UpdateMatchResultRequest struct {
 	Team1Score int `json:"team1_score"`
 	Team2Score int `json:"team2_score"`
 	WinnerID   int `json:"winner_id,omitempty"`
+	// ResultType は normal（省略時）, walkover, forfeit, double_forfeit, disqualification のいずれか
+	ResultType string `json:"result_type,omitempty"`
+	// ForfeitingTeamID は不出場・棄権・失格となったチーム。walkover, forfeit, disqualification で必須。
+	ForfeitingTeamID int `json:"forfeiting_team_id,omitempty"`
 }
 
 func (h *TournamentHandler) UpdateMatchResultHandler(c *gin.Context) {
@@ -115,17 +183,53 @@ func (h *TournamentHandler) UpdateMatchResultHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
+	if req.ResultType == "" {
+		req.ResultType = models.MatchResultNormal
+	}
+	if !models.IsValidMatchResultType(req.ResultType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "result_type must be one of normal, walkover, forfeit, double_forfeit, disqualification"})
+		return
+	}
+	// 不戦勝は相手チームがいない試合（前の試合が両者不戦敗）に限り、棄権したチームを省略できる
+	if models.MatchResultNeedsForfeitingTeam(req.ResultType) && req.ResultType != models.MatchResultWalkover && req.ForfeitingTeamID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "forfeiting_team_id is required for this result_type"})
+		return
+	}
 
 	// 既に入力済みの場合は修正用メソッドを使用（次の試合のチームも更新）
 	if alreadyEntered {
-		if err := h.tournRepo.UpdateMatchResultForCorrection(matchID, req.Team1Score, req.Team2Score, req.WinnerID); err != nil {
+		if err := h.tournRepo.UpdateMatchResultForCorrection(matchID, req.Team1Score, req.Team2Score, req.WinnerID, req.ResultType, req.ForfeitingTeamID, actorUserID(c)); err != nil {
+			if errors.Is(err, repository.ErrInvalidMatchResult) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, repository.ErrMatchTeamsNotDecided) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "対戦チームが揃っていない試合には結果を入力できません"})
+				return
+			}
 			log.Printf("UpdateMatchResultForCorrection error: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to correct match result"})
 			return
 		}
 	} else {
 		// 未入力の場合は通常の更新メソッドを使用
-		if err := h.tournRepo.UpdateMatchResult(matchID, req.Team1Score, req.Team2Score, req.WinnerID); err != nil {
+		if err := h.tournRepo.UpdateMatchResult(matchID, req.Team1Score, req.Team2Score, req.WinnerID, req.ResultType, req.ForfeitingTeamID, actorUserID(c)); err != nil {
+			if errors.Is(err, repository.ErrByeMatchResult) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "不戦勝の試合には結果を入力できません"})
+				return
+			}
+			if errors.Is(err, repository.ErrCancelledMatchResult) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "中止になった試合には結果を入力できません"})
+				return
+			}
+			if errors.Is(err, repository.ErrInvalidMatchResult) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, repository.ErrMatchTeamsNotDecided) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "対戦チームが揃っていない試合には結果を入力できません"})
+				return
+			}
 			log.Printf("UpdateMatchResult error: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update match result"})
 			return
@@ -136,6 +240,13 @@ func (h *TournamentHandler) UpdateMatchResultHandler(c *gin.Context) {
 	if err == nil && h.hubManager != nil {
 		h.hubManager.BroadcastTo("tournament:"+strconv.Itoa(tournamentID), gin.H{"type": "update"})
 	}
+	if h.scoreboard != nil {
+		h.scoreboard.MatchUpdated(matchID)
+	}
+	// 結果の通知は初回の入力だけ送る。修正のたびには送らない。
+	if h.notifier != nil && !alreadyEntered {
+		go h.notifier.MatchFinished(matchID)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Match result updated successfully"})
 }
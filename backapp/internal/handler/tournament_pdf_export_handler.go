@@ -0,0 +1,457 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"backapp/internal/fonts"
+	"backapp/internal/models"
+	"backapp/internal/schedule"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfExportFont   = "mplus"
+	pdfExportMargin = 10.0
+	// pdfExportRoundWidth は対戦表の1回戦分の幅（mm）。Excel の列幅 24/6/4/4 と同じ比率で分ける。
+	pdfExportRoundWidth = 58.0
+	pdfExportMaxRowStep = 5.0
+	pdfExportTableRow   = 7.0
+	pdfExportTimeLayout = "1/2 15:04"
+)
+
+// pdfExportColumn はタイムテーブルの列
+type pdfExportColumn struct {
+	title string
+	width float64
+}
+
+// pdfExportMatch はタイムテーブルとクラス別の試合予定に載せる1試合
+type pdfExportMatch struct {
+	schedule  models.ScheduleMatch
+	sportName string
+	round     string
+	matchup   string
+	result    string
+}
+
+// ExportTournamentsPDFHandler は競技ごとの対戦表、コート別のタイムテーブル、クラス別の試合予定を1つの PDF にまとめて返す
+func (h *TournamentHandler) ExportTournamentsPDFHandler(c *gin.Context) {
+	eventIDStr := c.Param("id")
+	eventID, err := strconv.Atoi(eventIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	tournaments, err := h.tournRepo.GetTournamentsByEventID(eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tournaments"})
+		return
+	}
+	if len(tournaments) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "保存済みトーナメントがありません"})
+		return
+	}
+
+	event, err := h.eventRepo.GetEventByID(eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	eventName := fmt.Sprintf("Event %d", eventID)
+	isRainyMode := false
+	if event != nil {
+		if event.Name != "" {
+			eventName = event.Name
+		}
+		isRainyMode = event.IsRainyMode
+	}
+
+	var matches []models.ScheduleMatch
+	if h.scheduleRepo != nil {
+		matches, err = h.scheduleRepo.GetMatches(eventID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve matches"})
+			return
+		}
+		// 雨天時は雨天用の開始時刻で並べる。会場が変わるためコートは使わない。
+		if isRainyMode {
+			matches = schedule.RainyModeSchedule(matches)
+		}
+	}
+
+	classes, err := h.classRepo.GetAllClasses(eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve classes"})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := buildTournamentExportPDF(&buf, eventName, tournaments, matches, classes); err != nil {
+		log.Printf("ExportTournamentsPDF error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create PDF"})
+		return
+	}
+
+	filename := fmt.Sprintf("event_%d_tournaments.pdf", eventID)
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+func buildTournamentExportPDF(w *bytes.Buffer, eventName string, tournaments []*models.Tournament, matches []models.ScheduleMatch, classes []*models.Class) error {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetMargins(pdfExportMargin, pdfExportMargin, pdfExportMargin)
+	pdf.SetAutoPageBreak(false, pdfExportMargin)
+	pdf.AddUTF8FontFromBytes(pdfExportFont, "", fonts.MPlus1pRegular)
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+
+	exportMatches := make(map[int]*pdfExportMatch)
+	tournamentNames := make(map[int]string, len(tournaments))
+	for _, tournament := range tournaments {
+		tournamentNames[tournament.ID] = tournament.Name
+		data, maxRoundIndex, groupedMatches, err := parseTournamentExportData(tournament)
+		if err != nil {
+			return err
+		}
+		renderTournamentPDFBracket(pdf, eventName, tournament, data, maxRoundIndex, groupedMatches)
+
+		for _, match := range data.Matches {
+			if match.ID == 0 {
+				continue
+			}
+			exportMatches[match.ID] = &pdfExportMatch{
+				sportName: tournament.Name,
+				round:     exportRoundLabel(data.Rounds, match.RoundIndex),
+				matchup:   pdfExportMatchup(match, data.Contestants),
+				result:    pdfExportResult(match, data.Contestants),
+			}
+		}
+	}
+
+	scheduled := make([]*pdfExportMatch, 0, len(matches))
+	for _, m := range matches {
+		entry, ok := exportMatches[m.MatchID]
+		if !ok {
+			entry = &pdfExportMatch{sportName: tournamentNames[m.TournamentID], round: fmt.Sprintf("Round %d", m.Round+1), matchup: "TBD vs TBD"}
+		}
+		entry.schedule = m
+		scheduled = append(scheduled, entry)
+	}
+	sort.SliceStable(scheduled, func(i, j int) bool {
+		return pdfExportMatchBefore(scheduled[i], scheduled[j])
+	})
+
+	renderCourtTimetablePDF(pdf, eventName, scheduled)
+	renderClassMatchesPDF(pdf, eventName, scheduled, classes)
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+// renderTournamentPDFBracket は Excel 出力と同じ配置で1競技の対戦表を1ページに描く。収まらない場合は縮小する。
+func renderTournamentPDFBracket(pdf *fpdf.Fpdf, eventName string, tournament *models.Tournament, data models.TournamentData, maxRoundIndex int, groupedMatches map[int][]models.Match) {
+	pdf.AddPage()
+	pageWidth, pageHeight := pdf.GetPageSize()
+	writePDFTitle(pdf, fmt.Sprintf("%s - %s", eventName, tournament.Name))
+
+	totalRows := 1
+	for roundIndex, roundMatches := range groupedMatches {
+		for _, match := range roundMatches {
+			if _, bottomRow := tournamentSlotRows(roundIndex, match.Order); bottomRow+1 > totalRows {
+				totalRows = bottomRow + 1
+			}
+		}
+	}
+	roundWidth := pdfExportRoundWidth
+	if available := (pageWidth - 2*pdfExportMargin) / float64(maxRoundIndex+1); available < roundWidth {
+		roundWidth = available
+	}
+	teamWidth := roundWidth * 24 / 38
+	scoreWidth := roundWidth * 6 / 38
+	lineWidth := roundWidth * 4 / 38
+
+	headerY := pdf.GetY()
+	pdf.SetFillColor(226, 232, 240)
+	pdf.SetDrawColor(100, 116, 139)
+	pdf.SetTextColor(30, 41, 59)
+	pdf.SetFontSize(9)
+	for roundIndex := 0; roundIndex <= maxRoundIndex; roundIndex++ {
+		pdf.SetXY(pdfExportMargin+float64(roundIndex)*roundWidth, headerY)
+		pdf.CellFormat(teamWidth+scoreWidth, 6, exportRoundLabel(data.Rounds, roundIndex), "1", 0, "C", true, 0, "")
+	}
+
+	if len(data.Matches) == 0 {
+		pdf.SetXY(pdfExportMargin, headerY+10)
+		pdf.SetTextColor(100, 116, 139)
+		pdf.CellFormat(0, 6, "トーナメントの対戦データがありません。", "", 0, "L", false, 0, "")
+		return
+	}
+
+	top := headerY + 9
+	rowStep := (pageHeight - pdfExportMargin - top) / float64(totalRows)
+	if rowStep > pdfExportMaxRowStep {
+		rowStep = pdfExportMaxRowStep
+	}
+	boxHeight := rowStep * 1.6
+	fontSize := boxHeight * 2.0
+	if fontSize > 9 {
+		fontSize = 9
+	}
+	pdf.SetFontSize(fontSize)
+	pdf.SetLineWidth(0.3)
+
+	rowCenter := func(row int) float64 {
+		return top + (float64(row)+0.5)*rowStep
+	}
+
+	for roundIndex := 0; roundIndex <= maxRoundIndex; roundIndex++ {
+		baseX := pdfExportMargin + float64(roundIndex)*roundWidth
+		connectorX := baseX + teamWidth + scoreWidth + lineWidth
+		for _, match := range groupedMatches[roundIndex] {
+			topRow, bottomRow := tournamentSlotRows(roundIndex, match.Order)
+			for sideIndex, row := range []int{topRow, bottomRow} {
+				side, label, score, isWinner := exportSideDisplay(match, sideIndex, data.Contestants)
+				y := rowCenter(row) - boxHeight/2
+				if isWinner {
+					pdf.SetFillColor(220, 252, 231)
+					pdf.SetDrawColor(22, 163, 74)
+					pdf.SetTextColor(20, 83, 45)
+				} else {
+					pdf.SetFillColor(255, 255, 255)
+					pdf.SetDrawColor(203, 213, 225)
+					pdf.SetTextColor(15, 23, 42)
+				}
+				pdf.SetXY(baseX, y)
+				pdf.CellFormat(teamWidth, boxHeight, fitPDFText(pdf, label, teamWidth-2), "1", 0, "L", true, 0, "")
+				pdf.CellFormat(scoreWidth, boxHeight, score, "1", 0, "C", true, 0, "")
+
+				if side != nil {
+					pdf.SetDrawColor(71, 85, 105)
+					pdf.Line(baseX+teamWidth+scoreWidth, rowCenter(row), connectorX, rowCenter(row))
+				}
+			}
+
+			pdf.SetDrawColor(71, 85, 105)
+			pdf.Line(connectorX, rowCenter(topRow), connectorX, rowCenter(bottomRow))
+			if roundIndex < maxRoundIndex {
+				middle := (rowCenter(topRow) + rowCenter(bottomRow)) / 2
+				pdf.Line(connectorX, middle, baseX+roundWidth, middle)
+			}
+		}
+	}
+}
+
+// renderCourtTimetablePDF は開始時刻が決まっている試合を会場・コートごとに時刻順で並べる
+func renderCourtTimetablePDF(pdf *fpdf.Fpdf, eventName string, matches []*pdfExportMatch) {
+	courts := make([]string, 0)
+	byCourt := make(map[string][]*pdfExportMatch)
+	for _, m := range matches {
+		if m.schedule.StartTime == nil {
+			continue
+		}
+		court := pdfExportCourtLabel(m.schedule)
+		if _, exists := byCourt[court]; !exists {
+			courts = append(courts, court)
+		}
+		byCourt[court] = append(byCourt[court], m)
+	}
+	sort.Strings(courts)
+
+	columns := []pdfExportColumn{
+		{title: "開始時刻", width: 30},
+		{title: "競技", width: 55},
+		{title: "回戦", width: 35},
+		{title: "対戦", width: 107},
+		{title: "結果", width: 50},
+	}
+
+	if len(courts) == 0 {
+		pdf.AddPage()
+		writePDFTitle(pdf, fmt.Sprintf("%s - コート別タイムテーブル", eventName))
+		writePDFMessage(pdf, "開始時刻が設定された試合がありません。")
+		return
+	}
+
+	for _, court := range courts {
+		title := fmt.Sprintf("%s - コート別タイムテーブル（%s）", eventName, court)
+		rows := make([][]string, 0, len(byCourt[court]))
+		for _, m := range byCourt[court] {
+			rows = append(rows, []string{m.schedule.StartTime.Format(pdfExportTimeLayout), m.sportName, m.round, m.matchup, m.result})
+		}
+		writePDFTable(pdf, title, columns, rows)
+	}
+}
+
+// renderClassMatchesPDF はクラスごとに1ページ、そのクラスが出る試合を時刻順に並べる
+func renderClassMatchesPDF(pdf *fpdf.Fpdf, eventName string, matches []*pdfExportMatch, classes []*models.Class) {
+	columns := []pdfExportColumn{
+		{title: "開始時刻", width: 30},
+		{title: "競技", width: 50},
+		{title: "回戦", width: 32},
+		{title: "会場・コート", width: 45},
+		{title: "対戦", width: 120},
+	}
+
+	for _, class := range classes {
+		title := fmt.Sprintf("%s - %s の試合予定", eventName, class.Name)
+		rows := make([][]string, 0)
+		for _, m := range matches {
+			if !containsInt(m.schedule.ClassIDs, class.ID) {
+				continue
+			}
+			startTime := "未定"
+			if m.schedule.StartTime != nil {
+				startTime = m.schedule.StartTime.Format(pdfExportTimeLayout)
+			}
+			rows = append(rows, []string{startTime, m.sportName, m.round, pdfExportCourtLabel(m.schedule), m.matchup})
+		}
+		if len(rows) == 0 {
+			pdf.AddPage()
+			writePDFTitle(pdf, title)
+			writePDFMessage(pdf, "予定されている試合はありません。")
+			continue
+		}
+		writePDFTable(pdf, title, columns, rows)
+	}
+}
+
+// writePDFTable は見出し付きの表を描く。ページに収まらない行は次のページに送り、見出しを繰り返す。
+func writePDFTable(pdf *fpdf.Fpdf, title string, columns []pdfExportColumn, rows [][]string) {
+	_, pageHeight := pdf.GetPageSize()
+	writeHeader := func() {
+		pdf.AddPage()
+		writePDFTitle(pdf, title)
+		pdf.SetFontSize(10)
+		pdf.SetFillColor(226, 232, 240)
+		pdf.SetDrawColor(100, 116, 139)
+		pdf.SetTextColor(30, 41, 59)
+		for _, column := range columns {
+			pdf.CellFormat(column.width, pdfExportTableRow, column.title, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(pdfExportTableRow)
+	}
+
+	writeHeader()
+	for _, row := range rows {
+		if pdf.GetY()+pdfExportTableRow > pageHeight-pdfExportMargin {
+			writeHeader()
+		}
+		pdf.SetFillColor(255, 255, 255)
+		pdf.SetDrawColor(203, 213, 225)
+		pdf.SetTextColor(15, 23, 42)
+		for i, column := range columns {
+			pdf.CellFormat(column.width, pdfExportTableRow, fitPDFText(pdf, row[i], column.width-2), "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(pdfExportTableRow)
+	}
+}
+
+func writePDFTitle(pdf *fpdf.Fpdf, title string) {
+	pdf.SetFont(pdfExportFont, "", 16)
+	pdf.SetFillColor(219, 234, 254)
+	pdf.SetTextColor(15, 23, 42)
+	pdf.CellFormat(0, 10, title, "", 1, "C", true, 0, "")
+	pdf.Ln(3)
+}
+
+func writePDFMessage(pdf *fpdf.Fpdf, message string) {
+	pdf.SetFontSize(10)
+	pdf.SetTextColor(100, 116, 139)
+	pdf.CellFormat(0, 8, message, "", 1, "L", false, 0, "")
+}
+
+// fitPDFText は枠の幅に収まるよう末尾を省略する
+func fitPDFText(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := string(runes) + "…"
+		if pdf.GetStringWidth(candidate) <= width {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func pdfExportMatchup(match models.Match, contestants map[string]models.Contestant) string {
+	_, team1, _, _ := exportSideDisplay(match, 0, contestants)
+	_, team2, _, _ := exportSideDisplay(match, 1, contestants)
+	return fmt.Sprintf("%s vs %s", team1, team2)
+}
+
+// pdfExportResult は終わった試合のスコアと勝者を返す。不戦勝や棄権は種別名を付ける。
+func pdfExportResult(match models.Match, contestants map[string]models.Contestant) string {
+	_, team1, score1, winner1 := exportSideDisplay(match, 0, contestants)
+	_, team2, score2, winner2 := exportSideDisplay(match, 1, contestants)
+	result := ""
+	if score1 != "" && score2 != "" {
+		result = fmt.Sprintf("%s - %s", score1, score2)
+	}
+	if winner1 != winner2 {
+		winner := team1
+		if winner2 {
+			winner = team2
+		}
+		if result != "" {
+			result += " "
+		}
+		result += fmt.Sprintf("(%s 勝)", winner)
+	}
+	if label := models.MatchResultLabel(match.ResultType); label != "" {
+		result = label + " " + result
+	}
+	return result
+}
+
+func pdfExportCourtLabel(m models.ScheduleMatch) string {
+	location := m.Location
+	if location == "" {
+		location = "会場未定"
+	}
+	if m.Court == "" {
+		return location
+	}
+	return fmt.Sprintf("%s コート%s", location, m.Court)
+}
+
+// pdfExportMatchBefore は開始時刻順（未定は最後）、同時刻はトーナメント・回戦・試合番号順に並べる
+func pdfExportMatchBefore(a, b *pdfExportMatch) bool {
+	at, bt := a.schedule.StartTime, b.schedule.StartTime
+	if (at == nil) != (bt == nil) {
+		return at != nil
+	}
+	if at != nil && !at.Equal(*bt) {
+		return at.Before(*bt)
+	}
+	if a.schedule.TournamentID != b.schedule.TournamentID {
+		return a.schedule.TournamentID < b.schedule.TournamentID
+	}
+	if a.schedule.Round != b.schedule.Round {
+		return a.schedule.Round < b.schedule.Round
+	}
+	return a.schedule.MatchNumberInRound < b.schedule.MatchNumberInRound
+}
+
+func containsInt(values []int, target int) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package identity
+
+import "strings"
+
+// myIDFormat は MyID 学生証の Code39 バーコード。接頭辞・学籍番号・チェックディジットの順に並ぶ。
+// バーコードリーダーによっては Code39 の開始・終了文字の * がそのまま入力される
+type myIDFormat struct {
+	prefixes []string
+	length   int
+}
+
+func (f myIDFormat) Name() string { return FormatMyID }
+
+func (f myIDFormat) Parse(data string) (Identifier, error) {
+	barcode := data
+	if len(barcode) >= 2 && strings.HasPrefix(barcode, "*") && strings.HasSuffix(barcode, "*") {
+		barcode = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(barcode, "*"), "*"))
+	}
+	barcode = strings.ToUpper(barcode)
+
+	for _, prefix := range f.prefixes {
+		if !strings.HasPrefix(barcode, prefix) {
+			continue
+		}
+		digits := strings.TrimPrefix(barcode, prefix)
+		if len(digits) != f.length+1 || !isDigits(digits) {
+			continue
+		}
+		return Identifier{Kind: KindStudentNumber, Value: digits[:f.length]}, nil
+	}
+	return Identifier{}, ErrUnrecognized
+}
+
+// studentNumberFormat は学籍番号だけのデータ。キーボードでの手入力や番号だけを印字したカードに使う
+type studentNumberFormat struct {
+	length int
+}
+
+func (f studentNumberFormat) Name() string { return FormatStudentNumber }
+
+func (f studentNumberFormat) Parse(data string) (Identifier, error) {
+	if len(data) != f.length || !isDigits(data) {
+		return Identifier{}, ErrUnrecognized
+	}
+	return Identifier{Kind: KindStudentNumber, Value: data}, nil
+}
+
+const sportEaseQRPrefix = "SPORTEASE:ID:"
+
+// sportEaseQRFormat は SportEase が発行した QR コード。接頭辞の後にトークンが入る
+type sportEaseQRFormat struct{}
+
+func (sportEaseQRFormat) Name() string { return FormatSportEaseQR }
+
+func (sportEaseQRFormat) Parse(data string) (Identifier, error) {
+	if !strings.HasPrefix(data, sportEaseQRPrefix) {
+		return Identifier{}, ErrUnrecognized
+	}
+	token := strings.TrimPrefix(data, sportEaseQRPrefix)
+	if len(token) < 16 || len(token) > 64 {
+		return Identifier{}, ErrUnrecognized
+	}
+	for _, char := range token {
+		isTokenChar := char >= 'A' && char <= 'Z' || char >= 'a' && char <= 'z' || char >= '0' && char <= '9' || char == '-' || char == '_'
+		if !isTokenChar {
+			return Identifier{}, ErrUnrecognized
+		}
+	}
+	return Identifier{Kind: KindPassToken, Value: token}, nil
+}
+
+func isDigits(value string) bool {
+	for _, char := range value {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
@@ -0,0 +1,161 @@
+// Package identity は読み取ったバーコード・QRコードから本人を特定するための識別子を取り出す。
+// 読める形式は設定で切り替えられ、MyID の学生証バーコードのほか、学籍番号の手入力、
+// MyID を持たない人向けに SportEase が発行する QR コード、スマートフォンに表示する署名付きパスに対応する。
+package identity
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Identifier.Kind の値
+const (
+	KindStudentNumber = "student_number"
+	KindPassToken     = "pass_token"
+	KindUserID        = "user_id"
+)
+
+// 設定で指定できる形式の名前
+const (
+	FormatMyID          = "myid"
+	FormatStudentNumber = "student_number"
+	FormatSportEaseQR   = "sportease_qr"
+	FormatSignedPass    = "signed_pass"
+)
+
+// FormatManual は読み取りではなく、手動照合で管理者が本人を選んだことを表す
+const FormatManual = "manual"
+
+var ErrUnrecognized = errors.New("unrecognized identifier format")
+
+// Identifier は読み取ったデータから取り出した識別子。Format は読めた形式の名前。
+// EventID は大会に結び付いた署名付きパスのときだけ入る
+type Identifier struct {
+	Kind    string
+	Value   string
+	Format  string
+	EventID int
+}
+
+// Format は1種類のバーコード・QRコードの読み方。この形式でなければ ErrUnrecognized を返し、
+// この形式だが使えないデータ（署名が合わない・期限切れなど）ならそれを表すエラーを返す
+type Format interface {
+	Name() string
+	Parse(data string) (Identifier, error)
+}
+
+type Config struct {
+	// Formats は試す順番に並べた形式の名前
+	Formats []string
+	// MyIDPrefixes は MyID バーコードの学籍番号の前に付く文字列。再発行した学生証は別の接頭辞になる
+	MyIDPrefixes        []string
+	StudentNumberLength int
+	// PassSigner があれば署名付きパスを読めるようにし、既定の形式では最初に試す
+	PassSigner *PassSigner
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Formats:             []string{FormatMyID, FormatSportEaseQR},
+		MyIDPrefixes:        []string{"H10"},
+		StudentNumberLength: 7,
+	}
+}
+
+// Parser は設定された形式を順に試して識別子を取り出す
+type Parser struct {
+	formats []Format
+}
+
+// NewParser は設定から Parser を作る。空の項目には既定値を使う
+func NewParser(cfg Config) (*Parser, error) {
+	defaults := DefaultConfig()
+	if len(cfg.Formats) == 0 {
+		cfg.Formats = defaults.Formats
+		if cfg.PassSigner != nil {
+			cfg.Formats = append([]string{FormatSignedPass}, cfg.Formats...)
+		}
+	}
+	if len(cfg.MyIDPrefixes) == 0 {
+		cfg.MyIDPrefixes = defaults.MyIDPrefixes
+	}
+	if cfg.StudentNumberLength <= 0 {
+		cfg.StudentNumberLength = defaults.StudentNumberLength
+	}
+
+	formats := make([]Format, 0, len(cfg.Formats))
+	for _, name := range cfg.Formats {
+		switch strings.TrimSpace(name) {
+		case FormatMyID:
+			prefixes := make([]string, 0, len(cfg.MyIDPrefixes))
+			for _, prefix := range cfg.MyIDPrefixes {
+				prefixes = append(prefixes, strings.ToUpper(strings.TrimSpace(prefix)))
+			}
+			formats = append(formats, myIDFormat{prefixes: prefixes, length: cfg.StudentNumberLength})
+		case FormatStudentNumber:
+			formats = append(formats, studentNumberFormat{length: cfg.StudentNumberLength})
+		case FormatSportEaseQR:
+			formats = append(formats, sportEaseQRFormat{})
+		case FormatSignedPass:
+			if cfg.PassSigner == nil {
+				return nil, fmt.Errorf("identifier format %q requires a pass signing secret", name)
+			}
+			formats = append(formats, signedPassFormat{signer: cfg.PassSigner})
+		default:
+			return nil, fmt.Errorf("unknown identifier format %q", name)
+		}
+	}
+	return &Parser{formats: formats}, nil
+}
+
+// DefaultParser は既定の設定の Parser を返す
+func DefaultParser() *Parser {
+	parser, _ := NewParser(DefaultConfig())
+	return parser
+}
+
+// Parse は読み取ったデータを設定の順に各形式で読み、最初に読めた識別子を返す
+func (p *Parser) Parse(data string) (Identifier, error) {
+	trimmed := strings.TrimSpace(data)
+	if trimmed == "" {
+		return Identifier{}, ErrUnrecognized
+	}
+	for _, format := range p.formats {
+		id, err := format.Parse(trimmed)
+		if errors.Is(err, ErrUnrecognized) {
+			continue
+		}
+		if err != nil {
+			return Identifier{Format: format.Name()}, err
+		}
+		id.Format = format.Name()
+		return id, nil
+	}
+	return Identifier{}, ErrUnrecognized
+}
+
+// FormatNames は有効な形式の名前を試す順に返す
+func (p *Parser) FormatNames() []string {
+	names := make([]string, 0, len(p.formats))
+	for _, format := range p.formats {
+		names = append(names, format.Name())
+	}
+	return names
+}
+
+// NewPassToken は SportEase が発行する QR コードに載せるランダムなトークンを作る
+func NewPassToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// PassPayload は QR コードに載せる文字列を返す
+func PassPayload(token string) string {
+	return sportEaseQRPrefix + token
+}
@@ -0,0 +1,73 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_Parse(t *testing.T) {
+	parser, err := NewParser(Config{
+		Formats:             []string{FormatMyID, FormatSportEaseQR, FormatStudentNumber},
+		MyIDPrefixes:        []string{"H10", "h11"},
+		StudentNumberLength: 7,
+	})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name   string
+		data   string
+		kind   string
+		value  string
+		format string
+	}{
+		{"MyID バーコード", "H1023010590", KindStudentNumber, "2301059", FormatMyID},
+		{"Code39 の開始・終了文字付き", " *h1023010590* ", KindStudentNumber, "2301059", FormatMyID},
+		{"再発行した学生証の接頭辞", "H1123010590", KindStudentNumber, "2301059", FormatMyID},
+		{"学籍番号の手入力", "2301059", KindStudentNumber, "2301059", FormatStudentNumber},
+		{"SportEase の QR コード", PassPayload("abcDEF0123456789_-xyz"), KindPassToken, "abcDEF0123456789_-xyz", FormatSportEaseQR},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			id, err := parser.Parse(tc.data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.kind, id.Kind)
+			assert.Equal(t, tc.value, id.Value)
+			assert.Equal(t, tc.format, id.Format)
+		})
+	}
+
+	for _, data := range []string{"", "X999", "H10230105", "H102301059A", "SPORTEASE:ID:short", "230105"} {
+		_, err := parser.Parse(data)
+		assert.ErrorIs(t, err, ErrUnrecognized, data)
+	}
+}
+
+func TestNewParser(t *testing.T) {
+	t.Run("既定では MyID と SportEase の QR コードを読む", func(t *testing.T) {
+		parser, err := NewParser(Config{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{FormatMyID, FormatSportEaseQR}, parser.FormatNames())
+
+		_, err = parser.Parse("2301059")
+		assert.ErrorIs(t, err, ErrUnrecognized)
+	})
+
+	t.Run("知らない形式はエラー", func(t *testing.T) {
+		_, err := NewParser(Config{Formats: []string{"felica"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestNewPassToken(t *testing.T) {
+	token, err := NewPassToken()
+	require.NoError(t, err)
+	other, err := NewPassToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+
+	id, err := DefaultParser().Parse(PassPayload(token))
+	require.NoError(t, err)
+	assert.Equal(t, token, id.Value)
+}
@@ -0,0 +1,483 @@
+package identity
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+var ErrQRDataTooLong = errors.New("data is too long for a QR code")
+
+// QRCode は誤り訂正レベル M・8ビットバイトモードで符号化した QR コード。
+// 発行するトークンが収まれば十分なので、型番は1〜10だけを扱う
+type QRCode struct {
+	Version int
+	Size    int
+	modules [][]bool
+}
+
+// qrBlockGroup は型番ごとの RS ブロック構成（ブロック数とブロックあたりのデータコード語数）
+type qrBlockGroup struct {
+	count     int
+	dataWords int
+}
+
+type qrVersionM struct {
+	eccPerBlock int
+	groups      []qrBlockGroup
+	alignment   []int
+}
+
+// 誤り訂正レベル M の型番1〜10の構成（JIS X 0510 表9・付属書E）
+var qrVersionsM = []qrVersionM{
+	{},
+	{10, []qrBlockGroup{{1, 16}}, nil},
+	{16, []qrBlockGroup{{1, 28}}, []int{6, 18}},
+	{26, []qrBlockGroup{{1, 44}}, []int{6, 22}},
+	{18, []qrBlockGroup{{2, 32}}, []int{6, 26}},
+	{24, []qrBlockGroup{{2, 43}}, []int{6, 30}},
+	{16, []qrBlockGroup{{4, 27}}, []int{6, 34}},
+	{18, []qrBlockGroup{{4, 31}}, []int{6, 22, 38}},
+	{22, []qrBlockGroup{{2, 38}, {2, 39}}, []int{6, 24, 42}},
+	{22, []qrBlockGroup{{3, 36}, {2, 37}}, []int{6, 26, 46}},
+	{26, []qrBlockGroup{{4, 43}, {1, 44}}, []int{6, 28, 50}},
+}
+
+const qrMaxVersion = 10
+
+func (v qrVersionM) dataCodewords() int {
+	total := 0
+	for _, group := range v.groups {
+		total += group.count * group.dataWords
+	}
+	return total
+}
+
+// EncodeQR は data を収まる最小の型番の QR コードにする
+func EncodeQR(data string) (*QRCode, error) {
+	payload := []byte(data)
+	version := 0
+	for v := 1; v <= qrMaxVersion; v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(payload) <= qrVersionsM[v].dataCodewords()*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrQRDataTooLong
+	}
+
+	codewords := qrInterleave(qrVersionsM[version], qrDataCodewords(version, payload))
+
+	qr := &QRCode{Version: version, Size: version*4 + 17}
+	qr.modules = make([][]bool, qr.Size)
+	isFunction := make([][]bool, qr.Size)
+	for y := range qr.modules {
+		qr.modules[y] = make([]bool, qr.Size)
+		isFunction[y] = make([]bool, qr.Size)
+	}
+	qr.drawFunctionPatterns(isFunction)
+	qr.placeCodewords(codewords, isFunction)
+
+	// 減点が最も少ないマスクを選ぶ
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		qr.applyMask(mask, isFunction)
+		qr.drawFormatBits(mask, isFunction)
+		penalty := qr.penalty()
+		if bestPenalty < 0 || penalty < bestPenalty {
+			bestMask, bestPenalty = mask, penalty
+		}
+		qr.applyMask(mask, isFunction)
+	}
+	qr.applyMask(bestMask, isFunction)
+	qr.drawFormatBits(bestMask, isFunction)
+	return qr, nil
+}
+
+// Dark はモジュールが暗（黒）かを返す。x が列、y が行
+func (q *QRCode) Dark(x, y int) bool {
+	return q.modules[y][x]
+}
+
+// PNG は1モジュールを scale ピクセルとし、周囲に4モジュールの余白を付けた PNG 画像を返す
+func (q *QRCode) PNG(scale int) ([]byte, error) {
+	if scale < 1 {
+		scale = 1
+	}
+	const quietZone = 4
+	width := (q.Size + quietZone*2) * scale
+	img := image.NewGray(image.Rect(0, 0, width, width))
+	for py := 0; py < width; py++ {
+		for px := 0; px < width; px++ {
+			x := px/scale - quietZone
+			y := py/scale - quietZone
+			c := color.Gray{Y: 0xFF}
+			if x >= 0 && y >= 0 && x < q.Size && y < q.Size && q.modules[y][x] {
+				c = color.Gray{Y: 0x00}
+			}
+			img.SetGray(px, py, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// qrDataCodewords はモード指示子・文字数指示子・データ・終端パターン・埋め草を並べたデータコード語を返す
+func qrDataCodewords(version int, payload []byte) []byte {
+	capacity := qrVersionsM[version].dataCodewords()
+	var bits qrBitBuffer
+	bits.append(0x4, 4)
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	bits.append(len(payload), countBits)
+	for _, b := range payload {
+		bits.append(int(b), 8)
+	}
+	terminator := capacity*8 - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	if len(bits)%8 != 0 {
+		bits.append(0, 8-len(bits)%8)
+	}
+
+	codewords := make([]byte, 0, capacity)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for _, bit := range bits[i : i+8] {
+			b = b<<1 | bit
+		}
+		codewords = append(codewords, b)
+	}
+	for pad := byte(0xEC); len(codewords) < capacity; pad ^= 0xEC ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+	return codewords
+}
+
+type qrBitBuffer []byte
+
+func (b *qrBitBuffer) append(value int, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, byte(value>>uint(i)&1))
+	}
+}
+
+// qrInterleave はブロックごとに誤り訂正コード語を付け、データ・誤り訂正の順にブロックを交互に並べる
+func qrInterleave(v qrVersionM, data []byte) []byte {
+	var dataBlocks, eccBlocks [][]byte
+	divisor := reedSolomonDivisor(v.eccPerBlock)
+	offset := 0
+	for _, group := range v.groups {
+		for i := 0; i < group.count; i++ {
+			block := data[offset : offset+group.dataWords]
+			offset += group.dataWords
+			dataBlocks = append(dataBlocks, block)
+			eccBlocks = append(eccBlocks, reedSolomonRemainder(block, divisor))
+		}
+	}
+
+	result := make([]byte, 0, len(data)+len(eccBlocks)*v.eccPerBlock)
+	maxDataWords := len(dataBlocks[len(dataBlocks)-1])
+	for i := 0; i < maxDataWords; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.eccPerBlock; i++ {
+		for _, block := range eccBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// reedSolomonDivisor は GF(2^8)（原始多項式 0x11D）上の生成多項式の係数を最高次を除いて返す
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := 0; j < degree; j++ {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < degree {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func reedSolomonRemainder(data []byte, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+	return result
+}
+
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int(y>>uint(i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+func (q *QRCode) setFunction(x, y int, dark bool, isFunction [][]bool) {
+	q.modules[y][x] = dark
+	isFunction[y][x] = true
+}
+
+func (q *QRCode) drawFunctionPatterns(isFunction [][]bool) {
+	// タイミングパターン
+	for i := 0; i < q.Size; i++ {
+		q.setFunction(6, i, i%2 == 0, isFunction)
+		q.setFunction(i, 6, i%2 == 0, isFunction)
+	}
+
+	// 位置検出パターンと分離パターン
+	for _, center := range [][2]int{{3, 3}, {q.Size - 4, 3}, {3, q.Size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := center[0]+dx, center[1]+dy
+				if x < 0 || y < 0 || x >= q.Size || y >= q.Size {
+					continue
+				}
+				dist := qrMax(qrAbs(dx), qrAbs(dy))
+				q.setFunction(x, y, dist != 2 && dist != 4, isFunction)
+			}
+		}
+	}
+
+	// 位置合わせパターン。位置検出パターンと重なる3か所には置かない
+	positions := qrVersionsM[q.Version].alignment
+	last := len(positions) - 1
+	for i, cy := range positions {
+		for j, cx := range positions {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.setFunction(cx+dx, cy+dy, qrMax(qrAbs(dx), qrAbs(dy)) != 1, isFunction)
+				}
+			}
+		}
+	}
+
+	// 形式情報の領域を確保しておく。値はマスクを決めた後で書き直す
+	q.drawFormatBits(0, isFunction)
+
+	// 型番情報（型番7以上）
+	if q.Version >= 7 {
+		rem := q.Version
+		for i := 0; i < 12; i++ {
+			rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+		}
+		bits := q.Version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := bits>>uint(i)&1 != 0
+			a := q.Size - 11 + i%3
+			b := i / 3
+			q.setFunction(a, b, dark, isFunction)
+			q.setFunction(b, a, dark, isFunction)
+		}
+	}
+}
+
+// drawFormatBits は誤り訂正レベル M とマスク番号の形式情報を2か所に書く
+func (q *QRCode) drawFormatBits(mask int, isFunction [][]bool) {
+	const eccLevelM = 0
+	data := eccLevelM<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>uint(i)&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, bit(i), isFunction)
+	}
+	q.setFunction(8, 7, bit(6), isFunction)
+	q.setFunction(8, 8, bit(7), isFunction)
+	q.setFunction(7, 8, bit(8), isFunction)
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bit(i), isFunction)
+	}
+
+	for i := 0; i < 8; i++ {
+		q.setFunction(q.Size-1-i, 8, bit(i), isFunction)
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, q.Size-15+i, bit(i), isFunction)
+	}
+	// 常に暗にするモジュール
+	q.setFunction(8, q.Size-8, true, isFunction)
+}
+
+// placeCodewords は右下から2列ずつ上下に折り返しながらコード語のビットを配置する
+func (q *QRCode) placeCodewords(codewords []byte, isFunction [][]bool) {
+	i := 0
+	total := len(codewords) * 8
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < q.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if upward {
+					y = q.Size - 1 - vert
+				}
+				if isFunction[y][x] || i >= total {
+					continue
+				}
+				q.modules[y][x] = codewords[i/8]>>uint(7-i%8)&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+// applyMask は機能パターン以外のモジュールをマスクで反転する。同じマスクを2回かけると元に戻る
+func (q *QRCode) applyMask(mask int, isFunction [][]bool) {
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if isFunction[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty はマスク評価の失点（同色の連続・2x2のかたまり・位置検出パターンに似た並び・暗モジュールの偏り）を返す
+func (q *QRCode) penalty() int {
+	result := 0
+	line := make([]bool, q.Size)
+	for _, vertical := range []bool{false, true} {
+		for a := 0; a < q.Size; a++ {
+			for b := 0; b < q.Size; b++ {
+				if vertical {
+					line[b] = q.modules[b][a]
+				} else {
+					line[b] = q.modules[a][b]
+				}
+			}
+			run := 1
+			for b := 1; b <= q.Size; b++ {
+				if b < q.Size && line[b] == line[b-1] {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += 3 + run - 5
+				}
+				run = 1
+			}
+			for b := 0; b+11 <= q.Size; b++ {
+				if qrMatchesFinderLike(line[b : b+11]) {
+					result += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.Size && y+1 < q.Size {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					result += 3
+				}
+			}
+		}
+	}
+	total := q.Size * q.Size
+	deviation := qrAbs(dark*20 - total*10)
+	result += (deviation / total) * 10
+	return result
+}
+
+var (
+	qrFinderLikeLeft  = []bool{true, false, true, true, true, false, true, false, false, false, false}
+	qrFinderLikeRight = []bool{false, false, false, false, true, false, true, true, true, false, true}
+)
+
+func qrMatchesFinderLike(segment []bool) bool {
+	left, right := true, true
+	for i, dark := range segment {
+		if dark != qrFinderLikeLeft[i] {
+			left = false
+		}
+		if dark != qrFinderLikeRight[i] {
+			right = false
+		}
+	}
+	return left || right
+}
+
+func qrAbs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func qrMax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
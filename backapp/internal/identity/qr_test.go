@@ -0,0 +1,147 @@
+package identity
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// "HELLO WORLD" を型番1・誤り訂正レベル Q で符号化したときのデータコード語と誤り訂正コード語
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236}
+	expected := []byte{168, 72, 22, 82, 217, 54, 156, 0, 46, 15, 180, 122, 16}
+
+	assert.Equal(t, expected, reedSolomonRemainder(data, reedSolomonDivisor(13)))
+}
+
+// readFormatBits は左上の形式情報を読み、マスクを外した15ビットを返す
+func readFormatBits(q *QRCode) int {
+	bits := 0
+	set := func(i int, dark bool) {
+		if dark {
+			bits |= 1 << uint(i)
+		}
+	}
+	for i := 0; i <= 5; i++ {
+		set(i, q.Dark(8, i))
+	}
+	set(6, q.Dark(8, 7))
+	set(7, q.Dark(8, 8))
+	set(8, q.Dark(7, 8))
+	for i := 9; i < 15; i++ {
+		set(i, q.Dark(14-i, 8))
+	}
+	return bits ^ 0x5412
+}
+
+// readCodewords は形式情報のマスクを外して配置順にコード語を読み戻す
+func readCodewords(t *testing.T, q *QRCode) []byte {
+	t.Helper()
+	mask := readFormatBits(q) >> 10 & 0x7
+
+	isFunction := make([][]bool, q.Size)
+	scratch := &QRCode{Version: q.Version, Size: q.Size, modules: make([][]bool, q.Size)}
+	for y := range isFunction {
+		isFunction[y] = make([]bool, q.Size)
+		scratch.modules[y] = make([]bool, q.Size)
+	}
+	scratch.drawFunctionPatterns(isFunction)
+
+	unmasked := &QRCode{Version: q.Version, Size: q.Size, modules: make([][]bool, q.Size)}
+	for y := range unmasked.modules {
+		unmasked.modules[y] = append([]bool(nil), q.modules[y]...)
+	}
+	unmasked.applyMask(mask, isFunction)
+
+	v := qrVersionsM[q.Version]
+	total := v.dataCodewords()
+	for _, group := range v.groups {
+		total += group.count * v.eccPerBlock
+	}
+	codewords := make([]byte, total)
+	i := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < q.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if upward {
+					y = q.Size - 1 - vert
+				}
+				if isFunction[y][x] || i >= total*8 {
+					continue
+				}
+				if unmasked.modules[y][x] {
+					codewords[i/8] |= 1 << uint(7-i%8)
+				}
+				i++
+			}
+		}
+	}
+	return codewords
+}
+
+func TestEncodeQR(t *testing.T) {
+	t.Run("最小の型番を選び、形式情報とコード語を読み戻せる", func(t *testing.T) {
+		payload := PassPayload(strings.Repeat("A", 24))
+		qr, err := EncodeQR(payload)
+		require.NoError(t, err)
+		assert.Equal(t, 3, qr.Version)
+		assert.Equal(t, 29, qr.Size)
+
+		// 位置検出パターンの中心と分離パターン
+		assert.True(t, qr.Dark(3, 3))
+		assert.True(t, qr.Dark(qr.Size-4, 3))
+		assert.True(t, qr.Dark(3, qr.Size-4))
+		assert.False(t, qr.Dark(7, 7))
+		assert.True(t, qr.Dark(8, qr.Size-8))
+
+		format := readFormatBits(qr)
+		assert.Equal(t, 0, format>>13, "誤り訂正レベル M")
+
+		codewords := readCodewords(t, qr)
+		v := qrVersionsM[qr.Version]
+		data := codewords[:v.dataCodewords()]
+		assert.Equal(t, qrDataCodewords(qr.Version, []byte(payload)), data)
+		assert.Equal(t, byte(0x40|len(payload)>>4), data[0])
+		assert.Equal(t, codewords[v.dataCodewords():], reedSolomonRemainder(data, reedSolomonDivisor(v.eccPerBlock)))
+	})
+
+	t.Run("型番7以上は型番情報を書く", func(t *testing.T) {
+		qr, err := EncodeQR(strings.Repeat("x", 110))
+		require.NoError(t, err)
+		assert.Equal(t, 7, qr.Version)
+
+		// 型番7の型番情報は 000111110010010100
+		bits := 0
+		for i := 0; i < 18; i++ {
+			if qr.Dark(qr.Size-11+i%3, i/3) {
+				bits |= 1 << uint(i)
+			}
+		}
+		assert.Equal(t, 0x07C94, bits)
+	})
+
+	t.Run("収まらないデータはエラー", func(t *testing.T) {
+		_, err := EncodeQR(strings.Repeat("x", 300))
+		assert.ErrorIs(t, err, ErrQRDataTooLong)
+	})
+
+	t.Run("余白付きの PNG にする", func(t *testing.T) {
+		qr, err := EncodeQR("SPORTEASE")
+		require.NoError(t, err)
+		data, err := qr.PNG(4)
+		require.NoError(t, err)
+
+		img, err := png.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, (qr.Size+8)*4, img.Bounds().Dx())
+	})
+}
@@ -0,0 +1,124 @@
+package identity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrPassExpired          = errors.New("pass has expired")
+	ErrPassInvalidSignature = errors.New("pass signature is invalid")
+	ErrPassEventMismatch    = errors.New("pass is for another event")
+)
+
+const signedPassPrefix = "SPORTEASE:PASS:"
+
+// 署名は HMAC-SHA256 の先頭16バイト。QR コードを小さく保ちつつ推測できない長さにする
+const signedPassSignatureBytes = 16
+
+const DefaultPassRotation = 2 * time.Minute
+
+// SignedPass は発行した署名付きパス
+type SignedPass struct {
+	Payload   string
+	UserID    string
+	EventID   int
+	ExpiresAt time.Time
+	// RefreshAt を過ぎたら次のパスに切り替える。前のパスも ExpiresAt までは読み取れる
+	RefreshAt time.Time
+}
+
+// PassSigner はユーザーと大会に結び付けた、短時間で切り替わる署名付きパスを発行・検証する。
+// 有効期限は切り替え周期の2倍で、画面に表示した直後に切り替わっても読み取りが間に合うようにする
+type PassSigner struct {
+	secret   []byte
+	rotation time.Duration
+	now      func() time.Time
+}
+
+func NewPassSigner(secret []byte, rotation time.Duration) *PassSigner {
+	if rotation <= 0 {
+		rotation = DefaultPassRotation
+	}
+	return &PassSigner{secret: secret, rotation: rotation, now: time.Now}
+}
+
+// WithClock は現在時刻の取得を差し替える（テスト用）
+func (s *PassSigner) WithClock(now func() time.Time) *PassSigner {
+	s.now = now
+	return s
+}
+
+// Rotation はパスの切り替え周期を返す
+func (s *PassSigner) Rotation() time.Duration {
+	return s.rotation
+}
+
+// Issue は現在の切り替え周期のパスを発行する。同じ周期の間は同じパスになる
+func (s *PassSigner) Issue(userID string, eventID int) *SignedPass {
+	windowStart := s.now().Truncate(s.rotation)
+	expiresAt := windowStart.Add(2 * s.rotation)
+	body := fmt.Sprintf("%d:%d:%s", eventID, expiresAt.Unix(), userID)
+	return &SignedPass{
+		Payload:   signedPassPrefix + body + ":" + s.sign(body),
+		UserID:    userID,
+		EventID:   eventID,
+		ExpiresAt: expiresAt,
+		RefreshAt: windowStart.Add(s.rotation),
+	}
+}
+
+// Verify は署名と有効期限を確かめ、パスのユーザーIDと大会IDを返す
+func (s *PassSigner) Verify(payload string) (string, int, error) {
+	if !strings.HasPrefix(payload, signedPassPrefix) {
+		return "", 0, ErrUnrecognized
+	}
+	parts := strings.Split(strings.TrimPrefix(payload, signedPassPrefix), ":")
+	if len(parts) != 4 || parts[2] == "" {
+		return "", 0, ErrUnrecognized
+	}
+	eventID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", 0, ErrUnrecognized
+	}
+	expiresUnix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, ErrUnrecognized
+	}
+
+	body := strings.Join(parts[:3], ":")
+	if !hmac.Equal([]byte(parts[3]), []byte(s.sign(body))) {
+		return "", 0, ErrPassInvalidSignature
+	}
+	if !s.now().Before(time.Unix(expiresUnix, 0)) {
+		return "", 0, ErrPassExpired
+	}
+	return parts[2], eventID, nil
+}
+
+func (s *PassSigner) sign(body string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:signedPassSignatureBytes])
+}
+
+// signedPassFormat は PassSigner が発行した署名付きパス
+type signedPassFormat struct {
+	signer *PassSigner
+}
+
+func (signedPassFormat) Name() string { return FormatSignedPass }
+
+func (f signedPassFormat) Parse(data string) (Identifier, error) {
+	userID, eventID, err := f.signer.Verify(data)
+	if err != nil {
+		return Identifier{}, err
+	}
+	return Identifier{Kind: KindUserID, Value: userID, EventID: eventID}, nil
+}
@@ -0,0 +1,89 @@
+package identity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassSigner(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 1, 30, 0, time.UTC)
+	clock := func() time.Time { return now }
+	signer := NewPassSigner([]byte("secret"), 2*time.Minute).WithClock(clock)
+
+	t.Run("発行したパスを検証できる", func(t *testing.T) {
+		pass := signer.Issue("user-1", 3)
+		assert.True(t, strings.HasPrefix(pass.Payload, "SPORTEASE:PASS:3:"))
+		assert.Equal(t, time.Date(2026, 10, 18, 9, 2, 0, 0, time.UTC), pass.RefreshAt)
+		assert.Equal(t, time.Date(2026, 10, 18, 9, 4, 0, 0, time.UTC), pass.ExpiresAt)
+
+		userID, eventID, err := signer.Verify(pass.Payload)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", userID)
+		assert.Equal(t, 3, eventID)
+	})
+
+	t.Run("同じ周期の間は同じパスになる", func(t *testing.T) {
+		first := signer.Issue("user-1", 3)
+		later := NewPassSigner([]byte("secret"), 2*time.Minute).WithClock(func() time.Time { return now.Add(20 * time.Second) })
+		assert.Equal(t, first.Payload, later.Issue("user-1", 3).Payload)
+	})
+
+	t.Run("有効期限を過ぎたパスは使えない", func(t *testing.T) {
+		pass := signer.Issue("user-1", 3)
+		expired := NewPassSigner([]byte("secret"), 2*time.Minute).WithClock(func() time.Time { return pass.ExpiresAt })
+		_, _, err := expired.Verify(pass.Payload)
+		assert.ErrorIs(t, err, ErrPassExpired)
+	})
+
+	t.Run("書き換えたパスや別の鍵のパスは使えない", func(t *testing.T) {
+		pass := signer.Issue("user-1", 3)
+		_, _, err := signer.Verify(strings.Replace(pass.Payload, ":user-1:", ":user-2:", 1))
+		assert.ErrorIs(t, err, ErrPassInvalidSignature)
+
+		other := NewPassSigner([]byte("other"), 2*time.Minute).WithClock(clock)
+		_, _, err = other.Verify(pass.Payload)
+		assert.ErrorIs(t, err, ErrPassInvalidSignature)
+	})
+
+	t.Run("形式が違えば読めない", func(t *testing.T) {
+		for _, data := range []string{"H1023010590", "SPORTEASE:PASS:3:user-1", "SPORTEASE:PASS:x:1:user-1:sig"} {
+			_, _, err := signer.Verify(data)
+			assert.ErrorIs(t, err, ErrUnrecognized, data)
+		}
+	})
+}
+
+func TestParser_SignedPass(t *testing.T) {
+	signer := NewPassSigner([]byte("secret"), time.Minute)
+
+	t.Run("鍵があれば署名付きパスを先に試し、MyID も読める", func(t *testing.T) {
+		parser, err := NewParser(Config{PassSigner: signer})
+		require.NoError(t, err)
+		assert.Equal(t, []string{FormatSignedPass, FormatMyID, FormatSportEaseQR}, parser.FormatNames())
+
+		id, err := parser.Parse(signer.Issue("user-1", 3).Payload)
+		require.NoError(t, err)
+		assert.Equal(t, Identifier{Kind: KindUserID, Value: "user-1", Format: FormatSignedPass, EventID: 3}, id)
+
+		id, err = parser.Parse("H1023010590")
+		require.NoError(t, err)
+		assert.Equal(t, FormatMyID, id.Format)
+	})
+
+	t.Run("署名が違えば他の形式を試さずにエラーにする", func(t *testing.T) {
+		parser, err := NewParser(Config{PassSigner: signer})
+		require.NoError(t, err)
+		id, err := parser.Parse(signer.Issue("user-1", 3).Payload + "x")
+		assert.ErrorIs(t, err, ErrPassInvalidSignature)
+		assert.Equal(t, FormatSignedPass, id.Format)
+	})
+
+	t.Run("鍵がなければ署名付きパスは指定できない", func(t *testing.T) {
+		_, err := NewParser(Config{Formats: []string{FormatSignedPass}})
+		assert.Error(t, err)
+	})
+}
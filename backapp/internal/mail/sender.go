@@ -0,0 +1,249 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBatchSize     = 50
+	defaultRatePerMinute = 60
+	dialTimeout          = 5 * time.Second
+	sessionTimeout       = 2 * time.Minute
+)
+
+// Message は1通のメール。本文はプレーンテキスト。
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Result struct {
+	Message Message
+	Err     error
+}
+
+// Sender は Push 通知が届かない利用者へのメール送信手段
+type Sender interface {
+	Enabled() bool
+	SendBatch(ctx context.Context, messages []Message) []Result
+}
+
+type Config struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	// BatchSize は1回の SMTP 接続で送る最大件数
+	BatchSize int
+	// RatePerMinute は1分あたりに送る最大件数
+	RatePerMinute int
+}
+
+type smtpSender struct {
+	host      string
+	port      string
+	username  string
+	password  string
+	from      string
+	batchSize int
+	interval  time.Duration
+	now       func() time.Time
+	wait      func(ctx context.Context, d time.Duration) error
+}
+
+// NewSMTPSender は SMTP でメールを送る Sender を返す。Host と From が空なら無効。
+func NewSMTPSender(cfg Config) Sender {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	rate := cfg.RatePerMinute
+	if rate <= 0 {
+		rate = defaultRatePerMinute
+	}
+	port := strings.TrimSpace(cfg.Port)
+	if port == "" {
+		port = "587"
+	}
+
+	return &smtpSender{
+		host:      strings.TrimSpace(cfg.Host),
+		port:      port,
+		username:  cfg.Username,
+		password:  cfg.Password,
+		from:      strings.TrimSpace(cfg.From),
+		batchSize: batchSize,
+		interval:  time.Minute / time.Duration(rate),
+		now:       time.Now,
+		wait:      sleepContext,
+	}
+}
+
+func (s *smtpSender) Enabled() bool {
+	return s.host != "" && s.from != ""
+}
+
+// SendBatch は BatchSize 件ごとに SMTP 接続を張り直し、RatePerMinute を超えない間隔で送る
+func (s *smtpSender) SendBatch(ctx context.Context, messages []Message) []Result {
+	results := make([]Result, len(messages))
+	for i, message := range messages {
+		results[i].Message = message
+	}
+	if !s.Enabled() {
+		for i := range results {
+			results[i].Err = errors.New("mail is disabled")
+		}
+		return results
+	}
+
+	var last time.Time
+	for start := 0; start < len(messages); start += s.batchSize {
+		end := start + s.batchSize
+		if end > len(messages) {
+			end = len(messages)
+		}
+
+		client, err := s.connect(ctx)
+		if err != nil {
+			for i := start; i < end; i++ {
+				results[i].Err = err
+			}
+			continue
+		}
+
+		for i := start; i < end; i++ {
+			if !last.IsZero() {
+				if err := s.wait(ctx, s.interval-s.now().Sub(last)); err != nil {
+					for j := i; j < end; j++ {
+						results[j].Err = err
+					}
+					break
+				}
+			}
+			last = s.now()
+			results[i].Err = s.send(client, messages[i])
+			if results[i].Err != nil {
+				// 失敗した宛先の状態を次の宛先に持ち越さない
+				_ = client.Reset()
+			}
+		}
+		_ = client.Quit()
+	}
+	return results
+}
+
+func (s *smtpSender) connect(ctx context.Context) (*smtp.Client, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.host, s.port))
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(sessionTimeout))
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12}); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+func (s *smtpSender) send(client *smtp.Client, message Message) error {
+	data, err := buildMessage(s.from, message, s.now())
+	if err != nil {
+		return err
+	}
+	if err := client.Mail(s.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(message.To); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// buildMessage は UTF-8 の件名と本文を持つメールを組み立てる
+func buildMessage(from string, message Message, date time.Time) ([]byte, error) {
+	for _, value := range []string{from, message.To, message.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, errors.New("mail header must not contain line breaks")
+		}
+	}
+	if strings.TrimSpace(message.To) == "" {
+		return nil, errors.New("mail recipient is empty")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", message.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", message.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n")
+	b.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(message.Body))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76])
+		b.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded)
+	b.WriteString("\r\n")
+	return []byte(b.String()), nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RecipientLogID はログに残すための宛先の識別子。アドレスそのものは残さない。
+func RecipientLogID(address string) string {
+	domain := "invalid-domain"
+	if at := strings.LastIndex(address, "@"); at >= 0 && at < len(address)-1 {
+		domain = strings.ToLower(address[at+1:])
+	}
+	return domain
+}
@@ -0,0 +1,137 @@
+package mail
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"backapp/internal/mail/mailtest"
+)
+
+func newTestSender(t *testing.T, server *mailtest.Server, batchSize, rate int) *smtpSender {
+	t.Helper()
+	host, port := server.HostPort()
+	sender := NewSMTPSender(Config{Host: host, Port: port, From: "noreply@school.example", BatchSize: batchSize, RatePerMinute: rate}).(*smtpSender)
+	sender.wait = func(context.Context, time.Duration) error { return nil }
+	return sender
+}
+
+func TestSMTPSenderSendBatch(t *testing.T) {
+	server, err := mailtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	server.RejectRecipients["gone@school.example"] = true
+
+	sender := newTestSender(t, server, 2, 600)
+	results := sender.SendBatch(context.Background(), []Message{
+		{To: "a@school.example", Subject: "開会式", Body: "体育館に集合してください"},
+		{To: "gone@school.example", Subject: "開会式", Body: "体育館に集合してください"},
+		{To: "b@school.example", Subject: "開会式", Body: "体育館に集合してください"},
+	})
+
+	if len(results) != 3 {
+		t.Fatalf("got %d results", len(results))
+	}
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Fatalf("unexpected errors: %v, %v", results[0].Err, results[2].Err)
+	}
+	// 拒否された宛先だけ失敗し、後続の宛先には影響しない
+	if results[1].Err == nil {
+		t.Fatal("expected rejected recipient to fail")
+	}
+
+	received := server.Messages()
+	if len(received) != 2 {
+		t.Fatalf("got %d messages", len(received))
+	}
+	if received[0].From != "noreply@school.example" || received[1].To[0] != "b@school.example" {
+		t.Fatalf("unexpected envelope: %+v", received)
+	}
+	if !strings.Contains(received[0].Data, "Subject: =?UTF-8?b?") {
+		t.Fatalf("subject is not encoded: %q", received[0].Data)
+	}
+	body := received[0].Data[strings.Index(received[0].Data, "\r\n\r\n")+4:]
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\r\n", ""))
+	if err != nil || string(decoded) != "体育館に集合してください" {
+		t.Fatalf("unexpected body: %q, %v", decoded, err)
+	}
+}
+
+func TestSMTPSenderRateLimit(t *testing.T) {
+	server, err := mailtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	sender := newTestSender(t, server, 50, 120)
+	now := time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC)
+	sender.now = func() time.Time { return now }
+	var waits []time.Duration
+	sender.wait = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		now = now.Add(d)
+		return nil
+	}
+
+	messages := make([]Message, 3)
+	for i := range messages {
+		messages[i] = Message{To: "a@school.example", Subject: "連絡", Body: "本文"}
+	}
+	for _, result := range sender.SendBatch(context.Background(), messages) {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+	}
+
+	// 1分あたり120件なら0.5秒ずつ空ける
+	if len(waits) != 2 || waits[0] != 500*time.Millisecond || waits[1] != 500*time.Millisecond {
+		t.Fatalf("unexpected waits: %v", waits)
+	}
+}
+
+func TestSMTPSenderDisabled(t *testing.T) {
+	sender := NewSMTPSender(Config{})
+	if sender.Enabled() {
+		t.Fatal("sender without host should be disabled")
+	}
+	results := sender.SendBatch(context.Background(), []Message{{To: "a@school.example"}})
+	if results[0].Err == nil {
+		t.Fatal("expected error from disabled sender")
+	}
+}
+
+func TestBuildMessageRejectsHeaderInjection(t *testing.T) {
+	_, err := buildMessage("noreply@school.example", Message{To: "a@school.example", Subject: "連絡\r\nBcc: x@evil.example"}, time.Now())
+	if err == nil {
+		t.Fatal("expected header injection to be rejected")
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	message, err := NotificationRequestDecisionMessage("a@school.example", "部活動の集合", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Subject != "通知申請の結果" || !strings.Contains(message.Body, "「部活動の集合」は否認されました") {
+		t.Fatalf("unexpected message: %+v", message)
+	}
+
+	message, err = SurveyNoticeMessage("a@school.example", "春季球技大会", "https://school.example/survey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(message.Body, "「春季球技大会」") || !strings.Contains(message.Body, "https://school.example/survey") {
+		t.Fatalf("unexpected message: %+v", message)
+	}
+}
+
+func TestRecipientLogID(t *testing.T) {
+	if got := RecipientLogID("Taro@School.Example"); got != "school.example" {
+		t.Fatalf("got %q", got)
+	}
+}
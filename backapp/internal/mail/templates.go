@@ -0,0 +1,56 @@
+package mail
+
+import (
+	"strings"
+	"text/template"
+)
+
+var (
+	notificationTemplate = template.Must(template.New("notification").Parse(`{{.Body}}
+
+---
+このメールは Push 通知を受け取る設定がされていない方にお送りしています。
+アプリで通知を有効にすると、次回からは Push 通知でお知らせします。
+{{if .URL}}
+{{.URL}}
+{{end}}`))
+
+	surveyNoticeTemplate = template.Must(template.New("survey").Parse(`「{{.EventName}}」のアンケートが公開されました。
+期間内にダッシュボードの一番上のリンクから回答にご協力ください。
+{{if .URL}}
+{{.URL}}
+{{end}}`))
+
+	requestDecisionTemplate = template.Must(template.New("request-decision").Parse(`通知申請「{{.Title}}」は{{.Result}}されました。
+{{if .URL}}
+申請の詳細はこちらから確認できます。
+{{.URL}}
+{{end}}`))
+)
+
+// NotificationMessage は通常の通知をメールにする
+func NotificationMessage(to, title, body, url string) (Message, error) {
+	return render(to, title, notificationTemplate, map[string]string{"Body": body, "URL": url})
+}
+
+// SurveyNoticeMessage は大会アンケート公開のお知らせをメールにする
+func SurveyNoticeMessage(to, eventName, url string) (Message, error) {
+	return render(to, "大会アンケートのお願い", surveyNoticeTemplate, map[string]string{"EventName": eventName, "URL": url})
+}
+
+// NotificationRequestDecisionMessage は通知申請の承認・否認の結果をメールにする
+func NotificationRequestDecisionMessage(to, requestTitle string, approved bool, url string) (Message, error) {
+	result := "承認"
+	if !approved {
+		result = "否認"
+	}
+	return render(to, "通知申請の結果", requestDecisionTemplate, map[string]string{"Title": requestTitle, "Result": result, "URL": url})
+}
+
+func render(to, subject string, tmpl *template.Template, data map[string]string) (Message, error) {
+	var body strings.Builder
+	if err := tmpl.Execute(&body, data); err != nil {
+		return Message{}, err
+	}
+	return Message{To: to, Subject: subject, Body: strings.TrimSpace(body.String()) + "\n"}, nil
+}
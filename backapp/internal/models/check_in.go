@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// check_ins.purpose の値
+const (
+	CheckInPurposeOpeningCeremony    = "opening_ceremony"
+	CheckInPurposeEventParticipation = "event_participation"
+)
+
+func IsValidCheckInPurpose(purpose string) bool {
+	switch purpose {
+	case CheckInPurposeOpeningCeremony, CheckInPurposeEventParticipation:
+		return true
+	default:
+		return false
+	}
+}
+
+// CheckIn は生徒1人分の出席チェックイン
+type CheckIn struct {
+	ID          int       `json:"id"`
+	UserID      string    `json:"user_id"`
+	EventID     int       `json:"event_id"`
+	EventDayID  *int      `json:"event_day_id"`
+	Purpose     string    `json:"purpose"`
+	CheckedInAt time.Time `json:"checked_in_at"`
+	CheckedInBy *string   `json:"checked_in_by"`
+}
+
+// AttendanceCheckInRequest は学生証のバーコードや QR コードで生徒の出席を記録するリクエスト
+type AttendanceCheckInRequest struct {
+	BarcodeData string `json:"barcode_data"`
+	// Purpose を省略したときは event_participation として記録する
+	Purpose string `json:"purpose"`
+	// UserID はバーコードを読めず手動照合で本人を選んだときに BarcodeData の代わりに指定する
+	UserID string `json:"user_id"`
+}
@@ -56,4 +56,5 @@ type MatchDetail struct {
 	IsBronzeMatch  bool
 	Team1Name      sql.NullString
 	Team2Name      sql.NullString
+	EventDayID     sql.NullInt64
 }
@@ -13,15 +13,18 @@ type ClassScore struct {
 	Gym1Win2Points                 int               `json:"gym1_win2_points"`
 	Gym1Win3Points                 int               `json:"gym1_win3_points"`
 	Gym1ChampionPoints             int               `json:"gym1_champion_points"`
+	Gym1LeaguePoints               int               `json:"gym1_league_points"`
 	Gym2Win1Points                 int               `json:"gym2_win1_points"`
 	Gym2Win2Points                 int               `json:"gym2_win2_points"`
 	Gym2Win3Points                 int               `json:"gym2_win3_points"`
 	Gym2ChampionPoints             int               `json:"gym2_champion_points"`
 	Gym2LoserBracketChampionPoints int               `json:"gym2_loser_bracket_champion_points"`
+	Gym2LeaguePoints               int               `json:"gym2_league_points"`
 	GroundWin1Points               int               `json:"ground_win1_points"`
 	GroundWin2Points               int               `json:"ground_win2_points"`
 	GroundWin3Points               int               `json:"ground_win3_points"`
 	GroundChampionPoints           int               `json:"ground_champion_points"`
+	GroundLeaguePoints             int               `json:"ground_league_points"`
 	NoonGamePoints                 int               `json:"noon_game_points"`
 	TotalPointsCurrentEvent        int               `json:"total_points_current_event"`
 	RankCurrentEvent               int               `json:"rank_current_event"`
@@ -0,0 +1,96 @@
+package models
+
+// 大会の複製で引き継げる項目（EventCloneRequest.Parts）
+const (
+	EventClonePartSports                = "sports"
+	EventClonePartClasses               = "classes"
+	EventClonePartRainyModeSettings     = "rainy_mode_settings"
+	EventClonePartGuideDocuments        = "guide_documents"
+	EventClonePartNoonGameDefaultGroups = "noon_game_default_groups"
+)
+
+// EventClonePartsAll は Parts を指定しなかったときに引き継ぐ項目
+var EventClonePartsAll = []string{
+	EventClonePartSports,
+	EventClonePartClasses,
+	EventClonePartRainyModeSettings,
+	EventClonePartGuideDocuments,
+	EventClonePartNoonGameDefaultGroups,
+}
+
+// EventCloneRequest は前回の大会から新しい大会を作るときの指定
+type EventCloneRequest struct {
+	Name      string   `json:"name"`
+	Year      int      `json:"year"`
+	Season    string   `json:"season"`
+	StartDate string   `json:"start_date"`
+	EndDate   string   `json:"end_date"`
+	Status    string   `json:"status"`
+	Parts     []string `json:"parts"`
+	// ClassNameMap は複製元のクラス名から新しいクラス名への対応（例: IS2 → IS3）。
+	// 空文字に対応させたクラスは引き継がない。対応にないクラスは同じ名前で引き継ぐ。
+	ClassNameMap map[string]string `json:"class_name_map"`
+}
+
+// EventCloneSource は複製元の大会から引き継ぐ候補
+type EventCloneSource struct {
+	Event                 *Event
+	Sports                []*EventSport
+	Classes               []*Class
+	RainyModeSettings     []*RainyModeSetting
+	GuideDocuments        []*GuideDocument
+	NoonGameDefaultGroups []*NoonGameTemplateDefaultGroup
+}
+
+// EventClonePlan は複製で作られる大会と引き継ぐ内容
+type EventClonePlan struct {
+	SourceEventID         int                       `json:"source_event_id"`
+	Event                 *Event                    `json:"event"`
+	Parts                 []string                  `json:"parts"`
+	Sports                []*EventSport             `json:"sports"`
+	Classes               []*EventCloneClass        `json:"classes"`
+	SkippedClasses        []string                  `json:"skipped_classes"`
+	RainyModeSettings     []*EventCloneRainySetting `json:"rainy_mode_settings"`
+	GuideDocuments        []*GuideDocument          `json:"guide_documents"`
+	NoonGameDefaultGroups []*EventCloneDefaultGroup `json:"noon_game_default_groups"`
+	// Conflicts があるあいだは複製できない
+	Conflicts []string `json:"conflicts"`
+}
+
+// EventCloneClass は新しい大会に作るクラス
+type EventCloneClass struct {
+	SourceClassID int    `json:"source_class_id"`
+	SourceName    string `json:"source_name"`
+	Name          string `json:"name"`
+	StudentCount  int    `json:"student_count"`
+}
+
+// EventCloneRainySetting は新しい大会に引き継ぐ雨天時設定。クラスは新しいクラス名で指定する。
+type EventCloneRainySetting struct {
+	SportID         int     `json:"sport_id"`
+	SourceClassName string  `json:"source_class_name"`
+	ClassName       string  `json:"class_name"`
+	MinCapacity     *int    `json:"min_capacity,omitempty"`
+	MaxCapacity     *int    `json:"max_capacity,omitempty"`
+	MatchStartTime  *string `json:"match_start_time,omitempty"`
+}
+
+// EventCloneDefaultGroup はクラス名を付け替える昼競技テンプレートのデフォルトグループ
+type EventCloneDefaultGroup struct {
+	ID                 int      `json:"id"`
+	TemplateKey        string   `json:"template_key"`
+	GroupIndex         int      `json:"group_index"`
+	GroupName          string   `json:"group_name"`
+	PreviousClassNames []string `json:"previous_class_names"`
+	ClassNames         []string `json:"class_names"`
+}
+
+// HasPart は plan が指定の項目を引き継ぐかどうかを返す
+func (p *EventClonePlan) HasPart(part string) bool {
+	for _, selected := range p.Parts {
+		if selected == part {
+			return true
+		}
+	}
+	return false
+}
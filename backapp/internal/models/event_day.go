@@ -0,0 +1,36 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxEventDays は1つの大会に登録できる開催日の上限
+const MaxEventDays = 14
+
+// EventDay は複数日にわたる大会の1日分。試合・昼競技・出席チェックインはこの日に結び付く。
+type EventDay struct {
+	ID          int       `json:"id"`
+	EventID     int       `json:"event_id"`
+	DayNumber   int       `json:"day_number"`
+	Date        time.Time `json:"date"`
+	IsRainyMode bool      `json:"is_rainy_mode"`
+}
+
+// EventDayDates は開始日から終了日までの日付を1日ずつ返す
+func EventDayDates(start, end time.Time) ([]time.Time, error) {
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	last := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	if last.Before(first) {
+		return nil, fmt.Errorf("終了日は開始日以降にしてください")
+	}
+
+	var dates []time.Time
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		if len(dates) == MaxEventDays {
+			return nil, fmt.Errorf("開催日は%d日までです", MaxEventDays)
+		}
+		dates = append(dates, d)
+	}
+	return dates, nil
+}
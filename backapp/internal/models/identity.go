@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// IDPass は MyID を持たない人向けに SportEase が発行した本人確認用 QR コード
+type IDPass struct {
+	ID        int        `json:"id"`
+	UserID    string     `json:"user_id"`
+	Token     string     `json:"-"`
+	IssuedBy  *string    `json:"issued_by"`
+	IssuedAt  time.Time  `json:"issued_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// StudentNumberEntry は学籍番号CSVの1行。Line はヘッダーを1行目とした行番号
+type StudentNumberEntry struct {
+	Line          int
+	Email         string
+	StudentNumber string
+}
+
+type StudentNumberImportError struct {
+	Line          int    `json:"line"`
+	Email         string `json:"email"`
+	StudentNumber string `json:"student_number"`
+	Reason        string `json:"reason"`
+}
+
+// StudentNumberImportResult は学籍番号CSVの取り込み結果。エラーの行は飛ばし、それ以外の行は登録する
+type StudentNumberImportResult struct {
+	Updated   int                        `json:"updated"`
+	Unchanged int                        `json:"unchanged"`
+	Errors    []StudentNumberImportError `json:"errors"`
+}
+
+// IdentityCandidate はバーコードを読めないときに手動で本人を選ぶための候補
+type IdentityCandidate struct {
+	UserID        string  `json:"user_id"`
+	Email         string  `json:"email"`
+	DisplayName   *string `json:"display_name"`
+	StudentNumber *string `json:"student_number"`
+	ClassID       *int    `json:"class_id"`
+	ClassName     *string `json:"class_name"`
+	HasIDPass     bool    `json:"has_id_pass"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// 通知の種類（notifications.type）。利用者は users.notification_filters で受け取る種類を選ぶ。
+const (
+	NotificationTypeGeneral      = "general"
+	NotificationTypeMatchMyClass = "match_my_class"
+	NotificationTypeFinals       = "finals"
+	NotificationTypeAllMatches   = "all_matches"
+)
+
+// MatchStartReminderLead は試合開始の何分前に自動の Push 通知を送るか
+const MatchStartReminderLead = 10 * time.Minute
+
+// MatchNotification は試合の自動通知に使う試合情報
+type MatchNotification struct {
+	EventID    int        `json:"event_id"`
+	MatchID    int        `json:"match_id"`
+	SportName  string     `json:"sport_name"`
+	Location   string     `json:"location"`
+	Court      string     `json:"court"`
+	Team1Name  string     `json:"team1_name"`
+	Team2Name  string     `json:"team2_name"`
+	ClassIDs   []int      `json:"class_ids"`
+	Team1Score *int       `json:"team1_score"`
+	Team2Score *int       `json:"team2_score"`
+	WinnerName string     `json:"winner_name"`
+	Status     string     `json:"status"`
+	ResultType string     `json:"result_type"`
+	IsFinal    bool       `json:"is_final"`
+	StartTime  *time.Time `json:"start_time"`
+}
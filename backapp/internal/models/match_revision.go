@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// match_revisions.operation の値。試合がどの操作で変更されたかを表す。
+// 得点と同じ操作は score_logs.operation と同じ値を使う。
+const (
+	MatchRevisionInitial         = "initial"
+	MatchRevisionMatchResult     = ScoreOperationMatchResult
+	MatchRevisionMatchCorrection = ScoreOperationMatchCorrection
+	MatchRevisionMatchRestore    = ScoreOperationMatchRestore
+	MatchRevisionStartTime       = "start_time"
+	MatchRevisionSchedule        = "schedule"
+	MatchRevisionLeagueKnockout  = "league_knockout"
+	MatchRevisionRainyPlan       = "rainy_plan"
+)
+
+// MatchRevision は試合の変更履歴の1行で、変更後の試合の状態を表す。
+// 同じ操作で変更された試合（勝ち上がり先や無効化された後続の試合）は ChangeID が同じになる。
+type MatchRevision struct {
+	ID                 int       `json:"id"`
+	MatchID            int       `json:"matchId"`
+	ChangeID           string    `json:"changeId"`
+	Operation          string    `json:"operation"`
+	Team1ID            *int      `json:"team1Id"`
+	Team2ID            *int      `json:"team2Id"`
+	Team1Score         *int      `json:"team1Score"`
+	Team2Score         *int      `json:"team2Score"`
+	ResultType         string    `json:"resultType"`
+	ForfeitingTeamID   *int      `json:"forfeitingTeamId"`
+	Status             string    `json:"status"`
+	MatchStartTime     *string   `json:"matchStartTime"`
+	RainyModeStartTime *string   `json:"rainyModeStartTime"`
+	CourtNumber        *string   `json:"courtNumber"`
+	ActorUserID        *string   `json:"actorUserId"`
+	ActorDisplayName   *string   `json:"actorDisplayName"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+// MatchRestoreResult は試合を履歴から復元した結果。
+// RestoredMatchIDs には指定した試合と、後の変更で一緒に書き換わっていたため元に戻した試合が入る。
+type MatchRestoreResult struct {
+	MatchID          int   `json:"matchId"`
+	RevisionID       int   `json:"revisionId"`
+	RestoredMatchIDs []int `json:"restoredMatchIds"`
+}
@@ -3,14 +3,45 @@ package models
 import "time"
 
 type Notification struct {
-	ID          int       `json:"id"`
-	Title       string    `json:"title"`
-	Body        string    `json:"body"`
-	Type        string    `json:"type"`
-	CreatedAt   time.Time `json:"created_at"`
-	CreatedBy   *string   `json:"created_by,omitempty"`
-	EventID     *int      `json:"event_id,omitempty"`
-	TargetRoles []string  `json:"target_roles"`
+	ID          int                  `json:"id"`
+	Title       string               `json:"title"`
+	Body        string               `json:"body"`
+	Type        string               `json:"type"`
+	CreatedAt   time.Time            `json:"created_at"`
+	CreatedBy   *string              `json:"created_by,omitempty"`
+	EventID     *int                 `json:"event_id,omitempty"`
+	TargetRoles []string             `json:"target_roles"`
+	Targets     []NotificationTarget `json:"targets,omitempty"`
+	IsRead      bool                 `json:"is_read"`
+	ReadAt      *time.Time           `json:"read_at,omitempty"`
+}
+
+// NewNotification は作成する通知と、その対象ロール・宛先
+type NewNotification struct {
+	Title       string
+	Body        string
+	Type        string
+	CreatedBy   string
+	EventID     *int
+	TargetRoles []string
+	Targets     []NotificationTarget
+}
+
+// 通知のロール以外の宛先の種類（notification_audiences.target_type）
+const (
+	NotificationTargetClass = "class"
+	NotificationTargetTeam  = "team"
+	NotificationTargetSport = "sport"
+	NotificationTargetMatch = "match"
+	NotificationTargetUser  = "user"
+)
+
+// NotificationTarget はロール以外の通知の宛先。
+// class・team・sport・match は ID を、user は UserID を指定する。sport はその競技のチームメンバー、match は試合にチェックインした生徒。
+type NotificationTarget struct {
+	Type   string  `json:"type"`
+	ID     *int    `json:"id,omitempty"`
+	UserID *string `json:"user_id,omitempty"`
 }
 
 type PushSubscription struct {
@@ -22,6 +53,33 @@ type PushSubscription struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// NotificationReadStats は通知の既読状況。対象者は集計した時点のロール・宛先で数える。
+type NotificationReadStats struct {
+	NotificationID  int                         `json:"notification_id"`
+	TargetUserCount int                         `json:"target_user_count"`
+	ReadUserCount   int                         `json:"read_user_count"`
+	ReadRate        float64                     `json:"read_rate"`
+	ByRole          []NotificationReadBreakdown `json:"by_role"`
+	ByClass         []NotificationReadBreakdown `json:"by_class"`
+}
+
+// NotificationReadBreakdown はロール別・クラス別の既読状況。クラス未所属のユーザーは ID なしでまとめる。
+type NotificationReadBreakdown struct {
+	ID              *int    `json:"id,omitempty"`
+	Name            string  `json:"name"`
+	TargetUserCount int     `json:"target_user_count"`
+	ReadUserCount   int     `json:"read_user_count"`
+	ReadRate        float64 `json:"read_rate"`
+}
+
+// NotificationReadRate は既読率（0〜1）を返す。対象者がいなければ0。
+func NotificationReadRate(read, target int) float64 {
+	if target == 0 {
+		return 0
+	}
+	return float64(read) / float64(target)
+}
+
 type PushSubscriptionStats struct {
 	TargetUserCount           int `json:"target_user_count"`
 	SubscribedUserCount       int `json:"subscribed_user_count"`
@@ -11,19 +11,22 @@ const (
 )
 
 type NotificationRequest struct {
-	ID          int                           `json:"id"`
-	Title       string                        `json:"title"`
-	Body        string                        `json:"body"`
-	TargetText  string                        `json:"target_text"`
-	Status      NotificationRequestStatus     `json:"status"`
-	RequesterID string                        `json:"requester_id"`
-	ResolvedBy  *string                       `json:"resolved_by,omitempty"`
-	ResolvedAt  *time.Time                    `json:"resolved_at,omitempty"`
-	CreatedAt   time.Time                     `json:"created_at"`
-	UpdatedAt   time.Time                     `json:"updated_at"`
-	Requester   *User                         `json:"requester,omitempty"`
-	Resolver    *User                         `json:"resolver,omitempty"`
-	Messages    []*NotificationRequestMessage `json:"messages,omitempty"`
+	ID                      int                           `json:"id"`
+	Title                   string                        `json:"title"`
+	Body                    string                        `json:"body"`
+	TargetText              string                        `json:"target_text"`
+	Status                  NotificationRequestStatus     `json:"status"`
+	RequesterID             string                        `json:"requester_id"`
+	ResolvedBy              *string                       `json:"resolved_by,omitempty"`
+	ResolvedAt              *time.Time                    `json:"resolved_at,omitempty"`
+	NotificationID          *int                          `json:"notification_id,omitempty"`
+	ScheduledNotificationID *int                          `json:"scheduled_notification_id,omitempty"`
+	EscalatedAt             *time.Time                    `json:"escalated_at,omitempty"`
+	CreatedAt               time.Time                     `json:"created_at"`
+	UpdatedAt               time.Time                     `json:"updated_at"`
+	Requester               *User                         `json:"requester,omitempty"`
+	Resolver                *User                         `json:"resolver,omitempty"`
+	Messages                []*NotificationRequestMessage `json:"messages,omitempty"`
 }
 
 type NotificationRequestMessage struct {
@@ -35,3 +38,19 @@ type NotificationRequestMessage struct {
 	Sender    *User     `json:"sender,omitempty"`
 	IsSystem  bool      `json:"is_system"`
 }
+
+// NotificationRequestStats は通知申請の状態ごとの件数と、承認・否認までにかかった時間
+type NotificationRequestStats struct {
+	ByStatus []NotificationRequestStatusStats `json:"by_status"`
+	// OverdueCount は承認待ちのまま SLAMinutes を過ぎた申請の件数
+	OverdueCount    int        `json:"overdue_count"`
+	OldestPendingAt *time.Time `json:"oldest_pending_at,omitempty"`
+	SLAMinutes      int        `json:"sla_minutes"`
+}
+
+type NotificationRequestStatusStats struct {
+	Status                 NotificationRequestStatus `json:"status"`
+	Count                  int                       `json:"count"`
+	AverageResponseSeconds *float64                  `json:"average_response_seconds,omitempty"`
+	MaxResponseSeconds     *float64                  `json:"max_response_seconds,omitempty"`
+}
@@ -0,0 +1,42 @@
+package models
+
+// Push 通知の配信状況（push_deliveries.status）
+const (
+	PushDeliveryStatusPending = "pending"
+	PushDeliveryStatusSent    = "sent"
+	PushDeliveryStatusFailed  = "failed"
+	PushDeliveryStatusExpired = "expired"
+)
+
+// PushDelivery は送信キューから取り出した1購読分の配信。
+// 購読が削除されていれば Subscription は nil。
+type PushDelivery struct {
+	ID             int
+	MessageID      int
+	NotificationID *int
+	Source         string
+	UserID         string
+	Subscription   *PushSubscription
+	Payload        []byte
+	TTL            int
+	Attempts       int
+}
+
+// PushMessage は通知と一緒に送信キューへ登録する Push 通知。
+// 送る内容は通知の ID が決まってから Payload で作る。
+type PushMessage struct {
+	Source        string
+	TTL           int
+	Subscriptions []PushSubscription
+	Payload       func(notificationID int) ([]byte, error)
+}
+
+// PushDeliveryStats は1つの通知の配信結果の集計
+type PushDeliveryStats struct {
+	NotificationID int `json:"notification_id"`
+	Total          int `json:"total"`
+	Sent           int `json:"sent"`
+	Failed         int `json:"failed"`
+	Expired        int `json:"expired"`
+	Pending        int `json:"pending"`
+}
@@ -0,0 +1,118 @@
+package models
+
+// 雨天時計画での競技の扱い（RainySportChange.Action / RainySportPlan.Action）
+const (
+	RainySportKeep   = "keep"   // そのまま実施する
+	RainySportCancel = "cancel" // 中止する。結果入力済みの試合と得点は残す
+	RainySportMerge  = "merge"  // 別の競技に統合する。メンバーは同じクラスの統合先チームに移る
+)
+
+// 雨天時計画でのチームの変化（RainyPlanTeam.Change）
+const (
+	RainyTeamCancelled = "cancelled" // 競技の中止で出場がなくなる
+	RainyTeamMerged    = "merged"    // 統合先の競技のチームにメンバーが移る
+	RainyTeamCreated   = "created"   // 統合先の競技にクラスのチームがないため新しく作る
+	RainyTeamAdjusted  = "adjusted"  // 定員の変更やメンバーの受け入れがある
+)
+
+// RainyScenario は雨天時にどの競技を中止・統合するかの想定
+type RainyScenario struct {
+	Sports []RainySportChange `json:"sports"`
+	// ApplyCapacities が true のときは雨天時設定の定員をチームに反映する
+	ApplyCapacities bool `json:"apply_capacities"`
+}
+
+// RainySportChange は想定の中の1競技の扱い。指定しない競技はそのまま実施する。
+type RainySportChange struct {
+	SportID          int    `json:"sport_id"`
+	Action           string `json:"action"`
+	MergeIntoSportID *int   `json:"merge_into_sport_id,omitempty"`
+}
+
+// RainyPlan は想定を適用したときに影響を受ける試合・チーム・メンバーの一覧
+type RainyPlan struct {
+	EventID int               `json:"event_id"`
+	Sports  []*RainySportPlan `json:"sports"`
+	// Conflicts があるあいだは計画を適用できない
+	Conflicts           []string `json:"conflicts"`
+	AffectedMatchCount  int      `json:"affected_match_count"`
+	AffectedTeamCount   int      `json:"affected_team_count"`
+	AffectedMemberCount int      `json:"affected_member_count"`
+}
+
+// RainySportPlan は1競技分の計画
+type RainySportPlan struct {
+	SportID          int    `json:"sport_id"`
+	SportName        string `json:"sport_name"`
+	Location         string `json:"location"`
+	Action           string `json:"action"`
+	MergeIntoSportID *int   `json:"merge_into_sport_id,omitempty"`
+	// CancelledMatches はまだ結果のない、中止になる試合
+	CancelledMatches []*RainyPlanMatch `json:"cancelled_matches"`
+	// PreservedMatches は結果入力済みのため、結果と得点をそのまま残す試合
+	PreservedMatches []*RainyPlanMatch `json:"preserved_matches"`
+	Teams            []*RainyPlanTeam  `json:"teams"`
+	// RegenerateTournament が true の競技は、統合で増えたチームを含めて対戦表を組み直す
+	RegenerateTournament bool `json:"regenerate_tournament"`
+	// ByeSeeds は結果入力済みの試合がある対戦表で、統合で増えたチームを入れる不戦勝の試合
+	ByeSeeds []*RainyByeSeed `json:"bye_seeds"`
+}
+
+// RainyByeSeed は統合で増えたチームを不戦勝の一回戦に入れる割り当て。
+// 不戦勝だったチームは勝ち上がり先から外れ、この試合で新しいチームと対戦する。
+type RainyByeSeed struct {
+	MatchID      int    `json:"match_id"`
+	ClassID      int    `json:"class_id"`
+	TeamName     string `json:"team_name"`
+	OpponentName string `json:"opponent_name"`
+}
+
+// RainyPlanMatch は計画の影響を受ける試合
+type RainyPlanMatch struct {
+	MatchID        int    `json:"match_id"`
+	SportID        int    `json:"sport_id"`
+	TournamentName string `json:"tournament_name"`
+	Round          int    `json:"round"`
+	Status         string `json:"status"`
+	Team1Name      string `json:"team1_name"`
+	Team2Name      string `json:"team2_name"`
+	HasResult      bool   `json:"has_result"`
+	// Team1ID と NextMatchID は不戦勝の枠を探すために使う
+	Team1ID     int `json:"-"`
+	NextMatchID int `json:"-"`
+}
+
+// RainyPlanTeam は計画の影響を受けるチーム。新しく作るチームは TeamID が 0。
+type RainyPlanTeam struct {
+	TeamID        int    `json:"team_id"`
+	TeamName      string `json:"team_name"`
+	ClassID       int    `json:"class_id"`
+	SportID       int    `json:"sport_id"`
+	Change        string `json:"change"`
+	TargetSportID *int   `json:"target_sport_id,omitempty"`
+	// MinCapacity と MaxCapacity は計画を適用した後の定員
+	MinCapacity     *int              `json:"min_capacity"`
+	MaxCapacity     *int              `json:"max_capacity"`
+	CapacityChanged bool              `json:"capacity_changed"`
+	Members         []RainyPlanMember `json:"members"`
+	ReceivedMembers []RainyPlanMember `json:"received_members"`
+	OverCapacity    int               `json:"over_capacity"`
+}
+
+// RainyPlanMember は計画の影響を受ける生徒
+type RainyPlanMember struct {
+	UserID      string  `json:"user_id"`
+	Email       string  `json:"email"`
+	DisplayName *string `json:"display_name"`
+}
+
+// CancelledMatchIDs は計画で中止にする試合のIDを返す
+func (p *RainyPlan) CancelledMatchIDs() []int {
+	ids := make([]int, 0)
+	for _, sport := range p.Sports {
+		for _, match := range sport.CancelledMatches {
+			ids = append(ids, match.MatchID)
+		}
+	}
+	return ids
+}
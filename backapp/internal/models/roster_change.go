@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// RosterPhase は大会ごとのメンバー登録の段階
+type RosterPhase string
+
+const (
+	// RosterPhaseOpen はクラスが自由にメンバーを登録・変更できる段階
+	RosterPhaseOpen RosterPhase = "open"
+	// RosterPhaseLocked は登録を締め切った段階。変更は root が承認する変更申請になる
+	RosterPhaseLocked RosterPhase = "locked"
+	// RosterPhaseEventDay は大会当日。変更申請に加えて、参加確認済みのメンバーは外せない
+	RosterPhaseEventDay RosterPhase = "event_day"
+)
+
+func (p RosterPhase) Valid() bool {
+	switch p {
+	case RosterPhaseOpen, RosterPhaseLocked, RosterPhaseEventDay:
+		return true
+	}
+	return false
+}
+
+type RosterChangeAction string
+
+const (
+	RosterChangeActionAdd    RosterChangeAction = "add"
+	RosterChangeActionRemove RosterChangeAction = "remove"
+)
+
+type RosterChangeRequestStatus string
+
+const (
+	RosterChangeRequestStatusPending  RosterChangeRequestStatus = "pending"
+	RosterChangeRequestStatusApproved RosterChangeRequestStatus = "approved"
+	RosterChangeRequestStatusRejected RosterChangeRequestStatus = "rejected"
+)
+
+// RosterChangeRequest は締め切り後のメンバー変更申請。1件につき1人の追加か削除を表す
+type RosterChangeRequest struct {
+	ID          int                       `json:"id"`
+	EventID     int                       `json:"event_id"`
+	ClassID     int                       `json:"class_id"`
+	SportID     int                       `json:"sport_id"`
+	UserID      string                    `json:"user_id"`
+	Action      RosterChangeAction        `json:"action"`
+	Reason      string                    `json:"reason"`
+	Status      RosterChangeRequestStatus `json:"status"`
+	RequestedBy *string                   `json:"requested_by,omitempty"`
+	ReviewedBy  *string                   `json:"reviewed_by,omitempty"`
+	ReviewNote  *string                   `json:"review_note,omitempty"`
+	ReviewedAt  *time.Time                `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time                 `json:"created_at"`
+	ClassName   string                    `json:"class_name,omitempty"`
+	SportName   string                    `json:"sport_name,omitempty"`
+	UserEmail   string                    `json:"user_email,omitempty"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// round_check_in_scans.status の値
+const (
+	RoundCheckInScanStatusCheckedIn        = "checked_in"
+	RoundCheckInScanStatusAlreadyCheckedIn = "already_checked_in"
+	RoundCheckInScanStatusRejected         = "rejected"
+	// Failed はサーバー側の一時的な失敗。結果を残さないので同じ冪等キーで再送できる
+	RoundCheckInScanStatusFailed = "failed"
+)
+
+// RoundCheckInScan はスキャナー端末がオフラインで読み取った試合チェックイン1件
+type RoundCheckInScan struct {
+	// IdempotencyKey は端末が読み取りごとに発行するキー。再送されたときはこのキーで前回の結果を返す
+	IdempotencyKey string `json:"idempotency_key"`
+	// ScannedAt は端末で読み取った時刻。省略したとき・未来の時刻のときは受け取った時刻で記録する
+	ScannedAt *time.Time `json:"scanned_at"`
+	BarcodeCheckInRequest
+}
+
+// RoundCheckInBatchRequest は端末に溜まった読み取りをまとめて送るリクエスト
+type RoundCheckInBatchRequest struct {
+	Scans []RoundCheckInScan `json:"scans"`
+}
+
+// RoundCheckInScanResult は読み取り1件分の処理結果。Body は1件ずつ送ったときのレスポンスと同じ内容
+type RoundCheckInScanResult struct {
+	IdempotencyKey string                 `json:"idempotency_key"`
+	Status         string                 `json:"status"`
+	HTTPStatus     int                    `json:"http_status"`
+	Body           map[string]interface{} `json:"body"`
+	Replayed       bool                   `json:"replayed"`
+}
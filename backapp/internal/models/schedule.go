@@ -0,0 +1,161 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ScheduleTimeLayout は試合開始時刻の受け渡しに使う書式。matches.match_start_time と同じ。
+const ScheduleTimeLayout = "2006-01-02 15:04:05"
+
+// DefaultScheduleSlotMinutes は日程設定がないイベントで重複を調べるときの1試合の長さ
+const DefaultScheduleSlotMinutes = 30
+
+// 試合日程の衝突の種類
+const (
+	ScheduleConflictCourt      = "court_overlap"
+	ScheduleConflictClass      = "class_overlap"
+	ScheduleConflictStudent    = "student_overlap"
+	ScheduleConflictRoundOrder = "round_order"
+	ScheduleConflictNoCourt    = "no_court"
+)
+
+// ScheduleVenue は会場（event_sports.location）で使えるコート
+type ScheduleVenue struct {
+	Location string   `json:"location"`
+	Courts   []string `json:"courts"`
+}
+
+// ScheduleSportSlot は競技ごとの1試合の枠の長さ
+type ScheduleSportSlot struct {
+	SportID     int `json:"sport_id"`
+	SlotMinutes int `json:"slot_minutes"`
+}
+
+// ScheduleConfig はイベントの試合日程を組むための設定
+type ScheduleConfig struct {
+	EventID            int                 `json:"event_id"`
+	StartTime          string              `json:"start_time"`
+	DefaultSlotMinutes int                 `json:"default_slot_minutes"`
+	Venues             []ScheduleVenue     `json:"venues"`
+	Sports             []ScheduleSportSlot `json:"sports"`
+}
+
+// ParseScheduleTime は試合の開始時刻を読む。rainy_mode_start_time は文字列で保存されているため、ISO 8601 形式も受け付ける。
+func ParseScheduleTime(value string) (time.Time, error) {
+	t, err := time.Parse(ScheduleTimeLayout, value)
+	if err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", time.RFC3339} {
+		if t, parseErr := time.Parse(layout, value); parseErr == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// DefaultScheduleConfig は日程設定がないイベントで重複を調べるための設定
+func DefaultScheduleConfig(eventID int) ScheduleConfig {
+	return ScheduleConfig{EventID: eventID, DefaultSlotMinutes: DefaultScheduleSlotMinutes}
+}
+
+// Validate は設定が日程を組める内容かを確認する
+func (c ScheduleConfig) Validate() error {
+	if _, err := time.Parse(ScheduleTimeLayout, c.StartTime); err != nil {
+		return errors.New("start_time must be in YYYY-MM-DD HH:MM:SS format")
+	}
+	if c.DefaultSlotMinutes <= 0 {
+		return errors.New("default_slot_minutes must be positive")
+	}
+
+	locations := make(map[string]bool)
+	for _, venue := range c.Venues {
+		if venue.Location == "" {
+			return errors.New("venue location is required")
+		}
+		if locations[venue.Location] {
+			return fmt.Errorf("duplicate venue location: %s", venue.Location)
+		}
+		locations[venue.Location] = true
+		if len(venue.Courts) == 0 {
+			return fmt.Errorf("venue %s must have at least one court", venue.Location)
+		}
+		courts := make(map[string]bool)
+		for _, court := range venue.Courts {
+			if court == "" || courts[court] {
+				return fmt.Errorf("venue %s has an empty or duplicate court", venue.Location)
+			}
+			courts[court] = true
+		}
+	}
+
+	for _, sport := range c.Sports {
+		if sport.SlotMinutes <= 0 {
+			return fmt.Errorf("slot_minutes for sport %d must be positive", sport.SportID)
+		}
+	}
+	return nil
+}
+
+// SlotFor は競技の1試合の枠の長さを返す
+func (c ScheduleConfig) SlotFor(sportID int) time.Duration {
+	for _, sport := range c.Sports {
+		if sport.SportID == sportID {
+			return time.Duration(sport.SlotMinutes) * time.Minute
+		}
+	}
+	return time.Duration(c.DefaultSlotMinutes) * time.Minute
+}
+
+// CourtsFor は会場のコートを返す。設定されていなければ nil。
+func (c ScheduleConfig) CourtsFor(location string) []string {
+	for _, venue := range c.Venues {
+		if venue.Location == location {
+			return venue.Courts
+		}
+	}
+	return nil
+}
+
+// ScheduleMatch は日程を組む対象の試合。ClassIDs と StudentIDs は対戦が決まっているチームのクラスとメンバー。
+type ScheduleMatch struct {
+	MatchID            int        `json:"match_id"`
+	TournamentID       int        `json:"tournament_id"`
+	SportID            int        `json:"sport_id"`
+	Location           string     `json:"location"`
+	Round              int        `json:"round"`
+	MatchNumberInRound int        `json:"match_number_in_round"`
+	ClassIDs           []int      `json:"class_ids"`
+	StudentIDs         []string   `json:"student_ids"`
+	Status             string     `json:"status"`
+	StartTime          *time.Time `json:"start_time"`
+	RainyModeStartTime *time.Time `json:"rainy_mode_start_time"`
+	Court              string     `json:"court"`
+}
+
+// ScheduleAssignment は試合に割り当てた開始時刻とコート
+type ScheduleAssignment struct {
+	MatchID   int    `json:"match_id"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	Court     string `json:"court"`
+}
+
+// ScheduleConflict は日程の衝突。NoCourt の場合は試合が割り当てられていない。
+type ScheduleConflict struct {
+	Type     string   `json:"type"`
+	MatchIDs []int    `json:"match_ids"`
+	ClassID  *int     `json:"class_id,omitempty"`
+	UserIDs  []string `json:"user_ids,omitempty"`
+	Court    string   `json:"court,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// ScheduleResult は日程の作成・組み直しの結果。Assignments は変更した試合のみ。
+type ScheduleResult struct {
+	EventID     int                  `json:"event_id"`
+	Assignments []ScheduleAssignment `json:"assignments"`
+	Conflicts   []ScheduleConflict   `json:"conflicts"`
+}
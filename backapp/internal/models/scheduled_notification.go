@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// 予約通知の状態（scheduled_notifications.status）
+const (
+	ScheduledNotificationStatusPending   = "pending"
+	ScheduledNotificationStatusSending   = "sending"
+	ScheduledNotificationStatusSent      = "sent"
+	ScheduledNotificationStatusCancelled = "cancelled"
+	ScheduledNotificationStatusMissed    = "missed"
+)
+
+// ScheduledNotification は送信日時を指定して予約した通知。
+// RepeatIntervalMinutes を指定すると RepeatUntil まで同じ間隔で繰り返し送る。
+// Targets の宛先に含まれる利用者は送信のたびに求め直す。Channel は "push" または "push_email_fallback"。
+type ScheduledNotification struct {
+	ID                    int                  `json:"id"`
+	Title                 string               `json:"title"`
+	Body                  string               `json:"body"`
+	Type                  string               `json:"type"`
+	TargetRoles           []string             `json:"target_roles"`
+	Targets               []NotificationTarget `json:"targets,omitempty"`
+	Channel               string               `json:"channel"`
+	SendAt                time.Time            `json:"send_at"`
+	RepeatIntervalMinutes *int                 `json:"repeat_interval_minutes,omitempty"`
+	RepeatUntil           *time.Time           `json:"repeat_until,omitempty"`
+	Status                string               `json:"status"`
+	SentCount             int                  `json:"sent_count"`
+	LastNotificationID    *int                 `json:"last_notification_id,omitempty"`
+	CreatedBy             string               `json:"created_by"`
+	CreatedAt             time.Time            `json:"created_at"`
+	UpdatedAt             time.Time            `json:"updated_at"`
+}
+
+// Advance は送信日時を過ぎた予約を now 時点で送るかを返し、次の送信日時と状態に進める。
+// 送信日時から lateLimit 以上遅れた回は送らない。繰り返しの予約は now より後の次の回に進め、
+// RepeatUntil を過ぎれば送信済みにする。
+func (n *ScheduledNotification) Advance(now time.Time, lateLimit time.Duration) bool {
+	send := now.Sub(n.SendAt) < lateLimit
+
+	if n.RepeatIntervalMinutes == nil || *n.RepeatIntervalMinutes <= 0 {
+		if send {
+			n.Status = ScheduledNotificationStatusSent
+		} else {
+			n.Status = ScheduledNotificationStatusMissed
+		}
+		return send
+	}
+
+	interval := time.Duration(*n.RepeatIntervalMinutes) * time.Minute
+	next := n.SendAt.Add(interval)
+	for !next.After(now) {
+		next = next.Add(interval)
+	}
+	n.SendAt = next
+	if n.RepeatUntil != nil && next.After(*n.RepeatUntil) {
+		if n.SentCount > 0 || send {
+			n.Status = ScheduledNotificationStatusSent
+		} else {
+			n.Status = ScheduledNotificationStatusMissed
+		}
+	}
+	return send
+}
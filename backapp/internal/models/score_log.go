@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// score_logs.operation の値。得点がどの操作で記録されたかを表す。
+const (
+	ScoreOperationMatchResult     = "match_result"
+	ScoreOperationMatchCorrection = "match_correction"
+	ScoreOperationAttendance      = "attendance"
+	ScoreOperationSurvey          = "survey"
+	ScoreOperationMIC             = "mic"
+	ScoreOperationNoonGame        = "noon_game"
+	ScoreOperationManual          = "manual"
+	ScoreOperationInitial         = "initial"
+	ScoreOperationRecalculation   = "recalculation"
+	ScoreOperationMatchRestore    = "match_restore"
+)
+
+// ScoreLogEntry は得点台帳の1行。打ち消された記録も削除せずに残し、
+// 打ち消した側の記録は ReversesLogID で元の記録を指す。
+type ScoreLogEntry struct {
+	ID               int       `json:"id"`
+	EventID          int       `json:"eventId"`
+	ClassID          int       `json:"classId"`
+	Points           int       `json:"points"`
+	Reason           string    `json:"reason"`
+	Operation        string    `json:"operation"`
+	SourceMatchID    *int      `json:"sourceMatchId"`
+	ActorUserID      *string   `json:"actorUserId"`
+	ActorDisplayName *string   `json:"actorDisplayName"`
+	ReversesLogID    *int      `json:"reversesLogId"`
+	ReversedByLogID  *int      `json:"reversedByLogId"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// ScoreLogFilter はクラスの得点履歴の絞り込み条件。ゼロ値の項目は条件に含めない。
+type ScoreLogFilter struct {
+	Reason        string
+	Operation     string
+	SourceMatchID int
+	From          *time.Time
+	To            *time.Time
+}
+
+// ClassScoreHistory はクラスの得点履歴と、絞り込み後の記録の合計点
+type ClassScoreHistory struct {
+	EventID int              `json:"eventId"`
+	ClassID int              `json:"classId"`
+	Total   int              `json:"total"`
+	Logs    []*ScoreLogEntry `json:"logs"`
+}
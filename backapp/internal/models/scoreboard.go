@@ -0,0 +1,55 @@
+package models
+
+// スコアボード配信で送るメッセージの種類
+const (
+	ScoreboardMessageSnapshot       = "snapshot"
+	ScoreboardMessageMatchResult    = "match_result"
+	ScoreboardMessageNextMatch      = "next_match"
+	ScoreboardMessageClassScores    = "class_scores"
+	ScoreboardMessageNoonGamePoints = "noon_game_points"
+)
+
+// ScoreboardClassScore は掲示用に絞ったクラスの得点
+type ScoreboardClassScore struct {
+	ClassID        int    `json:"class_id"`
+	ClassName      string `json:"class_name"`
+	TotalPoints    int    `json:"total_points"`
+	Rank           int    `json:"rank"`
+	NoonGamePoints int    `json:"noon_game_points"`
+}
+
+// ScoreboardMatch は試合結果と次の試合の割り当てを伝えるための試合情報
+type ScoreboardMatch struct {
+	EventID      int     `json:"event_id"`
+	TournamentID int     `json:"tournament_id"`
+	SportID      int     `json:"sport_id"`
+	MatchID      int     `json:"match_id"`
+	Round        int     `json:"round"`
+	Team1ID      *int    `json:"team1_id"`
+	Team1Name    *string `json:"team1_name"`
+	Team2ID      *int    `json:"team2_id"`
+	Team2Name    *string `json:"team2_name"`
+	Team1Score   *int    `json:"team1_score"`
+	Team2Score   *int    `json:"team2_score"`
+	WinnerID     *int    `json:"winner_id"`
+	Status       string  `json:"status"`
+	ResultType   string  `json:"result_type"`
+	NextMatchID  *int    `json:"next_match_id"`
+}
+
+// ScoreboardSnapshot は接続時に送るスコアボードの全体像。
+// 得点が非表示のイベントでは ClassScores を空にする。
+type ScoreboardSnapshot struct {
+	EventID      int                    `json:"event_id"`
+	EventName    string                 `json:"event_name"`
+	ScoresHidden bool                   `json:"scores_hidden"`
+	ClassScores  []ScoreboardClassScore `json:"class_scores"`
+	Tournaments  []*Tournament          `json:"tournaments"`
+}
+
+// ScoreboardToken は発行したスコアボード用トークン。Token は発行時にだけ返す。
+type ScoreboardToken struct {
+	EventID    int    `json:"event_id"`
+	Token      string `json:"token"`
+	StreamPath string `json:"stream_path"`
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// RateTier は出席率・アンケート回答率が MinRate 以上のときに付与する得点
+type RateTier struct {
+	MinRate float64 `json:"minRate"`
+	Points  int     `json:"points"`
+}
+
+// RateLadder は率に応じた段階的な得点表。どの段階にも届かない場合は BasePoints を付与する。
+type RateLadder struct {
+	Tiers      []RateTier `json:"tiers"`
+	BasePoints int        `json:"basePoints"`
+}
+
+// PointsFor は率に対応する得点を返す。Tiers は MinRate の降順で並んでいる前提。
+func (l RateLadder) PointsFor(rate float64) int {
+	for _, tier := range l.Tiers {
+		if rate >= tier.MinRate {
+			return tier.Points
+		}
+	}
+	return l.BasePoints
+}
+
+// ScoringRules はイベントごとの配点ルール
+type ScoringRules struct {
+	// RoundWinPoints は本戦の1回戦、2回戦、3回戦の勝利ごとに付与する得点
+	RoundWinPoints             []int      `json:"roundWinPoints"`
+	ChampionPoints             int        `json:"championPoints"`
+	RunnerUpPoints             int        `json:"runnerUpPoints"`
+	ThirdPlacePoints           int        `json:"thirdPlacePoints"`
+	FourthPlacePoints          int        `json:"fourthPlacePoints"`
+	LoserBracketChampionPoints int        `json:"loserBracketChampionPoints"`
+	LeagueWinPoints            int        `json:"leagueWinPoints"`
+	LeagueDrawPoints           int        `json:"leagueDrawPoints"`
+	Attendance                 RateLadder `json:"attendance"`
+	Survey                     RateLadder `json:"survey"`
+	MICPoints                  int        `json:"micPoints"`
+}
+
+// ScoringRuleSet は保存された配点ルールの1バージョン
+type ScoringRuleSet struct {
+	ID        int          `json:"id"`
+	EventID   int          `json:"eventId"`
+	Version   int          `json:"version"`
+	Rules     ScoringRules `json:"rules"`
+	CreatedBy *string      `json:"createdBy"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+// ScoreRecalculationResult は得点の再計算で再生した試合数と、出席点・アンケート得点・MIC得点を計算し直したクラス数
+type ScoreRecalculationResult struct {
+	EventID           int `json:"eventId"`
+	ReplayedMatches   int `json:"replayedMatches"`
+	AttendanceClasses int `json:"attendanceClasses"`
+	SurveyClasses     int `json:"surveyClasses"`
+	MICClasses        int `json:"micClasses"`
+}
+
+func defaultRateLadder() RateLadder {
+	return RateLadder{
+		Tiers: []RateTier{
+			{MinRate: 0.9, Points: 10},
+			{MinRate: 0.8, Points: 9},
+			{MinRate: 0.7, Points: 8},
+			{MinRate: 0.6, Points: 7},
+			{MinRate: 0.5, Points: 6},
+		},
+		BasePoints: 5,
+	}
+}
+
+// DefaultScoringRules はルールが保存されていないイベントに適用する従来の配点
+func DefaultScoringRules() ScoringRules {
+	return ScoringRules{
+		RoundWinPoints:             []int{10, 10, 10},
+		ChampionPoints:             80,
+		RunnerUpPoints:             60,
+		ThirdPlacePoints:           50,
+		FourthPlacePoints:          40,
+		LoserBracketChampionPoints: 10,
+		LeagueWinPoints:            10,
+		LeagueDrawPoints:           5,
+		Attendance:                 defaultRateLadder(),
+		Survey:                     defaultRateLadder(),
+		MICPoints:                  3,
+	}
+}
+
+// WinPointsForRound は本戦のラウンド勝利点を返す。設定のないラウンドは0点。
+func (r ScoringRules) WinPointsForRound(round int) int {
+	if round < 0 || round >= len(r.RoundWinPoints) {
+		return 0
+	}
+	return r.RoundWinPoints[round]
+}
+
+// Validate は配点ルールとして保存できる内容かを確認する
+func (r ScoringRules) Validate() error {
+	if len(r.RoundWinPoints) != 3 {
+		return errors.New("roundWinPoints must have 3 entries")
+	}
+	for _, p := range r.RoundWinPoints {
+		if p < 0 {
+			return errors.New("points must not be negative")
+		}
+	}
+	for _, p := range []int{
+		r.ChampionPoints, r.RunnerUpPoints, r.ThirdPlacePoints, r.FourthPlacePoints,
+		r.LoserBracketChampionPoints, r.LeagueWinPoints, r.LeagueDrawPoints, r.MICPoints,
+	} {
+		if p < 0 {
+			return errors.New("points must not be negative")
+		}
+	}
+	for _, ladder := range []RateLadder{r.Attendance, r.Survey} {
+		if ladder.BasePoints < 0 {
+			return errors.New("points must not be negative")
+		}
+		for i, tier := range ladder.Tiers {
+			if tier.MinRate < 0 || tier.MinRate > 1 {
+				return errors.New("minRate must be between 0 and 1")
+			}
+			if tier.Points < 0 {
+				return errors.New("points must not be negative")
+			}
+			if i > 0 && tier.MinRate >= ladder.Tiers[i-1].MinRate {
+				return errors.New("tiers must be sorted by minRate in descending order")
+			}
+		}
+	}
+	return nil
+}
@@ -8,12 +8,21 @@ type Sport struct {
 
 // EventSport represents the event_sports table
 type EventSport struct {
-	EventID     int     `json:"event_id"`
-	SportID     int     `json:"sport_id"`
-	SportName   string  `json:"sport_name,omitempty"`
-	Description *string `json:"description"`
-	RulesPdfURL *string `json:"rules_pdf_url"`
-	Location    string  `json:"location"`
-	MinCapacity *int    `json:"min_capacity"`
-	MaxCapacity *int    `json:"max_capacity"`
+	EventID            int     `json:"event_id"`
+	SportID            int     `json:"sport_id"`
+	SportName          string  `json:"sport_name,omitempty"`
+	Description        *string `json:"description"`
+	RulesPdfURL        *string `json:"rules_pdf_url"`
+	Location           string  `json:"location"`
+	MinCapacity        *int    `json:"min_capacity"`
+	MaxCapacity        *int    `json:"max_capacity"`
+	Format             string  `json:"format,omitempty"`
+	LeagueGroupCount   *int    `json:"league_group_count,omitempty"`
+	LeagueAdvanceCount *int    `json:"league_advance_count,omitempty"`
 }
+
+// event_sports.format の値。league の場合はグループごとの総当たり戦を生成する
+const (
+	SportFormatTournament = "tournament"
+	SportFormatLeague     = "league"
+)
@@ -30,6 +30,8 @@ type BarcodeCheckInRequest struct {
 	SportID     int    `json:"sport_id"`
 	MatchID     int    `json:"match_id"`
 	MatchIDs    []int  `json:"match_ids"`
+	// UserID is set instead of BarcodeData when an admin picked the student from the manual lookup.
+	UserID string `json:"user_id"`
 }
 
 // MatchCheckInMember represents a student checked in for a selected match.
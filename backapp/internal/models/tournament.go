@@ -5,6 +5,74 @@ import (
 	"encoding/json"
 )
 
+// MatchStatusBye は不戦勝（対戦相手なし）の一回戦を表す matches.status の値
+const MatchStatusBye = "bye"
+
+// MatchStatusCancelled は雨天時計画で競技ごと中止になった試合を表す matches.status の値
+const MatchStatusCancelled = "cancelled"
+
+// 試合結果の種別（matches.result_type）
+const (
+	MatchResultNormal           = "normal"
+	MatchResultWalkover         = "walkover"         // 相手チームの不出場による不戦勝
+	MatchResultForfeit          = "forfeit"          // 試合途中の棄権
+	MatchResultDoubleForfeit    = "double_forfeit"   // 両チームの不出場。勝者なし
+	MatchResultDisqualification = "disqualification" // 失格
+)
+
+// IsValidMatchResultType は試合結果の種別として受け付ける値かを返す
+func IsValidMatchResultType(resultType string) bool {
+	switch resultType {
+	case MatchResultNormal, MatchResultWalkover, MatchResultForfeit, MatchResultDoubleForfeit, MatchResultDisqualification:
+		return true
+	}
+	return false
+}
+
+// MatchResultNeedsForfeitingTeam は棄権・不出場・失格となったチームの指定が必要な種別かを返す
+func MatchResultNeedsForfeitingTeam(resultType string) bool {
+	return resultType == MatchResultWalkover || resultType == MatchResultForfeit || resultType == MatchResultDisqualification
+}
+
+// MatchResultKeepsScores はスコアを記録する種別かを返す。不出場の試合はスコアを持たない。
+func MatchResultKeepsScores(resultType string) bool {
+	return resultType != MatchResultWalkover && resultType != MatchResultDoubleForfeit
+}
+
+// MatchResultLoserContinues は敗者が3位決定戦・敗者戦に回るかを返す。
+// 試合に出場した敗者（通常・途中棄権）だけが回り、不出場や失格のチームの枠は空けておく。
+func MatchResultLoserContinues(resultType string) bool {
+	return resultType == MatchResultNormal || resultType == MatchResultForfeit
+}
+
+// MatchResultLabel は対戦表に表示する試合結果の種別名を返す。通常の結果は空文字。
+func MatchResultLabel(resultType string) string {
+	switch resultType {
+	case MatchResultWalkover:
+		return "不戦勝"
+	case MatchResultForfeit:
+		return "棄権"
+	case MatchResultDoubleForfeit:
+		return "両者不戦敗"
+	case MatchResultDisqualification:
+		return "失格"
+	}
+	return ""
+}
+
+// MatchResultSideNote は棄権・不出場・失格となったチームの枠に表示する注記を返す
+func MatchResultSideNote(resultType string) string {
+	switch resultType {
+	case MatchResultWalkover, MatchResultDoubleForfeit:
+		return "不出場"
+	case MatchResultForfeit:
+		return "棄権"
+	case MatchResultDisqualification:
+		return "失格"
+	}
+	return ""
+}
+
 // Tournament represents a tournament entity in the database
 
 type Tournament struct {
@@ -35,6 +103,11 @@ type MatchDB struct {
 	LoserBracketRound   sql.NullInt64
 	LoserBracketBlock   sql.NullString
 	RainyModeStartTime  sql.NullString
+	IsLeagueMatch       bool
+	LeagueGroup         sql.NullString
+	ResultType          string
+	ForfeitingTeamID    sql.NullInt64
+	IsRainyDay          bool // 試合の開催日が雨天時モードか（大会一覧の取得時だけ設定する）
 }
 
 // Player represents a player in a contestant
@@ -64,6 +137,7 @@ type Side struct {
 	Scores       []Score `json:"scores,omitempty"`
 	IsServing    bool    `json:"isServing,omitempty"`
 	IsWinner     bool    `json:"isWinner,omitempty"`
+	ResultNote   string  `json:"resultNote,omitempty"`
 }
 
 // Match represents a match in the tournament
@@ -78,9 +152,13 @@ type Match struct {
 	RainyModeStartTime  string `json:"rainyModeStartTime,omitempty"`
 	IsLive              bool   `json:"isLive,omitempty"`
 	IsBronzeMatch       bool   `json:"isBronzeMatch,omitempty"`
+	IsBye               bool   `json:"isBye,omitempty"`
 	IsLoserBracketMatch bool   `json:"isLoserBracketMatch,omitempty"`
 	LoserBracketRound   *int   `json:"loserBracketRound,omitempty"`
 	LoserBracketBlock   string `json:"loserBracketBlock,omitempty"`
+	IsLeagueMatch       bool   `json:"isLeagueMatch,omitempty"`
+	LeagueGroup         string `json:"leagueGroup,omitempty"`
+	ResultType          string `json:"resultType,omitempty"`
 }
 
 // Round represents a round in the tournament
@@ -95,6 +173,8 @@ type TournamentData struct {
 	Rounds      []Round               `json:"rounds"`
 	Matches     []Match               `json:"matches,omitempty"`
 	Contestants map[string]Contestant `json:"contestants,omitempty"`
+	// IsLeagueKnockout はリーグ戦の上位チームが進む決勝トーナメントであることを示す
+	IsLeagueKnockout bool `json:"isLeagueKnockout,omitempty"`
 }
 
 // Team represents a team for tournament generation
@@ -110,3 +190,35 @@ type GeneratedTournament struct {
 	TournamentData TournamentData `json:"tournament_data"`
 	ShuffledTeams  []Team         `json:"shuffled_teams"`
 }
+
+// リーグ戦順位表の勝点（勝ち3・引き分け1・負け0）
+const (
+	LeagueStandingWinPoints  = 3
+	LeagueStandingDrawPoints = 1
+)
+
+// LeagueStanding represents one team's row in a league group standings table
+
+type LeagueStanding struct {
+	Rank           int    `json:"rank"`
+	TeamID         int    `json:"team_id"`
+	TeamName       string `json:"team_name"`
+	ClassID        int    `json:"class_id"`
+	Played         int    `json:"played"`
+	Wins           int    `json:"wins"`
+	Draws          int    `json:"draws"`
+	Losses         int    `json:"losses"`
+	GoalsFor       int    `json:"goals_for"`
+	GoalsAgainst   int    `json:"goals_against"`
+	GoalDifference int    `json:"goal_difference"`
+	Points         int    `json:"points"`
+}
+
+// LeagueGroupStandings represents the standings table of a league group
+
+type LeagueGroupStandings struct {
+	TournamentID int              `json:"tournament_id"`
+	Group        string           `json:"group"`
+	Completed    bool             `json:"completed"`
+	Standings    []LeagueStanding `json:"standings"`
+}
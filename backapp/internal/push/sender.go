@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/netip"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -47,7 +48,20 @@ type Result struct {
 	Subscription        models.PushSubscription
 	StatusCode          int
 	InvalidSubscription bool
-	Err                 error
+	// RetryAfter は 429・5xx の応答で Push サービスが指定した再送までの待ち時間。指定がなければ0。
+	RetryAfter time.Duration
+	Err        error
+}
+
+// Retryable は時間をおいて再送すれば届く見込みがある失敗かを返す
+func (r Result) Retryable() bool {
+	if r.InvalidSubscription {
+		return false
+	}
+	if r.Err != nil && r.StatusCode == 0 {
+		return true
+	}
+	return r.StatusCode == http.StatusTooManyRequests || r.StatusCode >= http.StatusInternalServerError
 }
 
 type Config struct {
@@ -210,6 +224,9 @@ func (s *sender) sendOne(ctx context.Context, payload []byte, sub models.PushSub
 	defer response.Body.Close()
 
 	result.StatusCode = response.StatusCode
+	if result.Retryable() {
+		result.RetryAfter = parseRetryAfter(response.Header.Get("Retry-After"), time.Now())
+	}
 	if response.StatusCode >= http.StatusBadRequest {
 		_, readErr := io.Copy(io.Discard, io.LimitReader(response.Body, maxErrorBodyBytes))
 		if readErr != nil {
@@ -221,6 +238,24 @@ func (s *sender) sendOne(ctx context.Context, payload []byte, sub models.PushSub
 	return result
 }
 
+// parseRetryAfter は Retry-After ヘッダーの秒数または日時を待ち時間にする。読めなければ0。
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
 func EndpointLogID(endpoint string) string {
 	host := "invalid-host"
 	if parsed, err := url.Parse(endpoint); err == nil && parsed.Hostname() != "" {
@@ -215,6 +215,71 @@ func TestSendBatchHonorsConcurrencyLimit(t *testing.T) {
 	}
 }
 
+func TestSendBatchReportsRetryAfter(t *testing.T) {
+	authKey, p256dhKey := validSubscriptionKeys(t)
+	privateKey, publicKey, err := webpush.GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatalf("generate VAPID keys: %v", err)
+	}
+
+	client := &statusHTTPClient{status: http.StatusTooManyRequests, header: http.Header{"Retry-After": []string{"120"}}}
+	sender := newSender(Config{VAPIDPublicKey: publicKey, VAPIDPrivateKey: privateKey}, newHostPolicy([]string{"push.example"}), client)
+
+	results := sender.SendBatch(context.Background(), []byte("payload"), []models.PushSubscription{{
+		ID:        1,
+		UserID:    "user",
+		Endpoint:  "https://push.example/send/a",
+		AuthKey:   authKey,
+		P256dhKey: p256dhKey,
+	}}, 60)
+	if len(results) != 1 {
+		t.Fatalf("result count = %d, want 1", len(results))
+	}
+	if !results[0].Retryable() || results[0].RetryAfter != 2*time.Minute {
+		t.Fatalf("unexpected retry result: status=%d retryable=%v retryAfter=%v", results[0].StatusCode, results[0].Retryable(), results[0].RetryAfter)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: 0},
+		{value: "30", want: 30 * time.Second},
+		{value: "-5", want: 0},
+		{value: "Tue, 20 May 2025 09:01:30 GMT", want: 90 * time.Second},
+		{value: "Tue, 20 May 2025 08:59:00 GMT", want: 0},
+		{value: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestResultRetryable(t *testing.T) {
+	tests := []struct {
+		name   string
+		result Result
+		want   bool
+	}{
+		{name: "created", result: Result{StatusCode: http.StatusCreated}, want: false},
+		{name: "too many requests", result: Result{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "server error", result: Result{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "gone", result: Result{StatusCode: http.StatusGone}, want: false},
+		{name: "network error", result: Result{Err: io.ErrUnexpectedEOF}, want: true},
+		{name: "invalid subscription", result: Result{InvalidSubscription: true, Err: io.ErrUnexpectedEOF}, want: false},
+	}
+	for _, tt := range tests {
+		if got := tt.result.Retryable(); got != tt.want {
+			t.Errorf("%s: Retryable() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
 func validSubscriptionKeys(t *testing.T) (string, string) {
 	t.Helper()
 	auth := []byte("0123456789abcdef")
@@ -260,3 +325,16 @@ func (c *countingHTTPClient) Do(_ *http.Request) (*http.Response, error) {
 		Body:       io.NopCloser(strings.NewReader("")),
 	}, nil
 }
+
+type statusHTTPClient struct {
+	status int
+	header http.Header
+}
+
+func (c *statusHTTPClient) Do(_ *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: c.status,
+		Header:     c.header,
+		Body:       io.NopCloser(strings.NewReader("")),
+	}, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"database/sql"
+
+	"backapp/internal/models"
+)
+
+type CheckInRepository interface {
+	CheckIn(eventID int, userID string, purpose string, actorUserID string) (*models.CheckIn, bool, error)
+	CountClassCheckIns(eventID int, classID int) (int, error)
+	GetUncheckedClassMembers(eventID int, classID int) ([]*models.User, error)
+}
+
+type checkInRepository struct {
+	db *sql.DB
+}
+
+func NewCheckInRepository(db *sql.DB) CheckInRepository {
+	return &checkInRepository{db: db}
+}
+
+// CheckIn は生徒の出席を記録する。開催日が登録されていれば今日の開催日に結び付ける。
+// 同じ日・同じ目的のチェックインが既にあれば記録し直さず、既存のチェックインと false を返す。
+// 重複は uq_check_ins_event_user_day で防ぐため、同時に読み取られても1件しか記録されない。
+func (r *checkInRepository) CheckIn(eventID int, userID string, purpose string, actorUserID string) (*models.CheckIn, bool, error) {
+	var dayID sql.NullInt64
+	err := r.db.QueryRow("SELECT id FROM event_days WHERE event_id = ? AND date = CURDATE()", eventID).Scan(&dayID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, false, err
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO check_ins (user_id, event_id, event_day_id, purpose, checked_in_by)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = id
+	`, userID, eventID, dayID, purpose, actorUserID)
+	if err != nil {
+		return nil, false, err
+	}
+	// 既存の行に当たった場合は値が変わらないため、影響行数は0になる
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+
+	checkIn := &models.CheckIn{}
+	var eventDayID sql.NullInt64
+	var checkedInBy sql.NullString
+	err = r.db.QueryRow(`
+		SELECT id, user_id, event_id, event_day_id, purpose, checked_in_at, checked_in_by
+		FROM check_ins
+		WHERE event_id = ? AND user_id = ? AND purpose = ? AND event_day_key = ?
+	`, eventID, userID, purpose, dayID.Int64).Scan(&checkIn.ID, &checkIn.UserID, &checkIn.EventID, &eventDayID, &checkIn.Purpose, &checkIn.CheckedInAt, &checkedInBy)
+	if err != nil {
+		return nil, false, err
+	}
+	if eventDayID.Valid {
+		id := int(eventDayID.Int64)
+		checkIn.EventDayID = &id
+	}
+	if checkedInBy.Valid {
+		checkIn.CheckedInBy = &checkedInBy.String
+	}
+	return checkIn, affected > 0, nil
+}
+
+// CountClassCheckIns は大会で1回以上チェックインしたクラスの生徒の人数を返す
+func (r *checkInRepository) CountClassCheckIns(eventID int, classID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(DISTINCT ci.user_id)
+		FROM check_ins ci
+		JOIN users u ON u.id = ci.user_id
+		WHERE ci.event_id = ? AND u.class_id = ?
+	`, eventID, classID).Scan(&count)
+	return count, err
+}
+
+// GetUncheckedClassMembers は大会でまだチェックインしていないクラスの生徒を返す
+func (r *checkInRepository) GetUncheckedClassMembers(eventID int, classID int) ([]*models.User, error) {
+	rows, err := r.db.Query(`
+		SELECT u.id, u.email, u.display_name
+		FROM users u
+		WHERE u.class_id = ?
+		  AND NOT EXISTS (SELECT 1 FROM check_ins ci WHERE ci.event_id = ? AND ci.user_id = u.id)
+		ORDER BY u.email
+	`, classID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*models.User, 0)
+	for rows.Next() {
+		user := &models.User{}
+		var displayName sql.NullString
+		if err := rows.Scan(&user.ID, &user.Email, &displayName); err != nil {
+			return nil, err
+		}
+		if displayName.Valid {
+			user.DisplayName = &displayName.String
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
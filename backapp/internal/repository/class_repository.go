@@ -4,6 +4,7 @@ import (
 	"backapp/internal/models"
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -11,15 +12,15 @@ type ClassRepository interface {
 	GetAllClasses(eventID int) ([]*models.Class, error)
 	GetClassByID(id int) (*models.Class, error)
 	GetClassDetails(classID int, eventID int) (*models.ClassDetails, error)
-	UpdateAttendance(classID int, eventID int, attendanceCount int) (int, error)
+	UpdateAttendance(classID int, eventID int, attendanceCount int, actorUserID string) (int, error)
 	UpdateStudentCounts(eventID int, counts map[int]int) error
 	CreateClasses(eventID int, classNames []string) error
 	GetClassScoresByEvent(eventID int) ([]*models.ClassScore, error)
 	GetClassScoresByEvents(eventIDs []int) (map[int][]*models.ClassScore, error)
 	UpdateClassRanks(eventID int) error
 	GetClassMembers(classID int) ([]*models.User, error)
-	SetNoonGamePoints(eventID int, points map[int]int) error
-	SetSurveyPoints(eventID int, points map[int]int) error
+	SetNoonGamePoints(eventID int, points map[int]int, actorUserID string) error
+	SetSurveyPoints(eventID int, submissions map[int]int, points map[int]int, actorUserID string) error
 }
 
 type classRepository struct {
@@ -113,7 +114,7 @@ func (r *classRepository) GetClassDetails(classID int, eventID int) (*models.Cla
 	return details, nil
 }
 
-func (r *classRepository) UpdateAttendance(classID int, eventID int, attendanceCount int) (int, error) {
+func (r *classRepository) UpdateAttendance(classID int, eventID int, attendanceCount int, actorUserID string) (int, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return 0, err
@@ -146,38 +147,18 @@ func (r *classRepository) UpdateAttendance(classID int, eventID int, attendanceC
 			tx.Rollback()
 			return 0, fmt.Errorf("class '%s' (ID %d) has zero students, cannot calculate attendance points", className, classID)
 		}
-		attendanceRate = float64(attendanceCount) / float64(studentCount)
-		switch {
-		case attendanceRate >= 0.9:
-			points = 10
-		case attendanceRate >= 0.8:
-			points = 9
-		case attendanceRate >= 0.7:
-			points = 8
-		case attendanceRate >= 0.6:
-			points = 7
-		case attendanceRate >= 0.5:
-			points = 6
-		default:
-			points = 5
+		rules, err := loadScoringRules(tx, eventID)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
 		}
+		attendanceRate = float64(attendanceCount) / float64(studentCount)
+		points = rules.Attendance.PointsFor(attendanceRate)
 	}
 
-	// Delete old attendance points
-	_, err = tx.Exec("DELETE FROM score_logs WHERE event_id = ? AND class_id = ? AND reason = 'attendance_points'", eventID, classID)
-	if err != nil {
-		tx.Rollback()
-		return 0, err
-	}
-
-	// Insert new attendance points into score_logs
-	logQuery := `
-        INSERT INTO score_logs (event_id, class_id, points, reason)
-        VALUES (?, ?, ?, ?)
-    `
-	reason := "attendance_points"
-	_, err = tx.Exec(logQuery, eventID, classID, points, reason)
-	if err != nil {
+	// 以前の出席点を打ち消して新しい出席点を記録
+	audit := scoreAudit{operation: models.ScoreOperationAttendance, actorUserID: actorUserID}
+	if err := replaceClassPoints(tx, eventID, classID, "attendance_points", points, audit); err != nil {
 		tx.Rollback()
 		return 0, err
 	}
@@ -248,15 +229,18 @@ func (r *classRepository) GetClassScoresByEvent(eventID int) ([]*models.ClassSco
 			cs.gym1_win2_points,
 			cs.gym1_win3_points,
 			cs.gym1_champion_points,
+			cs.gym1_league_points,
 		cs.gym2_win1_points,
 		cs.gym2_win2_points,
 		cs.gym2_win3_points,
 		cs.gym2_champion_points,
 		cs.gym2_loser_bracket_champion_points,
+		cs.gym2_league_points,
 		cs.ground_win1_points,
 			cs.ground_win2_points,
 			cs.ground_win3_points,
 			cs.ground_champion_points,
+			cs.ground_league_points,
 			cs.noon_game_points,
 			cs.total_points_current_event,
 			cs.rank_current_event,
@@ -291,15 +275,18 @@ func (r *classRepository) GetClassScoresByEvent(eventID int) ([]*models.ClassSco
 			&score.Gym1Win2Points,
 			&score.Gym1Win3Points,
 			&score.Gym1ChampionPoints,
+			&score.Gym1LeaguePoints,
 			&score.Gym2Win1Points,
 			&score.Gym2Win2Points,
 			&score.Gym2Win3Points,
 			&score.Gym2ChampionPoints,
 			&score.Gym2LoserBracketChampionPoints,
+			&score.Gym2LeaguePoints,
 			&score.GroundWin1Points,
 			&score.GroundWin2Points,
 			&score.GroundWin3Points,
 			&score.GroundChampionPoints,
+			&score.GroundLeaguePoints,
 			&score.NoonGamePoints,
 			&score.TotalPointsCurrentEvent,
 			&score.RankCurrentEvent,
@@ -371,15 +358,18 @@ func (r *classRepository) GetClassScoresByEvents(eventIDs []int) (map[int][]*mod
 			cs.gym1_win2_points,
 			cs.gym1_win3_points,
 			cs.gym1_champion_points,
+			cs.gym1_league_points,
 			cs.gym2_win1_points,
 			cs.gym2_win2_points,
 			cs.gym2_win3_points,
 			cs.gym2_champion_points,
 			cs.gym2_loser_bracket_champion_points,
+			cs.gym2_league_points,
 			cs.ground_win1_points,
 			cs.ground_win2_points,
 			cs.ground_win3_points,
 			cs.ground_champion_points,
+			cs.ground_league_points,
 			cs.noon_game_points,
 			cs.total_points_current_event,
 			cs.rank_current_event,
@@ -413,15 +403,18 @@ func (r *classRepository) GetClassScoresByEvents(eventIDs []int) (map[int][]*mod
 			&score.Gym1Win2Points,
 			&score.Gym1Win3Points,
 			&score.Gym1ChampionPoints,
+			&score.Gym1LeaguePoints,
 			&score.Gym2Win1Points,
 			&score.Gym2Win2Points,
 			&score.Gym2Win3Points,
 			&score.Gym2ChampionPoints,
 			&score.Gym2LoserBracketChampionPoints,
+			&score.Gym2LeaguePoints,
 			&score.GroundWin1Points,
 			&score.GroundWin2Points,
 			&score.GroundWin3Points,
 			&score.GroundChampionPoints,
+			&score.GroundLeaguePoints,
 			&score.NoonGamePoints,
 			&score.TotalPointsCurrentEvent,
 			&score.RankCurrentEvent,
@@ -484,32 +477,16 @@ func (r *classRepository) GetClassMembers(classID int) ([]*models.User, error) {
 	return users, nil
 }
 
-func (r *classRepository) SetNoonGamePoints(eventID int, points map[int]int) error {
+func (r *classRepository) SetNoonGamePoints(eventID int, points map[int]int, actorUserID string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	if _, err := tx.Exec("DELETE FROM score_logs WHERE event_id = ? AND reason = 'noon_game_points'", eventID); err != nil {
-		return fmt.Errorf("failed to reset noon_game_points: %w", err)
-	}
-
-	if len(points) > 0 {
-		stmt, err := tx.Prepare(`
-			INSERT INTO score_logs (event_id, class_id, points, reason)
-			VALUES (?, ?, ?, 'noon_game_points')
-		`)
-		if err != nil {
-			return fmt.Errorf("failed to prepare noon_game_points statement: %w", err)
-		}
-		defer stmt.Close()
-
-		for classID, value := range points {
-			if _, err := stmt.Exec(eventID, classID, value); err != nil {
-				return fmt.Errorf("failed to update noon_game_points for class %d: %w", classID, err)
-			}
-		}
+	audit := scoreAudit{operation: models.ScoreOperationNoonGame, actorUserID: actorUserID}
+	if err := replaceEventPoints(tx, eventID, "noon_game_points", points, audit); err != nil {
+		return fmt.Errorf("failed to update noon_game_points: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -519,33 +496,29 @@ func (r *classRepository) SetNoonGamePoints(eventID int, points map[int]int) err
 	return nil
 }
 
-func (r *classRepository) SetSurveyPoints(eventID int, points map[int]int) error {
+// SetSurveyPoints はクラスごとのアンケートの提出数を残し、アンケート得点を points に置き換える。
+// 提出数は配点ルールを変えたときの再計算に使う。
+func (r *classRepository) SetSurveyPoints(eventID int, submissions map[int]int, points map[int]int, actorUserID string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Clear old points specifically for the event
-	if _, err := tx.Exec("DELETE FROM score_logs WHERE event_id = ? AND reason = 'survey_points'", eventID); err != nil {
-		return fmt.Errorf("failed to reset survey_points: %w", err)
+	classIDs := make([]int, 0, len(submissions))
+	for classID := range submissions {
+		classIDs = append(classIDs, classID)
 	}
-
-	if len(points) > 0 {
-		stmt, err := tx.Prepare(`
-			INSERT INTO score_logs (event_id, class_id, points, reason)
-			VALUES (?, ?, ?, 'survey_points')
-		`)
-		if err != nil {
-			return fmt.Errorf("failed to prepare survey_points statement: %w", err)
+	sort.Ints(classIDs)
+	for _, classID := range classIDs {
+		if _, err := tx.Exec("UPDATE classes SET survey_submission_count = ? WHERE id = ? AND event_id = ?", submissions[classID], classID, eventID); err != nil {
+			return fmt.Errorf("failed to update survey_submission_count: %w", err)
 		}
-		defer stmt.Close()
+	}
 
-		for classID, value := range points {
-			if _, err := stmt.Exec(eventID, classID, value); err != nil {
-				return fmt.Errorf("failed to update survey_points for class %d: %w", classID, err)
-			}
-		}
+	audit := scoreAudit{operation: models.ScoreOperationSurvey, actorUserID: actorUserID}
+	if err := replaceEventPoints(tx, eventID, "survey_points", points, audit); err != nil {
+		return fmt.Errorf("failed to update survey_points: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
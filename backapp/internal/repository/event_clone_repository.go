@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"backapp/internal/models"
+)
+
+// GetCloneSource は大会の複製で引き継げる競技・クラス・雨天時設定・大会資料と、
+// 昼競技テンプレートのデフォルトグループを返す。大会がなければ nil を返す。
+func (r *eventRepository) GetCloneSource(eventID int) (*models.EventCloneSource, error) {
+	event, err := r.GetEventByID(eventID)
+	if err != nil || event == nil {
+		return nil, err
+	}
+	source := &models.EventCloneSource{Event: event}
+
+	sportRows, err := r.db.Query(`
+		SELECT es.event_id, es.sport_id, s.name, es.description, es.rules_pdf_url, es.location, es.min_capacity, es.max_capacity, es.format, es.league_group_count, es.league_advance_count
+		FROM event_sports es
+		JOIN sports s ON es.sport_id = s.id
+		WHERE es.event_id = ?
+		ORDER BY es.sport_id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer sportRows.Close()
+	for sportRows.Next() {
+		sport := &models.EventSport{}
+		if err := sportRows.Scan(&sport.EventID, &sport.SportID, &sport.SportName, &sport.Description, &sport.RulesPdfURL, &sport.Location, &sport.MinCapacity, &sport.MaxCapacity, &sport.Format, &sport.LeagueGroupCount, &sport.LeagueAdvanceCount); err != nil {
+			return nil, err
+		}
+		source.Sports = append(source.Sports, sport)
+	}
+	if err := sportRows.Err(); err != nil {
+		return nil, err
+	}
+
+	classRows, err := r.db.Query("SELECT id, event_id, name, student_count, attend_count FROM classes WHERE event_id = ? ORDER BY name", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer classRows.Close()
+	for classRows.Next() {
+		class := &models.Class{}
+		if err := classRows.Scan(&class.ID, &class.EventID, &class.Name, &class.StudentCount, &class.AttendCount); err != nil {
+			return nil, err
+		}
+		source.Classes = append(source.Classes, class)
+	}
+	if err := classRows.Err(); err != nil {
+		return nil, err
+	}
+
+	settingRows, err := r.db.Query(`
+		SELECT id, event_id, sport_id, class_id, min_capacity, max_capacity, match_start_time
+		FROM rainy_mode_settings
+		WHERE event_id = ?
+		ORDER BY sport_id, class_id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer settingRows.Close()
+	for settingRows.Next() {
+		setting := &models.RainyModeSetting{}
+		if err := settingRows.Scan(&setting.ID, &setting.EventID, &setting.SportID, &setting.ClassID, &setting.MinCapacity, &setting.MaxCapacity, &setting.MatchStartTime); err != nil {
+			return nil, err
+		}
+		source.RainyModeSettings = append(source.RainyModeSettings, setting)
+	}
+	if err := settingRows.Err(); err != nil {
+		return nil, err
+	}
+
+	docRows, err := r.db.Query(`
+		SELECT id, event_id, title, description, pdf_url, created_at, updated_at
+		FROM guide_documents
+		WHERE event_id = ?
+		ORDER BY created_at, id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer docRows.Close()
+	for docRows.Next() {
+		doc := &models.GuideDocument{}
+		if err := docRows.Scan(&doc.ID, &doc.EventID, &doc.Title, &doc.Description, &doc.PdfURL, &doc.CreatedAt, &doc.UpdatedAt); err != nil {
+			return nil, err
+		}
+		source.GuideDocuments = append(source.GuideDocuments, doc)
+	}
+	if err := docRows.Err(); err != nil {
+		return nil, err
+	}
+
+	groupRows, err := r.db.Query(`
+		SELECT id, template_key, group_index, group_name, class_names
+		FROM noon_game_template_default_groups
+		ORDER BY template_key, group_index
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer groupRows.Close()
+	for groupRows.Next() {
+		group := &models.NoonGameTemplateDefaultGroup{}
+		var classNamesJSON string
+		if err := groupRows.Scan(&group.ID, &group.TemplateKey, &group.GroupIndex, &group.GroupName, &classNamesJSON); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(classNamesJSON), &group.ClassNames); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal class_names: %w", err)
+		}
+		source.NoonGameDefaultGroups = append(source.NoonGameDefaultGroups, group)
+	}
+	if err := groupRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return source, nil
+}
+
+// CloneEvent は複製の計画どおりに新しい大会を作り、選んだ項目を1つのトランザクションで引き継ぐ
+func (r *eventRepository) CloneEvent(plan *models.EventClonePlan) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	eventID, err := insertEvent(tx, plan.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, sport := range plan.Sports {
+		if _, err := tx.Exec(`
+			INSERT INTO event_sports (event_id, sport_id, description, rules_pdf_url, location, format, league_group_count, league_advance_count, min_capacity, max_capacity)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, eventID, sport.SportID, sport.Description, sport.RulesPdfURL, sport.Location, sport.Format, sport.LeagueGroupCount, sport.LeagueAdvanceCount, sport.MinCapacity, sport.MaxCapacity); err != nil {
+			return 0, err
+		}
+	}
+
+	// 雨天時設定は新しいクラス名で指定されているため、作ったクラスのIDに引き当てる
+	classIDs := make(map[string]int64, len(plan.Classes))
+	for _, class := range plan.Classes {
+		result, err := tx.Exec("INSERT INTO classes (event_id, name, student_count) VALUES (?, ?, ?)", eventID, class.Name, class.StudentCount)
+		if err != nil {
+			return 0, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+		classIDs[class.Name] = id
+	}
+
+	for _, setting := range plan.RainyModeSettings {
+		classID, ok := classIDs[setting.ClassName]
+		if !ok {
+			return 0, fmt.Errorf("class %q is not part of the clone plan", setting.ClassName)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO rainy_mode_settings (event_id, sport_id, class_id, min_capacity, max_capacity, match_start_time)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, eventID, setting.SportID, classID, setting.MinCapacity, setting.MaxCapacity, setting.MatchStartTime); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, doc := range plan.GuideDocuments {
+		if _, err := tx.Exec("INSERT INTO guide_documents (event_id, title, description, pdf_url) VALUES (?, ?, ?, ?)", eventID, doc.Title, doc.Description, doc.PdfURL); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, group := range plan.NoonGameDefaultGroups {
+		classNamesJSON, err := json.Marshal(group.ClassNames)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal class_names: %w", err)
+		}
+		if _, err := tx.Exec("UPDATE noon_game_template_default_groups SET class_names = ? WHERE id = ?", string(classNamesJSON), group.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return eventID, nil
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"backapp/internal/models"
+)
+
+type EventDayRepository interface {
+	GetDaysByEventID(eventID int) ([]*models.EventDay, error)
+	GetDayByID(dayID int) (*models.EventDay, error)
+	SyncDays(eventID int, dates []time.Time) ([]*models.EventDay, error)
+	SetRainyMode(dayID int, isRainyMode bool, actorUserID string) error
+	AssignMatches(dayID int, matchIDs []int) (int64, error)
+	AssignNoonSessions(dayID int, sessionIDs []int) (int64, error)
+	IsNoonSessionRainy(sessionID int) (bool, error)
+	CountCheckedInUsers(dayID int) (int, error)
+	GetSportNamesByDay(dayID int) ([]string, error)
+}
+
+type eventDayRepository struct {
+	db *sql.DB
+}
+
+func NewEventDayRepository(db *sql.DB) EventDayRepository {
+	return &eventDayRepository{db: db}
+}
+
+const eventDayDateLayout = "2006-01-02"
+
+func (r *eventDayRepository) GetDaysByEventID(eventID int) ([]*models.EventDay, error) {
+	rows, err := r.db.Query(`
+		SELECT id, event_id, day_number, date, is_rainy_mode
+		FROM event_days
+		WHERE event_id = ?
+		ORDER BY date
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make([]*models.EventDay, 0)
+	for rows.Next() {
+		day, err := scanEventDay(rows)
+		if err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+// GetDayByID は開催日を返す。存在しなければ nil。
+func (r *eventDayRepository) GetDayByID(dayID int) (*models.EventDay, error) {
+	day, err := scanEventDay(r.db.QueryRow(`
+		SELECT id, event_id, day_number, date, is_rainy_mode
+		FROM event_days
+		WHERE id = ?
+	`, dayID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return day, err
+}
+
+func scanEventDay(row rowScanner) (*models.EventDay, error) {
+	var day models.EventDay
+	if err := row.Scan(&day.ID, &day.EventID, &day.DayNumber, &day.Date, &day.IsRainyMode); err != nil {
+		return nil, err
+	}
+	return &day, nil
+}
+
+// SyncDays は大会の開催日を dates に合わせる。残る日の雨天時モードや試合の割り当てはそのまま保ち、
+// dates にない日は削除する（結び付いていた試合などは開催日なしに戻る）。
+func (r *eventDayRepository) SyncDays(eventID int, dates []time.Time) ([]*models.EventDay, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	placeholders := make([]string, len(dates))
+	args := make([]interface{}, 0, len(dates)+1)
+	args = append(args, eventID)
+	for i, date := range dates {
+		placeholders[i] = "?"
+		args = append(args, date.Format(eventDayDateLayout))
+	}
+	deleteQuery := "DELETE FROM event_days WHERE event_id = ?"
+	if len(dates) > 0 {
+		deleteQuery += " AND date NOT IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	if _, err := tx.Exec(deleteQuery, args...); err != nil {
+		return nil, err
+	}
+
+	for i, date := range dates {
+		if _, err := tx.Exec(`
+			INSERT INTO event_days (event_id, day_number, date)
+			VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE day_number = VALUES(day_number)
+		`, eventID, i+1, date.Format(eventDayDateLayout)); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetDaysByEventID(eventID)
+}
+
+// SetRainyMode は開催日の雨天時モードを切り替える。有効にしたときは、その日の試合だけ雨天用の開始時刻に切り替える。
+func (r *eventDayRepository) SetRainyMode(dayID int, isRainyMode bool, actorUserID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE event_days SET is_rainy_mode = ? WHERE id = ?", isRainyMode, dayID); err != nil {
+		return err
+	}
+
+	if isRainyMode {
+		const condition = "m.event_day_id = ? AND m.rainy_mode_start_time IS NOT NULL AND m.rainy_mode_start_time != ''"
+		if err := ensureMatchBaselines(tx, condition, dayID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+			UPDATE matches m
+			SET m.match_start_time = m.rainy_mode_start_time,
+			    m.status = CASE WHEN m.status = 'pending' THEN 'scheduled' ELSE m.status END
+			WHERE `+condition, dayID); err != nil {
+			return err
+		}
+		if err := recordMatchRevisions(tx, newMatchChange(models.MatchRevisionStartTime, actorUserID), condition, dayID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AssignMatches は試合を開催日に割り当てる。開催日と別の大会の試合は変更しない。
+func (r *eventDayRepository) AssignMatches(dayID int, matchIDs []int) (int64, error) {
+	if len(matchIDs) == 0 {
+		return 0, nil
+	}
+	condition, args := matchIDCondition("m.id", matchIDs)
+	// #nosec G202 -- condition contains only internally generated placeholders; values are bound below.
+	result, err := r.db.Exec(`
+		UPDATE matches m
+		JOIN tournaments t ON t.id = m.tournament_id
+		JOIN event_days ed ON ed.id = ? AND ed.event_id = t.event_id
+		SET m.event_day_id = ed.id
+		WHERE `+condition, append([]interface{}{dayID}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// AssignNoonSessions は昼競技のセッションを開催日に割り当てる。開催日と別の大会のセッションは変更しない。
+func (r *eventDayRepository) AssignNoonSessions(dayID int, sessionIDs []int) (int64, error) {
+	if len(sessionIDs) == 0 {
+		return 0, nil
+	}
+	condition, args := matchIDCondition("s.id", sessionIDs)
+	// #nosec G202 -- condition contains only internally generated placeholders; values are bound below.
+	result, err := r.db.Exec(`
+		UPDATE noon_game_sessions s
+		JOIN event_days ed ON ed.id = ? AND ed.event_id = s.event_id
+		SET s.event_day_id = ed.id
+		WHERE `+condition, append([]interface{}{dayID}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// IsNoonSessionRainy は昼競技のセッションが雨天時モードの日に割り当てられているかを返す
+func (r *eventDayRepository) IsNoonSessionRainy(sessionID int) (bool, error) {
+	var rainy bool
+	err := r.db.QueryRow(`
+		SELECT COALESCE(ed.is_rainy_mode, FALSE)
+		FROM noon_game_sessions s
+		LEFT JOIN event_days ed ON ed.id = s.event_day_id
+		WHERE s.id = ?
+	`, sessionID).Scan(&rainy)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return rainy, err
+}
+
+// CountCheckedInUsers は開催日に出席チェックインした生徒の人数を返す
+func (r *eventDayRepository) CountCheckedInUsers(dayID int) (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(DISTINCT user_id) FROM check_ins WHERE event_day_id = ?", dayID).Scan(&count)
+	return count, err
+}
+
+// GetSportNamesByDay は開催日に試合がある競技名を返す
+func (r *eventDayRepository) GetSportNamesByDay(dayID int) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT s.name
+		FROM matches m
+		JOIN tournaments t ON t.id = m.tournament_id
+		JOIN sports s ON s.id = t.sport_id
+		WHERE m.event_day_id = ?
+		ORDER BY s.name
+	`, dayID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sportNames := make([]string, 0)
+	for rows.Next() {
+		var sportName string
+		if err := rows.Scan(&sportName); err != nil {
+			return nil, err
+		}
+		sportNames = append(sportNames, sportName)
+	}
+	return sportNames, rows.Err()
+}
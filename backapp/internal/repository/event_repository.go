@@ -13,6 +13,8 @@ type EventRepository interface {
 	SetActiveEvent(event_id *int) error
 	GetEventByYearAndSeason(year int, season string) (*models.Event, error)
 	CopyClassScores(fromEventID int, toEventID int) error
+	GetCloneSource(eventID int) (*models.EventCloneSource, error)
+	CloneEvent(plan *models.EventClonePlan) (int64, error)
 	GetEventByID(id int) (*models.Event, error)
 	SetRainyMode(eventID int, isRainyMode bool) error
 	SetMICVotingEnabled(eventID int, isEnabled bool) error
@@ -54,15 +56,28 @@ func (r *eventRepository) CreateEvent(event *models.Event) (int64, error) {
 		return 0, err
 	}
 
+	id, err := insertEvent(tx, event)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// insertEvent は大会を追加し、稼働中・準備中の大会であれば操作対象の大会に設定する
+func insertEvent(tx *sql.Tx, event *models.Event) (int64, error) {
 	query := "INSERT INTO events (name, `year`, season, start_date, end_date, is_rainy_mode, competition_guidelines_pdf_url, survey_url, is_survey_published, status, hide_scores, duplicate_registration_threshold) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 	result, err := tx.Exec(query, event.Name, event.Year, event.Season, event.Start_date, event.End_date, event.IsRainyMode, event.CompetitionGuidelinesPdfUrl, event.SurveyUrl, event.IsSurveyPublished, event.Status, event.HideScores, event.DuplicateRegistrationThreshold)
 	if err != nil {
-		tx.Rollback()
 		return 0, err
 	}
 	id, err := result.LastInsertId()
 	if err != nil {
-		tx.Rollback()
 		return 0, err
 	}
 
@@ -73,24 +88,15 @@ func (r *eventRepository) CreateEvent(event *models.Event) (int64, error) {
 			// 操作対象は一意にするため、既存の準備中大会もアーカイブする。
 			archiveQuery = "UPDATE events SET status = 'archived' WHERE id != ? AND status IN ('active', 'preparing')"
 		}
-		_, err = tx.Exec(archiveQuery, id)
-		if err != nil {
-			tx.Rollback()
+		if _, err := tx.Exec(archiveQuery, id); err != nil {
 			return 0, err
 		}
 
 		activeQuery := "INSERT INTO active_event (id, event_id) VALUES (1, ?) ON DUPLICATE KEY UPDATE event_id = VALUES(event_id)"
-		_, err = tx.Exec(activeQuery, id)
-		if err != nil {
-			tx.Rollback()
+		if _, err := tx.Exec(activeQuery, id); err != nil {
 			return 0, err
 		}
 	}
-
-	err = tx.Commit()
-	if err != nil {
-		return 0, err
-	}
 	return id, nil
 }
 
@@ -243,20 +249,28 @@ func (r *eventRepository) GetEventByYearAndSeason(year int, season string) (*mod
 }
 
 func (r *eventRepository) CopyClassScores(fromEventID int, toEventID int) error {
-	deleteQuery := "DELETE FROM score_logs WHERE event_id = ? AND reason = 'initial_points'"
-	_, err := r.db.Exec(deleteQuery, toEventID)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	audit := scoreAudit{operation: models.ScoreOperationInitial}
+	if err := reverseScoreLogs(tx, audit, "l.event_id = ? AND l.reason = 'initial_points'", toEventID); err != nil {
+		return err
+	}
 
 	insertQuery := `
-		INSERT INTO score_logs (event_id, class_id, points, reason)
-		SELECT ?, class_id, total_points_current_event, 'initial_points'
+		INSERT INTO score_logs (event_id, class_id, points, reason, operation)
+		SELECT ?, class_id, total_points_current_event, 'initial_points', ?
 		FROM class_scores
 		WHERE event_id = ? AND total_points_current_event > 0
 	`
-	_, err = r.db.Exec(insertQuery, toEventID, fromEventID)
-	return err
+	if _, err := tx.Exec(insertQuery, toEventID, models.ScoreOperationInitial, fromEventID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (r *eventRepository) SetRainyMode(eventID int, isRainyMode bool) error {
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"backapp/internal/models"
+)
+
+type IdentityRepository interface {
+	FindUserIDByStudentNumber(studentNumber string) (string, error)
+	FindUserIDByPassToken(token string) (string, error)
+	ImportStudentNumbers(entries []models.StudentNumberEntry) (*models.StudentNumberImportResult, error)
+	SearchCandidates(query string, limit int) ([]*models.IdentityCandidate, error)
+	IssueIDPass(userID string, token string, issuedBy string) (*models.IDPass, error)
+	GetActiveIDPass(userID string) (*models.IDPass, error)
+	RevokeIDPasses(userID string) (int64, error)
+}
+
+type identityRepository struct {
+	db *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+// FindUserIDByStudentNumber は登録された学籍番号のユーザーIDを返す。登録がなければ空文字を返す
+func (r *identityRepository) FindUserIDByStudentNumber(studentNumber string) (string, error) {
+	var userID string
+	err := r.db.QueryRow("SELECT id FROM users WHERE student_number = ?", studentNumber).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return userID, err
+}
+
+// FindUserIDByPassToken は無効にされていないパスのユーザーIDを返す。該当がなければ空文字を返す
+func (r *identityRepository) FindUserIDByPassToken(token string) (string, error) {
+	var userID string
+	err := r.db.QueryRow("SELECT user_id FROM id_passes WHERE token = ? AND revoked_at IS NULL", token).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return userID, err
+}
+
+// ImportStudentNumbers はメールアドレスでユーザーを引いて学籍番号を登録する。
+// 見つからない・他の人と重複する行はエラーとして返し、残りの行は登録する
+func (r *identityRepository) ImportStudentNumbers(entries []models.StudentNumberEntry) (*models.StudentNumberImportResult, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &models.StudentNumberImportResult{Errors: make([]models.StudentNumberImportError, 0)}
+	for _, entry := range entries {
+		importError := models.StudentNumberImportError{Line: entry.Line, Email: entry.Email, StudentNumber: entry.StudentNumber}
+
+		var userID string
+		var current sql.NullString
+		err := tx.QueryRow("SELECT id, student_number FROM users WHERE email = ? FOR UPDATE", entry.Email).Scan(&userID, &current)
+		if err == sql.ErrNoRows {
+			importError.Reason = "user not found"
+			result.Errors = append(result.Errors, importError)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if current.Valid && current.String == entry.StudentNumber {
+			result.Unchanged++
+			continue
+		}
+
+		if _, err := tx.Exec("UPDATE users SET student_number = ? WHERE id = ?", entry.StudentNumber, userID); err != nil {
+			if isMySQLDuplicateEntryError(err) {
+				importError.Reason = "student number already registered to another user"
+				result.Errors = append(result.Errors, importError)
+				continue
+			}
+			return nil, err
+		}
+		result.Updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SearchCandidates は学籍番号の前方一致、メールアドレス・表示名の部分一致でユーザーを探す
+func (r *identityRepository) SearchCandidates(query string, limit int) ([]*models.IdentityCandidate, error) {
+	rows, err := r.db.Query(`
+		SELECT u.id, u.email, u.display_name, u.student_number, u.class_id, c.name,
+			EXISTS (SELECT 1 FROM id_passes p WHERE p.user_id = u.id AND p.revoked_at IS NULL)
+		FROM users u
+		LEFT JOIN classes c ON c.id = u.class_id
+		WHERE u.student_number LIKE ? OR u.email LIKE ? OR u.display_name LIKE ?
+		ORDER BY u.email
+		LIMIT ?
+	`, query+"%", "%"+query+"%", "%"+query+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := make([]*models.IdentityCandidate, 0)
+	for rows.Next() {
+		candidate := &models.IdentityCandidate{}
+		var displayName, studentNumber, className sql.NullString
+		var classID sql.NullInt64
+		if err := rows.Scan(&candidate.UserID, &candidate.Email, &displayName, &studentNumber, &classID, &className, &candidate.HasIDPass); err != nil {
+			return nil, err
+		}
+		if displayName.Valid {
+			candidate.DisplayName = &displayName.String
+		}
+		if studentNumber.Valid {
+			candidate.StudentNumber = &studentNumber.String
+		}
+		if classID.Valid {
+			id := int(classID.Int64)
+			candidate.ClassID = &id
+		}
+		if className.Valid {
+			candidate.ClassName = &className.String
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
+
+// IssueIDPass はユーザーの有効なパスを無効にしてから新しいパスを発行する
+func (r *identityRepository) IssueIDPass(userID string, token string, issuedBy string) (*models.IDPass, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE id_passes SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = ? AND revoked_at IS NULL", userID); err != nil {
+		return nil, err
+	}
+	res, err := tx.Exec("INSERT INTO id_passes (user_id, token, issued_by) VALUES (?, ?, ?)", userID, token, issuedBy)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &models.IDPass{
+		ID:       int(id),
+		UserID:   userID,
+		Token:    token,
+		IssuedBy: &issuedBy,
+		IssuedAt: time.Now(),
+	}, nil
+}
+
+// GetActiveIDPass はユーザーの有効なパスを返す。なければ nil を返す
+func (r *identityRepository) GetActiveIDPass(userID string) (*models.IDPass, error) {
+	pass := &models.IDPass{}
+	var issuedBy sql.NullString
+	err := r.db.QueryRow(`
+		SELECT id, user_id, token, issued_by, issued_at
+		FROM id_passes
+		WHERE user_id = ? AND revoked_at IS NULL
+		ORDER BY id DESC
+		LIMIT 1
+	`, userID).Scan(&pass.ID, &pass.UserID, &pass.Token, &issuedBy, &pass.IssuedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if issuedBy.Valid {
+		pass.IssuedBy = &issuedBy.String
+	}
+	return pass, nil
+}
+
+// RevokeIDPasses はユーザーの有効なパスをすべて無効にし、無効にした件数を返す
+func (r *identityRepository) RevokeIDPasses(userID string) (int64, error) {
+	res, err := r.db.Exec("UPDATE id_passes SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = ? AND revoked_at IS NULL", userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
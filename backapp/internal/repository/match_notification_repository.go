@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"backapp/internal/models"
+)
+
+type MatchNotificationRepository interface {
+	GetUpcomingMatchStarts(eventID int, rainyMode bool) ([]*models.MatchNotification, error)
+	GetMatchNotification(matchID int) (*models.MatchNotification, error)
+	ClaimMatchStartNotification(matchID int, startTime time.Time) (bool, error)
+	GetUserIDsByNotificationType(classIDs []int, notificationType string) ([]string, error)
+}
+
+type matchNotificationRepository struct {
+	db *sql.DB
+}
+
+func NewMatchNotificationRepository(db *sql.DB) MatchNotificationRepository {
+	return &matchNotificationRepository{db: db}
+}
+
+// matchNotificationQuery は通知に使う試合情報を取り出す。決勝は3位決定戦・敗者戦・リーグ戦を除いた最終回戦の試合。
+const matchNotificationQuery = `
+	SELECT
+		t.event_id,
+		m.id,
+		s.name,
+		es.location,
+		COALESCE(m.court_number, ''),
+		COALESCE(t1.name, ''),
+		COALESCE(t2.name, ''),
+		t1.class_id,
+		t2.class_id,
+		m.team1_score,
+		m.team2_score,
+		CASE
+			WHEN m.forfeiting_team_id = m.team1_id THEN t2.name
+			WHEN m.forfeiting_team_id = m.team2_id THEN t1.name
+			WHEN m.result_type = 'walkover' AND m.forfeiting_team_id IS NULL THEN COALESCE(t1.name, t2.name)
+			WHEN m.team1_score > m.team2_score THEN t1.name
+			WHEN m.team2_score > m.team1_score THEN t2.name
+			ELSE NULL
+		END AS winner_name,
+		COALESCE(m.status, ''),
+		m.result_type,
+		m.is_bronze_match = FALSE AND m.is_loser_bracket_match = FALSE AND m.is_league_match = FALSE AND m.round = (
+			SELECT MAX(f.round) FROM matches f
+			WHERE f.tournament_id = m.tournament_id AND f.is_bronze_match = FALSE AND f.is_loser_bracket_match = FALSE AND f.is_league_match = FALSE
+		) AS is_final,
+		m.match_start_time,
+		m.rainy_mode_start_time,
+		COALESCE(ed.is_rainy_mode, FALSE)
+	FROM matches m
+	JOIN tournaments t ON m.tournament_id = t.id
+	JOIN sports s ON t.sport_id = s.id
+	JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id
+	LEFT JOIN teams t1 ON m.team1_id = t1.id
+	LEFT JOIN teams t2 ON m.team2_id = t2.id
+	LEFT JOIN event_days ed ON ed.id = m.event_day_id
+`
+
+// scanMatchNotification は matchNotificationQuery の1行を読む。大会全体か試合の開催日が雨天時なら、
+// 雨天用の開始時刻が設定されていればそれを StartTime とする。
+func scanMatchNotification(row rowScanner, rainyMode bool) (*models.MatchNotification, error) {
+	var m models.MatchNotification
+	var class1, class2, team1Score, team2Score sql.NullInt64
+	var winnerName, rainyModeStartTime sql.NullString
+	var startTime sql.NullTime
+	var rainyDay bool
+	if err := row.Scan(&m.EventID, &m.MatchID, &m.SportName, &m.Location, &m.Court, &m.Team1Name, &m.Team2Name,
+		&class1, &class2, &team1Score, &team2Score, &winnerName, &m.Status, &m.ResultType, &m.IsFinal,
+		&startTime, &rainyModeStartTime, &rainyDay); err != nil {
+		return nil, err
+	}
+
+	m.ClassIDs = make([]int, 0, 2)
+	for _, classID := range []sql.NullInt64{class1, class2} {
+		if classID.Valid && !containsClassID(m.ClassIDs, int(classID.Int64)) {
+			m.ClassIDs = append(m.ClassIDs, int(classID.Int64))
+		}
+	}
+	m.Team1Score = nullIntPtr(team1Score)
+	m.Team2Score = nullIntPtr(team2Score)
+	m.WinnerName = winnerName.String
+
+	if startTime.Valid {
+		m.StartTime = &startTime.Time
+	}
+	// 雨天時は会場が変わるため、雨天用の開始時刻がある試合はコートを伝えない
+	if (rainyMode || rainyDay) && rainyModeStartTime.Valid && rainyModeStartTime.String != "" {
+		if t, err := models.ParseScheduleTime(rainyModeStartTime.String); err == nil {
+			m.StartTime = &t
+			m.Court = ""
+		}
+	}
+	return &m, nil
+}
+
+func containsClassID(classIDs []int, classID int) bool {
+	for _, id := range classIDs {
+		if id == classID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetUpcomingMatchStarts は開始時刻が決まっていて、まだ終わっていない試合を返す
+func (r *matchNotificationRepository) GetUpcomingMatchStarts(eventID int, rainyMode bool) ([]*models.MatchNotification, error) {
+	rows, err := r.db.Query(matchNotificationQuery+`
+		WHERE t.event_id = ?
+			AND COALESCE(m.status, '') NOT IN ('finished', 'bye', 'cancelled')
+			AND (m.team1_id IS NOT NULL OR m.team2_id IS NOT NULL)
+		ORDER BY m.id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := make([]*models.MatchNotification, 0)
+	for rows.Next() {
+		m, err := scanMatchNotification(rows, rainyMode)
+		if err != nil {
+			return nil, err
+		}
+		if m.StartTime != nil {
+			matches = append(matches, m)
+		}
+	}
+	return matches, rows.Err()
+}
+
+// GetMatchNotification は1試合の通知用の情報を返す。試合が存在しなければ nil。
+func (r *matchNotificationRepository) GetMatchNotification(matchID int) (*models.MatchNotification, error) {
+	m, err := scanMatchNotification(r.db.QueryRow(matchNotificationQuery+" WHERE m.id = ?", matchID), false)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return m, err
+}
+
+// ClaimMatchStartNotification は試合開始の通知を送る権利を得る。
+// 同じ開始時刻で既に送っていれば false。開始時刻が変わった試合は改めて送る。
+func (r *matchNotificationRepository) ClaimMatchStartNotification(matchID int, startTime time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		INSERT INTO match_start_notifications (match_id, start_time)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE start_time = VALUES(start_time), notified_at = CURRENT_TIMESTAMP
+	`, matchID, startTime.Format(models.ScheduleTimeLayout))
+	if err != nil {
+		return false, err
+	}
+	// 追加なら1、更新なら2、同じ値で変わらなければ0
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// GetUserIDsByNotificationType は通知の種類（または all_matches）を受け取る設定にしているユーザーを返す。
+// classIDs を指定した場合はそのクラスのユーザーに絞る。
+func (r *matchNotificationRepository) GetUserIDsByNotificationType(classIDs []int, notificationType string) ([]string, error) {
+	query := `
+		SELECT id FROM users
+		WHERE (JSON_CONTAINS(notification_filters, JSON_QUOTE(?)) OR JSON_CONTAINS(notification_filters, JSON_QUOTE(?)))
+	`
+	args := []interface{}{notificationType, models.NotificationTypeAllMatches}
+	if len(classIDs) > 0 {
+		placeholders := make([]string, len(classIDs))
+		for i, classID := range classIDs {
+			placeholders[i] = "?"
+			args = append(args, classID)
+		}
+		query += " AND class_id IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	query += " ORDER BY id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := make([]string, 0)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
@@ -0,0 +1,348 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+
+	"backapp/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrMatchRevisionNotFound は指定した試合に指定したリビジョンがない場合に返される
+var ErrMatchRevisionNotFound = errors.New("match revision not found")
+
+// matchRevisionSnapshot は match_revisions に残す試合の列。matches を m として参照する。
+const matchRevisionSnapshot = "m.team1_id, m.team2_id, m.team1_score, m.team2_score, m.result_type, m.forfeiting_team_id, m.status, m.match_start_time, m.rainy_mode_start_time, m.court_number"
+
+// sportMatchCondition はイベントの競技の試合（敗者戦・決勝トーナメントを含む）に絞り込む条件
+const sportMatchCondition = "m.tournament_id IN (SELECT id FROM tournaments WHERE event_id = ? AND sport_id = ?)"
+
+// matchChange は1回の操作で変更した試合をまとめ、同じ change_id のリビジョンとして記録する
+type matchChange struct {
+	id          string
+	operation   string
+	actorUserID string
+	matchIDs    map[int]bool
+}
+
+func newMatchChange(operation, actorUserID string) *matchChange {
+	return &matchChange{
+		id:          uuid.New().String(),
+		operation:   operation,
+		actorUserID: actorUserID,
+		matchIDs:    make(map[int]bool),
+	}
+}
+
+// touch は操作で変更した試合を覚えておく。記録は record でまとめて行う。
+func (c *matchChange) touch(matchIDs ...int) {
+	for _, id := range matchIDs {
+		c.matchIDs[id] = true
+	}
+}
+
+func (c *matchChange) actor() interface{} {
+	if c.actorUserID == "" {
+		return nil
+	}
+	return c.actorUserID
+}
+
+// record は変更した試合の変更後の状態をリビジョンとして記録する。変更した試合がなければ何もしない。
+func (c *matchChange) record(ex execer) error {
+	if len(c.matchIDs) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(c.matchIDs))
+	for id := range c.matchIDs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	condition, args := matchIDCondition("m.id", ids)
+	return recordMatchRevisions(ex, c, condition, args...)
+}
+
+// recordMatchRevisions は条件に一致する試合の現在の状態をリビジョンとして記録する。
+// condition は matches を m として参照する固定の条件式で、値はプレースホルダで渡す。
+func recordMatchRevisions(ex execer, c *matchChange, condition string, args ...interface{}) error {
+	// #nosec G202 -- condition is always a fixed expression written in this package.
+	query := `
+		INSERT INTO match_revisions (match_id, change_id, operation, actor_user_id, team1_id, team2_id, team1_score, team2_score, result_type, forfeiting_team_id, status, match_start_time, rainy_mode_start_time, court_number)
+		SELECT m.id, ?, ?, ?, ` + matchRevisionSnapshot + `
+		FROM matches m
+		WHERE ` + condition
+	_, err := ex.Exec(query, append([]interface{}{c.id, c.operation, c.actor()}, args...)...)
+	return err
+}
+
+// ensureMatchBaselines は条件に一致する試合のうちまだリビジョンがない試合について、
+// 変更前の状態を initial のリビジョンとして残す。試合を変更する前に呼ぶ。
+func ensureMatchBaselines(ex execer, condition string, args ...interface{}) error {
+	// #nosec G202 -- condition is always a fixed expression written in this package.
+	query := `
+		INSERT INTO match_revisions (match_id, change_id, operation, actor_user_id, team1_id, team2_id, team1_score, team2_score, result_type, forfeiting_team_id, status, match_start_time, rainy_mode_start_time, court_number)
+		SELECT m.id, UUID(), 'initial', NULL, ` + matchRevisionSnapshot + `
+		FROM matches m
+		WHERE ` + condition + ` AND NOT EXISTS (SELECT 1 FROM match_revisions mr WHERE mr.match_id = m.id)`
+	_, err := ex.Exec(query, args...)
+	return err
+}
+
+// matchIDCondition は column が ids のいずれかに一致する条件とその値を返す
+func matchIDCondition(column string, ids []int) (string, []interface{}) {
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	return column + " IN (" + strings.Join(placeholders, ", ") + ")", args
+}
+
+// affectedMatchIDs は matchID と、後の変更で matchID と一緒に書き換わった試合をたどって集める。
+// changes は変更ごとに書き換えた試合の一覧。
+func affectedMatchIDs(matchID int, changes map[string][]int) []int {
+	affected := map[int]bool{matchID: true}
+	for grown := true; grown; {
+		grown = false
+		for _, ids := range changes {
+			touches := false
+			for _, id := range ids {
+				if affected[id] {
+					touches = true
+					break
+				}
+			}
+			if !touches {
+				continue
+			}
+			for _, id := range ids {
+				if !affected[id] {
+					affected[id] = true
+					grown = true
+				}
+			}
+		}
+	}
+
+	result := make([]int, 0, len(affected))
+	for id := range affected {
+		result = append(result, id)
+	}
+	sort.Ints(result)
+	return result
+}
+
+// GetMatchRevisions は試合の変更履歴を古い順に返す
+func (r *tournamentRepository) GetMatchRevisions(matchID int) ([]*models.MatchRevision, error) {
+	rows, err := r.db.Query(`
+		SELECT mr.id, mr.match_id, mr.change_id, mr.operation, mr.team1_id, mr.team2_id, mr.team1_score, mr.team2_score,
+		       mr.result_type, mr.forfeiting_team_id, mr.status, mr.match_start_time, mr.rainy_mode_start_time, mr.court_number,
+		       mr.actor_user_id, u.display_name, mr.created_at
+		FROM match_revisions mr
+		LEFT JOIN users u ON u.id = mr.actor_user_id
+		WHERE mr.match_id = ?
+		ORDER BY mr.id
+	`, matchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*models.MatchRevision{}
+	for rows.Next() {
+		var rev models.MatchRevision
+		var team1ID, team2ID, team1Score, team2Score, forfeitingTeamID sql.NullInt64
+		var status, startTime, rainyStartTime, courtNumber, actorUserID, actorDisplayName sql.NullString
+		if err := rows.Scan(&rev.ID, &rev.MatchID, &rev.ChangeID, &rev.Operation, &team1ID, &team2ID, &team1Score, &team2Score,
+			&rev.ResultType, &forfeitingTeamID, &status, &startTime, &rainyStartTime, &courtNumber,
+			&actorUserID, &actorDisplayName, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		rev.Team1ID = nullIntPtr(team1ID)
+		rev.Team2ID = nullIntPtr(team2ID)
+		rev.Team1Score = nullIntPtr(team1Score)
+		rev.Team2Score = nullIntPtr(team2Score)
+		rev.ForfeitingTeamID = nullIntPtr(forfeitingTeamID)
+		rev.Status = status.String
+		rev.MatchStartTime = nullStringPtr(startTime)
+		rev.RainyModeStartTime = nullStringPtr(rainyStartTime)
+		rev.CourtNumber = nullStringPtr(courtNumber)
+		rev.ActorUserID = nullStringPtr(actorUserID)
+		rev.ActorDisplayName = nullStringPtr(actorDisplayName)
+		revisions = append(revisions, &rev)
+	}
+	return revisions, rows.Err()
+}
+
+func nullStringPtr(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
+
+// RestoreMatchRevision は試合をリビジョンの時点の結果に戻す。
+// その後の変更で一緒に書き換わった勝ち上がり先・3位決定戦・敗者戦の試合も同じ時点に戻し、
+// 戻した試合の得点は打ち消してから現在の配点ルールで付け直す。開始時刻とコートは戻さない。
+func (r *tournamentRepository) RestoreMatchRevision(matchID int, revisionID int, actorUserID string) (*models.MatchRestoreResult, error) {
+	audit := scoreAudit{operation: models.ScoreOperationMatchRestore, actorUserID: actorUserID}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var changeID string
+	err = tx.QueryRow("SELECT change_id FROM match_revisions WHERE id = ? AND match_id = ?", revisionID, matchID).Scan(&changeID)
+	if err == sql.ErrNoRows {
+		return nil, ErrMatchRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := r.getMatchByID(tx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	eventID, sportID, location, err := r.getTournamentMetadata(tx, match.TournamentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkRainyModeResultEntry(tx, match.ID, location); err != nil {
+		return nil, err
+	}
+
+	// 同じ操作で記録したリビジョンまでを含めた時点に戻す
+	var cutoff int
+	if err := tx.QueryRow("SELECT MAX(id) FROM match_revisions WHERE change_id = ?", changeID).Scan(&cutoff); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(`
+		SELECT mr.match_id, mr.change_id
+		FROM match_revisions mr
+		JOIN matches m ON m.id = mr.match_id
+		WHERE `+sportMatchCondition+` AND mr.id > ? AND mr.operation NOT IN ('initial', 'start_time', 'schedule')
+		ORDER BY mr.id
+	`, eventID, sportID, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	changes := make(map[string][]int)
+	for rows.Next() {
+		var id int
+		var laterChangeID string
+		if err := rows.Scan(&id, &laterChangeID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		changes[laterChangeID] = append(changes[laterChangeID], id)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	// 開始時刻・日程の変更は結果に関わらないため、たどる対象に含めない
+	affected := affectedMatchIDs(matchID, changes)
+	for _, id := range affected {
+		if err := restoreMatchState(tx, id, cutoff); err != nil {
+			return nil, err
+		}
+	}
+
+	// 同点で勝者を選んだ試合は既存の得点から勝者を推定するため、打ち消す前に確定させる
+	restored := make([]*models.MatchDB, 0, len(affected))
+	winners := make(map[int]int64)
+	for _, id := range affected {
+		m, err := r.getMatchByID(tx, id)
+		if err != nil {
+			return nil, err
+		}
+		restored = append(restored, m)
+		if m.Status != "finished" {
+			continue
+		}
+		if m.IsLeagueMatch {
+			// リーグ戦はスコアから勝ち・引き分けの得点を付け直す
+			if err := tx.QueryRow("SELECT team1_score, team2_score FROM matches WHERE id = ?", m.ID).Scan(&m.Team1Score, &m.Team2Score); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		winnerID, err := r.inferStoredWinnerID(tx, m)
+		if err != nil {
+			return nil, err
+		}
+		winners[m.ID] = winnerID
+	}
+
+	condition, args := matchIDCondition("l.source_match_id", affected)
+	if err := reverseScoreLogs(tx, audit, condition, args...); err != nil {
+		return nil, err
+	}
+
+	rules, err := loadScoringRules(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	maxRounds := make(map[int]int)
+	for _, m := range restored {
+		totalRounds, ok := maxRounds[m.TournamentID]
+		if !ok {
+			var maxRound sql.NullInt64
+			if err := tx.QueryRow("SELECT MAX(round) FROM matches WHERE tournament_id = ?", m.TournamentID).Scan(&maxRound); err != nil {
+				return nil, err
+			}
+			totalRounds = int(maxRound.Int64)
+			maxRounds[m.TournamentID] = totalRounds
+		}
+		if _, err := r.replayMatchScoring(tx, m, winners[m.ID], eventID, location, totalRounds, rules, audit); err != nil {
+			return nil, err
+		}
+	}
+
+	change := newMatchChange(models.MatchRevisionMatchRestore, actorUserID)
+	change.touch(affected...)
+	if err := change.record(tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &models.MatchRestoreResult{MatchID: matchID, RevisionID: revisionID, RestoredMatchIDs: affected}, nil
+}
+
+// restoreMatchState は試合の結果を cutoff 時点のリビジョンに戻す。
+// cutoff より後に初めて変更された試合は、最初に残した変更前の状態に戻す。
+// 予定済み・未定の区別は開始時刻に合わせて残すため、どちらかが終了済みの場合だけ状態を戻す。
+func restoreMatchState(tx *sql.Tx, matchID int, cutoff int) error {
+	var team1ID, team2ID, team1Score, team2Score, forfeitingTeamID sql.NullInt64
+	var resultType string
+	var status sql.NullString
+
+	const selectRevision = "SELECT team1_id, team2_id, team1_score, team2_score, result_type, forfeiting_team_id, status FROM match_revisions WHERE match_id = ?"
+	err := tx.QueryRow(selectRevision+" AND id <= ? ORDER BY id DESC LIMIT 1", matchID, cutoff).
+		Scan(&team1ID, &team2ID, &team1Score, &team2Score, &resultType, &forfeitingTeamID, &status)
+	if err == sql.ErrNoRows {
+		err = tx.QueryRow(selectRevision+" ORDER BY id LIMIT 1", matchID).
+			Scan(&team1ID, &team2ID, &team1Score, &team2Score, &resultType, &forfeitingTeamID, &status)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE matches SET team1_id = ?, team2_id = ?, team1_score = ?, team2_score = ?, result_type = ?, forfeiting_team_id = ?, status = CASE WHEN status = 'finished' OR ? = 'finished' THEN ? ELSE status END WHERE id = ?",
+		team1ID, team2ID, team1Score, team2Score, resultType, forfeitingTeamID, status, status, matchID,
+	)
+	return err
+}
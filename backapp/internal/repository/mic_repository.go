@@ -72,16 +72,21 @@ func (r *micRepository) VoteMIC(userID string, votedForClassID int, eventID int,
 		return errors.New("user has already voted")
 	}
 
+	rules, err := loadScoringRules(tx, eventID)
+	if err != nil {
+		return err
+	}
+
 	// Insert the vote
-	micPoints := 3
+	micPoints := rules.MICPoints
 	_, err = tx.Exec("INSERT INTO mic_votes (voter_user_id, voted_for_class_id, event_id, reason, points) VALUES (?, ?, ?, ?, ?)", userID, votedForClassID, eventID, reason, micPoints)
 	if err != nil {
 		return err
 	}
 
 	// Insert into score_logs
-	_, err = tx.Exec("INSERT INTO score_logs (event_id, class_id, points, reason) VALUES (?, ?, ?, ?)", eventID, votedForClassID, micPoints, "mic_points")
-	if err != nil {
+	audit := scoreAudit{operation: models.ScoreOperationMIC, actorUserID: userID}
+	if err := insertScoreLog(tx, eventID, votedForClassID, micPoints, "mic_points", nil, audit); err != nil {
 		return err
 	}
 
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 )
 
 var (
@@ -14,10 +15,16 @@ var (
 )
 
 type NotificationRepository interface {
-	CreateNotification(title, body, notificationType, createdBy string, eventID *int) (int64, error)
-	AddNotificationTargets(notificationID int64, roles []string) error
+	PublishNotification(notification models.NewNotification, message *models.PushMessage) (int64, error)
 	GetNotificationsForAccess(roleNames []string, authorID string, includeAuthored bool, limit int) ([]models.Notification, error)
+	GetNotificationByID(id int) (*models.Notification, error)
+	MarkNotificationRead(notificationID int, roleNames []string, userID string) (bool, error)
+	MarkAllNotificationsRead(roleNames []string, userID string) (int64, error)
+	CountUnreadNotifications(roleNames []string, userID string) (int, error)
+	GetNotificationReadStats(notificationID int, userIDs []string) (models.NotificationReadStats, error)
 	GetUserIDsByRoles(roleNames []string) ([]string, error)
+	GetUserIDsByTargets(eventID *int, targets []models.NotificationTarget) ([]string, error)
+	GetUserEmailsByIDs(userIDs []string) (map[string]string, error)
 	GetPushSubscriptionsByUserIDs(userIDs []string) ([]models.PushSubscription, error)
 	GetPushSubscriptionsByUserID(userID string) ([]models.PushSubscription, error)
 	GetPushSubscriptionStatsByRoles(roleNames []string) (models.PushSubscriptionStats, error)
@@ -33,56 +40,63 @@ func NewNotificationRepository(db *sql.DB) NotificationRepository {
 	return &notificationRepository{db: db}
 }
 
-func (r *notificationRepository) CreateNotification(title, body, notificationType, createdBy string, eventID *int) (int64, error) {
-	query := "INSERT INTO notifications (title, body, type, created_by, event_id) VALUES (?, ?, ?, ?, ?)"
-	var result sql.Result
-	var err error
-
-	var createdByParam interface{}
-	if createdBy != "" {
-		createdByParam = createdBy
+// PublishNotification は通知と対象ロール・宛先を登録する。
+// message があれば Push 通知の配信も同じトランザクションで送信キューに登録する。
+func (r *notificationRepository) PublishNotification(notification models.NewNotification, message *models.PushMessage) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	if eventID != nil {
-		result, err = r.db.Exec(query, title, body, notificationType, createdByParam, *eventID)
-	} else {
-		result, err = r.db.Exec(query, title, body, notificationType, createdByParam, nil)
+	var createdByParam interface{}
+	if notification.CreatedBy != "" {
+		createdByParam = notification.CreatedBy
 	}
-
+	result, err := tx.Exec(
+		"INSERT INTO notifications (title, body, type, created_by, event_id) VALUES (?, ?, ?, ?, ?)",
+		notification.Title, notification.Body, notification.Type, createdByParam, nullableInt(notification.EventID),
+	)
 	if err != nil {
 		return 0, err
 	}
-
-	return result.LastInsertId()
-}
-
-func (r *notificationRepository) AddNotificationTargets(notificationID int64, roles []string) error {
-	if len(roles) == 0 {
-		return nil
-	}
-
-	tx, err := r.db.Begin()
+	notificationID, err := result.LastInsertId()
 	if err != nil {
-		return err
+		return 0, err
 	}
-	defer tx.Rollback()
 
-	stmt, err := tx.Prepare("INSERT IGNORE INTO notification_targets (notification_id, role_name) VALUES (?, ?)")
-	if err != nil {
-		return err
+	for _, role := range notification.TargetRoles {
+		if _, err := tx.Exec("INSERT IGNORE INTO notification_targets (notification_id, role_name) VALUES (?, ?)", notificationID, role); err != nil {
+			return 0, err
+		}
+	}
+	for _, target := range notification.Targets {
+		if _, err := tx.Exec(
+			"INSERT INTO notification_audiences (notification_id, target_type, target_id, user_id) VALUES (?, ?, ?, ?)",
+			notificationID, target.Type, nullableInt(target.ID), nullableString(target.UserID),
+		); err != nil {
+			return 0, err
+		}
 	}
-	defer stmt.Close()
 
-	for _, role := range roles {
-		if _, err := stmt.Exec(notificationID, role); err != nil {
-			return err
+	if message != nil && len(message.Subscriptions) > 0 {
+		id := int(notificationID)
+		payload, err := message.Payload(id)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := insertPushMessage(tx, &id, message.Source, payload, message.TTL, message.Subscriptions); err != nil {
+			return 0, err
 		}
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return notificationID, nil
 }
 
-func (r *notificationRepository) GetNotificationsForAccess(roleNames []string, authorID string, includeAuthored bool, limit int) ([]models.Notification, error) {
+func (r *notificationRepository) GetNotificationsForAccess(roleNames []string, userID string, includeAuthored bool, limit int) ([]models.Notification, error) {
 	var args []interface{}
 	var filters []string
 
@@ -94,9 +108,16 @@ func (r *notificationRepository) GetNotificationsForAccess(roleNames []string, a
 		}
 	}
 
-	if includeAuthored && authorID != "" {
+	if includeAuthored && userID != "" {
 		filters = append(filters, "n.created_by = ?")
-		args = append(args, authorID)
+		args = append(args, userID)
+	}
+
+	if userID != "" {
+		filters = append(filters, notificationAudienceFilter)
+		for i := 0; i < strings.Count(notificationAudienceFilter, "?"); i++ {
+			args = append(args, userID)
+		}
 	}
 
 	query := `
@@ -170,10 +191,385 @@ func (r *notificationRepository) GetNotificationsForAccess(roleNames []string, a
 
 		notifications = append(notifications, notif)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	if err := r.attachNotificationAudiences(notifications); err != nil {
+		return nil, err
+	}
+	if err := r.attachNotificationReads(notifications, userID); err != nil {
+		return nil, err
+	}
 	return notifications, nil
 }
 
+// notificationAudienceFilter はユーザーがロール以外の宛先（クラス・チーム・競技・試合のチェックイン・個別指定）に含まれる通知に絞る。
+// プレースホルダーはすべてユーザー ID。
+const notificationAudienceFilter = `EXISTS (
+	SELECT 1 FROM notification_audiences na
+	WHERE na.notification_id = n.id AND (
+		(na.target_type = 'user' AND na.user_id = ?)
+		OR (na.target_type = 'class' AND na.target_id = (SELECT u.class_id FROM users u WHERE u.id = ?))
+		OR (na.target_type = 'team' AND EXISTS (
+			SELECT 1 FROM team_members tm WHERE tm.team_id = na.target_id AND tm.user_id = ?
+		))
+		OR (na.target_type = 'sport' AND EXISTS (
+			SELECT 1 FROM team_members tm JOIN teams t ON t.id = tm.team_id
+			WHERE t.sport_id = na.target_id AND (n.event_id IS NULL OR t.event_id = n.event_id) AND tm.user_id = ?
+		))
+		OR (na.target_type = 'match' AND EXISTS (
+			SELECT 1 FROM round_check_ins rc WHERE rc.match_id = na.target_id AND rc.user_id = ?
+		))
+	)
+)`
+
+// attachNotificationAudiences は通知にロール以外の宛先を付ける
+func (r *notificationRepository) attachNotificationAudiences(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	placeholders := strings.Repeat(",?", len(notifications)-1)
+	args := make([]interface{}, len(notifications))
+	index := make(map[int]int, len(notifications))
+	for i, notif := range notifications {
+		args[i] = notif.ID
+		index[notif.ID] = i
+	}
+
+	// #nosec G202 -- only the number of bound placeholders is constructed from notifications.
+	rows, err := r.db.Query(`
+		SELECT notification_id, target_type, target_id, user_id
+		FROM notification_audiences
+		WHERE notification_id IN (?`+placeholders+`)
+		ORDER BY id
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notificationID int
+		var target models.NotificationTarget
+		var targetID sql.NullInt64
+		var userID sql.NullString
+		if err := rows.Scan(&notificationID, &target.Type, &targetID, &userID); err != nil {
+			return err
+		}
+		target.ID = nullIntPtr(targetID)
+		target.UserID = nullStringPtr(userID)
+		i := index[notificationID]
+		notifications[i].Targets = append(notifications[i].Targets, target)
+	}
+	return rows.Err()
+}
+
+// attachNotificationReads は通知にユーザーの既読状態を付ける
+func (r *notificationRepository) attachNotificationReads(notifications []models.Notification, userID string) error {
+	if len(notifications) == 0 || userID == "" {
+		return nil
+	}
+
+	placeholders := strings.Repeat(",?", len(notifications)-1)
+	args := make([]interface{}, 0, len(notifications)+1)
+	args = append(args, userID)
+	index := make(map[int]int, len(notifications))
+	for i, notif := range notifications {
+		args = append(args, notif.ID)
+		index[notif.ID] = i
+	}
+
+	// #nosec G202 -- only the number of bound placeholders is constructed from notifications.
+	rows, err := r.db.Query(`
+		SELECT notification_id, read_at
+		FROM notification_reads
+		WHERE user_id = ? AND notification_id IN (?`+placeholders+`)
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notificationID int
+		var readAt time.Time
+		if err := rows.Scan(&notificationID, &readAt); err != nil {
+			return err
+		}
+		i := index[notificationID]
+		notifications[i].IsRead = true
+		notifications[i].ReadAt = &readAt
+	}
+	return rows.Err()
+}
+
+// notificationVisibleFilter はロールか宛先の指定でユーザーに届く通知に絞る条件を返す。
+// includeAuthored ならユーザーが作成した通知も含める。
+func notificationVisibleFilter(roleNames []string, userID string, includeAuthored bool) (string, []interface{}) {
+	var args []interface{}
+	var filters []string
+
+	if len(roleNames) > 0 {
+		placeholders := strings.Repeat(",?", len(roleNames)-1)
+		filters = append(filters, fmt.Sprintf("EXISTS (SELECT 1 FROM notification_targets nt WHERE nt.notification_id = n.id AND nt.role_name IN (?%s))", placeholders))
+		for _, role := range roleNames {
+			args = append(args, role)
+		}
+	}
+	if includeAuthored {
+		filters = append(filters, "n.created_by = ?")
+		args = append(args, userID)
+	}
+	filters = append(filters, notificationAudienceFilter)
+	for i := 0; i < strings.Count(notificationAudienceFilter, "?"); i++ {
+		args = append(args, userID)
+	}
+
+	return "(" + strings.Join(filters, " OR ") + ")", args
+}
+
+// GetNotificationByID は通知を対象ロール・宛先付きで返す。存在しなければ nil。
+func (r *notificationRepository) GetNotificationByID(id int) (*models.Notification, error) {
+	var notif models.Notification
+	var createdBy sql.NullString
+	var eventID sql.NullInt64
+	var targetRoles sql.NullString
+
+	err := r.db.QueryRow(`
+		SELECT
+			n.id,
+			n.title,
+			n.body,
+			n.type,
+			n.created_by,
+			n.event_id,
+			n.created_at,
+			GROUP_CONCAT(DISTINCT nt.role_name ORDER BY nt.role_name SEPARATOR ',') AS target_roles
+		FROM notifications n
+		LEFT JOIN notification_targets nt ON n.id = nt.notification_id
+		WHERE n.id = ?
+		GROUP BY n.id
+	`, id).Scan(&notif.ID, &notif.Title, &notif.Body, &notif.Type, &createdBy, &eventID, &notif.CreatedAt, &targetRoles)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	notif.CreatedBy = nullStringPtr(createdBy)
+	notif.EventID = nullIntPtr(eventID)
+	notif.TargetRoles = []string{}
+	if targetRoles.Valid && targetRoles.String != "" {
+		notif.TargetRoles = strings.Split(targetRoles.String, ",")
+	}
+
+	notifications := []models.Notification{notif}
+	if err := r.attachNotificationAudiences(notifications); err != nil {
+		return nil, err
+	}
+	return &notifications[0], nil
+}
+
+// MarkNotificationRead はユーザーに届いた通知を既読にする。届いていない通知なら false を返す。
+func (r *notificationRepository) MarkNotificationRead(notificationID int, roleNames []string, userID string) (bool, error) {
+	filter, filterArgs := notificationVisibleFilter(roleNames, userID, true)
+	args := append([]interface{}{userID, notificationID}, filterArgs...)
+
+	// #nosec G202 -- filter is built from fixed SQL fragments and all values are bound.
+	result, err := r.db.Exec(`
+		INSERT IGNORE INTO notification_reads (notification_id, user_id)
+		SELECT n.id, ? FROM notifications n
+		WHERE n.id = ? AND `+filter, args...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected > 0 {
+		return true, nil
+	}
+
+	// 既に既読だった場合も成功として扱う
+	var exists bool
+	err = r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM notification_reads WHERE notification_id = ? AND user_id = ?)
+	`, notificationID, userID).Scan(&exists)
+	return exists, err
+}
+
+// MarkAllNotificationsRead はユーザーに届いた未読の通知をすべて既読にし、既読にした件数を返す
+func (r *notificationRepository) MarkAllNotificationsRead(roleNames []string, userID string) (int64, error) {
+	filter, filterArgs := notificationVisibleFilter(roleNames, userID, false)
+	args := append([]interface{}{userID}, filterArgs...)
+
+	// #nosec G202 -- filter is built from fixed SQL fragments and all values are bound.
+	result, err := r.db.Exec(`
+		INSERT IGNORE INTO notification_reads (notification_id, user_id)
+		SELECT n.id, ? FROM notifications n
+		WHERE `+filter, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// CountUnreadNotifications はユーザーに届いた未読の通知の件数を返す。自分が作成した通知は数えない。
+func (r *notificationRepository) CountUnreadNotifications(roleNames []string, userID string) (int, error) {
+	filter, filterArgs := notificationVisibleFilter(roleNames, userID, false)
+	args := append(filterArgs, userID, userID)
+
+	var count int
+	// #nosec G202 -- filter is built from fixed SQL fragments and all values are bound.
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM notifications n
+		WHERE `+filter+`
+			AND (n.created_by IS NULL OR n.created_by <> ?)
+			AND NOT EXISTS (SELECT 1 FROM notification_reads nr WHERE nr.notification_id = n.id AND nr.user_id = ?)
+	`, args...).Scan(&count)
+	return count, err
+}
+
+// GetNotificationReadStats は userIDs を対象者として、通知の既読状況を全体・ロール別・クラス別に集計する
+func (r *notificationRepository) GetNotificationReadStats(notificationID int, userIDs []string) (models.NotificationReadStats, error) {
+	stats := models.NotificationReadStats{
+		NotificationID: notificationID,
+		ByRole:         []models.NotificationReadBreakdown{},
+		ByClass:        []models.NotificationReadBreakdown{},
+	}
+	if len(userIDs) == 0 {
+		return stats, nil
+	}
+
+	placeholders := strings.Repeat(",?", len(userIDs)-1)
+	args := make([]interface{}, 0, len(userIDs)+1)
+	args = append(args, notificationID)
+	for _, id := range userIDs {
+		args = append(args, id)
+	}
+
+	// #nosec G202 -- only the number of bound placeholders is constructed from userIDs.
+	err := r.db.QueryRow(`
+		SELECT COUNT(*), COUNT(nr.user_id)
+		FROM users u
+		LEFT JOIN notification_reads nr ON nr.notification_id = ? AND nr.user_id = u.id
+		WHERE u.id IN (?`+placeholders+`)
+	`, args...).Scan(&stats.TargetUserCount, &stats.ReadUserCount)
+	if err != nil {
+		return stats, err
+	}
+	stats.ReadRate = models.NotificationReadRate(stats.ReadUserCount, stats.TargetUserCount)
+
+	// #nosec G202 -- only the number of bound placeholders is constructed from userIDs.
+	roleRows, err := r.db.Query(`
+		SELECT r.name, COUNT(DISTINCT u.id), COUNT(DISTINCT nr.user_id)
+		FROM users u
+		INNER JOIN user_roles ur ON ur.user_id = u.id
+		INNER JOIN roles r ON r.id = ur.role_id
+		LEFT JOIN notification_reads nr ON nr.notification_id = ? AND nr.user_id = u.id
+		WHERE u.id IN (?`+placeholders+`)
+		GROUP BY r.name
+		ORDER BY r.name
+	`, args...)
+	if err != nil {
+		return stats, err
+	}
+	defer roleRows.Close()
+	for roleRows.Next() {
+		var breakdown models.NotificationReadBreakdown
+		if err := roleRows.Scan(&breakdown.Name, &breakdown.TargetUserCount, &breakdown.ReadUserCount); err != nil {
+			return stats, err
+		}
+		breakdown.ReadRate = models.NotificationReadRate(breakdown.ReadUserCount, breakdown.TargetUserCount)
+		stats.ByRole = append(stats.ByRole, breakdown)
+	}
+	if err := roleRows.Err(); err != nil {
+		return stats, err
+	}
+
+	// #nosec G202 -- only the number of bound placeholders is constructed from userIDs.
+	classRows, err := r.db.Query(`
+		SELECT u.class_id, COALESCE(c.name, ''), COUNT(*), COUNT(nr.user_id)
+		FROM users u
+		LEFT JOIN classes c ON c.id = u.class_id
+		LEFT JOIN notification_reads nr ON nr.notification_id = ? AND nr.user_id = u.id
+		WHERE u.id IN (?`+placeholders+`)
+		GROUP BY u.class_id, c.name
+		ORDER BY c.name
+	`, args...)
+	if err != nil {
+		return stats, err
+	}
+	defer classRows.Close()
+	for classRows.Next() {
+		var breakdown models.NotificationReadBreakdown
+		var classID sql.NullInt64
+		if err := classRows.Scan(&classID, &breakdown.Name, &breakdown.TargetUserCount, &breakdown.ReadUserCount); err != nil {
+			return stats, err
+		}
+		breakdown.ID = nullIntPtr(classID)
+		breakdown.ReadRate = models.NotificationReadRate(breakdown.ReadUserCount, breakdown.TargetUserCount)
+		stats.ByClass = append(stats.ByClass, breakdown)
+	}
+	return stats, classRows.Err()
+}
+
+// GetUserIDsByTargets はロール以外の宛先に含まれるユーザーを返す。
+// sport はイベントを指定した場合そのイベントのチームに絞る。
+func (r *notificationRepository) GetUserIDsByTargets(eventID *int, targets []models.NotificationTarget) ([]string, error) {
+	selects := make([]string, 0, len(targets))
+	args := make([]interface{}, 0, len(targets)+1)
+	for _, target := range targets {
+		switch target.Type {
+		case models.NotificationTargetClass:
+			selects = append(selects, "SELECT id AS user_id FROM users WHERE class_id = ?")
+			args = append(args, nullableInt(target.ID))
+		case models.NotificationTargetTeam:
+			selects = append(selects, "SELECT user_id FROM team_members WHERE team_id = ?")
+			args = append(args, nullableInt(target.ID))
+		case models.NotificationTargetSport:
+			query := "SELECT tm.user_id FROM team_members tm JOIN teams t ON t.id = tm.team_id WHERE t.sport_id = ?"
+			args = append(args, nullableInt(target.ID))
+			if eventID != nil {
+				query += " AND t.event_id = ?"
+				args = append(args, *eventID)
+			}
+			selects = append(selects, query)
+		case models.NotificationTargetMatch:
+			selects = append(selects, "SELECT user_id FROM round_check_ins WHERE match_id = ?")
+			args = append(args, nullableInt(target.ID))
+		case models.NotificationTargetUser:
+			selects = append(selects, "SELECT id AS user_id FROM users WHERE id = ?")
+			args = append(args, nullableString(target.UserID))
+		}
+	}
+	if len(selects) == 0 {
+		return []string{}, nil
+	}
+
+	// UNION で重複を除く
+	// #nosec G202 -- selects are fixed SQL fragments and all values are bound.
+	rows, err := r.db.Query(strings.Join(selects, " UNION ")+" ORDER BY user_id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := make([]string, 0)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
 func (r *notificationRepository) GetUserIDsByRoles(roleNames []string) ([]string, error) {
 	if len(roleNames) == 0 {
 		return []string{}, nil
@@ -212,6 +608,43 @@ func (r *notificationRepository) GetUserIDsByRoles(roleNames []string) ([]string
 	return userIDs, nil
 }
 
+// GetUserEmailsByIDs はユーザーIDごとのメールアドレスを返す。アドレスが空のユーザーは含めない。
+func (r *notificationRepository) GetUserEmailsByIDs(userIDs []string) (map[string]string, error) {
+	emails := make(map[string]string)
+	if len(userIDs) == 0 {
+		return emails, nil
+	}
+
+	placeholders := strings.Repeat(",?", len(userIDs)-1)
+	// #nosec G202 -- only the number of bound placeholders is constructed from userIDs.
+	query := `
+		SELECT id, email
+		FROM users
+		WHERE id IN (?` + placeholders + `) AND email <> ''
+	`
+
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		args[i] = id
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, email string
+		if err := rows.Scan(&id, &email); err != nil {
+			return nil, err
+		}
+		emails[id] = email
+	}
+
+	return emails, rows.Err()
+}
+
 func (r *notificationRepository) GetPushSubscriptionsByUserIDs(userIDs []string) ([]models.PushSubscription, error) {
 	if len(userIDs) == 0 {
 		return []models.PushSubscription{}, nil
@@ -4,6 +4,7 @@ import (
 	"backapp/internal/models"
 	"database/sql"
 	"errors"
+	"time"
 )
 
 type NotificationRequestRepository interface {
@@ -15,6 +16,10 @@ type NotificationRequestRepository interface {
 	AddMessage(requestID int, senderID, message string) (int64, error)
 	GetMessages(requestID int) ([]*models.NotificationRequestMessage, error)
 	GetParticipants(requestID int) (string, *string, error)
+	ResolvePendingRequest(id int, status models.NotificationRequestStatus, resolverID string) (bool, error)
+	LinkRequestNotification(id int, notificationID, scheduledNotificationID *int) error
+	EscalateStaleRequests(now time.Time, threshold time.Duration) ([]*models.NotificationRequest, error)
+	GetRequestStats(now time.Time, threshold time.Duration) (models.NotificationRequestStats, error)
 }
 
 type notificationRequestRepository struct {
@@ -65,6 +70,9 @@ func (r *notificationRequestRepository) GetRequestByID(id int) (*models.Notifica
 			nr.requester_id,
 			nr.resolved_by,
 			nr.resolved_at,
+			nr.notification_id,
+			nr.scheduled_notification_id,
+			nr.escalated_at,
 			nr.created_at,
 			nr.updated_at,
 			req.id,
@@ -82,6 +90,8 @@ func (r *notificationRequestRepository) GetRequestByID(id int) (*models.Notifica
 	req := &models.NotificationRequest{}
 	var resolvedBy sql.NullString
 	var resolvedAt sql.NullTime
+	var notificationID, scheduledNotificationID sql.NullInt64
+	var escalatedAt sql.NullTime
 	requester := &models.User{}
 	var requesterEmail string
 	var requesterDisplay sql.NullString
@@ -98,6 +108,9 @@ func (r *notificationRequestRepository) GetRequestByID(id int) (*models.Notifica
 		&req.RequesterID,
 		&resolvedBy,
 		&resolvedAt,
+		&notificationID,
+		&scheduledNotificationID,
+		&escalatedAt,
 		&req.CreatedAt,
 		&req.UpdatedAt,
 		&requester.ID,
@@ -119,6 +132,9 @@ func (r *notificationRequestRepository) GetRequestByID(id int) (*models.Notifica
 		value := resolvedAt.Time
 		req.ResolvedAt = &value
 	}
+	req.NotificationID = nullIntPtr(notificationID)
+	req.ScheduledNotificationID = nullIntPtr(scheduledNotificationID)
+	req.EscalatedAt = nullTimePtr(escalatedAt)
 	requester.Email = requesterEmail
 	if requesterDisplay.Valid {
 		name := requesterDisplay.String
@@ -150,6 +166,9 @@ func (r *notificationRequestRepository) GetRequestsByRequester(requesterID strin
 			nr.requester_id,
 			nr.resolved_by,
 			nr.resolved_at,
+			nr.notification_id,
+			nr.scheduled_notification_id,
+			nr.escalated_at,
 			nr.created_at,
 			nr.updated_at
 		FROM notification_requests nr
@@ -167,6 +186,8 @@ func (r *notificationRequestRepository) GetRequestsByRequester(requesterID strin
 		req := &models.NotificationRequest{}
 		var resolvedBy sql.NullString
 		var resolvedAt sql.NullTime
+		var notificationID, scheduledNotificationID sql.NullInt64
+		var escalatedAt sql.NullTime
 		if err := rows.Scan(
 			&req.ID,
 			&req.Title,
@@ -176,6 +197,9 @@ func (r *notificationRequestRepository) GetRequestsByRequester(requesterID strin
 			&req.RequesterID,
 			&resolvedBy,
 			&resolvedAt,
+			&notificationID,
+			&scheduledNotificationID,
+			&escalatedAt,
 			&req.CreatedAt,
 			&req.UpdatedAt,
 		); err != nil {
@@ -188,6 +212,9 @@ func (r *notificationRequestRepository) GetRequestsByRequester(requesterID strin
 			value := resolvedAt.Time
 			req.ResolvedAt = &value
 		}
+		req.NotificationID = nullIntPtr(notificationID)
+		req.ScheduledNotificationID = nullIntPtr(scheduledNotificationID)
+		req.EscalatedAt = nullTimePtr(escalatedAt)
 		results = append(results, req)
 	}
 	return results, nil
@@ -204,6 +231,9 @@ func (r *notificationRequestRepository) GetAllRequests() ([]*models.Notification
 			nr.requester_id,
 			nr.resolved_by,
 			nr.resolved_at,
+			nr.notification_id,
+			nr.scheduled_notification_id,
+			nr.escalated_at,
 			nr.created_at,
 			nr.updated_at,
 			req.id,
@@ -224,6 +254,8 @@ func (r *notificationRequestRepository) GetAllRequests() ([]*models.Notification
 		req := &models.NotificationRequest{}
 		var resolvedBy sql.NullString
 		var resolvedAt sql.NullTime
+		var notificationID, scheduledNotificationID sql.NullInt64
+		var escalatedAt sql.NullTime
 		var requesterEmail string
 		var requesterDisplay sql.NullString
 		requester := &models.User{}
@@ -236,6 +268,9 @@ func (r *notificationRequestRepository) GetAllRequests() ([]*models.Notification
 			&req.RequesterID,
 			&resolvedBy,
 			&resolvedAt,
+			&notificationID,
+			&scheduledNotificationID,
+			&escalatedAt,
 			&req.CreatedAt,
 			&req.UpdatedAt,
 			&requester.ID,
@@ -251,6 +286,9 @@ func (r *notificationRequestRepository) GetAllRequests() ([]*models.Notification
 			value := resolvedAt.Time
 			req.ResolvedAt = &value
 		}
+		req.NotificationID = nullIntPtr(notificationID)
+		req.ScheduledNotificationID = nullIntPtr(scheduledNotificationID)
+		req.EscalatedAt = nullTimePtr(escalatedAt)
 		requester.Email = requesterEmail
 		if requesterDisplay.Valid {
 			name := requesterDisplay.String
@@ -338,3 +376,134 @@ func (r *notificationRequestRepository) GetParticipants(requestID int) (string,
 	}
 	return requester, resolverPtr, nil
 }
+
+// ResolvePendingRequest は承認待ちの申請だけを承認・否認する。既に処理済みなら false を返す。
+func (r *notificationRequestRepository) ResolvePendingRequest(id int, status models.NotificationRequestStatus, resolverID string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE notification_requests
+		SET status = ?, resolved_by = ?, resolved_at = NOW()
+		WHERE id = ? AND status = 'pending'
+	`, status, resolverID, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// LinkRequestNotification は承認で作成した通知・予約通知を申請に記録する
+func (r *notificationRequestRepository) LinkRequestNotification(id int, notificationID, scheduledNotificationID *int) error {
+	_, err := r.db.Exec(`
+		UPDATE notification_requests
+		SET notification_id = ?, scheduled_notification_id = ?
+		WHERE id = ?
+	`, nullableInt(notificationID), nullableInt(scheduledNotificationID), id)
+	return err
+}
+
+// EscalateStaleRequests は threshold 以上承認待ちの申請のうち、前回の催促から threshold 経った申請の催促日時を now にして返す
+func (r *notificationRequestRepository) EscalateStaleRequests(now time.Time, threshold time.Duration) ([]*models.NotificationRequest, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	cutoff := now.Add(-threshold)
+	rows, err := tx.Query(`
+		SELECT id, title, target_text, requester_id, created_at
+		FROM notification_requests
+		WHERE status = 'pending' AND created_at <= ? AND (escalated_at IS NULL OR escalated_at <= ?)
+		ORDER BY created_at
+		FOR UPDATE SKIP LOCKED
+	`, cutoff, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []*models.NotificationRequest
+	for rows.Next() {
+		req := &models.NotificationRequest{Status: models.NotificationRequestStatusPending}
+		if err := rows.Scan(&req.ID, &req.Title, &req.TargetText, &req.RequesterID, &req.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, req := range requests {
+		if _, err := tx.Exec(`UPDATE notification_requests SET escalated_at = ? WHERE id = ?`, now, req.ID); err != nil {
+			return nil, err
+		}
+		escalatedAt := now
+		req.EscalatedAt = &escalatedAt
+	}
+	return requests, tx.Commit()
+}
+
+// GetRequestStats は状態ごとの件数と、承認・否認までにかかった時間（秒）の平均・最大を集計する
+func (r *notificationRequestRepository) GetRequestStats(now time.Time, threshold time.Duration) (models.NotificationRequestStats, error) {
+	stats := models.NotificationRequestStats{
+		ByStatus:   []models.NotificationRequestStatusStats{},
+		SLAMinutes: int(threshold / time.Minute),
+	}
+
+	rows, err := r.db.Query(`
+		SELECT
+			status,
+			COUNT(*),
+			AVG(CASE WHEN status <> 'pending' THEN TIMESTAMPDIFF(SECOND, created_at, resolved_at) END),
+			MAX(CASE WHEN status <> 'pending' THEN TIMESTAMPDIFF(SECOND, created_at, resolved_at) END)
+		FROM notification_requests
+		GROUP BY status
+		ORDER BY FIELD(status, 'pending', 'approved', 'rejected')
+	`)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.NotificationRequestStatusStats
+		var average, max sql.NullFloat64
+		if err := rows.Scan(&item.Status, &item.Count, &average, &max); err != nil {
+			return stats, err
+		}
+		if average.Valid {
+			item.AverageResponseSeconds = &average.Float64
+		}
+		if max.Valid {
+			item.MaxResponseSeconds = &max.Float64
+		}
+		stats.ByStatus = append(stats.ByStatus, item)
+	}
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	var oldest sql.NullTime
+	err = r.db.QueryRow(`
+		SELECT
+			COALESCE(SUM(created_at <= ?), 0),
+			MIN(created_at)
+		FROM notification_requests
+		WHERE status = 'pending'
+	`, now.Add(-threshold)).Scan(&stats.OverdueCount, &oldest)
+	if err != nil {
+		return stats, err
+	}
+	stats.OldestPendingAt = nullTimePtr(oldest)
+	return stats, nil
+}
+
+func nullTimePtr(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := v.Time
+	return &t
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"backapp/internal/models"
+)
+
+// pushDeliveryInsertChunk は配信を一度の INSERT で登録する最大件数
+const pushDeliveryInsertChunk = 500
+
+type PushDeliveryRepository interface {
+	EnqueuePushMessage(notificationID *int, source string, payload []byte, ttl int, subscriptions []models.PushSubscription) (int64, error)
+	ClaimPushDeliveries(limit int, lease time.Duration) ([]*models.PushDelivery, error)
+	CompletePushDelivery(id int, status string, statusCode int, lastError string) error
+	RetryPushDelivery(id int, delay time.Duration, statusCode int, lastError string) error
+	GetPushDeliveryStats(notificationID int) (models.PushDeliveryStats, error)
+}
+
+type pushDeliveryRepository struct {
+	db *sql.DB
+}
+
+func NewPushDeliveryRepository(db *sql.DB) PushDeliveryRepository {
+	return &pushDeliveryRepository{db: db}
+}
+
+// EnqueuePushMessage は送る内容と購読ごとの配信を送信キューに登録し、push_messages の ID を返す
+func (r *pushDeliveryRepository) EnqueuePushMessage(notificationID *int, source string, payload []byte, ttl int, subscriptions []models.PushSubscription) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	messageID, err := insertPushMessage(tx, notificationID, source, payload, ttl, subscriptions)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return messageID, nil
+}
+
+// insertPushMessage は tx の中で送る内容と購読ごとの配信を登録し、push_messages の ID を返す
+func insertPushMessage(tx *sql.Tx, notificationID *int, source string, payload []byte, ttl int, subscriptions []models.PushSubscription) (int64, error) {
+	result, err := tx.Exec(
+		"INSERT INTO push_messages (notification_id, source, payload, ttl) VALUES (?, ?, ?, ?)",
+		nullableInt(notificationID), source, string(payload), ttl,
+	)
+	if err != nil {
+		return 0, err
+	}
+	messageID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for start := 0; start < len(subscriptions); start += pushDeliveryInsertChunk {
+		end := start + pushDeliveryInsertChunk
+		if end > len(subscriptions) {
+			end = len(subscriptions)
+		}
+		values := make([]string, 0, end-start)
+		args := make([]interface{}, 0, (end-start)*3)
+		for _, sub := range subscriptions[start:end] {
+			values = append(values, "(?, ?, ?, 'pending', NOW())")
+			args = append(args, messageID, sub.ID, sub.UserID)
+		}
+		// #nosec G202 -- only the number of bound placeholders is constructed from subscriptions.
+		query := "INSERT INTO push_deliveries (message_id, subscription_id, user_id, status, next_attempt_at) VALUES " + strings.Join(values, ", ")
+		if _, err := tx.Exec(query, args...); err != nil {
+			return 0, err
+		}
+	}
+	return messageID, nil
+}
+
+// ClaimPushDeliveries は送信時刻を過ぎた未送信の配信を最大 limit 件取り出す。
+// 取り出した配信は試行回数を増やし、次の試行時刻を lease 後にずらす。
+// 送信中にプロセスが止まっても lease が過ぎれば別のワーカーが取り出し直す。
+func (r *pushDeliveryRepository) ClaimPushDeliveries(limit int, lease time.Duration) ([]*models.PushDelivery, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT d.id, d.message_id, m.notification_id, m.source, d.user_id, d.attempts, m.payload, m.ttl,
+			s.id, s.endpoint, s.auth_key, s.p256dh_key
+		FROM push_deliveries d
+		JOIN push_messages m ON m.id = d.message_id
+		LEFT JOIN push_subscriptions s ON s.id = d.subscription_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= NOW()
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT ?
+		FOR UPDATE OF d SKIP LOCKED
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*models.PushDelivery, 0)
+	for rows.Next() {
+		var d models.PushDelivery
+		var notificationID, subscriptionID sql.NullInt64
+		var payload string
+		var endpoint, authKey, p256dhKey sql.NullString
+		if err := rows.Scan(&d.ID, &d.MessageID, &notificationID, &d.Source, &d.UserID, &d.Attempts, &payload, &d.TTL,
+			&subscriptionID, &endpoint, &authKey, &p256dhKey); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		d.NotificationID = nullIntPtr(notificationID)
+		d.Payload = []byte(payload)
+		if subscriptionID.Valid {
+			d.Subscription = &models.PushSubscription{
+				ID:        int(subscriptionID.Int64),
+				UserID:    d.UserID,
+				Endpoint:  endpoint.String,
+				AuthKey:   authKey.String,
+				P256dhKey: p256dhKey.String,
+			}
+		}
+		d.Attempts++
+		deliveries = append(deliveries, &d)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	if len(deliveries) == 0 {
+		return deliveries, tx.Commit()
+	}
+
+	placeholders := strings.Repeat(",?", len(deliveries)-1)
+	args := []interface{}{int(lease.Seconds())}
+	for _, d := range deliveries {
+		args = append(args, d.ID)
+	}
+	// #nosec G202 -- only the number of bound placeholders is constructed from deliveries.
+	if _, err := tx.Exec(`
+		UPDATE push_deliveries
+		SET attempts = attempts + 1, next_attempt_at = DATE_ADD(NOW(), INTERVAL ? SECOND)
+		WHERE id IN (?`+placeholders+`)
+	`, args...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// CompletePushDelivery は配信を送信済み・失敗・失効のいずれかで確定する
+func (r *pushDeliveryRepository) CompletePushDelivery(id int, status string, statusCode int, lastError string) error {
+	code, errText := pushDeliveryResultArgs(statusCode, lastError)
+	_, err := r.db.Exec(
+		"UPDATE push_deliveries SET status = ?, last_status_code = ?, last_error = ? WHERE id = ?",
+		status, code, errText, id,
+	)
+	return err
+}
+
+// RetryPushDelivery は配信を delay 後に再送する
+func (r *pushDeliveryRepository) RetryPushDelivery(id int, delay time.Duration, statusCode int, lastError string) error {
+	code, errText := pushDeliveryResultArgs(statusCode, lastError)
+	_, err := r.db.Exec(`
+		UPDATE push_deliveries
+		SET status = 'pending', next_attempt_at = DATE_ADD(NOW(), INTERVAL ? SECOND), last_status_code = ?, last_error = ?
+		WHERE id = ?
+	`, int(delay.Seconds()), code, errText, id)
+	return err
+}
+
+// GetPushDeliveryStats は通知の配信を状態ごとに数える
+func (r *pushDeliveryRepository) GetPushDeliveryStats(notificationID int) (models.PushDeliveryStats, error) {
+	stats := models.PushDeliveryStats{NotificationID: notificationID}
+	err := r.db.QueryRow(`
+		SELECT
+			COUNT(d.id),
+			COALESCE(SUM(d.status = 'sent'), 0),
+			COALESCE(SUM(d.status = 'failed'), 0),
+			COALESCE(SUM(d.status = 'expired'), 0),
+			COALESCE(SUM(d.status = 'pending'), 0)
+		FROM push_deliveries d
+		JOIN push_messages m ON m.id = d.message_id
+		WHERE m.notification_id = ?
+	`, notificationID).Scan(&stats.Total, &stats.Sent, &stats.Failed, &stats.Expired, &stats.Pending)
+	return stats, err
+}
+
+// pushDeliveryResultArgs は応答のステータスとエラー種別を、なければ NULL として保存する値にする
+func pushDeliveryResultArgs(statusCode int, lastError string) (interface{}, interface{}) {
+	var code, errText interface{}
+	if statusCode != 0 {
+		code = statusCode
+	}
+	if lastError != "" {
+		errText = lastError
+	}
+	return code, errText
+}
@@ -10,6 +10,9 @@ type RainyModeRepository interface {
 	GetSetting(eventID int, sportID int, classID int) (*models.RainyModeSetting, error)
 	UpsertSetting(setting *models.RainyModeSetting) error
 	DeleteSetting(eventID int, sportID int, classID int) error
+	GetPlanMatches(eventID int) ([]*models.RainyPlanMatch, error)
+	GetPlanTeams(eventID int) ([]*models.Team, error)
+	ApplyRainyPlan(eventID int, plan *models.RainyPlan, tournaments []models.GeneratedTournament, actorUserID string) error
 }
 
 type rainyModeRepository struct {
@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"backapp/internal/models"
+)
+
+// ErrRainyPlanOutdated は計画を確認した後に試合結果が入力されるなどして、計画どおりに適用できない場合に返される
+var ErrRainyPlanOutdated = errors.New("rainy plan is outdated")
+
+// GetPlanMatches は大会の全試合を、雨天時計画の確認に必要な項目だけ返す
+func (r *rainyModeRepository) GetPlanMatches(eventID int) ([]*models.RainyPlanMatch, error) {
+	rows, err := r.db.Query(`
+		SELECT m.id, t.sport_id, t.name, COALESCE(m.round, 0), COALESCE(m.status, ''),
+		       COALESCE(t1.name, ''), COALESCE(t2.name, ''),
+		       (COALESCE(m.status, '') = 'finished' OR m.team1_score IS NOT NULL OR m.team2_score IS NOT NULL),
+		       COALESCE(m.team1_id, 0), COALESCE(m.next_match_id, 0)
+		FROM matches m
+		JOIN tournaments t ON t.id = m.tournament_id
+		LEFT JOIN teams t1 ON t1.id = m.team1_id
+		LEFT JOIN teams t2 ON t2.id = m.team2_id
+		WHERE t.event_id = ?
+		ORDER BY t.sport_id, t.id, m.round, m.match_number_in_round
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := make([]*models.RainyPlanMatch, 0)
+	for rows.Next() {
+		var m models.RainyPlanMatch
+		if err := rows.Scan(&m.MatchID, &m.SportID, &m.TournamentName, &m.Round, &m.Status, &m.Team1Name, &m.Team2Name, &m.HasResult, &m.Team1ID, &m.NextMatchID); err != nil {
+			return nil, err
+		}
+		matches = append(matches, &m)
+	}
+	return matches, rows.Err()
+}
+
+// GetPlanTeams は大会のクラスの全チームを定員とともに返す
+func (r *rainyModeRepository) GetPlanTeams(eventID int) ([]*models.Team, error) {
+	rows, err := r.db.Query(`
+		SELECT t.id, t.name, t.class_id, t.sport_id, c.event_id, t.min_capacity, t.max_capacity
+		FROM teams t
+		JOIN classes c ON c.id = t.class_id
+		WHERE c.event_id = ?
+		ORDER BY t.sport_id, t.class_id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := make([]*models.Team, 0)
+	for rows.Next() {
+		var team models.Team
+		var minCapacity, maxCapacity sql.NullInt64
+		if err := rows.Scan(&team.ID, &team.Name, &team.ClassID, &team.SportID, &team.EventID, &minCapacity, &maxCapacity); err != nil {
+			return nil, err
+		}
+		if minCapacity.Valid {
+			capacity := int(minCapacity.Int64)
+			team.MinCapacity = &capacity
+		}
+		if maxCapacity.Valid {
+			capacity := int(maxCapacity.Int64)
+			team.MaxCapacity = &capacity
+		}
+		teams = append(teams, &team)
+	}
+	return teams, rows.Err()
+}
+
+// ApplyRainyPlan は確認済みの雨天時計画を1つのトランザクションで適用する。
+// 未実施の試合の中止、統合先チームの作成とメンバーの移動、定員の変更、対戦表の組み直しか
+// 不戦勝の枠への新しいチームの割り当てを行い、結果入力済みの試合と得点には手を付けない。tournaments は組み直す競技の新しい対戦表で、
+// 統合で新しく作るチームは ID を 0 にしてクラスで指定する。
+func (r *rainyModeRepository) ApplyRainyPlan(eventID int, plan *models.RainyPlan, tournaments []models.GeneratedTournament, actorUserID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if ids := plan.CancelledMatchIDs(); len(ids) > 0 {
+		condition, args := matchIDCondition("m.id", ids)
+		condition += " AND COALESCE(m.status, '') NOT IN ('finished', 'bye', 'cancelled') AND m.team1_score IS NULL AND m.team2_score IS NULL"
+		if err := ensureMatchBaselines(tx, condition, args...); err != nil {
+			return err
+		}
+		// #nosec G202 -- condition contains only internally generated placeholders; values are bound below.
+		result, err := tx.Exec("UPDATE matches m SET m.status = 'cancelled' WHERE "+condition, args...)
+		if err != nil {
+			return err
+		}
+		cancelled, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		// 確認の後に結果が入力された試合があれば、計画を確認し直してもらう
+		if int(cancelled) != len(ids) {
+			return ErrRainyPlanOutdated
+		}
+		idCondition, idArgs := matchIDCondition("m.id", ids)
+		if err := recordMatchRevisions(tx, newMatchChange(models.MatchRevisionRainyPlan, actorUserID), idCondition, idArgs...); err != nil {
+			return err
+		}
+	}
+
+	// 統合先の競技ごとの、クラスIDからチームIDへの対応
+	targetTeams := make(map[int]map[int]int)
+	targetTeamID := func(sportID, classID int) (int, error) {
+		if id, ok := targetTeams[sportID][classID]; ok {
+			return id, nil
+		}
+		var id int
+		if err := tx.QueryRow("SELECT id FROM teams WHERE class_id = ? AND sport_id = ?", classID, sportID).Scan(&id); err != nil {
+			return 0, err
+		}
+		if targetTeams[sportID] == nil {
+			targetTeams[sportID] = make(map[int]int)
+		}
+		targetTeams[sportID][classID] = id
+		return id, nil
+	}
+
+	for _, sport := range plan.Sports {
+		for _, team := range sport.Teams {
+			if team.Change != models.RainyTeamCreated {
+				continue
+			}
+			result, err := tx.Exec("INSERT INTO teams (name, class_id, sport_id, min_capacity, max_capacity) VALUES (?, ?, ?, ?, ?)",
+				team.TeamName, team.ClassID, team.SportID, team.MinCapacity, team.MaxCapacity)
+			if err != nil {
+				return err
+			}
+			id, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+			if targetTeams[team.SportID] == nil {
+				targetTeams[team.SportID] = make(map[int]int)
+			}
+			targetTeams[team.SportID][team.ClassID] = int(id)
+		}
+	}
+
+	for _, sport := range plan.Sports {
+		for _, team := range sport.Teams {
+			switch {
+			case team.Change == models.RainyTeamMerged && team.TargetSportID != nil:
+				targetID, err := targetTeamID(*team.TargetSportID, team.ClassID)
+				if err != nil {
+					return err
+				}
+				if _, err := tx.Exec(`
+					INSERT IGNORE INTO team_members (team_id, user_id, is_confirmed)
+					SELECT ?, user_id, is_confirmed FROM team_members WHERE team_id = ?
+				`, targetID, team.TeamID); err != nil {
+					return err
+				}
+			case team.Change == models.RainyTeamAdjusted && team.CapacityChanged:
+				if _, err := tx.Exec("UPDATE teams SET min_capacity = ?, max_capacity = ? WHERE id = ?", team.MinCapacity, team.MaxCapacity, team.TeamID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	byeChange := newMatchChange(models.MatchRevisionRainyPlan, actorUserID)
+	for _, sport := range plan.Sports {
+		created := 0
+		for _, team := range sport.Teams {
+			if team.Change == models.RainyTeamCreated {
+				created++
+			}
+		}
+		if created == 0 || sport.RegenerateTournament {
+			continue
+		}
+		// 対戦表に入らないチームを作らないよう、新しいチームはすべて不戦勝の枠に入れる
+		if len(sport.ByeSeeds) != created {
+			var matches int
+			if err := tx.QueryRow(`
+				SELECT COUNT(*)
+				FROM matches m
+				JOIN tournaments t ON t.id = m.tournament_id
+				WHERE t.event_id = ? AND t.sport_id = ?
+			`, eventID, sport.SportID).Scan(&matches); err != nil {
+				return err
+			}
+			if matches > 0 {
+				return ErrRainyPlanOutdated
+			}
+		}
+		for _, seed := range sport.ByeSeeds {
+			teamID, err := targetTeamID(sport.SportID, seed.ClassID)
+			if err != nil {
+				return err
+			}
+			if err := seedByeMatch(tx, seed.MatchID, teamID, byeChange); err != nil {
+				return err
+			}
+		}
+	}
+	if err := byeChange.record(tx); err != nil {
+		return err
+	}
+
+	for _, sport := range plan.Sports {
+		if !sport.RegenerateTournament {
+			continue
+		}
+		// 組み直す対戦表に結果が入っていれば消せないため、計画を確認し直してもらう
+		var entered int
+		if err := tx.QueryRow(`
+			SELECT COUNT(*)
+			FROM matches m
+			JOIN tournaments t ON t.id = m.tournament_id
+			WHERE t.event_id = ? AND t.sport_id = ?
+			  AND (COALESCE(m.status, '') = 'finished' OR m.team1_score IS NOT NULL OR m.team2_score IS NOT NULL)
+		`, eventID, sport.SportID).Scan(&entered); err != nil {
+			return err
+		}
+		if entered > 0 {
+			return ErrRainyPlanOutdated
+		}
+		if err := deleteSportTournaments(tx, eventID, sport.SportID); err != nil {
+			return err
+		}
+
+		for i := range tournaments {
+			tournament := &tournaments[i]
+			if tournament.SportID != sport.SportID {
+				continue
+			}
+			teams := make([]*models.Team, len(tournament.ShuffledTeams))
+			for j := range tournament.ShuffledTeams {
+				team := &tournament.ShuffledTeams[j]
+				if team.ID == 0 {
+					id, err := targetTeamID(sport.SportID, team.ClassID)
+					if err != nil {
+						return err
+					}
+					team.ID = id
+				}
+				teams[j] = team
+			}
+			if err := saveTournament(tx, eventID, tournament.SportID, tournament.SportName, &tournament.TournamentData, teams); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// seedByeMatch は不戦勝の一回戦に teamID のチームを入れて未実施の試合に戻し、
+// 不戦勝で勝ち上がっていたチームを勝ち上がり先の試合から外す。
+// 確認の後に勝ち上がり先の試合が行われるなどして枠が使えなくなっていれば ErrRainyPlanOutdated を返す。
+func seedByeMatch(tx *sql.Tx, matchID, teamID int, change *matchChange) error {
+	var status string
+	var byeTeamID, nextMatchID sql.NullInt64
+	if err := tx.QueryRow("SELECT COALESCE(status, ''), team1_id, next_match_id FROM matches WHERE id = ? FOR UPDATE", matchID).
+		Scan(&status, &byeTeamID, &nextMatchID); err != nil {
+		return err
+	}
+	if status != models.MatchStatusBye || !byeTeamID.Valid || !nextMatchID.Valid {
+		return ErrRainyPlanOutdated
+	}
+
+	next := models.MatchDB{ID: int(nextMatchID.Int64)}
+	var nextStatus string
+	var hasResult bool
+	if err := tx.QueryRow(`
+		SELECT team1_id, team2_id, COALESCE(status, ''), (team1_score IS NOT NULL OR team2_score IS NOT NULL)
+		FROM matches WHERE id = ? FOR UPDATE
+	`, next.ID).Scan(&next.Team1ID, &next.Team2ID, &nextStatus, &hasResult); err != nil {
+		return err
+	}
+	if hasResult || nextStatus == "finished" || nextStatus == models.MatchStatusCancelled {
+		return ErrRainyPlanOutdated
+	}
+
+	condition, args := matchIDCondition("m.id", []int{matchID, next.ID})
+	if err := ensureMatchBaselines(tx, condition, args...); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE matches SET team2_id = ?, status = 'pending' WHERE id = ?", teamID, matchID); err != nil {
+		return err
+	}
+	if err := replaceSlotTeam(tx, &next, byeTeamID.Int64, 0); err != nil {
+		return err
+	}
+	change.touch(matchID, next.ID)
+	return nil
+}
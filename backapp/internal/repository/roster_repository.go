@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"backapp/internal/models"
+)
+
+type RosterRepository interface {
+	GetRosterPhase(eventID int) (models.RosterPhase, error)
+	SetRosterPhase(eventID int, phase models.RosterPhase) (bool, error)
+	CreateChangeRequests(requests []*models.RosterChangeRequest) error
+	GetChangeRequest(id int) (*models.RosterChangeRequest, error)
+	ListChangeRequests(eventID int, status models.RosterChangeRequestStatus) ([]*models.RosterChangeRequest, error)
+	ResolvePendingChangeRequest(id int, status models.RosterChangeRequestStatus, reviewerID string, note *string) (bool, error)
+	ReopenChangeRequest(id int) error
+}
+
+type rosterRepository struct {
+	db *sql.DB
+}
+
+func NewRosterRepository(db *sql.DB) RosterRepository {
+	return &rosterRepository{db: db}
+}
+
+const rosterChangeRequestColumns = `
+	r.id, r.event_id, r.class_id, r.sport_id, r.user_id, r.action, r.reason, r.status,
+	r.requested_by, r.reviewed_by, r.review_note, r.reviewed_at, r.created_at,
+	c.name, s.name, u.email
+`
+
+const rosterChangeRequestFrom = `
+	FROM roster_change_requests r
+	JOIN classes c ON c.id = r.class_id
+	JOIN sports s ON s.id = r.sport_id
+	JOIN users u ON u.id = r.user_id
+`
+
+// GetRosterPhase は大会のメンバー登録の段階を返す。大会がなければ空文字を返す
+func (r *rosterRepository) GetRosterPhase(eventID int) (models.RosterPhase, error) {
+	var phase string
+	err := r.db.QueryRow("SELECT roster_phase FROM events WHERE id = ?", eventID).Scan(&phase)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return models.RosterPhase(phase), nil
+}
+
+// SetRosterPhase は大会のメンバー登録の段階を変える。大会がなければ false を返す
+func (r *rosterRepository) SetRosterPhase(eventID int, phase models.RosterPhase) (bool, error) {
+	var exists int
+	err := r.db.QueryRow("SELECT 1 FROM events WHERE id = ?", eventID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	_, err = r.db.Exec("UPDATE events SET roster_phase = ? WHERE id = ?", phase, eventID)
+	return err == nil, err
+}
+
+// CreateChangeRequests は変更申請をまとめて登録し、採番した ID を requests に入れる
+func (r *rosterRepository) CreateChangeRequests(requests []*models.RosterChangeRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, request := range requests {
+		result, err := tx.Exec(`
+			INSERT INTO roster_change_requests (event_id, class_id, sport_id, user_id, action, reason, status, requested_by)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, request.EventID, request.ClassID, request.SportID, request.UserID, request.Action, request.Reason, models.RosterChangeRequestStatusPending, request.RequestedBy)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		request.ID = int(id)
+		request.Status = models.RosterChangeRequestStatusPending
+	}
+	return tx.Commit()
+}
+
+func (r *rosterRepository) GetChangeRequest(id int) (*models.RosterChangeRequest, error) {
+	rows, err := r.db.Query("SELECT "+rosterChangeRequestColumns+rosterChangeRequestFrom+"WHERE r.id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests, err := scanRosterChangeRequests(rows)
+	if err != nil || len(requests) == 0 {
+		return nil, err
+	}
+	return requests[0], nil
+}
+
+// ListChangeRequests は大会の変更申請を新しい順に返す。status が空なら処理済みの申請も含めて全件返す
+func (r *rosterRepository) ListChangeRequests(eventID int, status models.RosterChangeRequestStatus) ([]*models.RosterChangeRequest, error) {
+	conditions := []string{"r.event_id = ?"}
+	args := []interface{}{eventID}
+	if status != "" {
+		conditions = append(conditions, "r.status = ?")
+		args = append(args, status)
+	}
+
+	rows, err := r.db.Query("SELECT "+rosterChangeRequestColumns+rosterChangeRequestFrom+"WHERE "+strings.Join(conditions, " AND ")+" ORDER BY r.created_at DESC, r.id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRosterChangeRequests(rows)
+}
+
+// ResolvePendingChangeRequest は承認待ちの申請だけを承認・却下する。既に処理済みなら false を返す
+func (r *rosterRepository) ResolvePendingChangeRequest(id int, status models.RosterChangeRequestStatus, reviewerID string, note *string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE roster_change_requests
+		SET status = ?, reviewed_by = ?, review_note = ?, reviewed_at = NOW()
+		WHERE id = ? AND status = 'pending'
+	`, status, reviewerID, note, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ReopenChangeRequest は変更を反映できなかった申請を承認待ちに戻す
+func (r *rosterRepository) ReopenChangeRequest(id int) error {
+	_, err := r.db.Exec(`
+		UPDATE roster_change_requests
+		SET status = 'pending', reviewed_by = NULL, review_note = NULL, reviewed_at = NULL
+		WHERE id = ?
+	`, id)
+	return err
+}
+
+func scanRosterChangeRequests(rows *sql.Rows) ([]*models.RosterChangeRequest, error) {
+	requests := make([]*models.RosterChangeRequest, 0)
+	for rows.Next() {
+		request := &models.RosterChangeRequest{}
+		var requestedBy, reviewedBy, reviewNote sql.NullString
+		var reviewedAt sql.NullTime
+		if err := rows.Scan(
+			&request.ID, &request.EventID, &request.ClassID, &request.SportID, &request.UserID, &request.Action, &request.Reason, &request.Status,
+			&requestedBy, &reviewedBy, &reviewNote, &reviewedAt, &request.CreatedAt,
+			&request.ClassName, &request.SportName, &request.UserEmail,
+		); err != nil {
+			return nil, err
+		}
+		if requestedBy.Valid {
+			request.RequestedBy = &requestedBy.String
+		}
+		if reviewedBy.Valid {
+			request.ReviewedBy = &reviewedBy.String
+		}
+		if reviewNote.Valid {
+			request.ReviewNote = &reviewNote.String
+		}
+		if reviewedAt.Valid {
+			request.ReviewedAt = &reviewedAt.Time
+		}
+		requests = append(requests, request)
+	}
+	return requests, rows.Err()
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"backapp/internal/models"
+)
+
+type RoundCheckInScanRepository interface {
+	GetScanResult(idempotencyKey string) (*models.RoundCheckInScanResult, error)
+	SaveScanResult(scannedBy string, scan *models.RoundCheckInScan, scannedAt time.Time, result *models.RoundCheckInScanResult) error
+}
+
+type roundCheckInScanRepository struct {
+	db *sql.DB
+}
+
+func NewRoundCheckInScanRepository(db *sql.DB) RoundCheckInScanRepository {
+	return &roundCheckInScanRepository{db: db}
+}
+
+// GetScanResult は冪等キーで処理済みの読み取り結果を返す。未処理なら nil を返す
+func (r *roundCheckInScanRepository) GetScanResult(idempotencyKey string) (*models.RoundCheckInScanResult, error) {
+	result := &models.RoundCheckInScanResult{IdempotencyKey: idempotencyKey}
+	var body []byte
+	err := r.db.QueryRow(
+		"SELECT status, http_status, body FROM round_check_in_scans WHERE idempotency_key = ?",
+		idempotencyKey,
+	).Scan(&result.Status, &result.HTTPStatus, &body)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, &result.Body); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SaveScanResult は読み取りの処理結果を残す。同じキーが同時に送られたときは先に残った結果を優先する
+func (r *roundCheckInScanRepository) SaveScanResult(scannedBy string, scan *models.RoundCheckInScan, scannedAt time.Time, result *models.RoundCheckInScanResult) error {
+	body, err := json.Marshal(result.Body)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		INSERT IGNORE INTO round_check_in_scans (idempotency_key, scanned_by, barcode_data, scanned_at, status, http_status, body)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, scan.IdempotencyKey, scannedBy, scan.BarcodeData, scannedAt, result.Status, result.HTTPStatus, body)
+	return err
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"backapp/internal/models"
+)
+
+type ScheduleRepository interface {
+	GetConfig(eventID int) (*models.ScheduleConfig, error)
+	SaveConfig(config models.ScheduleConfig, updatedBy string) error
+	GetMatches(eventID int) ([]models.ScheduleMatch, error)
+	GetEventIDByMatchID(matchID int) (int, error)
+	ApplyAssignments(assignments []models.ScheduleAssignment, actorUserID string) error
+}
+
+type scheduleRepository struct {
+	db *sql.DB
+}
+
+func NewScheduleRepository(db *sql.DB) ScheduleRepository {
+	return &scheduleRepository{db: db}
+}
+
+// GetConfig はイベントの日程設定を返す。保存されていなければ nil。
+func (r *scheduleRepository) GetConfig(eventID int) (*models.ScheduleConfig, error) {
+	var raw []byte
+	err := r.db.QueryRow("SELECT config FROM match_schedule_configs WHERE event_id = ?", eventID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var config models.ScheduleConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, err
+	}
+	config.EventID = eventID
+	return &config, nil
+}
+
+func (r *scheduleRepository) SaveConfig(config models.ScheduleConfig, updatedBy string) error {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	var updater interface{}
+	if updatedBy != "" {
+		updater = updatedBy
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO match_schedule_configs (event_id, config, updated_by)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE config = VALUES(config), updated_by = VALUES(updated_by)
+	`, config.EventID, raw, updater)
+	return err
+}
+
+// GetMatches はイベントの全試合を、会場と対戦が決まっているチームのクラス・メンバーとともに返す
+func (r *scheduleRepository) GetMatches(eventID int) ([]models.ScheduleMatch, error) {
+	rows, err := r.db.Query(`
+		SELECT
+			m.id,
+			m.tournament_id,
+			t.sport_id,
+			es.location,
+			COALESCE(m.round, 0),
+			COALESCE(m.match_number_in_round, 0),
+			t1.class_id,
+			t2.class_id,
+			COALESCE(m.status, ''),
+			m.match_start_time,
+			m.rainy_mode_start_time,
+			COALESCE(m.court_number, '')
+		FROM matches m
+		JOIN tournaments t ON m.tournament_id = t.id
+		JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id
+		LEFT JOIN teams t1 ON m.team1_id = t1.id
+		LEFT JOIN teams t2 ON m.team2_id = t2.id
+		WHERE t.event_id = ?
+		ORDER BY m.tournament_id, m.round, m.match_number_in_round, m.id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := make([]models.ScheduleMatch, 0)
+	for rows.Next() {
+		var m models.ScheduleMatch
+		var class1, class2 sql.NullInt64
+		var startTime sql.NullTime
+		var rainyModeStartTime sql.NullString
+		if err := rows.Scan(&m.MatchID, &m.TournamentID, &m.SportID, &m.Location, &m.Round, &m.MatchNumberInRound,
+			&class1, &class2, &m.Status, &startTime, &rainyModeStartTime, &m.Court); err != nil {
+			return nil, err
+		}
+		m.ClassIDs = make([]int, 0, 2)
+		for _, classID := range []sql.NullInt64{class1, class2} {
+			if classID.Valid {
+				m.ClassIDs = append(m.ClassIDs, int(classID.Int64))
+			}
+		}
+		if startTime.Valid {
+			m.StartTime = &startTime.Time
+		}
+		// 読めない雨天時の開始時刻は未設定として扱う
+		if rainyModeStartTime.Valid && rainyModeStartTime.String != "" {
+			if t, err := models.ParseScheduleTime(rainyModeStartTime.String); err == nil {
+				m.RainyModeStartTime = &t
+			}
+		}
+		m.StudentIDs = make([]string, 0)
+		matches = append(matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachStudents(eventID, matches); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// attachStudents は各試合に出るチームのメンバーを StudentIDs に入れる
+func (r *scheduleRepository) attachStudents(eventID int, matches []models.ScheduleMatch) error {
+	rows, err := r.db.Query(`
+		SELECT m.id, tm.user_id
+		FROM matches m
+		JOIN tournaments t ON m.tournament_id = t.id
+		JOIN team_members tm ON tm.team_id = m.team1_id OR tm.team_id = m.team2_id
+		WHERE t.event_id = ?
+		ORDER BY m.id, tm.user_id
+	`, eventID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	index := make(map[int]int, len(matches))
+	for i, m := range matches {
+		index[m.MatchID] = i
+	}
+	for rows.Next() {
+		var matchID int
+		var userID string
+		if err := rows.Scan(&matchID, &userID); err != nil {
+			return err
+		}
+		if i, ok := index[matchID]; ok {
+			matches[i].StudentIDs = append(matches[i].StudentIDs, userID)
+		}
+	}
+	return rows.Err()
+}
+
+// GetEventIDByMatchID は試合が属するイベントを返す。試合が存在しなければ0。
+func (r *scheduleRepository) GetEventIDByMatchID(matchID int) (int, error) {
+	var eventID int
+	err := r.db.QueryRow(`
+		SELECT t.event_id
+		FROM matches m
+		JOIN tournaments t ON m.tournament_id = t.id
+		WHERE m.id = ?
+	`, matchID).Scan(&eventID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return eventID, err
+}
+
+// ApplyAssignments は試合の開始時刻とコートをまとめて更新する。未定の試合は予定済みにする。
+func (r *scheduleRepository) ApplyAssignments(assignments []models.ScheduleAssignment, actorUserID string) error {
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	matchIDs := make([]int, len(assignments))
+	for i, a := range assignments {
+		matchIDs[i] = a.MatchID
+	}
+	condition, args := matchIDCondition("m.id", matchIDs)
+	if err := ensureMatchBaselines(tx, condition, args...); err != nil {
+		return err
+	}
+
+	change := newMatchChange(models.MatchRevisionSchedule, actorUserID)
+	for _, a := range assignments {
+		if _, err := tx.Exec(
+			"UPDATE matches SET match_start_time = ?, court_number = ?, status = CASE WHEN status = 'pending' THEN 'scheduled' ELSE status END WHERE id = ?",
+			a.StartTime, a.Court, a.MatchID,
+		); err != nil {
+			return err
+		}
+		change.touch(a.MatchID)
+	}
+	if err := change.record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
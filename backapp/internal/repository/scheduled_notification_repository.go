@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"backapp/internal/models"
+)
+
+type ScheduledNotificationRepository interface {
+	CreateScheduledNotification(n *models.ScheduledNotification) (int64, error)
+	GetScheduledNotification(id int) (*models.ScheduledNotification, error)
+	ListScheduledNotifications(status string) ([]*models.ScheduledNotification, error)
+	UpdateScheduledNotification(n *models.ScheduledNotification) (bool, error)
+	CancelScheduledNotification(id int) (bool, error)
+	ClaimDueScheduledNotifications(now time.Time, lateLimit, lease time.Duration) ([]*models.ScheduledNotification, error)
+	CompleteScheduledNotification(next *models.ScheduledNotification, notificationID int) error
+	ReleaseScheduledNotification(id int) error
+}
+
+type scheduledNotificationRepository struct {
+	db *sql.DB
+}
+
+func NewScheduledNotificationRepository(db *sql.DB) ScheduledNotificationRepository {
+	return &scheduledNotificationRepository{db: db}
+}
+
+const scheduledNotificationColumns = `
+	id, title, body, type, target_roles, targets, channel, send_at, repeat_interval_minutes, repeat_until,
+	status, sent_count, last_notification_id, created_by, created_at, updated_at
+`
+
+func scanScheduledNotification(row rowScanner) (*models.ScheduledNotification, error) {
+	var n models.ScheduledNotification
+	var targetRoles string
+	var targets sql.NullString
+	var repeatInterval, lastNotificationID sql.NullInt64
+	var repeatUntil sql.NullTime
+	if err := row.Scan(&n.ID, &n.Title, &n.Body, &n.Type, &targetRoles, &targets, &n.Channel, &n.SendAt, &repeatInterval, &repeatUntil,
+		&n.Status, &n.SentCount, &lastNotificationID, &n.CreatedBy, &n.CreatedAt, &n.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(targetRoles), &n.TargetRoles); err != nil {
+		return nil, err
+	}
+	if targets.Valid {
+		if err := json.Unmarshal([]byte(targets.String), &n.Targets); err != nil {
+			return nil, err
+		}
+	}
+	n.RepeatIntervalMinutes = nullIntPtr(repeatInterval)
+	n.LastNotificationID = nullIntPtr(lastNotificationID)
+	if repeatUntil.Valid {
+		n.RepeatUntil = &repeatUntil.Time
+	}
+	return &n, nil
+}
+
+// marshalScheduledTargets は予約通知の対象ロールと宛先を JSON にする。宛先がなければ NULL にする。
+func marshalScheduledTargets(n *models.ScheduledNotification) (string, interface{}, error) {
+	roles := n.TargetRoles
+	if roles == nil {
+		roles = []string{}
+	}
+	targetRoles, err := json.Marshal(roles)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(n.Targets) == 0 {
+		return string(targetRoles), nil, nil
+	}
+	targets, err := json.Marshal(n.Targets)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(targetRoles), string(targets), nil
+}
+
+func (r *scheduledNotificationRepository) CreateScheduledNotification(n *models.ScheduledNotification) (int64, error) {
+	targetRoles, targets, err := marshalScheduledTargets(n)
+	if err != nil {
+		return 0, err
+	}
+	result, err := r.db.Exec(`
+		INSERT INTO scheduled_notifications (title, body, type, target_roles, targets, channel, send_at, repeat_interval_minutes, repeat_until, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, n.Title, n.Body, n.Type, targetRoles, targets, n.Channel, n.SendAt.UTC(), nullableInt(n.RepeatIntervalMinutes), nullableTime(n.RepeatUntil), n.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetScheduledNotification は予約通知を返す。存在しなければ nil。
+func (r *scheduledNotificationRepository) GetScheduledNotification(id int) (*models.ScheduledNotification, error) {
+	n, err := scanScheduledNotification(r.db.QueryRow("SELECT "+scheduledNotificationColumns+" FROM scheduled_notifications WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return n, err
+}
+
+// ListScheduledNotifications は予約通知を送信日時順に返す。status を指定するとその状態のものに絞る。
+func (r *scheduledNotificationRepository) ListScheduledNotifications(status string) ([]*models.ScheduledNotification, error) {
+	query := "SELECT " + scheduledNotificationColumns + " FROM scheduled_notifications"
+	args := []interface{}{}
+	if status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY send_at, id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := make([]*models.ScheduledNotification, 0)
+	for rows.Next() {
+		n, err := scanScheduledNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+// UpdateScheduledNotification は送信前の予約通知の内容と送信日時を変更する。送信前でなければ false。
+func (r *scheduledNotificationRepository) UpdateScheduledNotification(n *models.ScheduledNotification) (bool, error) {
+	targetRoles, targets, err := marshalScheduledTargets(n)
+	if err != nil {
+		return false, err
+	}
+	result, err := r.db.Exec(`
+		UPDATE scheduled_notifications
+		SET title = ?, body = ?, type = ?, target_roles = ?, targets = ?, channel = ?, send_at = ?, repeat_interval_minutes = ?, repeat_until = ?
+		WHERE id = ? AND status = 'pending'
+	`, n.Title, n.Body, n.Type, targetRoles, targets, n.Channel, n.SendAt.UTC(), nullableInt(n.RepeatIntervalMinutes), nullableTime(n.RepeatUntil), n.ID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// CancelScheduledNotification は送信前の予約通知を取り消す。送信前でなければ false。
+func (r *scheduledNotificationRepository) CancelScheduledNotification(id int) (bool, error) {
+	result, err := r.db.Exec("UPDATE scheduled_notifications SET status = 'cancelled' WHERE id = ? AND status = 'pending'", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// ClaimDueScheduledNotifications は送信日時を過ぎた予約通知を送信中として取り出し、送る回の予約を返す。
+// 取り出した予約は lease の間ほかのサーバーが取り出さず、送り終えたら CompleteScheduledNotification で次の回に進める。
+// 送信中のまま lease を過ぎた予約は、送る途中でサーバーが止まったものとして取り出し直す。
+// 遅れすぎて送らない回はここで次の回に進める。
+func (r *scheduledNotificationRepository) ClaimDueScheduledNotifications(now time.Time, lateLimit, lease time.Duration) ([]*models.ScheduledNotification, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT "+scheduledNotificationColumns+`
+		FROM scheduled_notifications
+		WHERE (status = 'pending' AND send_at <= ?) OR (status = 'sending' AND claimed_until <= ?)
+		ORDER BY send_at, id
+		FOR UPDATE SKIP LOCKED
+	`, now.UTC(), now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	due := make([]*models.ScheduledNotification, 0)
+	for rows.Next() {
+		n, err := scanScheduledNotification(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, n)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	claimed := make([]*models.ScheduledNotification, 0, len(due))
+	for _, n := range due {
+		next := *n
+		next.Status = models.ScheduledNotificationStatusPending
+		if !next.Advance(now, lateLimit) {
+			if _, err := tx.Exec(
+				"UPDATE scheduled_notifications SET status = ?, send_at = ?, claimed_until = NULL WHERE id = ?",
+				next.Status, next.SendAt.UTC(), n.ID,
+			); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if _, err := tx.Exec(
+			"UPDATE scheduled_notifications SET status = 'sending', claimed_until = ? WHERE id = ?",
+			now.Add(lease).UTC(), n.ID,
+		); err != nil {
+			return nil, err
+		}
+		n.Status = models.ScheduledNotificationStatusSending
+		claimed = append(claimed, n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// CompleteScheduledNotification は送り終えた予約を next の送信日時と状態に進め、作成した通知を記録する
+func (r *scheduledNotificationRepository) CompleteScheduledNotification(next *models.ScheduledNotification, notificationID int) error {
+	_, err := r.db.Exec(`
+		UPDATE scheduled_notifications
+		SET status = ?, send_at = ?, sent_count = sent_count + 1, last_notification_id = ?, claimed_until = NULL
+		WHERE id = ? AND status = 'sending'
+	`, next.Status, next.SendAt.UTC(), notificationID, next.ID)
+	return err
+}
+
+// ReleaseScheduledNotification は送れなかった予約を送信前に戻し、次の確認で送り直す
+func (r *scheduledNotificationRepository) ReleaseScheduledNotification(id int) error {
+	_, err := r.db.Exec("UPDATE scheduled_notifications SET status = 'pending', claimed_until = NULL WHERE id = ? AND status = 'sending'", id)
+	return err
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+
+	"backapp/internal/models"
+)
+
+type ScoreLogRepository interface {
+	GetClassScoreLogs(eventID int, classID int, filter models.ScoreLogFilter) ([]*models.ScoreLogEntry, error)
+}
+
+type scoreLogRepository struct {
+	db *sql.DB
+}
+
+func NewScoreLogRepository(db *sql.DB) ScoreLogRepository {
+	return &scoreLogRepository{db: db}
+}
+
+// GetClassScoreLogs はクラスの得点履歴を打ち消された記録も含めて古い順に返す
+func (r *scoreLogRepository) GetClassScoreLogs(eventID int, classID int, filter models.ScoreLogFilter) ([]*models.ScoreLogEntry, error) {
+	conditions := []string{"l.event_id = ?", "l.class_id = ?"}
+	args := []interface{}{eventID, classID}
+	if filter.Reason != "" {
+		conditions = append(conditions, "l.reason = ?")
+		args = append(args, filter.Reason)
+	}
+	if filter.Operation != "" {
+		conditions = append(conditions, "l.operation = ?")
+		args = append(args, filter.Operation)
+	}
+	if filter.SourceMatchID > 0 {
+		conditions = append(conditions, "l.source_match_id = ?")
+		args = append(args, filter.SourceMatchID)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "l.created_at >= ?")
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "l.created_at < ?")
+		args = append(args, *filter.To)
+	}
+
+	// #nosec G202 -- conditions contains only fixed column comparisons with placeholders.
+	query := `
+		SELECT l.id, l.event_id, l.class_id, l.points, l.reason, l.operation, l.source_match_id,
+		       l.actor_user_id, u.display_name, l.reverses_log_id, rv.id, l.created_at
+		FROM score_logs l
+		LEFT JOIN users u ON u.id = l.actor_user_id
+		LEFT JOIN score_logs rv ON rv.reverses_log_id = l.id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY l.created_at, l.id
+	`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []*models.ScoreLogEntry{}
+	for rows.Next() {
+		var entry models.ScoreLogEntry
+		var sourceMatchID, reversesLogID, reversedByLogID sql.NullInt64
+		var actorUserID, actorDisplayName sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.EventID, &entry.ClassID, &entry.Points, &entry.Reason, &entry.Operation, &sourceMatchID,
+			&actorUserID, &actorDisplayName, &reversesLogID, &reversedByLogID, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.SourceMatchID = nullIntPtr(sourceMatchID)
+		entry.ReversesLogID = nullIntPtr(reversesLogID)
+		entry.ReversedByLogID = nullIntPtr(reversedByLogID)
+		if actorUserID.Valid {
+			entry.ActorUserID = &actorUserID.String
+		}
+		if actorDisplayName.Valid {
+			entry.ActorDisplayName = &actorDisplayName.String
+		}
+		logs = append(logs, &entry)
+	}
+	return logs, rows.Err()
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
+
+// scoreAudit は score_logs に残す操作の種類と操作したユーザー
+type scoreAudit struct {
+	operation   string
+	actorUserID string
+}
+
+func (a scoreAudit) actor() interface{} {
+	if a.actorUserID == "" {
+		return nil
+	}
+	return a.actorUserID
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// activeScoreLogCondition はまだ打ち消されていない通常の記録に絞り込む条件
+const activeScoreLogCondition = "l.reverses_log_id IS NULL AND NOT EXISTS (SELECT 1 FROM score_logs r WHERE r.reverses_log_id = l.id)"
+
+func insertScoreLog(ex execer, eventID int, classID int, points int, reason string, sourceMatchID interface{}, audit scoreAudit) error {
+	_, err := ex.Exec(`
+		INSERT INTO score_logs (event_id, class_id, points, reason, source_match_id, operation, actor_user_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, eventID, classID, points, reason, sourceMatchID, audit.operation, audit.actor())
+	return err
+}
+
+// reverseScoreLogs は条件に一致するまだ打ち消されていない記録ごとに、符号を反転した打ち消しの記録を追加する。
+// condition は score_logs を l として参照する固定の条件式で、値はプレースホルダで渡す。
+func reverseScoreLogs(ex execer, audit scoreAudit, condition string, args ...interface{}) error {
+	// #nosec G202 -- condition is always a fixed expression written in this package.
+	query := `
+		INSERT INTO score_logs (event_id, class_id, points, reason, source_match_id, operation, actor_user_id, reverses_log_id)
+		SELECT l.event_id, l.class_id, -l.points, l.reason, l.source_match_id, ?, ?, l.id
+		FROM score_logs l
+		WHERE ` + condition + ` AND ` + activeScoreLogCondition
+	_, err := ex.Exec(query, append([]interface{}{audit.operation, audit.actor()}, args...)...)
+	return err
+}
+
+// replaceClassPoints はクラスの reason の得点を points に置き換える。
+// 現在の得点と同じなら何も記録せず、異なる場合は現在の記録を打ち消してから新しい記録を追加する。
+func replaceClassPoints(tx *sql.Tx, eventID int, classID int, reason string, points int, audit scoreAudit) error {
+	var count int
+	var total sql.NullInt64
+	err := tx.QueryRow(
+		"SELECT COUNT(*), SUM(l.points) FROM score_logs l WHERE l.event_id = ? AND l.class_id = ? AND l.reason = ? AND "+activeScoreLogCondition,
+		eventID, classID, reason,
+	).Scan(&count, &total)
+	if err != nil {
+		return err
+	}
+	if count > 0 && int(total.Int64) == points {
+		return nil
+	}
+
+	if count > 0 {
+		if err := reverseScoreLogs(tx, audit, "l.event_id = ? AND l.class_id = ? AND l.reason = ?", eventID, classID, reason); err != nil {
+			return err
+		}
+	}
+	return insertScoreLog(tx, eventID, classID, points, reason, nil, audit)
+}
+
+// replaceEventPoints はイベント全体の reason の得点をクラスごとの points に置き換える。
+// points に含まれないクラスの得点は打ち消す。
+func replaceEventPoints(tx *sql.Tx, eventID int, reason string, points map[int]int, audit scoreAudit) error {
+	rows, err := tx.Query(
+		"SELECT DISTINCT l.class_id FROM score_logs l WHERE l.event_id = ? AND l.reason = ? AND "+activeScoreLogCondition,
+		eventID, reason,
+	)
+	if err != nil {
+		return err
+	}
+	var stale []int
+	for rows.Next() {
+		var classID int
+		if err := rows.Scan(&classID); err != nil {
+			rows.Close()
+			return err
+		}
+		if _, ok := points[classID]; !ok {
+			stale = append(stale, classID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	sort.Ints(stale)
+	for _, classID := range stale {
+		if err := reverseScoreLogs(tx, audit, "l.event_id = ? AND l.class_id = ? AND l.reason = ?", eventID, classID, reason); err != nil {
+			return err
+		}
+	}
+
+	classIDs := make([]int, 0, len(points))
+	for classID := range points {
+		classIDs = append(classIDs, classID)
+	}
+	sort.Ints(classIDs)
+	for _, classID := range classIDs {
+		if err := replaceClassPoints(tx, eventID, classID, reason, points[classID], audit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
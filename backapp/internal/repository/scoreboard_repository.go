@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+
+	"backapp/internal/models"
+)
+
+type ScoreboardRepository interface {
+	GetTokenHash(eventID int) (string, error)
+	SetTokenHash(eventID int, tokenHash string, createdBy string) error
+	DeleteToken(eventID int) error
+	GetMatch(matchID int) (*models.ScoreboardMatch, error)
+}
+
+type scoreboardRepository struct {
+	db *sql.DB
+}
+
+func NewScoreboardRepository(db *sql.DB) ScoreboardRepository {
+	return &scoreboardRepository{db: db}
+}
+
+// GetTokenHash はイベントのスコアボード用トークンのハッシュを返す。未発行なら空文字列。
+func (r *scoreboardRepository) GetTokenHash(eventID int) (string, error) {
+	var tokenHash string
+	err := r.db.QueryRow("SELECT token_hash FROM scoreboard_tokens WHERE event_id = ?", eventID).Scan(&tokenHash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return tokenHash, nil
+}
+
+// SetTokenHash はトークンを発行する。既に発行済みなら置き換える。
+func (r *scoreboardRepository) SetTokenHash(eventID int, tokenHash string, createdBy string) error {
+	var creator interface{}
+	if createdBy != "" {
+		creator = createdBy
+	}
+	_, err := r.db.Exec(`
+		INSERT INTO scoreboard_tokens (event_id, token_hash, created_by)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE token_hash = VALUES(token_hash), created_by = VALUES(created_by), created_at = CURRENT_TIMESTAMP
+	`, eventID, tokenHash, creator)
+	return err
+}
+
+func (r *scoreboardRepository) DeleteToken(eventID int) error {
+	_, err := r.db.Exec("DELETE FROM scoreboard_tokens WHERE event_id = ?", eventID)
+	return err
+}
+
+// GetMatch は試合の結果とチーム名を返す。試合が存在しなければ nil。
+func (r *scoreboardRepository) GetMatch(matchID int) (*models.ScoreboardMatch, error) {
+	var m models.ScoreboardMatch
+	var team1ID, team2ID, team1Score, team2Score, winnerID, nextMatchID sql.NullInt64
+	var team1Name, team2Name sql.NullString
+	err := r.db.QueryRow(`
+		SELECT
+			t.event_id,
+			m.tournament_id,
+			t.sport_id,
+			m.id,
+			m.round,
+			m.team1_id,
+			t1.name,
+			m.team2_id,
+			t2.name,
+			m.team1_score,
+			m.team2_score,
+			CASE
+				WHEN m.forfeiting_team_id = m.team1_id THEN m.team2_id
+				WHEN m.forfeiting_team_id = m.team2_id THEN m.team1_id
+				WHEN m.result_type = 'walkover' AND m.forfeiting_team_id IS NULL THEN COALESCE(m.team1_id, m.team2_id)
+				WHEN m.team1_score > m.team2_score THEN m.team1_id
+				WHEN m.team2_score > m.team1_score THEN m.team2_id
+				ELSE NULL
+			END AS winner_team_id,
+			m.status,
+			m.result_type,
+			m.next_match_id
+		FROM matches m
+		JOIN tournaments t ON m.tournament_id = t.id
+		LEFT JOIN teams t1 ON m.team1_id = t1.id
+		LEFT JOIN teams t2 ON m.team2_id = t2.id
+		WHERE m.id = ?
+	`, matchID).Scan(&m.EventID, &m.TournamentID, &m.SportID, &m.MatchID, &m.Round, &team1ID, &team1Name, &team2ID, &team2Name,
+		&team1Score, &team2Score, &winnerID, &m.Status, &m.ResultType, &nextMatchID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m.Team1ID = nullIntPtr(team1ID)
+	m.Team2ID = nullIntPtr(team2ID)
+	m.Team1Score = nullIntPtr(team1Score)
+	m.Team2Score = nullIntPtr(team2Score)
+	m.WinnerID = nullIntPtr(winnerID)
+	m.NextMatchID = nullIntPtr(nextMatchID)
+	if team1Name.Valid {
+		m.Team1Name = &team1Name.String
+	}
+	if team2Name.Valid {
+		m.Team2Name = &team2Name.String
+	}
+	return &m, nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"backapp/internal/models"
+)
+
+type ScoringRuleRepository interface {
+	GetScoringRules(eventID int) (models.ScoringRules, error)
+	GetLatestRuleSet(eventID int) (*models.ScoringRuleSet, error)
+	ListRuleSets(eventID int) ([]*models.ScoringRuleSet, error)
+	CreateRuleSet(eventID int, rules models.ScoringRules, createdBy string) (*models.ScoringRuleSet, error)
+}
+
+type scoringRuleRepository struct {
+	db *sql.DB
+}
+
+func NewScoringRuleRepository(db *sql.DB) ScoringRuleRepository {
+	return &scoringRuleRepository{db: db}
+}
+
+// rowQuerier は *sql.DB と *sql.Tx の両方から配点ルールを読むためのもの
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// loadScoringRules はイベントの最新の配点ルールを返す。保存されていなければ従来の配点を返す。
+func loadScoringRules(q rowQuerier, eventID int) (models.ScoringRules, error) {
+	rules := models.DefaultScoringRules()
+
+	var raw []byte
+	err := q.QueryRow("SELECT rules FROM scoring_rule_sets WHERE event_id = ? ORDER BY version DESC LIMIT 1", eventID).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return rules, nil
+	}
+	if err != nil {
+		return rules, err
+	}
+
+	// 保存後に追加された項目は従来の配点のままにする
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return models.DefaultScoringRules(), err
+	}
+	return rules, nil
+}
+
+func (r *scoringRuleRepository) GetScoringRules(eventID int) (models.ScoringRules, error) {
+	return loadScoringRules(r.db, eventID)
+}
+
+func (r *scoringRuleRepository) GetLatestRuleSet(eventID int) (*models.ScoringRuleSet, error) {
+	row := r.db.QueryRow(`
+		SELECT id, event_id, version, rules, created_by, created_at
+		FROM scoring_rule_sets
+		WHERE event_id = ?
+		ORDER BY version DESC
+		LIMIT 1
+	`, eventID)
+
+	set, err := scanScoringRuleSet(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return set, err
+}
+
+func (r *scoringRuleRepository) ListRuleSets(eventID int) ([]*models.ScoringRuleSet, error) {
+	rows, err := r.db.Query(`
+		SELECT id, event_id, version, rules, created_by, created_at
+		FROM scoring_rule_sets
+		WHERE event_id = ?
+		ORDER BY version DESC
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sets := []*models.ScoringRuleSet{}
+	for rows.Next() {
+		set, err := scanScoringRuleSet(rows)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+	return sets, rows.Err()
+}
+
+// CreateRuleSet は配点ルールを新しいバージョンとして保存する
+func (r *scoringRuleRepository) CreateRuleSet(eventID int, rules models.ScoringRules, createdBy string) (*models.ScoringRuleSet, error) {
+	raw, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var latest int
+	if err := tx.QueryRow("SELECT COALESCE(MAX(version), 0) FROM scoring_rule_sets WHERE event_id = ? FOR UPDATE", eventID).Scan(&latest); err != nil {
+		return nil, err
+	}
+
+	var createdByValue interface{}
+	if createdBy != "" {
+		createdByValue = createdBy
+	}
+	res, err := tx.Exec(
+		"INSERT INTO scoring_rule_sets (event_id, version, rules, created_by) VALUES (?, ?, ?, ?)",
+		eventID, latest+1, raw, createdByValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	set := &models.ScoringRuleSet{
+		ID:        int(id),
+		EventID:   eventID,
+		Version:   latest + 1,
+		Rules:     rules,
+		CreatedAt: time.Now(),
+	}
+	if createdBy != "" {
+		set.CreatedBy = &createdBy
+	}
+	return set, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanScoringRuleSet(s rowScanner) (*models.ScoringRuleSet, error) {
+	var set models.ScoringRuleSet
+	var raw []byte
+	var createdBy sql.NullString
+	if err := s.Scan(&set.ID, &set.EventID, &set.Version, &raw, &createdBy, &set.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	set.Rules = models.DefaultScoringRules()
+	if err := json.Unmarshal(raw, &set.Rules); err != nil {
+		return nil, err
+	}
+	if createdBy.Valid {
+		set.CreatedBy = &createdBy.String
+	}
+	return &set, nil
+}
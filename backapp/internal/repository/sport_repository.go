@@ -20,6 +20,7 @@ type SportRepository interface {
 	GetTeamsBySportID(sportID int) ([]*models.Team, error)
 	GetSportDetails(eventID int, sportID int) (*models.EventSport, error)
 	UpdateSportDetails(eventID int, sportID int, details models.EventSport) error
+	UpdateSportFormat(eventID int, sportID int, format string, leagueGroupCount *int, leagueAdvanceCount *int) error
 }
 
 type sportRepository struct {
@@ -96,7 +97,7 @@ func (r *sportRepository) GetSportByName(name string) (*models.Sport, error) {
 // GetSportsByEventID retrieves all sports assigned to a specific event.
 func (r *sportRepository) GetSportsByEventID(eventID int) ([]*models.EventSport, error) {
 	query := `
-		SELECT es.event_id, es.sport_id, s.name, es.description, es.rules_pdf_url, es.location, es.min_capacity, es.max_capacity
+		SELECT es.event_id, es.sport_id, s.name, es.description, es.rules_pdf_url, es.location, es.min_capacity, es.max_capacity, es.format, es.league_group_count, es.league_advance_count
 		FROM event_sports es
 		JOIN sports s ON es.sport_id = s.id
 		WHERE es.event_id = ?
@@ -111,7 +112,7 @@ func (r *sportRepository) GetSportsByEventID(eventID int) ([]*models.EventSport,
 	var eventSports []*models.EventSport
 	for rows.Next() {
 		eventSport := &models.EventSport{}
-		if err := rows.Scan(&eventSport.EventID, &eventSport.SportID, &eventSport.SportName, &eventSport.Description, &eventSport.RulesPdfURL, &eventSport.Location, &eventSport.MinCapacity, &eventSport.MaxCapacity); err != nil {
+		if err := rows.Scan(&eventSport.EventID, &eventSport.SportID, &eventSport.SportName, &eventSport.Description, &eventSport.RulesPdfURL, &eventSport.Location, &eventSport.MinCapacity, &eventSport.MaxCapacity, &eventSport.Format, &eventSport.LeagueGroupCount, &eventSport.LeagueAdvanceCount); err != nil {
 			return nil, err
 		}
 		eventSports = append(eventSports, eventSport)
@@ -201,9 +202,9 @@ func (r *sportRepository) GetTeamsBySportID(sportID int) ([]*models.Team, error)
 }
 
 func (r *sportRepository) GetSportDetails(eventID int, sportID int) (*models.EventSport, error) {
-	query := "SELECT event_id, sport_id, description, rules_pdf_url, location, min_capacity, max_capacity FROM event_sports WHERE event_id = ? AND sport_id = ?"
+	query := "SELECT event_id, sport_id, description, rules_pdf_url, location, min_capacity, max_capacity, format, league_group_count, league_advance_count FROM event_sports WHERE event_id = ? AND sport_id = ?"
 	eventSport := &models.EventSport{}
-	err := r.db.QueryRow(query, eventID, sportID).Scan(&eventSport.EventID, &eventSport.SportID, &eventSport.Description, &eventSport.RulesPdfURL, &eventSport.Location, &eventSport.MinCapacity, &eventSport.MaxCapacity)
+	err := r.db.QueryRow(query, eventID, sportID).Scan(&eventSport.EventID, &eventSport.SportID, &eventSport.Description, &eventSport.RulesPdfURL, &eventSport.Location, &eventSport.MinCapacity, &eventSport.MaxCapacity, &eventSport.Format, &eventSport.LeagueGroupCount, &eventSport.LeagueAdvanceCount)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return &models.EventSport{EventID: eventID, SportID: sportID}, nil
@@ -218,3 +219,10 @@ func (r *sportRepository) UpdateSportDetails(eventID int, sportID int, details m
 	_, err := r.db.Exec(query, details.Description, details.RulesPdfURL, details.MinCapacity, details.MaxCapacity, eventID, sportID)
 	return err
 }
+
+// UpdateSportFormat は event_sports の試合形式とリーグ設定を更新する。
+func (r *sportRepository) UpdateSportFormat(eventID int, sportID int, format string, leagueGroupCount *int, leagueAdvanceCount *int) error {
+	query := "UPDATE event_sports SET format = ?, league_group_count = ?, league_advance_count = ? WHERE event_id = ? AND sport_id = ?"
+	_, err := r.db.Exec(query, format, leagueGroupCount, leagueAdvanceCount, eventID, sportID)
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 )
@@ -28,6 +29,7 @@ type TeamRepository interface {
 	GetConfirmedTeamMembers(teamID int) ([]*models.User, error)
 	GetConfirmedTeamMembersCount(teamID int) (int, error)
 	CheckInRound(teamID int, userID string, eventID int, sportID int, matchID int, round int) error
+	CheckInRoundAt(teamID int, userID string, eventID int, sportID int, matchID int, round int, checkedInAt time.Time) error
 	GetMatchTeamMembersByTeamIDs(teamIDs []int, eventID int, sportID int) (map[int][]*models.MatchCheckInMember, error)
 	GetMatchCheckIns(eventID int, sportID int, matchID int) ([]*models.MatchCheckInMember, error)
 	CreateTeamsBulk(teams []*models.Team) error
@@ -399,6 +401,22 @@ func (r *teamRepository) CheckInRound(teamID int, userID string, eventID int, sp
 	return nil
 }
 
+// CheckInRoundAt records a round check-in with the time it was scanned, for scans synced after the fact.
+func (r *teamRepository) CheckInRoundAt(teamID int, userID string, eventID int, sportID int, matchID int, round int, checkedInAt time.Time) error {
+	query := `
+		INSERT INTO round_check_ins (event_id, sport_id, match_id, round, user_id, team_id, checked_in_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.db.Exec(query, eventID, sportID, matchID, round, userID, teamID, checkedInAt)
+	if err != nil {
+		if isMySQLDuplicateEntryError(err) {
+			return ErrRoundAlreadyCheckedIn
+		}
+		return err
+	}
+	return nil
+}
+
 func isMySQLDuplicateEntryError(err error) bool {
 	var mysqlErr *mysql.MySQLError
 	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
@@ -3,7 +3,9 @@ package repository
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -11,6 +13,30 @@ import (
 	"backapp/internal/models"
 )
 
+// ErrByeMatchResult は不戦勝の試合に結果を入力しようとした場合に返される
+var ErrByeMatchResult = errors.New("bye match has no result to enter")
+
+// ErrCancelledMatchResult は雨天時計画で中止になった試合に結果を入力しようとした場合に返される
+var ErrCancelledMatchResult = errors.New("cancelled match has no result to enter")
+
+// ErrInvalidMatchResult は試合結果の種別や棄権・不出場・失格のチームの指定が試合と合わない場合に返される
+var ErrInvalidMatchResult = errors.New("invalid match result")
+
+// ErrLeagueKnockoutNotFound はリーグ戦に続く決勝トーナメントが生成されていない場合に返される
+var ErrLeagueKnockoutNotFound = errors.New("league knockout tournament not found")
+
+// ErrLeagueKnockoutStarted は決勝トーナメントの試合が既に始まっている場合に返される
+var ErrLeagueKnockoutStarted = errors.New("league knockout tournament already started")
+
+// ErrLeagueKnockoutSizeMismatch は決勝トーナメントの一回戦の枠や不戦勝の位置が進出チームの組み合わせと合わない場合に返される
+var ErrLeagueKnockoutSizeMismatch = errors.New("league knockout bracket does not match the advancing teams")
+
+// ErrMatchTeamsNotDecided は対戦チームが揃っていない試合に結果を入力しようとした場合に返される
+var ErrMatchTeamsNotDecided = errors.New("match teams are not decided")
+
+// ErrSurveySubmissionsMissing は提出数を残す前に取り込んだアンケート得点があり、再計算できない場合に返される
+var ErrSurveySubmissionsMissing = errors.New("survey submissions are not recorded")
+
 type TournamentRepository interface {
 	SaveTournament(eventID int, sportID int, sportName string, tournamentData *models.TournamentData, teams []*models.Team) error
 	DeleteTournamentsByEventID(eventID int) error
@@ -23,13 +49,18 @@ type TournamentRepository interface {
 	CountTeamsBySportForEvent(eventID int) (map[int]int, error)
 	GetMatchesForTeam(eventID int, teamID int) ([]*models.MatchDetail, error)
 	GetMatchesForTeams(eventID int, teamIDs []int) (map[int][]*models.MatchDetail, error)
-	UpdateMatchStartTime(matchID int, startTime string) error
-	UpdateMatchRainyModeStartTime(matchID int, rainyModeStartTime string) error
-	UpdateMatchResult(matchID, team1Score, team2Score, winnerID int) error
-	UpdateMatchResultForCorrection(matchID, team1Score, team2Score, winnerID int) error
+	UpdateMatchStartTime(matchID int, startTime string, actorUserID string) error
+	UpdateMatchRainyModeStartTime(matchID int, rainyModeStartTime string, actorUserID string) error
+	UpdateMatchResult(matchID, team1Score, team2Score, winnerID int, resultType string, forfeitingTeamID int, actorUserID string) error
+	UpdateMatchResultForCorrection(matchID, team1Score, team2Score, winnerID int, resultType string, forfeitingTeamID int, actorUserID string) error
 	GetTournamentIDByMatchID(matchID int) (int, error)
-	ApplyRainyModeStartTimes(eventID int) error
+	ApplyRainyModeStartTimes(eventID int, actorUserID string) error
+	RecalculateScores(eventID int, actorUserID string) (*models.ScoreRecalculationResult, error)
 	IsMatchResultAlreadyEntered(matchID int) (bool, error)
+	GetLeagueStandings(eventID int, sportID int) ([]*models.LeagueGroupStandings, error)
+	AssignLeagueKnockoutTeams(eventID int, sportID int, pairings [][2]int, actorUserID string) error
+	GetMatchRevisions(matchID int) ([]*models.MatchRevision, error)
+	RestoreMatchRevision(matchID int, revisionID int, actorUserID string) (*models.MatchRestoreResult, error)
 }
 
 type tournamentRepository struct {
@@ -130,6 +161,7 @@ func (r *tournamentRepository) GetTournamentsByEventID(eventID int) ([]*models.T
 				if m.WinnerID.Valid && m.WinnerID.Int64 == m.Team1ID.Int64 {
 					side.IsWinner = true
 				}
+				side.ResultNote = resultSideNote(m, m.Team1ID.Int64)
 				sides = append(sides, side)
 			}
 
@@ -144,9 +176,19 @@ func (r *tournamentRepository) GetTournamentsByEventID(eventID int) ([]*models.T
 				if m.WinnerID.Valid && m.WinnerID.Int64 == m.Team2ID.Int64 {
 					side.IsWinner = true
 				}
+				side.ResultNote = resultSideNote(m, m.Team2ID.Int64)
 				sides = append(sides, side)
 			}
 
+			// 不戦勝の試合は残ったチームを勝者とし、空き枠を BYE として表示する
+			isBye := m.Status == models.MatchStatusBye
+			if isBye {
+				for i := range sides {
+					sides[i].IsWinner = true
+				}
+				sides = append(sides, models.Side{Title: "BYE"})
+			}
+
 			var loserBracketRound *int
 			if m.LoserBracketRound.Valid {
 				round := int(m.LoserBracketRound.Int64)
@@ -159,8 +201,8 @@ func (r *tournamentRepository) GetTournamentsByEventID(eventID int) ([]*models.T
 
 			// Determine which start time to use
 			var effectiveStartTime string
-			if isRainyMode && m.RainyModeStartTime.Valid && m.RainyModeStartTime.String != "" {
-				// Use rainy mode start time when rainy mode is enabled
+			if (isRainyMode || m.IsRainyDay) && m.RainyModeStartTime.Valid && m.RainyModeStartTime.String != "" {
+				// Use rainy mode start time when rainy mode is enabled for the event or the match day
 				effectiveStartTime = m.RainyModeStartTime.String
 			} else if m.StartTime.Valid {
 				// Use normal start time
@@ -168,7 +210,15 @@ func (r *tournamentRepository) GetTournamentsByEventID(eventID int) ([]*models.T
 			}
 
 			matchStatus := m.Status
-			if effectiveStartTime != "" {
+			resultType := ""
+			if m.ResultType != "" && m.ResultType != models.MatchResultNormal {
+				resultType = m.ResultType
+			}
+			if isBye {
+				matchStatus = "不戦勝"
+			} else if resultType != "" {
+				matchStatus = models.MatchResultLabel(resultType)
+			} else if effectiveStartTime != "" {
 				formats := []string{time.RFC3339Nano, time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05"}
 				for _, f := range formats {
 					if t, err := time.Parse(f, effectiveStartTime); err == nil {
@@ -185,9 +235,13 @@ func (r *tournamentRepository) GetTournamentsByEventID(eventID int) ([]*models.T
 				Sides:               sides,
 				MatchStatus:         matchStatus,
 				IsBronzeMatch:       m.IsBronzeMatch,
+				IsBye:               isBye,
 				IsLoserBracketMatch: m.IsLoserBracketMatch,
 				LoserBracketRound:   loserBracketRound,
 				LoserBracketBlock:   loserBracketBlock,
+				IsLeagueMatch:       m.IsLeagueMatch,
+				LeagueGroup:         m.LeagueGroup.String,
+				ResultType:          resultType,
 				StartTime:           effectiveStartTime,
 				RainyModeStartTime: func() string {
 					if m.RainyModeStartTime.Valid {
@@ -199,14 +253,22 @@ func (r *tournamentRepository) GetTournamentsByEventID(eventID int) ([]*models.T
 		}
 
 		numRounds := 0
+		isLeague := false
 		for _, m := range bracketryMatches {
 			if m.RoundIndex+1 > numRounds {
 				numRounds = m.RoundIndex + 1
 			}
+			if m.IsLeagueMatch {
+				isLeague = true
+			}
 		}
 		rounds := make([]models.Round, numRounds)
 		for i := 0; i < numRounds; i++ {
-			rounds[i] = models.Round{Name: fmt.Sprintf("Round %d", i+1)}
+			if isLeague {
+				rounds[i] = models.Round{Name: fmt.Sprintf("第%d節", i+1)}
+			} else {
+				rounds[i] = models.Round{Name: fmt.Sprintf("Round %d", i+1)}
+			}
 		}
 
 		t.Data, err = r.marshal(models.TournamentData{
@@ -223,6 +285,14 @@ func (r *tournamentRepository) GetTournamentsByEventID(eventID int) ([]*models.T
 	return tournaments, nil
 }
 
+// resultSideNote は棄権・不出場・失格となったチームの枠に表示する注記を返す。両者不戦敗は両方の枠に付ける。
+func resultSideNote(m *models.MatchDB, teamID int64) string {
+	if m.ResultType == models.MatchResultDoubleForfeit || (m.ForfeitingTeamID.Valid && m.ForfeitingTeamID.Int64 == teamID) {
+		return models.MatchResultSideNote(m.ResultType)
+	}
+	return ""
+}
+
 func (r *tournamentRepository) getMatchesByEventID(eventID int) (map[int][]*models.MatchDB, error) {
 	rows, err := r.db.Query(`
 		SELECT
@@ -235,6 +305,9 @@ func (r *tournamentRepository) getMatchesByEventID(eventID int) (map[int][]*mode
 			m.team1_score,
 			m.team2_score,
 			CASE
+				WHEN m.forfeiting_team_id = m.team1_id THEN m.team2_id
+				WHEN m.forfeiting_team_id = m.team2_id THEN m.team1_id
+				WHEN m.result_type = 'walkover' AND m.forfeiting_team_id IS NULL THEN COALESCE(m.team1_id, m.team2_id)
 				WHEN m.team1_score > m.team2_score THEN m.team1_id
 				WHEN m.team2_score > m.team1_score THEN m.team2_id
 				ELSE NULL
@@ -246,9 +319,15 @@ func (r *tournamentRepository) getMatchesByEventID(eventID int) (map[int][]*mode
 			m.is_loser_bracket_match,
 			m.loser_bracket_round,
 			m.loser_bracket_block,
-			m.rainy_mode_start_time
+			m.rainy_mode_start_time,
+			m.is_league_match,
+			m.league_group,
+			m.result_type,
+			m.forfeiting_team_id,
+			COALESCE(ed.is_rainy_mode, FALSE) AS is_rainy_day
 		FROM matches m
 		JOIN tournaments t ON m.tournament_id = t.id
+		LEFT JOIN event_days ed ON ed.id = m.event_day_id
 		WHERE t.event_id = ?
 		ORDER BY m.tournament_id, m.round, m.match_number_in_round
 	`, eventID)
@@ -280,6 +359,11 @@ func (r *tournamentRepository) getMatchesByEventID(eventID int) (map[int][]*mode
 			&loserBracketRound,
 			&loserBracketBlock,
 			&m.RainyModeStartTime,
+			&m.IsLeagueMatch,
+			&m.LeagueGroup,
+			&m.ResultType,
+			&m.ForfeitingTeamID,
+			&m.IsRainyDay,
 		); err != nil {
 			return nil, err
 		}
@@ -437,6 +521,9 @@ func (r *tournamentRepository) GetMatchesForTeams(eventID int, teamIDs []int) (m
 			m.team1_score,
 			m.team2_score,
 			CASE
+				WHEN m.forfeiting_team_id = m.team1_id THEN m.team2_id
+				WHEN m.forfeiting_team_id = m.team2_id THEN m.team1_id
+				WHEN m.result_type = 'walkover' AND m.forfeiting_team_id IS NULL THEN COALESCE(m.team1_id, m.team2_id)
 				WHEN m.team1_score > m.team2_score THEN m.team1_id
 				WHEN m.team2_score > m.team1_score THEN m.team2_id
 				ELSE NULL
@@ -446,7 +533,8 @@ func (r *tournamentRepository) GetMatchesForTeams(eventID int, teamIDs []int) (m
 			m.match_start_time,
 			m.is_bronze_match,
 			team1.name,
-			team2.name
+			team2.name,
+			m.event_day_id
 		FROM matches m
 		JOIN tournaments t ON m.tournament_id = t.id
 		JOIN sports s ON t.sport_id = s.id
@@ -488,6 +576,7 @@ func (r *tournamentRepository) GetMatchesForTeams(eventID int, teamIDs []int) (m
 			&detail.IsBronzeMatch,
 			&detail.Team1Name,
 			&detail.Team2Name,
+			&detail.EventDayID,
 		); err != nil {
 			return nil, err
 		}
@@ -548,7 +637,7 @@ func (r *tournamentRepository) getSide(teamID int64, contestantCounter *int, tea
 }
 
 func (r *tournamentRepository) getMatchesByTournamentID(tournamentID int64) ([]*models.MatchDB, error) {
-	rows, err := r.db.Query("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, team1_score, team2_score, CASE WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time FROM matches WHERE tournament_id = ? ORDER BY round, match_number_in_round", tournamentID)
+	rows, err := r.db.Query("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, team1_score, team2_score, CASE WHEN forfeiting_team_id = team1_id THEN team2_id WHEN forfeiting_team_id = team2_id THEN team1_id WHEN result_type = 'walkover' AND forfeiting_team_id IS NULL THEN COALESCE(team1_id, team2_id) WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time, is_league_match, league_group, result_type, forfeiting_team_id FROM matches WHERE tournament_id = ? ORDER BY round, match_number_in_round", tournamentID)
 	if err != nil {
 		return nil, err
 	}
@@ -559,7 +648,7 @@ func (r *tournamentRepository) getMatchesByTournamentID(tournamentID int64) ([]*
 		var m models.MatchDB
 		var loserBracketRound sql.NullInt64
 		var loserBracketBlock sql.NullString
-		if err := rows.Scan(&m.ID, &m.TournamentID, &m.Round, &m.MatchNumberInRound, &m.Team1ID, &m.Team2ID, &m.Team1Score, &m.Team2Score, &m.WinnerID, &m.Status, &m.NextMatchID, &m.StartTime, &m.IsBronzeMatch, &m.IsLoserBracketMatch, &loserBracketRound, &loserBracketBlock, &m.RainyModeStartTime); err != nil {
+		if err := rows.Scan(&m.ID, &m.TournamentID, &m.Round, &m.MatchNumberInRound, &m.Team1ID, &m.Team2ID, &m.Team1Score, &m.Team2Score, &m.WinnerID, &m.Status, &m.NextMatchID, &m.StartTime, &m.IsBronzeMatch, &m.IsLoserBracketMatch, &loserBracketRound, &loserBracketBlock, &m.RainyModeStartTime, &m.IsLeagueMatch, &m.LeagueGroup, &m.ResultType, &m.ForfeitingTeamID); err != nil {
 			return nil, err
 		}
 		if loserBracketRound.Valid {
@@ -605,7 +694,7 @@ func (r *tournamentRepository) populateDisplayWinnerIDs(matches []*models.MatchD
 }
 
 func (r *tournamentRepository) inferWinnerIDForDisplay(match *models.MatchDB, matchByID map[int]*matchWinnerLookup) (int64, error) {
-	if match == nil || match.Status != "finished" {
+	if match == nil || match.Status != "finished" || match.ResultType == models.MatchResultDoubleForfeit {
 		return 0, nil
 	}
 	if match.WinnerID.Valid {
@@ -688,8 +777,8 @@ func (r *tournamentRepository) getMatchByID(tx *sql.Tx, matchID int) (*models.Ma
 	var m models.MatchDB
 	var loserBracketRound sql.NullInt64
 	var loserBracketBlock sql.NullString
-	row := tx.QueryRow("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time FROM matches WHERE id = ?", matchID)
-	if err := row.Scan(&m.ID, &m.TournamentID, &m.Round, &m.MatchNumberInRound, &m.Team1ID, &m.Team2ID, &m.WinnerID, &m.Status, &m.NextMatchID, &m.StartTime, &m.IsBronzeMatch, &m.IsLoserBracketMatch, &loserBracketRound, &loserBracketBlock, &m.RainyModeStartTime); err != nil {
+	row := tx.QueryRow("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN forfeiting_team_id = team1_id THEN team2_id WHEN forfeiting_team_id = team2_id THEN team1_id WHEN result_type = 'walkover' AND forfeiting_team_id IS NULL THEN COALESCE(team1_id, team2_id) WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time, is_league_match, league_group, result_type, forfeiting_team_id FROM matches WHERE id = ?", matchID)
+	if err := row.Scan(&m.ID, &m.TournamentID, &m.Round, &m.MatchNumberInRound, &m.Team1ID, &m.Team2ID, &m.WinnerID, &m.Status, &m.NextMatchID, &m.StartTime, &m.IsBronzeMatch, &m.IsLoserBracketMatch, &loserBracketRound, &loserBracketBlock, &m.RainyModeStartTime, &m.IsLeagueMatch, &m.LeagueGroup, &m.ResultType, &m.ForfeitingTeamID); err != nil {
 		return nil, err
 	}
 	if loserBracketRound.Valid {
@@ -716,6 +805,9 @@ func (r *tournamentRepository) GetMatchForEventSport(matchID int, eventID int, s
 			m.team1_score,
 			m.team2_score,
 			CASE
+				WHEN m.forfeiting_team_id = m.team1_id THEN m.team2_id
+				WHEN m.forfeiting_team_id = m.team2_id THEN m.team1_id
+				WHEN m.result_type = 'walkover' AND m.forfeiting_team_id IS NULL THEN COALESCE(m.team1_id, m.team2_id)
 				WHEN m.team1_score > m.team2_score THEN m.team1_id
 				WHEN m.team2_score > m.team1_score THEN m.team2_id
 				ELSE NULL
@@ -727,7 +819,11 @@ func (r *tournamentRepository) GetMatchForEventSport(matchID int, eventID int, s
 			m.is_loser_bracket_match,
 			m.loser_bracket_round,
 			m.loser_bracket_block,
-			m.rainy_mode_start_time
+			m.rainy_mode_start_time,
+			m.is_league_match,
+			m.league_group,
+			m.result_type,
+			m.forfeiting_team_id
 		FROM matches m
 		JOIN tournaments t ON m.tournament_id = t.id
 		WHERE m.id = ? AND t.event_id = ? AND t.sport_id = ?
@@ -750,6 +846,8 @@ func (r *tournamentRepository) GetMatchForEventSport(matchID int, eventID int, s
 		&loserBracketRound,
 		&loserBracketBlock,
 		&m.RainyModeStartTime,
+		&m.IsLeagueMatch,
+		&m.LeagueGroup,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -768,7 +866,7 @@ func (r *tournamentRepository) GetMatchForEventSport(matchID int, eventID int, s
 func (r *tournamentRepository) IsMatchResultAlreadyEntered(matchID int) (bool, error) {
 	var winnerID sql.NullInt64
 	var status string
-	err := r.db.QueryRow("SELECT CASE WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status FROM matches WHERE id = ?", matchID).Scan(&winnerID, &status)
+	err := r.db.QueryRow("SELECT CASE WHEN forfeiting_team_id = team1_id THEN team2_id WHEN forfeiting_team_id = team2_id THEN team1_id WHEN result_type = 'walkover' AND forfeiting_team_id IS NULL THEN COALESCE(team1_id, team2_id) WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status FROM matches WHERE id = ?", matchID).Scan(&winnerID, &status)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return false, fmt.Errorf("match not found")
@@ -876,20 +974,24 @@ func (r *tournamentRepository) getTeamByIDTx(tx *sql.Tx, teamID int64) (*models.
 type locationScoreColumns struct {
 	win      [3]string
 	champion string
+	league   string
 }
 
 var locationColumns = map[string]locationScoreColumns{
 	"gym1": {
 		win:      [3]string{"gym1_win1_points", "gym1_win2_points", "gym1_win3_points"},
 		champion: "gym1_champion_points",
+		league:   "gym1_league_points",
 	},
 	"gym2": {
 		win:      [3]string{"gym2_win1_points", "gym2_win2_points", "gym2_win3_points"},
 		champion: "gym2_champion_points",
+		league:   "gym2_league_points",
 	},
 	"ground": {
 		win:      [3]string{"ground_win1_points", "ground_win2_points", "ground_win3_points"},
 		champion: "ground_champion_points",
+		league:   "ground_league_points",
 	},
 }
 
@@ -913,36 +1015,35 @@ func (r *tournamentRepository) getTournamentMetadata(tx *sql.Tx, tournamentID in
 	return eventID, sportID, loc, nil
 }
 
-func (r *tournamentRepository) addPoints(tx *sql.Tx, eventID int, classID int, column string, points int, sourceMatchID int) error {
-	if column == "" || points == 0 {
-		return nil
+// checkRainyModeResultEntry は雨天時モードで結果を更新できない昼競技・グラウンド競技の試合ならエラーを返す。
+// 大会全体か、試合の開催日のどちらかが雨天時モードなら更新できない。
+func (r *tournamentRepository) checkRainyModeResultEntry(tx *sql.Tx, matchID int, location string) error {
+	var isRainyMode bool
+	err := tx.QueryRow(`
+		SELECT e.is_rainy_mode OR COALESCE(ed.is_rainy_mode, FALSE)
+		FROM matches m
+		JOIN tournaments t ON t.id = m.tournament_id
+		JOIN events e ON e.id = t.event_id
+		LEFT JOIN event_days ed ON ed.id = m.event_day_id
+		WHERE m.id = ?
+	`, matchID).Scan(&isRainyMode)
+	if err == nil && isRainyMode {
+		if location == "noon_game" || location == "ground" {
+			return fmt.Errorf("雨天時モードでは、昼競技とグラウンド競技の試合結果を更新できません")
+		}
 	}
-
-	query := `
-		INSERT INTO score_logs (event_id, class_id, points, reason, source_match_id)
-		VALUES (?, ?, ?, ?, ?)
-	`
-
-	_, err := tx.Exec(query, eventID, classID, points, column, sourceMatchID)
-	return err
+	return nil
 }
 
-func (r *tournamentRepository) subtractPoints(tx *sql.Tx, eventID int, classID int, column string, points int, sourceMatchID int) error {
+func (r *tournamentRepository) addPoints(tx *sql.Tx, eventID int, classID int, column string, points int, sourceMatchID int, audit scoreAudit) error {
 	if column == "" || points == 0 {
 		return nil
 	}
-
-	query := `
-		INSERT INTO score_logs (event_id, class_id, points, reason, source_match_id)
-		VALUES (?, ?, ?, ?, ?)
-	`
-
-	_, err := tx.Exec(query, eventID, classID, -points, column, sourceMatchID)
-	return err
+	return insertScoreLog(tx, eventID, classID, points, column, sourceMatchID, audit)
 }
 
 func (r *tournamentRepository) inferStoredWinnerID(tx *sql.Tx, match *models.MatchDB) (int64, error) {
-	if match == nil || match.Status != "finished" {
+	if match == nil || match.Status != "finished" || match.ResultType == models.MatchResultDoubleForfeit {
 		return 0, nil
 	}
 	if match.WinnerID.Valid {
@@ -1023,8 +1124,13 @@ func (r *tournamentRepository) inferStoredWinnerID(tx *sql.Tx, match *models.Mat
 	return 0, nil
 }
 
-func (r *tournamentRepository) applyScoring(tx *sql.Tx, match *models.MatchDB, winnerID, loserID int64, totalRounds int) error {
-	if winnerID == 0 || loserID == 0 {
+func (r *tournamentRepository) applyScoring(tx *sql.Tx, match *models.MatchDB, winnerID, loserID int64, totalRounds int, rules models.ScoringRules, audit scoreAudit) error {
+	// 両者不戦敗は勝者がいないため得点を付与しない
+	if winnerID == 0 || loserID == 0 || match.ResultType == models.MatchResultDoubleForfeit {
+		return nil
+	}
+	// 不戦勝で勝ち上がったラウンドには勝利点を付与しない
+	if match.Status == models.MatchStatusBye {
 		return nil
 	}
 
@@ -1059,10 +1165,13 @@ func (r *tournamentRepository) applyScoring(tx *sql.Tx, match *models.MatchDB, w
 		return nil
 	}
 
-	// 敗者戦二回戦の場合、勝者に10点付与（敗者戦ブロック優勝）
+	// 不戦勝・失格でも勝者には勝利点を付与するが、不出場・失格のチームには3位以下・準優勝の得点を付与しない
+	loserPlaced := models.MatchResultLoserContinues(match.ResultType)
+
+	// 敗者戦二回戦の場合、勝者に敗者戦ブロック優勝の得点を付与
 	if match.IsLoserBracketMatch && match.LoserBracketRound.Valid && match.LoserBracketRound.Int64 == 2 {
 		if location == "gym2" {
-			if err := r.addPoints(tx, eventID, winnerTeam.ClassID, "gym2_loser_bracket_champion_points", 10, match.ID); err != nil {
+			if err := r.addPoints(tx, eventID, winnerTeam.ClassID, "gym2_loser_bracket_champion_points", rules.LoserBracketChampionPoints, match.ID, audit); err != nil {
 				return err
 			}
 		}
@@ -1071,7 +1180,7 @@ func (r *tournamentRepository) applyScoring(tx *sql.Tx, match *models.MatchDB, w
 
 	if match.Round >= 0 && match.Round < len(columns.win) {
 		column := columns.win[match.Round]
-		if err := r.addPoints(tx, eventID, winnerTeam.ClassID, column, 10, match.ID); err != nil {
+		if err := r.addPoints(tx, eventID, winnerTeam.ClassID, column, rules.WinPointsForRound(match.Round), match.ID, audit); err != nil {
 			return err
 		}
 	}
@@ -1081,21 +1190,25 @@ func (r *tournamentRepository) applyScoring(tx *sql.Tx, match *models.MatchDB, w
 	}
 
 	if isEffectiveBronzeMatch(match, totalRounds) {
-		if err := r.addPoints(tx, eventID, winnerTeam.ClassID, columns.champion, 50, match.ID); err != nil {
+		if err := r.addPoints(tx, eventID, winnerTeam.ClassID, columns.champion, rules.ThirdPlacePoints, match.ID, audit); err != nil {
 			return err
 		}
-		if err := r.addPoints(tx, eventID, loserTeam.ClassID, columns.champion, 40, match.ID); err != nil {
-			return err
+		if loserPlaced {
+			if err := r.addPoints(tx, eventID, loserTeam.ClassID, columns.champion, rules.FourthPlacePoints, match.ID, audit); err != nil {
+				return err
+			}
 		}
 		return nil
 	}
 
 	if match.Round == totalRounds {
-		if err := r.addPoints(tx, eventID, winnerTeam.ClassID, columns.champion, 80, match.ID); err != nil {
+		if err := r.addPoints(tx, eventID, winnerTeam.ClassID, columns.champion, rules.ChampionPoints, match.ID, audit); err != nil {
 			return err
 		}
-		if err := r.addPoints(tx, eventID, loserTeam.ClassID, columns.champion, 60, match.ID); err != nil {
-			return err
+		if loserPlaced {
+			if err := r.addPoints(tx, eventID, loserTeam.ClassID, columns.champion, rules.RunnerUpPoints, match.ID, audit); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -1115,7 +1228,15 @@ func (r *tournamentRepository) SaveTournament(eventID int, sportID int, sportNam
 	if err != nil {
 		return err
 	}
+	if err := saveTournament(tx, eventID, sportID, sportName, tournamentData, teams); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
 
+// saveTournament はトーナメントと試合を tx の中で保存する。teams は対戦表の contestant の並び順。
+func saveTournament(tx *sql.Tx, eventID int, sportID int, sportName string, tournamentData *models.TournamentData, teams []*models.Team) error {
 	// トーナメント名を生成
 	// sportNameが既に完全なトーナメント名の場合はそのまま使用（" Tournament"が含まれている場合）
 	// そうでない場合は "{sportName} Tournament" を生成
@@ -1123,22 +1244,22 @@ func (r *tournamentRepository) SaveTournament(eventID int, sportID int, sportNam
 	if !strings.Contains(sportName, " Tournament") {
 		tournamentName = fmt.Sprintf("%s Tournament", sportName)
 	}
-	res, err := tx.Exec("INSERT INTO tournaments (name, event_id, sport_id) VALUES (?, ?, ?)", tournamentName, eventID, sportID)
+	res, err := tx.Exec("INSERT INTO tournaments (name, event_id, sport_id, is_league_knockout) VALUES (?, ?, ?, ?)", tournamentName, eventID, sportID, tournamentData.IsLeagueKnockout)
 	if err != nil {
-		tx.Rollback()
 		return fmt.Errorf("failed to insert tournament: %w (tournamentName: %s, eventID: %d, sportID: %d)", err, tournamentName, eventID, sportID)
 	}
 	tournamentID, err := res.LastInsertId()
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
 	roundMatchIDs := make([][]int64, len(tournamentData.Rounds))
 	matchMetas := map[int64]models.Match{}
 	insertedMatches := make([]models.Match, 0, len(tournamentData.Matches))
+	insertedTeam1IDs := make([]sql.NullInt64, 0, len(tournamentData.Matches))
+	byeTeamIDs := map[int64]int64{}
 	matchValuePlaceholders := make([]string, 0, len(tournamentData.Matches))
-	matchArgs := make([]interface{}, 0, len(tournamentData.Matches)*10)
+	matchArgs := make([]interface{}, 0, len(tournamentData.Matches)*12)
 
 	for _, match := range tournamentData.Matches {
 		var team1ID, team2ID sql.NullInt64
@@ -1168,36 +1289,45 @@ func (r *tournamentRepository) SaveTournament(eventID int, sportID int, sportNam
 		} else {
 			loserBracketBlock = nil
 		}
-		matchValuePlaceholders = append(matchValuePlaceholders, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		status := "pending"
+		if match.IsBye {
+			status = models.MatchStatusBye
+		}
+		var leagueGroup interface{}
+		if match.LeagueGroup != "" {
+			leagueGroup = match.LeagueGroup
+		}
+		matchValuePlaceholders = append(matchValuePlaceholders, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 		matchArgs = append(matchArgs,
 			tournamentID,
 			match.RoundIndex,
 			match.Order,
 			team1ID,
 			team2ID,
-			"pending",
+			status,
 			match.IsBronzeMatch,
 			match.IsLoserBracketMatch,
 			loserBracketRound,
 			loserBracketBlock,
+			match.IsLeagueMatch,
+			leagueGroup,
 		)
 		insertedMatches = append(insertedMatches, match)
+		insertedTeam1IDs = append(insertedTeam1IDs, team1ID)
 	}
 
 	if len(insertedMatches) > 0 {
 		// #nosec G202 -- each value tuple is a static placeholder template and matchArgs are bound.
 		res, err := tx.Exec(
-			"INSERT INTO matches (tournament_id, round, match_number_in_round, team1_id, team2_id, status, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block) VALUES "+strings.Join(matchValuePlaceholders, ","),
+			"INSERT INTO matches (tournament_id, round, match_number_in_round, team1_id, team2_id, status, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, is_league_match, league_group) VALUES "+strings.Join(matchValuePlaceholders, ","),
 			matchArgs...,
 		)
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
 
 		firstMatchID, err := res.LastInsertId()
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
 
@@ -1213,6 +1343,9 @@ func (r *tournamentRepository) SaveTournament(eventID int, sportID int, sportNam
 			}
 			roundMatchIDs[match.RoundIndex] = append(roundMatchIDs[match.RoundIndex], matchID)
 			matchMetas[matchID] = match
+			if match.IsBye && insertedTeam1IDs[i].Valid {
+				byeTeamIDs[matchID] = insertedTeam1IDs[i].Int64
+			}
 		}
 	}
 
@@ -1222,12 +1355,23 @@ func (r *tournamentRepository) SaveTournament(eventID int, sportID int, sportNam
 	}
 	nextMatchLinks := make([]nextMatchLink, 0)
 
+	type byeAdvancement struct {
+		nextMatchID int64
+		teamID      int64
+		isTeam1     bool
+	}
+	byeAdvancements := make([]byeAdvancement, 0, len(byeTeamIDs))
+
 	for i := 0; i < len(roundMatchIDs)-1; i++ {
 		for j, matchID := range roundMatchIDs[i] {
 			if match, ok := matchMetas[matchID]; ok && match.IsBronzeMatch {
 				continue
 			}
 			match := matchMetas[matchID]
+			// リーグ戦の試合は勝ち上がりがないため次の試合と結ばない
+			if match.IsLeagueMatch {
+				continue
+			}
 
 			// 敗者戦の試合の場合は、ブロックとラウンドを考慮して次の試合を決定
 			if match.IsLoserBracketMatch && match.LoserBracketRound != nil && *match.LoserBracketRound == 1 {
@@ -1258,6 +1402,10 @@ func (r *tournamentRepository) SaveTournament(eventID int, sportID int, sportNam
 				if i+1 < len(roundMatchIDs) && j/2 < len(roundMatchIDs[i+1]) {
 					nextMatchID := roundMatchIDs[i+1][j/2]
 					nextMatchLinks = append(nextMatchLinks, nextMatchLink{matchID: matchID, nextMatchID: nextMatchID})
+					// 不戦勝のチームは次の試合の対応する枠へそのまま勝ち上がる
+					if teamID, ok := byeTeamIDs[matchID]; ok {
+						byeAdvancements = append(byeAdvancements, byeAdvancement{nextMatchID: nextMatchID, teamID: teamID, isTeam1: j%2 == 0})
+					}
 				}
 			}
 		}
@@ -1281,12 +1429,22 @@ func (r *tournamentRepository) SaveTournament(eventID int, sportID int, sportNam
 			strings.TrimRight(strings.Repeat("?,", len(whereArgs)), ","),
 		)
 		if _, err := tx.Exec(query, updateArgs...); err != nil {
-			tx.Rollback()
 			return err
 		}
 	}
 
-	return tx.Commit()
+	for _, advancement := range byeAdvancements {
+		column := "team2_id"
+		if advancement.isTeam1 {
+			column = "team1_id"
+		}
+		// #nosec G202 -- column is one of two fixed column names.
+		if _, err := tx.Exec("UPDATE matches SET "+column+" = ? WHERE id = ?", advancement.teamID, advancement.nextMatchID); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (r *tournamentRepository) DeleteTournamentsByEventID(eventID int) error {
@@ -1344,10 +1502,17 @@ func (r *tournamentRepository) DeleteTournamentsByEventAndSportID(eventID int, s
 	if err != nil {
 		return err
 	}
+	if err := deleteSportTournaments(tx, eventID, sportID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
 
+// deleteSportTournaments は競技のトーナメントと試合を tx の中で削除する
+func deleteSportTournaments(tx *sql.Tx, eventID int, sportID int) error {
 	rows, err := tx.Query("SELECT id FROM tournaments WHERE event_id = ? AND sport_id = ?", eventID, sportID)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 	defer rows.Close()
@@ -1356,7 +1521,6 @@ func (r *tournamentRepository) DeleteTournamentsByEventAndSportID(eventID int, s
 	for rows.Next() {
 		var id int
 		if err := rows.Scan(&id); err != nil {
-			tx.Rollback()
 			return err
 		}
 		tournamentIDs = append(tournamentIDs, id)
@@ -1372,40 +1536,58 @@ func (r *tournamentRepository) DeleteTournamentsByEventAndSportID(eventID int, s
 
 		// #nosec G201 -- qMarks contains only placeholders generated from integer database IDs.
 		query := fmt.Sprintf("DELETE FROM matches WHERE tournament_id IN (%s)", qMarks)
-		_, err = tx.Exec(query, args...)
-		if err != nil {
-			tx.Rollback()
+		if _, err := tx.Exec(query, args...); err != nil {
 			return err
 		}
 
 		query = fmt.Sprintf("DELETE FROM tournaments WHERE id IN (%s)", qMarks)
-		_, err = tx.Exec(query, args...)
-		if err != nil {
-			tx.Rollback()
+		if _, err := tx.Exec(query, args...); err != nil {
 			return err
 		}
 	}
-
-	return tx.Commit()
+	return nil
 }
 
-func (r *tournamentRepository) UpdateMatchStartTime(matchID int, startTime string) error {
+func (r *tournamentRepository) UpdateMatchStartTime(matchID int, startTime string, actorUserID string) error {
 	if startTime != "" {
 		// Only change status to 'scheduled' if it is currently 'pending'.
-		_, err := r.db.Exec("UPDATE matches SET match_start_time = ?, status = CASE WHEN status = 'pending' THEN 'scheduled' ELSE status END WHERE id = ?", startTime, matchID)
-		return err
+		return r.updateMatchWithRevision(matchID, actorUserID, "UPDATE matches SET match_start_time = ?, status = CASE WHEN status = 'pending' THEN 'scheduled' ELSE status END WHERE id = ?", startTime, matchID)
 	}
 	// If startTime is empty, just update the time.
-	_, err := r.db.Exec("UPDATE matches SET match_start_time = ? WHERE id = ?", startTime, matchID)
-	return err
+	return r.updateMatchWithRevision(matchID, actorUserID, "UPDATE matches SET match_start_time = ? WHERE id = ?", startTime, matchID)
 }
 
-func (r *tournamentRepository) UpdateMatchRainyModeStartTime(matchID int, rainyModeStartTime string) error {
-	_, err := r.db.Exec("UPDATE matches SET rainy_mode_start_time = ? WHERE id = ?", rainyModeStartTime, matchID)
-	return err
+func (r *tournamentRepository) UpdateMatchRainyModeStartTime(matchID int, rainyModeStartTime string, actorUserID string) error {
+	return r.updateMatchWithRevision(matchID, actorUserID, "UPDATE matches SET rainy_mode_start_time = ? WHERE id = ?", rainyModeStartTime, matchID)
+}
+
+// updateMatchWithRevision は1試合の開始時刻を更新し、変更後の状態をリビジョンとして残す
+func (r *tournamentRepository) updateMatchWithRevision(matchID int, actorUserID string, query string, args ...interface{}) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := ensureMatchBaselines(tx, "m.id = ?", matchID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	change := newMatchChange(models.MatchRevisionStartTime, actorUserID)
+	change.touch(matchID)
+	if err := change.record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (r *tournamentRepository) UpdateMatchResult(matchID, team1Score, team2Score, winnerIDInput int) error {
+func (r *tournamentRepository) UpdateMatchResult(matchID, team1Score, team2Score, winnerIDInput int, resultType string, forfeitingTeamID int, actorUserID string) error {
+	audit := scoreAudit{operation: models.ScoreOperationMatchResult, actorUserID: actorUserID}
+	change := newMatchChange(models.MatchRevisionMatchResult, actorUserID)
+	resultType = normalizeResultType(resultType)
+
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
@@ -1416,46 +1598,63 @@ func (r *tournamentRepository) UpdateMatchResult(matchID, team1Score, team2Score
 	if err != nil {
 		return err
 	}
+	if match.Status == models.MatchStatusBye {
+		return ErrByeMatchResult
+	}
+	if match.Status == models.MatchStatusCancelled {
+		return ErrCancelledMatchResult
+	}
 
 	// 雨天時モードのチェック: 昼競技とグラウンド競技をブロック
 	eventID, sportID, location, err := r.getTournamentMetadata(tx, match.TournamentID)
-	if err == nil {
-		var isRainyMode bool
-		err := tx.QueryRow("SELECT is_rainy_mode FROM events WHERE id = ?", eventID).Scan(&isRainyMode)
-		if err == nil && isRainyMode {
-			if location == "noon_game" || location == "ground" {
-				return fmt.Errorf("雨天時モードでは、昼競技とグラウンド競技の試合結果を更新できません")
-			}
-		}
-	} else {
+	if err != nil {
+		return err
+	}
+	if err := r.checkRainyModeResultEntry(tx, match.ID, location); err != nil {
 		return err
 	}
 
-	alreadyFinished := match.WinnerID.Valid && match.Status == "finished"
-
-	var winnerID, loserID int64
-	if team1Score > team2Score {
-		winnerID = match.Team1ID.Int64
-		loserID = match.Team2ID.Int64
-	} else if team2Score > team1Score {
-		winnerID = match.Team2ID.Int64
-		loserID = match.Team1ID.Int64
-	} else {
-		winnerID = int64(winnerIDInput)
-		if winnerID == match.Team1ID.Int64 {
-			loserID = match.Team2ID.Int64
-		} else {
-			loserID = match.Team1ID.Int64
+	// リーグ戦は引き分けがあり勝ち上がりもないため、専用の処理で結果と得点を記録する
+	if match.IsLeagueMatch {
+		if resultType != models.MatchResultNormal {
+			return fmt.Errorf("%w: リーグ戦の試合にはスコアで結果を入力してください", ErrInvalidMatchResult)
+		}
+		if err := ensureMatchBaselines(tx, sportMatchCondition, eventID, sportID); err != nil {
+			return err
+		}
+		if err := r.recordLeagueMatchResult(tx, match, eventID, location, team1Score, team2Score, audit); err != nil {
+			return err
 		}
+		change.touch(match.ID)
+		if err := change.record(tx); err != nil {
+			return err
+		}
+		return tx.Commit()
 	}
 
-	_, err = tx.Exec("UPDATE matches SET team1_score = ?, team2_score = ?, status = 'finished' WHERE id = ?", team1Score, team2Score, matchID)
+	alreadyFinished := match.WinnerID.Valid && match.Status == "finished"
+
+	if err := checkSingleTeamWalkover(tx, match, resultType, forfeitingTeamID); err != nil {
+		return err
+	}
+	winnerID, loserID, err := resolveMatchResult(match, team1Score, team2Score, winnerIDInput, resultType, forfeitingTeamID)
 	if err != nil {
 		return err
 	}
+	// 不出場・失格のチームは3位決定戦・敗者戦に回さず、枠を空けておく
+	loserContinues := loserID != 0 && models.MatchResultLoserContinues(resultType)
 
-	// Advance winner to the next match
-	if match.NextMatchID.Valid {
+	if err := ensureMatchBaselines(tx, sportMatchCondition, eventID, sportID); err != nil {
+		return err
+	}
+	if err := saveMatchResult(tx, matchID, team1Score, team2Score, resultType, forfeitingTeamID); err != nil {
+		return err
+	}
+	change.touch(matchID)
+	match.ResultType = resultType
+
+	// Advance winner to the next match（両者不戦敗は次の試合の枠を空けたままにする）
+	if match.NextMatchID.Valid && winnerID != 0 {
 		nextMatch, err := r.getMatchByID(tx, int(match.NextMatchID.Int64))
 		if err != nil {
 			return err
@@ -1469,6 +1668,7 @@ func (r *tournamentRepository) UpdateMatchResult(matchID, team1Score, team2Score
 		if err != nil {
 			return err
 		}
+		change.touch(nextMatch.ID)
 	}
 
 	var maxRound sql.NullInt64
@@ -1481,7 +1681,7 @@ func (r *tournamentRepository) UpdateMatchResult(matchID, team1Score, team2Score
 	}
 
 	// Handle bronze match for semi-final losers
-	if !match.IsBronzeMatch && totalRounds > 0 {
+	if !match.IsBronzeMatch && totalRounds > 0 && loserContinues {
 		// Semi-finals are in the second to last round
 		if match.Round == totalRounds-1 {
 			var bronzeMatchID int64
@@ -1505,12 +1705,13 @@ func (r *tournamentRepository) UpdateMatchResult(matchID, team1Score, team2Score
 				if err != nil {
 					return err
 				}
+				change.touch(bronzeMatch.ID)
 			}
 		}
 	}
 
 	// Handle loser bracket tournament assignment for first round losers (gym2 only)
-	if !match.IsBronzeMatch && !match.IsLoserBracketMatch && match.Round == 0 && location == "gym2" {
+	if !match.IsBronzeMatch && !match.IsLoserBracketMatch && match.Round == 0 && location == "gym2" && loserContinues {
 		// Get loser bracket tournaments (A and B blocks)
 		var loserBracketAID, loserBracketBID int64
 		errA := tx.QueryRow("SELECT id FROM tournaments WHERE event_id = ? AND sport_id = ? AND name LIKE ?", eventID, sportID, "%敗者戦Aブロック%").Scan(&loserBracketAID)
@@ -1559,6 +1760,7 @@ func (r *tournamentRepository) UpdateMatchResult(matchID, team1Score, team2Score
 					if err != nil {
 						return err
 					}
+					change.touch(int(targetMatchID))
 				} else if err != sql.ErrNoRows {
 					return err
 				}
@@ -1567,16 +1769,27 @@ func (r *tournamentRepository) UpdateMatchResult(matchID, team1Score, team2Score
 	}
 
 	if !alreadyFinished {
-		if err := r.applyScoring(tx, match, winnerID, loserID, totalRounds); err != nil {
+		rules, err := loadScoringRules(tx, eventID)
+		if err != nil {
+			return err
+		}
+		if err := r.applyScoring(tx, match, winnerID, loserID, totalRounds, rules, audit); err != nil {
 			return err
 		}
 	}
 
+	if err := change.record(tx); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
 // UpdateMatchResultForCorrection updates an already entered match result and corrects the next match teams
-func (r *tournamentRepository) UpdateMatchResultForCorrection(matchID, team1Score, team2Score, winnerIDInput int) error {
+func (r *tournamentRepository) UpdateMatchResultForCorrection(matchID, team1Score, team2Score, winnerIDInput int, resultType string, forfeitingTeamID int, actorUserID string) error {
+	audit := scoreAudit{operation: models.ScoreOperationMatchCorrection, actorUserID: actorUserID}
+	change := newMatchChange(models.MatchRevisionMatchCorrection, actorUserID)
+	resultType = normalizeResultType(resultType)
+
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
@@ -1595,156 +1808,106 @@ func (r *tournamentRepository) UpdateMatchResultForCorrection(matchID, team1Scor
 
 	// 雨天時モードのチェック: 昼競技とグラウンド競技をブロック
 	eventID, sportID, location, err := r.getTournamentMetadata(tx, match.TournamentID)
-	if err == nil {
-		var isRainyMode bool
-		err := tx.QueryRow("SELECT is_rainy_mode FROM events WHERE id = ?", eventID).Scan(&isRainyMode)
-		if err == nil && isRainyMode {
-			if location == "noon_game" || location == "ground" {
-				return fmt.Errorf("雨天時モードでは、昼競技とグラウンド競技の試合結果を更新できません")
-			}
-		}
-	} else {
+	if err != nil {
+		return err
+	}
+	if err := r.checkRainyModeResultEntry(tx, match.ID, location); err != nil {
 		return err
 	}
 
-	// 前回の勝者を取得
+	if match.IsLeagueMatch {
+		if resultType != models.MatchResultNormal {
+			return fmt.Errorf("%w: リーグ戦の試合にはスコアで結果を入力してください", ErrInvalidMatchResult)
+		}
+		if err := ensureMatchBaselines(tx, sportMatchCondition, eventID, sportID); err != nil {
+			return err
+		}
+		if err := r.recordLeagueMatchResult(tx, match, eventID, location, team1Score, team2Score, audit); err != nil {
+			return err
+		}
+		change.touch(match.ID)
+		if err := change.record(tx); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	// 前回の勝者を取得（両者不戦敗の場合は勝者なし）
+	previousResultType := normalizeResultType(match.ResultType)
 	previousWinnerID, err := r.inferStoredWinnerID(tx, match)
 	if err != nil {
 		return err
 	}
-	if previousWinnerID == 0 {
+	if previousWinnerID == 0 && previousResultType != models.MatchResultDoubleForfeit {
 		return fmt.Errorf("前回の勝者を特定できないため、この同点試合は修正できません")
 	}
 
-	// 前回の敗者を取得
-	previousLoserID := match.Team1ID.Int64
-	if previousLoserID == previousWinnerID {
-		previousLoserID = match.Team2ID.Int64
-	}
-
-	// 新しい勝者を決定
-	var newWinnerID, loserID int64
-	if team1Score > team2Score {
-		newWinnerID = match.Team1ID.Int64
-		loserID = match.Team2ID.Int64
-	} else if team2Score > team1Score {
-		newWinnerID = match.Team2ID.Int64
-		loserID = match.Team1ID.Int64
-	} else {
-		newWinnerID = int64(winnerIDInput)
-		if newWinnerID == match.Team1ID.Int64 {
-			loserID = match.Team2ID.Int64
-		} else {
-			loserID = match.Team1ID.Int64
+	// 前回3位決定戦・敗者戦に回した敗者を取得
+	var previousLoserID int64
+	if previousWinnerID != 0 && models.MatchResultLoserContinues(previousResultType) {
+		previousLoserID = match.Team1ID.Int64
+		if previousLoserID == previousWinnerID {
+			previousLoserID = match.Team2ID.Int64
 		}
 	}
 
-	// 勝者が変わらない場合は通常の更新メソッドと同じ処理
-	if previousWinnerID == newWinnerID {
-		_, err = tx.Exec("UPDATE matches SET team1_score = ?, team2_score = ?, status = 'finished' WHERE id = ?", team1Score, team2Score, matchID)
-		if err != nil {
-			return err
-		}
-		return tx.Commit()
+	// 新しい勝者を決定
+	if err := checkSingleTeamWalkover(tx, match, resultType, forfeitingTeamID); err != nil {
+		return err
 	}
-
-	// 前回の勝者と敗者のチーム情報を取得
-	previousWinnerTeam, err := r.getTeamByIDTx(tx, previousWinnerID)
+	newWinnerID, loserID, err := resolveMatchResult(match, team1Score, team2Score, winnerIDInput, resultType, forfeitingTeamID)
 	if err != nil {
 		return err
 	}
-	previousLoserTeam, err := r.getTeamByIDTx(tx, previousLoserID)
-	if err != nil {
+	var newLoserID int64
+	if loserID != 0 && models.MatchResultLoserContinues(resultType) {
+		newLoserID = loserID
+	}
+
+	if err := ensureMatchBaselines(tx, sportMatchCondition, eventID, sportID); err != nil {
 		return err
 	}
 
-	// 前回の勝者に付与された点数をリセット
-	if previousWinnerTeam != nil && previousLoserTeam != nil {
-		// 前回の点数を計算して減算
-		if err := r.revertScoring(tx, match, previousWinnerID, previousLoserID, eventID, location); err != nil {
+	// 勝者も結果の種別も変わらない場合はスコアだけを更新する
+	if previousWinnerID == newWinnerID && previousResultType == resultType {
+		if err := saveMatchResult(tx, matchID, team1Score, team2Score, resultType, forfeitingTeamID); err != nil {
+			return err
+		}
+		change.touch(matchID)
+		if err := change.record(tx); err != nil {
 			return err
 		}
+		return tx.Commit()
+	}
+
+	// 前回付与した点数を打ち消す
+	if err := r.revertScoring(tx, match.ID, audit); err != nil {
+		return err
 	}
 
 	// 試合結果を更新
-	_, err = tx.Exec("UPDATE matches SET team1_score = ?, team2_score = ?, status = 'finished' WHERE id = ?", team1Score, team2Score, matchID)
-	if err != nil {
+	if err := saveMatchResult(tx, matchID, team1Score, team2Score, resultType, forfeitingTeamID); err != nil {
 		return err
 	}
+	change.touch(matchID)
+	match.ResultType = resultType
 
-	// 次の試合から前の勝者を削除し、新しい勝者を設定
-	if match.NextMatchID.Valid {
+	// 次の試合から前の勝者を外し、新しい勝者を設定（両者不戦敗なら枠を空ける）
+	if match.NextMatchID.Valid && previousWinnerID != newWinnerID {
 		nextMatch, err := r.getMatchByID(tx, int(match.NextMatchID.Int64))
 		if err != nil {
 			return err
 		}
 
-		// 次の試合が既に終了している場合、その試合の結果を無効化
-		if nextMatch.WinnerID.Valid && nextMatch.Status == "finished" {
-			// 次の試合の勝者と敗者を取得
-			nextMatchWinnerID := nextMatch.WinnerID.Int64
-			nextMatchLoserID := nextMatch.Team1ID.Int64
-			if nextMatchLoserID == nextMatchWinnerID {
-				nextMatchLoserID = nextMatch.Team2ID.Int64
-			}
-
-			// 次の試合の勝者に付与された点数をリセット
-			if nextMatchWinnerID != 0 && nextMatchLoserID != 0 {
-				if err := r.revertScoring(tx, nextMatch, nextMatchWinnerID, nextMatchLoserID, eventID, location); err != nil {
-					return err
-				}
-			}
-
-			// 次の試合の結果を無効化
-			_, err = tx.Exec("UPDATE matches SET team1_score = NULL, team2_score = NULL, status = 'pending' WHERE id = ?", nextMatch.ID)
-			if err != nil {
-				return err
-			}
-
-			// さらにその次の試合も連鎖的に無効化（再帰的に処理）
-			if nextMatch.NextMatchID.Valid {
-				if err := r.invalidateSubsequentMatches(tx, int(nextMatch.NextMatchID.Int64), eventID, location); err != nil {
-					return err
-				}
-			}
-		}
-
-		// 前の勝者を次の試合から削除
-		if nextMatch.Team1ID.Valid && nextMatch.Team1ID.Int64 == previousWinnerID {
-			_, err = tx.Exec("UPDATE matches SET team1_id = NULL WHERE id = ?", nextMatch.ID)
-			if err != nil {
-				return err
-			}
-		} else if nextMatch.Team2ID.Valid && nextMatch.Team2ID.Int64 == previousWinnerID {
-			_, err = tx.Exec("UPDATE matches SET team2_id = NULL WHERE id = ?", nextMatch.ID)
-			if err != nil {
-				return err
-			}
+		// 次の試合が既に終了している場合、その試合とそれ以降の結果を無効化
+		if err := r.invalidateSubsequentMatches(tx, nextMatch.ID, audit, change); err != nil {
+			return err
 		}
 
-		// 新しい勝者を次の試合に設定
-		if !nextMatch.Team1ID.Valid {
-			_, err = tx.Exec("UPDATE matches SET team1_id = ? WHERE id = ?", newWinnerID, nextMatch.ID)
-		} else if !nextMatch.Team2ID.Valid {
-			_, err = tx.Exec("UPDATE matches SET team2_id = ? WHERE id = ?", newWinnerID, nextMatch.ID)
-		} else {
-			// 両方のチームが既に設定されている場合は、前の勝者の位置に新しい勝者を設定
-			if nextMatch.Team1ID.Int64 == previousWinnerID {
-				_, err = tx.Exec("UPDATE matches SET team1_id = ? WHERE id = ?", newWinnerID, nextMatch.ID)
-			} else if nextMatch.Team2ID.Int64 == previousWinnerID {
-				_, err = tx.Exec("UPDATE matches SET team2_id = ? WHERE id = ?", newWinnerID, nextMatch.ID)
-			} else {
-				// 前の勝者が次の試合にいない場合（既に削除されているなど）、空いている位置に設定
-				if !nextMatch.Team1ID.Valid {
-					_, err = tx.Exec("UPDATE matches SET team1_id = ? WHERE id = ?", newWinnerID, nextMatch.ID)
-				} else {
-					_, err = tx.Exec("UPDATE matches SET team2_id = ? WHERE id = ?", newWinnerID, nextMatch.ID)
-				}
-			}
-		}
-		if err != nil {
+		if err := replaceSlotTeam(tx, nextMatch, previousWinnerID, newWinnerID); err != nil {
 			return err
 		}
+		change.touch(nextMatch.ID)
 	}
 
 	// 敗者戦への進出も更新する必要がある場合
@@ -1758,47 +1921,26 @@ func (r *tournamentRepository) UpdateMatchResultForCorrection(matchID, team1Scor
 	}
 
 	// Handle bronze match for semi-final losers
-	if !match.IsBronzeMatch && totalRounds > 0 {
+	if !match.IsBronzeMatch && totalRounds > 0 && previousLoserID != newLoserID {
 		// Semi-finals are in the second to last round
 		if match.Round == totalRounds-1 {
 			var bronzeMatchID int64
 			err := tx.QueryRow("SELECT id FROM matches WHERE tournament_id = ? AND is_bronze_match = TRUE", match.TournamentID).Scan(&bronzeMatchID)
 			if err == nil {
-				// 前の敗者を敗者戦から削除
+				// 前の敗者を3位決定戦から外し、新しい敗者を設定
 				bronzeMatch, err := r.getMatchByID(tx, int(bronzeMatchID))
 				if err == nil {
-					if bronzeMatch.Team1ID.Valid && bronzeMatch.Team1ID.Int64 == previousLoserID {
-						_, err = tx.Exec("UPDATE matches SET team1_id = NULL WHERE id = ?", bronzeMatchID)
-					} else if bronzeMatch.Team2ID.Valid && bronzeMatch.Team2ID.Int64 == previousLoserID {
-						_, err = tx.Exec("UPDATE matches SET team2_id = NULL WHERE id = ?", bronzeMatchID)
-					}
-					if err != nil {
-						return err
-					}
-
-					// 新しい敗者を敗者戦に設定
-					if !bronzeMatch.Team1ID.Valid {
-						_, err = tx.Exec("UPDATE matches SET team1_id = ? WHERE id = ?", loserID, bronzeMatchID)
-					} else if !bronzeMatch.Team2ID.Valid {
-						_, err = tx.Exec("UPDATE matches SET team2_id = ? WHERE id = ?", loserID, bronzeMatchID)
-					} else {
-						// 前の敗者の位置に新しい敗者を設定
-						if bronzeMatch.Team1ID.Int64 == previousLoserID {
-							_, err = tx.Exec("UPDATE matches SET team1_id = ? WHERE id = ?", loserID, bronzeMatchID)
-						} else if bronzeMatch.Team2ID.Int64 == previousLoserID {
-							_, err = tx.Exec("UPDATE matches SET team2_id = ? WHERE id = ?", loserID, bronzeMatchID)
-						}
-					}
-					if err != nil {
+					if err := replaceSlotTeam(tx, bronzeMatch, previousLoserID, newLoserID); err != nil {
 						return err
 					}
+					change.touch(bronzeMatch.ID)
 				}
 			}
 		}
 	}
 
 	// Handle loser bracket tournament assignment for first round losers (gym2 only)
-	if !match.IsBronzeMatch && !match.IsLoserBracketMatch && match.Round == 0 && location == "gym2" {
+	if !match.IsBronzeMatch && !match.IsLoserBracketMatch && match.Round == 0 && location == "gym2" && previousLoserID != newLoserID {
 		// Get loser bracket tournaments (A and B blocks)
 		var loserBracketAID, loserBracketBID int64
 		errA := tx.QueryRow("SELECT id FROM tournaments WHERE event_id = ? AND sport_id = ? AND name LIKE ?", eventID, sportID, "%敗者戦Aブロック%").Scan(&loserBracketAID)
@@ -1834,30 +1976,18 @@ func (r *tournamentRepository) UpdateMatchResultForCorrection(matchID, team1Scor
 				).Scan(&targetMatchID)
 
 				if err == nil {
-					// 前の敗者を敗者戦から削除
+					// 敗者戦の枠が空いているか前の敗者が入っている場合だけ、新しい敗者に入れ替える
 					targetMatch, err := r.getMatchByID(tx, int(targetMatchID))
 					if err == nil {
+						slot := targetMatch.Team2ID
 						if isTeam1 {
-							if targetMatch.Team1ID.Valid && targetMatch.Team1ID.Int64 == previousLoserID {
-								_, err = tx.Exec("UPDATE matches SET team1_id = NULL WHERE id = ?", targetMatchID)
-							}
-						} else {
-							if targetMatch.Team2ID.Valid && targetMatch.Team2ID.Int64 == previousLoserID {
-								_, err = tx.Exec("UPDATE matches SET team2_id = NULL WHERE id = ?", targetMatchID)
-							}
-						}
-						if err != nil {
-							return err
-						}
-
-						// 新しい敗者を敗者戦に設定
-						if isTeam1 {
-							_, err = tx.Exec("UPDATE matches SET team1_id = ? WHERE id = ?", loserID, targetMatchID)
-						} else {
-							_, err = tx.Exec("UPDATE matches SET team2_id = ? WHERE id = ?", loserID, targetMatchID)
+							slot = targetMatch.Team1ID
 						}
-						if err != nil {
-							return err
+						if !slot.Valid || slot.Int64 == previousLoserID {
+							if err := setMatchSlot(tx, targetMatch.ID, isTeam1, newLoserID); err != nil {
+								return err
+							}
+							change.touch(targetMatch.ID)
 						}
 					}
 				} else if err != sql.ErrNoRows {
@@ -1868,100 +1998,139 @@ func (r *tournamentRepository) UpdateMatchResultForCorrection(matchID, team1Scor
 	}
 
 	// 新しい勝者に点数を付与（totalRoundsは既に取得済み）
-	if err := r.applyScoring(tx, match, newWinnerID, loserID, totalRounds); err != nil {
+	rules, err := loadScoringRules(tx, eventID)
+	if err != nil {
+		return err
+	}
+	if err := r.applyScoring(tx, match, newWinnerID, loserID, totalRounds, rules, audit); err != nil {
 		return err
 	}
 
+	if err := change.record(tx); err != nil {
+		return err
+	}
 	return tx.Commit()
 }
 
-// revertScoring reverts the points that were awarded to the previous winner and loser
-func (r *tournamentRepository) revertScoring(tx *sql.Tx, match *models.MatchDB, previousWinnerID, previousLoserID int64, eventID int, location string) error {
-	if previousWinnerID == 0 || previousLoserID == 0 {
-		return nil
+// normalizeResultType は結果の種別の指定がなければ通常の結果とみなす
+func normalizeResultType(resultType string) string {
+	if resultType == "" {
+		return models.MatchResultNormal
 	}
+	return resultType
+}
 
-	if location == "noon_game" {
-		return nil
+// resolveMatchResult は入力された結果から勝者と敗者を決める。
+// 棄権・不出場・失格は指定されたチームを敗者とし、両者不戦敗は勝者・敗者ともに0を返す。
+// 相手のいない試合の不戦勝は残ったチームを勝者とし、敗者は0を返す。
+func resolveMatchResult(match *models.MatchDB, team1Score, team2Score, winnerIDInput int, resultType string, forfeitingTeamID int) (int64, int64, error) {
+	switch {
+	case !models.IsValidMatchResultType(resultType):
+		return 0, 0, fmt.Errorf("%w: 不明な結果の種別です: %s", ErrInvalidMatchResult, resultType)
+	case resultType == models.MatchResultDoubleForfeit:
+		return 0, 0, nil
+	case isSingleTeamWalkover(match, resultType, forfeitingTeamID):
+		if match.Team1ID.Valid {
+			return match.Team1ID.Int64, 0, nil
+		}
+		return match.Team2ID.Int64, 0, nil
+	case models.MatchResultNeedsForfeitingTeam(resultType):
+		if !match.Team1ID.Valid || !match.Team2ID.Valid {
+			return 0, 0, fmt.Errorf("%w: 対戦チームが揃っていない試合には棄権・不出場・失格を入力できません", ErrInvalidMatchResult)
+		}
+		switch int64(forfeitingTeamID) {
+		case match.Team1ID.Int64:
+			return match.Team2ID.Int64, match.Team1ID.Int64, nil
+		case match.Team2ID.Int64:
+			return match.Team1ID.Int64, match.Team2ID.Int64, nil
+		}
+		return 0, 0, fmt.Errorf("%w: 棄権・不出場・失格のチームはこの試合の対戦チームから選んでください", ErrInvalidMatchResult)
 	}
 
-	columns, ok := locationColumns[location]
-	if !ok {
-		return nil
+	if team1Score > team2Score {
+		return match.Team1ID.Int64, match.Team2ID.Int64, nil
 	}
-
-	previousWinnerTeam, err := r.getTeamByIDTx(tx, previousWinnerID)
-	if err != nil {
-		return err
+	if team2Score > team1Score {
+		return match.Team2ID.Int64, match.Team1ID.Int64, nil
 	}
-	previousLoserTeam, err := r.getTeamByIDTx(tx, previousLoserID)
-	if err != nil {
-		return err
+	winnerID := int64(winnerIDInput)
+	if winnerID == match.Team1ID.Int64 {
+		return winnerID, match.Team2ID.Int64, nil
 	}
+	return winnerID, match.Team1ID.Int64, nil
+}
+
+// isSingleTeamWalkover は棄権したチームを指定せずに、片方のチームしかいない試合へ不戦勝を入力したかを返す
+func isSingleTeamWalkover(match *models.MatchDB, resultType string, forfeitingTeamID int) bool {
+	return resultType == models.MatchResultWalkover && forfeitingTeamID == 0 && match.Team1ID.Valid != match.Team2ID.Valid
+}
 
-	if previousWinnerTeam == nil || previousLoserTeam == nil {
+// checkSingleTeamWalkover は片方のチームだけの不戦勝を、前の試合が両者不戦敗で相手が来なくなった場合に限る
+func checkSingleTeamWalkover(tx *sql.Tx, match *models.MatchDB, resultType string, forfeitingTeamID int) error {
+	if !isSingleTeamWalkover(match, resultType, forfeitingTeamID) {
 		return nil
 	}
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM matches WHERE next_match_id = ? AND status = 'finished' AND result_type = ?", match.ID, models.MatchResultDoubleForfeit).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: 相手チームが決まっていない試合には不戦勝を入力できません", ErrInvalidMatchResult)
+	}
+	return nil
+}
 
-	if previousWinnerTeam.EventID != eventID || previousLoserTeam.EventID != eventID {
-		return nil
+// saveMatchResult は試合結果を保存して終了にする。不出場の試合はスコアを持たない。
+func saveMatchResult(tx *sql.Tx, matchID, team1Score, team2Score int, resultType string, forfeitingTeamID int) error {
+	var score1, score2, forfeiting interface{}
+	if models.MatchResultKeepsScores(resultType) {
+		score1, score2 = team1Score, team2Score
+	}
+	if models.MatchResultNeedsForfeitingTeam(resultType) && forfeitingTeamID != 0 {
+		forfeiting = forfeitingTeamID
 	}
+	_, err := tx.Exec("UPDATE matches SET team1_score = ?, team2_score = ?, result_type = ?, forfeiting_team_id = ?, status = 'finished' WHERE id = ?", score1, score2, resultType, forfeiting, matchID)
+	return err
+}
 
-	// 敗者戦二回戦の場合、前回の勝者から10点減算
-	if match.IsLoserBracketMatch && match.LoserBracketRound.Valid && match.LoserBracketRound.Int64 == 2 {
-		if location == "gym2" {
-			if err := r.subtractPoints(tx, eventID, previousWinnerTeam.ClassID, "gym2_loser_bracket_champion_points", 10, match.ID); err != nil {
-				return err
-			}
-			return nil
-		}
-	}
-
-	// ラウンド勝利の点数を減算
-	var maxRound sql.NullInt64
-	if err := tx.QueryRow("SELECT MAX(round) FROM matches WHERE tournament_id = ?", match.TournamentID).Scan(&maxRound); err != nil {
-		return err
+// setMatchSlot は試合の team1_id か team2_id を設定する。teamID が0なら枠を空ける。
+func setMatchSlot(tx *sql.Tx, matchID int, isTeam1 bool, teamID int64) error {
+	var value interface{}
+	if teamID != 0 {
+		value = teamID
 	}
-	totalRounds := 0
-	if maxRound.Valid {
-		totalRounds = int(maxRound.Int64)
-	}
-
-	if match.Round >= 0 && match.Round < len(columns.win) {
-		column := columns.win[match.Round]
-		if err := r.subtractPoints(tx, eventID, previousWinnerTeam.ClassID, column, 10, match.ID); err != nil {
-			return err
-		}
-	}
-
-	if totalRounds <= 0 {
-		return nil
+	query := "UPDATE matches SET team2_id = ? WHERE id = ?"
+	if isTeam1 {
+		query = "UPDATE matches SET team1_id = ? WHERE id = ?"
 	}
+	_, err := tx.Exec(query, value, matchID)
+	return err
+}
 
-	// 3位決定戦の場合
-	if isEffectiveBronzeMatch(match, totalRounds) {
-		if err := r.subtractPoints(tx, eventID, previousWinnerTeam.ClassID, columns.champion, 50, match.ID); err != nil {
-			return err
-		}
-		if err := r.subtractPoints(tx, eventID, previousLoserTeam.ClassID, columns.champion, 40, match.ID); err != nil {
-			return err
-		}
+// replaceSlotTeam は試合の枠にいる previousID のチームを newID に入れ替える。
+// previousID が枠にいなければ空いている枠に newID を入れ、newID が0なら枠を空ける。
+func replaceSlotTeam(tx *sql.Tx, target *models.MatchDB, previousID, newID int64) error {
+	switch {
+	case previousID != 0 && target.Team1ID.Valid && target.Team1ID.Int64 == previousID:
+		return setMatchSlot(tx, target.ID, true, newID)
+	case previousID != 0 && target.Team2ID.Valid && target.Team2ID.Int64 == previousID:
+		return setMatchSlot(tx, target.ID, false, newID)
+	case newID == 0:
 		return nil
+	case !target.Team1ID.Valid:
+		return setMatchSlot(tx, target.ID, true, newID)
+	case !target.Team2ID.Valid:
+		return setMatchSlot(tx, target.ID, false, newID)
 	}
-
-	// 決勝の場合
-	if match.Round == totalRounds {
-		if err := r.subtractPoints(tx, eventID, previousWinnerTeam.ClassID, columns.champion, 80, match.ID); err != nil {
-			return err
-		}
-		if err := r.subtractPoints(tx, eventID, previousLoserTeam.ClassID, columns.champion, 60, match.ID); err != nil {
-			return err
-		}
-	}
-
 	return nil
 }
 
+// revertScoring は試合に付与済みの得点を記録ごとに打ち消す。
+// 付与時の配点ルールが変更されていても正しく戻せるよう、score_logs に残っている点数をそのまま打ち消す。
+func (r *tournamentRepository) revertScoring(tx *sql.Tx, matchID int, audit scoreAudit) error {
+	return reverseScoreLogs(tx, audit, "l.source_match_id = ?", matchID)
+}
+
 func isEffectiveBronzeMatch(match *models.MatchDB, totalRounds int) bool {
 	if match == nil {
 		return false
@@ -1977,7 +2146,7 @@ func isEffectiveBronzeMatch(match *models.MatchDB, totalRounds int) bool {
 
 // invalidateSubsequentMatches invalidates all subsequent matches that depend on the given match
 // This is used when a match result is corrected and subsequent matches need to be invalidated
-func (r *tournamentRepository) invalidateSubsequentMatches(tx *sql.Tx, matchID int, eventID int, location string) error {
+func (r *tournamentRepository) invalidateSubsequentMatches(tx *sql.Tx, matchID int, audit scoreAudit, change *matchChange) error {
 	match, err := r.getMatchByID(tx, matchID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -1986,34 +2155,26 @@ func (r *tournamentRepository) invalidateSubsequentMatches(tx *sql.Tx, matchID i
 		return err
 	}
 
-	// 既に終了していない場合は何もしない
-	if !match.WinnerID.Valid || match.Status != "finished" {
+	// 既に終了していない場合は何もしない（両者不戦敗のように勝者のいない試合も無効化する）
+	if match.Status != "finished" {
 		return nil
 	}
 
-	// この試合の勝者と敗者を取得
-	winnerID := match.WinnerID.Int64
-	loserID := match.Team1ID.Int64
-	if loserID == winnerID {
-		loserID = match.Team2ID.Int64
-	}
-
-	// この試合の勝者に付与された点数をリセット
-	if winnerID != 0 && loserID != 0 {
-		if err := r.revertScoring(tx, match, winnerID, loserID, eventID, location); err != nil {
-			return err
-		}
+	// この試合で付与された点数をリセット
+	if err := r.revertScoring(tx, match.ID, audit); err != nil {
+		return err
 	}
 
 	// この試合の結果を無効化
-	_, err = tx.Exec("UPDATE matches SET team1_score = NULL, team2_score = NULL, status = 'pending' WHERE id = ?", matchID)
+	_, err = tx.Exec("UPDATE matches SET team1_score = NULL, team2_score = NULL, result_type = 'normal', forfeiting_team_id = NULL, status = 'pending' WHERE id = ?", matchID)
 	if err != nil {
 		return err
 	}
+	change.touch(matchID)
 
 	// さらにその次の試合も連鎖的に無効化（再帰的に処理）
 	if match.NextMatchID.Valid {
-		if err := r.invalidateSubsequentMatches(tx, int(match.NextMatchID.Int64), eventID, location); err != nil {
+		if err := r.invalidateSubsequentMatches(tx, int(match.NextMatchID.Int64), audit, change); err != nil {
 			return err
 		}
 	}
@@ -2027,9 +2188,294 @@ func (r *tournamentRepository) GetTournamentIDByMatchID(matchID int) (int, error
 	return tournamentID, err
 }
 
+// RecalculateScores はイベントの試合由来の得点をすべて打ち消し、終了済みの試合結果を
+// 現在の配点ルールで付け直す。出席点・アンケート得点・MIC得点も、記録済みの出席者数・
+// 提出数・投票から計算し直す。途中で失敗した場合はすべて元に戻るよう1トランザクションで行う。
+func (r *tournamentRepository) RecalculateScores(eventID int, actorUserID string) (*models.ScoreRecalculationResult, error) {
+	audit := scoreAudit{operation: models.ScoreOperationRecalculation, actorUserID: actorUserID}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT
+			m.id,
+			m.tournament_id,
+			m.round,
+			m.match_number_in_round,
+			m.team1_id,
+			m.team2_id,
+			m.team1_score,
+			m.team2_score,
+			CASE
+				WHEN m.forfeiting_team_id = m.team1_id THEN m.team2_id
+				WHEN m.forfeiting_team_id = m.team2_id THEN m.team1_id
+				WHEN m.result_type = 'walkover' AND m.forfeiting_team_id IS NULL THEN COALESCE(m.team1_id, m.team2_id)
+				WHEN m.team1_score > m.team2_score THEN m.team1_id
+				WHEN m.team2_score > m.team1_score THEN m.team2_id
+				ELSE NULL
+			END AS winner_team_id,
+			m.status,
+			m.next_match_id,
+			m.is_bronze_match,
+			m.is_loser_bracket_match,
+			m.loser_bracket_round,
+			m.is_league_match,
+			m.result_type,
+			m.forfeiting_team_id,
+			es.location
+		FROM matches m
+		JOIN tournaments t ON m.tournament_id = t.id
+		LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id
+		WHERE t.event_id = ?
+		ORDER BY m.tournament_id, m.round, m.match_number_in_round
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*models.MatchDB
+	locations := make(map[int]string)
+	maxRounds := make(map[int]int)
+	for rows.Next() {
+		var m models.MatchDB
+		var location sql.NullString
+		if err := rows.Scan(&m.ID, &m.TournamentID, &m.Round, &m.MatchNumberInRound, &m.Team1ID, &m.Team2ID, &m.Team1Score, &m.Team2Score, &m.WinnerID, &m.Status, &m.NextMatchID, &m.IsBronzeMatch, &m.IsLoserBracketMatch, &m.LoserBracketRound, &m.IsLeagueMatch, &m.ResultType, &m.ForfeitingTeamID, &location); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		locations[m.TournamentID] = location.String
+		if m.Round > maxRounds[m.TournamentID] {
+			maxRounds[m.TournamentID] = m.Round
+		}
+		matches = append(matches, &m)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	// 同点で勝者を選んだ試合は既存の得点から勝者を推定するため、打ち消す前に確定させる
+	winners := make(map[int]int64)
+	for _, m := range matches {
+		if m.Status != "finished" || m.IsLeagueMatch {
+			continue
+		}
+		winnerID, err := r.inferStoredWinnerID(tx, m)
+		if err != nil {
+			return nil, err
+		}
+		winners[m.ID] = winnerID
+	}
+
+	if err := reverseScoreLogs(tx, audit, "l.event_id = ? AND l.source_match_id IS NOT NULL", eventID); err != nil {
+		return nil, err
+	}
+
+	rules, err := loadScoringRules(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ScoreRecalculationResult{EventID: eventID}
+	for _, m := range matches {
+		scored, err := r.replayMatchScoring(tx, m, winners[m.ID], eventID, locations[m.TournamentID], maxRounds[m.TournamentID], rules, audit)
+		if err != nil {
+			return nil, err
+		}
+		if scored {
+			result.ReplayedMatches++
+		}
+	}
+
+	if result.AttendanceClasses, err = recalculateAttendancePoints(tx, eventID, rules, audit); err != nil {
+		return nil, err
+	}
+	if result.SurveyClasses, err = recalculateSurveyPoints(tx, eventID, rules, audit); err != nil {
+		return nil, err
+	}
+	if result.MICClasses, err = recalculateMICPoints(tx, eventID, rules, audit); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// recalculateAttendancePoints は出席点が記録されたクラスの出席点を、記録済みの出席者数から
+// 計算し直す。UpdateAttendance と同じく専教は0点とする。戻り値は計算し直したクラス数。
+func recalculateAttendancePoints(tx *sql.Tx, eventID int, rules models.ScoringRules, audit scoreAudit) (int, error) {
+	rows, err := tx.Query(`
+		SELECT c.id, c.name, c.student_count, c.attend_count
+		FROM classes c
+		WHERE c.event_id = ?
+		  AND EXISTS (
+			SELECT 1 FROM score_logs l
+			WHERE l.event_id = c.event_id AND l.class_id = c.id AND l.reason = 'attendance_points' AND `+activeScoreLogCondition+`
+		  )
+		ORDER BY c.id
+	`, eventID)
+	if err != nil {
+		return 0, err
+	}
+	points := make(map[int]int)
+	var classIDs []int
+	for rows.Next() {
+		var classID, studentCount, attendCount int
+		var className string
+		if err := rows.Scan(&classID, &className, &studentCount, &attendCount); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if className != "専教" {
+			if studentCount == 0 {
+				rows.Close()
+				return 0, fmt.Errorf("class '%s' (ID %d) has zero students, cannot calculate attendance points", className, classID)
+			}
+			points[classID] = rules.Attendance.PointsFor(float64(attendCount) / float64(studentCount))
+		}
+		classIDs = append(classIDs, classID)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, err
+	}
+	rows.Close()
+
+	for _, classID := range classIDs {
+		if err := replaceClassPoints(tx, eventID, classID, "attendance_points", points[classID], audit); err != nil {
+			return 0, err
+		}
+	}
+	return len(classIDs), nil
+}
+
+// recalculateSurveyPoints はアンケート得点を、取り込み時に残した提出数から計算し直す。
+// 提出数を残す前に取り込んだアンケート得点は計算し直せないため ErrSurveySubmissionsMissing を返す。
+// 戻り値は得点を付けたクラス数。
+func recalculateSurveyPoints(tx *sql.Tx, eventID int, rules models.ScoringRules, audit scoreAudit) (int, error) {
+	var missing int
+	err := tx.QueryRow(`
+		SELECT COUNT(*)
+		FROM score_logs l
+		JOIN classes c ON c.id = l.class_id
+		WHERE l.event_id = ? AND l.reason = 'survey_points' AND c.survey_submission_count IS NULL AND `+activeScoreLogCondition,
+		eventID,
+	).Scan(&missing)
+	if err != nil {
+		return 0, err
+	}
+	if missing > 0 {
+		return 0, ErrSurveySubmissionsMissing
+	}
+
+	rows, err := tx.Query(
+		"SELECT id, student_count, survey_submission_count FROM classes WHERE event_id = ? AND survey_submission_count IS NOT NULL ORDER BY id",
+		eventID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	points := make(map[int]int)
+	imported := false
+	for rows.Next() {
+		var classID, studentCount, submissions int
+		if err := rows.Scan(&classID, &studentCount, &submissions); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		imported = true
+		// ImportSurveyScores と同じく生徒数が登録されていないクラスには得点を付けない
+		if studentCount > 0 {
+			points[classID] = rules.Survey.PointsFor(float64(submissions) / float64(studentCount))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, err
+	}
+	rows.Close()
+
+	if !imported {
+		return 0, nil
+	}
+	if err := replaceEventPoints(tx, eventID, "survey_points", points, audit); err != nil {
+		return 0, err
+	}
+	return len(points), nil
+}
+
+// recalculateMICPoints は投票1件あたりのMIC得点を現在の配点ルールに揃え、
+// クラスごとのMIC得点を得票数から計算し直す。戻り値は得票のあったクラス数。
+func recalculateMICPoints(tx *sql.Tx, eventID int, rules models.ScoringRules, audit scoreAudit) (int, error) {
+	if _, err := tx.Exec("UPDATE mic_votes SET points = ? WHERE event_id = ?", rules.MICPoints, eventID); err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.Query("SELECT voted_for_class_id, COUNT(*) FROM mic_votes WHERE event_id = ? GROUP BY voted_for_class_id", eventID)
+	if err != nil {
+		return 0, err
+	}
+	points := make(map[int]int)
+	for rows.Next() {
+		var classID, votes int
+		if err := rows.Scan(&classID, &votes); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		points[classID] = votes * rules.MICPoints
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, err
+	}
+	rows.Close()
+
+	if err := replaceEventPoints(tx, eventID, "mic_points", points, audit); err != nil {
+		return 0, err
+	}
+	return len(points), nil
+}
+
+// replayMatchScoring は終了済みの試合の得点を付け直す。winnerID はリーグ戦以外の試合の勝者。
+// 得点を付け直す対象の試合だった場合に true を返す。
+func (r *tournamentRepository) replayMatchScoring(tx *sql.Tx, m *models.MatchDB, winnerID int64, eventID int, location string, totalRounds int, rules models.ScoringRules, audit scoreAudit) (bool, error) {
+	if m.Status != "finished" || !m.Team1ID.Valid || !m.Team2ID.Valid {
+		return false, nil
+	}
+
+	if m.IsLeagueMatch {
+		if !m.Team1Score.Valid || !m.Team2Score.Valid {
+			return false, nil
+		}
+		if err := r.applyLeagueScoring(tx, m, eventID, location, int(m.Team1Score.Int32), int(m.Team2Score.Int32), rules, audit); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	if winnerID == 0 {
+		return false, nil
+	}
+	loserID := m.Team1ID.Int64
+	if loserID == winnerID {
+		loserID = m.Team2ID.Int64
+	}
+	if err := r.applyScoring(tx, m, winnerID, loserID, totalRounds, rules, audit); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // ApplyRainyModeStartTimes applies rainy_mode_start_time to match_start_time for all matches in the event's tournaments
 // This is called when rainy mode is enabled
-func (r *tournamentRepository) ApplyRainyModeStartTimes(eventID int) error {
+func (r *tournamentRepository) ApplyRainyModeStartTimes(eventID int, actorUserID string) error {
 	// Update all matches that have rainy_mode_start_time set
 	// Set match_start_time = rainy_mode_start_time where rainy_mode_start_time is not null
 	query := `
@@ -2041,6 +2487,352 @@ func (r *tournamentRepository) ApplyRainyModeStartTimes(eventID int) error {
 		  AND m.rainy_mode_start_time IS NOT NULL
 		  AND m.rainy_mode_start_time != ''
 	`
-	_, err := r.db.Exec(query, eventID)
-	return err
+	// 変更した試合をまとめて履歴に残す
+	const condition = "m.tournament_id IN (SELECT id FROM tournaments WHERE event_id = ?) AND m.rainy_mode_start_time IS NOT NULL AND m.rainy_mode_start_time != ''"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := ensureMatchBaselines(tx, condition, eventID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, eventID); err != nil {
+		return err
+	}
+	if err := recordMatchRevisions(tx, newMatchChange(models.MatchRevisionStartTime, actorUserID), condition, eventID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordLeagueMatchResult はリーグ戦の試合結果を保存し、勝ち・引き分けの得点を付け直す。
+// 修正時も同じ処理を通るよう、以前に付与したリーグ戦の得点は打ち消してから付与する。
+func (r *tournamentRepository) recordLeagueMatchResult(tx *sql.Tx, match *models.MatchDB, eventID int, location string, team1Score, team2Score int, audit scoreAudit) error {
+	if !match.Team1ID.Valid || !match.Team2ID.Valid {
+		return ErrMatchTeamsNotDecided
+	}
+
+	if _, err := tx.Exec("UPDATE matches SET team1_score = ?, team2_score = ?, status = 'finished' WHERE id = ?", team1Score, team2Score, match.ID); err != nil {
+		return err
+	}
+
+	if _, ok := locationColumns[location]; !ok {
+		return nil
+	}
+
+	if err := r.revertScoring(tx, match.ID, audit); err != nil {
+		return err
+	}
+
+	rules, err := loadScoringRules(tx, eventID)
+	if err != nil {
+		return err
+	}
+	return r.applyLeagueScoring(tx, match, eventID, location, team1Score, team2Score, rules, audit)
+}
+
+// applyLeagueScoring はリーグ戦1試合の勝ち・引き分けの得点を付与する（順位表の勝点とは別）
+func (r *tournamentRepository) applyLeagueScoring(tx *sql.Tx, match *models.MatchDB, eventID int, location string, team1Score, team2Score int, rules models.ScoringRules, audit scoreAudit) error {
+	columns, ok := locationColumns[location]
+	if !ok {
+		return nil
+	}
+
+	team1, err := r.getTeamByIDTx(tx, match.Team1ID.Int64)
+	if err != nil {
+		return err
+	}
+	team2, err := r.getTeamByIDTx(tx, match.Team2ID.Int64)
+	if err != nil {
+		return err
+	}
+	if team1.EventID != eventID || team2.EventID != eventID {
+		return nil
+	}
+
+	switch {
+	case team1Score > team2Score:
+		return r.addPoints(tx, eventID, team1.ClassID, columns.league, rules.LeagueWinPoints, match.ID, audit)
+	case team2Score > team1Score:
+		return r.addPoints(tx, eventID, team2.ClassID, columns.league, rules.LeagueWinPoints, match.ID, audit)
+	default:
+		if err := r.addPoints(tx, eventID, team1.ClassID, columns.league, rules.LeagueDrawPoints, match.ID, audit); err != nil {
+			return err
+		}
+		return r.addPoints(tx, eventID, team2.ClassID, columns.league, rules.LeagueDrawPoints, match.ID, audit)
+	}
+}
+
+type leagueMatchResult struct {
+	team1ID    int
+	team2ID    int
+	team1Score int
+	team2Score int
+	finished   bool
+}
+
+// GetLeagueStandings は競技のリーグ戦をグループごとに集計した順位表を返す。
+// 順位は勝点、得失点差、総得点、当該チーム間の成績、チーム名の順で決める。
+func (r *tournamentRepository) GetLeagueStandings(eventID int, sportID int) ([]*models.LeagueGroupStandings, error) {
+	rows, err := r.db.Query(`
+		SELECT m.tournament_id, m.league_group, m.team1_id, m.team2_id, m.team1_score, m.team2_score, m.status
+		FROM matches m
+		JOIN tournaments t ON m.tournament_id = t.id
+		WHERE t.event_id = ? AND t.sport_id = ? AND m.is_league_match = TRUE
+		ORDER BY m.league_group, m.round, m.match_number_in_round
+	`, eventID, sportID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]*models.LeagueGroupStandings, 0)
+	resultsByGroup := make(map[string][]leagueMatchResult)
+	for rows.Next() {
+		var tournamentID int
+		var group sql.NullString
+		var team1ID, team2ID sql.NullInt64
+		var team1Score, team2Score sql.NullInt32
+		var status string
+		if err := rows.Scan(&tournamentID, &group, &team1ID, &team2ID, &team1Score, &team2Score, &status); err != nil {
+			return nil, err
+		}
+		if !team1ID.Valid || !team2ID.Valid {
+			continue
+		}
+
+		if _, ok := resultsByGroup[group.String]; !ok {
+			groups = append(groups, &models.LeagueGroupStandings{TournamentID: tournamentID, Group: group.String, Completed: true})
+		}
+		finished := status == "finished" && team1Score.Valid && team2Score.Valid
+		if !finished {
+			groups[len(groups)-1].Completed = false
+		}
+		resultsByGroup[group.String] = append(resultsByGroup[group.String], leagueMatchResult{
+			team1ID:    int(team1ID.Int64),
+			team2ID:    int(team2ID.Int64),
+			team1Score: int(team1Score.Int32),
+			team2Score: int(team2Score.Int32),
+			finished:   finished,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	teamMap, err := r.getTeamsByEventID(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		group.Standings = buildLeagueStandings(resultsByGroup[group.Group], teamMap)
+	}
+	return groups, nil
+}
+
+func buildLeagueStandings(results []leagueMatchResult, teamMap map[int]*models.Team) []models.LeagueStanding {
+	rowByTeam := make(map[int]*models.LeagueStanding)
+	teamOrder := make([]int, 0)
+	rowFor := func(teamID int) *models.LeagueStanding {
+		if row, ok := rowByTeam[teamID]; ok {
+			return row
+		}
+		row := &models.LeagueStanding{TeamID: teamID}
+		if team, ok := teamMap[teamID]; ok {
+			row.TeamName = team.Name
+			row.ClassID = team.ClassID
+		}
+		rowByTeam[teamID] = row
+		teamOrder = append(teamOrder, teamID)
+		return row
+	}
+
+	for _, result := range results {
+		team1 := rowFor(result.team1ID)
+		team2 := rowFor(result.team2ID)
+		if result.finished {
+			tallyLeagueResult(team1, team2, result.team1Score, result.team2Score)
+		}
+	}
+
+	standings := make([]models.LeagueStanding, 0, len(teamOrder))
+	for _, teamID := range teamOrder {
+		standings = append(standings, *rowByTeam[teamID])
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		return compareLeagueRecords(standings[i], standings[j]) < 0
+	})
+
+	// 勝点・得失点差・総得点が並んだチーム同士は、当該チーム間の試合だけで再集計して並べる
+	for start := 0; start < len(standings); {
+		end := start + 1
+		for end < len(standings) && compareLeagueRecords(standings[start], standings[end]) == 0 {
+			end++
+		}
+		if end-start > 1 {
+			sortByHeadToHead(standings[start:end], results)
+		}
+		start = end
+	}
+
+	for i := range standings {
+		standings[i].Rank = i + 1
+	}
+	return standings
+}
+
+func tallyLeagueResult(team1, team2 *models.LeagueStanding, team1Score, team2Score int) {
+	team1.Played++
+	team2.Played++
+	team1.GoalsFor += team1Score
+	team1.GoalsAgainst += team2Score
+	team2.GoalsFor += team2Score
+	team2.GoalsAgainst += team1Score
+	team1.GoalDifference = team1.GoalsFor - team1.GoalsAgainst
+	team2.GoalDifference = team2.GoalsFor - team2.GoalsAgainst
+
+	switch {
+	case team1Score > team2Score:
+		team1.Wins++
+		team2.Losses++
+		team1.Points += models.LeagueStandingWinPoints
+	case team2Score > team1Score:
+		team2.Wins++
+		team1.Losses++
+		team2.Points += models.LeagueStandingWinPoints
+	default:
+		team1.Draws++
+		team2.Draws++
+		team1.Points += models.LeagueStandingDrawPoints
+		team2.Points += models.LeagueStandingDrawPoints
+	}
+}
+
+// compareLeagueRecords は上位のチームほど小さくなるよう比較する
+func compareLeagueRecords(a, b models.LeagueStanding) int {
+	switch {
+	case a.Points != b.Points:
+		return b.Points - a.Points
+	case a.GoalDifference != b.GoalDifference:
+		return b.GoalDifference - a.GoalDifference
+	default:
+		return b.GoalsFor - a.GoalsFor
+	}
+}
+
+func sortByHeadToHead(tied []models.LeagueStanding, results []leagueMatchResult) {
+	miniTable := make(map[int]*models.LeagueStanding, len(tied))
+	for _, row := range tied {
+		miniTable[row.TeamID] = &models.LeagueStanding{TeamID: row.TeamID}
+	}
+	for _, result := range results {
+		team1, ok1 := miniTable[result.team1ID]
+		team2, ok2 := miniTable[result.team2ID]
+		if !ok1 || !ok2 || !result.finished {
+			continue
+		}
+		tallyLeagueResult(team1, team2, result.team1Score, result.team2Score)
+	}
+
+	sort.SliceStable(tied, func(i, j int) bool {
+		if c := compareLeagueRecords(*miniTable[tied[i].TeamID], *miniTable[tied[j].TeamID]); c != 0 {
+			return c < 0
+		}
+		return tied[i].TeamName < tied[j].TeamName
+	})
+}
+
+// AssignLeagueKnockoutTeams はリーグ戦の上位チームを決勝トーナメントの一回戦に配置する。
+// pairings の添字が一回戦の試合番号に対応する。
+func (r *tournamentRepository) AssignLeagueKnockoutTeams(eventID int, sportID int, pairings [][2]int, actorUserID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var tournamentID int
+	err = tx.QueryRow("SELECT id FROM tournaments WHERE event_id = ? AND sport_id = ? AND is_league_knockout = TRUE", eventID, sportID).Scan(&tournamentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrLeagueKnockoutNotFound
+		}
+		return err
+	}
+
+	var finishedCount int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM matches WHERE tournament_id = ? AND status = 'finished'", tournamentID).Scan(&finishedCount); err != nil {
+		return err
+	}
+	if finishedCount > 0 {
+		return ErrLeagueKnockoutStarted
+	}
+
+	if err := ensureMatchBaselines(tx, "m.tournament_id = ?", tournamentID); err != nil {
+		return err
+	}
+	var firstRoundCount int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM matches WHERE tournament_id = ? AND round = 0 AND is_bronze_match = FALSE", tournamentID).Scan(&firstRoundCount); err != nil {
+		return err
+	}
+	if firstRoundCount != len(pairings) {
+		return ErrLeagueKnockoutSizeMismatch
+	}
+
+	change := newMatchChange(models.MatchRevisionLeagueKnockout, actorUserID)
+	for order, pairing := range pairings {
+		var matchID int
+		var status string
+		var nextMatchID sql.NullInt64
+		err := tx.QueryRow(
+			"SELECT id, status, next_match_id FROM matches WHERE tournament_id = ? AND round = 0 AND match_number_in_round = ? AND is_bronze_match = FALSE",
+			tournamentID, order,
+		).Scan(&matchID, &status, &nextMatchID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("決勝トーナメントの一回戦 %d 試合目が見つかりません", order+1)
+			}
+			return err
+		}
+
+		// 相手のいない組み合わせは生成時に不戦勝にした試合にだけ入れられる
+		isBye := pairing[1] == 0
+		if isBye != (status == models.MatchStatusBye) {
+			return ErrLeagueKnockoutSizeMismatch
+		}
+		if !isBye {
+			if _, err := tx.Exec("UPDATE matches SET team1_id = ?, team2_id = ? WHERE id = ?", pairing[0], pairing[1], matchID); err != nil {
+				return err
+			}
+			change.touch(matchID)
+			continue
+		}
+
+		if _, err := tx.Exec("UPDATE matches SET team1_id = ?, team2_id = NULL WHERE id = ?", pairing[0], matchID); err != nil {
+			return err
+		}
+		change.touch(matchID)
+		// 不戦勝のチームは次の試合の対応する枠へそのまま勝ち上がる
+		if nextMatchID.Valid {
+			column := "team2_id"
+			if order%2 == 0 {
+				column = "team1_id"
+			}
+			// #nosec G202 -- column is one of two fixed column names.
+			if _, err := tx.Exec("UPDATE matches SET "+column+" = ? WHERE id = ?", pairing[0], nextMatchID.Int64); err != nil {
+				return err
+			}
+			change.touch(int(nextMatchID.Int64))
+		}
+	}
+
+	if err := change.record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
@@ -3,19 +3,43 @@ package router
 import (
 	"backapp/internal/config"
 	"backapp/internal/handler"
+	"backapp/internal/identity"
+	"backapp/internal/mail"
 	"backapp/internal/middleware"
 	"backapp/internal/push"
 	"backapp/internal/repository"
+	"backapp/internal/scoreboard"
 	"backapp/internal/websocket"
+	"context"
+	"crypto/rand"
 	"database/sql"
 	"fmt"
+	"log"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Workers は SetupRouter が組み立てたバックグラウンド処理。
+// サーバーの終了に合わせて止められるよう、起動は main が行う。
+type Workers struct {
+	PushOutbox           *handler.PushOutbox
+	MatchNotifier        *handler.MatchNotifier
+	NotificationRequests *handler.NotificationRequestHandler
+}
+
+// Run は ctx が終わるまでバックグラウンド処理を動かし、すべて止まるまで待つ
+func (w *Workers) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Go(func() { w.PushOutbox.Run(ctx) })
+	wg.Go(func() { w.MatchNotifier.Run(ctx) })
+	wg.Go(func() { w.NotificationRequests.RunEscalation(ctx) })
+	wg.Wait()
+}
+
 // SetupRouter はGinルーターをセットアップし、ルーティングを定義します
-func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManager) *gin.Engine {
+func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManager, notificationScheduler *handler.NotificationScheduler) (*gin.Engine, *Workers) {
 	router := gin.Default()
 	if err := router.SetTrustedProxies(cfg.TrustedProxyCIDRs); err != nil {
 		panic(fmt.Sprintf("invalid TRUSTED_PROXY_CIDRS: %v", err))
@@ -32,8 +56,10 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 
 	userRepo := repository.NewUserRepository(db)
 	eventRepo := repository.NewEventRepository(db)
+	eventDayRepo := repository.NewEventDayRepository(db)
 
 	classRepo := repository.NewClassRepository(db)
+	checkInRepo := repository.NewCheckInRepository(db)
 	teamRepo := repository.NewTeamRepository(db)
 	tournRepo := repository.NewTournamentRepository(db)
 	classHandler := handler.NewClassHandler(classRepo, eventRepo, teamRepo, tournRepo)
@@ -43,7 +69,7 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 	sportRepo := repository.NewSportRepository(db)
 	sportHandler := handler.NewSportHandler(sportRepo, classRepo, teamRepo, eventRepo, tournRepo)
 
-	statisticsHandler := handler.NewStatisticsHandler(classRepo, eventRepo, sportRepo, tournRepo)
+	statisticsHandler := handler.NewStatisticsHandler(classRepo, eventRepo, sportRepo, tournRepo).WithEventDays(eventDayRepo)
 
 	notificationRepo := repository.NewNotificationRepository(db)
 	pushSender := push.NewSender(push.Config{
@@ -52,25 +78,62 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 		AllowedHosts:    cfg.WebPushAllowedHosts,
 		MaxConcurrency:  32,
 	})
-	eventHandler := handler.NewEventHandler(eventRepo, tournRepo, classRepo, notificationRepo, userRepo, cfg.WebPushPublicKey, cfg.WebPushPrivateKey).WithPushSender(pushSender)
+	mailSender := mail.NewSMTPSender(mail.Config{
+		Host:          cfg.SMTPHost,
+		Port:          cfg.SMTPPort,
+		Username:      cfg.SMTPUsername,
+		Password:      cfg.SMTPPassword,
+		From:          cfg.SMTPFrom,
+		BatchSize:     cfg.SMTPBatchSize,
+		RatePerMinute: cfg.SMTPRatePerMinute,
+	})
+	pushOutbox := handler.NewPushOutbox(repository.NewPushDeliveryRepository(db), notificationRepo, pushSender)
+	scoreboardRepo := repository.NewScoreboardRepository(db)
+	scoreboardFeed := scoreboard.NewFeed(eventRepo, classRepo, tournRepo, scoreboardRepo)
+	scoreboardHandler := handler.NewScoreboardHandler(scoreboardRepo, eventRepo, scoreboardFeed)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	scheduleHandler := handler.NewScheduleHandler(scheduleRepo, eventRepo)
+
+	scoringRuleRepo := repository.NewScoringRuleRepository(db)
+	eventHandler := handler.NewEventHandler(eventRepo, tournRepo, classRepo, notificationRepo, userRepo, cfg.WebPushPublicKey, cfg.WebPushPrivateKey).WithPushSender(pushSender).WithPushOutbox(pushOutbox).WithMailSender(mailSender).WithScoringRules(scoringRuleRepo).WithScoreboard(scoreboardFeed)
+	scoringRuleHandler := handler.NewScoringRuleHandler(scoringRuleRepo, tournRepo, eventRepo).WithScoreboard(scoreboardFeed)
+	scoreLogHandler := handler.NewScoreLogHandler(repository.NewScoreLogRepository(db), classRepo)
 
 	rainyModeRepo := repository.NewRainyModeRepository(db)
-	rainyModeHandler := handler.NewRainyModeHandler(rainyModeRepo, eventRepo)
+	rainyModeHandler := handler.NewRainyModeHandler(rainyModeRepo, eventRepo).WithPlanner(sportRepo, teamRepo).WithScoreboard(scoreboardFeed)
+	eventDayHandler := handler.NewEventDayHandler(eventRepo, eventDayRepo).WithScoreboard(scoreboardFeed)
 
-	tournHandler := handler.NewTournamentHandler(tournRepo, sportRepo, teamRepo, classRepo, eventRepo, hubManager)
+	matchNotifier := handler.NewMatchNotifier(eventRepo, repository.NewMatchNotificationRepository(db), notificationRepo, pushSender).WithPushOutbox(pushOutbox)
+	tournHandler := handler.NewTournamentHandler(tournRepo, sportRepo, teamRepo, classRepo, eventRepo, hubManager).WithScoreboard(scoreboardFeed).WithScheduleCheck(scheduleRepo).WithMatchNotifier(matchNotifier)
 	noonRepo := repository.NewNoonGameRepository(db)
-	noonHandler := handler.NewNoonGameHandler(noonRepo, classRepo, eventRepo).WithSportSync(sportRepo)
+	noonHandler := handler.NewNoonGameHandler(noonRepo, classRepo, eventRepo).WithSportSync(sportRepo).WithScoreboard(scoreboardFeed).WithEventDays(eventDayRepo)
 
 	roleRepo := repository.NewRoleRepository(db)
-	notificationHandler := handler.NewNotificationHandler(notificationRepo, eventRepo, roleRepo, userRepo, cfg.WebPushPublicKey, cfg.WebPushPrivateKey).WithPushSender(pushSender)
+	notificationHandler := handler.NewNotificationHandler(notificationRepo, eventRepo, roleRepo, userRepo, cfg.WebPushPublicKey, cfg.WebPushPrivateKey).WithPushSender(pushSender).WithPushOutbox(pushOutbox).WithMailSender(mailSender).WithScheduler(notificationScheduler, repository.NewScheduledNotificationRepository(db))
 	notificationRequestRepo := repository.NewNotificationRequestRepository(db)
-	notificationRequestHandler := handler.NewNotificationRequestHandler(notificationRequestRepo, notificationRepo, roleRepo, cfg.WebPushPublicKey, cfg.WebPushPrivateKey).WithPushSender(pushSender)
+	notificationRequestHandler := handler.NewNotificationRequestHandler(notificationRequestRepo, notificationRepo, roleRepo, cfg.WebPushPublicKey, cfg.WebPushPrivateKey).WithPushSender(pushSender).WithPushOutbox(pushOutbox).WithMailSender(mailSender).
+		WithNotificationHandler(notificationHandler).
+		WithEscalation(time.Duration(cfg.RequestSLAMinutes) * time.Minute)
+
+	passSigner := identity.NewPassSigner(qrPassSecret(cfg.QRPassSecret), time.Duration(cfg.QRPassRotationSeconds)*time.Second)
+	idParser, err := identity.NewParser(identity.Config{
+		Formats:             cfg.StudentIDFormats,
+		MyIDPrefixes:        cfg.MyIDBarcodePrefixes,
+		StudentNumberLength: cfg.StudentNumberLength,
+		PassSigner:          passSigner,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("invalid STUDENT_ID_FORMATS: %v", err))
+	}
+	identityRepo := repository.NewIdentityRepository(db)
+	identityHandler := handler.NewIdentityHandler(identityRepo, userRepo).WithPassSigner(passSigner, eventRepo)
 
-	attendanceHandler := handler.NewAttendanceHandler(classRepo, eventRepo)
+	attendanceHandler := handler.NewAttendanceHandler(classRepo, eventRepo).WithCheckIns(checkInRepo, userRepo).WithIdentity(idParser, identityRepo).WithScoreboard(scoreboardFeed)
 
-	barcodeHandler := handler.NewBarcodeHandler(teamRepo, sportRepo, userRepo, eventRepo, classRepo, tournRepo)
+	roundCheckInScanRepo := repository.NewRoundCheckInScanRepository(db)
+	barcodeHandler := handler.NewBarcodeHandler(teamRepo, sportRepo, userRepo, eventRepo, classRepo, tournRepo).WithRoundCheckInScans(roundCheckInScanRepo).WithIdentity(idParser, identityRepo)
 
-	classTeamHandler := handler.NewClassTeamHandler(classRepo, teamRepo, userRepo, eventRepo, sportRepo)
+	classTeamHandler := handler.NewClassTeamHandler(classRepo, teamRepo, userRepo, eventRepo, sportRepo).WithRosterLock(repository.NewRosterRepository(db))
 
 	imageHandler := handler.NewImageHandler()
 	pdfHandler := handler.NewPdfHandler()
@@ -78,7 +141,7 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 	guideDocumentHandler := handler.NewGuideDocumentHandler(guideDocumentRepo)
 
 	micRepo := repository.NewMICRepository(db)
-	micHandler := handler.NewMICHandler(micRepo, eventRepo)
+	micHandler := handler.NewMICHandler(micRepo, eventRepo).WithScoreboard(scoreboardFeed)
 
 	wsHandler := handler.NewWebSocketHandler(hubManager, cfg.FrontendURL)
 
@@ -101,6 +164,9 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 			ws.GET("/progress", wsHandler.ServeProgressWebSocket)
 		}
 
+		// 会場の掲示用スコアボード。ログインの代わりにイベントごとのトークンで購読する。
+		api.GET("/scoreboard/events/:id/stream", scoreboardHandler.StreamScoreboardHandler)
+
 		api.GET("/classes", classHandler.GetAllClasses)
 		api.GET("/scores/class", middleware.AuthMiddleware(userRepo), classHandler.GetClassScores)
 
@@ -123,6 +189,8 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 		{
 			user.Use(middleware.AuthMiddleware(userRepo))
 			user.PUT("/profile", authHandler.UpdateProfile)
+			user.GET("/pass", identityHandler.GetMySignedPassHandler)
+			user.GET("/pass/qr", identityHandler.GetMySignedPassQRCodeHandler)
 		}
 
 		// Events accessible to any authenticated user
@@ -132,6 +200,7 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 			events.GET("", eventHandler.GetAllEvents)
 			// Get sports for a specific event
 			events.GET("/:id/sports", sportHandler.GetSportsByEventHandler)
+			events.GET("/:id/days", eventDayHandler.GetEventDays)
 		}
 
 		// MyID barcode check-in routes accessible to authenticated users
@@ -140,7 +209,10 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 			barcode.Use(middleware.AuthMiddleware(userRepo))
 			barcode.GET("/teams", barcodeHandler.GetUserTeamsHandler)
 			barcode.POST("/check-in", middleware.RoleRequired("admin", "root"), middleware.RateLimit(20, time.Minute), barcodeHandler.CheckInRoundHandler)
+			barcode.POST("/check-in/batch", middleware.RoleRequired("admin", "root"), middleware.RateLimit(20, time.Minute), barcodeHandler.CheckInRoundBatchHandler)
 			barcode.GET("/matches/:match_id/check-ins", middleware.RoleRequired("admin", "root"), barcodeHandler.GetMatchCheckInsHandler)
+			barcode.GET("/lookup", middleware.RoleRequired("admin", "root"), identityHandler.LookupHandler)
+			barcode.GET("/id-pass/qr", middleware.NoStore(), identityHandler.GetMyIDPassQRCodeHandler)
 		}
 
 		student := api.Group("/student")
@@ -151,6 +223,7 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 			studentEvents := student.Group("/events")
 			{
 				studentEvents.GET("/:event_id/tournaments", tournHandler.GetTournamentsByEventHandler)
+				studentEvents.GET("/:event_id/sports/:sport_id/league/standings", tournHandler.GetLeagueStandingsHandler)
 				studentEvents.GET("/:event_id/noon-game/session", noonHandler.GetSession)
 				studentEvents.GET("/:event_id/noon-game/sessions", noonHandler.ListSessions)
 				studentEvents.GET("/:event_id/noon-game/sessions/:session_id", noonHandler.GetSessionByID)
@@ -169,6 +242,9 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 		{
 			notifications.Use(middleware.AuthMiddleware(userRepo), middleware.RoleRequired("student", "admin", "root"))
 			notifications.GET("", notificationHandler.ListNotifications)
+			notifications.GET("/unread-count", notificationHandler.GetUnreadNotificationCount)
+			notifications.POST("/read-all", notificationHandler.MarkAllNotificationsRead)
+			notifications.POST("/:notification_id/read", notificationHandler.MarkNotificationRead)
 			notifications.PUT("/filters", notificationHandler.UpdateNotificationFilters)
 			notifications.GET("/subscription", notificationHandler.GetSubscription)
 			notifications.POST("/subscription", middleware.UserRateLimit(10, time.Hour, "push-subscription"), notificationHandler.SaveSubscription)
@@ -181,6 +257,7 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 			adminEvent := admin.Group("/events")
 			{
 				adminEvent.GET("/:event_id/tournaments", tournHandler.GetTournamentsByEventHandler)
+				adminEvent.GET("/:event_id/sports/:sport_id/league/standings", tournHandler.GetLeagueStandingsHandler)
 				adminEvent.GET("/:event_id/noon-game/session", noonHandler.GetSession)
 				adminEvent.GET("/:event_id/noon-game/sessions", noonHandler.ListSessions)
 				adminEvent.GET("/:event_id/noon-game/sessions/:session_id", noonHandler.GetSessionByID)
@@ -195,6 +272,16 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 			{
 				attendance.GET("/class-details/:classID", attendanceHandler.GetClassDetailsHandler)
 				attendance.POST("/register", attendanceHandler.RegisterAttendanceHandler)
+				attendance.POST("/check-in", attendanceHandler.CheckInHandler)
+				attendance.GET("/classes/:classID/unchecked", attendanceHandler.GetUncheckedMembersHandler)
+			}
+
+			// ID passes (QR codes) for people without MyID cards
+			idPasses := admin.Group("/id-passes")
+			{
+				idPasses.POST("/:userID", identityHandler.IssueIDPassHandler)
+				idPasses.DELETE("/:userID", identityHandler.RevokeIDPassHandler)
+				idPasses.GET("/:userID/qr", middleware.NoStore(), identityHandler.GetIDPassQRCodeHandler)
 			}
 
 			// Assign a sport to a specific event
@@ -205,10 +292,12 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 			admin.GET("/events/:event_id/sports/:sport_id/details", sportHandler.GetSportDetailsHandler)
 			admin.PUT("/events/:event_id/sports/:sport_id/details", sportHandler.UpdateSportDetailsHandler)
 			admin.PUT("/events/:event_id/sports/:sport_id/capacity", sportHandler.UpdateCapacityHandler)
+			admin.PUT("/events/:event_id/sports/:sport_id/format", sportHandler.UpdateSportFormatHandler)
 			admin.PUT("/events/:event_id/sports/:sport_id/classes/:class_id/capacity", sportHandler.UpdateClassCapacityHandler)
 
 			admin.PUT("/matches/:match_id/start-time", tournHandler.UpdateMatchStartTimeHandler)
 			admin.PUT("/matches/:match_id/rainy-mode-start-time", tournHandler.UpdateMatchRainyModeStartTimeHandler)
+			admin.POST("/matches/:match_id/schedule/reflow", scheduleHandler.ReflowScheduleHandler)
 			resultEntryRequired := middleware.ActiveEventStatusRequired(eventRepo, "active")
 			admin.PUT("/matches/:match_id/result", resultEntryRequired, tournHandler.UpdateMatchResultHandler)
 			admin.PUT("/noon-game/matches/:match_id/result", resultEntryRequired, noonHandler.RecordMatchResult)
@@ -257,6 +346,8 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 			adminClassTeam.DELETE("/remove-member", classTeamHandler.RemoveTeamMemberHandler)
 			adminClassTeam.GET("/sports/:sport_id/members", classTeamHandler.GetTeamMembersHandler)
 			adminClassTeam.GET("/sports/:sport_id/confirmed-members", classTeamHandler.GetConfirmedTeamMembersHandler)
+			adminClassTeam.GET("/roster-phase", classTeamHandler.GetRosterPhaseHandler)
+			adminClassTeam.GET("/roster-change-requests", classTeamHandler.ListRosterChangeRequestsHandler)
 		}
 
 		root := api.Group("/root")
@@ -270,17 +361,28 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 				rootEvents.PUT("/active", eventHandler.SetActiveEvent)
 				// More specific routes must come before the generic :id route
 				rootEvents.PUT("/:id/rainy-mode", eventHandler.SetRainyMode)
+				rootEvents.PUT("/:id/roster-phase", classTeamHandler.SetRosterPhaseHandler)
+				rootEvents.POST("/:id/clone/preview", eventHandler.PreviewEventClone)
+				rootEvents.POST("/:id/clone", eventHandler.CloneEvent)
 				rootEvents.GET("/:id/rainy-mode/settings", rainyModeHandler.GetRainyModeSettingsHandler)
 				rootEvents.POST("/:id/rainy-mode/settings", rainyModeHandler.UpsertRainyModeSettingHandler)
 				rootEvents.PUT("/:id/rainy-mode/settings", rainyModeHandler.UpsertRainyModeSettingHandler)
 				rootEvents.DELETE("/:id/rainy-mode/settings/:sport_id/:class_id", rainyModeHandler.DeleteRainyModeSettingHandler)
+				rootEvents.POST("/:id/rainy-mode/plan/preview", rainyModeHandler.PreviewRainyPlanHandler)
+				rootEvents.POST("/:id/rainy-mode/plan/apply", rainyModeHandler.ApplyRainyPlanHandler)
+				rootEvents.POST("/:id/days/sync", eventDayHandler.SyncEventDays)
+				rootEvents.PUT("/:id/days/:day_id/rainy-mode", eventDayHandler.SetDayRainyMode)
+				rootEvents.PUT("/:id/days/:day_id/assignments", eventDayHandler.AssignEventDay)
 				rootEvents.GET("/:id/mic/settings", eventHandler.GetMICVotingSettings)
 				rootEvents.PUT("/:id/mic/settings", eventHandler.SetMICVotingSettings)
 				rootEvents.POST("/:id/tournaments/generate-all", tournHandler.GenerateAllTournamentsHandler)
 				rootEvents.POST("/:id/tournaments/generate-preview", tournHandler.GenerateAllTournamentsPreviewHandler)
 				rootEvents.POST("/:id/tournaments/bulk-create", tournHandler.BulkCreateTournamentsHandler)
 				rootEvents.GET("/:id/tournaments/export/excel", tournHandler.ExportTournamentsExcelHandler)
+				rootEvents.GET("/:id/tournaments/export/pdf", tournHandler.ExportTournamentsPDFHandler)
 				rootEvents.GET("/:id/tournaments", tournHandler.GetTournamentsByEventHandler)
+				rootEvents.GET("/:id/sports/:sport_id/league/standings", tournHandler.GetLeagueStandingsHandler)
+				rootEvents.POST("/:id/sports/:sport_id/league/advance", tournHandler.AdvanceLeagueToKnockoutHandler)
 				rootEvents.GET("/:id/noon-game/session", noonHandler.GetSession)
 				rootEvents.POST("/:id/noon-game/session", noonHandler.UpsertSession)
 				rootEvents.GET("/:id/noon-game/sessions", noonHandler.ListSessions)
@@ -294,6 +396,17 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 				rootEvents.PUT("/:id/competition-guidelines", eventHandler.UpdateCompetitionGuidelines)
 				rootEvents.POST("/:id/notify-survey", eventHandler.NotifySurvey)
 				rootEvents.POST("/:id/import-survey-scores", eventHandler.ImportSurveyScores)
+				rootEvents.GET("/:id/scoring-rules", scoringRuleHandler.GetScoringRulesHandler)
+				rootEvents.PUT("/:id/scoring-rules", scoringRuleHandler.UpdateScoringRulesHandler)
+				rootEvents.GET("/:id/scoring-rules/versions", scoringRuleHandler.ListScoringRuleVersionsHandler)
+				rootEvents.POST("/:id/scoring-rules/recalculate", scoringRuleHandler.RecalculateScoresHandler)
+				rootEvents.GET("/:id/classes/:class_id/score-logs", scoreLogHandler.GetClassScoreLogsHandler)
+				rootEvents.POST("/:id/scoreboard-token", scoreboardHandler.IssueScoreboardTokenHandler)
+				rootEvents.DELETE("/:id/scoreboard-token", scoreboardHandler.RevokeScoreboardTokenHandler)
+				rootEvents.GET("/:id/schedule/config", scheduleHandler.GetScheduleConfigHandler)
+				rootEvents.PUT("/:id/schedule/config", scheduleHandler.UpdateScheduleConfigHandler)
+				rootEvents.POST("/:id/schedule/generate", scheduleHandler.GenerateScheduleHandler)
+				rootEvents.GET("/:id/schedule/conflicts", scheduleHandler.GetScheduleConflictsHandler)
 
 				// Export endpoints
 				rootEvents.GET("/:id/export/csv", classHandler.ExportClassScoresCSVHandler)
@@ -301,12 +414,18 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 				// Generic :id route should be last
 				rootEvents.PUT("/:id", eventHandler.UpdateEvent)
 			}
-			// Sport management routes that require 'root' role
+			// Match revision routes that require 'root' role
+			rootMatches := root.Group("/matches")
+			{
+				rootMatches.GET("/:match_id/revisions", tournHandler.GetMatchRevisionsHandler)
+				rootMatches.POST("/:match_id/revisions/:revision_id/restore", tournHandler.RestoreMatchRevisionHandler)
+			}
 			rootClasses := root.Group("/classes")
 			{
 				rootClasses.PUT("/student-counts", classHandler.UpdateStudentCountsHandler)
 				rootClasses.POST("/student-counts/csv", classHandler.UpdateStudentCountsFromCSVHandler)
 			}
+			// Sport management routes that require 'root' role
 			rootSports := root.Group("/sports")
 			{
 				rootSports.GET("", sportHandler.GetAllSportsHandler)
@@ -347,6 +466,12 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 				rootNotifications.POST("", notificationHandler.CreateNotification)
 				rootNotifications.GET("/roles", notificationHandler.ListAvailableRoles)
 				rootNotifications.GET("/subscription-stats", notificationHandler.GetPushSubscriptionStats)
+				rootNotifications.GET("/:notification_id/delivery-stats", notificationHandler.GetPushDeliveryStats)
+				rootNotifications.GET("/:notification_id/read-stats", notificationHandler.GetNotificationReadStats)
+				rootNotifications.GET("/scheduled", notificationHandler.ListScheduledNotifications)
+				rootNotifications.POST("/scheduled", notificationHandler.CreateScheduledNotification)
+				rootNotifications.PUT("/scheduled/:scheduled_id", notificationHandler.UpdateScheduledNotification)
+				rootNotifications.DELETE("/scheduled/:scheduled_id", notificationHandler.CancelScheduledNotification)
 			}
 
 			rootUsers := root.Group("/users")
@@ -355,6 +480,7 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 				rootUsers.PUT("/display-name", authHandler.UpdateUserDisplayNameByAdmin)
 				rootUsers.PUT("/promote", authHandler.PromoteUserByRoot)
 				rootUsers.DELETE("/promote", authHandler.DemoteUserByRoot)
+				rootUsers.POST("/student-numbers/csv", identityHandler.ImportStudentNumbersCSVHandler)
 			}
 
 			rootGuideDocuments := root.Group("/guide-documents")
@@ -367,12 +493,33 @@ func SetupRouter(db *sql.DB, cfg *config.Config, hubManager *websocket.HubManage
 			rootNotificationRequests := root.Group("/notification-requests")
 			{
 				rootNotificationRequests.GET("", notificationRequestHandler.ListRootRequests)
+				rootNotificationRequests.GET("/stats", notificationRequestHandler.GetRequestStats)
 				rootNotificationRequests.GET("/:request_id", notificationRequestHandler.GetRequestDetail)
 				rootNotificationRequests.POST("/:request_id/messages", notificationRequestHandler.AddMessage)
 				rootNotificationRequests.POST("/:request_id/decision", notificationRequestHandler.DecideRequest)
 			}
+
+			rootRosterChangeRequests := root.Group("/roster-change-requests")
+			{
+				rootRosterChangeRequests.GET("", classTeamHandler.ListRosterChangeRequestsHandler)
+				rootRosterChangeRequests.POST("/:request_id/approve", classTeamHandler.ApproveRosterChangeRequestHandler)
+				rootRosterChangeRequests.POST("/:request_id/reject", classTeamHandler.RejectRosterChangeRequestHandler)
+			}
 		}
 	}
 
-	return router
+	return router, &Workers{PushOutbox: pushOutbox, MatchNotifier: matchNotifier, NotificationRequests: notificationRequestHandler}
+}
+
+// qrPassSecret は署名付きパスの鍵を返す。未設定なら起動ごとに作る鍵を使うため、再起動すると表示中のパスは読み取れなくなる
+func qrPassSecret(secret string) []byte {
+	if secret != "" {
+		return []byte(secret)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("failed to generate QR pass secret: %v", err))
+	}
+	log.Printf("QR_PASS_SECRET is not set; using a random key until the server restarts")
+	return key
 }
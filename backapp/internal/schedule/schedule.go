@@ -0,0 +1,409 @@
+// Package schedule は試合の開始時刻とコートを割り当てる。
+// 同じクラス・同じ生徒が同時に2試合に出ないこと、前の回戦が終わってから次の回戦を始めることを守り、
+// 各試合を会場のコートのうち最も早く始められるものに先着順で入れていく。
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"backapp/internal/models"
+)
+
+var (
+	ErrMatchNotFound     = errors.New("match not found")
+	ErrMatchNotScheduled = errors.New("match has no start time")
+	ErrInvalidEndTime    = errors.New("end time is before the match start time")
+)
+
+// Generate はイベントの全試合の日程を設定の開始時刻から組む。
+// 終了済みの試合は時刻とコートをそのまま残し、不戦勝の試合には割り当てない。
+func Generate(cfg models.ScheduleConfig, matches []models.ScheduleMatch) (*models.ScheduleResult, error) {
+	start, err := time.Parse(models.ScheduleTimeLayout, cfg.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start_time: %w", err)
+	}
+
+	p := newPlanner(cfg)
+	pending := make([]models.ScheduleMatch, 0, len(matches))
+	for _, m := range matches {
+		switch {
+		case hasNoSlot(m):
+		case isSettled(m):
+			if m.StartTime != nil {
+				p.occupy(m, *m.StartTime, m.Court, m.StartTime.Add(cfg.SlotFor(m.SportID)))
+			}
+		default:
+			pending = append(pending, m)
+		}
+	}
+
+	// 回戦ごとに競技をまたいで並べ、どの競技も同じ速さで進むようにする
+	sort.SliceStable(pending, func(i, j int) bool {
+		a, b := pending[i], pending[j]
+		if a.Round != b.Round {
+			return a.Round < b.Round
+		}
+		if a.MatchNumberInRound != b.MatchNumberInRound {
+			return a.MatchNumberInRound < b.MatchNumberInRound
+		}
+		if a.TournamentID != b.TournamentID {
+			return a.TournamentID < b.TournamentID
+		}
+		return a.MatchID < b.MatchID
+	})
+
+	assignments := make([]models.ScheduleAssignment, 0, len(pending))
+	for _, m := range pending {
+		if a, ok := p.place(m, start); ok {
+			assignments = append(assignments, a)
+		}
+	}
+
+	return p.result(matches, assignments), nil
+}
+
+// Reflow は試合が延びたときに、その試合の開始時刻以降の試合を endsAt に合わせて組み直す。
+// それより前に始まった試合は動かさず、組み直す試合も元の開始時刻より前には動かさない。
+func Reflow(cfg models.ScheduleConfig, matches []models.ScheduleMatch, lateMatchID int, endsAt time.Time) (*models.ScheduleResult, error) {
+	start, err := time.Parse(models.ScheduleTimeLayout, cfg.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start_time: %w", err)
+	}
+
+	var late *models.ScheduleMatch
+	for i := range matches {
+		if matches[i].MatchID == lateMatchID {
+			late = &matches[i]
+			break
+		}
+	}
+	if late == nil {
+		return nil, ErrMatchNotFound
+	}
+	if late.StartTime == nil {
+		return nil, ErrMatchNotScheduled
+	}
+	if !endsAt.After(*late.StartTime) {
+		return nil, ErrInvalidEndTime
+	}
+
+	p := newPlanner(cfg)
+	movable := make([]models.ScheduleMatch, 0, len(matches))
+	for _, m := range matches {
+		switch {
+		case hasNoSlot(m):
+		case m.MatchID == lateMatchID:
+			p.occupy(m, *m.StartTime, m.Court, endsAt)
+		case isSettled(m) || (m.StartTime != nil && m.StartTime.Before(*late.StartTime)):
+			if m.StartTime != nil {
+				p.occupy(m, *m.StartTime, m.Court, m.StartTime.Add(cfg.SlotFor(m.SportID)))
+			}
+		default:
+			movable = append(movable, m)
+		}
+	}
+
+	// 元の開始時刻の順に入れ直す。時刻が未定の試合は最後に回す。
+	sort.SliceStable(movable, func(i, j int) bool {
+		a, b := movable[i], movable[j]
+		if (a.StartTime == nil) != (b.StartTime == nil) {
+			return a.StartTime != nil
+		}
+		if a.StartTime != nil && !a.StartTime.Equal(*b.StartTime) {
+			return a.StartTime.Before(*b.StartTime)
+		}
+		if a.Round != b.Round {
+			return a.Round < b.Round
+		}
+		if a.MatchNumberInRound != b.MatchNumberInRound {
+			return a.MatchNumberInRound < b.MatchNumberInRound
+		}
+		return a.MatchID < b.MatchID
+	})
+
+	assignments := make([]models.ScheduleAssignment, 0)
+	for _, m := range movable {
+		notBefore := start
+		if m.StartTime != nil {
+			notBefore = *m.StartTime
+		}
+		a, ok := p.place(m, notBefore)
+		if !ok {
+			continue
+		}
+		// 時刻もコートも変わらない試合は結果に含めない
+		if m.StartTime != nil && a.StartTime == m.StartTime.Format(models.ScheduleTimeLayout) && a.Court == m.Court {
+			continue
+		}
+		assignments = append(assignments, a)
+	}
+
+	return p.result(matches, assignments), nil
+}
+
+// DetectConflicts は現在の日程で、同じコート・同じクラス・同じ生徒の試合が重なっていないか、
+// 前の回戦が終わる前に次の回戦が始まっていないかを調べる
+func DetectConflicts(cfg models.ScheduleConfig, matches []models.ScheduleMatch) []models.ScheduleConflict {
+	type slot struct {
+		match      models.ScheduleMatch
+		start, end time.Time
+	}
+
+	slots := make([]slot, 0, len(matches))
+	for _, m := range matches {
+		if m.StartTime == nil || hasNoSlot(m) {
+			continue
+		}
+		slots = append(slots, slot{match: m, start: *m.StartTime, end: m.StartTime.Add(cfg.SlotFor(m.SportID))})
+	}
+	sort.SliceStable(slots, func(i, j int) bool {
+		if !slots[i].start.Equal(slots[j].start) {
+			return slots[i].start.Before(slots[j].start)
+		}
+		return slots[i].match.MatchID < slots[j].match.MatchID
+	})
+
+	conflicts := make([]models.ScheduleConflict, 0)
+	for i := 0; i < len(slots); i++ {
+		a := slots[i]
+		for j := i + 1; j < len(slots); j++ {
+			b := slots[j]
+			if !b.start.Before(a.end) {
+				break
+			}
+			ids := []int{a.match.MatchID, b.match.MatchID}
+			if a.match.Court != "" && a.match.Location == b.match.Location && a.match.Court == b.match.Court {
+				conflicts = append(conflicts, models.ScheduleConflict{
+					Type:     models.ScheduleConflictCourt,
+					MatchIDs: ids,
+					Court:    a.match.Court,
+					Message:  fmt.Sprintf("matches %d and %d overlap on court %s (%s)", ids[0], ids[1], a.match.Court, a.match.Location),
+				})
+			}
+			for _, classID := range intersect(a.match.ClassIDs, b.match.ClassIDs) {
+				classID := classID
+				conflicts = append(conflicts, models.ScheduleConflict{
+					Type:     models.ScheduleConflictClass,
+					MatchIDs: ids,
+					ClassID:  &classID,
+					Message:  fmt.Sprintf("class %d plays matches %d and %d at the same time", classID, ids[0], ids[1]),
+				})
+			}
+			if students := intersect(a.match.StudentIDs, b.match.StudentIDs); len(students) > 0 {
+				conflicts = append(conflicts, models.ScheduleConflict{
+					Type:     models.ScheduleConflictStudent,
+					MatchIDs: ids,
+					UserIDs:  students,
+					Message:  fmt.Sprintf("%d students are members of both matches %d and %d", len(students), ids[0], ids[1]),
+				})
+			}
+		}
+	}
+
+	// 次の回戦は前の回戦のどの試合よりも後に始まらなければならない
+	for _, earlier := range slots {
+		for _, later := range slots {
+			if earlier.match.TournamentID != later.match.TournamentID || earlier.match.Round >= later.match.Round {
+				continue
+			}
+			if later.start.Before(earlier.end) {
+				conflicts = append(conflicts, models.ScheduleConflict{
+					Type:     models.ScheduleConflictRoundOrder,
+					MatchIDs: []int{earlier.match.MatchID, later.match.MatchID},
+					Message:  fmt.Sprintf("match %d starts before match %d of an earlier round ends", later.match.MatchID, earlier.match.MatchID),
+				})
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// hasNoSlot は不戦勝や中止になった試合のように、コートと時間を使わない試合かを返す
+func hasNoSlot(m models.ScheduleMatch) bool {
+	return m.Status == models.MatchStatusBye || m.Status == models.MatchStatusCancelled
+}
+
+func isSettled(m models.ScheduleMatch) bool {
+	return m.Status == "finished" || m.Status == "completed"
+}
+
+// CheckMatchTime は試合の開始時刻を start に変えた場合に、その試合で起きるクラス・生徒の重複を返す。
+// rainyMode が true なら雨天時の日程で調べる。
+func CheckMatchTime(cfg models.ScheduleConfig, matches []models.ScheduleMatch, matchID int, start time.Time, rainyMode bool) []models.ScheduleConflict {
+	planned := make([]models.ScheduleMatch, len(matches))
+	for i, m := range matches {
+		if m.MatchID == matchID {
+			if rainyMode {
+				m.RainyModeStartTime = &start
+			} else {
+				m.StartTime = &start
+			}
+		}
+		planned[i] = m
+	}
+	if rainyMode {
+		planned = RainyModeSchedule(planned)
+	}
+
+	conflicts := make([]models.ScheduleConflict, 0)
+	for _, conflict := range DetectConflicts(cfg, planned) {
+		if conflict.Type != models.ScheduleConflictClass && conflict.Type != models.ScheduleConflictStudent {
+			continue
+		}
+		for _, id := range conflict.MatchIDs {
+			if id == matchID {
+				conflicts = append(conflicts, conflict)
+				break
+			}
+		}
+	}
+	return conflicts
+}
+
+// RainyModeSchedule は雨天時の開始時刻が設定された試合をその時刻に置き換えた日程を返す。
+// 雨天時は会場が変わるため、コートの重複は調べない。
+func RainyModeSchedule(matches []models.ScheduleMatch) []models.ScheduleMatch {
+	rainy := make([]models.ScheduleMatch, len(matches))
+	for i, m := range matches {
+		if m.RainyModeStartTime != nil {
+			m.StartTime = m.RainyModeStartTime
+		}
+		m.Court = ""
+		rainy[i] = m
+	}
+	return rainy
+}
+
+func intersect[T comparable](a, b []T) []T {
+	shared := make([]T, 0)
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				shared = append(shared, x)
+				break
+			}
+		}
+	}
+	return shared
+}
+
+// planner はコート・クラス・生徒・回戦ごとに、次に試合を入れられる時刻を覚えておく
+type planner struct {
+	cfg         models.ScheduleConfig
+	courtFree   map[string]time.Time
+	classFree   map[int]time.Time
+	studentFree map[string]time.Time
+	roundEnd    map[int]map[int]time.Time
+	conflicts   []models.ScheduleConflict
+}
+
+func newPlanner(cfg models.ScheduleConfig) *planner {
+	return &planner{
+		cfg:         cfg,
+		courtFree:   make(map[string]time.Time),
+		classFree:   make(map[int]time.Time),
+		studentFree: make(map[string]time.Time),
+		roundEnd:    make(map[int]map[int]time.Time),
+		conflicts:   make([]models.ScheduleConflict, 0),
+	}
+}
+
+func courtKey(location, court string) string {
+	return location + "/" + court
+}
+
+func laterOf(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+func (p *planner) occupy(m models.ScheduleMatch, start time.Time, court string, end time.Time) {
+	if court != "" {
+		key := courtKey(m.Location, court)
+		p.courtFree[key] = laterOf(p.courtFree[key], end)
+	}
+	for _, classID := range m.ClassIDs {
+		p.classFree[classID] = laterOf(p.classFree[classID], end)
+	}
+	for _, userID := range m.StudentIDs {
+		p.studentFree[userID] = laterOf(p.studentFree[userID], end)
+	}
+	if p.roundEnd[m.TournamentID] == nil {
+		p.roundEnd[m.TournamentID] = make(map[int]time.Time)
+	}
+	p.roundEnd[m.TournamentID][m.Round] = laterOf(p.roundEnd[m.TournamentID][m.Round], end)
+}
+
+// place は試合を notBefore 以降で最も早く始められるコートに入れる。
+// 同じ時刻に始められるなら今のコートを優先する。
+func (p *planner) place(m models.ScheduleMatch, notBefore time.Time) (models.ScheduleAssignment, bool) {
+	courts := p.cfg.CourtsFor(m.Location)
+	if len(courts) == 0 {
+		p.conflicts = append(p.conflicts, models.ScheduleConflict{
+			Type:     models.ScheduleConflictNoCourt,
+			MatchIDs: []int{m.MatchID},
+			Message:  fmt.Sprintf("no courts are configured for location %s", m.Location),
+		})
+		return models.ScheduleAssignment{}, false
+	}
+
+	earliest := notBefore
+	for _, classID := range m.ClassIDs {
+		earliest = laterOf(earliest, p.classFree[classID])
+	}
+	for _, userID := range m.StudentIDs {
+		earliest = laterOf(earliest, p.studentFree[userID])
+	}
+	for round, end := range p.roundEnd[m.TournamentID] {
+		if round < m.Round {
+			earliest = laterOf(earliest, end)
+		}
+	}
+
+	var bestCourt string
+	var bestStart time.Time
+	for i, court := range courts {
+		start := laterOf(earliest, p.courtFree[courtKey(m.Location, court)])
+		if i == 0 || start.Before(bestStart) || (start.Equal(bestStart) && court == m.Court) {
+			bestCourt, bestStart = court, start
+		}
+	}
+
+	end := bestStart.Add(p.cfg.SlotFor(m.SportID))
+	p.occupy(m, bestStart, bestCourt, end)
+	return models.ScheduleAssignment{
+		MatchID:   m.MatchID,
+		StartTime: bestStart.Format(models.ScheduleTimeLayout),
+		EndTime:   end.Format(models.ScheduleTimeLayout),
+		Court:     bestCourt,
+	}, true
+}
+
+// result は割り当てを反映した日程で衝突を調べ、割り当てられなかった試合と合わせて返す
+func (p *planner) result(matches []models.ScheduleMatch, assignments []models.ScheduleAssignment) *models.ScheduleResult {
+	byID := make(map[int]models.ScheduleAssignment, len(assignments))
+	for _, a := range assignments {
+		byID[a.MatchID] = a
+	}
+
+	planned := make([]models.ScheduleMatch, len(matches))
+	for i, m := range matches {
+		if a, ok := byID[m.MatchID]; ok {
+			start, _ := time.Parse(models.ScheduleTimeLayout, a.StartTime)
+			m.StartTime = &start
+			m.Court = a.Court
+		}
+		planned[i] = m
+	}
+
+	return &models.ScheduleResult{
+		Assignments: assignments,
+		Conflicts:   append(p.conflicts, DetectConflicts(p.cfg, planned)...),
+	}
+}
@@ -0,0 +1,220 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"backapp/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func at(clock string) *time.Time {
+	t, err := time.Parse(models.ScheduleTimeLayout, "2026-05-20 "+clock+":00")
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func testConfig() models.ScheduleConfig {
+	return models.ScheduleConfig{
+		StartTime:          "2026-05-20 09:00:00",
+		DefaultSlotMinutes: 30,
+		Venues: []models.ScheduleVenue{
+			{Location: "gym1", Courts: []string{"A", "B"}},
+			{Location: "ground", Courts: []string{"1"}},
+		},
+		Sports: []models.ScheduleSportSlot{{SportID: 2, SlotMinutes: 20}},
+	}
+}
+
+func assignmentsByID(result *models.ScheduleResult) map[int]models.ScheduleAssignment {
+	byID := make(map[int]models.ScheduleAssignment)
+	for _, a := range result.Assignments {
+		byID[a.MatchID] = a
+	}
+	return byID
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("spreads a round across courts and starts the next round after it", func(t *testing.T) {
+		matches := []models.ScheduleMatch{
+			{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym1", Round: 0, MatchNumberInRound: 0, ClassIDs: []int{101, 102}, Status: "pending"},
+			{MatchID: 2, TournamentID: 10, SportID: 1, Location: "gym1", Round: 0, MatchNumberInRound: 1, ClassIDs: []int{103, 104}, Status: "pending"},
+			{MatchID: 3, TournamentID: 10, SportID: 1, Location: "gym1", Round: 1, MatchNumberInRound: 0, Status: "pending"},
+		}
+
+		result, err := Generate(testConfig(), matches)
+		require.NoError(t, err)
+		byID := assignmentsByID(result)
+
+		assert.Equal(t, models.ScheduleAssignment{MatchID: 1, StartTime: "2026-05-20 09:00:00", EndTime: "2026-05-20 09:30:00", Court: "A"}, byID[1])
+		assert.Equal(t, "2026-05-20 09:00:00", byID[2].StartTime)
+		assert.Equal(t, "B", byID[2].Court)
+		assert.Equal(t, "2026-05-20 09:30:00", byID[3].StartTime)
+		assert.Empty(t, result.Conflicts)
+	})
+
+	t.Run("never puts a class in two sports at once", func(t *testing.T) {
+		matches := []models.ScheduleMatch{
+			{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym1", ClassIDs: []int{101, 102}, Status: "pending"},
+			{MatchID: 2, TournamentID: 20, SportID: 2, Location: "ground", ClassIDs: []int{101, 103}, Status: "pending"},
+		}
+
+		result, err := Generate(testConfig(), matches)
+		require.NoError(t, err)
+		byID := assignmentsByID(result)
+
+		assert.Equal(t, "2026-05-20 09:00:00", byID[1].StartTime)
+		assert.Equal(t, "2026-05-20 09:30:00", byID[2].StartTime)
+		assert.Equal(t, "2026-05-20 09:50:00", byID[2].EndTime)
+		assert.Empty(t, result.Conflicts)
+	})
+
+	t.Run("keeps finished matches and skips byes", func(t *testing.T) {
+		matches := []models.ScheduleMatch{
+			{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym1", ClassIDs: []int{101, 102}, Status: "finished", StartTime: at("09:00"), Court: "A"},
+			{MatchID: 2, TournamentID: 10, SportID: 1, Location: "gym1", MatchNumberInRound: 1, ClassIDs: []int{103}, Status: models.MatchStatusBye},
+			{MatchID: 3, TournamentID: 10, SportID: 1, Location: "gym1", Round: 1, ClassIDs: []int{101, 103}, Status: "pending"},
+		}
+
+		result, err := Generate(testConfig(), matches)
+		require.NoError(t, err)
+
+		require.Len(t, result.Assignments, 1)
+		assert.Equal(t, 3, result.Assignments[0].MatchID)
+		assert.Equal(t, "2026-05-20 09:30:00", result.Assignments[0].StartTime)
+	})
+
+	t.Run("skips matches cancelled by a rainy plan", func(t *testing.T) {
+		matches := []models.ScheduleMatch{
+			{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym1", ClassIDs: []int{101, 102}, Status: models.MatchStatusCancelled},
+			{MatchID: 2, TournamentID: 20, SportID: 2, Location: "gym1", ClassIDs: []int{101, 103}, Status: "pending"},
+		}
+
+		result, err := Generate(testConfig(), matches)
+		require.NoError(t, err)
+
+		require.Len(t, result.Assignments, 1)
+		assert.Equal(t, 2, result.Assignments[0].MatchID)
+		assert.Equal(t, "2026-05-20 09:00:00", result.Assignments[0].StartTime)
+	})
+
+	t.Run("reports matches whose venue has no courts", func(t *testing.T) {
+		matches := []models.ScheduleMatch{
+			{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym2", Status: "pending"},
+		}
+
+		result, err := Generate(testConfig(), matches)
+		require.NoError(t, err)
+
+		assert.Empty(t, result.Assignments)
+		require.Len(t, result.Conflicts, 1)
+		assert.Equal(t, models.ScheduleConflictNoCourt, result.Conflicts[0].Type)
+		assert.Equal(t, []int{1}, result.Conflicts[0].MatchIDs)
+	})
+}
+
+func TestReflow(t *testing.T) {
+	matches := func() []models.ScheduleMatch {
+		return []models.ScheduleMatch{
+			{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym1", ClassIDs: []int{101, 102}, Status: "scheduled", StartTime: at("09:00"), Court: "A"},
+			{MatchID: 2, TournamentID: 10, SportID: 1, Location: "gym1", MatchNumberInRound: 1, ClassIDs: []int{103, 104}, Status: "scheduled", StartTime: at("09:00"), Court: "B"},
+			{MatchID: 3, TournamentID: 10, SportID: 1, Location: "gym1", Round: 1, Status: "pending", StartTime: at("09:30"), Court: "A"},
+			{MatchID: 4, TournamentID: 20, SportID: 2, Location: "ground", ClassIDs: []int{101, 105}, Status: "scheduled", StartTime: at("09:30"), Court: "1"},
+			{MatchID: 5, TournamentID: 20, SportID: 2, Location: "ground", MatchNumberInRound: 1, ClassIDs: []int{106, 107}, Status: "scheduled", StartTime: at("09:50"), Court: "1"},
+		}
+	}
+
+	t.Run("pushes back the later round and the late classes' other matches", func(t *testing.T) {
+		result, err := Reflow(testConfig(), matches(), 1, *at("09:45"))
+		require.NoError(t, err)
+		byID := assignmentsByID(result)
+
+		assert.Equal(t, "2026-05-20 09:45:00", byID[3].StartTime)
+		assert.Equal(t, "2026-05-20 09:45:00", byID[4].StartTime)
+		assert.Equal(t, "2026-05-20 10:05:00", byID[5].StartTime)
+		assert.NotContains(t, byID, 2)
+		assert.Empty(t, result.Conflicts)
+	})
+
+	t.Run("never moves matches earlier", func(t *testing.T) {
+		result, err := Reflow(testConfig(), matches(), 2, *at("09:35"))
+		require.NoError(t, err)
+		byID := assignmentsByID(result)
+
+		assert.Equal(t, "2026-05-20 09:35:00", byID[3].StartTime)
+		assert.NotContains(t, byID, 4)
+		assert.NotContains(t, byID, 5)
+	})
+
+	t.Run("rejects unknown or unscheduled matches", func(t *testing.T) {
+		_, err := Reflow(testConfig(), matches(), 99, *at("10:00"))
+		assert.ErrorIs(t, err, ErrMatchNotFound)
+
+		ms := matches()
+		ms[2].StartTime = nil
+		_, err = Reflow(testConfig(), ms, 3, *at("10:00"))
+		assert.ErrorIs(t, err, ErrMatchNotScheduled)
+
+		_, err = Reflow(testConfig(), matches(), 1, *at("08:30"))
+		assert.ErrorIs(t, err, ErrInvalidEndTime)
+	})
+}
+
+func TestCheckMatchTime(t *testing.T) {
+	matches := []models.ScheduleMatch{
+		{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym1", ClassIDs: []int{101, 102}, StudentIDs: []string{"a", "b"}, StartTime: at("09:00"), Court: "A"},
+		{MatchID: 2, TournamentID: 20, SportID: 2, Location: "ground", ClassIDs: []int{103, 104}, StudentIDs: []string{"b", "c"}, StartTime: at("10:00"), RainyModeStartTime: at("11:00"), Court: "1"},
+		{MatchID: 3, TournamentID: 30, SportID: 1, Location: "gym1", ClassIDs: []int{101, 105}, StartTime: at("11:00"), Court: "A"},
+	}
+
+	t.Run("flags students who are on teams in both matches", func(t *testing.T) {
+		conflicts := CheckMatchTime(testConfig(), matches, 2, *at("09:10"), false)
+
+		require.Len(t, conflicts, 1)
+		assert.Equal(t, models.ScheduleConflictStudent, conflicts[0].Type)
+		assert.Equal(t, []int{1, 2}, conflicts[0].MatchIDs)
+		assert.Equal(t, []string{"b"}, conflicts[0].UserIDs)
+	})
+
+	t.Run("flags a class playing two sports at once and ignores unrelated overlaps", func(t *testing.T) {
+		conflicts := CheckMatchTime(testConfig(), matches, 3, *at("09:00"), false)
+
+		require.Len(t, conflicts, 1)
+		assert.Equal(t, models.ScheduleConflictClass, conflicts[0].Type)
+		assert.Equal(t, 101, *conflicts[0].ClassID)
+	})
+
+	t.Run("checks the rainy mode schedule on its own times", func(t *testing.T) {
+		assert.Empty(t, CheckMatchTime(testConfig(), matches, 1, *at("10:00"), true))
+
+		conflicts := CheckMatchTime(testConfig(), matches, 1, *at("11:10"), true)
+		require.Len(t, conflicts, 2)
+		assert.Equal(t, models.ScheduleConflictStudent, conflicts[0].Type)
+		assert.Equal(t, models.ScheduleConflictClass, conflicts[1].Type)
+	})
+}
+
+func TestDetectConflicts(t *testing.T) {
+	matches := []models.ScheduleMatch{
+		{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym1", ClassIDs: []int{101, 102}, StartTime: at("09:00"), Court: "A"},
+		{MatchID: 2, TournamentID: 10, SportID: 1, Location: "gym1", MatchNumberInRound: 1, ClassIDs: []int{103, 104}, StartTime: at("09:10"), Court: "A"},
+		{MatchID: 3, TournamentID: 20, SportID: 2, Location: "ground", ClassIDs: []int{101, 105}, StartTime: at("09:20"), Court: "1"},
+		{MatchID: 4, TournamentID: 10, SportID: 1, Location: "gym1", Round: 1, StartTime: at("09:35"), Court: "B"},
+		{MatchID: 5, TournamentID: 30, SportID: 1, Location: "ground", Status: models.MatchStatusBye, StartTime: at("09:00"), Court: "1"},
+	}
+
+	conflicts := DetectConflicts(testConfig(), matches)
+
+	require.Len(t, conflicts, 3)
+	assert.Equal(t, models.ScheduleConflictCourt, conflicts[0].Type)
+	assert.Equal(t, []int{1, 2}, conflicts[0].MatchIDs)
+	assert.Equal(t, models.ScheduleConflictClass, conflicts[1].Type)
+	assert.Equal(t, []int{1, 3}, conflicts[1].MatchIDs)
+	assert.Equal(t, 101, *conflicts[1].ClassID)
+	assert.Equal(t, models.ScheduleConflictRoundOrder, conflicts[2].Type)
+	assert.Equal(t, []int{2, 4}, conflicts[2].MatchIDs)
+}
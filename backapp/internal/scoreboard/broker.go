@@ -0,0 +1,113 @@
+package scoreboard
+
+import "sync"
+
+// subscriberBuffer は購読者ごとに溜められるメッセージ数。溢れた購読者は切断する。
+const subscriberBuffer = 64
+
+// Message はスコアボードに配信する1件の変更。Seq はイベントごとに単調増加する。
+type Message struct {
+	Seq  uint64
+	Type string
+	Data interface{}
+}
+
+// Subscription はイベントの配信を受け取る購読。C は切断されると閉じられる。
+type Subscription struct {
+	C <-chan Message
+
+	ch      chan Message
+	eventID int
+	broker  *Broker
+}
+
+// Close は購読を解除する。何度呼んでもよい。
+func (s *Subscription) Close() {
+	s.broker.unsubscribe(s)
+}
+
+// Broker はイベントごとの購読者にメッセージを配信する
+type Broker struct {
+	mu   sync.Mutex
+	seq  map[int]uint64
+	subs map[int]map[*Subscription]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		seq:  make(map[int]uint64),
+		subs: make(map[int]map[*Subscription]struct{}),
+	}
+}
+
+func (b *Broker) Subscribe(eventID int) *Subscription {
+	ch := make(chan Message, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, eventID: eventID, broker: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs[eventID] == nil {
+		b.subs[eventID] = make(map[*Subscription]struct{})
+	}
+	b.subs[eventID][sub] = struct{}{}
+	return sub
+}
+
+func (b *Broker) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeLocked(sub)
+}
+
+func (b *Broker) removeLocked(sub *Subscription) {
+	subs := b.subs[sub.eventID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subs, sub.eventID)
+	}
+	close(sub.ch)
+}
+
+// Seq はイベントで最後に配信したメッセージの番号を返す
+func (b *Broker) Seq(eventID int) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.seq[eventID]
+}
+
+// HasSubscribers はイベントに購読者がいるかを返す
+func (b *Broker) HasSubscribers(eventID int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs[eventID]) > 0
+}
+
+// Publish はメッセージに番号を付けてイベントの購読者へ配信し、その番号を返す。
+// 受け取りが追いつかない購読者は待たずに切断し、再接続時のスナップショットで追いつかせる。
+func (b *Broker) Publish(eventID int, messageType string, data interface{}) uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq[eventID]++
+	msg := Message{Seq: b.seq[eventID], Type: messageType, Data: data}
+	for sub := range b.subs[eventID] {
+		select {
+		case sub.ch <- msg:
+		default:
+			b.removeLocked(sub)
+		}
+	}
+	return msg.Seq
+}
+
+// Disconnect はイベントの購読者をすべて切断する
+func (b *Broker) Disconnect(eventID int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs[eventID] {
+		b.removeLocked(sub)
+	}
+}
@@ -0,0 +1,84 @@
+package scoreboard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_Publish(t *testing.T) {
+	t.Run("numbers messages per event and delivers only to that event", func(t *testing.T) {
+		b := NewBroker()
+		sub1 := b.Subscribe(1)
+		defer sub1.Close()
+		sub2 := b.Subscribe(2)
+		defer sub2.Close()
+
+		assert.Equal(t, uint64(1), b.Publish(1, "class_scores", "a"))
+		assert.Equal(t, uint64(2), b.Publish(1, "class_scores", "b"))
+		assert.Equal(t, uint64(1), b.Publish(2, "match_result", "c"))
+
+		msg := <-sub1.C
+		assert.Equal(t, Message{Seq: 1, Type: "class_scores", Data: "a"}, msg)
+		msg = <-sub1.C
+		assert.Equal(t, uint64(2), msg.Seq)
+		msg = <-sub2.C
+		assert.Equal(t, Message{Seq: 1, Type: "match_result", Data: "c"}, msg)
+		assert.Equal(t, uint64(2), b.Seq(1))
+	})
+
+	t.Run("counts messages even without subscribers", func(t *testing.T) {
+		b := NewBroker()
+		b.Publish(1, "class_scores", nil)
+		assert.Equal(t, uint64(1), b.Seq(1))
+		assert.False(t, b.HasSubscribers(1))
+	})
+
+	t.Run("drops a subscriber that falls behind", func(t *testing.T) {
+		b := NewBroker()
+		slow := b.Subscribe(1)
+		defer slow.Close()
+
+		for i := 0; i < subscriberBuffer+1; i++ {
+			b.Publish(1, "class_scores", i)
+		}
+
+		received := 0
+		for range slow.C {
+			received++
+		}
+		assert.Equal(t, subscriberBuffer, received)
+		assert.False(t, b.HasSubscribers(1))
+	})
+}
+
+func TestBroker_Close(t *testing.T) {
+	t.Run("closing a subscription twice is safe", func(t *testing.T) {
+		b := NewBroker()
+		sub := b.Subscribe(1)
+		require.True(t, b.HasSubscribers(1))
+
+		sub.Close()
+		assert.NotPanics(t, sub.Close)
+		_, ok := <-sub.C
+		assert.False(t, ok)
+		assert.False(t, b.HasSubscribers(1))
+	})
+
+	t.Run("disconnect closes every subscriber of the event", func(t *testing.T) {
+		b := NewBroker()
+		sub1 := b.Subscribe(1)
+		sub2 := b.Subscribe(1)
+		other := b.Subscribe(2)
+		defer other.Close()
+
+		b.Disconnect(1)
+
+		_, ok := <-sub1.C
+		assert.False(t, ok)
+		_, ok = <-sub2.C
+		assert.False(t, ok)
+		assert.True(t, b.HasSubscribers(2))
+	})
+}
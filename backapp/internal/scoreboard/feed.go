@@ -0,0 +1,234 @@
+package scoreboard
+
+import (
+	"errors"
+	"log"
+	"sync"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+)
+
+var ErrEventNotFound = errors.New("event not found")
+
+// Feed はスコアボードの購読者に、接続時のスナップショットとその後の変更を配信する。
+// クラス得点はイベントごとに前回配信した値を覚えておき、変わったクラスだけを送る。
+type Feed struct {
+	broker         *Broker
+	eventRepo      repository.EventRepository
+	classRepo      repository.ClassRepository
+	tournRepo      repository.TournamentRepository
+	scoreboardRepo repository.ScoreboardRepository
+
+	mu         sync.Mutex
+	lastScores map[int]map[int]models.ScoreboardClassScore
+}
+
+func NewFeed(eventRepo repository.EventRepository, classRepo repository.ClassRepository, tournRepo repository.TournamentRepository, scoreboardRepo repository.ScoreboardRepository) *Feed {
+	return &Feed{
+		broker:         NewBroker(),
+		eventRepo:      eventRepo,
+		classRepo:      classRepo,
+		tournRepo:      tournRepo,
+		scoreboardRepo: scoreboardRepo,
+		lastScores:     make(map[int]map[int]models.ScoreboardClassScore),
+	}
+}
+
+func (f *Feed) Subscribe(eventID int) *Subscription {
+	return f.broker.Subscribe(eventID)
+}
+
+// Disconnect はイベントの購読者をすべて切断する。トークンを失効させたときに使う。
+func (f *Feed) Disconnect(eventID int) {
+	f.broker.Disconnect(eventID)
+}
+
+// Snapshot は現在のスコアボードと、それに反映済みの最後のメッセージ番号を返す。
+// 購読してから呼び出し、番号がそれ以下のメッセージは読み飛ばせばよい。
+func (f *Feed) Snapshot(eventID int) (*models.ScoreboardSnapshot, uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// 番号を先に読むことで、それ以前に配信された変更はすべてスナップショットに含まれる
+	seq := f.broker.Seq(eventID)
+	snapshot, err := f.buildSnapshotLocked(eventID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return snapshot, seq, nil
+}
+
+// Resync は現在のスナップショットを購読者全員に送り直す。
+// 得点の非表示設定のように、差分では表せない変更のあとに使う。
+func (f *Feed) Resync(eventID int) {
+	if !f.broker.HasSubscribers(eventID) {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	snapshot, err := f.buildSnapshotLocked(eventID)
+	if err != nil {
+		log.Printf("Scoreboard resync error: event_id=%d err=%v", eventID, err)
+		return
+	}
+	f.broker.Publish(eventID, models.ScoreboardMessageSnapshot, snapshot)
+}
+
+// MatchUpdated は試合結果と、勝者が進んだ次の試合の割り当てを配信し、クラス得点の変化も送る
+func (f *Feed) MatchUpdated(matchID int) {
+	match, err := f.scoreboardRepo.GetMatch(matchID)
+	if err != nil {
+		log.Printf("Scoreboard GetMatch error: match_id=%d err=%v", matchID, err)
+		return
+	}
+	if match == nil || !f.broker.HasSubscribers(match.EventID) {
+		return
+	}
+
+	f.broker.Publish(match.EventID, models.ScoreboardMessageMatchResult, match)
+	if match.NextMatchID != nil {
+		next, err := f.scoreboardRepo.GetMatch(*match.NextMatchID)
+		if err != nil {
+			log.Printf("Scoreboard GetMatch error: match_id=%d err=%v", *match.NextMatchID, err)
+		} else if next != nil {
+			f.broker.Publish(match.EventID, models.ScoreboardMessageNextMatch, next)
+		}
+	}
+
+	f.ClassScoresChanged(match.EventID)
+}
+
+// ClassScoresChanged は前回から得点か順位が変わったクラスだけを配信する。
+// 得点が非表示のイベントでは何も送らない。
+func (f *Feed) ClassScoresChanged(eventID int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.broker.HasSubscribers(eventID) {
+		// 購読者がいない間の差分は追えないので、次の接続時のスナップショットから数え直す
+		delete(f.lastScores, eventID)
+		return
+	}
+
+	hidden, err := f.scoresHidden(eventID)
+	if err != nil {
+		log.Printf("Scoreboard GetEventByID error: event_id=%d err=%v", eventID, err)
+		return
+	}
+	if hidden {
+		return
+	}
+
+	scores, err := f.loadClassScores(eventID)
+	if err != nil {
+		log.Printf("Scoreboard GetClassScoresByEvent error: event_id=%d err=%v", eventID, err)
+		return
+	}
+
+	last := f.lastScores[eventID]
+	changed := make([]models.ScoreboardClassScore, 0)
+	for _, score := range scores {
+		if prev, ok := last[score.ClassID]; !ok || prev != score {
+			changed = append(changed, score)
+		}
+	}
+	f.lastScores[eventID] = indexScores(scores)
+
+	if len(changed) > 0 {
+		f.broker.Publish(eventID, models.ScoreboardMessageClassScores, changed)
+	}
+}
+
+// NoonGamePointsChanged は昼競技の得点をクラスごとに配信する。得点が非表示のイベントでは送らない。
+func (f *Feed) NoonGamePointsChanged(eventID int, points map[int]int) {
+	if !f.broker.HasSubscribers(eventID) {
+		return
+	}
+
+	hidden, err := f.scoresHidden(eventID)
+	if err != nil {
+		log.Printf("Scoreboard GetEventByID error: event_id=%d err=%v", eventID, err)
+		return
+	}
+	if hidden {
+		return
+	}
+
+	f.broker.Publish(eventID, models.ScoreboardMessageNoonGamePoints, points)
+}
+
+func (f *Feed) buildSnapshotLocked(eventID int) (*models.ScoreboardSnapshot, error) {
+	event, err := f.eventRepo.GetEventByID(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, ErrEventNotFound
+	}
+
+	tournaments, err := f.tournRepo.GetTournamentsByEventID(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &models.ScoreboardSnapshot{
+		EventID:      event.ID,
+		EventName:    event.Name,
+		ScoresHidden: event.HideScores,
+		ClassScores:  []models.ScoreboardClassScore{},
+		Tournaments:  tournaments,
+	}
+	if event.HideScores {
+		delete(f.lastScores, eventID)
+		return snapshot, nil
+	}
+
+	scores, err := f.loadClassScores(eventID)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.ClassScores = scores
+	f.lastScores[eventID] = indexScores(scores)
+	return snapshot, nil
+}
+
+func (f *Feed) scoresHidden(eventID int) (bool, error) {
+	event, err := f.eventRepo.GetEventByID(eventID)
+	if err != nil {
+		return false, err
+	}
+	if event == nil {
+		return false, ErrEventNotFound
+	}
+	return event.HideScores, nil
+}
+
+func (f *Feed) loadClassScores(eventID int) ([]models.ScoreboardClassScore, error) {
+	classScores, err := f.classRepo.GetClassScoresByEvent(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]models.ScoreboardClassScore, 0, len(classScores))
+	for _, cs := range classScores {
+		scores = append(scores, models.ScoreboardClassScore{
+			ClassID:        cs.ClassID,
+			ClassName:      cs.ClassName,
+			TotalPoints:    cs.TotalPointsCurrentEvent,
+			Rank:           cs.RankCurrentEvent,
+			NoonGamePoints: cs.NoonGamePoints,
+		})
+	}
+	return scores, nil
+}
+
+func indexScores(scores []models.ScoreboardClassScore) map[int]models.ScoreboardClassScore {
+	index := make(map[int]models.ScoreboardClassScore, len(scores))
+	for _, score := range scores {
+		index[score.ClassID] = score
+	}
+	return index
+}
@@ -433,3 +433,106 @@ func TestGenerateAllTournamentsPreview_LoserBracketFirstRoundMatchMapping(t *tes
 		// The actual loser assignment happens when match results are recorded.
 	})
 }
+
+func TestGenerateAllTournamentsPreview_NonPowerOfTwoTeamsGetByes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockTournRepo := new(MockTournamentRepository)
+	mockSportRepo := new(MockSportRepository)
+	mockTeamRepo := new(MockTeamRepository)
+	mockClassRepo := new(MockClassRepository)
+	mockEventRepo := new(MockEventRepository)
+	hubManager := websocket.NewHubManager()
+
+	h := handler.NewTournamentHandler(mockTournRepo, mockSportRepo, mockTeamRepo, mockClassRepo, mockEventRepo, hubManager)
+
+	eventID := 1
+	sportID := 1
+	mockSportRepo.On("GetSportsByEventID", eventID).Return([]*models.EventSport{
+		{EventID: eventID, SportID: sportID, Location: "gym2"},
+	}, nil).Once()
+	mockSportRepo.On("GetSportByID", sportID).Return(&models.Sport{ID: sportID, Name: "Basketball"}, nil).Once()
+
+	// 6チームは8枠のトーナメントになり、一回戦に2つの不戦勝が入る
+	teams := make([]*models.Team, 6)
+	for i := range teams {
+		teams[i] = &models.Team{ID: i + 1, Name: "Team " + string(rune('A'+i)), ClassID: i + 1, SportID: sportID, EventID: eventID}
+	}
+	mockSportRepo.On("GetTeamsBySportID", sportID).Return(teams, nil).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	h.GenerateAllTournamentsPreviewHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var tournaments []models.GeneratedTournament
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tournaments))
+	// 不戦勝を含むため gym2 でも敗者戦は生成されない
+	assert.Len(t, tournaments, 1)
+
+	data := tournaments[0].TournamentData
+	assert.Len(t, data.Rounds, 3)
+	assert.Len(t, tournaments[0].ShuffledTeams, 6)
+
+	byeOrders := []int{}
+	usedContestants := map[string]bool{}
+	firstRoundCount := 0
+	for _, match := range data.Matches {
+		if match.RoundIndex != 0 || match.IsBronzeMatch {
+			continue
+		}
+		firstRoundCount++
+		for _, side := range match.Sides {
+			if side.ContestantID != "" {
+				assert.False(t, usedContestants[side.ContestantID], "contestant %s must appear once", side.ContestantID)
+				usedContestants[side.ContestantID] = true
+			}
+		}
+		if match.IsBye {
+			byeOrders = append(byeOrders, match.Order)
+			assert.NotEmpty(t, match.Sides[0].ContestantID)
+			assert.Empty(t, match.Sides[1].ContestantID)
+		}
+	}
+	assert.Equal(t, 4, firstRoundCount)
+	assert.Len(t, usedContestants, 6)
+	// 不戦勝はブラケットの上下に分散される
+	assert.ElementsMatch(t, []int{0, 2}, byeOrders)
+
+	mockSportRepo.AssertExpectations(t)
+}
+
+func TestGenerateAllTournamentsPreview_ThreeTeamsHasNoBronzeMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockTournRepo := new(MockTournamentRepository)
+	mockSportRepo := new(MockSportRepository)
+	hubManager := websocket.NewHubManager()
+	h := handler.NewTournamentHandler(mockTournRepo, mockSportRepo, new(MockTeamRepository), new(MockClassRepository), new(MockEventRepository), hubManager)
+
+	mockSportRepo.On("GetSportsByEventID", 1).Return([]*models.EventSport{
+		{EventID: 1, SportID: 1, Location: "gym1"},
+	}, nil).Once()
+	mockSportRepo.On("GetSportByID", 1).Return(&models.Sport{ID: 1, Name: "Volleyball"}, nil).Once()
+	teams := append(makeTournamentPreviewTeams(1, 1, 10), &models.Team{ID: 12, Name: "Team C", ClassID: 12, SportID: 1, EventID: 1})
+	mockSportRepo.On("GetTeamsBySportID", 1).Return(teams, nil).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	h.GenerateAllTournamentsPreviewHandler(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var tournaments []models.GeneratedTournament
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tournaments))
+	assert.Len(t, tournaments, 1)
+	for _, match := range tournaments[0].TournamentData.Matches {
+		assert.False(t, match.IsBronzeMatch, "準決勝に不戦勝がある場合は3位決定戦を作らない")
+	}
+	assert.Len(t, tournaments[0].TournamentData.Matches, 3)
+}
@@ -0,0 +1,197 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backapp/internal/handler"
+	"backapp/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttendanceHandler_CheckInHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventID := 1
+	classID := 5
+	student := &models.User{ID: "student-1", Email: "s2301059@sendai-nct.jp", ClassID: &classID}
+
+	t.Run("読み取った生徒を記録してクラスの出席点を更新する", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		eventRepo := new(MockEventRepository)
+		checkInRepo := new(MockCheckInRepository)
+		userRepo := new(MockUserRepository)
+		h := handler.NewAttendanceHandler(classRepo, eventRepo).WithCheckIns(checkInRepo, userRepo)
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+		userRepo.On("FindUsers", "s2301059", "email").Return([]*models.User{student}, nil).Once()
+		classRepo.On("GetClassByID", classID).Return(&models.Class{ID: classID, EventID: &eventID, Name: "IS3", StudentCount: 40}, nil).Once()
+		checkInRepo.On("CheckIn", eventID, "student-1", models.CheckInPurposeEventParticipation, "admin-1").
+			Return(&models.CheckIn{ID: 10, UserID: "student-1", EventID: eventID, Purpose: models.CheckInPurposeEventParticipation}, true, nil).Once()
+		checkInRepo.On("CountClassCheckIns", eventID, classID).Return(30, nil).Once()
+		classRepo.On("UpdateAttendance", classID, eventID, 30, "admin-1").Return(8, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(map[string]string{"barcode_data": "H1023010590"})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/admin/attendance/check-in", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, false, response["already_checked_in"])
+		assert.Equal(t, "IS3", response["class_name"])
+		assert.Equal(t, float64(30), response["attend_count"])
+		assert.Equal(t, float64(8), response["points"])
+		checkInRepo.AssertExpectations(t)
+		classRepo.AssertExpectations(t)
+	})
+
+	t.Run("読み取り直しても出席人数は在籍人数を超えない", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		eventRepo := new(MockEventRepository)
+		checkInRepo := new(MockCheckInRepository)
+		userRepo := new(MockUserRepository)
+		h := handler.NewAttendanceHandler(classRepo, eventRepo).WithCheckIns(checkInRepo, userRepo)
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+		userRepo.On("FindUsers", "s2301059", "email").Return([]*models.User{student}, nil).Once()
+		classRepo.On("GetClassByID", classID).Return(&models.Class{ID: classID, EventID: &eventID, Name: "IS3", StudentCount: 40}, nil).Once()
+		checkInRepo.On("CheckIn", eventID, "student-1", models.CheckInPurposeOpeningCeremony, "admin-1").
+			Return(&models.CheckIn{ID: 10, UserID: "student-1", EventID: eventID, Purpose: models.CheckInPurposeOpeningCeremony}, false, nil).Once()
+		checkInRepo.On("CountClassCheckIns", eventID, classID).Return(41, nil).Once()
+		classRepo.On("UpdateAttendance", classID, eventID, 40, "admin-1").Return(10, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(map[string]string{"barcode_data": "H1023010590", "purpose": models.CheckInPurposeOpeningCeremony})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/admin/attendance/check-in", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, true, response["already_checked_in"])
+		assert.Equal(t, float64(40), response["attend_count"])
+		classRepo.AssertExpectations(t)
+	})
+
+	t.Run("開催中の大会のクラスでない生徒は記録しない", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		eventRepo := new(MockEventRepository)
+		checkInRepo := new(MockCheckInRepository)
+		userRepo := new(MockUserRepository)
+		h := handler.NewAttendanceHandler(classRepo, eventRepo).WithCheckIns(checkInRepo, userRepo)
+		previousEventID := 2
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+		userRepo.On("FindUsers", "s2301059", "email").Return([]*models.User{student}, nil).Once()
+		classRepo.On("GetClassByID", classID).Return(&models.Class{ID: classID, EventID: &previousEventID, Name: "IS2"}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(map[string]string{"barcode_data": "H1023010590"})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/admin/attendance/check-in", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		checkInRepo.AssertNotCalled(t, "CheckIn", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("バーコード形式が不正なら400", func(t *testing.T) {
+		eventRepo := new(MockEventRepository)
+		userRepo := new(MockUserRepository)
+		h := handler.NewAttendanceHandler(new(MockClassRepository), eventRepo).WithCheckIns(new(MockCheckInRepository), userRepo)
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(map[string]string{"barcode_data": "X999"})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/admin/attendance/check-in", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		userRepo.AssertNotCalled(t, "FindUsers", mock.Anything, mock.Anything)
+	})
+
+	t.Run("目的が不正なら400", func(t *testing.T) {
+		eventRepo := new(MockEventRepository)
+		h := handler.NewAttendanceHandler(new(MockClassRepository), eventRepo).WithCheckIns(new(MockCheckInRepository), new(MockUserRepository))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(map[string]string{"barcode_data": "H1023010590", "purpose": "lunch"})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/admin/attendance/check-in", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		eventRepo.AssertNotCalled(t, "GetActiveEvent")
+	})
+
+	t.Run("学生でなければ読み取れない", func(t *testing.T) {
+		eventRepo := new(MockEventRepository)
+		h := handler.NewAttendanceHandler(new(MockClassRepository), eventRepo).WithCheckIns(new(MockCheckInRepository), new(MockUserRepository))
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(map[string]string{"barcode_data": "H1023010590"})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/admin/attendance/check-in", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		c.Set("user", &models.User{ID: "student-2", Roles: []models.Role{{Name: "student"}}})
+		h.CheckInHandler(c)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestAttendanceHandler_GetUncheckedMembersHandler(t *testing.T) {
+	t.Run("まだチェックインしていない生徒を返す", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		eventRepo := new(MockEventRepository)
+		checkInRepo := new(MockCheckInRepository)
+		h := handler.NewAttendanceHandler(classRepo, eventRepo).WithCheckIns(checkInRepo, new(MockUserRepository))
+		eventID := 1
+		name := "未到着の生徒"
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+		classRepo.On("GetClassByID", 5).Return(&models.Class{ID: 5, EventID: &eventID, Name: "IS3", StudentCount: 3}, nil).Once()
+		checkInRepo.On("CountClassCheckIns", eventID, 5).Return(2, nil).Once()
+		checkInRepo.On("GetUncheckedClassMembers", eventID, 5).Return([]*models.User{{ID: "student-3", Email: "s2301060@sendai-nct.jp", DisplayName: &name}}, nil).Once()
+
+		gin.SetMode(gin.TestMode)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "classID", Value: "5"}}
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/admin/attendance/classes/5/unchecked", nil)
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+
+		h.GetUncheckedMembersHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			CheckedInCount   int            `json:"checked_in_count"`
+			UncheckedCount   int            `json:"unchecked_count"`
+			UncheckedMembers []*models.User `json:"unchecked_members"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.CheckedInCount)
+		assert.Equal(t, 1, response.UncheckedCount)
+		require.Len(t, response.UncheckedMembers, 1)
+		assert.Equal(t, "student-3", response.UncheckedMembers[0].ID)
+	})
+}
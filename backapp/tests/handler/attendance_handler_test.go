@@ -108,7 +108,7 @@ func TestRegisterAttendanceHandler(t *testing.T) {
 		class := &models.Class{ID: 1, EventID: &activeEventID, Name: "Test Class", StudentCount: 25}
 		mockEventRepo.On("GetActiveEvent").Return(activeEventID, nil).Once()
 		mockClassRepo.On("GetClassByID", reqBody.ClassID).Return(class, nil).Once()
-		mockClassRepo.On("UpdateAttendance", reqBody.ClassID, activeEventID, reqBody.AttendanceCount).Return(10, nil).Once()
+		mockClassRepo.On("UpdateAttendance", reqBody.ClassID, activeEventID, reqBody.AttendanceCount, "test-user-id").Return(10, nil).Once()
 
 		h := handler.NewAttendanceHandler(mockClassRepo, mockEventRepo)
 
@@ -225,7 +225,7 @@ func TestRegisterAttendanceHandler(t *testing.T) {
 		mockEventRepo.On("GetActiveEvent").Return(activeEventID, nil).Once()
 		class := &models.Class{ID: 2, EventID: &activeEventID, StudentCount: 20}
 		mockClassRepo.On("GetClassByID", 2).Return(class, nil).Once()
-		mockClassRepo.On("UpdateAttendance", 2, activeEventID, 18).Return(0, nil).Once()
+		mockClassRepo.On("UpdateAttendance", 2, activeEventID, 18, "test-user-id").Return(0, nil).Once()
 
 		h := handler.NewAttendanceHandler(mockClassRepo, mockEventRepo)
 
@@ -0,0 +1,290 @@
+package handler_test
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backapp/internal/handler"
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func batchScan(key string, barcode string, scannedAt *time.Time) models.RoundCheckInScan {
+	return models.RoundCheckInScan{
+		IdempotencyKey: key,
+		ScannedAt:      scannedAt,
+		BarcodeCheckInRequest: models.BarcodeCheckInRequest{
+			BarcodeData: barcode,
+			EventID:     1,
+			SportID:     2,
+			MatchID:     100,
+		},
+	}
+}
+
+func TestBarcodeHandler_CheckInRoundBatchHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	scannedAt := time.Date(2026, 5, 20, 9, 15, 0, 0, time.UTC)
+
+	t.Run("読み取り時刻で記録し1件ずつ結果を返す", func(t *testing.T) {
+		teamRepo := new(MockTeamRepository)
+		userRepo := new(MockUserRepository)
+		tournRepo := new(MockTournamentRepository)
+		scanRepo := new(MockRoundCheckInScanRepository)
+		h := handler.NewBarcodeHandler(teamRepo, new(MockSportRepository), userRepo, new(MockEventRepository), new(MockClassRepository), tournRepo).
+			WithRoundCheckInScans(scanRepo)
+		scanRepo.On("GetScanResult", "scan-1").Return(nil, nil).Once()
+		// MyID "H1023010590" の生徒が試合100に出るチームに事前エントリーされている
+		userRepo.On("FindUsers", "s2301059", "email").Return([]*models.User{{ID: "user-1", Email: "s2301059@example.com"}}, nil).Once()
+		teamRepo.On("GetTeamsByUserID", "user-1").Return([]*models.TeamWithSport{{ID: 10, Name: "1A", ClassID: 1, EventID: 1, SportID: 2, SportName: "バスケットボール"}}, nil).Once()
+		tournRepo.On("GetMatchForEventSport", 100, 1, 2).Return(&models.MatchDB{ID: 100, Round: 0, Team1ID: sql.NullInt64{Int64: 10, Valid: true}}, nil).Once()
+		teamRepo.On("GetTeamByClassAndSport", 1, 2, 1).Return(&models.Team{ID: 10, ClassID: 1, EventID: 1, SportID: 2}, nil).Once()
+		teamRepo.On("ConfirmTeamMember", 10, "user-1").Return(nil).Once()
+		teamRepo.On("CheckInRoundAt", 10, "user-1", 1, 2, 100, 1, scannedAt).Return(nil).Once()
+		scanRepo.On("SaveScanResult", "admin-1", mock.Anything, scannedAt, mock.MatchedBy(func(r *models.RoundCheckInScanResult) bool {
+			return r.Status == models.RoundCheckInScanStatusCheckedIn && r.HTTPStatus == http.StatusOK
+		})).Return(nil).Once()
+		// 事前エントリーされていない生徒
+		scanRepo.On("GetScanResult", "scan-2").Return(nil, nil).Once()
+		userRepo.On("FindUsers", "s2301060", "email").Return([]*models.User{{ID: "user-2", Email: "s2301060@example.com"}}, nil).Once()
+		teamRepo.On("GetTeamsByUserID", "user-2").Return([]*models.TeamWithSport{}, nil).Once()
+		scanRepo.On("SaveScanResult", "admin-1", mock.Anything, scannedAt, mock.MatchedBy(func(r *models.RoundCheckInScanResult) bool {
+			return r.Status == models.RoundCheckInScanStatusRejected && r.HTTPStatus == http.StatusForbidden
+		})).Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, err := json.Marshal(models.RoundCheckInBatchRequest{Scans: []models.RoundCheckInScan{
+			batchScan("scan-1", "H1023010590", &scannedAt),
+			batchScan("scan-2", "H1023010600", &scannedAt),
+		}})
+		require.NoError(t, err)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/barcode/check-in/batch", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInRoundBatchHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Results []*models.RoundCheckInScanResult `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Results, 2)
+		assert.Equal(t, "scan-1", response.Results[0].IdempotencyKey)
+		assert.Equal(t, models.RoundCheckInScanStatusCheckedIn, response.Results[0].Status)
+		assert.Equal(t, float64(1), response.Results[0].Body["round"])
+		assert.False(t, response.Results[0].Replayed)
+		assert.Equal(t, models.RoundCheckInScanStatusRejected, response.Results[1].Status)
+		assert.Equal(t, http.StatusForbidden, response.Results[1].HTTPStatus)
+		teamRepo.AssertExpectations(t)
+		scanRepo.AssertExpectations(t)
+	})
+
+	t.Run("チェックイン済みの生徒は already_checked_in として残す", func(t *testing.T) {
+		teamRepo := new(MockTeamRepository)
+		userRepo := new(MockUserRepository)
+		tournRepo := new(MockTournamentRepository)
+		scanRepo := new(MockRoundCheckInScanRepository)
+		h := handler.NewBarcodeHandler(teamRepo, new(MockSportRepository), userRepo, new(MockEventRepository), new(MockClassRepository), tournRepo).
+			WithRoundCheckInScans(scanRepo)
+		scanRepo.On("GetScanResult", "scan-1").Return(nil, nil).Once()
+		// MyID "H1023010590" の生徒が試合100に出るチームに事前エントリーされている
+		userRepo.On("FindUsers", "s2301059", "email").Return([]*models.User{{ID: "user-1", Email: "s2301059@example.com"}}, nil).Once()
+		teamRepo.On("GetTeamsByUserID", "user-1").Return([]*models.TeamWithSport{{ID: 10, Name: "1A", ClassID: 1, EventID: 1, SportID: 2, SportName: "バスケットボール"}}, nil).Once()
+		tournRepo.On("GetMatchForEventSport", 100, 1, 2).Return(&models.MatchDB{ID: 100, Round: 0, Team1ID: sql.NullInt64{Int64: 10, Valid: true}}, nil).Once()
+		teamRepo.On("GetTeamByClassAndSport", 1, 2, 1).Return(&models.Team{ID: 10, ClassID: 1, EventID: 1, SportID: 2}, nil).Once()
+		teamRepo.On("ConfirmTeamMember", 10, "user-1").Return(nil).Once()
+		teamRepo.On("CheckInRoundAt", 10, "user-1", 1, 2, 100, 1, scannedAt).Return(repository.ErrRoundAlreadyCheckedIn).Once()
+		scanRepo.On("SaveScanResult", "admin-1", mock.Anything, scannedAt, mock.MatchedBy(func(r *models.RoundCheckInScanResult) bool {
+			return r.Status == models.RoundCheckInScanStatusAlreadyCheckedIn
+		})).Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, err := json.Marshal(models.RoundCheckInBatchRequest{Scans: []models.RoundCheckInScan{
+			batchScan("scan-1", "H1023010590", &scannedAt),
+		}})
+		require.NoError(t, err)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/barcode/check-in/batch", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInRoundBatchHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Results []*models.RoundCheckInScanResult `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Results, 1)
+		assert.Equal(t, models.RoundCheckInScanStatusAlreadyCheckedIn, response.Results[0].Status)
+		assert.Equal(t, http.StatusConflict, response.Results[0].HTTPStatus)
+		scanRepo.AssertExpectations(t)
+	})
+
+	t.Run("処理済みのキーは前回の結果を返し記録し直さない", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		scanRepo := new(MockRoundCheckInScanRepository)
+		h := handler.NewBarcodeHandler(new(MockTeamRepository), new(MockSportRepository), userRepo, new(MockEventRepository), new(MockClassRepository), new(MockTournamentRepository)).
+			WithRoundCheckInScans(scanRepo)
+		scanRepo.On("GetScanResult", "scan-1").Return(&models.RoundCheckInScanResult{
+			IdempotencyKey: "scan-1",
+			Status:         models.RoundCheckInScanStatusCheckedIn,
+			HTTPStatus:     http.StatusOK,
+			Body:           map[string]interface{}{"checked_in": true},
+		}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, err := json.Marshal(models.RoundCheckInBatchRequest{Scans: []models.RoundCheckInScan{
+			batchScan("scan-1", "H1023010590", &scannedAt),
+			batchScan("scan-1", "H1023010590", &scannedAt),
+		}})
+		require.NoError(t, err)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/barcode/check-in/batch", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInRoundBatchHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Results []*models.RoundCheckInScanResult `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Results, 2)
+		for _, result := range response.Results {
+			assert.True(t, result.Replayed)
+			assert.Equal(t, models.RoundCheckInScanStatusCheckedIn, result.Status)
+		}
+		userRepo.AssertNotCalled(t, "FindUsers", mock.Anything, mock.Anything)
+		scanRepo.AssertNotCalled(t, "SaveScanResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		scanRepo.AssertExpectations(t)
+	})
+
+	t.Run("サーバー側で失敗した読み取りは結果を残さず再送できるようにする", func(t *testing.T) {
+		teamRepo := new(MockTeamRepository)
+		userRepo := new(MockUserRepository)
+		tournRepo := new(MockTournamentRepository)
+		scanRepo := new(MockRoundCheckInScanRepository)
+		h := handler.NewBarcodeHandler(teamRepo, new(MockSportRepository), userRepo, new(MockEventRepository), new(MockClassRepository), tournRepo).
+			WithRoundCheckInScans(scanRepo)
+		scanRepo.On("GetScanResult", "scan-1").Return(nil, nil).Once()
+		// MyID "H1023010590" の生徒が試合100に出るチームに事前エントリーされている
+		userRepo.On("FindUsers", "s2301059", "email").Return([]*models.User{{ID: "user-1", Email: "s2301059@example.com"}}, nil).Once()
+		teamRepo.On("GetTeamsByUserID", "user-1").Return([]*models.TeamWithSport{{ID: 10, Name: "1A", ClassID: 1, EventID: 1, SportID: 2, SportName: "バスケットボール"}}, nil).Once()
+		tournRepo.On("GetMatchForEventSport", 100, 1, 2).Return(&models.MatchDB{ID: 100, Round: 0, Team1ID: sql.NullInt64{Int64: 10, Valid: true}}, nil).Once()
+		teamRepo.On("GetTeamByClassAndSport", 1, 2, 1).Return(&models.Team{ID: 10, ClassID: 1, EventID: 1, SportID: 2}, nil).Once()
+		teamRepo.On("ConfirmTeamMember", 10, "user-1").Return(nil).Once()
+		teamRepo.On("CheckInRoundAt", 10, "user-1", 1, 2, 100, 1, scannedAt).Return(errors.New("db error")).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, err := json.Marshal(models.RoundCheckInBatchRequest{Scans: []models.RoundCheckInScan{
+			batchScan("scan-1", "H1023010590", &scannedAt),
+		}})
+		require.NoError(t, err)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/barcode/check-in/batch", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInRoundBatchHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Results []*models.RoundCheckInScanResult `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Results, 1)
+		assert.Equal(t, models.RoundCheckInScanStatusFailed, response.Results[0].Status)
+		scanRepo.AssertNotCalled(t, "SaveScanResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("未来の読み取り時刻は受け取った時刻に置き換える", func(t *testing.T) {
+		teamRepo := new(MockTeamRepository)
+		userRepo := new(MockUserRepository)
+		tournRepo := new(MockTournamentRepository)
+		scanRepo := new(MockRoundCheckInScanRepository)
+		h := handler.NewBarcodeHandler(teamRepo, new(MockSportRepository), userRepo, new(MockEventRepository), new(MockClassRepository), tournRepo).
+			WithRoundCheckInScans(scanRepo)
+		future := time.Now().Add(time.Hour)
+		scanRepo.On("GetScanResult", "scan-1").Return(nil, nil).Once()
+		// MyID "H1023010590" の生徒が試合100に出るチームに事前エントリーされている
+		userRepo.On("FindUsers", "s2301059", "email").Return([]*models.User{{ID: "user-1", Email: "s2301059@example.com"}}, nil).Once()
+		teamRepo.On("GetTeamsByUserID", "user-1").Return([]*models.TeamWithSport{{ID: 10, Name: "1A", ClassID: 1, EventID: 1, SportID: 2, SportName: "バスケットボール"}}, nil).Once()
+		tournRepo.On("GetMatchForEventSport", 100, 1, 2).Return(&models.MatchDB{ID: 100, Round: 0, Team1ID: sql.NullInt64{Int64: 10, Valid: true}}, nil).Once()
+		teamRepo.On("GetTeamByClassAndSport", 1, 2, 1).Return(&models.Team{ID: 10, ClassID: 1, EventID: 1, SportID: 2}, nil).Once()
+		teamRepo.On("ConfirmTeamMember", 10, "user-1").Return(nil).Once()
+		beforeNow := mock.MatchedBy(func(at time.Time) bool { return at.Before(future) })
+		teamRepo.On("CheckInRoundAt", 10, "user-1", 1, 2, 100, 1, beforeNow).Return(nil).Once()
+		scanRepo.On("SaveScanResult", "admin-1", mock.Anything, beforeNow, mock.Anything).Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, err := json.Marshal(models.RoundCheckInBatchRequest{Scans: []models.RoundCheckInScan{
+			batchScan("scan-1", "H1023010590", &future),
+		}})
+		require.NoError(t, err)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/barcode/check-in/batch", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInRoundBatchHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		teamRepo.AssertExpectations(t)
+	})
+
+	t.Run("冪等キーがない読み取りは処理しない", func(t *testing.T) {
+		scanRepo := new(MockRoundCheckInScanRepository)
+		h := handler.NewBarcodeHandler(new(MockTeamRepository), new(MockSportRepository), new(MockUserRepository), new(MockEventRepository), new(MockClassRepository), new(MockTournamentRepository)).
+			WithRoundCheckInScans(scanRepo)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, err := json.Marshal(models.RoundCheckInBatchRequest{Scans: []models.RoundCheckInScan{
+			batchScan(" ", "H1023010590", &scannedAt),
+		}})
+		require.NoError(t, err)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/barcode/check-in/batch", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInRoundBatchHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Results []*models.RoundCheckInScanResult `json:"results"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Results, 1)
+		assert.Equal(t, models.RoundCheckInScanStatusRejected, response.Results[0].Status)
+		scanRepo.AssertNotCalled(t, "GetScanResult", mock.Anything)
+	})
+
+	t.Run("件数が上限を超えれば400", func(t *testing.T) {
+		h := handler.NewBarcodeHandler(new(MockTeamRepository), new(MockSportRepository), new(MockUserRepository), new(MockEventRepository), new(MockClassRepository), new(MockTournamentRepository)).
+			WithRoundCheckInScans(new(MockRoundCheckInScanRepository))
+		scans := make([]models.RoundCheckInScan, 201)
+		for i := range scans {
+			scans[i] = batchScan("scan", "H1023010590", &scannedAt)
+		}
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, err := json.Marshal(models.RoundCheckInBatchRequest{Scans: scans})
+		require.NoError(t, err)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/barcode/check-in/batch", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInRoundBatchHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -322,6 +322,54 @@ func TestClassHandler_GetClassProgress(t *testing.T) {
 		mockTournamentRepo.AssertExpectations(t)
 	})
 
+	t.Run("day_id を指定するとその開催日の試合だけ返す", func(t *testing.T) {
+		h, mockClassRepo, mockEventRepo, mockTeamRepo, mockTournamentRepo := newHandler()
+
+		firstDay := *matchDetails[0]
+		firstDay.EventDayID = sql.NullInt64{Int64: 7, Valid: true}
+		secondDay := *matchDetails[1]
+		secondDay.EventDayID = sql.NullInt64{Int64: 8, Valid: true}
+
+		mockEventRepo.On("GetActiveEvent").Return(1, nil).Once()
+		mockClassRepo.On("GetClassByID", class.ID).Return(class, nil).Once()
+		mockClassRepo.On("GetClassMembers", class.ID).Return(members, nil).Once()
+		mockTeamRepo.On("GetTeamsByClassID", class.ID, 1).Return([]*models.TeamWithSport{team}, nil).Once()
+		mockTeamRepo.On("GetNoonGameTeamsByClassID", class.ID, 1).Return([]*models.TeamWithSport{}, nil).Once()
+		mockTeamRepo.On("GetTeamMembersByTeamIDs", []int{team.ID}).Return(map[int][]*models.User{team.ID: members}, nil).Once()
+		mockTeamRepo.On("GetTeamMembersByTeamIDs", []int{}).Return(map[int][]*models.User{}, nil).Once()
+		mockTournamentRepo.On("GetMatchesForTeams", 1, []int{team.ID}).Return(map[int][]*models.MatchDetail{team.ID: {&firstDay, &secondDay}}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/student/class-progress?day_id=8", nil)
+		c.Set("user", user)
+
+		h.GetClassProgress(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]any
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Contains(t, w.Body.String(), "C1-A")
+		assert.NotContains(t, w.Body.String(), "B2-A")
+
+		mockTournamentRepo.AssertExpectations(t)
+	})
+
+	t.Run("day_id が不正なら400", func(t *testing.T) {
+		h, _, _, _, _ := newHandler()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/student/class-progress?day_id=abc", nil)
+		c.Set("user", user)
+
+		h.GetClassProgress(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
 	t.Run("includes noon game relay assignments in member list", func(t *testing.T) {
 		h, mockClassRepo, mockEventRepo, mockTeamRepo, mockTournamentRepo := newHandler()
 
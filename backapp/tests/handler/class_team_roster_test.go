@@ -0,0 +1,390 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backapp/internal/handler"
+	"backapp/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassTeamHandler_RosterLocked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventID := 1
+	admin := &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}}
+	class := &models.Class{ID: 10, Name: "1A", EventID: &eventID}
+
+	t.Run("締め切り後の追加は変更申請になる", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		teamRepo := new(MockTeamRepository)
+		userRepo := new(MockUserRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		rosterRepo := new(MockRosterRepository)
+		h := handler.NewClassTeamHandler(classRepo, teamRepo, userRepo, eventRepo, sportRepo).WithRosterLock(rosterRepo)
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+		classRepo.On("GetClassByID", 10).Return(class, nil).Once()
+		eventRepo.On("GetEventByID", eventID).Return(&models.Event{ID: eventID, DuplicateRegistrationThreshold: 31}, nil).Once()
+		sportRepo.On("GetSportByID", 2).Return(&models.Sport{ID: 2, Name: "Basketball"}, nil).Once()
+		rosterRepo.On("GetRosterPhase", eventID).Return(models.RosterPhaseLocked, nil).Once()
+		userRepo.On("GetUserWithRoles", "user-1").Return(&models.User{ID: "user-1", ClassID: classTeamIntPtr(10)}, nil).Once()
+		userRepo.On("GetUserWithRoles", "other-class").Return(&models.User{ID: "other-class", ClassID: classTeamIntPtr(11)}, nil).Once()
+		rosterRepo.On("CreateChangeRequests", mock.MatchedBy(func(requests []*models.RosterChangeRequest) bool {
+			return len(requests) == 1 && requests[0].UserID == "user-1" && requests[0].Action == models.RosterChangeActionAdd &&
+				requests[0].Reason == "けが人の代わり" && *requests[0].RequestedBy == "admin-1"
+		})).Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(gin.H{
+			"class_id": 10, "sport_id": 2, "user_ids": []string{"user-1", "other-class", "user-1"}, "reason": " けが人の代わり ",
+		})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/admin/class-team/assign-members", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", admin)
+		h.AssignTeamMembersHandler(c)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		rosterRepo.AssertExpectations(t)
+		teamRepo.AssertNotCalled(t, "AddTeamMember", mock.Anything, mock.Anything)
+		userRepo.AssertNotCalled(t, "UpdateUserRole", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("理由がなければ409", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		teamRepo := new(MockTeamRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		rosterRepo := new(MockRosterRepository)
+		h := handler.NewClassTeamHandler(classRepo, teamRepo, new(MockUserRepository), eventRepo, sportRepo).WithRosterLock(rosterRepo)
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+		classRepo.On("GetClassByID", 10).Return(class, nil).Once()
+		sportRepo.On("GetSportByID", 2).Return(&models.Sport{ID: 2, Name: "Basketball"}, nil).Once()
+		rosterRepo.On("GetRosterPhase", eventID).Return(models.RosterPhaseEventDay, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(gin.H{
+			"class_id": 10, "sport_id": 2, "user_id": "user-1",
+		})
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/api/admin/class-team/remove-member", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", admin)
+		h.RemoveTeamMemberHandler(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		rosterRepo.AssertNotCalled(t, "CreateChangeRequests", mock.Anything)
+		teamRepo.AssertNotCalled(t, "RemoveTeamMember", mock.Anything, mock.Anything)
+	})
+
+	t.Run("受付中はそのまま削除する", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		teamRepo := new(MockTeamRepository)
+		userRepo := new(MockUserRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		rosterRepo := new(MockRosterRepository)
+		h := handler.NewClassTeamHandler(classRepo, teamRepo, userRepo, eventRepo, sportRepo).WithRosterLock(rosterRepo)
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+		classRepo.On("GetClassByID", 10).Return(class, nil).Once()
+		sportRepo.On("GetSportByID", 2).Return(&models.Sport{ID: 2, Name: "Basketball"}, nil).Once()
+		rosterRepo.On("GetRosterPhase", eventID).Return(models.RosterPhaseOpen, nil).Once()
+		teamRepo.On("GetTeamByClassAndSport", 10, 2, eventID).Return(&models.Team{ID: 100}, nil).Once()
+		teamRepo.On("RemoveTeamMember", 100, "user-1").Return(nil).Once()
+		userRepo.On("DeleteUserRole", "user-1", "1A_Basketball").Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(gin.H{
+			"class_id": 10, "sport_id": 2, "user_id": "user-1",
+		})
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/api/admin/class-team/remove-member", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", admin)
+		h.RemoveTeamMemberHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		teamRepo.AssertExpectations(t)
+	})
+}
+
+func TestClassTeamHandler_ApproveRosterChangeRequestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventID := 1
+	root := &models.User{ID: "root-1", Roles: []models.Role{{Name: "root"}}}
+	class := &models.Class{ID: 10, Name: "1A", EventID: &eventID, StudentCount: 40}
+	params := gin.Params{{Key: "request_id", Value: "5"}}
+	addRequest := func() *models.RosterChangeRequest {
+		return &models.RosterChangeRequest{ID: 5, EventID: eventID, ClassID: 10, SportID: 2, UserID: "user-1", Action: models.RosterChangeActionAdd,
+			Status: models.RosterChangeRequestStatusPending, ClassName: "1A", SportName: "Basketball"}
+	}
+	removeRequest := func() *models.RosterChangeRequest {
+		request := addRequest()
+		request.Action = models.RosterChangeActionRemove
+		return request
+	}
+	t.Run("承認時の状態で検証してから追加する", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		teamRepo := new(MockTeamRepository)
+		userRepo := new(MockUserRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		rosterRepo := new(MockRosterRepository)
+		h := handler.NewClassTeamHandler(classRepo, teamRepo, userRepo, eventRepo, sportRepo).WithRosterLock(rosterRepo)
+		rosterRepo.On("GetChangeRequest", 5).Return(addRequest(), nil).Once()
+		eventRepo.On("GetEventByID", eventID).Return(&models.Event{ID: eventID, DuplicateRegistrationThreshold: 31}, nil).Once()
+		classRepo.On("GetClassByID", 10).Return(class, nil).Once()
+		teamRepo.On("GetTeamByClassAndSport", 10, 2, eventID).Return(&models.Team{ID: 100}, nil).Once()
+		teamRepo.On("GetTeamMembers", 100).Return([]*models.User{{ID: "user-2"}}, nil).Once()
+		userRepo.On("GetUserWithRoles", "user-1").Return(&models.User{ID: "user-1", ClassID: classTeamIntPtr(10)}, nil).Once()
+		sportRepo.On("GetSportDetails", eventID, 2).Return(&models.EventSport{MaxCapacity: classTeamIntPtr(5)}, nil).Once()
+		teamRepo.On("GetTeamsByUserID", "user-1").Return([]*models.TeamWithSport{}, nil).Once()
+		note := "了承"
+		rosterRepo.On("ResolvePendingChangeRequest", 5, models.RosterChangeRequestStatusApproved, "root-1", &note).Return(true, nil).Once()
+		teamRepo.On("AddTeamMember", 100, "user-1").Return(nil).Once()
+		userRepo.On("UpdateUserRole", "user-1", "1A_Basketball", &eventID).Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(gin.H{"note": "了承"})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/roster-change-requests/5/approve", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", root)
+		c.Params = params
+		h.ApproveRosterChangeRequestHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		teamRepo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("登録可能な競技数を超えるなら承認しない", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		teamRepo := new(MockTeamRepository)
+		userRepo := new(MockUserRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		rosterRepo := new(MockRosterRepository)
+		h := handler.NewClassTeamHandler(classRepo, teamRepo, userRepo, eventRepo, sportRepo).WithRosterLock(rosterRepo)
+		rosterRepo.On("GetChangeRequest", 5).Return(addRequest(), nil).Once()
+		eventRepo.On("GetEventByID", eventID).Return(&models.Event{ID: eventID, DuplicateRegistrationThreshold: 31}, nil).Once()
+		classRepo.On("GetClassByID", 10).Return(class, nil).Once()
+		teamRepo.On("GetTeamByClassAndSport", 10, 2, eventID).Return(&models.Team{ID: 100}, nil).Once()
+		teamRepo.On("GetTeamMembers", 100).Return([]*models.User{}, nil).Once()
+		userRepo.On("GetUserWithRoles", "user-1").Return(&models.User{ID: "user-1", ClassID: classTeamIntPtr(10)}, nil).Once()
+		sportRepo.On("GetSportDetails", eventID, 2).Return(&models.EventSport{}, nil).Once()
+		teamRepo.On("GetTeamsByUserID", "user-1").Return([]*models.TeamWithSport{{ID: 200, EventID: eventID, SportID: 3}}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/roster-change-requests/5/approve", nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", root)
+		c.Params = params
+		h.ApproveRosterChangeRequestHandler(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "登録可能な競技数")
+		rosterRepo.AssertNotCalled(t, "ResolvePendingChangeRequest", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("定員を超えるなら承認しない", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		teamRepo := new(MockTeamRepository)
+		userRepo := new(MockUserRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		rosterRepo := new(MockRosterRepository)
+		h := handler.NewClassTeamHandler(classRepo, teamRepo, userRepo, eventRepo, sportRepo).WithRosterLock(rosterRepo)
+		rosterRepo.On("GetChangeRequest", 5).Return(addRequest(), nil).Once()
+		eventRepo.On("GetEventByID", eventID).Return(&models.Event{ID: eventID, DuplicateRegistrationThreshold: 31}, nil).Once()
+		classRepo.On("GetClassByID", 10).Return(class, nil).Once()
+		teamRepo.On("GetTeamByClassAndSport", 10, 2, eventID).Return(&models.Team{ID: 100}, nil).Once()
+		teamRepo.On("GetTeamMembers", 100).Return([]*models.User{{ID: "user-2"}, {ID: "user-3"}}, nil).Once()
+		userRepo.On("GetUserWithRoles", "user-1").Return(&models.User{ID: "user-1", ClassID: classTeamIntPtr(10)}, nil).Once()
+		sportRepo.On("GetSportDetails", eventID, 2).Return(&models.EventSport{MaxCapacity: classTeamIntPtr(2)}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/roster-change-requests/5/approve", nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", root)
+		c.Params = params
+		h.ApproveRosterChangeRequestHandler(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "定員オーバー")
+	})
+
+	t.Run("最低人数を下回る削除は承認しない", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		teamRepo := new(MockTeamRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		rosterRepo := new(MockRosterRepository)
+		h := handler.NewClassTeamHandler(classRepo, teamRepo, new(MockUserRepository), eventRepo, sportRepo).WithRosterLock(rosterRepo)
+		rosterRepo.On("GetChangeRequest", 5).Return(removeRequest(), nil).Once()
+		eventRepo.On("GetEventByID", eventID).Return(&models.Event{ID: eventID, DuplicateRegistrationThreshold: 31}, nil).Once()
+		classRepo.On("GetClassByID", 10).Return(class, nil).Once()
+		teamRepo.On("GetTeamByClassAndSport", 10, 2, eventID).Return(&models.Team{ID: 100}, nil).Once()
+		teamRepo.On("GetTeamMembers", 100).Return([]*models.User{{ID: "user-1"}, {ID: "user-2"}}, nil).Once()
+		sportRepo.On("GetSportDetails", eventID, 2).Return(&models.EventSport{MinCapacity: classTeamIntPtr(2)}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/roster-change-requests/5/approve", nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", root)
+		c.Params = params
+		h.ApproveRosterChangeRequestHandler(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "最低人数")
+	})
+
+	t.Run("大会当日は参加確認済みのメンバーを外せない", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		teamRepo := new(MockTeamRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		rosterRepo := new(MockRosterRepository)
+		h := handler.NewClassTeamHandler(classRepo, teamRepo, new(MockUserRepository), eventRepo, sportRepo).WithRosterLock(rosterRepo)
+		rosterRepo.On("GetChangeRequest", 5).Return(removeRequest(), nil).Once()
+		eventRepo.On("GetEventByID", eventID).Return(&models.Event{ID: eventID, DuplicateRegistrationThreshold: 31}, nil).Once()
+		classRepo.On("GetClassByID", 10).Return(class, nil).Once()
+		teamRepo.On("GetTeamByClassAndSport", 10, 2, eventID).Return(&models.Team{ID: 100}, nil).Once()
+		teamRepo.On("GetTeamMembers", 100).Return([]*models.User{{ID: "user-1"}, {ID: "user-2"}}, nil).Once()
+		sportRepo.On("GetSportDetails", eventID, 2).Return(&models.EventSport{}, nil).Once()
+		rosterRepo.On("GetRosterPhase", eventID).Return(models.RosterPhaseEventDay, nil).Once()
+		teamRepo.On("GetConfirmedTeamMembers", 100).Return([]*models.User{{ID: "user-1"}}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/roster-change-requests/5/approve", nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", root)
+		c.Params = params
+		h.ApproveRosterChangeRequestHandler(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		teamRepo.AssertNotCalled(t, "RemoveTeamMember", mock.Anything, mock.Anything)
+	})
+
+	t.Run("反映に失敗したら承認待ちに戻す", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		teamRepo := new(MockTeamRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		rosterRepo := new(MockRosterRepository)
+		h := handler.NewClassTeamHandler(classRepo, teamRepo, new(MockUserRepository), eventRepo, sportRepo).WithRosterLock(rosterRepo)
+		rosterRepo.On("GetChangeRequest", 5).Return(removeRequest(), nil).Once()
+		eventRepo.On("GetEventByID", eventID).Return(&models.Event{ID: eventID, DuplicateRegistrationThreshold: 31}, nil).Once()
+		classRepo.On("GetClassByID", 10).Return(class, nil).Once()
+		teamRepo.On("GetTeamByClassAndSport", 10, 2, eventID).Return(&models.Team{ID: 100}, nil).Once()
+		teamRepo.On("GetTeamMembers", 100).Return([]*models.User{{ID: "user-1"}, {ID: "user-2"}}, nil).Once()
+		sportRepo.On("GetSportDetails", eventID, 2).Return(&models.EventSport{}, nil).Once()
+		rosterRepo.On("GetRosterPhase", eventID).Return(models.RosterPhaseLocked, nil).Once()
+		rosterRepo.On("ResolvePendingChangeRequest", 5, models.RosterChangeRequestStatusApproved, "root-1", (*string)(nil)).Return(true, nil).Once()
+		teamRepo.On("RemoveTeamMember", 100, "user-1").Return(errors.New("db down")).Once()
+		rosterRepo.On("ReopenChangeRequest", 5).Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/roster-change-requests/5/approve", nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", root)
+		c.Params = params
+		h.ApproveRosterChangeRequestHandler(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		rosterRepo.AssertExpectations(t)
+	})
+}
+
+func TestClassTeamHandler_RejectRosterChangeRequestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	root := &models.User{ID: "root-1", Roles: []models.Role{{Name: "root"}}}
+	params := gin.Params{{Key: "request_id", Value: "5"}}
+
+	t.Run("処理済みの申請は409", func(t *testing.T) {
+		rosterRepo := new(MockRosterRepository)
+		h := handler.NewClassTeamHandler(new(MockClassRepository), new(MockTeamRepository), new(MockUserRepository), new(MockEventRepository), new(MockSportRepository)).WithRosterLock(rosterRepo)
+		rosterRepo.On("GetChangeRequest", 5).Return(&models.RosterChangeRequest{ID: 5, Status: models.RosterChangeRequestStatusApproved}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/roster-change-requests/5/reject", nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", root)
+		c.Params = params
+		h.RejectRosterChangeRequestHandler(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("却下しても名簿は変えない", func(t *testing.T) {
+		rosterRepo := new(MockRosterRepository)
+		h := handler.NewClassTeamHandler(new(MockClassRepository), new(MockTeamRepository), new(MockUserRepository), new(MockEventRepository), new(MockSportRepository)).WithRosterLock(rosterRepo)
+		rosterRepo.On("GetChangeRequest", 5).Return(&models.RosterChangeRequest{ID: 5, Status: models.RosterChangeRequestStatusPending}, nil).Once()
+		rosterRepo.On("ResolvePendingChangeRequest", 5, models.RosterChangeRequestStatusRejected, "root-1", (*string)(nil)).Return(true, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/roster-change-requests/5/reject", nil)
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", root)
+		c.Params = params
+		h.RejectRosterChangeRequestHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		rosterRepo.AssertExpectations(t)
+	})
+}
+
+func TestClassTeamHandler_SetRosterPhaseHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	root := &models.User{ID: "root-1", Roles: []models.Role{{Name: "root"}}}
+
+	t.Run("段階を変える", func(t *testing.T) {
+		rosterRepo := new(MockRosterRepository)
+		h := handler.NewClassTeamHandler(new(MockClassRepository), new(MockTeamRepository), new(MockUserRepository), new(MockEventRepository), new(MockSportRepository)).WithRosterLock(rosterRepo)
+		rosterRepo.On("SetRosterPhase", 1, models.RosterPhaseLocked).Return(true, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(gin.H{"phase": "locked"})
+		c.Request, _ = http.NewRequest(http.MethodPut, "/api/root/events/1/roster-phase", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", root)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		h.SetRosterPhaseHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("知らない段階は400", func(t *testing.T) {
+		rosterRepo := new(MockRosterRepository)
+		h := handler.NewClassTeamHandler(new(MockClassRepository), new(MockTeamRepository), new(MockUserRepository), new(MockEventRepository), new(MockSportRepository)).WithRosterLock(rosterRepo)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(gin.H{"phase": "closed"})
+		c.Request, _ = http.NewRequest(http.MethodPut, "/api/root/events/1/roster-phase", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", root)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		h.SetRosterPhaseHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		rosterRepo.AssertNotCalled(t, "SetRosterPhase", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,172 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backapp/internal/handler"
+	"backapp/internal/mail"
+	"backapp/internal/mail/mailtest"
+	"backapp/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMailSender(t *testing.T) (mail.Sender, *mailtest.Server) {
+	t.Helper()
+	server, err := mailtest.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { server.Close() })
+	host, port := server.HostPort()
+	return mail.NewSMTPSender(mail.Config{Host: host, Port: port, From: "noreply@school.example", RatePerMinute: 6000}), server
+}
+
+func TestNotificationHandler_CreateNotification_EmailFallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockNotifRepo := new(MockNotificationRepository)
+	mockEventRepo := new(MockEventRepository)
+	mockRoleRepo := new(MockRoleRepository)
+	pushSender := &recordingPushSender{}
+	mailSender, server := newTestMailSender(t)
+
+	h := handler.NewNotificationHandler(mockNotifRepo, mockEventRepo, mockRoleRepo, new(MockUserRepository), "", "").
+		WithPushSender(pushSender).
+		WithMailSender(mailSender)
+
+	mockRoleRepo.On("GetAllRoles").Return([]models.Role{{ID: 1, Name: "student"}}, nil).Once()
+	mockEventRepo.On("GetActiveEvent").Return(0, nil).Once()
+	mockNotifRepo.On("PublishNotification", models.NewNotification{
+		Title: "雨天のお知らせ", Body: "屋外競技は体育館で行います", Type: "general", CreatedBy: "root-1", TargetRoles: []string{"student"}, Targets: []models.NotificationTarget{},
+	}, (*models.PushMessage)(nil)).Return(int64(10), nil).Once()
+	mockNotifRepo.On("GetUserIDsByRoles", []string{"student"}).Return([]string{"user-1", "user-2", "user-3"}, nil).Once()
+	mockNotifRepo.On("GetPushSubscriptionsByUserIDs", []string{"user-1", "user-2", "user-3"}).Return(subscriptionsFor("user-1"), nil).Once()
+	// 購読のない2人だけにメールを送る。アドレスのないユーザーは飛ばす
+	mockNotifRepo.On("GetUserEmailsByIDs", []string{"user-2", "user-3"}).Return(map[string]string{"user-2": "hanako@school.example"}, nil).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	payload, _ := json.Marshal(map[string]any{
+		"title":        "雨天のお知らせ",
+		"body":         "屋外競技は体育館で行います",
+		"target_roles": []string{"student"},
+		"channel":      handler.NotificationChannelPushEmailFallback,
+	})
+	c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/notifications", bytes.NewBuffer(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user", &models.User{ID: "root-1"})
+
+	h.CreateNotification(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Eventually(t, func() bool {
+		pushSender.mu.Lock()
+		defer pushSender.mu.Unlock()
+		return len(server.Messages()) == 1 && len(pushSender.targets) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"hanako@school.example"}, server.Messages()[0].To)
+	assert.Equal(t, [][]string{{"user-1"}}, pushSender.targets)
+	mockNotifRepo.AssertExpectations(t)
+}
+
+func TestNotificationHandler_CreateNotification_InvalidChannel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockNotifRepo := new(MockNotificationRepository)
+	h := handler.NewNotificationHandler(mockNotifRepo, new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	payload, _ := json.Marshal(map[string]any{
+		"title":        "お知らせ",
+		"body":         "本文です",
+		"target_roles": []string{"student"},
+		"channel":      "sms",
+	})
+	c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/notifications", bytes.NewBuffer(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user", &models.User{ID: "root-1"})
+
+	h.CreateNotification(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockNotifRepo.AssertNotCalled(t, "PublishNotification", mock.Anything, mock.Anything)
+}
+
+func TestEventHandler_NotifySurvey_EmailFallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockEventRepo := new(MockEventRepository)
+	mockNotifRepo := new(MockNotificationRepository)
+	mailSender, server := newTestMailSender(t)
+
+	// Push は無効でもメールだけで届ける
+	h := handler.NewEventHandler(mockEventRepo, nil, nil, mockNotifRepo, new(MockUserRepository), "", "").WithMailSender(mailSender)
+
+	eventID := 1
+	surveyURL := "https://forms.gle/dummy"
+	targetRoles := []string{"student", "admin", "root"}
+	mockEventRepo.On("GetEventByID", eventID).Return(&models.Event{ID: eventID, Name: "2025春季スポーツ大会", SurveyUrl: &surveyURL}, nil).Once()
+	mockNotifRepo.On("PublishNotification", mock.MatchedBy(func(n models.NewNotification) bool {
+		return n.Title == "大会アンケートのお願い" && *n.EventID == eventID && assert.ObjectsAreEqual(targetRoles, n.TargetRoles)
+	}), (*models.PushMessage)(nil)).Return(int64(10), nil).Once()
+	mockEventRepo.On("PublishSurvey", eventID).Return(nil).Once()
+	mockNotifRepo.On("GetUserIDsByRoles", targetRoles).Return([]string{"user-1"}, nil).Once()
+	mockNotifRepo.On("GetUserEmailsByIDs", []string{"user-1"}).Return(map[string]string{"user-1": "taro@school.example"}, nil).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/events/1/notify-survey", bytes.NewBufferString(`{"channel":"push_email_fallback"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	h.NotifySurvey(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Eventually(t, func() bool { return len(server.Messages()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"taro@school.example"}, server.Messages()[0].To)
+	mockNotifRepo.AssertNotCalled(t, "GetPushSubscriptionsByUserIDs", mock.Anything)
+}
+
+func TestNotificationRequestHandler_DecideRequest_EmailsRequesterWithoutPush(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRequestRepo := new(MockNotificationRequestRepository)
+	mockNotifRepo := new(MockNotificationRepository)
+	mailSender, server := newTestMailSender(t)
+
+	h := handler.NewNotificationRequestHandler(mockRequestRepo, mockNotifRepo, new(MockRoleRepository), "", "").WithMailSender(mailSender)
+
+	mockRequestRepo.On("GetRequestByID", 7).Return(&models.NotificationRequest{ID: 7, Title: "部活動の集合", RequesterID: "user-2"}, nil).Once()
+	mockRequestRepo.On("UpdateRequestStatus", 7, models.NotificationRequestStatusRejected, mock.Anything).Return(nil).Once()
+	mockNotifRepo.On("GetUserEmailsByIDs", []string{"user-2"}).Return(map[string]string{"user-2": "hanako@school.example"}, nil).Once()
+	added := make(chan struct{})
+	mockRequestRepo.On("AddMessage", 7, "root-1", "rootが申請を否認しました。").Return(int64(1), nil).Once().Run(func(mock.Arguments) { close(added) })
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "request_id", Value: "7"}}
+	c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/notification-requests/7/decision", bytes.NewBufferString(`{"status":"rejected"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user", &models.User{ID: "root-1"})
+
+	h.DecideRequest(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	select {
+	case <-added:
+	case <-time.After(2 * time.Second):
+		t.Fatal("decision message was not recorded")
+	}
+	received := server.Messages()
+	require.Len(t, received, 1)
+	assert.Equal(t, []string{"hanako@school.example"}, received[0].To)
+	mockNotifRepo.AssertNotCalled(t, "GetPushSubscriptionsByUserIDs", mock.Anything)
+}
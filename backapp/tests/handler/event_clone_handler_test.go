@@ -0,0 +1,206 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backapp/internal/handler"
+	"backapp/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func eventCloneSource() *models.EventCloneSource {
+	description := "学年対抗"
+	maxCapacity := 8
+	return &models.EventCloneSource{
+		Event: &models.Event{ID: 1, Name: "2025春季スポーツ大会", Year: 2025, Season: "spring", DuplicateRegistrationThreshold: 31},
+		Sports: []*models.EventSport{
+			{EventID: 1, SportID: 1, SportName: "サッカー", Description: &description, Location: "ground", MaxCapacity: &maxCapacity, Format: models.SportFormatTournament},
+		},
+		Classes: []*models.Class{
+			{ID: 11, Name: "IS2", StudentCount: 40},
+			{ID: 12, Name: "IS3", StudentCount: 38},
+			{ID: 13, Name: "IS5", StudentCount: 35},
+		},
+		RainyModeSettings: []*models.RainyModeSetting{
+			{EventID: 1, SportID: 1, ClassID: 11, MaxCapacity: intPtr(5)},
+			{EventID: 1, SportID: 1, ClassID: 13, MaxCapacity: intPtr(5)},
+		},
+		GuideDocuments: []*models.GuideDocument{
+			{ID: 7, EventID: 1, Title: "大会要項", PdfURL: "/uploads/guide.pdf"},
+		},
+		NoonGameDefaultGroups: []*models.NoonGameTemplateDefaultGroup{
+			{ID: 21, TemplateKey: "year_relay", GroupIndex: 0, GroupName: "2年", ClassNames: []string{"IS2"}},
+			{ID: 22, TemplateKey: "year_relay", GroupIndex: 1, GroupName: "専攻科", ClassNames: []string{"AS1"}},
+		},
+	}
+}
+
+func eventCloneContext(path string, body any) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	payload, _ := json.Marshal(body)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+	c.Request, _ = http.NewRequest(http.MethodPost, path, bytes.NewBuffer(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	return c, w
+}
+
+func eventCloneBody() map[string]any {
+	return map[string]any{
+		"name":   "2026春季スポーツ大会",
+		"year":   2026,
+		"season": "spring",
+		// 進級で IS2 は IS3、IS3 は IS4 になり、卒業する IS5 は引き継がない
+		"class_name_map": map[string]string{"IS2": "IS3", "IS3": "IS4", "IS5": ""},
+	}
+}
+
+func TestEventHandler_PreviewEventClone(t *testing.T) {
+	t.Run("クラス名を付け替えて引き継ぐ内容を返す", func(t *testing.T) {
+		repo := new(MockEventRepository)
+		h := handler.NewEventHandler(repo, nil, nil, nil, nil, "", "")
+		repo.On("GetCloneSource", 1).Return(eventCloneSource(), nil).Once()
+		repo.On("GetEventByYearAndSeason", 2026, "spring").Return(nil, nil).Once()
+
+		c, w := eventCloneContext("/api/root/events/1/clone/preview", eventCloneBody())
+		h.PreviewEventClone(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Plan models.EventClonePlan `json:"plan"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		plan := response.Plan
+
+		assert.Empty(t, plan.Conflicts)
+		assert.Equal(t, models.EventClonePartsAll, plan.Parts)
+		assert.Equal(t, "2026春季スポーツ大会", plan.Event.Name)
+		assert.Equal(t, models.EventStatusUpcoming, plan.Event.Status)
+		require.Len(t, plan.Sports, 1)
+		assert.Equal(t, "ground", plan.Sports[0].Location)
+
+		require.Len(t, plan.Classes, 2)
+		assert.Equal(t, "IS2", plan.Classes[0].SourceName)
+		assert.Equal(t, "IS3", plan.Classes[0].Name)
+		assert.Equal(t, 40, plan.Classes[0].StudentCount)
+		assert.Equal(t, "IS4", plan.Classes[1].Name)
+		assert.Equal(t, []string{"IS5"}, plan.SkippedClasses)
+
+		// 引き継がないクラスの雨天時設定は落とす
+		require.Len(t, plan.RainyModeSettings, 1)
+		assert.Equal(t, "IS2", plan.RainyModeSettings[0].SourceClassName)
+		assert.Equal(t, "IS3", plan.RainyModeSettings[0].ClassName)
+
+		require.Len(t, plan.GuideDocuments, 1)
+		assert.Equal(t, "大会要項", plan.GuideDocuments[0].Title)
+
+		// クラス名の変わらないグループは書き換えない
+		require.Len(t, plan.NoonGameDefaultGroups, 1)
+		assert.Equal(t, 21, plan.NoonGameDefaultGroups[0].ID)
+		assert.Equal(t, []string{"IS3"}, plan.NoonGameDefaultGroups[0].ClassNames)
+
+		repo.AssertNotCalled(t, "CloneEvent", mock.Anything)
+	})
+
+	t.Run("付け替えで同じ名前になるクラスと既存の大会を衝突として返す", func(t *testing.T) {
+		repo := new(MockEventRepository)
+		h := handler.NewEventHandler(repo, nil, nil, nil, nil, "", "")
+		repo.On("GetCloneSource", 1).Return(eventCloneSource(), nil).Once()
+		repo.On("GetEventByYearAndSeason", 2026, "spring").Return(&models.Event{ID: 2}, nil).Once()
+
+		body := eventCloneBody()
+		body["class_name_map"] = map[string]string{"IS2": "IS3", "IS9": "IS10"}
+		c, w := eventCloneContext("/api/root/events/1/clone/preview", body)
+		h.PreviewEventClone(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Plan models.EventClonePlan `json:"plan"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []string{
+			"An event for 2026 spring already exists",
+			"Class IS9 in class_name_map does not exist in the source event",
+			"Classes IS2 and IS3 would both become IS3",
+		}, response.Plan.Conflicts)
+	})
+
+	t.Run("雨天時設定だけを引き継ぐことはできない", func(t *testing.T) {
+		repo := new(MockEventRepository)
+		h := handler.NewEventHandler(repo, nil, nil, nil, nil, "", "")
+
+		body := eventCloneBody()
+		body["parts"] = []string{models.EventClonePartRainyModeSettings}
+		c, w := eventCloneContext("/api/root/events/1/clone/preview", body)
+		h.PreviewEventClone(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		repo.AssertNotCalled(t, "GetCloneSource", mock.Anything)
+	})
+
+	t.Run("複製元の大会がなければ404", func(t *testing.T) {
+		repo := new(MockEventRepository)
+		h := handler.NewEventHandler(repo, nil, nil, nil, nil, "", "")
+		repo.On("GetCloneSource", 1).Return(nil, nil).Once()
+
+		c, w := eventCloneContext("/api/root/events/1/clone/preview", eventCloneBody())
+		h.PreviewEventClone(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestEventHandler_CloneEvent(t *testing.T) {
+	t.Run("選んだ項目だけを引き継いで大会を作る", func(t *testing.T) {
+		repo := new(MockEventRepository)
+		h := handler.NewEventHandler(repo, nil, nil, nil, nil, "", "")
+		repo.On("GetCloneSource", 1).Return(eventCloneSource(), nil).Once()
+		repo.On("GetEventByYearAndSeason", 2026, "spring").Return(nil, nil).Once()
+		repo.On("CloneEvent", mock.MatchedBy(func(plan *models.EventClonePlan) bool {
+			return plan.SourceEventID == 1 &&
+				len(plan.Sports) == 1 &&
+				len(plan.Classes) == 2 &&
+				len(plan.RainyModeSettings) == 0 &&
+				len(plan.GuideDocuments) == 0 &&
+				len(plan.NoonGameDefaultGroups) == 0
+		})).Return(int64(5), nil).Once()
+
+		body := eventCloneBody()
+		body["parts"] = []string{models.EventClonePartClasses, models.EventClonePartSports}
+		c, w := eventCloneContext("/api/root/events/1/clone", body)
+		h.CloneEvent(c)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		var response struct {
+			Event models.Event          `json:"event"`
+			Plan  models.EventClonePlan `json:"plan"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 5, response.Event.ID)
+		assert.Equal(t, []string{models.EventClonePartSports, models.EventClonePartClasses}, response.Plan.Parts)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("衝突があれば大会を作らない", func(t *testing.T) {
+		repo := new(MockEventRepository)
+		h := handler.NewEventHandler(repo, nil, nil, nil, nil, "", "")
+		repo.On("GetCloneSource", 1).Return(eventCloneSource(), nil).Once()
+		repo.On("GetEventByYearAndSeason", 2026, "spring").Return(&models.Event{ID: 2}, nil).Once()
+
+		c, w := eventCloneContext("/api/root/events/1/clone", eventCloneBody())
+		h.CloneEvent(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "already exists")
+		repo.AssertNotCalled(t, "CloneEvent", mock.Anything)
+	})
+}
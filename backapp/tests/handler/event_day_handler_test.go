@@ -0,0 +1,142 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backapp/internal/handler"
+	"backapp/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func eventDayContext(method string, params gin.Params, body any) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	c.Params = params
+	c.Request, _ = http.NewRequest(method, "/api/root/events/1/days", bytes.NewBuffer(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user", &models.User{ID: "root-1"})
+	return c, w
+}
+
+func TestEventDayHandler_SyncEventDays(t *testing.T) {
+	t.Run("開始日から終了日までを開催日として登録する", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+		mockDayRepo := new(MockEventDayRepository)
+		h := handler.NewEventDayHandler(mockEventRepo, mockDayRepo)
+
+		start := time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC)
+		end := time.Date(2025, 6, 11, 17, 0, 0, 0, time.UTC)
+		mockEventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1, Start_date: &start, End_date: &end}, nil).Once()
+		dates := []time.Time{
+			time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC),
+			time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC),
+		}
+		mockDayRepo.On("SyncDays", 1, dates).Return([]*models.EventDay{
+			{ID: 4, EventID: 1, DayNumber: 1, Date: dates[0]},
+			{ID: 5, EventID: 1, DayNumber: 2, Date: dates[1]},
+		}, nil).Once()
+
+		c, w := eventDayContext(http.MethodPost, gin.Params{{Key: "id", Value: "1"}}, nil)
+		h.SyncEventDays(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Days []models.EventDay `json:"days"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Days, 2)
+		mockDayRepo.AssertExpectations(t)
+	})
+
+	t.Run("開始日と終了日がなければ400", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+		mockDayRepo := new(MockEventDayRepository)
+		h := handler.NewEventDayHandler(mockEventRepo, mockDayRepo)
+
+		mockEventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1}, nil).Once()
+
+		c, w := eventDayContext(http.MethodPost, gin.Params{{Key: "id", Value: "1"}}, nil)
+		h.SyncEventDays(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockDayRepo.AssertNotCalled(t, "SyncDays", mock.Anything, mock.Anything)
+	})
+}
+
+func TestEventDayHandler_SetDayRainyMode(t *testing.T) {
+	params := gin.Params{{Key: "id", Value: "1"}, {Key: "day_id", Value: "5"}}
+
+	t.Run("開催日の雨天時モードを切り替える", func(t *testing.T) {
+		mockDayRepo := new(MockEventDayRepository)
+		h := handler.NewEventDayHandler(new(MockEventRepository), mockDayRepo)
+
+		mockDayRepo.On("GetDayByID", 5).Return(&models.EventDay{ID: 5, EventID: 1, DayNumber: 2}, nil).Once()
+		mockDayRepo.On("SetRainyMode", 5, true, "root-1").Return(nil).Once()
+
+		c, w := eventDayContext(http.MethodPut, params, map[string]any{"is_rainy_mode": true})
+		h.SetDayRainyMode(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"message":"Rainy mode updated successfully","event_day_id":5,"is_rainy_mode":true}`, w.Body.String())
+		mockDayRepo.AssertExpectations(t)
+	})
+
+	t.Run("別の大会の開催日は404", func(t *testing.T) {
+		mockDayRepo := new(MockEventDayRepository)
+		h := handler.NewEventDayHandler(new(MockEventRepository), mockDayRepo)
+
+		mockDayRepo.On("GetDayByID", 5).Return(&models.EventDay{ID: 5, EventID: 2}, nil).Once()
+
+		c, w := eventDayContext(http.MethodPut, params, map[string]any{"is_rainy_mode": true})
+		h.SetDayRainyMode(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockDayRepo.AssertNotCalled(t, "SetRainyMode", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestEventDayHandler_AssignEventDay(t *testing.T) {
+	params := gin.Params{{Key: "id", Value: "1"}, {Key: "day_id", Value: "5"}}
+
+	t.Run("試合と昼競技のセッションを開催日に割り当てる", func(t *testing.T) {
+		mockDayRepo := new(MockEventDayRepository)
+		h := handler.NewEventDayHandler(new(MockEventRepository), mockDayRepo)
+
+		mockDayRepo.On("GetDayByID", 5).Return(&models.EventDay{ID: 5, EventID: 1}, nil).Once()
+		mockDayRepo.On("AssignMatches", 5, []int{11, 12}).Return(int64(2), nil).Once()
+		mockDayRepo.On("AssignNoonSessions", 5, []int{3}).Return(int64(1), nil).Once()
+
+		c, w := eventDayContext(http.MethodPut, params, map[string]any{"match_ids": []int{11, 12}, "noon_session_ids": []int{3}})
+		h.AssignEventDay(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"event_day_id":5,"match_count":2,"noon_session_count":1}`, w.Body.String())
+		mockDayRepo.AssertExpectations(t)
+	})
+
+	t.Run("割り当てる対象がなければ400", func(t *testing.T) {
+		mockDayRepo := new(MockEventDayRepository)
+		h := handler.NewEventDayHandler(new(MockEventRepository), mockDayRepo)
+
+		mockDayRepo.On("GetDayByID", 5).Return(&models.EventDay{ID: 5, EventID: 1}, nil).Once()
+
+		c, w := eventDayContext(http.MethodPut, params, map[string]any{})
+		h.AssignEventDay(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockDayRepo.AssertNotCalled(t, "AssignMatches", mock.Anything, mock.Anything)
+	})
+}
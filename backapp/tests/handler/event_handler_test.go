@@ -686,8 +686,14 @@ func TestEventHandler_NotifySurvey(t *testing.T) {
 		}
 
 		mockEventRepo.On("GetEventByID", eventID).Return(event, nil).Once()
-		mockNotificationRepo.On("CreateNotification", "大会アンケートのお願い", "大会に関するアンケート機能が公開されました。「2025春季スポーツ大会」についてダッシュボードの一番上のリンクからアンケートにご協力ください。", "general", "test-user-id", &eventID).Return(int64(10), nil).Once()
-		mockNotificationRepo.On("AddNotificationTargets", int64(10), []string{"student", "admin", "root"}).Return(nil).Once()
+		mockNotificationRepo.On("PublishNotification", models.NewNotification{
+			Title:       "大会アンケートのお願い",
+			Body:        "大会に関するアンケート機能が公開されました。「2025春季スポーツ大会」についてダッシュボードの一番上のリンクからアンケートにご協力ください。",
+			Type:        "general",
+			CreatedBy:   "test-user-id",
+			EventID:     &eventID,
+			TargetRoles: []string{"student", "admin", "root"},
+		}, (*models.PushMessage)(nil)).Return(int64(10), nil).Once()
 		mockEventRepo.On("PublishSurvey", eventID).Return(nil).Once()
 
 		// For the push dispatch explicitly matching our targetRoles
@@ -705,9 +711,6 @@ func TestEventHandler_NotifySurvey(t *testing.T) {
 
 		h.NotifySurvey(c)
 
-		// Wait briefly for the goroutine to hit the mock
-		time.Sleep(50 * time.Millisecond)
-
 		assert.Equal(t, http.StatusOK, w.Code)
 		mockEventRepo.AssertExpectations(t)
 		mockNotificationRepo.AssertExpectations(t)
@@ -737,7 +740,7 @@ func TestEventHandler_NotifySurvey(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		mockEventRepo.AssertExpectations(t)
-		mockNotificationRepo.AssertNotCalled(t, "CreateNotification")
+		mockNotificationRepo.AssertNotCalled(t, "PublishNotification", mock.Anything, mock.Anything)
 	})
 
 	t.Run("Error - Event not found", func(t *testing.T) {
@@ -759,7 +762,7 @@ func TestEventHandler_NotifySurvey(t *testing.T) {
 
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		mockEventRepo.AssertExpectations(t)
-		mockNotificationRepo.AssertNotCalled(t, "CreateNotification")
+		mockNotificationRepo.AssertNotCalled(t, "PublishNotification", mock.Anything, mock.Anything)
 	})
 }
 
@@ -811,7 +814,8 @@ func TestEventHandler_ImportSurveyScores(t *testing.T) {
 			101: 10, // 40/40 = 100% -> 10 points
 			102: 6,  // 20/38 = 52.6% -> 6 points
 		}
-		mockClassRepo.On("SetSurveyPoints", eventID, expectedPoints).Return(nil).Once()
+		expectedSubmissions := map[int]int{101: 40, 102: 20}
+		mockClassRepo.On("SetSurveyPoints", eventID, expectedSubmissions, expectedPoints, mock.Anything).Return(nil).Once()
 
 		// Prepare multipart form data
 		body := new(bytes.Buffer)
@@ -847,6 +851,41 @@ func TestEventHandler_ImportSurveyScores(t *testing.T) {
 		mockClassRepo.AssertExpectations(t)
 	})
 
+	t.Run("Success - Survey points follow the event scoring rules", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+		mockClassRepo := new(MockClassRepository)
+		mockRuleRepo := new(MockScoringRuleRepository)
+		h := handler.NewEventHandler(mockEventRepo, nil, mockClassRepo, nil, new(MockUserRepository), "", "").WithScoringRules(mockRuleRepo)
+
+		rules := models.DefaultScoringRules()
+		rules.Survey = models.RateLadder{Tiers: []models.RateTier{{MinRate: 0.5, Points: 20}}, BasePoints: 0}
+		mockEventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1, Season: "autumn"}, nil).Once()
+		mockClassRepo.On("GetAllClasses", 1).Return([]*models.Class{
+			{ID: 101, Name: "1A", StudentCount: 2},
+			{ID: 102, Name: "2B", StudentCount: 4},
+		}, nil).Once()
+		mockRuleRepo.On("GetScoringRules", 1).Return(rules, nil).Once()
+		mockClassRepo.On("SetSurveyPoints", 1, map[int]int{101: 1, 102: 1}, map[int]int{101: 20, 102: 0}, mock.Anything).Return(nil).Once()
+
+		body := new(bytes.Buffer)
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("file", "survey.csv")
+		assert.NoError(t, err)
+		part.Write([]byte("タイムスタンプ,クラス名\n2025/01/01,1A\n2025/01/01,2B\n"))
+		writer.Close()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/events/1/import-survey-scores", body)
+		c.Request.Header.Set("Content-Type", writer.FormDataContentType())
+
+		h.ImportSurveyScores(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockClassRepo.AssertExpectations(t)
+	})
+
 	t.Run("Error - Missing File", func(t *testing.T) {
 		mockEventRepo := new(MockEventRepository)
 		mockUserRepo := new(MockUserRepository)
@@ -0,0 +1,285 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backapp/internal/handler"
+	"backapp/internal/identity"
+	"backapp/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func identityContext(method string, target string, body *bytes.Buffer, contentType string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	if body == nil {
+		body = new(bytes.Buffer)
+	}
+	c.Request, _ = http.NewRequest(method, target, body)
+	if contentType != "" {
+		c.Request.Header.Set("Content-Type", contentType)
+	}
+	c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+	return c, w
+}
+
+func TestIdentityHandler_LookupHandler(t *testing.T) {
+	t.Run("学籍番号や名前で候補を返す", func(t *testing.T) {
+		identityRepo := new(MockIdentityRepository)
+		h := handler.NewIdentityHandler(identityRepo, new(MockUserRepository))
+		studentNumber := "2301059"
+		identityRepo.On("SearchCandidates", "2301", 20).
+			Return([]*models.IdentityCandidate{{UserID: "user-1", Email: "s2301059@example.com", StudentNumber: &studentNumber}}, nil).Once()
+
+		c, w := identityContext(http.MethodGet, "/api/barcode/lookup?q=2301", nil, "")
+		h.LookupHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Candidates []*models.IdentityCandidate `json:"candidates"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Candidates, 1)
+		assert.Equal(t, "user-1", response.Candidates[0].UserID)
+	})
+
+	t.Run("検索語が短すぎれば400", func(t *testing.T) {
+		identityRepo := new(MockIdentityRepository)
+		h := handler.NewIdentityHandler(identityRepo, new(MockUserRepository))
+
+		c, w := identityContext(http.MethodGet, "/api/barcode/lookup?q=s", nil, "")
+		h.LookupHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		identityRepo.AssertNotCalled(t, "SearchCandidates", mock.Anything, mock.Anything)
+	})
+}
+
+func TestIdentityHandler_ImportStudentNumbersCSVHandler(t *testing.T) {
+	identityRepo := new(MockIdentityRepository)
+	h := handler.NewIdentityHandler(identityRepo, new(MockUserRepository))
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("csv", "student_numbers.csv")
+	require.NoError(t, err)
+	part.Write([]byte("email,student_number\n" +
+		"s2301059@example.com,2301059\n" +
+		"teacher@example.com, T0012 \n" +
+		"dup@example.com,2301059\n" +
+		"bad@example.com,23-01\n"))
+	writer.Close()
+
+	identityRepo.On("ImportStudentNumbers", []models.StudentNumberEntry{
+		{Line: 2, Email: "s2301059@example.com", StudentNumber: "2301059"},
+		{Line: 3, Email: "teacher@example.com", StudentNumber: "T0012"},
+	}).Return(&models.StudentNumberImportResult{Updated: 1, Unchanged: 1, Errors: []models.StudentNumberImportError{}}, nil).Once()
+
+	c, w := identityContext(http.MethodPost, "/api/root/users/student-numbers/csv", body, writer.FormDataContentType())
+	h.ImportStudentNumbersCSVHandler(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var result models.StudentNumberImportResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, 1, result.Unchanged)
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, 4, result.Errors[0].Line)
+	assert.Equal(t, 5, result.Errors[1].Line)
+	identityRepo.AssertExpectations(t)
+}
+
+func TestIdentityHandler_IssueIDPassHandler(t *testing.T) {
+	t.Run("パスを発行して QR コードに載せる文字列を返す", func(t *testing.T) {
+		identityRepo := new(MockIdentityRepository)
+		userRepo := new(MockUserRepository)
+		h := handler.NewIdentityHandler(identityRepo, userRepo)
+		userRepo.On("GetUserWithRoles", "teacher-1").Return(&models.User{ID: "teacher-1"}, nil).Once()
+		identityRepo.On("IssueIDPass", "teacher-1", mock.AnythingOfType("string"), "admin-1").
+			Return(&models.IDPass{ID: 1, UserID: "teacher-1", Token: "abcdefghijklmnopqrstuvwxyz012345"}, nil).Once()
+
+		c, w := identityContext(http.MethodPost, "/api/admin/id-passes/teacher-1", nil, "")
+		c.Params = gin.Params{{Key: "userID", Value: "teacher-1"}}
+		h.IssueIDPassHandler(c)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		var response struct {
+			Pass    map[string]any `json:"pass"`
+			Payload string         `json:"payload"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.True(t, strings.HasPrefix(response.Payload, "SPORTEASE:ID:"))
+		assert.NotContains(t, response.Pass, "token")
+
+		id, err := identity.DefaultParser().Parse(response.Payload)
+		require.NoError(t, err)
+		assert.Equal(t, identity.KindPassToken, id.Kind)
+	})
+
+	t.Run("ユーザーがいなければ404", func(t *testing.T) {
+		identityRepo := new(MockIdentityRepository)
+		userRepo := new(MockUserRepository)
+		h := handler.NewIdentityHandler(identityRepo, userRepo)
+		userRepo.On("GetUserWithRoles", "missing").Return(nil, nil).Once()
+
+		c, w := identityContext(http.MethodPost, "/api/admin/id-passes/missing", nil, "")
+		c.Params = gin.Params{{Key: "userID", Value: "missing"}}
+		h.IssueIDPassHandler(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		identityRepo.AssertNotCalled(t, "IssueIDPass", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestIdentityHandler_GetMyIDPassQRCodeHandler(t *testing.T) {
+	t.Run("発行済みのパスを PNG で返す", func(t *testing.T) {
+		identityRepo := new(MockIdentityRepository)
+		h := handler.NewIdentityHandler(identityRepo, new(MockUserRepository))
+		identityRepo.On("GetActiveIDPass", "admin-1").Return(&models.IDPass{ID: 1, UserID: "admin-1", Token: "abcdefghijklmnopqrstuvwxyz012345"}, nil).Once()
+
+		c, w := identityContext(http.MethodGet, "/api/barcode/id-pass/qr", nil, "")
+		h.GetMyIDPassQRCodeHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		_, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+		assert.NoError(t, err)
+	})
+
+	t.Run("未発行なら404", func(t *testing.T) {
+		identityRepo := new(MockIdentityRepository)
+		h := handler.NewIdentityHandler(identityRepo, new(MockUserRepository))
+		identityRepo.On("GetActiveIDPass", "admin-1").Return(nil, nil).Once()
+
+		c, w := identityContext(http.MethodGet, "/api/barcode/id-pass/qr", nil, "")
+		h.GetMyIDPassQRCodeHandler(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAttendanceHandler_CheckInHandler_Identity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventID := 1
+	classID := 7
+	teacher := &models.User{ID: "teacher-1", Email: "yamada@example.com", ClassID: &classID}
+
+	t.Run("SportEase の QR コードで MyID のない先生を記録する", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		eventRepo := new(MockEventRepository)
+		checkInRepo := new(MockCheckInRepository)
+		userRepo := new(MockUserRepository)
+		h := handler.NewAttendanceHandler(classRepo, eventRepo).WithCheckIns(checkInRepo, userRepo)
+		identityRepo := new(MockIdentityRepository)
+		parser, err := identity.NewParser(identity.Config{Formats: []string{identity.FormatMyID, identity.FormatSportEaseQR}})
+		require.NoError(t, err)
+		h.WithIdentity(parser, identityRepo)
+
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+		classRepo.On("GetClassByID", classID).Return(&models.Class{ID: classID, EventID: &eventID, Name: "専教", StudentCount: 10}, nil).Once()
+		checkInRepo.On("CheckIn", eventID, "teacher-1", models.CheckInPurposeEventParticipation, "admin-1").
+			Return(&models.CheckIn{ID: 1, UserID: "teacher-1", EventID: eventID}, true, nil).Once()
+		checkInRepo.On("CountClassCheckIns", eventID, classID).Return(1, nil).Once()
+		classRepo.On("UpdateAttendance", classID, eventID, 1, "admin-1").Return(1, nil).Once()
+		token := "abcdefghijklmnopqrstuvwxyz012345"
+		identityRepo.On("FindUserIDByPassToken", token).Return("teacher-1", nil).Once()
+		userRepo.On("GetUserWithRoles", "teacher-1").Return(teacher, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(map[string]string{"barcode_data": identity.PassPayload(token)})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/admin/attendance/check-in", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, identity.FormatSportEaseQR, response["identified_by"])
+		assert.Equal(t, "", response["student_number"])
+		assert.Equal(t, "専教", response["class_name"])
+	})
+
+	t.Run("手動照合で選んだユーザーを記録する", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		eventRepo := new(MockEventRepository)
+		checkInRepo := new(MockCheckInRepository)
+		userRepo := new(MockUserRepository)
+		h := handler.NewAttendanceHandler(classRepo, eventRepo).WithCheckIns(checkInRepo, userRepo)
+		identityRepo := new(MockIdentityRepository)
+		parser, err := identity.NewParser(identity.Config{Formats: []string{identity.FormatMyID, identity.FormatSportEaseQR}})
+		require.NoError(t, err)
+		h.WithIdentity(parser, identityRepo)
+
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+		classRepo.On("GetClassByID", classID).Return(&models.Class{ID: classID, EventID: &eventID, Name: "専教", StudentCount: 10}, nil).Once()
+		checkInRepo.On("CheckIn", eventID, "teacher-1", models.CheckInPurposeEventParticipation, "admin-1").
+			Return(&models.CheckIn{ID: 1, UserID: "teacher-1", EventID: eventID}, true, nil).Once()
+		checkInRepo.On("CountClassCheckIns", eventID, classID).Return(1, nil).Once()
+		classRepo.On("UpdateAttendance", classID, eventID, 1, "admin-1").Return(1, nil).Once()
+		userRepo.On("GetUserWithRoles", "teacher-1").Return(teacher, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(map[string]string{"user_id": "teacher-1"})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/admin/attendance/check-in", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, identity.FormatManual, response["identified_by"])
+		identityRepo.AssertNotCalled(t, "FindUserIDByPassToken", mock.Anything)
+	})
+
+	t.Run("登録済みの学籍番号をメールアドレスより優先する", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		eventRepo := new(MockEventRepository)
+		checkInRepo := new(MockCheckInRepository)
+		userRepo := new(MockUserRepository)
+		h := handler.NewAttendanceHandler(classRepo, eventRepo).WithCheckIns(checkInRepo, userRepo)
+		identityRepo := new(MockIdentityRepository)
+		parser, err := identity.NewParser(identity.Config{Formats: []string{identity.FormatMyID, identity.FormatSportEaseQR}})
+		require.NoError(t, err)
+		h.WithIdentity(parser, identityRepo)
+
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+		classRepo.On("GetClassByID", classID).Return(&models.Class{ID: classID, EventID: &eventID, Name: "専教", StudentCount: 10}, nil).Once()
+		checkInRepo.On("CheckIn", eventID, "teacher-1", models.CheckInPurposeEventParticipation, "admin-1").
+			Return(&models.CheckIn{ID: 1, UserID: "teacher-1", EventID: eventID}, true, nil).Once()
+		checkInRepo.On("CountClassCheckIns", eventID, classID).Return(1, nil).Once()
+		classRepo.On("UpdateAttendance", classID, eventID, 1, "admin-1").Return(1, nil).Once()
+		identityRepo.On("FindUserIDByStudentNumber", "2301059").Return("teacher-1", nil).Once()
+		userRepo.On("GetUserWithRoles", "teacher-1").Return(teacher, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(map[string]string{"barcode_data": "H1023010590"})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/admin/attendance/check-in", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "2301059", response["student_number"])
+		assert.Equal(t, identity.FormatMyID, response["identified_by"])
+		userRepo.AssertNotCalled(t, "FindUsers", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,247 @@
+package handler_test
+
+import (
+	"backapp/internal/handler"
+	"backapp/internal/models"
+	"backapp/internal/repository"
+	"backapp/internal/websocket"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAllTournamentsPreview_LeagueFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockTournRepo := new(MockTournamentRepository)
+	mockSportRepo := new(MockSportRepository)
+	h := handler.NewTournamentHandler(mockTournRepo, mockSportRepo, new(MockTeamRepository), new(MockClassRepository), new(MockEventRepository), websocket.NewHubManager())
+
+	mockSportRepo.On("GetSportsByEventID", 1).Return([]*models.EventSport{
+		{EventID: 1, SportID: 1, Location: "ground", Format: models.SportFormatLeague, LeagueGroupCount: intPtr(2), LeagueAdvanceCount: intPtr(2)},
+	}, nil).Once()
+	mockSportRepo.On("GetSportByID", 1).Return(&models.Sport{ID: 1, Name: "Soccer"}, nil).Once()
+	teams := make([]*models.Team, 7)
+	for i := range teams {
+		teams[i] = &models.Team{ID: i + 1, Name: "Team " + string(rune('A'+i)), ClassID: i + 1, SportID: 1, EventID: 1}
+	}
+	mockSportRepo.On("GetTeamsBySportID", 1).Return(teams, nil).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	h.GenerateAllTournamentsPreviewHandler(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var tournaments []models.GeneratedTournament
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tournaments))
+	require.Len(t, tournaments, 3)
+
+	assert.Equal(t, "Soccer Tournament - リーグAグループ", tournaments[0].SportName)
+	assert.Equal(t, "Soccer Tournament - リーグBグループ", tournaments[1].SportName)
+	assert.Equal(t, "Soccer Tournament - 決勝トーナメント", tournaments[2].SportName)
+
+	t.Run("各グループは総当たりで全ての組み合わせを一度ずつ含む", func(t *testing.T) {
+		totalTeams := 0
+		for i, group := range tournaments[:2] {
+			n := len(group.ShuffledTeams)
+			totalTeams += n
+			assert.Len(t, group.TournamentData.Matches, n*(n-1)/2)
+
+			pairs := map[[2]string]bool{}
+			for _, match := range group.TournamentData.Matches {
+				assert.True(t, match.IsLeagueMatch)
+				assert.Equal(t, string(rune('A'+i)), match.LeagueGroup)
+				a, b := match.Sides[0].ContestantID, match.Sides[1].ContestantID
+				if a > b {
+					a, b = b, a
+				}
+				assert.False(t, pairs[[2]string{a, b}], "duplicate pairing %s-%s", a, b)
+				pairs[[2]string{a, b}] = true
+			}
+		}
+		assert.Equal(t, 7, totalTeams)
+	})
+
+	t.Run("決勝トーナメントはチーム未定の4チームブラケット", func(t *testing.T) {
+		knockout := tournaments[2]
+		assert.Empty(t, knockout.ShuffledTeams)
+		assert.True(t, knockout.TournamentData.IsLeagueKnockout)
+		assert.False(t, tournaments[0].TournamentData.IsLeagueKnockout)
+		assert.Len(t, knockout.TournamentData.Rounds, 2)
+		// 準決勝2試合・決勝・3位決定戦
+		assert.Len(t, knockout.TournamentData.Matches, 4)
+		for _, match := range knockout.TournamentData.Matches {
+			assert.False(t, match.IsLeagueMatch)
+		}
+	})
+}
+
+func TestGenerateAllTournamentsPreview_LeagueKnockoutWithByes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockTournRepo := new(MockTournamentRepository)
+	mockSportRepo := new(MockSportRepository)
+	h := handler.NewTournamentHandler(mockTournRepo, mockSportRepo, new(MockTeamRepository), new(MockClassRepository), new(MockEventRepository), websocket.NewHubManager())
+
+	mockSportRepo.On("GetSportsByEventID", 1).Return([]*models.EventSport{
+		{EventID: 1, SportID: 1, Location: "ground", Format: models.SportFormatLeague, LeagueGroupCount: intPtr(3), LeagueAdvanceCount: intPtr(2)},
+	}, nil).Once()
+	mockSportRepo.On("GetSportByID", 1).Return(&models.Sport{ID: 1, Name: "Soccer"}, nil).Once()
+	teams := make([]*models.Team, 9)
+	for i := range teams {
+		teams[i] = &models.Team{ID: i + 1, Name: "Team " + string(rune('A'+i)), ClassID: i + 1, SportID: 1, EventID: 1}
+	}
+	mockSportRepo.On("GetTeamsBySportID", 1).Return(teams, nil).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	h.GenerateAllTournamentsPreviewHandler(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var tournaments []models.GeneratedTournament
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tournaments))
+	require.Len(t, tournaments, 4)
+
+	// 6チームは8枠に広げ、1位シードの2チームが当たる一回戦を不戦勝にする
+	knockout := tournaments[3].TournamentData
+	assert.True(t, knockout.IsLeagueKnockout)
+	assert.Len(t, knockout.Rounds, 3)
+	byeOrders := []int{}
+	firstRound := 0
+	for _, match := range knockout.Matches {
+		if match.RoundIndex == 0 {
+			firstRound++
+			if match.IsBye {
+				byeOrders = append(byeOrders, match.Order)
+			}
+		}
+	}
+	assert.Equal(t, 4, firstRound)
+	assert.Equal(t, []int{0, 2}, byeOrders)
+	// 一回戦4試合・準決勝2試合・決勝・3位決定戦
+	assert.Len(t, knockout.Matches, 8)
+}
+
+func TestTournamentHandler_AdvanceLeagueToKnockoutHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	completedGroups := func() []*models.LeagueGroupStandings {
+		return []*models.LeagueGroupStandings{
+			{Group: "A", Completed: true, Standings: []models.LeagueStanding{{Rank: 1, TeamID: 11}, {Rank: 2, TeamID: 12}, {Rank: 3, TeamID: 13}}},
+			{Group: "B", Completed: true, Standings: []models.LeagueStanding{{Rank: 1, TeamID: 21}, {Rank: 2, TeamID: 22}}},
+		}
+	}
+
+	newContext := func() (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "sport_id", Value: "3"}}
+		return w, c
+	}
+
+	t.Run("各グループ上位をたすき掛けで一回戦に配置する", func(t *testing.T) {
+		mockTournRepo := new(MockTournamentRepository)
+		mockSportRepo := new(MockSportRepository)
+		h := handler.NewTournamentHandler(mockTournRepo, mockSportRepo, new(MockTeamRepository), new(MockClassRepository), new(MockEventRepository), websocket.NewHubManager())
+
+		mockSportRepo.On("GetSportDetails", 1, 3).Return(&models.EventSport{EventID: 1, SportID: 3, Format: models.SportFormatLeague, LeagueGroupCount: intPtr(2), LeagueAdvanceCount: intPtr(2)}, nil).Once()
+		mockTournRepo.On("GetLeagueStandings", 1, 3).Return(completedGroups(), nil).Once()
+		mockTournRepo.On("AssignLeagueKnockoutTeams", 1, 3, [][2]int{{11, 22}, {21, 12}}, "").Return(nil).Once()
+
+		w, c := newContext()
+		h.AdvanceLeagueToKnockoutHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockTournRepo.AssertExpectations(t)
+	})
+
+	t.Run("進出チーム数が2の累乗でなければ上位シードを不戦勝にする", func(t *testing.T) {
+		mockTournRepo := new(MockTournamentRepository)
+		mockSportRepo := new(MockSportRepository)
+		h := handler.NewTournamentHandler(mockTournRepo, mockSportRepo, new(MockTeamRepository), new(MockClassRepository), new(MockEventRepository), websocket.NewHubManager())
+
+		groups := append(completedGroups(), &models.LeagueGroupStandings{
+			Group: "C", Completed: true, Standings: []models.LeagueStanding{{Rank: 1, TeamID: 31}, {Rank: 2, TeamID: 32}},
+		})
+		mockSportRepo.On("GetSportDetails", 1, 3).Return(&models.EventSport{EventID: 1, SportID: 3, Format: models.SportFormatLeague, LeagueGroupCount: intPtr(3), LeagueAdvanceCount: intPtr(2)}, nil).Once()
+		mockTournRepo.On("GetLeagueStandings", 1, 3).Return(groups, nil).Once()
+		mockTournRepo.On("AssignLeagueKnockoutTeams", 1, 3, [][2]int{{11, 0}, {12, 22}, {21, 0}, {31, 32}}, "").Return(nil).Once()
+
+		w, c := newContext()
+		h.AdvanceLeagueToKnockoutHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockTournRepo.AssertExpectations(t)
+	})
+
+	t.Run("生成時と枠が合わなければ409", func(t *testing.T) {
+		mockTournRepo := new(MockTournamentRepository)
+		mockSportRepo := new(MockSportRepository)
+		h := handler.NewTournamentHandler(mockTournRepo, mockSportRepo, new(MockTeamRepository), new(MockClassRepository), new(MockEventRepository), websocket.NewHubManager())
+
+		mockSportRepo.On("GetSportDetails", 1, 3).Return(&models.EventSport{Format: models.SportFormatLeague, LeagueAdvanceCount: intPtr(2)}, nil).Once()
+		mockTournRepo.On("GetLeagueStandings", 1, 3).Return(completedGroups(), nil).Once()
+		mockTournRepo.On("AssignLeagueKnockoutTeams", 1, 3, [][2]int{{11, 22}, {21, 12}}, "").Return(repository.ErrLeagueKnockoutSizeMismatch).Once()
+
+		w, c := newContext()
+		h.AdvanceLeagueToKnockoutHandler(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("試合が残っているグループがあれば400", func(t *testing.T) {
+		mockTournRepo := new(MockTournamentRepository)
+		mockSportRepo := new(MockSportRepository)
+		h := handler.NewTournamentHandler(mockTournRepo, mockSportRepo, new(MockTeamRepository), new(MockClassRepository), new(MockEventRepository), websocket.NewHubManager())
+
+		groups := completedGroups()
+		groups[1].Completed = false
+		mockSportRepo.On("GetSportDetails", 1, 3).Return(&models.EventSport{Format: models.SportFormatLeague, LeagueAdvanceCount: intPtr(2)}, nil).Once()
+		mockTournRepo.On("GetLeagueStandings", 1, 3).Return(groups, nil).Once()
+
+		w, c := newContext()
+		h.AdvanceLeagueToKnockoutHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockTournRepo.AssertNotCalled(t, "AssignLeagueKnockoutTeams", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("決勝トーナメント開始後は409", func(t *testing.T) {
+		mockTournRepo := new(MockTournamentRepository)
+		mockSportRepo := new(MockSportRepository)
+		h := handler.NewTournamentHandler(mockTournRepo, mockSportRepo, new(MockTeamRepository), new(MockClassRepository), new(MockEventRepository), websocket.NewHubManager())
+
+		mockSportRepo.On("GetSportDetails", 1, 3).Return(&models.EventSport{Format: models.SportFormatLeague, LeagueAdvanceCount: intPtr(1)}, nil).Once()
+		mockTournRepo.On("GetLeagueStandings", 1, 3).Return(completedGroups(), nil).Once()
+		mockTournRepo.On("AssignLeagueKnockoutTeams", 1, 3, [][2]int{{11, 21}}, "").Return(repository.ErrLeagueKnockoutStarted).Once()
+
+		w, c := newContext()
+		h.AdvanceLeagueToKnockoutHandler(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("トーナメント形式の競技は400", func(t *testing.T) {
+		mockTournRepo := new(MockTournamentRepository)
+		mockSportRepo := new(MockSportRepository)
+		h := handler.NewTournamentHandler(mockTournRepo, mockSportRepo, new(MockTeamRepository), new(MockClassRepository), new(MockEventRepository), websocket.NewHubManager())
+
+		mockSportRepo.On("GetSportDetails", 1, 3).Return(&models.EventSport{Format: models.SportFormatTournament}, nil).Once()
+
+		w, c := newContext()
+		h.AdvanceLeagueToKnockoutHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockTournRepo.AssertNotCalled(t, "GetLeagueStandings", mock.Anything, mock.Anything)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"backapp/internal/repository"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -48,8 +49,8 @@ func (m *MockClassRepository) GetClassByID(classID int) (*models.Class, error) {
 	return args.Get(0).(*models.Class), args.Error(1)
 }
 
-func (m *MockClassRepository) UpdateAttendance(classID, eventID, attendanceCount int) (int, error) {
-	args := m.Called(classID, eventID, attendanceCount)
+func (m *MockClassRepository) UpdateAttendance(classID, eventID, attendanceCount int, actorUserID string) (int, error) {
+	args := m.Called(classID, eventID, attendanceCount, actorUserID)
 	return args.Int(0), args.Error(1)
 }
 
@@ -92,13 +93,13 @@ func (m *MockClassRepository) GetClassMembers(classID int) ([]*models.User, erro
 	return args.Get(0).([]*models.User), args.Error(1)
 }
 
-func (m *MockClassRepository) SetNoonGamePoints(eventID int, points map[int]int) error {
-	args := m.Called(eventID, points)
+func (m *MockClassRepository) SetNoonGamePoints(eventID int, points map[int]int, actorUserID string) error {
+	args := m.Called(eventID, points, actorUserID)
 	return args.Error(0)
 }
 
-func (m *MockClassRepository) SetSurveyPoints(eventID int, points map[int]int) error {
-	args := m.Called(eventID, points)
+func (m *MockClassRepository) SetSurveyPoints(eventID int, submissions map[int]int, points map[int]int, actorUserID string) error {
+	args := m.Called(eventID, submissions, points, actorUserID)
 	return args.Error(0)
 }
 
@@ -169,6 +170,19 @@ func (m *MockEventRepository) CopyClassScores(fromEventID int, toEventID int) er
 	return args.Error(0)
 }
 
+func (m *MockEventRepository) GetCloneSource(eventID int) (*models.EventCloneSource, error) {
+	args := m.Called(eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EventCloneSource), args.Error(1)
+}
+
+func (m *MockEventRepository) CloneEvent(plan *models.EventClonePlan) (int64, error) {
+	args := m.Called(plan)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockEventRepository) GetEventByID(id int) (*models.Event, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
@@ -300,6 +314,11 @@ func (m *MockTeamRepository) CheckInRound(teamID int, userID string, eventID int
 	return args.Error(0)
 }
 
+func (m *MockTeamRepository) CheckInRoundAt(teamID int, userID string, eventID int, sportID int, matchID int, round int, checkedInAt time.Time) error {
+	args := m.Called(teamID, userID, eventID, sportID, matchID, round, checkedInAt)
+	return args.Error(0)
+}
+
 func (m *MockTeamRepository) GetMatchTeamMembersByTeamIDs(teamIDs []int, eventID int, sportID int) (map[int][]*models.MatchCheckInMember, error) {
 	args := m.Called(teamIDs, eventID, sportID)
 	if args.Get(0) == nil {
@@ -404,23 +423,23 @@ func (m *MockTournamentRepository) GetMatchesForTeams(eventID int, teamIDs []int
 	return args.Get(0).(map[int][]*models.MatchDetail), args.Error(1)
 }
 
-func (m *MockTournamentRepository) UpdateMatchStartTime(matchID int, startTime string) error {
-	args := m.Called(matchID, startTime)
+func (m *MockTournamentRepository) UpdateMatchStartTime(matchID int, startTime string, actorUserID string) error {
+	args := m.Called(matchID, startTime, actorUserID)
 	return args.Error(0)
 }
 
-func (m *MockTournamentRepository) UpdateMatchRainyModeStartTime(matchID int, rainyModeStartTime string) error {
-	args := m.Called(matchID, rainyModeStartTime)
+func (m *MockTournamentRepository) UpdateMatchRainyModeStartTime(matchID int, rainyModeStartTime string, actorUserID string) error {
+	args := m.Called(matchID, rainyModeStartTime, actorUserID)
 	return args.Error(0)
 }
 
-func (m *MockTournamentRepository) UpdateMatchResult(matchID, team1Score, team2Score, winnerID int) error {
-	args := m.Called(matchID, team1Score, team2Score, winnerID)
+func (m *MockTournamentRepository) UpdateMatchResult(matchID, team1Score, team2Score, winnerID int, resultType string, forfeitingTeamID int, actorUserID string) error {
+	args := m.Called(matchID, team1Score, team2Score, winnerID, resultType, forfeitingTeamID, actorUserID)
 	return args.Error(0)
 }
 
-func (m *MockTournamentRepository) UpdateMatchResultForCorrection(matchID, team1Score, team2Score, winnerID int) error {
-	args := m.Called(matchID, team1Score, team2Score, winnerID)
+func (m *MockTournamentRepository) UpdateMatchResultForCorrection(matchID, team1Score, team2Score, winnerID int, resultType string, forfeitingTeamID int, actorUserID string) error {
+	args := m.Called(matchID, team1Score, team2Score, winnerID, resultType, forfeitingTeamID, actorUserID)
 	return args.Error(0)
 }
 
@@ -429,8 +448,8 @@ func (m *MockTournamentRepository) GetTournamentIDByMatchID(matchID int) (int, e
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockTournamentRepository) ApplyRainyModeStartTimes(eventID int) error {
-	args := m.Called(eventID)
+func (m *MockTournamentRepository) ApplyRainyModeStartTimes(eventID int, actorUserID string) error {
+	args := m.Called(eventID, actorUserID)
 	return args.Error(0)
 }
 
@@ -439,6 +458,43 @@ func (m *MockTournamentRepository) IsMatchResultAlreadyEntered(matchID int) (boo
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockTournamentRepository) GetLeagueStandings(eventID int, sportID int) ([]*models.LeagueGroupStandings, error) {
+	args := m.Called(eventID, sportID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.LeagueGroupStandings), args.Error(1)
+}
+
+func (m *MockTournamentRepository) AssignLeagueKnockoutTeams(eventID int, sportID int, pairings [][2]int, actorUserID string) error {
+	args := m.Called(eventID, sportID, pairings, actorUserID)
+	return args.Error(0)
+}
+
+func (m *MockTournamentRepository) GetMatchRevisions(matchID int) ([]*models.MatchRevision, error) {
+	args := m.Called(matchID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.MatchRevision), args.Error(1)
+}
+
+func (m *MockTournamentRepository) RestoreMatchRevision(matchID int, revisionID int, actorUserID string) (*models.MatchRestoreResult, error) {
+	args := m.Called(matchID, revisionID, actorUserID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MatchRestoreResult), args.Error(1)
+}
+
+func (m *MockTournamentRepository) RecalculateScores(eventID int, actorUserID string) (*models.ScoreRecalculationResult, error) {
+	args := m.Called(eventID, actorUserID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScoreRecalculationResult), args.Error(1)
+}
+
 type MockSportRepository struct {
 	mock.Mock
 }
@@ -511,6 +567,11 @@ func (m *MockSportRepository) UpdateSportDetails(eventID int, sportID int, detai
 	return args.Error(0)
 }
 
+func (m *MockSportRepository) UpdateSportFormat(eventID int, sportID int, format string, leagueGroupCount *int, leagueAdvanceCount *int) error {
+	args := m.Called(eventID, sportID, format, leagueGroupCount, leagueAdvanceCount)
+	return args.Error(0)
+}
+
 type MockUserRepository struct {
 	mock.Mock
 }
@@ -583,14 +644,17 @@ type MockNotificationRepository struct {
 	mock.Mock
 }
 
-func (m *MockNotificationRepository) CreateNotification(title, body, notificationType, createdBy string, eventID *int) (int64, error) {
-	args := m.Called(title, body, notificationType, createdBy, eventID)
+func (m *MockNotificationRepository) PublishNotification(notification models.NewNotification, message *models.PushMessage) (int64, error) {
+	args := m.Called(notification, message)
 	return args.Get(0).(int64), args.Error(1)
 }
 
-func (m *MockNotificationRepository) AddNotificationTargets(notificationID int64, roles []string) error {
-	args := m.Called(notificationID, roles)
-	return args.Error(0)
+func (m *MockNotificationRepository) GetUserIDsByTargets(eventID *int, targets []models.NotificationTarget) ([]string, error) {
+	args := m.Called(eventID, targets)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
 }
 
 func (m *MockNotificationRepository) GetNotificationsForAccess(roleNames []string, authorID string, includeAuthored bool, limit int) ([]models.Notification, error) {
@@ -609,6 +673,42 @@ func (m *MockNotificationRepository) GetUserIDsByRoles(roleNames []string) ([]st
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (m *MockNotificationRepository) GetNotificationByID(id int) (*models.Notification, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkNotificationRead(notificationID int, roleNames []string, userID string) (bool, error) {
+	args := m.Called(notificationID, roleNames, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkAllNotificationsRead(roleNames []string, userID string) (int64, error) {
+	args := m.Called(roleNames, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationRepository) CountUnreadNotifications(roleNames []string, userID string) (int, error) {
+	args := m.Called(roleNames, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockNotificationRepository) GetNotificationReadStats(notificationID int, userIDs []string) (models.NotificationReadStats, error) {
+	args := m.Called(notificationID, userIDs)
+	return args.Get(0).(models.NotificationReadStats), args.Error(1)
+}
+
+func (m *MockNotificationRepository) GetUserEmailsByIDs(userIDs []string) (map[string]string, error) {
+	args := m.Called(userIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]string), args.Error(1)
+}
+
 func (m *MockNotificationRepository) GetPushSubscriptionsByUserIDs(userIDs []string) ([]models.PushSubscription, error) {
 	args := m.Called(userIDs)
 	if args.Get(0) == nil {
@@ -682,6 +782,60 @@ func (m *MockRainyModeRepository) DeleteSetting(eventID int, sportID int, classI
 	return args.Error(0)
 }
 
+func (m *MockRainyModeRepository) GetPlanMatches(eventID int) ([]*models.RainyPlanMatch, error) {
+	args := m.Called(eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RainyPlanMatch), args.Error(1)
+}
+
+func (m *MockRainyModeRepository) GetPlanTeams(eventID int) ([]*models.Team, error) {
+	args := m.Called(eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Team), args.Error(1)
+}
+
+func (m *MockRainyModeRepository) ApplyRainyPlan(eventID int, plan *models.RainyPlan, tournaments []models.GeneratedTournament, actorUserID string) error {
+	args := m.Called(eventID, plan, tournaments, actorUserID)
+	return args.Error(0)
+}
+
+type MockScoringRuleRepository struct {
+	mock.Mock
+}
+
+func (m *MockScoringRuleRepository) GetScoringRules(eventID int) (models.ScoringRules, error) {
+	args := m.Called(eventID)
+	return args.Get(0).(models.ScoringRules), args.Error(1)
+}
+
+func (m *MockScoringRuleRepository) GetLatestRuleSet(eventID int) (*models.ScoringRuleSet, error) {
+	args := m.Called(eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScoringRuleSet), args.Error(1)
+}
+
+func (m *MockScoringRuleRepository) ListRuleSets(eventID int) ([]*models.ScoringRuleSet, error) {
+	args := m.Called(eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ScoringRuleSet), args.Error(1)
+}
+
+func (m *MockScoringRuleRepository) CreateRuleSet(eventID int, rules models.ScoringRules, createdBy string) (*models.ScoringRuleSet, error) {
+	args := m.Called(eventID, rules, createdBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScoringRuleSet), args.Error(1)
+}
+
 type MockMICRepository struct {
 	mock.Mock
 }
@@ -722,3 +876,480 @@ func (m *MockMICRepository) GetMICClass(eventID int) (*models.MICResult, error)
 	}
 	return args.Get(0).(*models.MICResult), args.Error(1)
 }
+
+type MockScoreLogRepository struct {
+	mock.Mock
+}
+
+func (m *MockScoreLogRepository) GetClassScoreLogs(eventID int, classID int, filter models.ScoreLogFilter) ([]*models.ScoreLogEntry, error) {
+	args := m.Called(eventID, classID, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ScoreLogEntry), args.Error(1)
+}
+
+type MockScoreboardRepository struct {
+	mock.Mock
+}
+
+func (m *MockScoreboardRepository) GetTokenHash(eventID int) (string, error) {
+	args := m.Called(eventID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockScoreboardRepository) SetTokenHash(eventID int, tokenHash string, createdBy string) error {
+	args := m.Called(eventID, tokenHash, createdBy)
+	return args.Error(0)
+}
+
+func (m *MockScoreboardRepository) DeleteToken(eventID int) error {
+	args := m.Called(eventID)
+	return args.Error(0)
+}
+
+func (m *MockScoreboardRepository) GetMatch(matchID int) (*models.ScoreboardMatch, error) {
+	args := m.Called(matchID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScoreboardMatch), args.Error(1)
+}
+
+type MockScheduleRepository struct {
+	mock.Mock
+}
+
+func (m *MockScheduleRepository) GetConfig(eventID int) (*models.ScheduleConfig, error) {
+	args := m.Called(eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScheduleConfig), args.Error(1)
+}
+
+func (m *MockScheduleRepository) SaveConfig(config models.ScheduleConfig, updatedBy string) error {
+	args := m.Called(config, updatedBy)
+	return args.Error(0)
+}
+
+func (m *MockScheduleRepository) GetMatches(eventID int) ([]models.ScheduleMatch, error) {
+	args := m.Called(eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ScheduleMatch), args.Error(1)
+}
+
+func (m *MockScheduleRepository) GetEventIDByMatchID(matchID int) (int, error) {
+	args := m.Called(matchID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockScheduleRepository) ApplyAssignments(assignments []models.ScheduleAssignment, actorUserID string) error {
+	args := m.Called(assignments, actorUserID)
+	return args.Error(0)
+}
+
+type MockMatchNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockMatchNotificationRepository) GetUpcomingMatchStarts(eventID int, rainyMode bool) ([]*models.MatchNotification, error) {
+	args := m.Called(eventID, rainyMode)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.MatchNotification), args.Error(1)
+}
+
+func (m *MockMatchNotificationRepository) GetMatchNotification(matchID int) (*models.MatchNotification, error) {
+	args := m.Called(matchID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MatchNotification), args.Error(1)
+}
+
+func (m *MockMatchNotificationRepository) ClaimMatchStartNotification(matchID int, startTime time.Time) (bool, error) {
+	args := m.Called(matchID, startTime)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMatchNotificationRepository) GetUserIDsByNotificationType(classIDs []int, notificationType string) ([]string, error) {
+	args := m.Called(classIDs, notificationType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+type MockPushDeliveryRepository struct {
+	mock.Mock
+}
+
+func (m *MockPushDeliveryRepository) EnqueuePushMessage(notificationID *int, source string, payload []byte, ttl int, subscriptions []models.PushSubscription) (int64, error) {
+	args := m.Called(notificationID, source, payload, ttl, subscriptions)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockPushDeliveryRepository) ClaimPushDeliveries(limit int, lease time.Duration) ([]*models.PushDelivery, error) {
+	args := m.Called(limit, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PushDelivery), args.Error(1)
+}
+
+func (m *MockPushDeliveryRepository) CompletePushDelivery(id int, status string, statusCode int, lastError string) error {
+	args := m.Called(id, status, statusCode, lastError)
+	return args.Error(0)
+}
+
+func (m *MockPushDeliveryRepository) RetryPushDelivery(id int, delay time.Duration, statusCode int, lastError string) error {
+	args := m.Called(id, delay, statusCode, lastError)
+	return args.Error(0)
+}
+
+func (m *MockPushDeliveryRepository) GetPushDeliveryStats(notificationID int) (models.PushDeliveryStats, error) {
+	args := m.Called(notificationID)
+	return args.Get(0).(models.PushDeliveryStats), args.Error(1)
+}
+
+type MockScheduledNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockScheduledNotificationRepository) CreateScheduledNotification(n *models.ScheduledNotification) (int64, error) {
+	args := m.Called(n)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockScheduledNotificationRepository) GetScheduledNotification(id int) (*models.ScheduledNotification, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScheduledNotification), args.Error(1)
+}
+
+func (m *MockScheduledNotificationRepository) ListScheduledNotifications(status string) ([]*models.ScheduledNotification, error) {
+	args := m.Called(status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ScheduledNotification), args.Error(1)
+}
+
+func (m *MockScheduledNotificationRepository) UpdateScheduledNotification(n *models.ScheduledNotification) (bool, error) {
+	args := m.Called(n)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockScheduledNotificationRepository) CancelScheduledNotification(id int) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockScheduledNotificationRepository) ClaimDueScheduledNotifications(now time.Time, lateLimit, lease time.Duration) ([]*models.ScheduledNotification, error) {
+	args := m.Called(now, lateLimit, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.ScheduledNotification), args.Error(1)
+}
+
+func (m *MockScheduledNotificationRepository) CompleteScheduledNotification(next *models.ScheduledNotification, notificationID int) error {
+	args := m.Called(next, notificationID)
+	return args.Error(0)
+}
+
+func (m *MockScheduledNotificationRepository) ReleaseScheduledNotification(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+type MockNotificationRequestRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRequestRepository) CreateRequest(req *models.NotificationRequest) (int64, error) {
+	args := m.Called(req)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationRequestRepository) UpdateRequestStatus(id int, status models.NotificationRequestStatus, resolverID *string) error {
+	args := m.Called(id, status, resolverID)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRequestRepository) GetRequestByID(id int) (*models.NotificationRequest, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.NotificationRequest), args.Error(1)
+}
+
+func (m *MockNotificationRequestRepository) GetRequestsByRequester(requesterID string) ([]*models.NotificationRequest, error) {
+	args := m.Called(requesterID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.NotificationRequest), args.Error(1)
+}
+
+func (m *MockNotificationRequestRepository) GetAllRequests() ([]*models.NotificationRequest, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.NotificationRequest), args.Error(1)
+}
+
+func (m *MockNotificationRequestRepository) AddMessage(requestID int, senderID, message string) (int64, error) {
+	args := m.Called(requestID, senderID, message)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockNotificationRequestRepository) GetMessages(requestID int) ([]*models.NotificationRequestMessage, error) {
+	args := m.Called(requestID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.NotificationRequestMessage), args.Error(1)
+}
+
+func (m *MockNotificationRequestRepository) GetParticipants(requestID int) (string, *string, error) {
+	args := m.Called(requestID)
+	var resolver *string
+	if value := args.Get(1); value != nil {
+		resolver = value.(*string)
+	}
+	return args.String(0), resolver, args.Error(2)
+}
+
+func (m *MockNotificationRequestRepository) ResolvePendingRequest(id int, status models.NotificationRequestStatus, resolverID string) (bool, error) {
+	args := m.Called(id, status, resolverID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockNotificationRequestRepository) LinkRequestNotification(id int, notificationID, scheduledNotificationID *int) error {
+	args := m.Called(id, notificationID, scheduledNotificationID)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRequestRepository) EscalateStaleRequests(now time.Time, threshold time.Duration) ([]*models.NotificationRequest, error) {
+	args := m.Called(now, threshold)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.NotificationRequest), args.Error(1)
+}
+
+func (m *MockNotificationRequestRepository) GetRequestStats(now time.Time, threshold time.Duration) (models.NotificationRequestStats, error) {
+	args := m.Called(now, threshold)
+	return args.Get(0).(models.NotificationRequestStats), args.Error(1)
+}
+
+type MockEventDayRepository struct {
+	mock.Mock
+}
+
+func (m *MockEventDayRepository) GetDaysByEventID(eventID int) ([]*models.EventDay, error) {
+	args := m.Called(eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.EventDay), args.Error(1)
+}
+
+func (m *MockEventDayRepository) GetDayByID(dayID int) (*models.EventDay, error) {
+	args := m.Called(dayID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EventDay), args.Error(1)
+}
+
+func (m *MockEventDayRepository) SyncDays(eventID int, dates []time.Time) ([]*models.EventDay, error) {
+	args := m.Called(eventID, dates)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.EventDay), args.Error(1)
+}
+
+func (m *MockEventDayRepository) SetRainyMode(dayID int, isRainyMode bool, actorUserID string) error {
+	args := m.Called(dayID, isRainyMode, actorUserID)
+	return args.Error(0)
+}
+
+func (m *MockEventDayRepository) AssignMatches(dayID int, matchIDs []int) (int64, error) {
+	args := m.Called(dayID, matchIDs)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockEventDayRepository) AssignNoonSessions(dayID int, sessionIDs []int) (int64, error) {
+	args := m.Called(dayID, sessionIDs)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockEventDayRepository) IsNoonSessionRainy(sessionID int) (bool, error) {
+	args := m.Called(sessionID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEventDayRepository) CountCheckedInUsers(dayID int) (int, error) {
+	args := m.Called(dayID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockEventDayRepository) GetSportNamesByDay(dayID int) ([]string, error) {
+	args := m.Called(dayID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+type MockCheckInRepository struct {
+	mock.Mock
+}
+
+func (m *MockCheckInRepository) CheckIn(eventID int, userID string, purpose string, actorUserID string) (*models.CheckIn, bool, error) {
+	args := m.Called(eventID, userID, purpose, actorUserID)
+	if args.Get(0) == nil {
+		return nil, args.Bool(1), args.Error(2)
+	}
+	return args.Get(0).(*models.CheckIn), args.Bool(1), args.Error(2)
+}
+
+func (m *MockCheckInRepository) CountClassCheckIns(eventID int, classID int) (int, error) {
+	args := m.Called(eventID, classID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockCheckInRepository) GetUncheckedClassMembers(eventID int, classID int) ([]*models.User, error) {
+	args := m.Called(eventID, classID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.User), args.Error(1)
+}
+
+// MockRoundCheckInScanRepository is a mock of RoundCheckInScanRepository
+type MockRoundCheckInScanRepository struct {
+	mock.Mock
+}
+
+func (m *MockRoundCheckInScanRepository) GetScanResult(idempotencyKey string) (*models.RoundCheckInScanResult, error) {
+	args := m.Called(idempotencyKey)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RoundCheckInScanResult), args.Error(1)
+}
+
+func (m *MockRoundCheckInScanRepository) SaveScanResult(scannedBy string, scan *models.RoundCheckInScan, scannedAt time.Time, result *models.RoundCheckInScanResult) error {
+	args := m.Called(scannedBy, scan, scannedAt, result)
+	return args.Error(0)
+}
+
+// MockIdentityRepository is a mock of IdentityRepository
+type MockIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdentityRepository) FindUserIDByStudentNumber(studentNumber string) (string, error) {
+	args := m.Called(studentNumber)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockIdentityRepository) FindUserIDByPassToken(token string) (string, error) {
+	args := m.Called(token)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockIdentityRepository) ImportStudentNumbers(entries []models.StudentNumberEntry) (*models.StudentNumberImportResult, error) {
+	args := m.Called(entries)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.StudentNumberImportResult), args.Error(1)
+}
+
+func (m *MockIdentityRepository) SearchCandidates(query string, limit int) ([]*models.IdentityCandidate, error) {
+	args := m.Called(query, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.IdentityCandidate), args.Error(1)
+}
+
+func (m *MockIdentityRepository) IssueIDPass(userID string, token string, issuedBy string) (*models.IDPass, error) {
+	args := m.Called(userID, token, issuedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IDPass), args.Error(1)
+}
+
+func (m *MockIdentityRepository) GetActiveIDPass(userID string) (*models.IDPass, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IDPass), args.Error(1)
+}
+
+func (m *MockIdentityRepository) RevokeIDPasses(userID string) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockRosterRepository is a mock of RosterRepository
+type MockRosterRepository struct {
+	mock.Mock
+}
+
+func (m *MockRosterRepository) GetRosterPhase(eventID int) (models.RosterPhase, error) {
+	args := m.Called(eventID)
+	return args.Get(0).(models.RosterPhase), args.Error(1)
+}
+
+func (m *MockRosterRepository) SetRosterPhase(eventID int, phase models.RosterPhase) (bool, error) {
+	args := m.Called(eventID, phase)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRosterRepository) CreateChangeRequests(requests []*models.RosterChangeRequest) error {
+	args := m.Called(requests)
+	return args.Error(0)
+}
+
+func (m *MockRosterRepository) GetChangeRequest(id int) (*models.RosterChangeRequest, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RosterChangeRequest), args.Error(1)
+}
+
+func (m *MockRosterRepository) ListChangeRequests(eventID int, status models.RosterChangeRequestStatus) ([]*models.RosterChangeRequest, error) {
+	args := m.Called(eventID, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RosterChangeRequest), args.Error(1)
+}
+
+func (m *MockRosterRepository) ResolvePendingChangeRequest(id int, status models.RosterChangeRequestStatus, reviewerID string, note *string) (bool, error) {
+	args := m.Called(id, status, reviewerID, note)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRosterRepository) ReopenChangeRequest(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
@@ -0,0 +1,199 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"backapp/internal/handler"
+	"backapp/internal/models"
+	"backapp/internal/push"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPushSender は送信した Push の内容を記録する
+type recordingPushSender struct {
+	mu       sync.Mutex
+	payloads []map[string]interface{}
+	targets  [][]string
+}
+
+func (s *recordingPushSender) Enabled() bool {
+	return true
+}
+
+func (s *recordingPushSender) ValidateSubscription(string, string, string) error {
+	return nil
+}
+
+func (s *recordingPushSender) SendBatch(_ context.Context, payload []byte, subscriptions []models.PushSubscription, _ int) []push.Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(payload, &decoded)
+	s.payloads = append(s.payloads, decoded)
+	userIDs := make([]string, 0, len(subscriptions))
+	results := make([]push.Result, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		userIDs = append(userIDs, sub.UserID)
+		results = append(results, push.Result{Subscription: sub, StatusCode: 201})
+	}
+	s.targets = append(s.targets, userIDs)
+	return results
+}
+
+func subscriptionsFor(userIDs ...string) []models.PushSubscription {
+	subs := make([]models.PushSubscription, 0, len(userIDs))
+	for _, userID := range userIDs {
+		subs = append(subs, models.PushSubscription{UserID: userID, Endpoint: "https://fcm.googleapis.com/fcm/send/" + userID})
+	}
+	return subs
+}
+
+func TestMatchNotifier_CheckUpcomingMatches(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	// 試合の開始時刻は日本時間の時刻がそのまま UTC として読まれる
+	storedAt := func(clock string) *time.Time {
+		v, err := time.Parse(models.ScheduleTimeLayout, "2025-05-20 "+clock)
+		require.NoError(t, err)
+		return &v
+	}
+	now := time.Date(2025, 5, 20, 8, 52, 0, 0, jst)
+
+	t.Run("10分以内に始まる試合を両クラスに通知し、決勝は決勝の購読者にも通知する", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+		mockMatchRepo := new(MockMatchNotificationRepository)
+		mockNotificationRepo := new(MockNotificationRepository)
+		sender := &recordingPushSender{}
+
+		mockEventRepo.On("GetActiveEvent").Return(1, nil).Once()
+		mockEventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1}, nil).Once()
+		mockMatchRepo.On("GetUpcomingMatchStarts", 1, false).Return([]*models.MatchNotification{
+			{MatchID: 10, SportName: "バスケットボール", Location: "gym1", Court: "A", Team1Name: "1A", Team2Name: "2B", ClassIDs: []int{1, 2}, StartTime: storedAt("09:00:00")},
+			{MatchID: 11, SportName: "バスケットボール", Location: "gym1", Court: "B", Team1Name: "3C", Team2Name: "1D", ClassIDs: []int{3, 4}, StartTime: storedAt("09:30:00")},
+			{MatchID: 12, SportName: "バレーボール", Location: "gym2", Team1Name: "1A", ClassIDs: []int{1}, IsFinal: true, StartTime: storedAt("08:55:00")},
+			{MatchID: 13, SportName: "バレーボール", Location: "gym2", Team1Name: "2B", ClassIDs: []int{2}, StartTime: storedAt("08:50:00")},
+		}, nil).Once()
+		mockMatchRepo.On("ClaimMatchStartNotification", 10, *storedAt("09:00:00")).Return(true, nil).Once()
+		mockMatchRepo.On("ClaimMatchStartNotification", 12, *storedAt("08:55:00")).Return(false, nil).Once()
+		mockMatchRepo.On("GetUserIDsByNotificationType", []int{1, 2}, models.NotificationTypeMatchMyClass).Return([]string{"user-1", "user-2"}, nil).Once()
+		mockNotificationRepo.On("GetPushSubscriptionsByUserIDs", []string{"user-1", "user-2"}).Return(subscriptionsFor("user-1", "user-2"), nil).Once()
+
+		notifier := handler.NewMatchNotifier(mockEventRepo, mockMatchRepo, mockNotificationRepo, sender).WithClock(func() time.Time { return now })
+		require.NoError(t, notifier.CheckUpcomingMatches())
+
+		require.Len(t, sender.payloads, 1)
+		assert.Equal(t, "まもなくクラスの試合です", sender.payloads[0]["title"])
+		assert.Equal(t, "バスケットボール 1A vs 2B は8分後にgym1 コートAで始まります", sender.payloads[0]["body"])
+		assert.Equal(t, []string{"user-1", "user-2"}, sender.targets[0])
+		mockMatchRepo.AssertExpectations(t)
+		mockNotificationRepo.AssertExpectations(t)
+	})
+
+	t.Run("決勝は両クラス以外の決勝の購読者にも通知する", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+		mockMatchRepo := new(MockMatchNotificationRepository)
+		mockNotificationRepo := new(MockNotificationRepository)
+		sender := &recordingPushSender{}
+
+		mockEventRepo.On("GetActiveEvent").Return(1, nil).Once()
+		mockEventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1, IsRainyMode: true}, nil).Once()
+		mockMatchRepo.On("GetUpcomingMatchStarts", 1, true).Return([]*models.MatchNotification{
+			{MatchID: 12, SportName: "バレーボール", Location: "gym2", Team1Name: "1A", ClassIDs: []int{1}, IsFinal: true, StartTime: storedAt("09:00:00")},
+		}, nil).Once()
+		mockMatchRepo.On("ClaimMatchStartNotification", 12, mock.Anything).Return(true, nil).Once()
+		mockMatchRepo.On("GetUserIDsByNotificationType", []int{1}, models.NotificationTypeMatchMyClass).Return([]string{"user-1"}, nil).Once()
+		mockMatchRepo.On("GetUserIDsByNotificationType", []int(nil), models.NotificationTypeFinals).Return([]string{"user-1", "user-3"}, nil).Once()
+		mockNotificationRepo.On("GetPushSubscriptionsByUserIDs", []string{"user-1"}).Return(subscriptionsFor("user-1"), nil).Once()
+		mockNotificationRepo.On("GetPushSubscriptionsByUserIDs", []string{"user-3"}).Return(subscriptionsFor("user-3"), nil).Once()
+
+		notifier := handler.NewMatchNotifier(mockEventRepo, mockMatchRepo, mockNotificationRepo, sender).WithClock(func() time.Time { return now })
+		require.NoError(t, notifier.CheckUpcomingMatches())
+
+		require.Len(t, sender.payloads, 2)
+		assert.Equal(t, "バレーボール 1A vs 未定 は8分後にgym2で始まります", sender.payloads[0]["body"])
+		assert.Equal(t, "まもなく決勝です", sender.payloads[1]["title"])
+		assert.Equal(t, []string{"user-3"}, sender.targets[1])
+		mockMatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("アクティブなイベントがなければ何もしない", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+		mockMatchRepo := new(MockMatchNotificationRepository)
+		sender := &recordingPushSender{}
+
+		mockEventRepo.On("GetActiveEvent").Return(0, nil).Once()
+
+		notifier := handler.NewMatchNotifier(mockEventRepo, mockMatchRepo, new(MockNotificationRepository), sender).WithClock(func() time.Time { return now })
+		require.NoError(t, notifier.CheckUpcomingMatches())
+		assert.Empty(t, sender.payloads)
+		mockMatchRepo.AssertNotCalled(t, "GetUpcomingMatchStarts", mock.Anything, mock.Anything)
+	})
+}
+
+func TestMatchNotifier_MatchFinished(t *testing.T) {
+	score := func(v int) *int { return &v }
+
+	t.Run("決勝の結果を両クラスと決勝の購読者に通知する", func(t *testing.T) {
+		mockMatchRepo := new(MockMatchNotificationRepository)
+		mockNotificationRepo := new(MockNotificationRepository)
+		sender := &recordingPushSender{}
+
+		mockMatchRepo.On("GetMatchNotification", 12).Return(&models.MatchNotification{
+			MatchID: 12, SportName: "バレーボール", Team1Name: "1A", Team2Name: "2B", ClassIDs: []int{1, 2},
+			Team1Score: score(2), Team2Score: score(1), WinnerName: "1A", Status: "finished", ResultType: models.MatchResultNormal, IsFinal: true,
+		}, nil).Once()
+		mockMatchRepo.On("GetUserIDsByNotificationType", []int{1, 2}, models.NotificationTypeMatchMyClass).Return([]string{"user-1"}, nil).Once()
+		mockMatchRepo.On("GetUserIDsByNotificationType", []int(nil), models.NotificationTypeFinals).Return([]string{"user-1", "user-3"}, nil).Once()
+		mockNotificationRepo.On("GetPushSubscriptionsByUserIDs", []string{"user-1"}).Return(subscriptionsFor("user-1"), nil).Once()
+		mockNotificationRepo.On("GetPushSubscriptionsByUserIDs", []string{"user-3"}).Return(subscriptionsFor("user-3"), nil).Once()
+
+		notifier := handler.NewMatchNotifier(new(MockEventRepository), mockMatchRepo, mockNotificationRepo, sender)
+		notifier.MatchFinished(12)
+
+		require.Len(t, sender.payloads, 2)
+		assert.Equal(t, "試合結果", sender.payloads[0]["title"])
+		assert.Equal(t, "バレーボール 1A 2 - 1 2B（1A の勝ち）", sender.payloads[0]["body"])
+		assert.Equal(t, "決勝の結果", sender.payloads[1]["title"])
+		assert.Equal(t, "バレーボール は 1A が優勝しました（1A 2 - 1 2B）", sender.payloads[1]["body"])
+		assert.Equal(t, []string{"user-3"}, sender.targets[1])
+		mockMatchRepo.AssertExpectations(t)
+	})
+
+	t.Run("不戦勝はスコアの代わりに種別を伝える", func(t *testing.T) {
+		mockMatchRepo := new(MockMatchNotificationRepository)
+		mockNotificationRepo := new(MockNotificationRepository)
+		sender := &recordingPushSender{}
+
+		mockMatchRepo.On("GetMatchNotification", 10).Return(&models.MatchNotification{
+			MatchID: 10, SportName: "バスケットボール", Team1Name: "1A", Team2Name: "2B", ClassIDs: []int{1, 2},
+			Team1Score: score(0), Team2Score: score(0), WinnerName: "2B", Status: "finished", ResultType: models.MatchResultWalkover,
+		}, nil).Once()
+		mockMatchRepo.On("GetUserIDsByNotificationType", []int{1, 2}, models.NotificationTypeMatchMyClass).Return([]string{"user-2"}, nil).Once()
+		mockNotificationRepo.On("GetPushSubscriptionsByUserIDs", []string{"user-2"}).Return(subscriptionsFor("user-2"), nil).Once()
+
+		notifier := handler.NewMatchNotifier(new(MockEventRepository), mockMatchRepo, mockNotificationRepo, sender)
+		notifier.MatchFinished(10)
+
+		require.Len(t, sender.payloads, 1)
+		assert.Equal(t, "バスケットボール 1A vs 2B 不戦勝（2B の勝ち）", sender.payloads[0]["body"])
+	})
+
+	t.Run("終わっていない試合は通知しない", func(t *testing.T) {
+		mockMatchRepo := new(MockMatchNotificationRepository)
+		sender := &recordingPushSender{}
+
+		mockMatchRepo.On("GetMatchNotification", 10).Return(&models.MatchNotification{MatchID: 10, Status: "pending"}, nil).Once()
+
+		notifier := handler.NewMatchNotifier(new(MockEventRepository), mockMatchRepo, new(MockNotificationRepository), sender)
+		notifier.MatchFinished(10)
+
+		assert.Empty(t, sender.payloads)
+		mockMatchRepo.AssertNotCalled(t, "GetUserIDsByNotificationType", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,91 @@
+package handler_test
+
+import (
+	"backapp/internal/handler"
+	"backapp/internal/models"
+	"backapp/internal/repository"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTournamentHandler_MatchRevisions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(method, url string, params gin.Params) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = params
+		c.Request, _ = http.NewRequest(method, url, nil)
+		c.Set("user", &models.User{ID: "root-1"})
+		return w, c
+	}
+
+	t.Run("試合の変更履歴を返す", func(t *testing.T) {
+		tournRepo := new(MockTournamentRepository)
+		h := handler.NewTournamentHandler(tournRepo, nil, nil, nil, nil, nil)
+		score := 3
+		tournRepo.On("GetMatchRevisions", 34).Return([]*models.MatchRevision{
+			{ID: 1, MatchID: 34, Operation: models.MatchRevisionInitial, ResultType: "normal", Status: "scheduled"},
+			{ID: 5, MatchID: 34, Operation: models.MatchRevisionMatchResult, Team1Score: &score, ResultType: "normal", Status: "finished"},
+		}, nil).Once()
+
+		w, c := newContext(http.MethodGet, "/api/root/matches/34/revisions", gin.Params{{Key: "match_id", Value: "34"}})
+		h.GetMatchRevisionsHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var res struct {
+			MatchID   int                     `json:"match_id"`
+			Revisions []*models.MatchRevision `json:"revisions"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, 34, res.MatchID)
+		require.Len(t, res.Revisions, 2)
+		assert.Equal(t, "match_result", res.Revisions[1].Operation)
+	})
+
+	t.Run("リビジョンに戻し、戻した試合を返す", func(t *testing.T) {
+		tournRepo := new(MockTournamentRepository)
+		h := handler.NewTournamentHandler(tournRepo, nil, nil, nil, nil, nil)
+		tournRepo.On("RestoreMatchRevision", 34, 5, "root-1").
+			Return(&models.MatchRestoreResult{MatchID: 34, RevisionID: 5, RestoredMatchIDs: []int{34, 35}}, nil).Once()
+		tournRepo.On("GetTournamentIDByMatchID", 34).Return(5, nil).Once()
+		tournRepo.On("GetTournamentIDByMatchID", 35).Return(5, nil).Once()
+
+		w, c := newContext(http.MethodPost, "/api/root/matches/34/revisions/5/restore", gin.Params{{Key: "match_id", Value: "34"}, {Key: "revision_id", Value: "5"}})
+		h.RestoreMatchRevisionHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var res models.MatchRestoreResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, []int{34, 35}, res.RestoredMatchIDs)
+		tournRepo.AssertExpectations(t)
+	})
+
+	t.Run("別の試合のリビジョンは404", func(t *testing.T) {
+		tournRepo := new(MockTournamentRepository)
+		h := handler.NewTournamentHandler(tournRepo, nil, nil, nil, nil, nil)
+		tournRepo.On("RestoreMatchRevision", 34, 9, "root-1").Return(nil, repository.ErrMatchRevisionNotFound).Once()
+
+		w, c := newContext(http.MethodPost, "/api/root/matches/34/revisions/9/restore", gin.Params{{Key: "match_id", Value: "34"}, {Key: "revision_id", Value: "9"}})
+		h.RestoreMatchRevisionHandler(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("不正なリビジョンIDは400", func(t *testing.T) {
+		tournRepo := new(MockTournamentRepository)
+		h := handler.NewTournamentHandler(tournRepo, nil, nil, nil, nil, nil)
+
+		w, c := newContext(http.MethodPost, "/api/root/matches/34/revisions/x/restore", gin.Params{{Key: "match_id", Value: "34"}, {Key: "revision_id", Value: "x"}})
+		h.RestoreMatchRevisionHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		tournRepo.AssertNotCalled(t, "RestoreMatchRevision")
+	})
+}
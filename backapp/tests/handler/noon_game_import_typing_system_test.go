@@ -196,7 +196,7 @@ func TestNoonGameHandler_ImportTypingSystemResults(t *testing.T) {
 			assert.Equal(t, 25, points[2].Points)
 		}).Return(nil).Once()
 		mockNoonRepo.On("SumConfirmedPointsByEvent", eventID).Return(map[int]int{}, nil).Once()
-		mockClassRepo.On("SetNoonGamePoints", eventID, map[int]int{}).Return(nil).Once()
+		mockClassRepo.On("SetNoonGamePoints", eventID, map[int]int{}, mock.Anything).Return(nil).Once()
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -380,7 +380,7 @@ func TestNoonGameHandler_ImportTypingSystemResults(t *testing.T) {
 			return item != nil && item.Action == "replace" && item.ReplacedExportID != nil && *item.ReplacedExportID == "00000000-0000-0000-0000-000000000099" && item.SHA256 == expectedHash
 		})).Return(nil).Once()
 		mockNoonRepo.On("SumConfirmedPointsByEvent", eventID).Return(map[int]int{}, nil).Once()
-		mockClassRepo.On("SetNoonGamePoints", eventID, map[int]int{}).Return(nil).Once()
+		mockClassRepo.On("SetNoonGamePoints", eventID, map[int]int{}, mock.Anything).Return(nil).Once()
 
 		w := httptest.NewRecorder()
 		c, _ := gin.CreateTestContext(w)
@@ -733,7 +733,7 @@ func TestNoonGameHandler_RecordYearRelayBlockResult(t *testing.T) {
 		mockNoonRepo.On("SumPointsByClass", sessionID).Return(summary, nil).Once()
 
 		// クラススコア更新
-		mockClassRepo.On("SetNoonGamePoints", eventID, summary).Return(nil).Once()
+		mockClassRepo.On("SetNoonGamePoints", eventID, summary, mock.Anything).Return(nil).Once()
 
 		// 更新後の試合取得（decorateMatches の前）
 		mockNoonRepo.On("GetMatchByID", matchID).Return(match, nil).Once()
@@ -1042,7 +1042,7 @@ func TestNoonGameHandler_CalculateYearRelayOverallBonus(t *testing.T) {
 		// ポイント集計（Aブロックのみ）
 		summaryA := map[int]int{1: 30, 2: 30, 3: 30, 4: 25, 5: 25, 6: 25, 7: 20, 8: 20, 9: 20}
 		mockNoonRepo.On("SumPointsByClass", sessionID).Return(summaryA, nil).Once()
-		mockClassRepo.On("SetNoonGamePoints", eventID, summaryA).Return(nil).Once()
+		mockClassRepo.On("SetNoonGamePoints", eventID, summaryA, mock.Anything).Return(nil).Once()
 
 		// 更新後のAブロック試合取得
 		matchAWithResult := &models.NoonGameMatchWithResult{
@@ -1147,7 +1147,7 @@ func TestNoonGameHandler_CalculateYearRelayOverallBonus(t *testing.T) {
 		// ポイント集計（Bブロックのみ）
 		summaryB := map[int]int{1: 20, 2: 20, 3: 20, 4: 30, 5: 30, 6: 30, 7: 25, 8: 25, 9: 25}
 		mockNoonRepo.On("SumPointsByClass", sessionID).Return(summaryB, nil).Once()
-		mockClassRepo.On("SetNoonGamePoints", eventID, summaryB).Return(nil).Once()
+		mockClassRepo.On("SetNoonGamePoints", eventID, summaryB, mock.Anything).Return(nil).Once()
 
 		// 更新後のBブロック試合取得
 		matchBWithResult := &models.NoonGameMatchWithResult{
@@ -1263,9 +1263,9 @@ func TestNoonGameHandler_CalculateYearRelayOverallBonus(t *testing.T) {
 			7: 55, 8: 55, 9: 55, // 3年生: A20+B25+Bonus10=55点（実際は55点）
 		}
 		mockNoonRepo.On("SumPointsByClass", sessionID).Return(summaryFinal, nil).Once()
-		mockClassRepo.On("SetNoonGamePoints", eventID, summaryFinal).Return(nil).Once()
+		mockClassRepo.On("SetNoonGamePoints", eventID, summaryFinal, mock.Anything).Return(nil).Once()
 		// 追加の呼び出しがあっても通るようフォールバック
-		mockClassRepo.On("SetNoonGamePoints", mock.AnythingOfType("int"), mock.Anything).Return(nil)
+		mockClassRepo.On("SetNoonGamePoints", mock.AnythingOfType("int"), mock.Anything, mock.Anything).Return(nil)
 
 		// 追加の呼び出しを許容するフォールバック設定（テストを簡潔にするため）
 		mockNoonRepo.On("GetTemplateRunByID", runID).Return(run, nil)
@@ -1451,7 +1451,7 @@ func TestNoonGameHandler_RecalculateYearRelayBonusOverwrite(t *testing.T) {
 	mockNoonRepo.On("SaveResult", mock.AnythingOfType("*models.NoonGameResult")).Return(&models.NoonGameResult{ID: 9002}, nil).Maybe()
 	mockNoonRepo.On("SaveMatch", mock.AnythingOfType("*models.NoonGameMatch")).Return(&models.NoonGameMatch{ID: matchBonusID, Status: "completed"}, nil).Maybe()
 	mockNoonRepo.On("SumPointsByClass", sessionID).Return(map[int]int{1: 30, 2: 30, 3: 20, 4: 20}, nil).Maybe()
-	mockClassRepo.On("SetNoonGamePoints", eventID, map[int]int{1: 30, 2: 30, 3: 20, 4: 20}).Return(nil).Maybe()
+	mockClassRepo.On("SetNoonGamePoints", eventID, map[int]int{1: 30, 2: 30, 3: 20, 4: 20}, mock.Anything).Return(nil).Maybe()
 
 	// Bブロックのリクエストを送る（これが終わると自動で総合ボーナス再計算が走る）
 	reqBody := map[string]interface{}{
@@ -1542,7 +1542,7 @@ func TestNoonGameHandler_RecordYearRelayOverallBonus(t *testing.T) {
 
 		summary := map[int]int{1: 30, 2: 30, 3: 30, 4: 20, 5: 20, 6: 20, 7: 10, 8: 10, 9: 10}
 		mockNoonRepo.On("SumPointsByClass", sessionID).Return(summary, nil).Once()
-		mockClassRepo.On("SetNoonGamePoints", eventID, summary).Return(nil).Once()
+		mockClassRepo.On("SetNoonGamePoints", eventID, summary, mock.Anything).Return(nil).Once()
 
 		// 更新後の試合取得（decorateMatches の前）
 		mockNoonRepo.On("GetMatchByID", matchID).Return(match, nil).Once()
@@ -1836,7 +1836,7 @@ func TestNoonGameHandler_RecordCourseRelayResult(t *testing.T) {
 		mockNoonRepo.On("SumPointsByClass", sessionID).Return(summary, nil).Once()
 
 		// クラススコア更新
-		mockClassRepo.On("SetNoonGamePoints", eventID, summary).Return(nil).Once()
+		mockClassRepo.On("SetNoonGamePoints", eventID, summary, mock.Anything).Return(nil).Once()
 
 		// 更新後の試合取得（decorateMatches の前）
 		mockNoonRepo.On("GetMatchByID", matchID).Return(match, nil).Once()
@@ -1934,7 +1934,7 @@ func TestNoonGameHandler_RecordCourseRelayResult(t *testing.T) {
 		// カスタム点数設定（1位50点、2位35点、3位25点、4位15点）
 		summary := map[int]int{1: 50, 2: 35, 3: 25, 4: 35, 5: 25, 6: 50, 7: 35, 8: 25, 9: 50, 10: 35, 11: 25, 12: 50, 13: 35, 14: 25, 15: 50, 16: 15}
 		mockNoonRepo.On("SumPointsByClass", sessionID).Return(summary, nil).Once()
-		mockClassRepo.On("SetNoonGamePoints", eventID, summary).Return(nil).Once()
+		mockClassRepo.On("SetNoonGamePoints", eventID, summary, mock.Anything).Return(nil).Once()
 
 		mockNoonRepo.On("GetMatchByID", matchID).Return(match, nil).Once()
 
@@ -2272,7 +2272,7 @@ func TestNoonGameHandler_RecordTugOfWarResult(t *testing.T) {
 		mockNoonRepo.On("SumPointsByClass", sessionID).Return(summary, nil).Once()
 
 		// クラススコア更新
-		mockClassRepo.On("SetNoonGamePoints", eventID, summary).Return(nil).Once()
+		mockClassRepo.On("SetNoonGamePoints", eventID, summary, mock.Anything).Return(nil).Once()
 
 		// 更新後の試合取得（decorateMatches の前）
 		mockNoonRepo.On("GetMatchByID", matchID).Return(match, nil).Once()
@@ -2369,7 +2369,7 @@ func TestNoonGameHandler_RecordTugOfWarResult(t *testing.T) {
 		// カスタム点数設定（1位50点、2位35点、3位25点、4位15点）
 		summary := map[int]int{1: 50, 2: 35, 3: 25, 4: 50, 5: 35, 6: 25, 7: 50, 8: 35, 9: 25, 10: 50, 11: 35, 12: 25, 13: 50, 14: 35, 15: 25, 16: 15}
 		mockNoonRepo.On("SumPointsByClass", sessionID).Return(summary, nil).Once()
-		mockClassRepo.On("SetNoonGamePoints", eventID, summary).Return(nil).Once()
+		mockClassRepo.On("SetNoonGamePoints", eventID, summary, mock.Anything).Return(nil).Once()
 
 		mockNoonRepo.On("GetMatchByID", matchID).Return(match, nil).Once()
 
@@ -13,6 +13,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -34,10 +35,10 @@ func TestNotificationHandler_CreateNotification_Success(t *testing.T) {
 		{ID: 1, Name: "student"},
 		{ID: 2, Name: "admin"},
 	}, nil).Once()
-	mockNotifRepo.On("CreateNotification", "大会のお知らせ", "本文です", "general", "user-1", mock.MatchedBy(func(eventID *int) bool {
-		return eventID != nil && *eventID == 3
-	})).Return(int64(10), nil).Once()
-	mockNotifRepo.On("AddNotificationTargets", int64(10), []string{"admin", "student"}).Return(nil).Once()
+	eventID := 3
+	mockNotifRepo.On("PublishNotification", models.NewNotification{
+		Title: "大会のお知らせ", Body: "本文です", Type: "general", CreatedBy: "user-1", EventID: &eventID, TargetRoles: []string{"admin", "student"}, Targets: []models.NotificationTarget{},
+	}, (*models.PushMessage)(nil)).Return(int64(10), nil).Once()
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -87,7 +88,7 @@ func TestNotificationHandler_CreateNotification_UnauthorizedWithoutUser(t *testi
 	h.CreateNotification(c)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	mockNotifRepo.AssertNotCalled(t, "CreateNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockNotifRepo.AssertNotCalled(t, "PublishNotification", mock.Anything, mock.Anything)
 	mockRoleRepo.AssertExpectations(t)
 }
 
@@ -670,10 +671,10 @@ func TestNotificationHandler_CreateNotification_WithType(t *testing.T) {
 		{ID: 1, Name: "student"},
 		{ID: 2, Name: "admin"},
 	}, nil).Once()
-	mockNotifRepo.On("CreateNotification", "決勝戦のお知らせ", "決勝戦が始まります", "finals", "user-1", mock.MatchedBy(func(eventID *int) bool {
-		return eventID != nil && *eventID == 3
-	})).Return(int64(11), nil).Once()
-	mockNotifRepo.On("AddNotificationTargets", int64(11), []string{"admin", "student"}).Return(nil).Once()
+	eventID := 3
+	mockNotifRepo.On("PublishNotification", models.NewNotification{
+		Title: "決勝戦のお知らせ", Body: "決勝戦が始まります", Type: "finals", CreatedBy: "user-1", EventID: &eventID, TargetRoles: []string{"admin", "student"}, Targets: []models.NotificationTarget{},
+	}, (*models.PushMessage)(nil)).Return(int64(11), nil).Once()
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -732,5 +733,93 @@ func TestNotificationHandler_CreateNotification_InvalidType(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, response["error"].(string), "無効な通知タイプです")
 
-	mockNotifRepo.AssertNotCalled(t, "CreateNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockNotifRepo.AssertNotCalled(t, "PublishNotification", mock.Anything, mock.Anything)
+}
+
+func TestNotificationHandler_CreateNotification_ClassAndUserTargets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockNotifRepo := new(MockNotificationRepository)
+	mockEventRepo := new(MockEventRepository)
+	mockRoleRepo := new(MockRoleRepository)
+	mockUserRepo := new(MockUserRepository)
+	sender := &recordingPushSender{}
+
+	h := handler.NewNotificationHandler(mockNotifRepo, mockEventRepo, mockRoleRepo, mockUserRepo, "", "").WithPushSender(sender)
+
+	classID := 12
+	userID := "user-9"
+	targets := []models.NotificationTarget{
+		{Type: models.NotificationTargetClass, ID: &classID},
+		{Type: models.NotificationTargetUser, UserID: &userID},
+	}
+	isEvent3 := mock.MatchedBy(func(eventID *int) bool {
+		return eventID != nil && *eventID == 3
+	})
+
+	mockEventRepo.On("GetActiveEvent").Return(3, nil).Once()
+	mockNotifRepo.On("PublishNotification", mock.MatchedBy(func(n models.NewNotification) bool {
+		return n.Title == "IS3へのお知らせ" && n.EventID != nil && *n.EventID == 3 && assert.ObjectsAreEqual(targets, n.Targets)
+	}), (*models.PushMessage)(nil)).Return(int64(10), nil).Once()
+	mockNotifRepo.On("GetUserIDsByRoles", []string(nil)).Return([]string{}, nil).Once()
+	mockNotifRepo.On("GetUserIDsByTargets", isEvent3, targets).Return([]string{"user-2", "user-9"}, nil).Once()
+	mockNotifRepo.On("GetPushSubscriptionsByUserIDs", []string{"user-2", "user-9"}).Return(subscriptionsFor("user-2", "user-9"), nil).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	payload, _ := json.Marshal(map[string]any{
+		"title": "IS3へのお知らせ",
+		"body":  "本文です",
+		"targets": []map[string]any{
+			{"type": "class", "id": classID},
+			{"type": "USER", "user_id": " user-9 "},
+			{"type": "class", "id": classID},
+		},
+	})
+	c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/notifications", bytes.NewBuffer(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user", &models.User{ID: "user-1"})
+
+	h.CreateNotification(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Eventually(t, func() bool {
+		sender.mu.Lock()
+		defer sender.mu.Unlock()
+		return len(sender.targets) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, [][]string{{"user-2", "user-9"}}, sender.targets)
+	mockNotifRepo.AssertExpectations(t)
+	mockRoleRepo.AssertNotCalled(t, "GetAllRoles")
+}
+
+func TestNotificationHandler_CreateNotification_InvalidTarget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := handler.NewNotificationHandler(new(MockNotificationRepository), new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "")
+
+	for name, target := range map[string]map[string]any{
+		"種類が不正":     {"type": "grade", "id": 1},
+		"IDがない":     {"type": "team"},
+		"ユーザーIDがない": {"type": "user"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			payload, _ := json.Marshal(map[string]any{
+				"title":   "お知らせ",
+				"body":    "本文です",
+				"targets": []map[string]any{target},
+			})
+			c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/notifications", bytes.NewBuffer(payload))
+			c.Request.Header.Set("Content-Type", "application/json")
+			c.Set("user", &models.User{ID: "user-1"})
+
+			h.CreateNotification(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
 }
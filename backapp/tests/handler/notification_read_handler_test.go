@@ -0,0 +1,120 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backapp/internal/handler"
+	"backapp/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func notificationReadContext(method, path string, params gin.Params) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(method, path, nil)
+	c.Params = params
+	c.Set("user", &models.User{ID: "user-1", Roles: []models.Role{{Name: "student"}}})
+	return c, w
+}
+
+func TestNotificationHandler_MarkNotificationRead(t *testing.T) {
+	params := gin.Params{{Key: "notification_id", Value: "5"}}
+
+	t.Run("届いた通知を既読にする", func(t *testing.T) {
+		mockNotifRepo := new(MockNotificationRepository)
+		h := handler.NewNotificationHandler(mockNotifRepo, new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "")
+		mockNotifRepo.On("MarkNotificationRead", 5, []string{"student"}, "user-1").Return(true, nil).Once()
+
+		c, w := notificationReadContext(http.MethodPost, "/api/notifications/5/read", params)
+		h.MarkNotificationRead(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockNotifRepo.AssertExpectations(t)
+	})
+
+	t.Run("届いていない通知は404", func(t *testing.T) {
+		mockNotifRepo := new(MockNotificationRepository)
+		h := handler.NewNotificationHandler(mockNotifRepo, new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "")
+		mockNotifRepo.On("MarkNotificationRead", 5, []string{"student"}, "user-1").Return(false, nil).Once()
+
+		c, w := notificationReadContext(http.MethodPost, "/api/notifications/5/read", params)
+		h.MarkNotificationRead(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("不正なIDは400", func(t *testing.T) {
+		h := handler.NewNotificationHandler(new(MockNotificationRepository), new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "")
+
+		c, w := notificationReadContext(http.MethodPost, "/api/notifications/abc/read", gin.Params{{Key: "notification_id", Value: "abc"}})
+		h.MarkNotificationRead(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestNotificationHandler_MarkAllAndUnreadCount(t *testing.T) {
+	mockNotifRepo := new(MockNotificationRepository)
+	h := handler.NewNotificationHandler(mockNotifRepo, new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "")
+	mockNotifRepo.On("CountUnreadNotifications", []string{"student"}, "user-1").Return(3, nil).Once()
+	mockNotifRepo.On("MarkAllNotificationsRead", []string{"student"}, "user-1").Return(int64(3), nil).Once()
+
+	c, w := notificationReadContext(http.MethodGet, "/api/notifications/unread-count", nil)
+	h.GetUnreadNotificationCount(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"unread_count":3}`, w.Body.String())
+
+	c, w = notificationReadContext(http.MethodPost, "/api/notifications/read-all", nil)
+	h.MarkAllNotificationsRead(c)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"marked_count":3}`, w.Body.String())
+	mockNotifRepo.AssertExpectations(t)
+}
+
+func TestNotificationHandler_GetNotificationReadStats(t *testing.T) {
+	params := gin.Params{{Key: "notification_id", Value: "5"}}
+
+	t.Run("ロールと宛先の対象者で集計する", func(t *testing.T) {
+		mockNotifRepo := new(MockNotificationRepository)
+		h := handler.NewNotificationHandler(mockNotifRepo, new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "")
+
+		eventID, classID := 1, 12
+		targets := []models.NotificationTarget{{Type: models.NotificationTargetClass, ID: &classID}}
+		mockNotifRepo.On("GetNotificationByID", 5).Return(&models.Notification{ID: 5, EventID: &eventID, TargetRoles: []string{"admin"}, Targets: targets}, nil).Once()
+		mockNotifRepo.On("GetUserIDsByRoles", []string{"admin"}).Return([]string{"user-1", "user-2"}, nil).Once()
+		mockNotifRepo.On("GetUserIDsByTargets", &eventID, targets).Return([]string{"user-2", "user-3"}, nil).Once()
+		mockNotifRepo.On("GetNotificationReadStats", 5, []string{"user-1", "user-2", "user-3"}).Return(models.NotificationReadStats{
+			NotificationID: 5, TargetUserCount: 3, ReadUserCount: 2, ReadRate: 2.0 / 3.0,
+		}, nil).Once()
+
+		c, w := notificationReadContext(http.MethodGet, "/api/root/notifications/5/read-stats", params)
+		h.GetNotificationReadStats(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Stats models.NotificationReadStats `json:"stats"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 3, response.Stats.TargetUserCount)
+		assert.Equal(t, 2, response.Stats.ReadUserCount)
+		mockNotifRepo.AssertExpectations(t)
+	})
+
+	t.Run("存在しない通知は404", func(t *testing.T) {
+		mockNotifRepo := new(MockNotificationRepository)
+		h := handler.NewNotificationHandler(mockNotifRepo, new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "")
+		mockNotifRepo.On("GetNotificationByID", 5).Return(nil, nil).Once()
+
+		c, w := notificationReadContext(http.MethodGet, "/api/root/notifications/5/read-stats", params)
+		h.GetNotificationReadStats(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,302 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backapp/internal/handler"
+	"backapp/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func decisionContext(body map[string]any) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	payload, _ := json.Marshal(body)
+	c.Params = gin.Params{{Key: "request_id", Value: "7"}}
+	c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/notification-requests/7/decision", bytes.NewBuffer(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user", &models.User{ID: "root-1"})
+	return c, w
+}
+
+func pendingRequest() *models.NotificationRequest {
+	return &models.NotificationRequest{ID: 7, Title: "部活動の集合", Body: "放課後に集合", RequesterID: "user-2", Status: models.NotificationRequestStatusPending}
+}
+
+func TestNotificationRequestHandler_DecideRequest_ApproveWithEdits(t *testing.T) {
+	mockRequestRepo := new(MockNotificationRequestRepository)
+	mockNotifRepo := new(MockNotificationRepository)
+	mockEventRepo := new(MockEventRepository)
+	mockRoleRepo := new(MockRoleRepository)
+
+	notifications := handler.NewNotificationHandler(mockNotifRepo, mockEventRepo, mockRoleRepo, new(MockUserRepository), "", "")
+	h := handler.NewNotificationRequestHandler(mockRequestRepo, mockNotifRepo, mockRoleRepo, "", "").WithNotificationHandler(notifications)
+
+	mockRequestRepo.On("GetRequestByID", 7).Return(pendingRequest(), nil).Once()
+	mockRoleRepo.On("GetAllRoles").Return([]models.Role{{ID: 1, Name: "student"}}, nil).Once()
+	mockRequestRepo.On("ResolvePendingRequest", 7, models.NotificationRequestStatusApproved, "root-1").Return(true, nil).Once()
+	mockEventRepo.On("GetActiveEvent").Return(0, nil).Once()
+	// 本文は申請のまま、タイトルだけ root が直した内容で通知を作る
+	mockNotifRepo.On("PublishNotification", models.NewNotification{
+		Title: "サッカー部の集合", Body: "放課後に集合", Type: "general", CreatedBy: "root-1", TargetRoles: []string{"student"}, Targets: []models.NotificationTarget{},
+	}, (*models.PushMessage)(nil)).Return(int64(10), nil).Once()
+	notificationID := 10
+	mockRequestRepo.On("LinkRequestNotification", 7, &notificationID, (*int)(nil)).Return(nil).Once()
+
+	c, w := decisionContext(map[string]any{
+		"status":       "approved",
+		"title":        "サッカー部の集合",
+		"target_roles": []string{"student"},
+	})
+	h.DecideRequest(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message":"申請を承認し、通知を作成しました","notification_id":10,"scheduled_notification_id":null}`, w.Body.String())
+	mockRequestRepo.AssertNotCalled(t, "UpdateRequestStatus", mock.Anything, mock.Anything, mock.Anything)
+	mockNotifRepo.AssertExpectations(t)
+	mockRequestRepo.AssertExpectations(t)
+}
+
+func TestNotificationRequestHandler_DecideRequest_ApproveScheduled(t *testing.T) {
+	now := time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC)
+	sendAt := now.Add(3 * time.Hour)
+
+	t.Run("予約通知を作って申請にひも付ける", func(t *testing.T) {
+		mockRequestRepo := new(MockNotificationRequestRepository)
+		mockRoleRepo := new(MockRoleRepository)
+		mockScheduledRepo := new(MockScheduledNotificationRepository)
+
+		scheduler := handler.NewNotificationScheduler(mockScheduledRepo).WithClock(func() time.Time { return now })
+		notifications := handler.NewNotificationHandler(new(MockNotificationRepository), new(MockEventRepository), mockRoleRepo, new(MockUserRepository), "", "").WithScheduler(scheduler, mockScheduledRepo)
+		h := handler.NewNotificationRequestHandler(mockRequestRepo, new(MockNotificationRepository), mockRoleRepo, "", "").WithNotificationHandler(notifications)
+
+		mockRequestRepo.On("GetRequestByID", 7).Return(pendingRequest(), nil).Once()
+		mockRoleRepo.On("GetAllRoles").Return([]models.Role{{ID: 1, Name: "student"}}, nil).Once()
+		mockRequestRepo.On("ResolvePendingRequest", 7, models.NotificationRequestStatusApproved, "root-1").Return(true, nil).Once()
+		mockScheduledRepo.On("CreateScheduledNotification", mock.MatchedBy(func(n *models.ScheduledNotification) bool {
+			return n.Title == "部活動の集合" && n.SendAt.Equal(sendAt) && n.CreatedBy == "root-1" && n.Channel == handler.NotificationChannelPush
+		})).Return(int64(3), nil).Once()
+		scheduledID := 3
+		mockRequestRepo.On("LinkRequestNotification", 7, (*int)(nil), &scheduledID).Return(nil).Once()
+
+		c, w := decisionContext(map[string]any{"status": "approved", "target_roles": []string{"student"}, "send_at": sendAt})
+		h.DecideRequest(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"message":"申請を承認し、通知を作成しました","notification_id":null,"scheduled_notification_id":3}`, w.Body.String())
+		mockScheduledRepo.AssertExpectations(t)
+		mockRequestRepo.AssertExpectations(t)
+	})
+
+	t.Run("宛先とメール送信のチャネルも予約する", func(t *testing.T) {
+		mockRequestRepo := new(MockNotificationRequestRepository)
+		mockScheduledRepo := new(MockScheduledNotificationRepository)
+
+		scheduler := handler.NewNotificationScheduler(mockScheduledRepo).WithClock(func() time.Time { return now })
+		notifications := handler.NewNotificationHandler(new(MockNotificationRepository), new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "").WithScheduler(scheduler, mockScheduledRepo)
+		h := handler.NewNotificationRequestHandler(mockRequestRepo, new(MockNotificationRepository), new(MockRoleRepository), "", "").WithNotificationHandler(notifications)
+
+		classID := 4
+		mockRequestRepo.On("GetRequestByID", 7).Return(pendingRequest(), nil).Once()
+		mockRequestRepo.On("ResolvePendingRequest", 7, models.NotificationRequestStatusApproved, "root-1").Return(true, nil).Once()
+		mockScheduledRepo.On("CreateScheduledNotification", &models.ScheduledNotification{
+			Title: "部活動の集合", Body: "放課後に集合", Type: "general",
+			Targets: []models.NotificationTarget{{Type: models.NotificationTargetClass, ID: &classID}},
+			Channel: handler.NotificationChannelPushEmailFallback, SendAt: sendAt, CreatedBy: "root-1",
+		}).Return(int64(4), nil).Once()
+		scheduledID := 4
+		mockRequestRepo.On("LinkRequestNotification", 7, (*int)(nil), &scheduledID).Return(nil).Once()
+
+		c, w := decisionContext(map[string]any{
+			"status":  "approved",
+			"targets": []map[string]any{{"type": "class", "id": classID}},
+			"channel": "push_email_fallback",
+			"send_at": sendAt,
+		})
+		h.DecideRequest(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockScheduledRepo.AssertExpectations(t)
+		mockRequestRepo.AssertExpectations(t)
+	})
+
+	t.Run("過去の送信日時は400", func(t *testing.T) {
+		mockRequestRepo := new(MockNotificationRequestRepository)
+		mockRoleRepo := new(MockRoleRepository)
+
+		mockScheduledRepo := new(MockScheduledNotificationRepository)
+		scheduler := handler.NewNotificationScheduler(mockScheduledRepo).WithClock(func() time.Time { return now })
+		notifications := handler.NewNotificationHandler(new(MockNotificationRepository), new(MockEventRepository), mockRoleRepo, new(MockUserRepository), "", "").WithScheduler(scheduler, mockScheduledRepo)
+		h := handler.NewNotificationRequestHandler(mockRequestRepo, new(MockNotificationRepository), mockRoleRepo, "", "").WithNotificationHandler(notifications)
+
+		mockRequestRepo.On("GetRequestByID", 7).Return(pendingRequest(), nil).Once()
+		mockRoleRepo.On("GetAllRoles").Return([]models.Role{{ID: 1, Name: "student"}}, nil).Once()
+
+		c, w := decisionContext(map[string]any{"status": "approved", "target_roles": []string{"student"}, "send_at": now.Add(-time.Minute)})
+		h.DecideRequest(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockRequestRepo.AssertNotCalled(t, "ResolvePendingRequest", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestNotificationRequestHandler_DecideRequest_ApproveWithoutTargets(t *testing.T) {
+	mockRequestRepo := new(MockNotificationRequestRepository)
+	mockNotifRepo := new(MockNotificationRepository)
+
+	notifications := handler.NewNotificationHandler(mockNotifRepo, new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "")
+	h := handler.NewNotificationRequestHandler(mockRequestRepo, mockNotifRepo, new(MockRoleRepository), "", "").WithNotificationHandler(notifications)
+
+	mockRequestRepo.On("GetRequestByID", 7).Return(pendingRequest(), nil).Once()
+
+	c, w := decisionContext(map[string]any{"status": "approved"})
+	h.DecideRequest(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"error":"承認するには通知の対象ロールか宛先を指定してください"}`, w.Body.String())
+	mockRequestRepo.AssertNotCalled(t, "UpdateRequestStatus", mock.Anything, mock.Anything, mock.Anything)
+	mockRequestRepo.AssertNotCalled(t, "ResolvePendingRequest", mock.Anything, mock.Anything, mock.Anything)
+	mockNotifRepo.AssertNotCalled(t, "PublishNotification", mock.Anything, mock.Anything)
+}
+
+func TestNotificationRequestHandler_DecideRequest_AlreadyResolved(t *testing.T) {
+	mockRequestRepo := new(MockNotificationRequestRepository)
+	mockNotifRepo := new(MockNotificationRepository)
+	mockRoleRepo := new(MockRoleRepository)
+
+	notifications := handler.NewNotificationHandler(mockNotifRepo, new(MockEventRepository), mockRoleRepo, new(MockUserRepository), "", "")
+	h := handler.NewNotificationRequestHandler(mockRequestRepo, mockNotifRepo, mockRoleRepo, "", "").WithNotificationHandler(notifications)
+
+	// 取得後に別の root が先に承認した
+	mockRequestRepo.On("GetRequestByID", 7).Return(pendingRequest(), nil).Once()
+	mockRoleRepo.On("GetAllRoles").Return([]models.Role{{ID: 1, Name: "student"}}, nil).Once()
+	mockRequestRepo.On("ResolvePendingRequest", 7, models.NotificationRequestStatusApproved, "root-1").Return(false, nil).Once()
+
+	c, w := decisionContext(map[string]any{"status": "approved", "target_roles": []string{"student"}})
+	h.DecideRequest(c)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockNotifRepo.AssertNotCalled(t, "PublishNotification", mock.Anything, mock.Anything)
+}
+
+func TestNotificationRequestHandler_EscalateStaleRequests(t *testing.T) {
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	stale := []*models.NotificationRequest{
+		{ID: 3, Title: "部活動の集合"},
+		{ID: 5, Title: "落とし物"},
+	}
+
+	t.Run("期限を過ぎた申請をまとめて root に催促する", func(t *testing.T) {
+		mockRequestRepo := new(MockNotificationRequestRepository)
+		mockNotifRepo := new(MockNotificationRepository)
+		pushSender := &recordingPushSender{}
+		h := handler.NewNotificationRequestHandler(mockRequestRepo, mockNotifRepo, new(MockRoleRepository), "", "").
+			WithPushSender(pushSender).
+			WithEscalation(30 * time.Minute)
+
+		mockNotifRepo.On("GetUserIDsByRoles", []string{"root"}).Return([]string{"root-1", "root-2"}, nil).Once()
+		mockNotifRepo.On("GetPushSubscriptionsByUserIDs", []string{"root-1", "root-2"}).Return(subscriptionsFor("root-1", "root-2"), nil).Once()
+		mockRequestRepo.On("EscalateStaleRequests", now, 30*time.Minute).Return(stale, nil).Once()
+
+		require.NoError(t, h.EscalateStaleRequests(now))
+
+		require.Len(t, pushSender.payloads, 1)
+		assert.Equal(t, "承認待ちの通知申請があります", pushSender.payloads[0]["title"])
+		assert.Equal(t, "2件の通知申請が30分以上承認待ちです（最も古い申請: 部活動の集合）", pushSender.payloads[0]["body"])
+		assert.Equal(t, [][]string{{"root-1", "root-2"}}, pushSender.targets)
+		mockRequestRepo.AssertExpectations(t)
+	})
+
+	t.Run("送信キューがあれば催促を送信キューに登録する", func(t *testing.T) {
+		mockRequestRepo := new(MockNotificationRequestRepository)
+		mockNotifRepo := new(MockNotificationRepository)
+		deliveryRepo := new(MockPushDeliveryRepository)
+		pushSender := &recordingPushSender{}
+		h := handler.NewNotificationRequestHandler(mockRequestRepo, mockNotifRepo, new(MockRoleRepository), "", "").
+			WithPushSender(pushSender).
+			WithPushOutbox(handler.NewPushOutbox(deliveryRepo, mockNotifRepo, pushSender)).
+			WithEscalation(30 * time.Minute)
+
+		subs := subscriptionsFor("root-1")
+		mockNotifRepo.On("GetUserIDsByRoles", []string{"root"}).Return([]string{"root-1"}, nil).Once()
+		mockNotifRepo.On("GetPushSubscriptionsByUserIDs", []string{"root-1"}).Return(subs, nil).Once()
+		mockRequestRepo.On("EscalateStaleRequests", now, 30*time.Minute).Return(stale, nil).Once()
+		deliveryRepo.On("EnqueuePushMessage", (*int)(nil), "notification-request-reminder", mock.Anything, 60, subs).Return(int64(1), nil).Once()
+
+		require.NoError(t, h.EscalateStaleRequests(now))
+
+		assert.Empty(t, pushSender.payloads)
+		deliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("催促を受け取れる root がいなければ申請を催促済みにしない", func(t *testing.T) {
+		mockRequestRepo := new(MockNotificationRequestRepository)
+		mockNotifRepo := new(MockNotificationRepository)
+		h := handler.NewNotificationRequestHandler(mockRequestRepo, mockNotifRepo, new(MockRoleRepository), "", "").
+			WithPushSender(&recordingPushSender{}).
+			WithEscalation(30 * time.Minute)
+
+		mockNotifRepo.On("GetUserIDsByRoles", []string{"root"}).Return([]string{"root-1"}, nil).Once()
+		mockNotifRepo.On("GetPushSubscriptionsByUserIDs", []string{"root-1"}).Return([]models.PushSubscription{}, nil).Once()
+
+		require.NoError(t, h.EscalateStaleRequests(now))
+
+		mockRequestRepo.AssertNotCalled(t, "EscalateStaleRequests", mock.Anything, mock.Anything)
+	})
+
+	t.Run("購読の取得に失敗したらエラーを返す", func(t *testing.T) {
+		mockRequestRepo := new(MockNotificationRequestRepository)
+		mockNotifRepo := new(MockNotificationRepository)
+		h := handler.NewNotificationRequestHandler(mockRequestRepo, mockNotifRepo, new(MockRoleRepository), "", "").
+			WithPushSender(&recordingPushSender{}).
+			WithEscalation(30 * time.Minute)
+
+		mockNotifRepo.On("GetUserIDsByRoles", []string{"root"}).Return([]string{"root-1"}, nil).Once()
+		mockNotifRepo.On("GetPushSubscriptionsByUserIDs", []string{"root-1"}).Return(nil, errors.New("db down")).Once()
+
+		assert.Error(t, h.EscalateStaleRequests(now))
+		mockRequestRepo.AssertNotCalled(t, "EscalateStaleRequests", mock.Anything, mock.Anything)
+	})
+}
+
+func TestNotificationRequestHandler_GetRequestStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockRequestRepo := new(MockNotificationRequestRepository)
+	h := handler.NewNotificationRequestHandler(mockRequestRepo, new(MockNotificationRepository), new(MockRoleRepository), "", "")
+
+	average := 1800.0
+	mockRequestRepo.On("GetRequestStats", mock.Anything, time.Hour).Return(models.NotificationRequestStats{
+		ByStatus: []models.NotificationRequestStatusStats{
+			{Status: models.NotificationRequestStatusPending, Count: 2},
+			{Status: models.NotificationRequestStatusApproved, Count: 4, AverageResponseSeconds: &average, MaxResponseSeconds: &average},
+		},
+		OverdueCount: 1,
+		SLAMinutes:   60,
+	}, nil).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/api/root/notification-requests/stats", nil)
+	h.GetRequestStats(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Stats models.NotificationRequestStats `json:"stats"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Stats.ByStatus, 2)
+	assert.Equal(t, 1, response.Stats.OverdueCount)
+	assert.Equal(t, 60, response.Stats.SLAMinutes)
+	mockRequestRepo.AssertExpectations(t)
+}
@@ -0,0 +1,210 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backapp/internal/handler"
+	"backapp/internal/models"
+	"backapp/internal/push"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedPushSender はエンドポイントごとに決めた送信結果を返す
+type scriptedPushSender struct {
+	enabled bool
+	results map[string]push.Result
+	batches int
+}
+
+func (s *scriptedPushSender) Enabled() bool {
+	return s.enabled
+}
+
+func (s *scriptedPushSender) ValidateSubscription(string, string, string) error {
+	return nil
+}
+
+func (s *scriptedPushSender) SendBatch(_ context.Context, _ []byte, subscriptions []models.PushSubscription, _ int) []push.Result {
+	s.batches++
+	results := make([]push.Result, len(subscriptions))
+	for i, sub := range subscriptions {
+		results[i] = s.results[sub.Endpoint]
+		results[i].Subscription = sub
+	}
+	return results
+}
+
+func pushDelivery(id, messageID, attempts int, endpoint string) *models.PushDelivery {
+	d := &models.PushDelivery{ID: id, MessageID: messageID, Source: "notification", UserID: "user-1", Payload: []byte(`{}`), TTL: 60, Attempts: attempts}
+	if endpoint != "" {
+		d.Subscription = &models.PushSubscription{ID: id, UserID: "user-1", Endpoint: endpoint}
+	}
+	return d
+}
+
+func TestPushOutbox_ProcessPending(t *testing.T) {
+	const base = "https://fcm.googleapis.com/fcm/send/"
+	sender := &scriptedPushSender{enabled: true, results: map[string]push.Result{
+		base + "ok":        {StatusCode: http.StatusCreated},
+		base + "gone":      {StatusCode: http.StatusGone},
+		base + "busy":      {StatusCode: http.StatusServiceUnavailable, RetryAfter: 2 * time.Minute},
+		base + "limited":   {StatusCode: http.StatusTooManyRequests},
+		base + "network":   {Err: errors.New("dial tcp: connection refused")},
+		base + "forbidden": {StatusCode: http.StatusForbidden},
+	}}
+	deliveryRepo := new(MockPushDeliveryRepository)
+	notificationRepo := new(MockNotificationRepository)
+	outbox := handler.NewPushOutbox(deliveryRepo, notificationRepo, sender)
+
+	deliveryRepo.On("ClaimPushDeliveries", 100, 2*time.Minute).Return([]*models.PushDelivery{
+		pushDelivery(1, 1, 1, base+"ok"),
+		pushDelivery(2, 1, 1, base+"gone"),
+		pushDelivery(3, 1, 1, base+"busy"),
+		pushDelivery(4, 2, 6, base+"limited"),
+		pushDelivery(5, 2, 1, ""),
+		pushDelivery(6, 2, 2, base+"network"),
+		pushDelivery(7, 2, 1, base+"forbidden"),
+	}, nil).Once()
+	deliveryRepo.On("CompletePushDelivery", 1, models.PushDeliveryStatusSent, http.StatusCreated, "").Return(nil).Once()
+	deliveryRepo.On("CompletePushDelivery", 2, models.PushDeliveryStatusExpired, http.StatusGone, "").Return(nil).Once()
+	notificationRepo.On("DeletePushSubscription", "user-1", base+"gone").Return(nil).Once()
+	// Retry-After が指数バックオフより長ければそちらに従う
+	deliveryRepo.On("RetryPushDelivery", 3, 2*time.Minute, http.StatusServiceUnavailable, "").Return(nil).Once()
+	// 試行回数の上限に達したら失敗として確定する
+	deliveryRepo.On("CompletePushDelivery", 4, models.PushDeliveryStatusFailed, http.StatusTooManyRequests, "").Return(nil).Once()
+	deliveryRepo.On("CompletePushDelivery", 5, models.PushDeliveryStatusExpired, 0, "subscription_deleted").Return(nil).Once()
+	deliveryRepo.On("RetryPushDelivery", 6, time.Minute, 0, "*errors.errorString").Return(nil).Once()
+	deliveryRepo.On("CompletePushDelivery", 7, models.PushDeliveryStatusFailed, http.StatusForbidden, "").Return(nil).Once()
+
+	processed, err := outbox.ProcessPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 7, processed)
+	// 同じ内容の配信はまとめて送る
+	assert.Equal(t, 2, sender.batches)
+	deliveryRepo.AssertExpectations(t)
+	notificationRepo.AssertExpectations(t)
+}
+
+func TestPushOutbox_Enqueue(t *testing.T) {
+	subs := subscriptionsFor("user-1", "user-2")
+	payload := []byte(`{"title":"t"}`)
+
+	t.Run("購読ごとの配信を登録する", func(t *testing.T) {
+		deliveryRepo := new(MockPushDeliveryRepository)
+		outbox := handler.NewPushOutbox(deliveryRepo, new(MockNotificationRepository), &scriptedPushSender{enabled: true})
+		notificationID := 10
+		deliveryRepo.On("EnqueuePushMessage", &notificationID, "notification", payload, 60, subs).Return(int64(1), nil).Once()
+
+		require.NoError(t, outbox.Enqueue(&notificationID, "notification", payload, subs, 60))
+		deliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("Push通知が無効なら登録しない", func(t *testing.T) {
+		deliveryRepo := new(MockPushDeliveryRepository)
+		outbox := handler.NewPushOutbox(deliveryRepo, new(MockNotificationRepository), &scriptedPushSender{enabled: false})
+
+		require.NoError(t, outbox.Enqueue(nil, "notification", payload, subs, 60))
+		deliveryRepo.AssertNotCalled(t, "EnqueuePushMessage")
+	})
+}
+
+func TestNotificationHandler_CreateNotification_EnqueuesPushWithNotification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	notificationRepo := new(MockNotificationRepository)
+	deliveryRepo := new(MockPushDeliveryRepository)
+	eventRepo := new(MockEventRepository)
+	roleRepo := new(MockRoleRepository)
+	sender := &scriptedPushSender{enabled: true}
+	outbox := handler.NewPushOutbox(deliveryRepo, notificationRepo, sender)
+	h := handler.NewNotificationHandler(notificationRepo, eventRepo, roleRepo, new(MockUserRepository), "", "").
+		WithPushSender(sender).
+		WithPushOutbox(outbox)
+
+	subs := subscriptionsFor("user-1", "user-2")
+	roleRepo.On("GetAllRoles").Return([]models.Role{{ID: 1, Name: "student"}}, nil).Once()
+	eventRepo.On("GetActiveEvent").Return(0, nil).Once()
+	notificationRepo.On("GetUserIDsByRoles", []string{"student"}).Return([]string{"user-1", "user-2"}, nil).Once()
+	notificationRepo.On("GetPushSubscriptionsByUserIDs", []string{"user-1", "user-2"}).Return(subs, nil).Once()
+	// 配信は通知と一緒に1回で登録し、応答の前に送信キューへ入っている
+	var queued *models.PushMessage
+	notificationRepo.On("PublishNotification", mock.Anything, mock.Anything).Return(int64(10), nil).Once().
+		Run(func(args mock.Arguments) { queued = args.Get(1).(*models.PushMessage) })
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	body, _ := json.Marshal(map[string]any{"title": "お知らせ", "body": "本文です", "target_roles": []string{"student"}})
+	c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/notifications", bytes.NewBuffer(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("user", &models.User{ID: "root-1"})
+
+	h.CreateNotification(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	require.NotNil(t, queued)
+	assert.Equal(t, subs, queued.Subscriptions)
+	payload, err := queued.Payload(10)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title":"お知らせ","body":"本文です","data":{"notificationId":10}}`, string(payload))
+	deliveryRepo.AssertNotCalled(t, "EnqueuePushMessage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.Zero(t, sender.batches)
+}
+
+func TestNotificationHandler_GetPushDeliveryStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(notificationID string) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/root/notifications/"+notificationID+"/delivery-stats", nil)
+		c.Params = gin.Params{{Key: "notification_id", Value: notificationID}}
+		return c, w
+	}
+
+	t.Run("配信状況を返す", func(t *testing.T) {
+		deliveryRepo := new(MockPushDeliveryRepository)
+		outbox := handler.NewPushOutbox(deliveryRepo, new(MockNotificationRepository), &scriptedPushSender{enabled: true})
+		h := handler.NewNotificationHandler(new(MockNotificationRepository), new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "").WithPushOutbox(outbox)
+		deliveryRepo.On("GetPushDeliveryStats", 10).Return(models.PushDeliveryStats{NotificationID: 10, Total: 5, Sent: 3, Failed: 1, Expired: 1}, nil).Once()
+
+		c, w := newContext("10")
+		h.GetPushDeliveryStats(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Stats models.PushDeliveryStats `json:"stats"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, models.PushDeliveryStats{NotificationID: 10, Total: 5, Sent: 3, Failed: 1, Expired: 1}, response.Stats)
+		deliveryRepo.AssertExpectations(t)
+	})
+
+	t.Run("不正な通知IDは400", func(t *testing.T) {
+		h := handler.NewNotificationHandler(new(MockNotificationRepository), new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "")
+
+		c, w := newContext("abc")
+		h.GetPushDeliveryStats(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("送信キューがなければ503", func(t *testing.T) {
+		h := handler.NewNotificationHandler(new(MockNotificationRepository), new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "")
+
+		c, w := newContext("10")
+		h.GetPushDeliveryStats(c)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -0,0 +1,349 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backapp/internal/handler"
+	"backapp/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func rainyScenarioBody() map[string]any {
+	return map[string]any{
+		"sports": []map[string]any{
+			{"sport_id": 1, "action": "merge", "merge_into_sport_id": 2},
+			{"sport_id": 3, "action": "cancel"},
+		},
+		"apply_capacities": true,
+	}
+}
+
+func planSport(plan models.RainyPlan, sportID int) *models.RainySportPlan {
+	for _, sport := range plan.Sports {
+		if sport.SportID == sportID {
+			return sport
+		}
+	}
+	return nil
+}
+
+func TestRainyModeHandler_PreviewRainyPlanHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("中止・統合で影響を受ける試合とチームとメンバーを返す", func(t *testing.T) {
+		// サッカー(1)をバスケットボール(2)に統合し、ソフトボール(3)を中止する想定の大会
+		rainyModeRepo := new(MockRainyModeRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		teamRepo := new(MockTeamRepository)
+		eventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1}, nil)
+		sportRepo.On("GetSportsByEventID", 1).Return([]*models.EventSport{
+			{EventID: 1, SportID: 1, SportName: "サッカー", Location: "ground"},
+			{EventID: 1, SportID: 2, SportName: "バスケットボール", Location: "gym1"},
+			{EventID: 1, SportID: 3, SportName: "ソフトボール", Location: "ground"},
+			{EventID: 1, SportID: 4, SportName: "学年対抗リレー", Location: "noon_game"},
+		}, nil)
+		rainyModeRepo.On("GetPlanMatches", 1).Return([]*models.RainyPlanMatch{
+			{MatchID: 100, SportID: 1, Status: "finished", HasResult: true},
+			{MatchID: 101, SportID: 1, Status: "pending"},
+			{MatchID: 200, SportID: 2, Status: "pending"},
+			{MatchID: 300, SportID: 3, Status: "scheduled"},
+		}, nil)
+		rainyModeRepo.On("GetPlanTeams", 1).Return([]*models.Team{
+			{ID: 11, Name: "1-1", ClassID: 101, SportID: 1},
+			{ID: 12, Name: "1-2", ClassID: 102, SportID: 1},
+			{ID: 21, Name: "1-1", ClassID: 101, SportID: 2},
+			{ID: 22, Name: "1-3", ClassID: 103, SportID: 2},
+			{ID: 31, Name: "1-1", ClassID: 101, SportID: 3},
+		}, nil)
+		teamRepo.On("GetTeamMembersByTeamIDs", []int{11, 12, 21, 22, 31}).Return(map[int][]*models.User{
+			11: {{ID: "user-a"}, {ID: "user-b"}},
+			12: {{ID: "user-c"}},
+			21: {{ID: "user-a"}, {ID: "user-d"}},
+			22: {{ID: "user-f"}},
+			31: {{ID: "user-e"}},
+		}, nil)
+		rainyModeRepo.On("GetSettingsByEventID", 1).Return([]*models.RainyModeSetting{
+			{EventID: 1, SportID: 2, ClassID: 101, MaxCapacity: intPtr(2)},
+		}, nil)
+		h := handler.NewRainyModeHandler(rainyModeRepo, eventRepo).WithPlanner(sportRepo, teamRepo)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(rainyScenarioBody())
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/events/1/rainy-mode/plan", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "root-1"})
+		h.PreviewRainyPlanHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Plan models.RainyPlan `json:"plan"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		plan := response.Plan
+		assert.Empty(t, plan.Conflicts)
+
+		// 結果入力済みの試合は残し、未実施の試合だけを中止する
+		soccer := planSport(plan, 1)
+		require.NotNil(t, soccer)
+		require.Len(t, soccer.CancelledMatches, 1)
+		assert.Equal(t, 101, soccer.CancelledMatches[0].MatchID)
+		require.Len(t, soccer.PreservedMatches, 1)
+		assert.Equal(t, 100, soccer.PreservedMatches[0].MatchID)
+		require.Len(t, soccer.Teams, 2)
+		assert.Equal(t, models.RainyTeamMerged, soccer.Teams[0].Change)
+
+		softball := planSport(plan, 3)
+		require.NotNil(t, softball)
+		require.Len(t, softball.CancelledMatches, 1)
+		assert.Equal(t, models.RainyTeamCancelled, softball.Teams[0].Change)
+
+		basketball := planSport(plan, 2)
+		require.NotNil(t, basketball)
+		assert.True(t, basketball.RegenerateTournament)
+		require.Len(t, basketball.Teams, 2)
+		// 1-1 は統合で1人増え、雨天時の定員2人を1人超える
+		adjusted := basketball.Teams[0]
+		assert.Equal(t, 21, adjusted.TeamID)
+		assert.True(t, adjusted.CapacityChanged)
+		require.Len(t, adjusted.ReceivedMembers, 1)
+		assert.Equal(t, "user-b", adjusted.ReceivedMembers[0].UserID)
+		assert.Equal(t, 1, adjusted.OverCapacity)
+		// 1-2 はバスケットボールのチームがないため作る
+		created := basketball.Teams[1]
+		assert.Equal(t, models.RainyTeamCreated, created.Change)
+		assert.Equal(t, 0, created.TeamID)
+		assert.Equal(t, "1-2", created.TeamName)
+
+		assert.Nil(t, planSport(plan, 4))
+		assert.Equal(t, 3, plan.AffectedMatchCount)
+		assert.Equal(t, 5, plan.AffectedTeamCount)
+		assert.Equal(t, 5, plan.AffectedMemberCount)
+		rainyModeRepo.AssertNotCalled(t, "ApplyRainyPlan", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("中止する競技は統合先にできない", func(t *testing.T) {
+		rainyModeRepo := new(MockRainyModeRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		eventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1}, nil)
+		sportRepo.On("GetSportsByEventID", 1).Return([]*models.EventSport{
+			{EventID: 1, SportID: 1, SportName: "サッカー", Location: "ground"},
+			{EventID: 1, SportID: 3, SportName: "ソフトボール", Location: "ground"},
+		}, nil)
+		h := handler.NewRainyModeHandler(rainyModeRepo, eventRepo).WithPlanner(sportRepo, new(MockTeamRepository))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(map[string]any{
+			"sports": []map[string]any{
+				{"sport_id": 1, "action": "merge", "merge_into_sport_id": 3},
+				{"sport_id": 3, "action": "cancel"},
+			},
+		})
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/events/1/rainy-mode/plan", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "root-1"})
+		h.PreviewRainyPlanHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		rainyModeRepo.AssertNotCalled(t, "GetPlanMatches", mock.Anything)
+	})
+}
+
+func TestRainyModeHandler_ApplyRainyPlanHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("計画と組み直した対戦表を適用する", func(t *testing.T) {
+		// サッカー(1)をバスケットボール(2)に統合し、ソフトボール(3)を中止する想定の大会
+		rainyModeRepo := new(MockRainyModeRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		teamRepo := new(MockTeamRepository)
+		eventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1}, nil)
+		sportRepo.On("GetSportsByEventID", 1).Return([]*models.EventSport{
+			{EventID: 1, SportID: 1, SportName: "サッカー", Location: "ground"},
+			{EventID: 1, SportID: 2, SportName: "バスケットボール", Location: "gym1"},
+			{EventID: 1, SportID: 3, SportName: "ソフトボール", Location: "ground"},
+			{EventID: 1, SportID: 4, SportName: "学年対抗リレー", Location: "noon_game"},
+		}, nil)
+		rainyModeRepo.On("GetPlanMatches", 1).Return([]*models.RainyPlanMatch{
+			{MatchID: 100, SportID: 1, Status: "finished", HasResult: true},
+			{MatchID: 101, SportID: 1, Status: "pending"},
+			{MatchID: 200, SportID: 2, Status: "pending"},
+			{MatchID: 300, SportID: 3, Status: "scheduled"},
+		}, nil)
+		rainyModeRepo.On("GetPlanTeams", 1).Return([]*models.Team{
+			{ID: 11, Name: "1-1", ClassID: 101, SportID: 1},
+			{ID: 12, Name: "1-2", ClassID: 102, SportID: 1},
+			{ID: 21, Name: "1-1", ClassID: 101, SportID: 2},
+			{ID: 22, Name: "1-3", ClassID: 103, SportID: 2},
+			{ID: 31, Name: "1-1", ClassID: 101, SportID: 3},
+		}, nil)
+		teamRepo.On("GetTeamMembersByTeamIDs", []int{11, 12, 21, 22, 31}).Return(map[int][]*models.User{
+			11: {{ID: "user-a"}, {ID: "user-b"}},
+			12: {{ID: "user-c"}},
+			21: {{ID: "user-a"}, {ID: "user-d"}},
+			22: {{ID: "user-f"}},
+			31: {{ID: "user-e"}},
+		}, nil)
+		rainyModeRepo.On("GetSettingsByEventID", 1).Return([]*models.RainyModeSetting{
+			{EventID: 1, SportID: 2, ClassID: 101, MaxCapacity: intPtr(2)},
+		}, nil)
+		h := handler.NewRainyModeHandler(rainyModeRepo, eventRepo).WithPlanner(sportRepo, teamRepo)
+		rainyModeRepo.On("ApplyRainyPlan", 1,
+			mock.MatchedBy(func(plan *models.RainyPlan) bool {
+				return assert.ObjectsAreEqual([]int{101, 300}, plan.CancelledMatchIDs())
+			}),
+			mock.MatchedBy(func(tournaments []models.GeneratedTournament) bool {
+				if len(tournaments) != 1 || tournaments[0].SportID != 2 || len(tournaments[0].ShuffledTeams) != 3 {
+					return false
+				}
+				// 新しく作るチームは ID 0 とクラスで渡す
+				for _, team := range tournaments[0].ShuffledTeams {
+					if team.ID == 0 && team.ClassID == 102 {
+						return true
+					}
+				}
+				return false
+			}),
+			"root-1").Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(rainyScenarioBody())
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/events/1/rainy-mode/plan", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "root-1"})
+		h.ApplyRainyPlanHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		rainyModeRepo.AssertExpectations(t)
+	})
+
+	t.Run("統合先の対戦表に結果があれば新しいチームを不戦勝の枠に入れる", func(t *testing.T) {
+		// バスケットボール(2)は一回戦の1試合が終わり、1-3 が不戦勝で二回戦を待っている
+		rainyModeRepo := new(MockRainyModeRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		teamRepo := new(MockTeamRepository)
+		eventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1}, nil)
+		sportRepo.On("GetSportsByEventID", 1).Return([]*models.EventSport{
+			{EventID: 1, SportID: 1, SportName: "サッカー", Location: "ground"},
+			{EventID: 1, SportID: 2, SportName: "バスケットボール", Location: "gym1"},
+			{EventID: 1, SportID: 3, SportName: "ソフトボール", Location: "ground"},
+		}, nil)
+		rainyModeRepo.On("GetPlanMatches", 1).Return([]*models.RainyPlanMatch{
+			{MatchID: 101, SportID: 1, Status: "pending"},
+			{MatchID: 200, SportID: 2, Round: 0, Status: "finished", HasResult: true, NextMatchID: 202},
+			{MatchID: 201, SportID: 2, Round: 0, Status: "bye", Team1Name: "1-3", Team1ID: 22, NextMatchID: 202},
+			{MatchID: 202, SportID: 2, Round: 1, Status: "pending"},
+			{MatchID: 300, SportID: 3, Status: "scheduled"},
+		}, nil)
+		rainyModeRepo.On("GetPlanTeams", 1).Return([]*models.Team{
+			{ID: 11, Name: "1-1", ClassID: 101, SportID: 1},
+			{ID: 12, Name: "1-2", ClassID: 102, SportID: 1},
+			{ID: 21, Name: "1-1", ClassID: 101, SportID: 2},
+			{ID: 22, Name: "1-3", ClassID: 103, SportID: 2},
+			{ID: 31, Name: "1-1", ClassID: 101, SportID: 3},
+		}, nil)
+		teamRepo.On("GetTeamMembersByTeamIDs", []int{11, 12, 21, 22, 31}).Return(map[int][]*models.User{
+			11: {{ID: "user-a"}},
+			12: {{ID: "user-c"}},
+			21: {{ID: "user-a"}},
+			22: {{ID: "user-f"}},
+			31: {{ID: "user-e"}},
+		}, nil)
+		rainyModeRepo.On("GetSettingsByEventID", 1).Return([]*models.RainyModeSetting{}, nil)
+		h := handler.NewRainyModeHandler(rainyModeRepo, eventRepo).WithPlanner(sportRepo, teamRepo)
+		rainyModeRepo.On("ApplyRainyPlan", 1,
+			mock.MatchedBy(func(plan *models.RainyPlan) bool {
+				basketball := planSport(*plan, 2)
+				if basketball == nil || basketball.RegenerateTournament || len(basketball.ByeSeeds) != 1 {
+					return false
+				}
+				seed := basketball.ByeSeeds[0]
+				return seed.MatchID == 201 && seed.ClassID == 102 && seed.TeamName == "1-2" && seed.OpponentName == "1-3"
+			}),
+			mock.MatchedBy(func(tournaments []models.GeneratedTournament) bool {
+				return len(tournaments) == 0
+			}),
+			"root-1").Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(rainyScenarioBody())
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/events/1/rainy-mode/plan", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "root-1"})
+		h.ApplyRainyPlanHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		rainyModeRepo.AssertExpectations(t)
+	})
+
+	t.Run("統合先の対戦表に結果があれば適用しない", func(t *testing.T) {
+		// サッカー(1)をバスケットボール(2)に統合し、ソフトボール(3)を中止する想定の大会
+		rainyModeRepo := new(MockRainyModeRepository)
+		eventRepo := new(MockEventRepository)
+		sportRepo := new(MockSportRepository)
+		teamRepo := new(MockTeamRepository)
+		eventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1}, nil)
+		sportRepo.On("GetSportsByEventID", 1).Return([]*models.EventSport{
+			{EventID: 1, SportID: 1, SportName: "サッカー", Location: "ground"},
+			{EventID: 1, SportID: 2, SportName: "バスケットボール", Location: "gym1"},
+			{EventID: 1, SportID: 3, SportName: "ソフトボール", Location: "ground"},
+			{EventID: 1, SportID: 4, SportName: "学年対抗リレー", Location: "noon_game"},
+		}, nil)
+		rainyModeRepo.On("GetPlanMatches", 1).Return([]*models.RainyPlanMatch{
+			{MatchID: 100, SportID: 1, Status: "finished", HasResult: true},
+			{MatchID: 101, SportID: 1, Status: "pending"},
+			{MatchID: 200, SportID: 2, Status: "pending", HasResult: true},
+			{MatchID: 300, SportID: 3, Status: "scheduled"},
+		}, nil)
+		rainyModeRepo.On("GetPlanTeams", 1).Return([]*models.Team{
+			{ID: 11, Name: "1-1", ClassID: 101, SportID: 1},
+			{ID: 12, Name: "1-2", ClassID: 102, SportID: 1},
+			{ID: 21, Name: "1-1", ClassID: 101, SportID: 2},
+			{ID: 22, Name: "1-3", ClassID: 103, SportID: 2},
+			{ID: 31, Name: "1-1", ClassID: 101, SportID: 3},
+		}, nil)
+		teamRepo.On("GetTeamMembersByTeamIDs", []int{11, 12, 21, 22, 31}).Return(map[int][]*models.User{
+			11: {{ID: "user-a"}, {ID: "user-b"}},
+			12: {{ID: "user-c"}},
+			21: {{ID: "user-a"}, {ID: "user-d"}},
+			22: {{ID: "user-f"}},
+			31: {{ID: "user-e"}},
+		}, nil)
+		rainyModeRepo.On("GetSettingsByEventID", 1).Return([]*models.RainyModeSetting{
+			{EventID: 1, SportID: 2, ClassID: 101, MaxCapacity: intPtr(2)},
+		}, nil)
+		h := handler.NewRainyModeHandler(rainyModeRepo, eventRepo).WithPlanner(sportRepo, teamRepo)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(rainyScenarioBody())
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/root/events/1/rainy-mode/plan", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "root-1"})
+		h.ApplyRainyPlanHandler(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "バスケットボールは結果入力済みの試合があり、統合したチームを入れる不戦勝の枠が足りない")
+		rainyModeRepo.AssertNotCalled(t, "ApplyRainyPlan", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,289 @@
+package handler_test
+
+import (
+	"backapp/internal/handler"
+	"backapp/internal/models"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func scheduleTime(clock string) *time.Time {
+	t, _ := time.Parse(models.ScheduleTimeLayout, "2026-05-20 "+clock+":00")
+	return &t
+}
+
+func TestScheduleHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := &models.ScheduleConfig{
+		EventID:            1,
+		StartTime:          "2026-05-20 09:00:00",
+		DefaultSlotMinutes: 30,
+		Venues:             []models.ScheduleVenue{{Location: "gym1", Courts: []string{"A"}}},
+	}
+
+	setup := func() (*handler.ScheduleHandler, *MockScheduleRepository) {
+		scheduleRepo := new(MockScheduleRepository)
+		eventRepo := new(MockEventRepository)
+		eventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1}, nil).Maybe()
+		return handler.NewScheduleHandler(scheduleRepo, eventRepo), scheduleRepo
+	}
+
+	newContext := func(method, url string, params gin.Params, body interface{}) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = params
+		var raw []byte
+		if body != nil {
+			raw, _ = json.Marshal(body)
+		}
+		c.Request, _ = http.NewRequest(method, url, bytes.NewBuffer(raw))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "root-user"})
+		return w, c
+	}
+	eventParams := gin.Params{{Key: "id", Value: "1"}}
+
+	t.Run("不正な設定は保存しない", func(t *testing.T) {
+		h, scheduleRepo := setup()
+
+		w, c := newContext(http.MethodPut, "/api/root/events/1/schedule/config", eventParams, models.ScheduleConfig{
+			StartTime:          "9:00",
+			DefaultSlotMinutes: 30,
+		})
+		h.UpdateScheduleConfigHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		scheduleRepo.AssertNotCalled(t, "SaveConfig", mock.Anything, mock.Anything)
+	})
+
+	t.Run("設定を実行したユーザーで保存する", func(t *testing.T) {
+		h, scheduleRepo := setup()
+		scheduleRepo.On("SaveConfig", *config, "root-user").Return(nil).Once()
+
+		body := *config
+		body.EventID = 0
+		w, c := newContext(http.MethodPut, "/api/root/events/1/schedule/config", eventParams, body)
+		h.UpdateScheduleConfigHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		scheduleRepo.AssertExpectations(t)
+	})
+
+	t.Run("設定がなければ日程を組めない", func(t *testing.T) {
+		h, scheduleRepo := setup()
+		scheduleRepo.On("GetConfig", 1).Return(nil, nil).Once()
+
+		w, c := newContext(http.MethodPost, "/api/root/events/1/schedule/generate", eventParams, nil)
+		h.GenerateScheduleHandler(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("日程を組んで保存する", func(t *testing.T) {
+		h, scheduleRepo := setup()
+		scheduleRepo.On("GetConfig", 1).Return(config, nil).Once()
+		scheduleRepo.On("GetMatches", 1).Return([]models.ScheduleMatch{
+			{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym1", ClassIDs: []int{101, 102}, Status: "pending"},
+			{MatchID: 2, TournamentID: 10, SportID: 1, Location: "gym1", MatchNumberInRound: 1, ClassIDs: []int{103, 104}, Status: "pending"},
+		}, nil).Once()
+		expected := []models.ScheduleAssignment{
+			{MatchID: 1, StartTime: "2026-05-20 09:00:00", EndTime: "2026-05-20 09:30:00", Court: "A"},
+			{MatchID: 2, StartTime: "2026-05-20 09:30:00", EndTime: "2026-05-20 10:00:00", Court: "A"},
+		}
+		scheduleRepo.On("ApplyAssignments", expected, "root-user").Return(nil).Once()
+
+		w, c := newContext(http.MethodPost, "/api/root/events/1/schedule/generate", eventParams, nil)
+		h.GenerateScheduleHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var res models.ScheduleResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, expected, res.Assignments)
+		assert.Empty(t, res.Conflicts)
+		scheduleRepo.AssertExpectations(t)
+	})
+
+	t.Run("dry_run では保存しない", func(t *testing.T) {
+		h, scheduleRepo := setup()
+		scheduleRepo.On("GetConfig", 1).Return(config, nil).Once()
+		scheduleRepo.On("GetMatches", 1).Return([]models.ScheduleMatch{
+			{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym1", Status: "pending"},
+		}, nil).Once()
+
+		w, c := newContext(http.MethodPost, "/api/root/events/1/schedule/generate?dry_run=true", eventParams, nil)
+		h.GenerateScheduleHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		scheduleRepo.AssertNotCalled(t, "ApplyAssignments", mock.Anything, mock.Anything)
+	})
+
+	t.Run("衝突を返す", func(t *testing.T) {
+		h, scheduleRepo := setup()
+		scheduleRepo.On("GetConfig", 1).Return(config, nil).Once()
+		scheduleRepo.On("GetMatches", 1).Return([]models.ScheduleMatch{
+			{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym1", StartTime: scheduleTime("09:00"), Court: "A"},
+			{MatchID: 2, TournamentID: 20, SportID: 2, Location: "gym1", StartTime: scheduleTime("09:15"), Court: "A"},
+		}, nil).Once()
+
+		w, c := newContext(http.MethodGet, "/api/root/events/1/schedule/conflicts", eventParams, nil)
+		h.GetScheduleConflictsHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var res models.ScheduleResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Conflicts, 1)
+		assert.Equal(t, models.ScheduleConflictCourt, res.Conflicts[0].Type)
+	})
+
+	t.Run("延びた試合の後を組み直す", func(t *testing.T) {
+		h, scheduleRepo := setup()
+		scheduleRepo.On("GetEventIDByMatchID", 1).Return(1, nil).Once()
+		scheduleRepo.On("GetConfig", 1).Return(config, nil).Once()
+		scheduleRepo.On("GetMatches", 1).Return([]models.ScheduleMatch{
+			{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym1", Status: "scheduled", StartTime: scheduleTime("09:00"), Court: "A"},
+			{MatchID: 2, TournamentID: 10, SportID: 1, Location: "gym1", MatchNumberInRound: 1, Status: "scheduled", StartTime: scheduleTime("09:30"), Court: "A"},
+		}, nil).Once()
+		scheduleRepo.On("ApplyAssignments", []models.ScheduleAssignment{
+			{MatchID: 2, StartTime: "2026-05-20 09:40:00", EndTime: "2026-05-20 10:10:00", Court: "A"},
+		}, "root-user").Return(nil).Once()
+
+		w, c := newContext(http.MethodPost, "/api/admin/matches/1/schedule/reflow", gin.Params{{Key: "match_id", Value: "1"}}, gin.H{"delay_minutes": 10})
+		h.ReflowScheduleHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		scheduleRepo.AssertExpectations(t)
+	})
+
+	t.Run("存在しない試合は組み直せない", func(t *testing.T) {
+		h, scheduleRepo := setup()
+		scheduleRepo.On("GetEventIDByMatchID", 99).Return(0, nil).Once()
+
+		w, c := newContext(http.MethodPost, "/api/admin/matches/99/schedule/reflow", gin.Params{{Key: "match_id", Value: "99"}}, gin.H{"ends_at": "2026-05-20 10:00:00"})
+		h.ReflowScheduleHandler(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestTournamentHandler_StartTimeConflicts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	matches := []models.ScheduleMatch{
+		{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym1", ClassIDs: []int{101, 102}, StudentIDs: []string{"s1"}, StartTime: scheduleTime("09:00")},
+		{MatchID: 2, TournamentID: 20, SportID: 2, Location: "gym2", ClassIDs: []int{103, 104}, StudentIDs: []string{"s1"}, StartTime: scheduleTime("10:00")},
+	}
+
+	setup := func() (*handler.TournamentHandler, *MockTournamentRepository, *MockScheduleRepository) {
+		tournRepo := new(MockTournamentRepository)
+		scheduleRepo := new(MockScheduleRepository)
+		scheduleRepo.On("GetEventIDByMatchID", 2).Return(1, nil).Maybe()
+		scheduleRepo.On("GetConfig", 1).Return(nil, nil).Maybe()
+		scheduleRepo.On("GetMatches", 1).Return(matches, nil).Maybe()
+		h := handler.NewTournamentHandler(tournRepo, nil, nil, nil, nil, nil).WithScheduleCheck(scheduleRepo)
+		return h, tournRepo, scheduleRepo
+	}
+
+	newContext := func(url string, body interface{}) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "match_id", Value: "2"}}
+		raw, _ := json.Marshal(body)
+		c.Request, _ = http.NewRequest(http.MethodPut, url, bytes.NewBuffer(raw))
+		c.Request.Header.Set("Content-Type", "application/json")
+		return w, c
+	}
+
+	t.Run("生徒が同じ時間に2試合に出るなら保存しない", func(t *testing.T) {
+		h, tournRepo, _ := setup()
+
+		w, c := newContext("/api/admin/matches/2/start-time", gin.H{"start_time": "2026-05-20 09:15:00"})
+		h.UpdateMatchStartTimeHandler(c)
+
+		require.Equal(t, http.StatusConflict, w.Code)
+		var res struct {
+			Conflicts []models.ScheduleConflict `json:"conflicts"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Conflicts, 1)
+		assert.Equal(t, models.ScheduleConflictStudent, res.Conflicts[0].Type)
+		assert.Equal(t, []string{"s1"}, res.Conflicts[0].UserIDs)
+		tournRepo.AssertNotCalled(t, "UpdateMatchStartTime", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("force なら重複があっても保存する", func(t *testing.T) {
+		h, tournRepo, scheduleRepo := setup()
+		tournRepo.On("UpdateMatchStartTime", 2, "2026-05-20 09:15:00", "").Return(nil).Once()
+
+		w, c := newContext("/api/admin/matches/2/start-time", gin.H{"start_time": "2026-05-20 09:15:00", "force": true})
+		h.UpdateMatchStartTimeHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		scheduleRepo.AssertNotCalled(t, "GetMatches", mock.Anything)
+	})
+
+	t.Run("重複がなければ保存する", func(t *testing.T) {
+		h, tournRepo, _ := setup()
+		tournRepo.On("UpdateMatchStartTime", 2, "2026-05-20 09:30:00", "").Return(nil).Once()
+
+		w, c := newContext("/api/admin/matches/2/start-time", gin.H{"start_time": "2026-05-20 09:30:00"})
+		h.UpdateMatchStartTimeHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("雨天時の開始時刻も調べる", func(t *testing.T) {
+		h, tournRepo, _ := setup()
+
+		w, c := newContext("/api/admin/matches/2/rainy-mode-start-time", gin.H{"rainy_mode_start_time": "2026-05-20 09:00:00"})
+		h.UpdateMatchRainyModeStartTimeHandler(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		tournRepo.AssertNotCalled(t, "UpdateMatchRainyModeStartTime", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestScheduleHandler_ConflictReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	scheduleRepo := new(MockScheduleRepository)
+	eventRepo := new(MockEventRepository)
+	eventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1}, nil)
+	scheduleRepo.On("GetConfig", 1).Return(nil, nil)
+	scheduleRepo.On("GetMatches", 1).Return([]models.ScheduleMatch{
+		{MatchID: 1, TournamentID: 10, SportID: 1, Location: "gym1", ClassIDs: []int{101}, StartTime: scheduleTime("09:00"), RainyModeStartTime: scheduleTime("13:00")},
+		{MatchID: 2, TournamentID: 20, SportID: 2, Location: "gym2", ClassIDs: []int{101}, StartTime: scheduleTime("11:00"), RainyModeStartTime: scheduleTime("13:00")},
+	}, nil)
+	h := handler.NewScheduleHandler(scheduleRepo, eventRepo)
+
+	report := func(url string) models.ScheduleResult {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request, _ = http.NewRequest(http.MethodGet, url, nil)
+		h.GetScheduleConflictsHandler(c)
+		require.Equal(t, http.StatusOK, w.Code)
+		var res models.ScheduleResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return res
+	}
+
+	t.Run("日程設定がなくても調べられる", func(t *testing.T) {
+		assert.Empty(t, report("/api/root/events/1/schedule/conflicts").Conflicts)
+	})
+
+	t.Run("雨天時の日程で調べる", func(t *testing.T) {
+		res := report("/api/root/events/1/schedule/conflicts?rainy_mode=true")
+		require.Len(t, res.Conflicts, 1)
+		assert.Equal(t, models.ScheduleConflictClass, res.Conflicts[0].Type)
+	})
+}
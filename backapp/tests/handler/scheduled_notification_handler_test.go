@@ -0,0 +1,284 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backapp/internal/handler"
+	"backapp/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var scheduledNow = time.Date(2025, 5, 20, 8, 0, 0, 0, time.UTC)
+
+func newScheduledNotificationHandler() (*handler.NotificationHandler, *MockScheduledNotificationRepository, *MockNotificationRepository, *MockEventRepository, *MockRoleRepository, *handler.NotificationScheduler) {
+	scheduledRepo := new(MockScheduledNotificationRepository)
+	notificationRepo := new(MockNotificationRepository)
+	eventRepo := new(MockEventRepository)
+	roleRepo := new(MockRoleRepository)
+	scheduler := handler.NewNotificationScheduler(scheduledRepo).WithClock(func() time.Time { return scheduledNow })
+	h := handler.NewNotificationHandler(notificationRepo, eventRepo, roleRepo, new(MockUserRepository), "", "").WithScheduler(scheduler, scheduledRepo)
+	return h, scheduledRepo, notificationRepo, eventRepo, roleRepo, scheduler
+}
+
+func scheduledNotificationContext(method, path string, body any, params gin.Params) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	var reader *bytes.Reader
+	if body != nil {
+		payload, _ := json.Marshal(body)
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	c.Request, _ = http.NewRequest(method, path, reader)
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	c.Set("user", &models.User{ID: "root-1"})
+	return c, w
+}
+
+func TestNotificationHandler_CreateScheduledNotification(t *testing.T) {
+	t.Run("対象ロールを正規化して予約する", func(t *testing.T) {
+		h, scheduledRepo, _, _, roleRepo, _ := newScheduledNotificationHandler()
+		roleRepo.On("GetAllRoles").Return([]models.Role{{ID: 1, Name: "student"}, {ID: 2, Name: "admin"}}, nil).Once()
+		interval := 1440
+		scheduledRepo.On("CreateScheduledNotification", mock.MatchedBy(func(n *models.ScheduledNotification) bool {
+			return n.Title == "開会式" && n.Type == models.NotificationTypeGeneral && n.Channel == handler.NotificationChannelPush && n.CreatedBy == "root-1" &&
+				assert.ObjectsAreEqual([]string{"admin", "student"}, n.TargetRoles) &&
+				n.SendAt.Equal(scheduledNow.Add(time.Hour)) && n.RepeatIntervalMinutes != nil && *n.RepeatIntervalMinutes == interval
+		})).Return(int64(5), nil).Once()
+
+		c, w := scheduledNotificationContext(http.MethodPost, "/api/root/notifications/scheduled", map[string]any{
+			"title":                   "開会式",
+			"body":                    "15分後に開会式を始めます",
+			"target_roles":            []string{"Student", "admin"},
+			"send_at":                 scheduledNow.Add(time.Hour).Format(time.RFC3339),
+			"repeat_interval_minutes": interval,
+		}, nil)
+		h.CreateScheduledNotification(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response struct {
+			ScheduledNotification models.ScheduledNotification `json:"scheduled_notification"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 5, response.ScheduledNotification.ID)
+		assert.Equal(t, models.ScheduledNotificationStatusPending, response.ScheduledNotification.Status)
+		scheduledRepo.AssertExpectations(t)
+	})
+
+	t.Run("クラスの宛先だけで予約する", func(t *testing.T) {
+		h, scheduledRepo, _, _, roleRepo, _ := newScheduledNotificationHandler()
+		classID := 12
+		scheduledRepo.On("CreateScheduledNotification", mock.MatchedBy(func(n *models.ScheduledNotification) bool {
+			return len(n.TargetRoles) == 0 && assert.ObjectsAreEqual([]models.NotificationTarget{{Type: models.NotificationTargetClass, ID: &classID}}, n.Targets) &&
+				n.Channel == handler.NotificationChannelPushEmailFallback
+		})).Return(int64(6), nil).Once()
+
+		c, w := scheduledNotificationContext(http.MethodPost, "/api/root/notifications/scheduled", map[string]any{
+			"title":   "IS3の集合",
+			"body":    "体育館に集合してください",
+			"targets": []map[string]any{{"type": "class", "id": classID}},
+			"channel": "push_email_fallback",
+			"send_at": scheduledNow.Add(time.Hour).Format(time.RFC3339),
+		}, nil)
+		h.CreateScheduledNotification(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		scheduledRepo.AssertExpectations(t)
+		roleRepo.AssertNotCalled(t, "GetAllRoles")
+	})
+
+	t.Run("過去の送信日時は400", func(t *testing.T) {
+		h, scheduledRepo, _, _, _, _ := newScheduledNotificationHandler()
+
+		c, w := scheduledNotificationContext(http.MethodPost, "/api/root/notifications/scheduled", map[string]any{
+			"title":        "開会式",
+			"body":         "本文",
+			"target_roles": []string{"student"},
+			"send_at":      scheduledNow.Add(-time.Minute).Format(time.RFC3339),
+		}, nil)
+		h.CreateScheduledNotification(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		scheduledRepo.AssertNotCalled(t, "CreateScheduledNotification", mock.Anything)
+	})
+
+	t.Run("繰り返しの間隔なしの終了日時は400", func(t *testing.T) {
+		h, _, _, _, _, _ := newScheduledNotificationHandler()
+
+		c, w := scheduledNotificationContext(http.MethodPost, "/api/root/notifications/scheduled", map[string]any{
+			"title":        "昼休み",
+			"body":         "本文",
+			"target_roles": []string{"student"},
+			"send_at":      scheduledNow.Add(time.Hour).Format(time.RFC3339),
+			"repeat_until": scheduledNow.Add(48 * time.Hour).Format(time.RFC3339),
+		}, nil)
+		h.CreateScheduledNotification(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("スケジューラーがなければ503", func(t *testing.T) {
+		h := handler.NewNotificationHandler(new(MockNotificationRepository), new(MockEventRepository), new(MockRoleRepository), new(MockUserRepository), "", "")
+
+		c, w := scheduledNotificationContext(http.MethodPost, "/api/root/notifications/scheduled", map[string]any{}, nil)
+		h.CreateScheduledNotification(c)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestNotificationHandler_UpdateScheduledNotification(t *testing.T) {
+	params := gin.Params{{Key: "scheduled_id", Value: "5"}}
+	body := map[string]any{
+		"title":        "昼休み",
+		"body":         "昼休みは13:00までです",
+		"target_roles": []string{"student"},
+		"send_at":      scheduledNow.Add(4 * time.Hour).Format(time.RFC3339),
+	}
+
+	t.Run("送信前の予約を変更する", func(t *testing.T) {
+		h, scheduledRepo, _, _, roleRepo, _ := newScheduledNotificationHandler()
+		scheduledRepo.On("GetScheduledNotification", 5).Return(&models.ScheduledNotification{ID: 5, Status: models.ScheduledNotificationStatusPending, CreatedBy: "root-1"}, nil).Once()
+		roleRepo.On("GetAllRoles").Return([]models.Role{{ID: 1, Name: "student"}}, nil).Once()
+		scheduledRepo.On("UpdateScheduledNotification", mock.MatchedBy(func(n *models.ScheduledNotification) bool {
+			return n.ID == 5 && n.Title == "昼休み" && n.SendAt.Equal(scheduledNow.Add(4*time.Hour))
+		})).Return(true, nil).Once()
+
+		c, w := scheduledNotificationContext(http.MethodPut, "/api/root/notifications/scheduled/5", body, params)
+		h.UpdateScheduledNotification(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		scheduledRepo.AssertExpectations(t)
+	})
+
+	t.Run("送信済みの予約は409", func(t *testing.T) {
+		h, scheduledRepo, _, _, _, _ := newScheduledNotificationHandler()
+		scheduledRepo.On("GetScheduledNotification", 5).Return(&models.ScheduledNotification{ID: 5, Status: models.ScheduledNotificationStatusSent}, nil).Once()
+
+		c, w := scheduledNotificationContext(http.MethodPut, "/api/root/notifications/scheduled/5", body, params)
+		h.UpdateScheduledNotification(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		scheduledRepo.AssertNotCalled(t, "UpdateScheduledNotification", mock.Anything)
+	})
+
+	t.Run("存在しない予約は404", func(t *testing.T) {
+		h, scheduledRepo, _, _, _, _ := newScheduledNotificationHandler()
+		scheduledRepo.On("GetScheduledNotification", 5).Return(nil, nil).Once()
+
+		c, w := scheduledNotificationContext(http.MethodPut, "/api/root/notifications/scheduled/5", body, params)
+		h.UpdateScheduledNotification(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestNotificationHandler_CancelScheduledNotification(t *testing.T) {
+	params := gin.Params{{Key: "scheduled_id", Value: "5"}}
+
+	t.Run("送信前の予約を取り消す", func(t *testing.T) {
+		h, scheduledRepo, _, _, _, _ := newScheduledNotificationHandler()
+		scheduledRepo.On("GetScheduledNotification", 5).Return(&models.ScheduledNotification{ID: 5, Status: models.ScheduledNotificationStatusPending}, nil).Once()
+		scheduledRepo.On("CancelScheduledNotification", 5).Return(true, nil).Once()
+
+		c, w := scheduledNotificationContext(http.MethodDelete, "/api/root/notifications/scheduled/5", nil, params)
+		h.CancelScheduledNotification(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		scheduledRepo.AssertExpectations(t)
+	})
+
+	t.Run("確認の後に送信された予約は409", func(t *testing.T) {
+		h, scheduledRepo, _, _, _, _ := newScheduledNotificationHandler()
+		scheduledRepo.On("GetScheduledNotification", 5).Return(&models.ScheduledNotification{ID: 5, Status: models.ScheduledNotificationStatusPending}, nil).Once()
+		scheduledRepo.On("CancelScheduledNotification", 5).Return(false, nil).Once()
+
+		c, w := scheduledNotificationContext(http.MethodDelete, "/api/root/notifications/scheduled/5", nil, params)
+		h.CancelScheduledNotification(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestNotificationHandler_ListScheduledNotifications(t *testing.T) {
+	h, scheduledRepo, _, _, _, _ := newScheduledNotificationHandler()
+	scheduledRepo.On("ListScheduledNotifications", models.ScheduledNotificationStatusPending).Return([]*models.ScheduledNotification{
+		{ID: 5, Title: "開会式", Status: models.ScheduledNotificationStatusPending},
+	}, nil).Once()
+
+	c, w := scheduledNotificationContext(http.MethodGet, "/api/root/notifications/scheduled?status=pending", nil, nil)
+	h.ListScheduledNotifications(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		ScheduledNotifications []models.ScheduledNotification `json:"scheduled_notifications"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.ScheduledNotifications, 1)
+	assert.Equal(t, "開会式", response.ScheduledNotifications[0].Title)
+
+	c, w = scheduledNotificationContext(http.MethodGet, "/api/root/notifications/scheduled?status=unknown", nil, nil)
+	h.ListScheduledNotifications(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestNotificationScheduler_DispatchDue(t *testing.T) {
+	teamID := 8
+	newDue := func() *models.ScheduledNotification {
+		return &models.ScheduledNotification{
+			ID:          5,
+			Title:       "開会式",
+			Body:        "15分後に開会式を始めます",
+			Type:        models.NotificationTypeGeneral,
+			TargetRoles: []string{"student"},
+			Targets:     []models.NotificationTarget{{Type: models.NotificationTargetTeam, ID: &teamID}},
+			SendAt:      scheduledNow.Add(-time.Minute),
+			Status:      models.ScheduledNotificationStatusSending,
+			CreatedBy:   "root-1",
+		}
+	}
+
+	t.Run("宛先付きで通知を作成してから送信済みにする", func(t *testing.T) {
+		_, scheduledRepo, notificationRepo, eventRepo, _, scheduler := newScheduledNotificationHandler()
+		scheduledRepo.On("ClaimDueScheduledNotifications", scheduledNow, 30*time.Minute, 5*time.Minute).Return([]*models.ScheduledNotification{newDue()}, nil).Once()
+		eventRepo.On("GetActiveEvent").Return(3, nil).Once()
+		eventID := 3
+		notificationRepo.On("PublishNotification", models.NewNotification{
+			Title: "開会式", Body: "15分後に開会式を始めます", Type: "general", CreatedBy: "root-1", EventID: &eventID, TargetRoles: []string{"student"},
+			Targets: []models.NotificationTarget{{Type: models.NotificationTargetTeam, ID: &teamID}},
+		}, (*models.PushMessage)(nil)).Return(int64(42), nil).Once()
+		scheduledRepo.On("CompleteScheduledNotification", mock.MatchedBy(func(next *models.ScheduledNotification) bool {
+			return next.ID == 5 && next.Status == models.ScheduledNotificationStatusSent
+		}), 42).Return(nil).Once()
+
+		require.NoError(t, scheduler.DispatchDue())
+		scheduledRepo.AssertExpectations(t)
+		notificationRepo.AssertExpectations(t)
+		eventRepo.AssertExpectations(t)
+	})
+
+	t.Run("送れなかった予約は送信前に戻す", func(t *testing.T) {
+		_, scheduledRepo, notificationRepo, eventRepo, _, scheduler := newScheduledNotificationHandler()
+		scheduledRepo.On("ClaimDueScheduledNotifications", scheduledNow, 30*time.Minute, 5*time.Minute).Return([]*models.ScheduledNotification{newDue()}, nil).Once()
+		eventRepo.On("GetActiveEvent").Return(0, errors.New("db down")).Once()
+		scheduledRepo.On("ReleaseScheduledNotification", 5).Return(nil).Once()
+
+		require.NoError(t, scheduler.DispatchDue())
+		scheduledRepo.AssertExpectations(t)
+		scheduledRepo.AssertNotCalled(t, "CompleteScheduledNotification", mock.Anything, mock.Anything)
+		notificationRepo.AssertNotCalled(t, "PublishNotification", mock.Anything, mock.Anything)
+	})
+}
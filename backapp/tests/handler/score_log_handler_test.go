@@ -0,0 +1,115 @@
+package handler_test
+
+import (
+	"backapp/internal/handler"
+	"backapp/internal/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreLogHandler_GetClassScoreLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	eventID := 1
+	setup := func() (*handler.ScoreLogHandler, *MockScoreLogRepository, *MockClassRepository) {
+		logRepo := new(MockScoreLogRepository)
+		classRepo := new(MockClassRepository)
+		return handler.NewScoreLogHandler(logRepo, classRepo), logRepo, classRepo
+	}
+
+	newContext := func(classID, query string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "1"}, {Key: "class_id", Value: classID}}
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/root/events/1/classes/"+classID+"/score-logs?"+query, nil)
+		return w, c
+	}
+
+	t.Run("打ち消しを含む履歴と合計点を返す", func(t *testing.T) {
+		h, logRepo, classRepo := setup()
+		classRepo.On("GetClassByID", 10).Return(&models.Class{ID: 10, EventID: &eventID}, nil).Once()
+		reversed := 2
+		original := 1
+		logRepo.On("GetClassScoreLogs", 1, 10, models.ScoreLogFilter{}).Return([]*models.ScoreLogEntry{
+			{ID: 1, Points: 10, Reason: "attendance_points", Operation: models.ScoreOperationAttendance, ReversedByLogID: &reversed},
+			{ID: 2, Points: -10, Reason: "attendance_points", Operation: models.ScoreOperationAttendance, ReversesLogID: &original},
+			{ID: 3, Points: 9, Reason: "attendance_points", Operation: models.ScoreOperationAttendance},
+		}, nil).Once()
+
+		w, c := newContext("10", "")
+		h.GetClassScoreLogsHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var res models.ClassScoreHistory
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, 9, res.Total)
+		assert.Len(t, res.Logs, 3)
+		logRepo.AssertExpectations(t)
+	})
+
+	t.Run("クエリで絞り込み条件を渡す", func(t *testing.T) {
+		h, logRepo, classRepo := setup()
+		classRepo.On("GetClassByID", 10).Return(&models.Class{ID: 10, EventID: &eventID}, nil).Once()
+		from := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+		logRepo.On("GetClassScoreLogs", 1, 10, models.ScoreLogFilter{
+			Reason:        "gym1_win1_points",
+			Operation:     models.ScoreOperationMatchCorrection,
+			SourceMatchID: 5,
+			From:          &from,
+		}).Return([]*models.ScoreLogEntry{}, nil).Once()
+
+		w, c := newContext("10", "reason=gym1_win1_points&operation=match_correction&match_id=5&from=2026-06-01T09:00:00%2B09:00")
+		h.GetClassScoreLogsHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		logRepo.AssertExpectations(t)
+	})
+
+	t.Run("不正なoperationは400", func(t *testing.T) {
+		h, _, _ := setup()
+
+		w, c := newContext("10", "operation=unknown")
+		h.GetClassScoreLogsHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("不正な日時は400", func(t *testing.T) {
+		h, _, _ := setup()
+
+		w, c := newContext("10", "to=2026-06-01")
+		h.GetClassScoreLogsHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("別イベントのクラスは404", func(t *testing.T) {
+		h, _, classRepo := setup()
+		otherEventID := 2
+		classRepo.On("GetClassByID", 20).Return(&models.Class{ID: 20, EventID: &otherEventID}, nil).Once()
+
+		w, c := newContext("20", "")
+		h.GetClassScoreLogsHandler(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("取得エラーは500", func(t *testing.T) {
+		h, logRepo, classRepo := setup()
+		classRepo.On("GetClassByID", 10).Return(&models.Class{ID: 10, EventID: &eventID}, nil).Once()
+		logRepo.On("GetClassScoreLogs", 1, 10, models.ScoreLogFilter{}).Return(nil, errors.New("db error")).Once()
+
+		w, c := newContext("10", "")
+		h.GetClassScoreLogsHandler(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
@@ -0,0 +1,237 @@
+package handler_test
+
+import (
+	"backapp/internal/handler"
+	"backapp/internal/models"
+	"backapp/internal/scoreboard"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// streamRecorder は配信中のレスポンスを別のゴルーチンから読めるようにした ResponseRecorder
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+	mu sync.Mutex
+}
+
+func (r *streamRecorder) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ResponseRecorder.Write(b)
+}
+
+func (r *streamRecorder) WriteString(s string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ResponseRecorder.WriteString(s)
+}
+
+func (r *streamRecorder) body() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ResponseRecorder.Body.String()
+}
+
+func scoreboardTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestScoreboardHandler_Stream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type deps struct {
+		boardRepo *MockScoreboardRepository
+		eventRepo *MockEventRepository
+		classRepo *MockClassRepository
+		tournRepo *MockTournamentRepository
+		feed      *scoreboard.Feed
+	}
+	setup := func() (*handler.ScoreboardHandler, deps) {
+		d := deps{
+			boardRepo: new(MockScoreboardRepository),
+			eventRepo: new(MockEventRepository),
+			classRepo: new(MockClassRepository),
+			tournRepo: new(MockTournamentRepository),
+		}
+		d.feed = scoreboard.NewFeed(d.eventRepo, d.classRepo, d.tournRepo, d.boardRepo)
+		return handler.NewScoreboardHandler(d.boardRepo, d.eventRepo, d.feed), d
+	}
+
+	newContext := func(w http.ResponseWriter, ctx context.Context, token string) *gin.Context {
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/scoreboard/events/1/stream?token="+token, nil).WithContext(ctx)
+		return c
+	}
+
+	t.Run("トークン未発行なら404", func(t *testing.T) {
+		h, d := setup()
+		d.boardRepo.On("GetTokenHash", 1).Return("", nil).Once()
+
+		w := httptest.NewRecorder()
+		h.StreamScoreboardHandler(newContext(w, context.Background(), "anything"))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("トークンが違えば401", func(t *testing.T) {
+		h, d := setup()
+		d.boardRepo.On("GetTokenHash", 1).Return(scoreboardTokenHash("secret"), nil).Once()
+
+		w := httptest.NewRecorder()
+		h.StreamScoreboardHandler(newContext(w, context.Background(), "wrong"))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("接続時にスナップショットを送り、その後は変わったクラスだけを送る", func(t *testing.T) {
+		h, d := setup()
+		d.boardRepo.On("GetTokenHash", 1).Return(scoreboardTokenHash("secret"), nil).Once()
+		d.eventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1, Name: "春季", HideScores: false}, nil)
+		d.tournRepo.On("GetTournamentsByEventID", 1).Return([]*models.Tournament{{ID: 7, Name: "バスケ", EventID: 1}}, nil).Once()
+		d.classRepo.On("GetClassScoresByEvent", 1).Return([]*models.ClassScore{
+			{ClassID: 101, ClassName: "1-1", TotalPointsCurrentEvent: 10, RankCurrentEvent: 1},
+			{ClassID: 102, ClassName: "1-2", TotalPointsCurrentEvent: 5, RankCurrentEvent: 2},
+		}, nil).Once()
+
+		w := &streamRecorder{ResponseRecorder: httptest.NewRecorder()}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			h.StreamScoreboardHandler(newContext(w, ctx, "secret"))
+		}()
+
+		require.Eventually(t, func() bool { return strings.Contains(w.body(), "event: snapshot") }, time.Second, 10*time.Millisecond)
+
+		d.classRepo.On("GetClassScoresByEvent", 1).Return([]*models.ClassScore{
+			{ClassID: 101, ClassName: "1-1", TotalPointsCurrentEvent: 10, RankCurrentEvent: 2},
+			{ClassID: 102, ClassName: "1-2", TotalPointsCurrentEvent: 20, RankCurrentEvent: 1},
+		}, nil).Once()
+		d.feed.ClassScoresChanged(1)
+
+		require.Eventually(t, func() bool { return strings.Contains(w.body(), "event: class_scores") }, time.Second, 10*time.Millisecond)
+		cancel()
+		<-done
+
+		events := strings.Split(strings.TrimSpace(w.body()), "\n\n")
+		require.Len(t, events, 2)
+		assert.True(t, strings.HasPrefix(events[0], "id: 0\nevent: snapshot\ndata: "))
+
+		var snapshot models.ScoreboardSnapshot
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(events[0], "id: 0\nevent: snapshot\ndata: ")), &snapshot))
+		assert.Equal(t, "春季", snapshot.EventName)
+		assert.Len(t, snapshot.ClassScores, 2)
+		assert.Len(t, snapshot.Tournaments, 1)
+
+		assert.True(t, strings.HasPrefix(events[1], "id: 1\nevent: class_scores\ndata: "))
+		var changed []models.ScoreboardClassScore
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(events[1], "id: 1\nevent: class_scores\ndata: ")), &changed))
+		assert.Len(t, changed, 2)
+	})
+
+	t.Run("得点が非表示ならクラス得点を送らない", func(t *testing.T) {
+		h, d := setup()
+		d.boardRepo.On("GetTokenHash", 1).Return(scoreboardTokenHash("secret"), nil).Once()
+		d.eventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1, HideScores: true}, nil)
+		d.tournRepo.On("GetTournamentsByEventID", 1).Return([]*models.Tournament{}, nil).Once()
+		d.boardRepo.On("GetMatch", 5).Return(&models.ScoreboardMatch{EventID: 1, TournamentID: 7, MatchID: 5, Status: "finished"}, nil).Once()
+
+		w := &streamRecorder{ResponseRecorder: httptest.NewRecorder()}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			h.StreamScoreboardHandler(newContext(w, ctx, "secret"))
+		}()
+
+		require.Eventually(t, func() bool { return strings.Contains(w.body(), "event: snapshot") }, time.Second, 10*time.Millisecond)
+		d.feed.NoonGamePointsChanged(1, map[int]int{101: 30})
+		d.feed.MatchUpdated(5)
+
+		require.Eventually(t, func() bool { return strings.Contains(w.body(), "event: match_result") }, time.Second, 10*time.Millisecond)
+		cancel()
+		<-done
+
+		body := w.body()
+		assert.Contains(t, body, `"scores_hidden":true`)
+		assert.NotContains(t, body, "event: noon_game_points")
+		assert.NotContains(t, body, "event: class_scores")
+		d.classRepo.AssertNotCalled(t, "GetClassScoresByEvent", mock.Anything)
+	})
+}
+
+func TestScoreboardHandler_Token(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func() (*handler.ScoreboardHandler, *MockScoreboardRepository, *MockEventRepository) {
+		boardRepo := new(MockScoreboardRepository)
+		eventRepo := new(MockEventRepository)
+		feed := scoreboard.NewFeed(eventRepo, new(MockClassRepository), new(MockTournamentRepository), boardRepo)
+		return handler.NewScoreboardHandler(boardRepo, eventRepo, feed), boardRepo, eventRepo
+	}
+
+	newContext := func(method string) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request = httptest.NewRequest(method, "/api/root/events/1/scoreboard-token", nil)
+		c.Set("user", &models.User{ID: "root-user"})
+		return w, c
+	}
+
+	t.Run("発行したトークンのハッシュだけを保存する", func(t *testing.T) {
+		h, boardRepo, eventRepo := setup()
+		eventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1}, nil).Once()
+		var savedHash string
+		boardRepo.On("SetTokenHash", 1, mock.AnythingOfType("string"), "root-user").
+			Run(func(args mock.Arguments) { savedHash = args.String(1) }).
+			Return(nil).Once()
+
+		w, c := newContext(http.MethodPost)
+		h.IssueScoreboardTokenHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var res models.ScoreboardToken
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Len(t, res.Token, 64)
+		assert.Equal(t, scoreboardTokenHash(res.Token), savedHash)
+		assert.Equal(t, "/api/scoreboard/events/1/stream?token="+res.Token, res.StreamPath)
+	})
+
+	t.Run("存在しないイベントは404", func(t *testing.T) {
+		h, _, eventRepo := setup()
+		eventRepo.On("GetEventByID", 1).Return(nil, nil).Once()
+
+		w, c := newContext(http.MethodPost)
+		h.IssueScoreboardTokenHandler(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("失効させる", func(t *testing.T) {
+		h, boardRepo, eventRepo := setup()
+		eventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1}, nil).Once()
+		boardRepo.On("DeleteToken", 1).Return(nil).Once()
+
+		w, c := newContext(http.MethodDelete)
+		h.RevokeScoreboardTokenHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		boardRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,133 @@
+package handler_test
+
+import (
+	"backapp/internal/handler"
+	"backapp/internal/models"
+	"backapp/internal/repository"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoringRuleHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func() (*handler.ScoringRuleHandler, *MockScoringRuleRepository, *MockTournamentRepository, *MockEventRepository) {
+		ruleRepo := new(MockScoringRuleRepository)
+		tournRepo := new(MockTournamentRepository)
+		eventRepo := new(MockEventRepository)
+		eventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1}, nil).Maybe()
+		return handler.NewScoringRuleHandler(ruleRepo, tournRepo, eventRepo), ruleRepo, tournRepo, eventRepo
+	}
+
+	newContext := func(method string, body []byte) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Request, _ = http.NewRequest(method, "/api/root/events/1/scoring-rules", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		return w, c
+	}
+
+	t.Run("ルール未保存なら従来の配点をバージョン0で返す", func(t *testing.T) {
+		h, ruleRepo, _, _ := setup()
+		ruleRepo.On("GetLatestRuleSet", 1).Return(nil, nil).Once()
+
+		w, c := newContext(http.MethodGet, nil)
+		h.GetScoringRulesHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var res struct {
+			Version int                 `json:"version"`
+			Rules   models.ScoringRules `json:"rules"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, 0, res.Version)
+		assert.Equal(t, models.DefaultScoringRules(), res.Rules)
+	})
+
+	t.Run("更新は実行したユーザーで新しいバージョンとして保存する", func(t *testing.T) {
+		h, ruleRepo, _, _ := setup()
+		rules := models.DefaultScoringRules()
+		rules.ChampionPoints = 100
+		ruleRepo.On("CreateRuleSet", 1, rules, "root-user").Return(&models.ScoringRuleSet{ID: 5, EventID: 1, Version: 2, Rules: rules}, nil).Once()
+
+		body, _ := json.Marshal(rules)
+		w, c := newContext(http.MethodPut, body)
+		c.Set("user", &models.User{ID: "root-user"})
+		h.UpdateScoringRulesHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		ruleRepo.AssertExpectations(t)
+	})
+
+	t.Run("不正なルールは400", func(t *testing.T) {
+		h, ruleRepo, _, _ := setup()
+		rules := models.DefaultScoringRules()
+		rules.Attendance.Tiers = []models.RateTier{{MinRate: 0.5, Points: 6}, {MinRate: 0.9, Points: 10}}
+
+		body, _ := json.Marshal(rules)
+		w, c := newContext(http.MethodPut, body)
+		h.UpdateScoringRulesHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		ruleRepo.AssertNotCalled(t, "CreateRuleSet", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("再計算は付け直した試合数とクラス数を返す", func(t *testing.T) {
+		h, _, tournRepo, _ := setup()
+		tournRepo.On("RecalculateScores", 1, mock.Anything).
+			Return(&models.ScoreRecalculationResult{EventID: 1, ReplayedMatches: 12, AttendanceClasses: 6, SurveyClasses: 6, MICClasses: 3}, nil).Once()
+
+		w, c := newContext(http.MethodPost, nil)
+		h.RecalculateScoresHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var res models.ScoreRecalculationResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, 12, res.ReplayedMatches)
+		assert.Equal(t, 6, res.AttendanceClasses)
+		assert.Equal(t, 6, res.SurveyClasses)
+		assert.Equal(t, 3, res.MICClasses)
+	})
+
+	t.Run("アンケートの提出数が残っていなければ409", func(t *testing.T) {
+		h, _, tournRepo, _ := setup()
+		tournRepo.On("RecalculateScores", 1, mock.Anything).Return(nil, repository.ErrSurveySubmissionsMissing).Once()
+
+		w, c := newContext(http.MethodPost, nil)
+		h.RecalculateScoresHandler(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("再計算に失敗したら500", func(t *testing.T) {
+		h, _, tournRepo, _ := setup()
+		tournRepo.On("RecalculateScores", 1, mock.Anything).Return(nil, errors.New("db error")).Once()
+
+		w, c := newContext(http.MethodPost, nil)
+		h.RecalculateScoresHandler(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("存在しないイベントは404", func(t *testing.T) {
+		ruleRepo := new(MockScoringRuleRepository)
+		eventRepo := new(MockEventRepository)
+		eventRepo.On("GetEventByID", 1).Return(nil, nil).Once()
+		h := handler.NewScoringRuleHandler(ruleRepo, new(MockTournamentRepository), eventRepo)
+
+		w, c := newContext(http.MethodGet, nil)
+		h.GetScoringRulesHandler(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,211 @@
+package handler_test
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backapp/internal/handler"
+	"backapp/internal/identity"
+	"backapp/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newSignedPassParser(t *testing.T, signer *identity.PassSigner) *identity.Parser {
+	parser, err := identity.NewParser(identity.Config{PassSigner: signer})
+	require.NoError(t, err)
+	return parser
+}
+
+func TestIdentityHandler_GetMySignedPassHandler(t *testing.T) {
+	signer := identity.NewPassSigner([]byte("secret"), 3*time.Minute)
+
+	t.Run("開催中の大会のパスを返す", func(t *testing.T) {
+		eventRepo := new(MockEventRepository)
+		h := handler.NewIdentityHandler(new(MockIdentityRepository), new(MockUserRepository)).WithPassSigner(signer, eventRepo)
+		eventRepo.On("GetActiveEvent").Return(4, nil).Once()
+
+		c, w := identityContext(http.MethodGet, "/api/user/pass", nil, "")
+		h.GetMySignedPassHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		var response struct {
+			Payload         string    `json:"payload"`
+			EventID         int       `json:"event_id"`
+			ExpiresAt       time.Time `json:"expires_at"`
+			RefreshAt       time.Time `json:"refresh_at"`
+			RotationSeconds int       `json:"rotation_seconds"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 4, response.EventID)
+		assert.Equal(t, 180, response.RotationSeconds)
+		assert.True(t, response.RefreshAt.Before(response.ExpiresAt))
+
+		userID, eventID, err := signer.Verify(response.Payload)
+		require.NoError(t, err)
+		assert.Equal(t, "admin-1", userID)
+		assert.Equal(t, 4, eventID)
+	})
+
+	t.Run("QR コードの PNG を返す", func(t *testing.T) {
+		eventRepo := new(MockEventRepository)
+		h := handler.NewIdentityHandler(new(MockIdentityRepository), new(MockUserRepository)).WithPassSigner(signer, eventRepo)
+		eventRepo.On("GetActiveEvent").Return(4, nil).Once()
+
+		c, w := identityContext(http.MethodGet, "/api/user/pass/qr", nil, "")
+		h.GetMySignedPassQRCodeHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		assert.NotEmpty(t, w.Header().Get("X-Pass-Refresh-At"))
+		_, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+		assert.NoError(t, err)
+	})
+
+	t.Run("開催中の大会がなければ404", func(t *testing.T) {
+		eventRepo := new(MockEventRepository)
+		h := handler.NewIdentityHandler(new(MockIdentityRepository), new(MockUserRepository)).WithPassSigner(signer, eventRepo)
+		eventRepo.On("GetActiveEvent").Return(0, nil).Once()
+
+		c, w := identityContext(http.MethodGet, "/api/user/pass", nil, "")
+		h.GetMySignedPassHandler(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestBarcodeHandler_CheckInRoundHandler_SignedPass(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer := identity.NewPassSigner([]byte("secret"), 2*time.Minute)
+
+	newHandler := func(parser *identity.Parser) (*handler.BarcodeHandler, *MockTeamRepository, *MockUserRepository, *MockTournamentRepository) {
+		teamRepo := new(MockTeamRepository)
+		userRepo := new(MockUserRepository)
+		tournamentRepo := new(MockTournamentRepository)
+		h := handler.NewBarcodeHandler(teamRepo, new(MockSportRepository), userRepo, new(MockEventRepository), new(MockClassRepository), tournamentRepo).
+			WithIdentity(parser, new(MockIdentityRepository))
+		return h, teamRepo, userRepo, tournamentRepo
+	}
+
+	request := func(h *handler.BarcodeHandler, barcode string) (*httptest.ResponseRecorder, map[string]any) {
+		body, _ := json.Marshal(models.BarcodeCheckInRequest{BarcodeData: barcode, EventID: 1, SportID: 2, MatchID: 100})
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/barcode/check-in", bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		h.CheckInRoundHandler(c)
+
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	t.Run("署名付きパスのユーザーをチェックインする", func(t *testing.T) {
+		h, teamRepo, userRepo, tournamentRepo := newHandler(newSignedPassParser(t, signer))
+		user := &models.User{ID: "user-1", Email: "s2301059@example.com"}
+		userRepo.On("GetUserWithRoles", "user-1").Return(user, nil).Once()
+		teamRepo.On("GetTeamsByUserID", "user-1").Return([]*models.TeamWithSport{{ID: 10, ClassID: 1, EventID: 1, SportID: 2, SportName: "バスケットボール"}}, nil).Once()
+		tournamentRepo.On("GetMatchForEventSport", 100, 1, 2).Return(&models.MatchDB{ID: 100, Round: 0, Team1ID: sql.NullInt64{Int64: 10, Valid: true}}, nil).Once()
+		teamRepo.On("GetTeamByClassAndSport", 1, 2, 1).Return(&models.Team{ID: 10, ClassID: 1, EventID: 1, SportID: 2}, nil).Once()
+		teamRepo.On("ConfirmTeamMember", 10, "user-1").Return(nil).Once()
+		teamRepo.On("CheckInRound", 10, "user-1", 1, 2, 100, 1).Return(nil).Once()
+
+		w, response := request(h, signer.Issue("user-1", 1).Payload)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, true, response["checked_in"])
+		assert.Equal(t, identity.FormatSignedPass, response["identified_by"])
+		userRepo.AssertNotCalled(t, "FindUsers", mock.Anything, mock.Anything)
+		teamRepo.AssertExpectations(t)
+	})
+
+	t.Run("別の大会のパスは400", func(t *testing.T) {
+		h, teamRepo, userRepo, _ := newHandler(newSignedPassParser(t, signer))
+
+		w, response := request(h, signer.Issue("user-1", 2).Payload)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "この大会のQRコードではありません", response["error"])
+		userRepo.AssertNotCalled(t, "GetUserWithRoles", mock.Anything)
+		teamRepo.AssertNotCalled(t, "CheckInRound", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("有効期限切れのパスは400", func(t *testing.T) {
+		issuedAt := time.Now().Add(-10 * time.Minute)
+		old := identity.NewPassSigner([]byte("secret"), 2*time.Minute).WithClock(func() time.Time { return issuedAt })
+		h, _, userRepo, _ := newHandler(newSignedPassParser(t, signer))
+
+		w, response := request(h, old.Issue("user-1", 1).Payload)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, response["error"], "有効期限")
+		userRepo.AssertNotCalled(t, "GetUserWithRoles", mock.Anything)
+	})
+}
+
+func TestAttendanceHandler_CheckInHandler_SignedPass(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	eventID := 1
+	classID := 5
+	signer := identity.NewPassSigner([]byte("secret"), 2*time.Minute)
+
+	t.Run("署名付きパスの生徒を記録する", func(t *testing.T) {
+		classRepo := new(MockClassRepository)
+		eventRepo := new(MockEventRepository)
+		checkInRepo := new(MockCheckInRepository)
+		userRepo := new(MockUserRepository)
+		h := handler.NewAttendanceHandler(classRepo, eventRepo).WithCheckIns(checkInRepo, userRepo)
+		h.WithIdentity(newSignedPassParser(t, signer), new(MockIdentityRepository))
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+		userRepo.On("GetUserWithRoles", "student-1").Return(&models.User{ID: "student-1", Email: "s2301059@example.com", ClassID: &classID}, nil).Once()
+		classRepo.On("GetClassByID", classID).Return(&models.Class{ID: classID, EventID: &eventID, Name: "IS3", StudentCount: 40}, nil).Once()
+		checkInRepo.On("CheckIn", eventID, "student-1", models.CheckInPurposeEventParticipation, "admin-1").
+			Return(&models.CheckIn{ID: 1, UserID: "student-1", EventID: eventID}, true, nil).Once()
+		checkInRepo.On("CountClassCheckIns", eventID, classID).Return(1, nil).Once()
+		classRepo.On("UpdateAttendance", classID, eventID, 1, "admin-1").Return(1, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(map[string]string{"barcode_data": signer.Issue("student-1", eventID).Payload})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/admin/attendance/check-in", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInHandler(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, identity.FormatSignedPass, response["identified_by"])
+		assert.Equal(t, "IS3", response["class_name"])
+	})
+
+	t.Run("署名が違うパスは400", func(t *testing.T) {
+		eventRepo := new(MockEventRepository)
+		checkInRepo := new(MockCheckInRepository)
+		h := handler.NewAttendanceHandler(new(MockClassRepository), eventRepo).WithCheckIns(checkInRepo, new(MockUserRepository))
+		h.WithIdentity(newSignedPassParser(t, signer), new(MockIdentityRepository))
+		eventRepo.On("GetActiveEvent").Return(eventID, nil).Once()
+		forged := identity.NewPassSigner([]byte("forged"), 2*time.Minute)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		payload, _ := json.Marshal(map[string]string{"barcode_data": forged.Issue("student-1", eventID).Payload})
+		c.Request, _ = http.NewRequest(http.MethodPost, "/api/admin/attendance/check-in", bytes.NewBuffer(payload))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user", &models.User{ID: "admin-1", Roles: []models.Role{{Name: "admin"}}})
+		h.CheckInHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		checkInRepo.AssertNotCalled(t, "CheckIn", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
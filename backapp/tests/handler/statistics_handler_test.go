@@ -311,6 +311,50 @@ func TestStatisticsHandler_GetRealtimeEventProgress(t *testing.T) {
 		mockEventRepo.AssertExpectations(t)
 		mockTournRepo.AssertExpectations(t)
 	})
+	t.Run("day_id を指定するとその開催日に試合がある競技だけ返す", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+		mockTournRepo := new(MockTournamentRepository)
+		mockDayRepo := new(MockEventDayRepository)
+
+		h := handler.NewStatisticsHandler(new(MockClassRepository), mockEventRepo, new(MockSportRepository), mockTournRepo).WithEventDays(mockDayRepo)
+
+		mockEventRepo.On("GetActiveEvent").Return(1, nil).Once()
+		mockDayRepo.On("GetDayByID", 2).Return(&models.EventDay{ID: 2, EventID: 1, DayNumber: 2}, nil).Once()
+		mockDayRepo.On("GetSportNamesByDay", 2).Return([]string{"サッカー"}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/admin/statistics/progress?day_id=2", nil)
+
+		h.GetRealtimeEventProgress(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]string
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, map[string]string{"サッカー": "進行中"}, resp)
+		mockTournRepo.AssertNotCalled(t, "GetTournamentSportNamesByEventID", mock.Anything)
+		mockDayRepo.AssertExpectations(t)
+	})
+
+	t.Run("別の大会の開催日を指定すると404を返す", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+		mockDayRepo := new(MockEventDayRepository)
+
+		h := handler.NewStatisticsHandler(new(MockClassRepository), mockEventRepo, new(MockSportRepository), new(MockTournamentRepository)).WithEventDays(mockDayRepo)
+
+		mockEventRepo.On("GetActiveEvent").Return(1, nil).Once()
+		mockDayRepo.On("GetDayByID", 9).Return(&models.EventDay{ID: 9, EventID: 3}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/admin/statistics/progress?day_id=9", nil)
+
+		h.GetRealtimeEventProgress(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockDayRepo.AssertNotCalled(t, "GetSportNamesByDay", mock.Anything)
+	})
 }
 
 func TestStatisticsHandler_GetOverallAttendanceRate(t *testing.T) {
@@ -372,4 +416,35 @@ func TestStatisticsHandler_GetOverallAttendanceRate(t *testing.T) {
 		assert.Equal(t, 0.0, resp["attendance_rate"])
 		mockEventRepo.AssertExpectations(t)
 	})
+	t.Run("day_id を指定するとその開催日のチェックイン人数で計算する", func(t *testing.T) {
+		mockEventRepo := new(MockEventRepository)
+		mockClassRepo := new(MockClassRepository)
+		mockDayRepo := new(MockEventDayRepository)
+
+		h := handler.NewStatisticsHandler(mockClassRepo, mockEventRepo, new(MockSportRepository), new(MockTournamentRepository)).WithEventDays(mockDayRepo)
+
+		classes := []*models.Class{
+			{ID: 1, Name: "1-A", AttendCount: 30, StudentCount: 40},
+			{ID: 2, Name: "1-B", AttendCount: 20, StudentCount: 40},
+		}
+
+		mockEventRepo.On("GetActiveEvent").Return(1, nil).Once()
+		mockDayRepo.On("GetDayByID", 2).Return(&models.EventDay{ID: 2, EventID: 1, DayNumber: 2}, nil).Once()
+		mockClassRepo.On("GetAllClasses", 1).Return(classes, nil).Once()
+		mockDayRepo.On("CountCheckedInUsers", 2).Return(60, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/api/admin/statistics/attendance?day_id=2", nil)
+
+		h.GetOverallAttendanceRate(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		// 60 / (40+40) * 100 = 75
+		assert.InDelta(t, 75.0, resp["attendance_rate"], 0.001)
+		mockDayRepo.AssertExpectations(t)
+	})
 }
@@ -8,7 +8,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -115,3 +117,116 @@ func TestTournamentHandler_ExportTournamentsExcelHandler(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, w.Code)
 	})
 }
+
+func TestTournamentHandler_ExportTournamentsPDFHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tournamentData, err := json.Marshal(models.TournamentData{
+		Rounds: []models.Round{{Name: "準決勝"}, {Name: "決勝"}},
+		Matches: []models.Match{
+			{ID: 10, RoundIndex: 0, Order: 0, Sides: []models.Side{{Title: "1A", Scores: []models.Score{{MainScore: 3}}, IsWinner: true}, {Title: "2B", Scores: []models.Score{{MainScore: 1}}}}},
+			{ID: 11, RoundIndex: 0, Order: 1, Sides: []models.Side{{Title: "3C"}, {Title: "1D"}}},
+			{ID: 12, RoundIndex: 1, Order: 0, Sides: []models.Side{{Title: "1A"}}},
+		},
+	})
+	assert.NoError(t, err)
+	at := func(clock string) *time.Time {
+		v, err := time.Parse(models.ScheduleTimeLayout, "2025-05-20 "+clock)
+		assert.NoError(t, err)
+		return &v
+	}
+	pagePattern := regexp.MustCompile(`/Type /Page\b[^s]`)
+
+	t.Run("Success", func(t *testing.T) {
+		mockTournRepo := new(MockTournamentRepository)
+		mockClassRepo := new(MockClassRepository)
+		mockEventRepo := new(MockEventRepository)
+		mockScheduleRepo := new(MockScheduleRepository)
+
+		mockEventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1, Name: "2025春季スポーツ大会"}, nil).Once()
+		mockTournRepo.On("GetTournamentsByEventID", 1).Return([]*models.Tournament{
+			{ID: 1, Name: "バスケットボール", Data: tournamentData},
+		}, nil).Once()
+		mockScheduleRepo.On("GetMatches", 1).Return([]models.ScheduleMatch{
+			{MatchID: 10, TournamentID: 1, Location: "gym1", ClassIDs: []int{1, 2}, StartTime: at("09:00:00"), Court: "A"},
+			{MatchID: 11, TournamentID: 1, Location: "gym1", ClassIDs: []int{3}, StartTime: at("09:00:00"), Court: "B"},
+			{MatchID: 12, TournamentID: 1, Round: 1, Location: "gym1", ClassIDs: []int{1}},
+		}, nil).Once()
+		mockClassRepo.On("GetAllClasses", 1).Return([]*models.Class{
+			{ID: 1, Name: "1A"}, {ID: 2, Name: "2B"}, {ID: 3, Name: "3C"}, {ID: 4, Name: "1D"},
+		}, nil).Once()
+
+		h := handler.NewTournamentHandler(mockTournRepo, new(MockSportRepository), new(MockTeamRepository), mockClassRepo, mockEventRepo, websocket.NewHubManager()).WithScheduleCheck(mockScheduleRepo)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		h.ExportTournamentsPDFHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment; filename=\"event_1_tournaments.pdf\"", w.Header().Get("Content-Disposition"))
+		assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-")))
+		// 対戦表1ページ、コート A・B のタイムテーブル2ページ、クラス4ページ
+		assert.Len(t, pagePattern.FindAll(w.Body.Bytes(), -1), 7)
+		mockScheduleRepo.AssertExpectations(t)
+		mockClassRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rainy mode ignores courts", func(t *testing.T) {
+		mockTournRepo := new(MockTournamentRepository)
+		mockClassRepo := new(MockClassRepository)
+		mockEventRepo := new(MockEventRepository)
+		mockScheduleRepo := new(MockScheduleRepository)
+
+		mockEventRepo.On("GetEventByID", 1).Return(&models.Event{ID: 1, Name: "2025春季スポーツ大会", IsRainyMode: true}, nil).Once()
+		mockTournRepo.On("GetTournamentsByEventID", 1).Return([]*models.Tournament{
+			{ID: 1, Name: "バスケットボール", Data: tournamentData},
+		}, nil).Once()
+		mockScheduleRepo.On("GetMatches", 1).Return([]models.ScheduleMatch{
+			{MatchID: 10, TournamentID: 1, Location: "gym1", StartTime: at("09:00:00"), RainyModeStartTime: at("10:00:00"), Court: "A"},
+			{MatchID: 11, TournamentID: 1, Location: "gym1", StartTime: at("09:00:00"), Court: "B"},
+		}, nil).Once()
+		mockClassRepo.On("GetAllClasses", 1).Return([]*models.Class{}, nil).Once()
+
+		h := handler.NewTournamentHandler(mockTournRepo, new(MockSportRepository), new(MockTeamRepository), mockClassRepo, mockEventRepo, websocket.NewHubManager()).WithScheduleCheck(mockScheduleRepo)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		h.ExportTournamentsPDFHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		// 対戦表1ページと会場 gym1 のタイムテーブル1ページ
+		assert.Len(t, pagePattern.FindAll(w.Body.Bytes(), -1), 2)
+	})
+
+	t.Run("No tournaments", func(t *testing.T) {
+		mockTournRepo := new(MockTournamentRepository)
+		mockTournRepo.On("GetTournamentsByEventID", 1).Return([]*models.Tournament{}, nil).Once()
+
+		h := handler.NewTournamentHandler(mockTournRepo, new(MockSportRepository), new(MockTeamRepository), new(MockClassRepository), new(MockEventRepository), websocket.NewHubManager())
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		h.ExportTournamentsPDFHandler(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid event ID", func(t *testing.T) {
+		h := handler.NewTournamentHandler(new(MockTournamentRepository), new(MockSportRepository), new(MockTeamRepository), new(MockClassRepository), new(MockEventRepository), websocket.NewHubManager())
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "id", Value: "abc"}}
+
+		h.ExportTournamentsPDFHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,107 @@
+package handler_test
+
+import (
+	"backapp/internal/handler"
+	"backapp/internal/repository"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTournamentHandler_UpdateMatchResultTypes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func() (*handler.TournamentHandler, *MockTournamentRepository) {
+		tournRepo := new(MockTournamentRepository)
+		tournRepo.On("IsMatchResultAlreadyEntered", 7).Return(false, nil)
+		tournRepo.On("GetTournamentIDByMatchID", 7).Return(3, nil).Maybe()
+		return handler.NewTournamentHandler(tournRepo, nil, nil, nil, nil, nil), tournRepo
+	}
+
+	newContext := func(body interface{}) (*httptest.ResponseRecorder, *gin.Context) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{{Key: "match_id", Value: "7"}}
+		raw, _ := json.Marshal(body)
+		c.Request, _ = http.NewRequest(http.MethodPut, "/api/admin/matches/7/result", bytes.NewBuffer(raw))
+		c.Request.Header.Set("Content-Type", "application/json")
+		return w, c
+	}
+
+	t.Run("不戦勝は不出場のチームを渡して保存する", func(t *testing.T) {
+		h, tournRepo := setup()
+		tournRepo.On("UpdateMatchResult", 7, 0, 0, 0, "walkover", 2, "").Return(nil).Once()
+
+		w, c := newContext(gin.H{"result_type": "walkover", "forfeiting_team_id": 2})
+		h.UpdateMatchResultHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		tournRepo.AssertExpectations(t)
+	})
+
+	t.Run("相手チームのいない試合の不戦勝は不出場のチームを省略できる", func(t *testing.T) {
+		h, tournRepo := setup()
+		tournRepo.On("UpdateMatchResult", 7, 0, 0, 0, "walkover", 0, "").Return(nil).Once()
+
+		w, c := newContext(gin.H{"result_type": "walkover"})
+		h.UpdateMatchResultHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		tournRepo.AssertExpectations(t)
+	})
+
+	t.Run("結果の種別を省略すると通常の結果", func(t *testing.T) {
+		h, tournRepo := setup()
+		tournRepo.On("UpdateMatchResult", 7, 3, 1, 0, "normal", 0, "").Return(nil).Once()
+
+		w, c := newContext(gin.H{"team1_score": 3, "team2_score": 1})
+		h.UpdateMatchResultHandler(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		tournRepo.AssertExpectations(t)
+	})
+
+	t.Run("不明な種別や不出場のチームの指定漏れは400", func(t *testing.T) {
+		h, tournRepo := setup()
+
+		w, c := newContext(gin.H{"result_type": "abandoned"})
+		h.UpdateMatchResultHandler(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w, c = newContext(gin.H{"result_type": "disqualification"})
+		h.UpdateMatchResultHandler(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		tournRepo.AssertNotCalled(t, "UpdateMatchResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("試合と合わない結果は400", func(t *testing.T) {
+		h, tournRepo := setup()
+		tournRepo.On("UpdateMatchResult", 7, 0, 0, 0, "forfeit", 9, "").
+			Return(fmt.Errorf("%w: 棄権・不出場・失格のチームはこの試合の対戦チームから選んでください", repository.ErrInvalidMatchResult)).Once()
+
+		w, c := newContext(gin.H{"result_type": "forfeit", "forfeiting_team_id": 9})
+		h.UpdateMatchResultHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "対戦チームから選んでください")
+	})
+
+	t.Run("対戦チームが揃っていない試合は400", func(t *testing.T) {
+		h, tournRepo := setup()
+		tournRepo.On("UpdateMatchResult", 7, 2, 1, 0, "normal", 0, "").Return(repository.ErrMatchTeamsNotDecided).Once()
+
+		w, c := newContext(gin.H{"team1_score": 2, "team2_score": 1})
+		h.UpdateMatchResultHandler(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "対戦チームが揃っていない試合には結果を入力できません")
+	})
+}
@@ -0,0 +1,93 @@
+package repository_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckInRepository_CheckIn(t *testing.T) {
+	const dayQ = "SELECT id FROM event_days WHERE event_id = ? AND date = CURDATE()"
+	const insertQ = "INSERT INTO check_ins (user_id, event_id, event_day_id, purpose, checked_in_by) VALUES (?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE id = id"
+	const selectQ = "FROM check_ins WHERE event_id = ? AND user_id = ? AND purpose = ? AND event_day_key = ?"
+	columns := []string{"id", "user_id", "event_id", "event_day_id", "purpose", "checked_in_at", "checked_in_by"}
+	checkedInAt := time.Date(2026, 5, 20, 8, 30, 0, 0, time.UTC)
+
+	t.Run("今日の開催日に結び付けて記録する", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewCheckInRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(dayQ)).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectExec(regexp.QuoteMeta(insertQ)).
+			WithArgs("student-1", 1, int64(3), models.CheckInPurposeEventParticipation, "admin-1").
+			WillReturnResult(sqlmock.NewResult(10, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(selectQ)).
+			WithArgs(1, "student-1", models.CheckInPurposeEventParticipation, int64(3)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(10, "student-1", 1, 3, models.CheckInPurposeEventParticipation, checkedInAt, "admin-1"))
+
+		checkIn, created, err := r.CheckIn(1, "student-1", models.CheckInPurposeEventParticipation, "admin-1")
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, 10, checkIn.ID)
+		require.NotNil(t, checkIn.EventDayID)
+		assert.Equal(t, 3, *checkIn.EventDayID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("同じ日のチェックインがあれば記録し直さない", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewCheckInRepository(db)
+
+		// 開催日が登録されていない大会
+		mock.ExpectQuery(regexp.QuoteMeta(dayQ)).WithArgs(1).WillReturnError(sql.ErrNoRows)
+		mock.ExpectExec(regexp.QuoteMeta(insertQ)).
+			WithArgs("student-1", 1, nil, models.CheckInPurposeEventParticipation, "admin-1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(selectQ)).
+			WithArgs(1, "student-1", models.CheckInPurposeEventParticipation, int64(0)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(7, "student-1", 1, nil, models.CheckInPurposeEventParticipation, checkedInAt, "admin-2"))
+
+		checkIn, created, err := r.CheckIn(1, "student-1", models.CheckInPurposeEventParticipation, "admin-1")
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, 7, checkIn.ID)
+		assert.Nil(t, checkIn.EventDayID)
+		require.NotNil(t, checkIn.CheckedInBy)
+		assert.Equal(t, "admin-2", *checkIn.CheckedInBy)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCheckInRepository_GetUncheckedClassMembers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewCheckInRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("NOT EXISTS (SELECT 1 FROM check_ins ci WHERE ci.event_id = ? AND ci.user_id = u.id)")).
+		WithArgs(5, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "display_name"}).
+			AddRow("student-3", "s2301060@sendai-nct.jp", nil))
+
+	users, err := r.GetUncheckedClassMembers(1, 5)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "student-3", users[0].ID)
+	assert.Nil(t, users[0].DisplayName)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -17,8 +17,7 @@ func TestClassRepository_UpdateAttendance(t *testing.T) {
 	const (
 		selectClass  = "SELECT student_count, name FROM classes WHERE id = ? AND event_id = ?"
 		updateAttend = "UPDATE classes SET attend_count = ? WHERE id = ? AND event_id = ?"
-		deleteLog    = "DELETE FROM score_logs WHERE event_id = ? AND class_id = ? AND reason = 'attendance_points'"
-		insertLog    = "INSERT INTO score_logs (event_id, class_id, points, reason) VALUES (?, ?, ?, ?)"
+		selectRules  = "SELECT rules FROM scoring_rule_sets WHERE event_id = ? ORDER BY version DESC LIMIT 1"
 	)
 
 	setup := func(t *testing.T) (repository.ClassRepository, sqlmock.Sqlmock, func()) {
@@ -42,13 +41,15 @@ func TestClassRepository_UpdateAttendance(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"student_count", "name"}).AddRow(30, "1-1"))
 		mock.ExpectExec(regexp.QuoteMeta(updateAttend)).
 			WithArgs(27, 1, 1).WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec(regexp.QuoteMeta(deleteLog)).
-			WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectExec(regexp.QuoteMeta(insertLog)).
-			WithArgs(1, 1, 10, "attendance_points").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(selectRules)).
+			WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"rules"}))
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 1, "attendance_points").WillReturnRows(noCurrentPoints())
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 1, 10, "attendance_points", nil, "attendance", "user-1").WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
-		points, err := repo.UpdateAttendance(1, 1, 27)
+		points, err := repo.UpdateAttendance(1, 1, 27, "user-1")
 		assert.NoError(t, err)
 		assert.Equal(t, 10, points)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -65,13 +66,15 @@ func TestClassRepository_UpdateAttendance(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"student_count", "name"}).AddRow(30, "1-1"))
 		mock.ExpectExec(regexp.QuoteMeta(updateAttend)).
 			WithArgs(24, 1, 1).WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec(regexp.QuoteMeta(deleteLog)).
-			WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectExec(regexp.QuoteMeta(insertLog)).
-			WithArgs(1, 1, 9, "attendance_points").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(selectRules)).
+			WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"rules"}))
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 1, "attendance_points").WillReturnRows(noCurrentPoints())
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 1, 9, "attendance_points", nil, "attendance", "user-1").WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
-		points, err := repo.UpdateAttendance(1, 1, 24)
+		points, err := repo.UpdateAttendance(1, 1, 24, "user-1")
 		assert.NoError(t, err)
 		assert.Equal(t, 9, points)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -88,13 +91,15 @@ func TestClassRepository_UpdateAttendance(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"student_count", "name"}).AddRow(30, "1-1"))
 		mock.ExpectExec(regexp.QuoteMeta(updateAttend)).
 			WithArgs(10, 1, 1).WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec(regexp.QuoteMeta(deleteLog)).
-			WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectExec(regexp.QuoteMeta(insertLog)).
-			WithArgs(1, 1, 5, "attendance_points").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(selectRules)).
+			WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"rules"}))
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 1, "attendance_points").WillReturnRows(noCurrentPoints())
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 1, 5, "attendance_points", nil, "attendance", "user-1").WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
-		points, err := repo.UpdateAttendance(1, 1, 10)
+		points, err := repo.UpdateAttendance(1, 1, 10, "user-1")
 		assert.NoError(t, err)
 		assert.Equal(t, 5, points)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -110,18 +115,68 @@ func TestClassRepository_UpdateAttendance(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"student_count", "name"}).AddRow(20, "専教"))
 		mock.ExpectExec(regexp.QuoteMeta(updateAttend)).
 			WithArgs(15, 2, 1).WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec(regexp.QuoteMeta(deleteLog)).
-			WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectExec(regexp.QuoteMeta(insertLog)).
-			WithArgs(1, 2, 0, "attendance_points").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 2, "attendance_points").WillReturnRows(noCurrentPoints())
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 2, 0, "attendance_points", nil, "attendance", "user-1").WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
-		points, err := repo.UpdateAttendance(2, 1, 15)
+		points, err := repo.UpdateAttendance(2, 1, 15, "user-1")
 		assert.NoError(t, err)
 		assert.Equal(t, 0, points)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("success - unchanged points records nothing", func(t *testing.T) {
+		repo, mock, close := setup(t)
+		defer close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(selectClass)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"student_count", "name"}).AddRow(30, "1-1"))
+		mock.ExpectExec(regexp.QuoteMeta(updateAttend)).
+			WithArgs(28, 1, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(selectRules)).
+			WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"rules"}))
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 1, "attendance_points").
+			WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(1, 10))
+		mock.ExpectCommit()
+
+		points, err := repo.UpdateAttendance(1, 1, 28, "user-1")
+		assert.NoError(t, err)
+		assert.Equal(t, 10, points)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("success - changed points reverses previous record", func(t *testing.T) {
+		repo, mock, close := setup(t)
+		defer close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(selectClass)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"student_count", "name"}).AddRow(30, "1-1"))
+		mock.ExpectExec(regexp.QuoteMeta(updateAttend)).
+			WithArgs(24, 1, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(selectRules)).
+			WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"rules"}))
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 1, "attendance_points").
+			WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(1, 10))
+		mock.ExpectExec(regexp.QuoteMeta(reverseScoreLogsSQL("l.event_id = ? AND l.class_id = ? AND l.reason = ?"))).
+			WithArgs("attendance", "user-1", 1, 1, "attendance_points").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 1, 9, "attendance_points", nil, "attendance", "user-1").WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectCommit()
+
+		points, err := repo.UpdateAttendance(1, 1, 24, "user-1")
+		assert.NoError(t, err)
+		assert.Equal(t, 9, points)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("rollback - begin transaction error", func(t *testing.T) {
 		repo, mock, close := setup(t)
 		defer close()
@@ -129,7 +184,7 @@ func TestClassRepository_UpdateAttendance(t *testing.T) {
 		dbErr := errors.New("connection refused")
 		mock.ExpectBegin().WillReturnError(dbErr)
 
-		points, err := repo.UpdateAttendance(1, 1, 27)
+		points, err := repo.UpdateAttendance(1, 1, 27, "user-1")
 		assert.Error(t, err)
 		assert.Equal(t, 0, points)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -145,7 +200,7 @@ func TestClassRepository_UpdateAttendance(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"student_count", "name"})) // no row → Scan returns ErrNoRows
 		mock.ExpectRollback()
 
-		points, err := repo.UpdateAttendance(999, 1, 27)
+		points, err := repo.UpdateAttendance(999, 1, 27, "user-1")
 		assert.Error(t, err)
 		assert.Equal(t, 0, points)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -163,7 +218,7 @@ func TestClassRepository_UpdateAttendance(t *testing.T) {
 			WithArgs(5, 1, 1).WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectRollback()
 
-		points, err := repo.UpdateAttendance(1, 1, 5)
+		points, err := repo.UpdateAttendance(1, 1, 5, "user-1")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "zero students")
 		assert.Equal(t, 0, points)
@@ -183,13 +238,13 @@ func TestClassRepository_UpdateAttendance(t *testing.T) {
 			WithArgs(27, 1, 1).WillReturnError(dbErr)
 		mock.ExpectRollback()
 
-		points, err := repo.UpdateAttendance(1, 1, 27)
+		points, err := repo.UpdateAttendance(1, 1, 27, "user-1")
 		assert.Error(t, err)
 		assert.Equal(t, 0, points)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("rollback - DELETE score_logs fails", func(t *testing.T) {
+	t.Run("rollback - SELECT current points fails", func(t *testing.T) {
 		repo, mock, close := setup(t)
 		defer close()
 
@@ -200,11 +255,13 @@ func TestClassRepository_UpdateAttendance(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"student_count", "name"}).AddRow(30, "1-1"))
 		mock.ExpectExec(regexp.QuoteMeta(updateAttend)).
 			WithArgs(27, 1, 1).WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec(regexp.QuoteMeta(deleteLog)).
-			WithArgs(1, 1).WillReturnError(dbErr)
+		mock.ExpectQuery(regexp.QuoteMeta(selectRules)).
+			WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"rules"}))
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 1, "attendance_points").WillReturnError(dbErr)
 		mock.ExpectRollback()
 
-		points, err := repo.UpdateAttendance(1, 1, 27)
+		points, err := repo.UpdateAttendance(1, 1, 27, "user-1")
 		assert.Error(t, err)
 		assert.Equal(t, 0, points)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -221,13 +278,15 @@ func TestClassRepository_UpdateAttendance(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"student_count", "name"}).AddRow(30, "1-1"))
 		mock.ExpectExec(regexp.QuoteMeta(updateAttend)).
 			WithArgs(27, 1, 1).WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec(regexp.QuoteMeta(deleteLog)).
-			WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectExec(regexp.QuoteMeta(insertLog)).
-			WithArgs(1, 1, 10, "attendance_points").WillReturnError(dbErr)
+		mock.ExpectQuery(regexp.QuoteMeta(selectRules)).
+			WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"rules"}))
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 1, "attendance_points").WillReturnRows(noCurrentPoints())
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 1, 10, "attendance_points", nil, "attendance", "user-1").WillReturnError(dbErr)
 		mock.ExpectRollback()
 
-		points, err := repo.UpdateAttendance(1, 1, 27)
+		points, err := repo.UpdateAttendance(1, 1, 27, "user-1")
 		assert.Error(t, err)
 		assert.Equal(t, 0, points)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -317,13 +376,7 @@ func TestClassRepository_UpdateStudentCounts(t *testing.T) {
 // ─── SetNoonGamePoints ─────────────────────────────────────────────────────
 
 func TestClassRepository_SetNoonGamePoints(t *testing.T) {
-	const (
-		deleteNoon = "DELETE FROM score_logs WHERE event_id = ? AND reason = 'noon_game_points'"
-		insertNoon = `
-				INSERT INTO score_logs (event_id, class_id, points, reason)
-				VALUES (?, ?, ?, 'noon_game_points')
-			`
-	)
+	const reverseClass = "l.event_id = ? AND l.class_id = ? AND l.reason = ?"
 
 	setup := func(t *testing.T) (repository.ClassRepository, sqlmock.Sqlmock, func()) {
 		t.Helper()
@@ -334,32 +387,47 @@ func TestClassRepository_SetNoonGamePoints(t *testing.T) {
 		return repository.NewClassRepository(db), mock, func() { db.Close() }
 	}
 
-	t.Run("success - replaces existing points", func(t *testing.T) {
+	t.Run("success - records new points", func(t *testing.T) {
 		repo, mock, close := setup(t)
 		defer close()
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta(deleteNoon)).
-			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
-		mock.ExpectPrepare(regexp.QuoteMeta(insertNoon)).
-			ExpectExec().WithArgs(1, 10, 50).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(activeEventClassesSQL)).
+			WithArgs(1, "noon_game_points").WillReturnRows(sqlmock.NewRows([]string{"class_id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 10, "noon_game_points").WillReturnRows(noCurrentPoints())
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 10, 50, "noon_game_points", nil, "noon_game", "user-1").WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
-		err := repo.SetNoonGamePoints(1, map[int]int{10: 50})
+		err := repo.SetNoonGamePoints(1, map[int]int{10: 50}, "user-1")
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("success - empty points only deletes previous records", func(t *testing.T) {
+	t.Run("success - reverses changed and removed classes", func(t *testing.T) {
 		repo, mock, close := setup(t)
 		defer close()
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta(deleteNoon)).
-			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectQuery(regexp.QuoteMeta(activeEventClassesSQL)).
+			WithArgs(1, "noon_game_points").WillReturnRows(sqlmock.NewRows([]string{"class_id"}).AddRow(10).AddRow(11).AddRow(12))
+		// クラス11は今回の集計に含まれないため打ち消す
+		mock.ExpectExec(regexp.QuoteMeta(reverseScoreLogsSQL(reverseClass))).
+			WithArgs("noon_game", "user-1", 1, 11, "noon_game_points").WillReturnResult(sqlmock.NewResult(1, 1))
+		// クラス10は得点が変わったので打ち消して記録し直す
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 10, "noon_game_points").WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(1, 5))
+		mock.ExpectExec(regexp.QuoteMeta(reverseScoreLogsSQL(reverseClass))).
+			WithArgs("noon_game", "user-1", 1, 10, "noon_game_points").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 10, 50, "noon_game_points", nil, "noon_game", "user-1").WillReturnResult(sqlmock.NewResult(3, 1))
+		// クラス12は変わらないので何も記録しない
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 12, "noon_game_points").WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(1, 7))
 		mock.ExpectCommit()
 
-		err := repo.SetNoonGamePoints(1, map[int]int{})
+		err := repo.SetNoonGamePoints(1, map[int]int{10: 50, 12: 7}, "user-1")
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -370,69 +438,52 @@ func TestClassRepository_SetNoonGamePoints(t *testing.T) {
 
 		mock.ExpectBegin().WillReturnError(errors.New("connection refused"))
 
-		err := repo.SetNoonGamePoints(1, map[int]int{10: 50})
-		assert.Error(t, err)
-		assert.NoError(t, mock.ExpectationsWereMet())
-	})
-
-	t.Run("rollback - DELETE fails", func(t *testing.T) {
-		repo, mock, close := setup(t)
-		defer close()
-
-		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta(deleteNoon)).
-			WithArgs(1).WillReturnError(errors.New("db error"))
-		mock.ExpectRollback()
-
-		err := repo.SetNoonGamePoints(1, map[int]int{10: 50})
+		err := repo.SetNoonGamePoints(1, map[int]int{10: 50}, "user-1")
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to reset noon_game_points")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("rollback - prepare INSERT fails", func(t *testing.T) {
+	t.Run("rollback - SELECT current classes fails", func(t *testing.T) {
 		repo, mock, close := setup(t)
 		defer close()
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta(deleteNoon)).
-			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectPrepare(regexp.QuoteMeta(insertNoon)).WillReturnError(errors.New("prepare error"))
+		mock.ExpectQuery(regexp.QuoteMeta(activeEventClassesSQL)).
+			WithArgs(1, "noon_game_points").WillReturnError(errors.New("db error"))
 		mock.ExpectRollback()
 
-		err := repo.SetNoonGamePoints(1, map[int]int{10: 50})
+		err := repo.SetNoonGamePoints(1, map[int]int{10: 50}, "user-1")
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to prepare noon_game_points statement")
+		assert.Contains(t, err.Error(), "failed to update noon_game_points")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("rollback - INSERT exec fails", func(t *testing.T) {
+	t.Run("rollback - INSERT fails", func(t *testing.T) {
 		repo, mock, close := setup(t)
 		defer close()
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta(deleteNoon)).
-			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectPrepare(regexp.QuoteMeta(insertNoon)).
-			ExpectExec().WithArgs(1, 10, 50).WillReturnError(errors.New("insert error"))
+		mock.ExpectQuery(regexp.QuoteMeta(activeEventClassesSQL)).
+			WithArgs(1, "noon_game_points").WillReturnRows(sqlmock.NewRows([]string{"class_id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 10, "noon_game_points").WillReturnRows(noCurrentPoints())
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 10, 50, "noon_game_points", nil, "noon_game", "user-1").WillReturnError(errors.New("insert error"))
 		mock.ExpectRollback()
 
-		err := repo.SetNoonGamePoints(1, map[int]int{10: 50})
+		err := repo.SetNoonGamePoints(1, map[int]int{10: 50}, "user-1")
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to update noon_game_points for class 10")
+		assert.Contains(t, err.Error(), "failed to update noon_game_points")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
 
-// ─── SetSurveyPoints ──────────────────────────────────────────────────────
+// ─── SetSurveyPoints ─────────────────────────────────────────────────────
 
 func TestClassRepository_SetSurveyPoints(t *testing.T) {
 	const (
-		deleteSurvey = "DELETE FROM score_logs WHERE event_id = ? AND reason = 'survey_points'"
-		insertSurvey = `
-				INSERT INTO score_logs (event_id, class_id, points, reason)
-				VALUES (?, ?, ?, 'survey_points')
-			`
+		reverseClass      = "l.event_id = ? AND l.class_id = ? AND l.reason = ?"
+		updateSubmissions = "UPDATE classes SET survey_submission_count = ? WHERE id = ? AND event_id = ?"
 	)
 
 	setup := func(t *testing.T) (repository.ClassRepository, sqlmock.Sqlmock, func()) {
@@ -444,32 +495,53 @@ func TestClassRepository_SetSurveyPoints(t *testing.T) {
 		return repository.NewClassRepository(db), mock, func() { db.Close() }
 	}
 
-	t.Run("success - replaces existing points", func(t *testing.T) {
+	t.Run("success - records new points", func(t *testing.T) {
 		repo, mock, close := setup(t)
 		defer close()
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta(deleteSurvey)).
-			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 2))
-		mock.ExpectPrepare(regexp.QuoteMeta(insertSurvey)).
-			ExpectExec().WithArgs(1, 10, 20).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(updateSubmissions)).
+			WithArgs(30, 10, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(activeEventClassesSQL)).
+			WithArgs(1, "survey_points").WillReturnRows(sqlmock.NewRows([]string{"class_id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 10, "survey_points").WillReturnRows(noCurrentPoints())
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 10, 20, "survey_points", nil, "survey", "user-1").WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
-		err := repo.SetSurveyPoints(1, map[int]int{10: 20})
+		err := repo.SetSurveyPoints(1, map[int]int{10: 30}, map[int]int{10: 20}, "user-1")
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("success - empty points only deletes previous records", func(t *testing.T) {
+	t.Run("success - reverses changed and removed classes", func(t *testing.T) {
 		repo, mock, close := setup(t)
 		defer close()
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta(deleteSurvey)).
-			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(regexp.QuoteMeta(updateSubmissions)).
+			WithArgs(30, 10, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(updateSubmissions)).
+			WithArgs(12, 12, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(activeEventClassesSQL)).
+			WithArgs(1, "survey_points").WillReturnRows(sqlmock.NewRows([]string{"class_id"}).AddRow(10).AddRow(11).AddRow(12))
+		// クラス11は今回の集計に含まれないため打ち消す
+		mock.ExpectExec(regexp.QuoteMeta(reverseScoreLogsSQL(reverseClass))).
+			WithArgs("survey", "user-1", 1, 11, "survey_points").WillReturnResult(sqlmock.NewResult(1, 1))
+		// クラス10は得点が変わったので打ち消して記録し直す
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 10, "survey_points").WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(1, 5))
+		mock.ExpectExec(regexp.QuoteMeta(reverseScoreLogsSQL(reverseClass))).
+			WithArgs("survey", "user-1", 1, 10, "survey_points").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 10, 20, "survey_points", nil, "survey", "user-1").WillReturnResult(sqlmock.NewResult(3, 1))
+		// クラス12は変わらないので何も記録しない
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 12, "survey_points").WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(1, 7))
 		mock.ExpectCommit()
 
-		err := repo.SetSurveyPoints(1, map[int]int{})
+		err := repo.SetSurveyPoints(1, map[int]int{10: 30, 12: 12}, map[int]int{10: 20, 12: 7}, "user-1")
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -480,56 +552,66 @@ func TestClassRepository_SetSurveyPoints(t *testing.T) {
 
 		mock.ExpectBegin().WillReturnError(errors.New("connection refused"))
 
-		err := repo.SetSurveyPoints(1, map[int]int{10: 20})
+		err := repo.SetSurveyPoints(1, map[int]int{10: 30}, map[int]int{10: 20}, "user-1")
 		assert.Error(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("rollback - DELETE fails", func(t *testing.T) {
+	t.Run("rollback - SELECT current classes fails", func(t *testing.T) {
 		repo, mock, close := setup(t)
 		defer close()
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta(deleteSurvey)).
-			WithArgs(1).WillReturnError(errors.New("db error"))
+		mock.ExpectExec(regexp.QuoteMeta(updateSubmissions)).
+			WithArgs(30, 10, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(activeEventClassesSQL)).
+			WithArgs(1, "survey_points").WillReturnError(errors.New("db error"))
 		mock.ExpectRollback()
 
-		err := repo.SetSurveyPoints(1, map[int]int{10: 20})
+		err := repo.SetSurveyPoints(1, map[int]int{10: 30}, map[int]int{10: 20}, "user-1")
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to reset survey_points")
+		assert.Contains(t, err.Error(), "failed to update survey_points")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("rollback - prepare INSERT fails", func(t *testing.T) {
+	t.Run("rollback - INSERT fails", func(t *testing.T) {
 		repo, mock, close := setup(t)
 		defer close()
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta(deleteSurvey)).
-			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectPrepare(regexp.QuoteMeta(insertSurvey)).WillReturnError(errors.New("prepare error"))
+		mock.ExpectExec(regexp.QuoteMeta(updateSubmissions)).
+			WithArgs(30, 10, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(activeEventClassesSQL)).
+			WithArgs(1, "survey_points").WillReturnRows(sqlmock.NewRows([]string{"class_id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).
+			WithArgs(1, 10, "survey_points").WillReturnRows(noCurrentPoints())
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 10, 20, "survey_points", nil, "survey", "user-1").WillReturnError(errors.New("insert error"))
 		mock.ExpectRollback()
 
-		err := repo.SetSurveyPoints(1, map[int]int{10: 20})
+		err := repo.SetSurveyPoints(1, map[int]int{10: 30}, map[int]int{10: 20}, "user-1")
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to prepare survey_points statement")
+		assert.Contains(t, err.Error(), "failed to update survey_points")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("rollback - INSERT exec fails", func(t *testing.T) {
+	t.Run("rollback - UPDATE survey_submission_count fails", func(t *testing.T) {
 		repo, mock, close := setup(t)
 		defer close()
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta(deleteSurvey)).
-			WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectPrepare(regexp.QuoteMeta(insertSurvey)).
-			ExpectExec().WithArgs(1, 10, 20).WillReturnError(errors.New("insert error"))
+		mock.ExpectExec(regexp.QuoteMeta(updateSubmissions)).
+			WithArgs(30, 10, 1).WillReturnError(errors.New("update error"))
 		mock.ExpectRollback()
 
-		err := repo.SetSurveyPoints(1, map[int]int{10: 20})
+		err := repo.SetSurveyPoints(1, map[int]int{10: 30}, map[int]int{10: 20}, "user-1")
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to update survey_points for class 10")
+		assert.Contains(t, err.Error(), "failed to update survey_submission_count")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+// noCurrentPoints はまだ得点が記録されていないときの集計結果
+func noCurrentPoints() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"count", "sum"}).AddRow(0, nil)
+}
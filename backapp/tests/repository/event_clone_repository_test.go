@@ -0,0 +1,85 @@
+package repository_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"backapp/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eventClonePlan() *models.EventClonePlan {
+	maxCapacity := 5
+	return &models.EventClonePlan{
+		SourceEventID: 1,
+		Event:         &models.Event{Name: "2026春季スポーツ大会", Year: 2026, Season: "spring", Status: models.EventStatusUpcoming, DuplicateRegistrationThreshold: 31},
+		Sports: []*models.EventSport{
+			{SportID: 1, Location: "ground", Format: models.SportFormatTournament},
+		},
+		Classes: []*models.EventCloneClass{
+			{SourceClassID: 11, SourceName: "IS2", Name: "IS3", StudentCount: 40},
+		},
+		RainyModeSettings: []*models.EventCloneRainySetting{
+			{SportID: 1, SourceClassName: "IS2", ClassName: "IS3", MaxCapacity: &maxCapacity},
+		},
+		GuideDocuments: []*models.GuideDocument{
+			{Title: "大会要項", PdfURL: "/uploads/guide.pdf"},
+		},
+		NoonGameDefaultGroups: []*models.EventCloneDefaultGroup{
+			{ID: 21, TemplateKey: "year_relay", ClassNames: []string{"IS3"}},
+		},
+	}
+}
+
+func TestEventRepository_CloneEvent(t *testing.T) {
+	const insertEventQ = "INSERT INTO events (name, `year`, season, start_date, end_date, is_rainy_mode, competition_guidelines_pdf_url, survey_url, is_survey_published, status, hide_scores, duplicate_registration_threshold) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+
+	t.Run("新しい大会と引き継ぐ項目を1つのトランザクションで作る", func(t *testing.T) {
+		repo, mock, close := setupEvent(t)
+		defer close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(insertEventQ)).WillReturnResult(sqlmock.NewResult(5, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_sports")).
+			WithArgs(int64(5), 1, nil, nil, "ground", models.SportFormatTournament, nil, nil, nil, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO classes (event_id, name, student_count) VALUES (?, ?, ?)")).
+			WithArgs(int64(5), "IS3", 40).
+			WillReturnResult(sqlmock.NewResult(50, 1))
+		// 雨天時設定は作ったクラスのIDで登録する
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO rainy_mode_settings")).
+			WithArgs(int64(5), 1, int64(50), nil, 5, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO guide_documents (event_id, title, description, pdf_url) VALUES (?, ?, ?, ?)")).
+			WithArgs(int64(5), "大会要項", nil, "/uploads/guide.pdf").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE noon_game_template_default_groups SET class_names = ? WHERE id = ?")).
+			WithArgs(`["IS3"]`, 21).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		id, err := repo.CloneEvent(eventClonePlan())
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("途中で失敗すれば大会ごと作らない", func(t *testing.T) {
+		repo, mock, close := setupEvent(t)
+		defer close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(insertEventQ)).WillReturnResult(sqlmock.NewResult(5, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO event_sports")).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		id, err := repo.CloneEvent(eventClonePlan())
+		assert.Error(t, err)
+		assert.Equal(t, int64(0), id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
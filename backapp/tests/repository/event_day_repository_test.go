@@ -0,0 +1,109 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventDayRepository_SyncDays(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewEventDayRepository(db)
+
+	first := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	second := time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	// 期間外になった開催日だけを削除し、残る日の雨天時モードはそのまま保つ
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM event_days WHERE event_id = ? AND date NOT IN (?, ?)")).
+		WithArgs(1, "2025-06-10", "2025-06-11").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("ON DUPLICATE KEY UPDATE day_number = VALUES(day_number)")).
+		WithArgs(1, 1, "2025-06-10").
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec(regexp.QuoteMeta("ON DUPLICATE KEY UPDATE day_number = VALUES(day_number)")).
+		WithArgs(1, 2, "2025-06-11").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM event_days")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "day_number", "date", "is_rainy_mode"}).
+			AddRow(4, 1, 1, first, true).
+			AddRow(5, 1, 2, second, false))
+
+	days, err := r.SyncDays(1, []time.Time{first, second})
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.Equal(t, 1, days[0].DayNumber)
+	assert.True(t, days[0].IsRainyMode)
+	assert.Equal(t, second, days[1].Date)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEventDayRepository_SetRainyMode(t *testing.T) {
+	t.Run("有効にするとその日の試合だけ雨天用の開始時刻に切り替える", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewEventDayRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE event_days SET is_rainy_mode = ? WHERE id = ?")).
+			WithArgs(true, 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_revisions")).
+			WithArgs(5).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("SET m.match_start_time = m.rainy_mode_start_time")).
+			WithArgs(5).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_revisions")).
+			WithArgs(sqlmock.AnyArg(), "start_time", "root-1", 5).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		require.NoError(t, r.SetRainyMode(5, true, "root-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("無効にしても試合の開始時刻は変えない", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewEventDayRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE event_days SET is_rainy_mode = ? WHERE id = ?")).
+			WithArgs(false, 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, r.SetRainyMode(5, false, "root-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestEventDayRepository_AssignMatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewEventDayRepository(db)
+
+	// 開催日と同じ大会の試合だけが更新される
+	mock.ExpectExec(regexp.QuoteMeta("JOIN event_days ed ON ed.id = ? AND ed.event_id = t.event_id")).
+		WithArgs(5, 11, 12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	count, err := r.AssignMatches(5, []int{11, 12})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
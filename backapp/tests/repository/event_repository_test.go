@@ -480,10 +480,10 @@ func TestEventRepository_SetActiveEvent(t *testing.T) {
 // ─── CopyClassScores ───────────────────────────────────────────────────────
 
 func TestEventRepository_CopyClassScores(t *testing.T) {
-	const deleteQ = "DELETE FROM score_logs WHERE event_id = ? AND reason = 'initial_points'"
+	reverseQ := reverseScoreLogsSQL("l.event_id = ? AND l.reason = 'initial_points'")
 	const insertQ = `
-			INSERT INTO score_logs (event_id, class_id, points, reason)
-			SELECT ?, class_id, total_points_current_event, 'initial_points'
+			INSERT INTO score_logs (event_id, class_id, points, reason, operation)
+			SELECT ?, class_id, total_points_current_event, 'initial_points', ?
 			FROM class_scores
 			WHERE event_id = ? AND total_points_current_event > 0
 		`
@@ -492,19 +492,23 @@ func TestEventRepository_CopyClassScores(t *testing.T) {
 		repo, mock, close := setupEvent(t)
 		defer close()
 
-		mock.ExpectExec(regexp.QuoteMeta(deleteQ)).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectExec(regexp.QuoteMeta(insertQ)).WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 5))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(reverseQ)).WithArgs("initial", nil, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(insertQ)).WithArgs(2, "initial", 1).WillReturnResult(sqlmock.NewResult(0, 5))
+		mock.ExpectCommit()
 
 		err := repo.CopyClassScores(1, 2)
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("db error on delete", func(t *testing.T) {
+	t.Run("db error on reverse", func(t *testing.T) {
 		repo, mock, close := setupEvent(t)
 		defer close()
 
-		mock.ExpectExec(regexp.QuoteMeta(deleteQ)).WithArgs(2).WillReturnError(errors.New("db error"))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(reverseQ)).WithArgs("initial", nil, 2).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
 
 		err := repo.CopyClassScores(1, 2)
 		assert.Error(t, err)
@@ -515,8 +519,10 @@ func TestEventRepository_CopyClassScores(t *testing.T) {
 		repo, mock, close := setupEvent(t)
 		defer close()
 
-		mock.ExpectExec(regexp.QuoteMeta(deleteQ)).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectExec(regexp.QuoteMeta(insertQ)).WithArgs(2, 1).WillReturnError(errors.New("db error"))
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(reverseQ)).WithArgs("initial", nil, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(insertQ)).WithArgs(2, "initial", 1).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
 
 		err := repo.CopyClassScores(1, 2)
 		assert.Error(t, err)
@@ -0,0 +1,101 @@
+package repository_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityRepository_ImportStudentNumbers(t *testing.T) {
+	const selectQ = "SELECT id, student_number FROM users WHERE email = ? FOR UPDATE"
+	const updateQ = "UPDATE users SET student_number = ? WHERE id = ?"
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewIdentityRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(selectQ)).WithArgs("s2301059@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "student_number"}).AddRow("user-1", nil))
+	mock.ExpectExec(regexp.QuoteMeta(updateQ)).WithArgs("2301059", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// 登録済みと同じ番号は更新しない
+	mock.ExpectQuery(regexp.QuoteMeta(selectQ)).WithArgs("s2301060@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "student_number"}).AddRow("user-2", "2301060"))
+	mock.ExpectQuery(regexp.QuoteMeta(selectQ)).WithArgs("missing@example.com").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta(selectQ)).WithArgs("teacher@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "student_number"}).AddRow("teacher-1", nil))
+	mock.ExpectExec(regexp.QuoteMeta(updateQ)).WithArgs("2301060", "teacher-1").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectCommit()
+
+	result, err := r.ImportStudentNumbers([]models.StudentNumberEntry{
+		{Line: 2, Email: "s2301059@example.com", StudentNumber: "2301059"},
+		{Line: 3, Email: "s2301060@example.com", StudentNumber: "2301060"},
+		{Line: 4, Email: "missing@example.com", StudentNumber: "2301061"},
+		{Line: 5, Email: "teacher@example.com", StudentNumber: "2301060"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, 1, result.Unchanged)
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, 4, result.Errors[0].Line)
+	assert.Equal(t, "user not found", result.Errors[0].Reason)
+	assert.Equal(t, 5, result.Errors[1].Line)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdentityRepository_IssueIDPass(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewIdentityRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE id_passes SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = ? AND revoked_at IS NULL")).
+		WithArgs("teacher-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO id_passes (user_id, token, issued_by) VALUES (?, ?, ?)")).
+		WithArgs("teacher-1", "token-1", "admin-1").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	pass, err := r.IssueIDPass("teacher-1", "token-1", "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, pass.ID)
+	assert.Equal(t, "token-1", pass.Token)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdentityRepository_FindUserIDByPassToken(t *testing.T) {
+	const q = "SELECT user_id FROM id_passes WHERE token = ? AND revoked_at IS NULL"
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewIdentityRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(q)).WithArgs("token-1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("teacher-1"))
+	mock.ExpectQuery(regexp.QuoteMeta(q)).WithArgs("revoked").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+
+	userID, err := r.FindUserIDByPassToken("token-1")
+	require.NoError(t, err)
+	assert.Equal(t, "teacher-1", userID)
+
+	userID, err = r.FindUserIDByPassToken("revoked")
+	require.NoError(t, err)
+	assert.Empty(t, userID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,155 @@
+package repository_test
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var matchNotificationColumns = []string{
+	"event_id", "id", "name", "location", "court_number", "team1_name", "team2_name", "class1", "class2",
+	"team1_score", "team2_score", "winner_name", "status", "result_type", "is_final", "match_start_time", "rainy_mode_start_time",
+	"is_rainy_day",
+}
+
+func TestMatchNotificationRepository_GetUpcomingMatchStarts(t *testing.T) {
+	startTime := time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC)
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(matchNotificationColumns).
+			AddRow(1, 10, "バスケットボール", "gym1", "A", "1A", "2B", 1, 2, nil, nil, nil, "pending", "normal", false, startTime, "2025-05-20T10:30:00", false).
+			AddRow(1, 11, "バスケットボール", "gym1", "B", "3C", "", 3, nil, nil, nil, nil, "pending", "normal", true, startTime, nil, false).
+			AddRow(1, 12, "バスケットボール", "gym1", "", "1D", "2E", 4, 4, nil, nil, nil, "pending", "normal", false, nil, nil, false)
+	}
+
+	t.Run("晴天時は通常の開始時刻とコートを返す", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewMatchNotificationRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("AND COALESCE(m.status, '') NOT IN ('finished', 'bye', 'cancelled')")).
+			WithArgs(1).
+			WillReturnRows(rows())
+
+		matches, err := r.GetUpcomingMatchStarts(1, false)
+		require.NoError(t, err)
+		require.Len(t, matches, 2)
+		assert.Equal(t, 10, matches[0].MatchID)
+		assert.Equal(t, []int{1, 2}, matches[0].ClassIDs)
+		assert.Equal(t, "A", matches[0].Court)
+		assert.Equal(t, startTime, *matches[0].StartTime)
+		assert.Equal(t, []int{3}, matches[1].ClassIDs)
+		assert.True(t, matches[1].IsFinal)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("雨天時は雨天用の開始時刻を使いコートを伝えない", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewMatchNotificationRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("AND COALESCE(m.status, '') NOT IN ('finished', 'bye', 'cancelled')")).
+			WithArgs(1).
+			WillReturnRows(rows())
+
+		matches, err := r.GetUpcomingMatchStarts(1, true)
+		require.NoError(t, err)
+		require.Len(t, matches, 2)
+		assert.Equal(t, time.Date(2025, 5, 20, 10, 30, 0, 0, time.UTC), *matches[0].StartTime)
+		assert.Equal(t, "", matches[0].Court)
+		// 雨天用の開始時刻がない試合は通常の開始時刻のまま
+		assert.Equal(t, startTime, *matches[1].StartTime)
+		assert.Equal(t, "B", matches[1].Court)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("雨天時モードの開催日の試合だけ雨天用の開始時刻を使う", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewMatchNotificationRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("LEFT JOIN event_days ed ON ed.id = m.event_day_id")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(matchNotificationColumns).
+				AddRow(1, 10, "バスケットボール", "gym1", "A", "1A", "2B", 1, 2, nil, nil, nil, "pending", "normal", false, startTime, "2025-05-20T10:30:00", true).
+				AddRow(1, 13, "バスケットボール", "gym1", "C", "1E", "2F", 5, 6, nil, nil, nil, "pending", "normal", false, startTime.AddDate(0, 0, 1), "2025-05-21T10:30:00", false))
+
+		matches, err := r.GetUpcomingMatchStarts(1, false)
+		require.NoError(t, err)
+		require.Len(t, matches, 2)
+		assert.Equal(t, time.Date(2025, 5, 20, 10, 30, 0, 0, time.UTC), *matches[0].StartTime)
+		assert.Equal(t, "", matches[0].Court)
+		assert.Equal(t, startTime.AddDate(0, 0, 1), *matches[1].StartTime)
+		assert.Equal(t, "C", matches[1].Court)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMatchNotificationRepository_ClaimMatchStartNotification(t *testing.T) {
+	startTime := time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		affected int64
+		claimed  bool
+	}{
+		{name: "初めての通知", affected: 1, claimed: true},
+		{name: "開始時刻が変わった", affected: 2, claimed: true},
+		{name: "同じ開始時刻で送信済み", affected: 0, claimed: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+			r := repository.NewMatchNotificationRepository(db)
+
+			mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_start_notifications (match_id, start_time)")).
+				WithArgs(10, "2025-05-20 09:00:00").
+				WillReturnResult(sqlmock.NewResult(0, tc.affected))
+
+			claimed, err := r.ClaimMatchStartNotification(10, startTime)
+			require.NoError(t, err)
+			assert.Equal(t, tc.claimed, claimed)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMatchNotificationRepository_GetUserIDsByNotificationType(t *testing.T) {
+	cases := []struct {
+		name     string
+		classIDs []int
+		args     []driver.Value
+		suffix   string
+	}{
+		{name: "クラスで絞る", classIDs: []int{1, 2}, args: []driver.Value{"match_my_class", "all_matches", 1, 2}, suffix: "AND class_id IN (?, ?) ORDER BY id"},
+		{name: "全員", args: []driver.Value{"finals", "all_matches"}, suffix: "OR JSON_CONTAINS(notification_filters, JSON_QUOTE(?))) ORDER BY id"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+			r := repository.NewMatchNotificationRepository(db)
+
+			notificationType := tc.args[0].(string)
+			mock.ExpectQuery(regexp.QuoteMeta(tc.suffix)).
+				WithArgs(tc.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("user-1").AddRow("user-2"))
+
+			userIDs, err := r.GetUserIDsByNotificationType(tc.classIDs, notificationType)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"user-1", "user-2"}, userIDs)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package repository_test
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	matchBaselineSQL = "SELECT m.id, UUID(), 'initial', NULL,"
+	matchRevisionSQL = "SELECT m.id, ?, ?, ?,"
+)
+
+// expectMatchBaselines は変更前の状態を initial として残す INSERT を期待する
+func expectMatchBaselines(mock sqlmock.Sqlmock, args ...driver.Value) {
+	mock.ExpectExec(regexp.QuoteMeta(matchBaselineSQL)).
+		WithArgs(args...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectMatchRevisions は変更後の状態をリビジョンとして残す INSERT を期待する。change_id は毎回異なるため値を問わない。
+func expectMatchRevisions(mock sqlmock.Sqlmock, operation string, actor interface{}, args ...driver.Value) {
+	mock.ExpectExec(regexp.QuoteMeta(matchRevisionSQL)).
+		WithArgs(append([]driver.Value{sqlmock.AnyArg(), operation, actor}, args...)...).
+		WillReturnResult(sqlmock.NewResult(0, int64(len(args))))
+}
+
+func TestTournamentRepository_GetMatchRevisions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewTournamentRepository(db)
+
+	createdAt := time.Date(2026, 5, 20, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM match_revisions mr")).
+		WithArgs(34).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "match_id", "change_id", "operation", "team1_id", "team2_id", "team1_score", "team2_score",
+			"result_type", "forfeiting_team_id", "status", "match_start_time", "rainy_mode_start_time", "court_number",
+			"actor_user_id", "display_name", "created_at"}).
+			AddRow(1, 34, "c-0", "initial", 1, 2, nil, nil, "normal", nil, "scheduled", "2026-05-20 09:00:00", nil, "A", nil, nil, createdAt).
+			AddRow(5, 34, "c-1", "match_result", 1, 2, 3, 1, "normal", nil, "finished", "2026-05-20 09:00:00", nil, "A", "admin-1", "体育委員", createdAt).
+			AddRow(7, 34, "c-2", "match_correction", 1, 2, nil, nil, "walkover", 1, "finished", "2026-05-20 09:00:00", nil, "A", "root-1", nil, createdAt))
+
+	revisions, err := r.GetMatchRevisions(34)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+
+	assert.Equal(t, "initial", revisions[0].Operation)
+	assert.Nil(t, revisions[0].Team1Score)
+	assert.Nil(t, revisions[0].ActorUserID)
+
+	assert.Equal(t, 3, *revisions[1].Team1Score)
+	assert.Equal(t, "finished", revisions[1].Status)
+	assert.Equal(t, "体育委員", *revisions[1].ActorDisplayName)
+
+	assert.Equal(t, "walkover", revisions[2].ResultType)
+	assert.Equal(t, 1, *revisions[2].ForfeitingTeamID)
+	assert.Equal(t, "root-1", *revisions[2].ActorUserID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTournamentRepository_RestoreMatchRevision(t *testing.T) {
+	const selectRevisionAt = "SELECT team1_id, team2_id, team1_score, team2_score, result_type, forfeiting_team_id, status FROM match_revisions WHERE match_id = ? AND id <= ? ORDER BY id DESC LIMIT 1"
+	const restoreMatch = "UPDATE matches SET team1_id = ?, team2_id = ?, team1_score = ?, team2_score = ?, result_type = ?, forfeiting_team_id = ?, status = CASE WHEN status = 'finished' OR ? = 'finished' THEN ? ELSE status END WHERE id = ?"
+	revisionCols := []string{"team1_id", "team2_id", "team1_score", "team2_score", "result_type", "forfeiting_team_id", "status"}
+
+	t.Run("準決勝の修正を取り消すと、無効化された決勝も修正前に戻して得点を付け直す", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT change_id FROM match_revisions WHERE id = ? AND match_id = ?")).
+			WithArgs(5, 34).
+			WillReturnRows(sqlmock.NewRows([]string{"change_id"}).AddRow("c-1"))
+		// 現在は両者不戦敗に修正され、決勝の枠が空いている
+		expectResultTypeMatch(mock, 34, 0, 0, nil, "finished", 35, "double_forfeit", nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT MAX(id) FROM match_revisions WHERE change_id = ?")).
+			WithArgs("c-1").
+			WillReturnRows(sqlmock.NewRows([]string{"MAX(id)"}).AddRow(6))
+		mock.ExpectQuery(regexp.QuoteMeta("mr.id > ? AND mr.operation NOT IN ('initial', 'start_time', 'schedule')")).
+			WithArgs(1, 2, 6).
+			WillReturnRows(sqlmock.NewRows([]string{"match_id", "change_id"}).
+				AddRow(35, "c-final").
+				AddRow(34, "c-2").
+				AddRow(35, "c-2").
+				AddRow(40, "c-other"))
+
+		// 準決勝と決勝を修正前の状態に戻す
+		mock.ExpectQuery(regexp.QuoteMeta(selectRevisionAt)).
+			WithArgs(34, 6).
+			WillReturnRows(sqlmock.NewRows(revisionCols).AddRow(1, 2, 3, 1, "normal", nil, "finished"))
+		mock.ExpectExec(regexp.QuoteMeta(restoreMatch)).
+			WithArgs(1, 2, 3, 1, "normal", nil, "finished", "finished", 34).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(selectRevisionAt)).
+			WithArgs(35, 6).
+			WillReturnRows(sqlmock.NewRows(revisionCols).AddRow(1, 3, nil, nil, "normal", nil, "scheduled"))
+		mock.ExpectExec(regexp.QuoteMeta(restoreMatch)).
+			WithArgs(1, 3, nil, nil, "normal", nil, "scheduled", "scheduled", 35).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		mock.ExpectQuery(regexp.QuoteMeta(getLeagueMatchByIDSQL)).
+			WithArgs(34).
+			WillReturnRows(sqlmock.NewRows(leagueMatchCols).
+				AddRow(34, 5, 0, 0, 1, 2, 1, "finished", 35, "", false, false, nil, nil, nil, false, nil, "normal", nil))
+		mock.ExpectQuery(regexp.QuoteMeta(getLeagueMatchByIDSQL)).
+			WithArgs(35).
+			WillReturnRows(sqlmock.NewRows(leagueMatchCols).
+				AddRow(35, 5, 1, 0, 1, 3, nil, "scheduled", nil, "", false, false, nil, nil, nil, false, nil, "normal", nil))
+
+		// 戻した試合の得点を打ち消してから付け直す
+		mock.ExpectExec(regexp.QuoteMeta(reverseScoreLogsSQL("l.source_match_id IN (?, ?)"))).
+			WithArgs("match_restore", "root-1", 34, 35).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectScoringRules(mock, 1, nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT MAX(round) FROM matches WHERE tournament_id = ?")).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"MAX(round)"}).AddRow(2))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 2, "gym1"))
+		selectTeam := regexp.QuoteMeta("SELECT t.id, t.name, t.class_id, t.sport_id, c.event_id FROM teams t JOIN classes c ON t.class_id = c.id WHERE t.id = ?")
+		teamCols := []string{"id", "name", "class_id", "sport_id", "event_id"}
+		mock.ExpectQuery(selectTeam).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows(teamCols).AddRow(1, "IE1", 101, 2, 1))
+		mock.ExpectQuery(selectTeam).WithArgs(int64(2)).WillReturnRows(sqlmock.NewRows(teamCols).AddRow(2, "IS1", 102, 2, 1))
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 101, 10, "gym1_win1_points", 34, "match_restore", "root-1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		expectMatchRevisions(mock, "match_restore", "root-1", 34, 35)
+		mock.ExpectCommit()
+
+		result, err := r.RestoreMatchRevision(34, 5, "root-1")
+		require.NoError(t, err)
+		assert.Equal(t, []int{34, 35}, result.RestoredMatchIDs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("別の試合のリビジョンは指定できない", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT change_id FROM match_revisions WHERE id = ? AND match_id = ?")).
+			WithArgs(9, 34).
+			WillReturnRows(sqlmock.NewRows([]string{"change_id"}))
+		mock.ExpectRollback()
+
+		_, err = r.RestoreMatchRevision(34, 9, "root-1")
+		assert.ErrorIs(t, err, repository.ErrMatchRevisionNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -32,8 +32,9 @@ func TestMICRepository_VoteMIC(t *testing.T) {
 			WithArgs(classID, eventID).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 		mock.ExpectQuery("SELECT COUNT(.+) FROM mic_votes").WithArgs(userID, eventID).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("SELECT rules FROM scoring_rule_sets").WithArgs(eventID).WillReturnRows(sqlmock.NewRows([]string{"rules"}))
 		mock.ExpectExec("INSERT INTO mic_votes").WithArgs(userID, classID, eventID, reason, 3).WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec("INSERT INTO score_logs").WithArgs(eventID, classID, 3, "mic_points").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO score_logs").WithArgs(eventID, classID, 3, "mic_points", nil, "mic", userID).WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
 		err := r.VoteMIC(userID, classID, eventID, reason)
@@ -52,8 +53,9 @@ func TestMICRepository_VoteMIC(t *testing.T) {
 			WithArgs(classID, eventID).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 		mock.ExpectQuery("SELECT COUNT(.+) FROM mic_votes").WithArgs(userID, eventID).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("SELECT rules FROM scoring_rule_sets").WithArgs(eventID).WillReturnRows(sqlmock.NewRows([]string{"rules"}))
 		mock.ExpectExec("INSERT INTO mic_votes").WithArgs(userID, classID, eventID, reason, 3).WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec("INSERT INTO score_logs").WithArgs(eventID, classID, 3, "mic_points").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO score_logs").WithArgs(eventID, classID, 3, "mic_points", nil, "mic", userID).WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
 		err := r.VoteMIC(userID, classID, eventID, reason)
@@ -110,8 +112,9 @@ func TestMICRepository_VoteMIC(t *testing.T) {
 			WithArgs(classID, eventID).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 		mock.ExpectQuery("SELECT COUNT(.+) FROM mic_votes").WithArgs(userID, eventID).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("SELECT rules FROM scoring_rule_sets").WithArgs(eventID).WillReturnRows(sqlmock.NewRows([]string{"rules"}))
 		mock.ExpectExec("INSERT INTO mic_votes").WithArgs(userID, classID, eventID, reason, 3).WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec("INSERT INTO score_logs").WithArgs(eventID, classID, 3, "mic_points").WillReturnError(dbErr)
+		mock.ExpectExec("INSERT INTO score_logs").WithArgs(eventID, classID, 3, "mic_points", nil, "mic", userID).WillReturnError(dbErr)
 		mock.ExpectRollback()
 
 		err := r.VoteMIC(userID, classID, eventID, reason)
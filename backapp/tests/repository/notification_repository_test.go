@@ -4,8 +4,11 @@ import (
 	"database/sql"
 	"errors"
 	"regexp"
+	"strconv"
 	"testing"
+	"time"
 
+	"backapp/internal/models"
 	"backapp/internal/repository"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -39,6 +42,91 @@ func TestUpsertPushSubscriptionInsertsBelowLimit(t *testing.T) {
 	}
 }
 
+func TestPublishNotificationEnqueuesPushInSameTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := repository.NewNotificationRepository(db)
+
+	eventID := 3
+	classID := 12
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO notifications (title, body, type, created_by, event_id) VALUES (?, ?, ?, ?, ?)")).
+		WithArgs("お知らせ", "本文", "general", "root-1", eventID).
+		WillReturnResult(sqlmock.NewResult(10, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO notification_targets (notification_id, role_name) VALUES (?, ?)")).
+		WithArgs(int64(10), "student").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO notification_audiences (notification_id, target_type, target_id, user_id) VALUES (?, ?, ?, ?)")).
+		WithArgs(int64(10), models.NotificationTargetClass, classID, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO push_messages (notification_id, source, payload, ttl) VALUES (?, ?, ?, ?)")).
+		WithArgs(10, "notification", `{"notificationId":10}`, 60).
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO push_deliveries (message_id, subscription_id, user_id, status, next_attempt_at) VALUES (?, ?, ?, 'pending', NOW())")).
+		WithArgs(int64(4), 1, "user-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	notificationID, err := repo.PublishNotification(models.NewNotification{
+		Title:       "お知らせ",
+		Body:        "本文",
+		Type:        "general",
+		CreatedBy:   "root-1",
+		EventID:     &eventID,
+		TargetRoles: []string{"student"},
+		Targets:     []models.NotificationTarget{{Type: models.NotificationTargetClass, ID: &classID}},
+	}, &models.PushMessage{
+		Source:        "notification",
+		TTL:           60,
+		Subscriptions: []models.PushSubscription{{ID: 1, UserID: "user-1"}},
+		Payload: func(notificationID int) ([]byte, error) {
+			return []byte(`{"notificationId":` + strconv.Itoa(notificationID) + `}`), nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("publish notification: %v", err)
+	}
+	if notificationID != 10 {
+		t.Fatalf("notification id = %d, want 10", notificationID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPublishNotificationRollsBackWhenPushEnqueueFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := repository.NewNotificationRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO notifications")).
+		WithArgs("お知らせ", "本文", "general", nil, nil).
+		WillReturnResult(sqlmock.NewResult(10, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO push_messages")).
+		WillReturnError(errors.New("deadlock"))
+	mock.ExpectRollback()
+
+	_, err = repo.PublishNotification(models.NewNotification{Title: "お知らせ", Body: "本文", Type: "general"}, &models.PushMessage{
+		Source:        "notification",
+		TTL:           60,
+		Subscriptions: []models.PushSubscription{{ID: 1, UserID: "user-1"}},
+		Payload:       func(int) ([]byte, error) { return []byte(`{}`), nil },
+	})
+	if err == nil {
+		t.Fatal("expected enqueue error")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestUpsertPushSubscriptionRejectsLimit(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -87,3 +175,191 @@ func TestUpsertPushSubscriptionRejectsEndpointOwnedByAnotherUser(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestGetUserIDsByTargetsUnionsEachTarget(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := repository.NewNotificationRepository(db)
+
+	classID, sportID, matchID := 12, 3, 42
+	eventID := 1
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT id AS user_id FROM users WHERE class_id = ? UNION "+
+			"SELECT tm.user_id FROM team_members tm JOIN teams t ON t.id = tm.team_id WHERE t.sport_id = ? AND t.event_id = ? UNION "+
+			"SELECT user_id FROM round_check_ins WHERE match_id = ? ORDER BY user_id",
+	)).
+		WithArgs(12, 3, 1, 42).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user-1").AddRow("user-2"))
+
+	userIDs, err := repo.GetUserIDsByTargets(&eventID, []models.NotificationTarget{
+		{Type: models.NotificationTargetClass, ID: &classID},
+		{Type: models.NotificationTargetSport, ID: &sportID},
+		{Type: models.NotificationTargetMatch, ID: &matchID},
+	})
+	if err != nil {
+		t.Fatalf("GetUserIDsByTargets: %v", err)
+	}
+	if len(userIDs) != 2 || userIDs[0] != "user-1" || userIDs[1] != "user-2" {
+		t.Fatalf("userIDs = %v", userIDs)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetUserEmailsByIDsSkipsEmptyAddresses(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := repository.NewNotificationRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, email FROM users WHERE id IN (?,?) AND email <> ''")).
+		WithArgs("user-1", "user-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow("user-1", "taro@school.example"))
+
+	emails, err := repo.GetUserEmailsByIDs([]string{"user-1", "user-2"})
+	if err != nil {
+		t.Fatalf("GetUserEmailsByIDs: %v", err)
+	}
+	if len(emails) != 1 || emails["user-1"] != "taro@school.example" {
+		t.Fatalf("emails = %v", emails)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetNotificationsForAccessIncludesAudienceTargets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := repository.NewNotificationRepository(db)
+
+	createdAt := time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE (nt.role_name IN (?) OR EXISTS (")).
+		WithArgs("student", "user-1", "user-1", "user-1", "user-1", "user-1", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "body", "type", "created_by", "event_id", "created_at", "target_roles"}).
+			AddRow(5, "IS3へのお知らせ", "本文", "general", "root-1", 1, createdAt, nil))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM notification_audiences WHERE notification_id IN (?)")).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"notification_id", "target_type", "target_id", "user_id"}).
+			AddRow(5, "class", 12, nil))
+	readAt := time.Date(2025, 5, 20, 9, 5, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM notification_reads WHERE user_id = ? AND notification_id IN (?)")).
+		WithArgs("user-1", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"notification_id", "read_at"}).AddRow(5, readAt))
+
+	notifications, err := repo.GetNotificationsForAccess([]string{"student"}, "user-1", false, 10)
+	if err != nil {
+		t.Fatalf("GetNotificationsForAccess: %v", err)
+	}
+	if len(notifications) != 1 || len(notifications[0].Targets) != 1 {
+		t.Fatalf("notifications = %+v", notifications)
+	}
+	if !notifications[0].IsRead || notifications[0].ReadAt == nil || !notifications[0].ReadAt.Equal(readAt) {
+		t.Fatalf("read state = %v, %v", notifications[0].IsRead, notifications[0].ReadAt)
+	}
+	target := notifications[0].Targets[0]
+	if target.Type != models.NotificationTargetClass || target.ID == nil || *target.ID != 12 || target.UserID != nil {
+		t.Fatalf("target = %+v", target)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMarkNotificationReadTreatsAlreadyReadAsSuccess(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := repository.NewNotificationRepository(db)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO notification_reads (notification_id, user_id) SELECT n.id, ? FROM notifications n WHERE n.id = ? AND (EXISTS (SELECT 1 FROM notification_targets nt WHERE nt.notification_id = n.id AND nt.role_name IN (?)) OR n.created_by = ? OR EXISTS (")).
+		WithArgs("user-1", 5, "student", "user-1", "user-1", "user-1", "user-1", "user-1", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM notification_reads WHERE notification_id = ? AND user_id = ?)")).
+		WithArgs(5, "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	found, err := repo.MarkNotificationRead(5, []string{"student"}, "user-1")
+	if err != nil {
+		t.Fatalf("MarkNotificationRead: %v", err)
+	}
+	if !found {
+		t.Fatal("already read notification should be found")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCountUnreadNotificationsExcludesReadAndAuthored(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := repository.NewNotificationRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("AND (n.created_by IS NULL OR n.created_by <> ?) AND NOT EXISTS (SELECT 1 FROM notification_reads nr WHERE nr.notification_id = n.id AND nr.user_id = ?)")).
+		WithArgs("student", "user-1", "user-1", "user-1", "user-1", "user-1", "user-1", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := repo.CountUnreadNotifications([]string{"student"}, "user-1")
+	if err != nil {
+		t.Fatalf("CountUnreadNotifications: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("count = %d", count)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetNotificationReadStatsBreaksDownByRoleAndClass(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("create sqlmock: %v", err)
+	}
+	defer db.Close()
+	repo := repository.NewNotificationRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*), COUNT(nr.user_id) FROM users u LEFT JOIN notification_reads nr")).
+		WithArgs(5, "user-1", "user-2", "user-3", "user-4").
+		WillReturnRows(sqlmock.NewRows([]string{"target", "read"}).AddRow(4, 3))
+	mock.ExpectQuery(regexp.QuoteMeta("GROUP BY r.name")).
+		WithArgs(5, "user-1", "user-2", "user-3", "user-4").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "target", "read"}).AddRow("admin", 1, 1).AddRow("student", 3, 2))
+	mock.ExpectQuery(regexp.QuoteMeta("GROUP BY u.class_id, c.name")).
+		WithArgs(5, "user-1", "user-2", "user-3", "user-4").
+		WillReturnRows(sqlmock.NewRows([]string{"class_id", "name", "target", "read"}).
+			AddRow(nil, "", 1, 1).
+			AddRow(12, "IS3", 3, 2))
+
+	stats, err := repo.GetNotificationReadStats(5, []string{"user-1", "user-2", "user-3", "user-4"})
+	if err != nil {
+		t.Fatalf("GetNotificationReadStats: %v", err)
+	}
+	if stats.TargetUserCount != 4 || stats.ReadUserCount != 3 || stats.ReadRate != 0.75 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if len(stats.ByRole) != 2 || stats.ByRole[1].Name != "student" || stats.ByRole[1].ReadRate != 2.0/3.0 {
+		t.Fatalf("by role = %+v", stats.ByRole)
+	}
+	if len(stats.ByClass) != 2 || stats.ByClass[0].ID != nil || stats.ByClass[1].ID == nil || *stats.ByClass[1].ID != 12 {
+		t.Fatalf("by class = %+v", stats.ByClass)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,76 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationRequestRepository_EscalateStaleRequests(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewNotificationRequestRepository(db)
+
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	cutoff := now.Add(-time.Hour)
+	created := time.Date(2025, 5, 20, 9, 30, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE status = 'pending' AND created_at <= ? AND (escalated_at IS NULL OR escalated_at <= ?)")).
+		WithArgs(cutoff, cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "target_text", "requester_id", "created_at"}).
+			AddRow(3, "部活動の集合", "2年生", "user-2", created))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE notification_requests SET escalated_at = ? WHERE id = ?")).
+		WithArgs(now, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	requests, err := r.EscalateStaleRequests(now, time.Hour)
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, 3, requests[0].ID)
+	require.NotNil(t, requests[0].EscalatedAt)
+	assert.Equal(t, now, *requests[0].EscalatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNotificationRequestRepository_GetRequestStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewNotificationRequestRepository(db)
+
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	oldest := time.Date(2025, 5, 20, 9, 30, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM notification_requests GROUP BY status")).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count", "average", "max"}).
+			AddRow("pending", 2, nil, nil).
+			AddRow("approved", 4, 1800.0, 3600.0))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM notification_requests WHERE status = 'pending'")).
+		WithArgs(now.Add(-time.Hour)).
+		WillReturnRows(sqlmock.NewRows([]string{"overdue", "oldest"}).AddRow(1, oldest))
+
+	stats, err := r.GetRequestStats(now, time.Hour)
+	require.NoError(t, err)
+	require.Len(t, stats.ByStatus, 2)
+	assert.Equal(t, models.NotificationRequestStatusPending, stats.ByStatus[0].Status)
+	// 承認待ちの申請には対応時間がない
+	assert.Nil(t, stats.ByStatus[0].AverageResponseSeconds)
+	require.NotNil(t, stats.ByStatus[1].AverageResponseSeconds)
+	assert.Equal(t, 1800.0, *stats.ByStatus[1].AverageResponseSeconds)
+	assert.Equal(t, 3600.0, *stats.ByStatus[1].MaxResponseSeconds)
+	assert.Equal(t, 1, stats.OverdueCount)
+	assert.Equal(t, 60, stats.SLAMinutes)
+	require.NotNil(t, stats.OldestPendingAt)
+	assert.Equal(t, oldest, *stats.OldestPendingAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,129 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushDeliveryRepository_EnqueuePushMessage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewPushDeliveryRepository(db)
+
+	notificationID := 7
+	payload := []byte(`{"title":"t"}`)
+	subs := []models.PushSubscription{
+		{ID: 1, UserID: "user-1", Endpoint: "https://fcm.googleapis.com/fcm/send/1"},
+		{ID: 2, UserID: "user-2", Endpoint: "https://fcm.googleapis.com/fcm/send/2"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO push_messages (notification_id, source, payload, ttl) VALUES (?, ?, ?, ?)")).
+		WithArgs(7, "notification", string(payload), 60).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO push_deliveries (message_id, subscription_id, user_id, status, next_attempt_at) VALUES (?, ?, ?, 'pending', NOW()), (?, ?, ?, 'pending', NOW())")).
+		WithArgs(int64(3), 1, "user-1", int64(3), 2, "user-2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	messageID, err := r.EnqueuePushMessage(&notificationID, "notification", payload, 60, subs)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), messageID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPushDeliveryRepository_ClaimPushDeliveries(t *testing.T) {
+	columns := []string{"id", "message_id", "notification_id", "source", "user_id", "attempts", "payload", "ttl", "sub_id", "endpoint", "auth_key", "p256dh_key"}
+
+	t.Run("取り出した配信の試行回数を増やし次の試行時刻をずらす", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewPushDeliveryRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE OF d SKIP LOCKED")).
+			WithArgs(100).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(10, 3, 7, "notification", "user-1", 0, `{"title":"t"}`, 60, 1, "https://fcm.googleapis.com/fcm/send/1", "auth", "p256dh").
+				AddRow(11, 3, 7, "notification", "user-2", 2, `{"title":"t"}`, 60, nil, nil, nil, nil))
+		mock.ExpectExec(regexp.QuoteMeta("SET attempts = attempts + 1, next_attempt_at = DATE_ADD(NOW(), INTERVAL ? SECOND) WHERE id IN (?,?)")).
+			WithArgs(120, 10, 11).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		deliveries, err := r.ClaimPushDeliveries(100, 2*time.Minute)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		assert.Equal(t, 1, deliveries[0].Attempts)
+		require.NotNil(t, deliveries[0].NotificationID)
+		assert.Equal(t, 7, *deliveries[0].NotificationID)
+		require.NotNil(t, deliveries[0].Subscription)
+		assert.Equal(t, "user-1", deliveries[0].Subscription.UserID)
+		assert.Equal(t, []byte(`{"title":"t"}`), deliveries[0].Payload)
+		assert.Equal(t, 3, deliveries[1].Attempts)
+		assert.Nil(t, deliveries[1].Subscription)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("送る配信がなければ更新しない", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewPushDeliveryRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE OF d SKIP LOCKED")).
+			WithArgs(100).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectCommit()
+
+		deliveries, err := r.ClaimPushDeliveries(100, 2*time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, deliveries)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPushDeliveryRepository_CompleteAndRetry(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewPushDeliveryRepository(db)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE push_deliveries SET status = ?, last_status_code = ?, last_error = ? WHERE id = ?")).
+		WithArgs("sent", 201, nil, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("SET status = 'pending', next_attempt_at = DATE_ADD(NOW(), INTERVAL ? SECOND), last_status_code = ?, last_error = ?")).
+		WithArgs(60, nil, "*net.OpError", 11).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, r.CompletePushDelivery(10, models.PushDeliveryStatusSent, 201, ""))
+	require.NoError(t, r.RetryPushDelivery(11, time.Minute, 0, "*net.OpError"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPushDeliveryRepository_GetPushDeliveryStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewPushDeliveryRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE m.notification_id = ?")).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"total", "sent", "failed", "expired", "pending"}).AddRow(10, 6, 1, 2, 1))
+
+	stats, err := r.GetPushDeliveryStats(7)
+	require.NoError(t, err)
+	assert.Equal(t, models.PushDeliveryStats{NotificationID: 7, Total: 10, Sent: 6, Failed: 1, Expired: 2, Pending: 1}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
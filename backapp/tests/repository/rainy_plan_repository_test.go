@@ -0,0 +1,236 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rainyPlanForApply() *models.RainyPlan {
+	basketball := 2
+	maxCapacity := 5
+	return &models.RainyPlan{
+		EventID: 1,
+		Sports: []*models.RainySportPlan{
+			{
+				SportID:          1,
+				Action:           models.RainySportMerge,
+				MergeIntoSportID: &basketball,
+				CancelledMatches: []*models.RainyPlanMatch{{MatchID: 101}, {MatchID: 102}},
+				Teams: []*models.RainyPlanTeam{
+					{TeamID: 11, ClassID: 101, SportID: 1, Change: models.RainyTeamMerged, TargetSportID: &basketball},
+					{TeamID: 12, ClassID: 102, SportID: 1, Change: models.RainyTeamMerged, TargetSportID: &basketball},
+				},
+			},
+			{
+				SportID: 2,
+				Action:  models.RainySportKeep,
+				Teams: []*models.RainyPlanTeam{
+					{TeamID: 21, ClassID: 101, SportID: 2, Change: models.RainyTeamAdjusted, MaxCapacity: &maxCapacity, CapacityChanged: true},
+					{TeamName: "1-2", ClassID: 102, SportID: 2, Change: models.RainyTeamCreated},
+				},
+			},
+		},
+	}
+}
+
+func TestRainyModeRepository_ApplyRainyPlan(t *testing.T) {
+	t.Run("試合の中止・チームの統合・定員の変更を1つのトランザクションで行う", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewRainyModeRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_revisions")).
+			WithArgs(101, 102).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches m SET m.status = 'cancelled' WHERE m.id IN (?, ?) AND COALESCE(m.status, '') NOT IN ('finished', 'bye', 'cancelled')")).
+			WithArgs(101, 102).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_revisions")).
+			WithArgs(sqlmock.AnyArg(), models.MatchRevisionRainyPlan, "root-1", 101, 102).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		// 統合先にチームのないクラスはチームを作ってからメンバーを移す
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO teams (name, class_id, sport_id, min_capacity, max_capacity)")).
+			WithArgs("1-2", 102, 2, nil, nil).
+			WillReturnResult(sqlmock.NewResult(40, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM teams WHERE class_id = ? AND sport_id = ?")).
+			WithArgs(101, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO team_members")).
+			WithArgs(21, 11).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO team_members")).
+			WithArgs(40, 12).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE teams SET min_capacity = ?, max_capacity = ? WHERE id = ?")).
+			WithArgs(nil, 5, 21).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		// 対戦表のない競技は作ったチームをそのまま残す
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)")).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectCommit()
+
+		require.NoError(t, r.ApplyRainyPlan(1, rainyPlanForApply(), nil, "root-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("確認後に結果が入力された試合があれば何も変えない", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewRainyModeRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_revisions")).
+			WithArgs(101, 102).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches m SET m.status = 'cancelled'")).
+			WithArgs(101, 102).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectRollback()
+
+		err = r.ApplyRainyPlan(1, rainyPlanForApply(), nil, "root-1")
+		assert.ErrorIs(t, err, repository.ErrRainyPlanOutdated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+	t.Run("作ったチームを不戦勝の一回戦に入れ、不戦勝のチームを勝ち上がり先から外す", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewRainyModeRepository(db)
+
+		plan := rainyPlanForApply()
+		plan.Sports[1].ByeSeeds = []*models.RainyByeSeed{{MatchID: 201, ClassID: 102, TeamName: "1-2", OpponentName: "1-3"}}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_revisions")).
+			WithArgs(101, 102).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches m SET m.status = 'cancelled'")).
+			WithArgs(101, 102).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_revisions")).
+			WithArgs(sqlmock.AnyArg(), models.MatchRevisionRainyPlan, "root-1", 101, 102).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO teams (name, class_id, sport_id, min_capacity, max_capacity)")).
+			WithArgs("1-2", 102, 2, nil, nil).
+			WillReturnResult(sqlmock.NewResult(40, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM teams WHERE class_id = ? AND sport_id = ?")).
+			WithArgs(101, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO team_members")).
+			WithArgs(21, 11).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO team_members")).
+			WithArgs(40, 12).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE teams SET min_capacity = ?, max_capacity = ? WHERE id = ?")).
+			WithArgs(nil, 5, 21).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(status, ''), team1_id, next_match_id FROM matches WHERE id = ? FOR UPDATE")).
+			WithArgs(201).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "team1_id", "next_match_id"}).AddRow("bye", 22, 202))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT team1_id, team2_id, COALESCE(status, '')")).
+			WithArgs(202).
+			WillReturnRows(sqlmock.NewRows([]string{"team1_id", "team2_id", "status", "has_result"}).AddRow(22, 23, "pending", false))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_revisions")).
+			WithArgs(201, 202).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team2_id = ?, status = 'pending' WHERE id = ?")).
+			WithArgs(40, 201).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_id = ? WHERE id = ?")).
+			WithArgs(nil, 202).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_revisions")).
+			WithArgs(sqlmock.AnyArg(), models.MatchRevisionRainyPlan, "root-1", 201, 202).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		require.NoError(t, r.ApplyRainyPlan(1, plan, nil, "root-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("勝ち上がり先の試合に結果が入っていればチームを作らずに戻す", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewRainyModeRepository(db)
+
+		plan := rainyPlanForApply()
+		plan.Sports[1].ByeSeeds = []*models.RainyByeSeed{{MatchID: 201, ClassID: 102, TeamName: "1-2", OpponentName: "1-3"}}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_revisions")).
+			WithArgs(101, 102).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches m SET m.status = 'cancelled'")).
+			WithArgs(101, 102).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_revisions")).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO teams")).
+			WillReturnResult(sqlmock.NewResult(40, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM teams")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO team_members")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO team_members")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE teams SET min_capacity")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(status, ''), team1_id, next_match_id FROM matches")).
+			WithArgs(201).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "team1_id", "next_match_id"}).AddRow("bye", 22, 202))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT team1_id, team2_id, COALESCE(status, '')")).
+			WithArgs(202).
+			WillReturnRows(sqlmock.NewRows([]string{"team1_id", "team2_id", "status", "has_result"}).AddRow(22, 23, "finished", true))
+		mock.ExpectRollback()
+
+		err = r.ApplyRainyPlan(1, plan, nil, "root-1")
+		assert.ErrorIs(t, err, repository.ErrRainyPlanOutdated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("対戦表のある競技に入れ先のないチームは作らない", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewRainyModeRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_revisions")).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches m SET m.status = 'cancelled'")).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO match_revisions")).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO teams")).
+			WillReturnResult(sqlmock.NewResult(40, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM teams")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO team_members")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO team_members")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE teams SET min_capacity")).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)")).
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectRollback()
+
+		err = r.ApplyRainyPlan(1, rainyPlanForApply(), nil, "root-1")
+		assert.ErrorIs(t, err, repository.ErrRainyPlanOutdated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
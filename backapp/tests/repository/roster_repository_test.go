@@ -0,0 +1,120 @@
+package repository_test
+
+import (
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRosterRepository_SetRosterPhase(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewRosterRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT 1 FROM events WHERE id = ?")).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE events SET roster_phase = ? WHERE id = ?")).WithArgs(models.RosterPhaseLocked, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT 1 FROM events WHERE id = ?")).WithArgs(99).
+		WillReturnError(sql.ErrNoRows)
+
+	found, err := r.SetRosterPhase(1, models.RosterPhaseLocked)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	found, err = r.SetRosterPhase(99, models.RosterPhaseLocked)
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRosterRepository_CreateChangeRequests(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewRosterRepository(db)
+
+	requestedBy := "admin-1"
+	insertQ := "INSERT INTO roster_change_requests (event_id, class_id, sport_id, user_id, action, reason, status, requested_by)"
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(insertQ)).
+		WithArgs(1, 10, 2, "user-1", models.RosterChangeActionAdd, "けが人の代わり", models.RosterChangeRequestStatusPending, &requestedBy).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec(regexp.QuoteMeta(insertQ)).
+		WithArgs(1, 10, 2, "user-2", models.RosterChangeActionAdd, "けが人の代わり", models.RosterChangeRequestStatusPending, &requestedBy).
+		WillReturnResult(sqlmock.NewResult(8, 1))
+	mock.ExpectCommit()
+
+	requests := []*models.RosterChangeRequest{
+		{EventID: 1, ClassID: 10, SportID: 2, UserID: "user-1", Action: models.RosterChangeActionAdd, Reason: "けが人の代わり", RequestedBy: &requestedBy},
+		{EventID: 1, ClassID: 10, SportID: 2, UserID: "user-2", Action: models.RosterChangeActionAdd, Reason: "けが人の代わり", RequestedBy: &requestedBy},
+	}
+	require.NoError(t, r.CreateChangeRequests(requests))
+	assert.Equal(t, 7, requests[0].ID)
+	assert.Equal(t, 8, requests[1].ID)
+	assert.Equal(t, models.RosterChangeRequestStatusPending, requests[1].Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRosterRepository_ListChangeRequests(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewRosterRepository(db)
+
+	createdAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	reviewedAt := createdAt.Add(time.Hour)
+	columns := []string{"id", "event_id", "class_id", "sport_id", "user_id", "action", "reason", "status",
+		"requested_by", "reviewed_by", "review_note", "reviewed_at", "created_at", "class_name", "sport_name", "email"}
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE r.event_id = ? ORDER BY r.created_at DESC, r.id DESC")).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(8, 1, 10, 2, "user-2", "remove", "転校", "pending", "admin-1", nil, nil, nil, createdAt, "1A", "Basketball", "s2301060@example.com").
+			AddRow(7, 1, 10, 2, "user-1", "add", "けが人の代わり", "approved", "admin-1", "root-1", "了承", reviewedAt, createdAt, "1A", "Basketball", "s2301059@example.com"))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE r.event_id = ? AND r.status = ?")).WithArgs(1, models.RosterChangeRequestStatusPending).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	requests, err := r.ListChangeRequests(1, "")
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+	assert.Equal(t, models.RosterChangeActionRemove, requests[0].Action)
+	assert.Nil(t, requests[0].ReviewedBy)
+	assert.Equal(t, "root-1", *requests[1].ReviewedBy)
+	assert.Equal(t, "了承", *requests[1].ReviewNote)
+	assert.Equal(t, reviewedAt, *requests[1].ReviewedAt)
+
+	requests, err = r.ListChangeRequests(1, models.RosterChangeRequestStatusPending)
+	require.NoError(t, err)
+	assert.Empty(t, requests)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRosterRepository_ResolvePendingChangeRequest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewRosterRepository(db)
+
+	q := "WHERE id = ? AND status = 'pending'"
+	mock.ExpectExec(regexp.QuoteMeta(q)).WithArgs(models.RosterChangeRequestStatusApproved, "root-1", nil, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(q)).WithArgs(models.RosterChangeRequestStatusRejected, "root-1", nil, 7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	resolved, err := r.ResolvePendingChangeRequest(7, models.RosterChangeRequestStatusApproved, "root-1", nil)
+	require.NoError(t, err)
+	assert.True(t, resolved)
+
+	resolved, err = r.ResolvePendingChangeRequest(7, models.RosterChangeRequestStatusRejected, "root-1", nil)
+	require.NoError(t, err)
+	assert.False(t, resolved)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,78 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundCheckInScanRepository_GetScanResult(t *testing.T) {
+	const q = "SELECT status, http_status, body FROM round_check_in_scans WHERE idempotency_key = ?"
+
+	t.Run("処理済みの結果を返す", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewRoundCheckInScanRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(q)).WithArgs("scan-1").
+			WillReturnRows(sqlmock.NewRows([]string{"status", "http_status", "body"}).
+				AddRow(models.RoundCheckInScanStatusAlreadyCheckedIn, 409, []byte(`{"already_checked_in":true}`)))
+
+		result, err := r.GetScanResult("scan-1")
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.Equal(t, "scan-1", result.IdempotencyKey)
+		assert.Equal(t, models.RoundCheckInScanStatusAlreadyCheckedIn, result.Status)
+		assert.Equal(t, 409, result.HTTPStatus)
+		assert.Equal(t, true, result.Body["already_checked_in"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("未処理なら nil", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewRoundCheckInScanRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(q)).WithArgs("scan-2").
+			WillReturnRows(sqlmock.NewRows([]string{"status", "http_status", "body"}))
+
+		result, err := r.GetScanResult("scan-2")
+		require.NoError(t, err)
+		assert.Nil(t, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRoundCheckInScanRepository_SaveScanResult(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewRoundCheckInScanRepository(db)
+
+	scannedAt := time.Date(2026, 5, 20, 9, 15, 0, 0, time.UTC)
+	scan := &models.RoundCheckInScan{
+		IdempotencyKey:        "scan-1",
+		BarcodeCheckInRequest: models.BarcodeCheckInRequest{BarcodeData: "H1023010590"},
+	}
+	result := &models.RoundCheckInScanResult{
+		Status:     models.RoundCheckInScanStatusCheckedIn,
+		HTTPStatus: 200,
+		Body:       map[string]interface{}{"checked_in": true},
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO round_check_in_scans (idempotency_key, scanned_by, barcode_data, scanned_at, status, http_status, body)")).
+		WithArgs("scan-1", "admin-1", "H1023010590", scannedAt, models.RoundCheckInScanStatusCheckedIn, 200, []byte(`{"checked_in":true}`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, r.SaveScanResult("admin-1", scan, scannedAt, result))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
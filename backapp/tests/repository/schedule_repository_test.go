@@ -0,0 +1,145 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleRepository_Config(t *testing.T) {
+	t.Run("未保存ならnil", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScheduleRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT config FROM match_schedule_configs WHERE event_id = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"config"}))
+
+		config, err := r.GetConfig(1)
+		require.NoError(t, err)
+		assert.Nil(t, config)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("保存された設定を返す", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScheduleRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT config FROM match_schedule_configs WHERE event_id = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"config"}).AddRow(
+				`{"start_time":"2026-05-20 09:00:00","default_slot_minutes":30,"venues":[{"location":"gym1","courts":["A","B"]}],"sports":[{"sport_id":2,"slot_minutes":20}]}`,
+			))
+
+		config, err := r.GetConfig(1)
+		require.NoError(t, err)
+		require.NotNil(t, config)
+		assert.Equal(t, 1, config.EventID)
+		assert.Equal(t, []string{"A", "B"}, config.CourtsFor("gym1"))
+		assert.Equal(t, 20*time.Minute, config.SlotFor(2))
+		assert.Equal(t, 30*time.Minute, config.SlotFor(3))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("保存する", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScheduleRepository(db)
+
+		mock.ExpectExec(regexp.QuoteMeta(`
+			INSERT INTO match_schedule_configs (event_id, config, updated_by)
+			VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE config = VALUES(config), updated_by = VALUES(updated_by)
+		`)).
+			WithArgs(1, sqlmock.AnyArg(), "root-user").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, r.SaveConfig(models.ScheduleConfig{EventID: 1, StartTime: "2026-05-20 09:00:00", DefaultSlotMinutes: 30}, "root-user"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestScheduleRepository_GetMatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewScheduleRepository(db)
+
+	start := time.Date(2026, 5, 20, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tournament_id", "sport_id", "location", "round", "match_number_in_round", "class1", "class2", "status", "match_start_time", "rainy_mode_start_time", "court_number"}).
+			AddRow(1, 10, 2, "gym1", 0, 0, 101, 102, "scheduled", start, "2026-05-20 10:00:00", "A").
+			AddRow(2, 10, 2, "gym1", 1, 0, nil, nil, "pending", nil, "", ""))
+	mock.ExpectQuery(regexp.QuoteMeta("JOIN team_members tm ON tm.team_id = m.team1_id OR tm.team_id = m.team2_id")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).
+			AddRow(1, "student-a").
+			AddRow(1, "student-b"))
+
+	matches, err := r.GetMatches(1)
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, []int{101, 102}, matches[0].ClassIDs)
+	assert.Equal(t, []string{"student-a", "student-b"}, matches[0].StudentIDs)
+	assert.Equal(t, start, *matches[0].StartTime)
+	assert.Equal(t, start.Add(time.Hour), *matches[0].RainyModeStartTime)
+	assert.Equal(t, "A", matches[0].Court)
+	assert.Empty(t, matches[1].ClassIDs)
+	assert.Empty(t, matches[1].StudentIDs)
+	assert.Nil(t, matches[1].StartTime)
+	assert.Nil(t, matches[1].RainyModeStartTime)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScheduleRepository_ApplyAssignments(t *testing.T) {
+	t.Run("まとめて更新する", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScheduleRepository(db)
+
+		updateSQL := regexp.QuoteMeta("UPDATE matches SET match_start_time = ?, court_number = ?, status = CASE WHEN status = 'pending' THEN 'scheduled' ELSE status END WHERE id = ?")
+		mock.ExpectBegin()
+		expectMatchBaselines(mock, 1, 2)
+		mock.ExpectExec(updateSQL).WithArgs("2026-05-20 09:00:00", "A", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(updateSQL).WithArgs("2026-05-20 09:30:00", "B", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+		expectMatchRevisions(mock, "schedule", "root-user", 1, 2)
+		mock.ExpectCommit()
+
+		err = r.ApplyAssignments([]models.ScheduleAssignment{
+			{MatchID: 1, StartTime: "2026-05-20 09:00:00", Court: "A"},
+			{MatchID: 2, StartTime: "2026-05-20 09:30:00", Court: "B"},
+		}, "root-user")
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("途中で失敗したら戻す", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScheduleRepository(db)
+
+		mock.ExpectBegin()
+		expectMatchBaselines(mock, 1)
+		mock.ExpectExec("UPDATE matches SET match_start_time").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		err = r.ApplyAssignments([]models.ScheduleAssignment{{MatchID: 1, StartTime: "2026-05-20 09:00:00", Court: "A"}}, "root-user")
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,170 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var scheduledNotificationColumns = []string{
+	"id", "title", "body", "type", "target_roles", "targets", "channel", "send_at", "repeat_interval_minutes", "repeat_until",
+	"status", "sent_count", "last_notification_id", "created_by", "created_at", "updated_at",
+}
+
+func TestScheduledNotificationRepository_CreateScheduledNotification(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewScheduledNotificationRepository(db)
+
+	sendAt := time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO scheduled_notifications")).
+		WithArgs("開会式", "本文", "general", `["admin","student"]`, nil, "push", sendAt, nil, nil, "root-1").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+
+	id, err := r.CreateScheduledNotification(&models.ScheduledNotification{
+		Title: "開会式", Body: "本文", Type: "general", TargetRoles: []string{"admin", "student"}, Channel: "push", SendAt: sendAt, CreatedBy: "root-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScheduledNotificationRepository_Targets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewScheduledNotificationRepository(db)
+
+	sendAt := time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC)
+	classID := 12
+	targetsJSON := `[{"type":"class","id":12}]`
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO scheduled_notifications")).
+		WithArgs("集合", "本文", "general", "[]", targetsJSON, "push_email_fallback", sendAt, nil, nil, "root-1").
+		WillReturnResult(sqlmock.NewResult(6, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM scheduled_notifications WHERE id = ?")).WithArgs(6).
+		WillReturnRows(sqlmock.NewRows(scheduledNotificationColumns).
+			AddRow(6, "集合", "本文", "general", "[]", targetsJSON, "push_email_fallback", sendAt, nil, nil, "pending", 0, nil, "root-1", sendAt, sendAt))
+
+	_, err = r.CreateScheduledNotification(&models.ScheduledNotification{
+		Title: "集合", Body: "本文", Type: "general", Channel: "push_email_fallback", SendAt: sendAt, CreatedBy: "root-1",
+		Targets: []models.NotificationTarget{{Type: models.NotificationTargetClass, ID: &classID}},
+	})
+	require.NoError(t, err)
+
+	n, err := r.GetScheduledNotification(6)
+	require.NoError(t, err)
+	require.Len(t, n.Targets, 1)
+	assert.Equal(t, models.NotificationTargetClass, n.Targets[0].Type)
+	assert.Equal(t, classID, *n.Targets[0].ID)
+	assert.Empty(t, n.TargetRoles)
+	assert.Equal(t, "push_email_fallback", n.Channel)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScheduledNotificationRepository_ClaimDueScheduledNotifications(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewScheduledNotificationRepository(db)
+
+	now := time.Date(2025, 5, 20, 9, 0, 30, 0, time.UTC)
+	created := time.Date(2025, 5, 19, 0, 0, 0, 0, time.UTC)
+	repeatUntil := time.Date(2025, 5, 21, 23, 59, 0, 0, time.UTC)
+
+	lease := 5 * time.Minute
+	claimQ := "UPDATE scheduled_notifications SET status = 'sending', claimed_until = ? WHERE id = ?"
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE (status = 'pending' AND send_at <= ?) OR (status = 'sending' AND claimed_until <= ?)")).
+		WithArgs(now, now).
+		WillReturnRows(sqlmock.NewRows(scheduledNotificationColumns).
+			// 1回だけの予約
+			AddRow(1, "開会式", "本文", "general", `["student"]`, nil, "push", time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC), nil, nil, "pending", 0, nil, "root-1", created, created).
+			// 停止中に送信日時を大きく過ぎた予約は送らない
+			AddRow(2, "朝の連絡", "本文", "general", `["student"]`, nil, "push", time.Date(2025, 5, 20, 7, 0, 0, 0, time.UTC), nil, nil, "pending", 0, nil, "root-1", created, created).
+			// 毎日の予約も送り終えるまでは次の回に進めない
+			AddRow(3, "昼休み", "本文", "general", `["student"]`, nil, "push", time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC), 1440, repeatUntil, "pending", 0, nil, "root-1", created, created).
+			// 送信中に止まったサーバーが取り出した予約は取り出し直す
+			AddRow(4, "集合", "本文", "general", `["student"]`, nil, "push", time.Date(2025, 5, 20, 8, 50, 0, 0, time.UTC), nil, nil, "sending", 0, nil, "root-1", created, created))
+	mock.ExpectExec(regexp.QuoteMeta(claimQ)).WithArgs(now.Add(lease), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE scheduled_notifications SET status = ?, send_at = ?, claimed_until = NULL WHERE id = ?")).
+		WithArgs("missed", time.Date(2025, 5, 20, 7, 0, 0, 0, time.UTC), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(claimQ)).WithArgs(now.Add(lease), 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(claimQ)).WithArgs(now.Add(lease), 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	claimed, err := r.ClaimDueScheduledNotifications(now, 30*time.Minute, lease)
+	require.NoError(t, err)
+	require.Len(t, claimed, 3)
+	assert.Equal(t, 1, claimed[0].ID)
+	assert.Equal(t, []string{"student"}, claimed[0].TargetRoles)
+	assert.Equal(t, models.ScheduledNotificationStatusSending, claimed[0].Status)
+	assert.Equal(t, 3, claimed[1].ID)
+	// 返すのは送る回の内容
+	assert.Equal(t, time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC), claimed[1].SendAt)
+	assert.Equal(t, 4, claimed[2].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScheduledNotificationRepository_CompleteScheduledNotification(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewScheduledNotificationRepository(db)
+
+	next := time.Date(2025, 5, 21, 9, 0, 0, 0, time.UTC)
+	mock.ExpectExec(regexp.QuoteMeta("SET status = ?, send_at = ?, sent_count = sent_count + 1, last_notification_id = ?, claimed_until = NULL")).
+		WithArgs("pending", next, 42, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE scheduled_notifications SET status = 'pending', claimed_until = NULL WHERE id = ? AND status = 'sending'")).
+		WithArgs(4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, r.CompleteScheduledNotification(&models.ScheduledNotification{ID: 3, Status: "pending", SendAt: next}, 42))
+	require.NoError(t, r.ReleaseScheduledNotification(4))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScheduledNotificationRepository_CancelScheduledNotification(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewScheduledNotificationRepository(db)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE scheduled_notifications SET status = 'cancelled' WHERE id = ? AND status = 'pending'")).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	cancelled, err := r.CancelScheduledNotification(5)
+	require.NoError(t, err)
+	assert.False(t, cancelled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScheduledNotification_Advance(t *testing.T) {
+	interval := 60
+	until := time.Date(2025, 5, 20, 11, 0, 0, 0, time.UTC)
+	n := &models.ScheduledNotification{
+		SendAt:                time.Date(2025, 5, 20, 10, 0, 0, 0, time.UTC),
+		RepeatIntervalMinutes: &interval,
+		RepeatUntil:           &until,
+		Status:                models.ScheduledNotificationStatusPending,
+		SentCount:             1,
+	}
+
+	// 最後の回を送ったら送信済みにする
+	assert.True(t, n.Advance(time.Date(2025, 5, 20, 10, 0, 5, 0, time.UTC), 30*time.Minute))
+	assert.Equal(t, time.Date(2025, 5, 20, 11, 0, 0, 0, time.UTC), n.SendAt)
+	assert.Equal(t, models.ScheduledNotificationStatusPending, n.Status)
+
+	assert.True(t, n.Advance(time.Date(2025, 5, 20, 11, 0, 5, 0, time.UTC), 30*time.Minute))
+	assert.Equal(t, models.ScheduledNotificationStatusSent, n.Status)
+}
@@ -0,0 +1,109 @@
+package repository_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// 得点台帳の書き込みで共通に使われるSQL
+const (
+	activeScoreLogCond    = "l.reverses_log_id IS NULL AND NOT EXISTS (SELECT 1 FROM score_logs r WHERE r.reverses_log_id = l.id)"
+	insertScoreLogSQL     = "INSERT INTO score_logs (event_id, class_id, points, reason, source_match_id, operation, actor_user_id) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	activeClassPointsSQL  = "SELECT COUNT(*), SUM(l.points) FROM score_logs l WHERE l.event_id = ? AND l.class_id = ? AND l.reason = ? AND " + activeScoreLogCond
+	activeEventClassesSQL = "SELECT DISTINCT l.class_id FROM score_logs l WHERE l.event_id = ? AND l.reason = ? AND " + activeScoreLogCond
+)
+
+func reverseScoreLogsSQL(condition string) string {
+	return `INSERT INTO score_logs (event_id, class_id, points, reason, source_match_id, operation, actor_user_id, reverses_log_id)
+		SELECT l.event_id, l.class_id, -l.points, l.reason, l.source_match_id, ?, ?, l.id
+		FROM score_logs l
+		WHERE ` + condition + ` AND ` + activeScoreLogCond
+}
+
+func TestScoreLogRepository_GetClassScoreLogs(t *testing.T) {
+	const selectLogs = `
+		SELECT l.id, l.event_id, l.class_id, l.points, l.reason, l.operation, l.source_match_id,
+		       l.actor_user_id, u.display_name, l.reverses_log_id, rv.id, l.created_at
+		FROM score_logs l
+		LEFT JOIN users u ON u.id = l.actor_user_id
+		LEFT JOIN score_logs rv ON rv.reverses_log_id = l.id
+		WHERE %s
+		ORDER BY l.created_at, l.id`
+	cols := []string{"id", "event_id", "class_id", "points", "reason", "operation", "source_match_id",
+		"actor_user_id", "display_name", "reverses_log_id", "reversed_by", "created_at"}
+
+	t.Run("success - returns original and reversal entries", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		repo := repository.NewScoreLogRepository(db)
+
+		now := time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)
+		query := regexp.QuoteMeta(fmt.Sprintf(selectLogs, "l.event_id = ? AND l.class_id = ?"))
+		mock.ExpectQuery(query).
+			WithArgs(1, 10).
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow(1, 1, 10, 10, "attendance_points", "attendance", nil, "user-1", "Alice", nil, 2, now).
+				AddRow(2, 1, 10, -10, "attendance_points", "attendance", nil, "user-1", "Alice", 1, nil, now).
+				AddRow(3, 1, 10, 9, "attendance_points", "attendance", nil, nil, nil, nil, nil, now))
+
+		logs, err := repo.GetClassScoreLogs(1, 10, models.ScoreLogFilter{})
+		assert.NoError(t, err)
+		assert.Len(t, logs, 3)
+		assert.Equal(t, 2, *logs[0].ReversedByLogID)
+		assert.Nil(t, logs[0].ReversesLogID)
+		assert.Equal(t, "Alice", *logs[0].ActorDisplayName)
+		assert.Equal(t, 1, *logs[1].ReversesLogID)
+		assert.Nil(t, logs[2].ActorUserID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("success - applies all filters", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		repo := repository.NewScoreLogRepository(db)
+
+		from := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC)
+		query := regexp.QuoteMeta(fmt.Sprintf(selectLogs,
+			"l.event_id = ? AND l.class_id = ? AND l.reason = ? AND l.operation = ? AND l.source_match_id = ? AND l.created_at >= ? AND l.created_at < ?"))
+		mock.ExpectQuery(query).
+			WithArgs(1, 10, "win_round_1", "match_correction", 5, from, to).
+			WillReturnRows(sqlmock.NewRows(cols))
+
+		logs, err := repo.GetClassScoreLogs(1, 10, models.ScoreLogFilter{
+			Reason:        "win_round_1",
+			Operation:     "match_correction",
+			SourceMatchID: 5,
+			From:          &from,
+			To:            &to,
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, logs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("error - query fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		repo := repository.NewScoreLogRepository(db)
+
+		mock.ExpectQuery("SELECT l.id").WillReturnError(errors.New("db error"))
+
+		logs, err := repo.GetClassScoreLogs(1, 10, models.ScoreLogFilter{})
+		assert.Error(t, err)
+		assert.Nil(t, logs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,136 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScoreboardRepository_Token(t *testing.T) {
+	t.Run("未発行なら空文字列", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScoreboardRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT token_hash FROM scoreboard_tokens WHERE event_id = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"token_hash"}))
+
+		hash, err := r.GetTokenHash(1)
+		require.NoError(t, err)
+		assert.Equal(t, "", hash)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("発行済みのハッシュを返す", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScoreboardRepository(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT token_hash FROM scoreboard_tokens WHERE event_id = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"token_hash"}).AddRow("abc"))
+
+		hash, err := r.GetTokenHash(1)
+		require.NoError(t, err)
+		assert.Equal(t, "abc", hash)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("発行済みのトークンを置き換える", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScoreboardRepository(db)
+
+		mock.ExpectExec(regexp.QuoteMeta(`
+			INSERT INTO scoreboard_tokens (event_id, token_hash, created_by)
+			VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE token_hash = VALUES(token_hash), created_by = VALUES(created_by), created_at = CURRENT_TIMESTAMP
+		`)).
+			WithArgs(1, "abc", nil).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		require.NoError(t, r.SetTokenHash(1, "abc", ""))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("失効させる", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScoreboardRepository(db)
+
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM scoreboard_tokens WHERE event_id = ?")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		require.NoError(t, r.DeleteToken(1))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestScoreboardRepository_GetMatch(t *testing.T) {
+	query := regexp.QuoteMeta("FROM matches m JOIN tournaments t ON m.tournament_id = t.id") + `[\s\S]*WHERE m\.id = \?`
+	cols := []string{"event_id", "tournament_id", "sport_id", "id", "round", "team1_id", "t1_name", "team2_id", "t2_name",
+		"team1_score", "team2_score", "winner_team_id", "status", "result_type", "next_match_id"}
+
+	t.Run("結果と次の試合を返す", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScoreboardRepository(db)
+
+		mock.ExpectQuery(query).WithArgs(5).
+			WillReturnRows(sqlmock.NewRows(cols).AddRow(1, 7, 2, 5, 0, 10, "1-1", 11, "1-2", 3, 1, 10, "finished", "normal", 9))
+
+		match, err := r.GetMatch(5)
+		require.NoError(t, err)
+		require.NotNil(t, match)
+		assert.Equal(t, 1, match.EventID)
+		assert.Equal(t, "1-1", *match.Team1Name)
+		assert.Equal(t, 10, *match.WinnerID)
+		assert.Equal(t, 9, *match.NextMatchID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("未確定の試合", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScoreboardRepository(db)
+
+		mock.ExpectQuery(query).WithArgs(9).
+			WillReturnRows(sqlmock.NewRows(cols).AddRow(1, 7, 2, 9, 1, 10, "1-1", nil, nil, nil, nil, nil, "pending", "normal", nil))
+
+		match, err := r.GetMatch(9)
+		require.NoError(t, err)
+		require.NotNil(t, match)
+		assert.Nil(t, match.Team2ID)
+		assert.Nil(t, match.Team2Name)
+		assert.Nil(t, match.WinnerID)
+		assert.Nil(t, match.NextMatchID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("試合がなければnil", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScoreboardRepository(db)
+
+		mock.ExpectQuery(query).WithArgs(99).WillReturnRows(sqlmock.NewRows(cols))
+
+		match, err := r.GetMatch(99)
+		require.NoError(t, err)
+		assert.Nil(t, match)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,272 @@
+package repository_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const selectScoringRulesSQL = "SELECT rules FROM scoring_rule_sets WHERE event_id = ? ORDER BY version DESC LIMIT 1"
+
+// expectScoringRules は配点ルールの読み込みを期待する。rules が nil の場合は保存されたルールがない扱いになる。
+// 得点の再計算で出席点・アンケート得点・MIC得点を付け直すときのSQL
+const (
+	reverseClassCond       = "l.event_id = ? AND l.class_id = ? AND l.reason = ?"
+	recalcAttendanceSQL    = "SELECT c.id, c.name, c.student_count, c.attend_count\\s+FROM classes c"
+	recalcSurveyMissingSQL = "c.survey_submission_count IS NULL"
+	recalcSurveySQL        = "SELECT id, student_count, survey_submission_count FROM classes WHERE event_id = ? AND survey_submission_count IS NOT NULL ORDER BY id"
+	recalcMICSQL           = "SELECT voted_for_class_id, COUNT(*) FROM mic_votes WHERE event_id = ? GROUP BY voted_for_class_id"
+)
+
+func expectScoringRules(mock sqlmock.Sqlmock, eventID int, rules map[string]interface{}) {
+	rows := sqlmock.NewRows([]string{"rules"})
+	if rules != nil {
+		raw, _ := json.Marshal(rules)
+		rows.AddRow(raw)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(selectScoringRulesSQL)).WithArgs(eventID).WillReturnRows(rows)
+}
+
+func TestScoringRuleRepository_GetScoringRules(t *testing.T) {
+	t.Run("保存されたルールがなければ従来の配点", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScoringRuleRepository(db)
+
+		expectScoringRules(mock, 1, nil)
+
+		rules, err := r.GetScoringRules(1)
+		require.NoError(t, err)
+		assert.Equal(t, models.DefaultScoringRules(), rules)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("保存されていない項目は従来の配点で補う", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewScoringRuleRepository(db)
+
+		expectScoringRules(mock, 1, map[string]interface{}{"championPoints": 100, "micPoints": 5})
+
+		rules, err := r.GetScoringRules(1)
+		require.NoError(t, err)
+		assert.Equal(t, 100, rules.ChampionPoints)
+		assert.Equal(t, 5, rules.MICPoints)
+		assert.Equal(t, 60, rules.RunnerUpPoints)
+		assert.Equal(t, 10, rules.Attendance.PointsFor(0.95))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestScoringRuleRepository_CreateRuleSet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewScoringRuleRepository(db)
+
+	rules := models.DefaultScoringRules()
+	rules.MICPoints = 4
+	raw, err := json.Marshal(rules)
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(MAX(version), 0) FROM scoring_rule_sets WHERE event_id = ? FOR UPDATE")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO scoring_rule_sets (event_id, version, rules, created_by) VALUES (?, ?, ?, ?)")).
+		WithArgs(1, 3, raw, "root-user").
+		WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectCommit()
+
+	set, err := r.CreateRuleSet(1, rules, "root-user")
+	require.NoError(t, err)
+	assert.Equal(t, 9, set.ID)
+	assert.Equal(t, 3, set.Version)
+	require.NotNil(t, set.CreatedBy)
+	assert.Equal(t, "root-user", *set.CreatedBy)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScoringRuleRepository_ListRuleSets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewScoringRuleRepository(db)
+
+	createdAt := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, event_id, version, rules, created_by, created_at
+		FROM scoring_rule_sets
+		WHERE event_id = ?
+		ORDER BY version DESC
+	`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "version", "rules", "created_by", "created_at"}).
+			AddRow(2, 1, 2, []byte(`{"micPoints":4}`), "root-user", createdAt).
+			AddRow(1, 1, 1, []byte(`{}`), nil, createdAt))
+
+	sets, err := r.ListRuleSets(1)
+	require.NoError(t, err)
+	require.Len(t, sets, 2)
+	assert.Equal(t, 2, sets[0].Version)
+	assert.Equal(t, 4, sets[0].Rules.MICPoints)
+	assert.Equal(t, 80, sets[0].Rules.ChampionPoints)
+	assert.Nil(t, sets[1].CreatedBy)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTournamentRepository_RecalculateScores(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewTournamentRepository(db)
+
+	insertScoreLog := regexp.QuoteMeta(insertScoreLogSQL)
+	selectTeam := regexp.QuoteMeta("SELECT t.id, t.name, t.class_id, t.sport_id, c.event_id FROM teams t JOIN classes c ON t.class_id = c.id WHERE t.id = ?")
+	teamCols := []string{"id", "name", "class_id", "sport_id", "event_id"}
+	selectMetadata := regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT
+			m.id,
+			m.tournament_id,
+			m.round,
+			m.match_number_in_round,
+			m.team1_id,
+			m.team2_id,
+			m.team1_score,
+			m.team2_score,
+			CASE
+				WHEN m.forfeiting_team_id = m.team1_id THEN m.team2_id
+				WHEN m.forfeiting_team_id = m.team2_id THEN m.team1_id
+				WHEN m.result_type = 'walkover' AND m.forfeiting_team_id IS NULL THEN COALESCE(m.team1_id, m.team2_id)
+				WHEN m.team1_score > m.team2_score THEN m.team1_id
+				WHEN m.team2_score > m.team1_score THEN m.team2_id
+				ELSE NULL
+			END AS winner_team_id,
+			m.status,
+			m.next_match_id,
+			m.is_bronze_match,
+			m.is_loser_bracket_match,
+			m.loser_bracket_round,
+			m.is_league_match,
+			m.result_type,
+			m.forfeiting_team_id,
+			es.location
+		FROM matches m
+		JOIN tournaments t ON m.tournament_id = t.id
+		LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id
+		WHERE t.event_id = ?
+		ORDER BY m.tournament_id, m.round, m.match_number_in_round
+	`)).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "team1_score", "team2_score", "winner_team_id", "status", "next_match_id", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "is_league_match", "result_type", "forfeiting_team_id", "location"}).
+			AddRow(1, 7, 0, 0, 1, 2, 2, 1, 1, "finished", 3, false, false, nil, false, "normal", nil, "gym1").
+			AddRow(2, 7, 0, 1, 3, 4, 1, 1, nil, "finished", 3, false, false, nil, false, "normal", nil, "gym1").
+			AddRow(3, 7, 1, 0, 1, 4, nil, nil, nil, "pending", nil, false, false, nil, false, "normal", nil, "gym1").
+			AddRow(4, 8, 0, 0, 5, 6, 0, 0, nil, "finished", nil, false, false, nil, true, "normal", nil, "ground"))
+
+	// 同点の試合は次の試合に進んだチームを勝者とする
+	mock.ExpectQuery(regexp.QuoteMeta(getLeagueMatchByIDSQL)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(leagueMatchCols).
+			AddRow(3, 7, 1, 0, 1, 4, nil, "pending", nil, "", false, false, nil, nil, nil, false, nil, "normal", nil))
+
+	mock.ExpectExec(regexp.QuoteMeta(reverseScoreLogsSQL("l.event_id = ? AND l.source_match_id IS NOT NULL"))).
+		WithArgs("recalculation", "user-1", 1).
+		WillReturnResult(sqlmock.NewResult(0, 6))
+
+	expectScoringRules(mock, 1, map[string]interface{}{"roundWinPoints": []int{20, 15, 10}, "leagueDrawPoints": 3, "micPoints": 5})
+
+	mock.ExpectQuery(selectMetadata).WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 2, "gym1"))
+	mock.ExpectQuery(selectTeam).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows(teamCols).AddRow(1, "IE1", 101, 2, 1))
+	mock.ExpectQuery(selectTeam).WithArgs(int64(2)).WillReturnRows(sqlmock.NewRows(teamCols).AddRow(2, "IS1", 102, 2, 1))
+	mock.ExpectExec(insertScoreLog).WithArgs(1, 101, 20, "gym1_win1_points", 1, "recalculation", "user-1").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectQuery(selectMetadata).WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 2, "gym1"))
+	mock.ExpectQuery(selectTeam).WithArgs(int64(4)).WillReturnRows(sqlmock.NewRows(teamCols).AddRow(4, "IS2", 104, 2, 1))
+	mock.ExpectQuery(selectTeam).WithArgs(int64(3)).WillReturnRows(sqlmock.NewRows(teamCols).AddRow(3, "IE2", 103, 2, 1))
+	mock.ExpectExec(insertScoreLog).WithArgs(1, 104, 20, "gym1_win1_points", 2, "recalculation", "user-1").WillReturnResult(sqlmock.NewResult(2, 1))
+
+	mock.ExpectQuery(selectTeam).WithArgs(int64(5)).WillReturnRows(sqlmock.NewRows(teamCols).AddRow(5, "IT1", 105, 3, 1))
+	mock.ExpectQuery(selectTeam).WithArgs(int64(6)).WillReturnRows(sqlmock.NewRows(teamCols).AddRow(6, "IT2", 106, 3, 1))
+	mock.ExpectExec(insertScoreLog).WithArgs(1, 105, 3, "ground_league_points", 4, "recalculation", "user-1").WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec(insertScoreLog).WithArgs(1, 106, 3, "ground_league_points", 4, "recalculation", "user-1").WillReturnResult(sqlmock.NewResult(4, 1))
+
+	// 出席点は記録済みの出席者数から付け直す。専教は0点のまま
+	mock.ExpectQuery(recalcAttendanceSQL).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "student_count", "attend_count"}).
+			AddRow(101, "IE1", 40, 36).
+			AddRow(109, "専教", 10, 10))
+	mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).WithArgs(1, 101, "attendance_points").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(1, 9))
+	mock.ExpectExec(regexp.QuoteMeta(reverseScoreLogsSQL(reverseClassCond))).
+		WithArgs("recalculation", "user-1", 1, 101, "attendance_points").WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec(insertScoreLog).WithArgs(1, 101, 10, "attendance_points", nil, "recalculation", "user-1").WillReturnResult(sqlmock.NewResult(6, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).WithArgs(1, 109, "attendance_points").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(1, 0))
+
+	// アンケート得点は取り込み時の提出数から付け直す
+	mock.ExpectQuery(recalcSurveyMissingSQL).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta(recalcSurveySQL)).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "student_count", "survey_submission_count"}).AddRow(101, 40, 20))
+	mock.ExpectQuery(regexp.QuoteMeta(activeEventClassesSQL)).WithArgs(1, "survey_points").
+		WillReturnRows(sqlmock.NewRows([]string{"class_id"}).AddRow(101))
+	mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).WithArgs(1, 101, "survey_points").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(1, 6))
+
+	// MIC得点は得票数と現在の1票あたりの得点から付け直す
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE mic_votes SET points = ? WHERE event_id = ?")).WithArgs(5, 1).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(regexp.QuoteMeta(recalcMICSQL)).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"voted_for_class_id", "count"}).AddRow(102, 2))
+	mock.ExpectQuery(regexp.QuoteMeta(activeEventClassesSQL)).WithArgs(1, "mic_points").
+		WillReturnRows(sqlmock.NewRows([]string{"class_id"}).AddRow(102))
+	mock.ExpectQuery(regexp.QuoteMeta(activeClassPointsSQL)).WithArgs(1, 102, "mic_points").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(2, 6))
+	mock.ExpectExec(regexp.QuoteMeta(reverseScoreLogsSQL(reverseClassCond))).
+		WithArgs("recalculation", "user-1", 1, 102, "mic_points").WillReturnResult(sqlmock.NewResult(7, 2))
+	mock.ExpectExec(insertScoreLog).WithArgs(1, 102, 10, "mic_points", nil, "recalculation", "user-1").WillReturnResult(sqlmock.NewResult(8, 1))
+	mock.ExpectCommit()
+
+	result, err := r.RecalculateScores(1, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, &models.ScoreRecalculationResult{EventID: 1, ReplayedMatches: 3, AttendanceClasses: 2, SurveyClasses: 1, MICClasses: 1}, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTournamentRepository_RecalculateScores_SurveySubmissionsMissing(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewTournamentRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FROM matches m\\s+JOIN tournaments t ON m.tournament_id = t.id").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "team1_score", "team2_score", "winner_team_id", "status", "next_match_id", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "is_league_match", "result_type", "forfeiting_team_id", "location"}))
+	mock.ExpectExec(regexp.QuoteMeta(reverseScoreLogsSQL("l.event_id = ? AND l.source_match_id IS NOT NULL"))).
+		WithArgs("recalculation", "user-1", 1).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectScoringRules(mock, 1, nil)
+	mock.ExpectQuery(recalcAttendanceSQL).WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "student_count", "attend_count"}))
+	// 提出数を残す前に取り込んだアンケート得点は計算し直せない
+	mock.ExpectQuery(recalcSurveyMissingSQL).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectRollback()
+
+	result, err := r.RecalculateScores(1, "user-1")
+	assert.ErrorIs(t, err, repository.ErrSurveySubmissionsMissing)
+	assert.Nil(t, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -22,12 +22,12 @@ func setupSport(t *testing.T) (repository.SportRepository, sqlmock.Sqlmock, func
 
 var eventSportCols = []string{
 	"event_id", "sport_id", "sport_name", "description", "rules_pdf_url", "location",
-	"min_capacity", "max_capacity",
+	"min_capacity", "max_capacity", "format", "league_group_count", "league_advance_count",
 }
 
 var eventSportDetailCols = []string{
 	"event_id", "sport_id", "description", "rules_pdf_url", "location", "min_capacity",
-	"max_capacity",
+	"max_capacity", "format", "league_group_count", "league_advance_count",
 }
 
 // ─── GetAllSports ──────────────────────────────────────────────────────────
@@ -168,7 +168,7 @@ func intPtr(i int) *int {
 
 func TestSportRepository_GetSportsByEventID(t *testing.T) {
 	const q = `
-		SELECT es.event_id, es.sport_id, s.name, es.description, es.rules_pdf_url, es.location, es.min_capacity, es.max_capacity
+		SELECT es.event_id, es.sport_id, s.name, es.description, es.rules_pdf_url, es.location, es.min_capacity, es.max_capacity, es.format, es.league_group_count, es.league_advance_count
 		FROM event_sports es
 		JOIN sports s ON es.sport_id = s.id
 		WHERE es.event_id = ?
@@ -181,14 +181,18 @@ func TestSportRepository_GetSportsByEventID(t *testing.T) {
 
 		mock.ExpectQuery(regexp.QuoteMeta(q)).WithArgs(1).
 			WillReturnRows(sqlmock.NewRows(eventSportCols).
-				AddRow(1, 1, "バスケットボール", "desc", nil, "gym1", 3, 8).
-				AddRow(1, 2, "サッカー", "desc2", nil, "ground", 5, 10))
+				AddRow(1, 1, "バスケットボール", "desc", nil, "gym1", 3, 8, "tournament", nil, nil).
+				AddRow(1, 2, "サッカー", "desc2", nil, "ground", 5, 10, "league", 2, 2))
 
 		sports, err := repo.GetSportsByEventID(1)
 		require.NoError(t, err)
 		assert.Len(t, sports, 2)
 		assert.Equal(t, "バスケットボール", sports[0].SportName)
 		assert.Equal(t, "gym1", sports[0].Location)
+		assert.Equal(t, models.SportFormatLeague, sports[1].Format)
+		require.NotNil(t, sports[1].LeagueGroupCount)
+		assert.Equal(t, 2, *sports[1].LeagueGroupCount)
+		assert.Nil(t, sports[0].LeagueGroupCount)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
@@ -409,7 +413,7 @@ func TestSportRepository_GetTeamsBySportID(t *testing.T) {
 // ─── GetSportDetails ───────────────────────────────────────────────────────
 
 func TestSportRepository_GetSportDetails(t *testing.T) {
-	const q = "SELECT event_id, sport_id, description, rules_pdf_url, location, min_capacity, max_capacity FROM event_sports WHERE event_id = ? AND sport_id = ?"
+	const q = "SELECT event_id, sport_id, description, rules_pdf_url, location, min_capacity, max_capacity, format, league_group_count, league_advance_count FROM event_sports WHERE event_id = ? AND sport_id = ?"
 
 	t.Run("success", func(t *testing.T) {
 		repo, mock, close := setupSport(t)
@@ -417,7 +421,7 @@ func TestSportRepository_GetSportDetails(t *testing.T) {
 
 		mock.ExpectQuery(regexp.QuoteMeta(q)).WithArgs(1, 1).
 			WillReturnRows(sqlmock.NewRows(eventSportDetailCols).
-				AddRow(1, 1, "desc", nil, "gym1", 3, 8))
+				AddRow(1, 1, "desc", nil, "gym1", 3, 8, "tournament", nil, nil))
 
 		es, err := repo.GetSportDetails(1, 1)
 		require.NoError(t, err)
@@ -482,3 +486,32 @@ func TestSportRepository_UpdateSportDetails(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+// ─── UpdateSportFormat ─────────────────────────────────────────────────────
+
+func TestSportRepository_UpdateSportFormat(t *testing.T) {
+	const q = "UPDATE event_sports SET format = ?, league_group_count = ?, league_advance_count = ? WHERE event_id = ? AND sport_id = ?"
+
+	t.Run("success", func(t *testing.T) {
+		repo, mock, close := setupSport(t)
+		defer close()
+
+		groups, advance := intPtr(2), intPtr(2)
+		mock.ExpectExec(regexp.QuoteMeta(q)).
+			WithArgs("league", groups, advance, 1, 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.UpdateSportFormat(1, 3, models.SportFormatLeague, groups, advance))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("db error", func(t *testing.T) {
+		repo, mock, close := setupSport(t)
+		defer close()
+
+		mock.ExpectExec(regexp.QuoteMeta(q)).WillReturnError(errors.New("db error"))
+
+		assert.Error(t, repo.UpdateSportFormat(1, 1, models.SportFormatTournament, nil, nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,292 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const getLeagueMatchByIDSQL = "SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN forfeiting_team_id = team1_id THEN team2_id WHEN forfeiting_team_id = team2_id THEN team1_id WHEN result_type = 'walkover' AND forfeiting_team_id IS NULL THEN COALESCE(team1_id, team2_id) WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time, is_league_match, league_group, result_type, forfeiting_team_id FROM matches WHERE id = ?"
+
+var leagueMatchCols = []string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time", "is_league_match", "league_group", "result_type", "forfeiting_team_id"}
+
+const checkRainyModeSQL = `
+		SELECT e.is_rainy_mode OR COALESCE(ed.is_rainy_mode, FALSE)
+		FROM matches m
+		JOIN tournaments t ON t.id = m.tournament_id
+		JOIN events e ON e.id = t.event_id
+		LEFT JOIN event_days ed ON ed.id = m.event_day_id
+		WHERE m.id = ?
+	`
+
+func expectRainyModeCheck(mock sqlmock.Sqlmock, matchID int, rainy bool) {
+	mock.ExpectQuery(regexp.QuoteMeta(checkRainyModeSQL)).
+		WithArgs(matchID).
+		WillReturnRows(sqlmock.NewRows([]string{"is_rainy_mode"}).AddRow(rainy))
+}
+
+func expectLeagueMatchPreamble(mock sqlmock.Sqlmock, matchID int, status string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(getLeagueMatchByIDSQL)).
+		WithArgs(matchID).
+		WillReturnRows(sqlmock.NewRows(leagueMatchCols).
+			AddRow(matchID, 7, 0, 0, 1, 2, nil, status, nil, "", false, false, nil, nil, nil, true, "A", "normal", nil))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 3, "ground"))
+	expectRainyModeCheck(mock, matchID, false)
+}
+
+func expectLeagueScoring(mock sqlmock.Sqlmock, matchID int, team1Score, team2Score int, operation string) {
+	expectMatchBaselines(mock, 1, 3)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_score = ?, team2_score = ?, status = 'finished' WHERE id = ?")).
+		WithArgs(team1Score, team2Score, matchID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(reverseScoreLogsSQL("l.source_match_id = ?"))).
+		WithArgs(operation, "user-1", matchID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectScoringRules(mock, 1, nil)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT t.id, t.name, t.class_id, t.sport_id, c.event_id FROM teams t JOIN classes c ON t.class_id = c.id WHERE t.id = ?")).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "class_id", "sport_id", "event_id"}).AddRow(1, "IE1", 101, 3, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT t.id, t.name, t.class_id, t.sport_id, c.event_id FROM teams t JOIN classes c ON t.class_id = c.id WHERE t.id = ?")).
+		WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "class_id", "sport_id", "event_id"}).AddRow(2, "IS1", 102, 3, 1))
+}
+
+func TestTournamentRepository_UpdateMatchResult_LeagueMatch(t *testing.T) {
+	insertScoreLog := regexp.QuoteMeta(insertScoreLogSQL)
+
+	t.Run("引き分けは両クラスにリーグ得点を付与し次の試合へは進めない", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		expectLeagueMatchPreamble(mock, 40, "scheduled")
+		expectLeagueScoring(mock, 40, 1, 1, "match_result")
+		mock.ExpectExec(insertScoreLog).WithArgs(1, 101, 5, "ground_league_points", 40, "match_result", "user-1").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(insertScoreLog).WithArgs(1, 102, 5, "ground_league_points", 40, "match_result", "user-1").WillReturnResult(sqlmock.NewResult(2, 1))
+		expectMatchRevisions(mock, "match_result", "user-1", 40)
+		mock.ExpectCommit()
+
+		assert.NoError(t, r.UpdateMatchResult(40, 1, 1, 0, "normal", 0, "user-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("修正時は以前のリーグ得点を打ち消して勝者に付け直す", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		expectLeagueMatchPreamble(mock, 41, "finished")
+		expectLeagueScoring(mock, 41, 0, 2, "match_correction")
+		mock.ExpectExec(insertScoreLog).WithArgs(1, 102, 10, "ground_league_points", 41, "match_correction", "user-1").WillReturnResult(sqlmock.NewResult(3, 1))
+		expectMatchRevisions(mock, "match_correction", "user-1", 41)
+		mock.ExpectCommit()
+
+		assert.NoError(t, r.UpdateMatchResultForCorrection(41, 0, 2, 0, "normal", 0, "user-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTournamentRepository_UpdateMatchResult_LeagueMatchWithoutTeams(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewTournamentRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(getLeagueMatchByIDSQL)).
+		WithArgs(42).
+		WillReturnRows(sqlmock.NewRows(leagueMatchCols).
+			AddRow(42, 7, 0, 0, 1, nil, nil, "scheduled", nil, "", false, false, nil, nil, nil, true, "A", "normal", nil))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 3, "ground"))
+	expectRainyModeCheck(mock, 42, false)
+	expectMatchBaselines(mock, 1, 3)
+	mock.ExpectRollback()
+
+	err = r.UpdateMatchResult(42, 1, 0, 0, "normal", 0, "user-1")
+	assert.ErrorIs(t, err, repository.ErrMatchTeamsNotDecided)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTournamentRepository_AssignLeagueKnockoutTeams(t *testing.T) {
+	knockoutQ := "SELECT id FROM tournaments WHERE event_id = ? AND sport_id = ? AND is_league_knockout = TRUE"
+
+	t.Run("決勝トーナメントがなければ ErrLeagueKnockoutNotFound", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(knockoutQ)).WithArgs(1, 3).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		err = r.AssignLeagueKnockoutTeams(1, 3, [][2]int{{11, 21}}, "user-1")
+		assert.ErrorIs(t, err, repository.ErrLeagueKnockoutNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("試合が終わっていれば ErrLeagueKnockoutStarted", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(knockoutQ)).WithArgs(1, 3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM matches WHERE tournament_id = ? AND status = 'finished'")).
+			WithArgs(9).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		err = r.AssignLeagueKnockoutTeams(1, 3, [][2]int{{11, 21}}, "user-1")
+		assert.ErrorIs(t, err, repository.ErrLeagueKnockoutStarted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("不戦勝のチームは次の試合へ勝ち上がる", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		firstRoundQ := regexp.QuoteMeta("SELECT id, status, next_match_id FROM matches WHERE tournament_id = ? AND round = 0 AND match_number_in_round = ? AND is_bronze_match = FALSE")
+		firstRoundCols := []string{"id", "status", "next_match_id"}
+		assignPair := regexp.QuoteMeta("UPDATE matches SET team1_id = ?, team2_id = ? WHERE id = ?")
+		assignBye := regexp.QuoteMeta("UPDATE matches SET team1_id = ?, team2_id = NULL WHERE id = ?")
+		advanceBye := regexp.QuoteMeta("UPDATE matches SET team1_id = ? WHERE id = ?")
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(knockoutQ)).WithArgs(1, 3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM matches WHERE tournament_id = ? AND status = 'finished'")).
+			WithArgs(9).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		expectMatchBaselines(mock, 9)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM matches WHERE tournament_id = ? AND round = 0 AND is_bronze_match = FALSE")).
+			WithArgs(9).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+		// 3グループ×2チームの6チームを8枠に並べ、1位シードの2チームが不戦勝になる
+		mock.ExpectQuery(firstRoundQ).WithArgs(9, 0).WillReturnRows(sqlmock.NewRows(firstRoundCols).AddRow(100, "bye", 104))
+		mock.ExpectExec(assignBye).WithArgs(11, 100).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(advanceBye).WithArgs(11, int64(104)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(firstRoundQ).WithArgs(9, 1).WillReturnRows(sqlmock.NewRows(firstRoundCols).AddRow(101, "pending", 104))
+		mock.ExpectExec(assignPair).WithArgs(12, 22, 101).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(firstRoundQ).WithArgs(9, 2).WillReturnRows(sqlmock.NewRows(firstRoundCols).AddRow(102, "bye", 105))
+		mock.ExpectExec(assignBye).WithArgs(21, 102).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(advanceBye).WithArgs(21, int64(105)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(firstRoundQ).WithArgs(9, 3).WillReturnRows(sqlmock.NewRows(firstRoundCols).AddRow(103, "pending", 105))
+		mock.ExpectExec(assignPair).WithArgs(31, 32, 103).WillReturnResult(sqlmock.NewResult(0, 1))
+		expectMatchRevisions(mock, "league_knockout", "user-1", 100, 101, 102, 103, 104, 105)
+		mock.ExpectCommit()
+
+		err = r.AssignLeagueKnockoutTeams(1, 3, [][2]int{{11, 0}, {12, 22}, {21, 0}, {31, 32}}, "user-1")
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("一回戦の枠が合わなければ ErrLeagueKnockoutSizeMismatch", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(knockoutQ)).WithArgs(1, 3).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM matches WHERE tournament_id = ? AND status = 'finished'")).
+			WithArgs(9).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		expectMatchBaselines(mock, 9)
+		// 生成時は2グループ×2チームの4枠だった
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM matches WHERE tournament_id = ? AND round = 0 AND is_bronze_match = FALSE")).
+			WithArgs(9).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectRollback()
+
+		err = r.AssignLeagueKnockoutTeams(1, 3, [][2]int{{11, 0}, {12, 22}, {21, 0}, {31, 32}}, "user-1")
+		assert.ErrorIs(t, err, repository.ErrLeagueKnockoutSizeMismatch)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTournamentRepository_GetLeagueStandings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewTournamentRepository(db)
+
+	// グループA: 3チームとも勝点3で並び、得失点差で IS1 が首位
+	// グループB: IS2 が総得点で首位、IE2 と IT2 は勝点・得失点差・総得点が並ぶ
+	// グループC: 未消化の試合は集計されず、グループは未完了になる
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT m.tournament_id, m.league_group, m.team1_id, m.team2_id, m.team1_score, m.team2_score, m.status
+		FROM matches m
+		JOIN tournaments t ON m.tournament_id = t.id
+		WHERE t.event_id = ? AND t.sport_id = ? AND m.is_league_match = TRUE
+		ORDER BY m.league_group, m.round, m.match_number_in_round
+	`)).
+		WithArgs(1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"tournament_id", "league_group", "team1_id", "team2_id", "team1_score", "team2_score", "status"}).
+			AddRow(7, "A", 1, 2, 1, 0, "finished").
+			AddRow(7, "A", 2, 3, 2, 0, "finished").
+			AddRow(7, "A", 3, 1, 1, 0, "finished").
+			AddRow(8, "B", 4, 5, 2, 1, "finished").
+			AddRow(8, "B", 5, 6, 2, 1, "finished").
+			AddRow(8, "B", 6, 4, 1, 0, "finished").
+			AddRow(9, "C", 7, 8, nil, nil, "pending"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT t.id, t.name, t.class_id, t.sport_id, c.event_id FROM teams t JOIN classes c ON t.class_id = c.id WHERE c.event_id = ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "class_id", "sport_id", "event_id"}).
+			AddRow(1, "IE1", 101, 3, 1).
+			AddRow(2, "IS1", 102, 3, 1).
+			AddRow(3, "IT1", 103, 3, 1).
+			AddRow(4, "IE2", 104, 3, 1).
+			AddRow(5, "IS2", 105, 3, 1).
+			AddRow(6, "IT2", 106, 3, 1).
+			AddRow(7, "IE3", 107, 3, 1).
+			AddRow(8, "IS3", 108, 3, 1))
+
+	groups, err := r.GetLeagueStandings(1, 3)
+	require.NoError(t, err)
+	require.Len(t, groups, 3)
+
+	t.Run("勝点が並ぶと得失点差で並ぶ", func(t *testing.T) {
+		a := groups[0]
+		assert.Equal(t, "A", a.Group)
+		assert.Equal(t, 7, a.TournamentID)
+		assert.True(t, a.Completed)
+		require.Len(t, a.Standings, 3)
+		assert.Equal(t, 2, a.Standings[0].TeamID)
+		assert.Equal(t, 1, a.Standings[0].Rank)
+		assert.Equal(t, "IS1", a.Standings[0].TeamName)
+		assert.Equal(t, 3, a.Standings[0].Points)
+		assert.Equal(t, 1, a.Standings[0].GoalDifference)
+		assert.Equal(t, 3, a.Standings[2].TeamID)
+	})
+
+	t.Run("並んだチームは当該チーム間の成績で決める", func(t *testing.T) {
+		// IE2 と IT2 はともに勝点3・得失点差0・総得点2で並ぶが、直接対決で IT2 が勝っている
+		b := groups[1]
+		require.Len(t, b.Standings, 3)
+		assert.Equal(t, 5, b.Standings[0].TeamID)
+		assert.Equal(t, 6, b.Standings[1].TeamID)
+		assert.Equal(t, 4, b.Standings[2].TeamID)
+		assert.Equal(t, 3, b.Standings[2].Points)
+		assert.Equal(t, 3, b.Standings[2].Rank)
+	})
+
+	t.Run("未消化の試合があるグループは未完了", func(t *testing.T) {
+		c := groups[2]
+		assert.False(t, c.Completed)
+		require.Len(t, c.Standings, 2)
+		assert.Equal(t, 0, c.Standings[0].Played)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -42,6 +42,9 @@ func TestTournamentRepository_GetTournamentsByEventID(t *testing.T) {
 			m.team1_score,
 			m.team2_score,
 			CASE
+				WHEN m.forfeiting_team_id = m.team1_id THEN m.team2_id
+				WHEN m.forfeiting_team_id = m.team2_id THEN m.team1_id
+				WHEN m.result_type = 'walkover' AND m.forfeiting_team_id IS NULL THEN COALESCE(m.team1_id, m.team2_id)
 				WHEN m.team1_score > m.team2_score THEN m.team1_id
 				WHEN m.team2_score > m.team1_score THEN m.team2_id
 				ELSE NULL
@@ -53,9 +56,15 @@ func TestTournamentRepository_GetTournamentsByEventID(t *testing.T) {
 			m.is_loser_bracket_match,
 			m.loser_bracket_round,
 			m.loser_bracket_block,
-			m.rainy_mode_start_time
+			m.rainy_mode_start_time,
+			m.is_league_match,
+			m.league_group,
+			m.result_type,
+			m.forfeiting_team_id,
+			COALESCE(ed.is_rainy_mode, FALSE) AS is_rainy_day
 		FROM matches m
 		JOIN tournaments t ON m.tournament_id = t.id
+		LEFT JOIN event_days ed ON ed.id = m.event_day_id
 		WHERE t.event_id = ?
 		ORDER BY m.tournament_id, m.round, m.match_number_in_round
 	`)).
@@ -78,7 +87,12 @@ func TestTournamentRepository_GetTournamentsByEventID(t *testing.T) {
 			"loser_bracket_round",
 			"loser_bracket_block",
 			"rainy_mode_start_time",
-		}).AddRow(100, 10, 0, 0, 1, 2, nil, nil, nil, "pending", nil, nil, false, false, nil, nil, nil))
+			"is_league_match",
+			"league_group",
+			"result_type",
+			"forfeiting_team_id",
+			"is_rainy_day",
+		}).AddRow(100, 10, 0, 0, 1, 2, nil, nil, nil, "pending", nil, nil, false, false, nil, nil, nil, false, nil, "normal", nil, false))
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
 		SELECT t.id, t.name, t.class_id, t.sport_id, c.event_id
@@ -180,6 +194,9 @@ func TestTournamentRepository_GetTournamentsByEventID_InferWinnerForTie(t *testi
 			m.team1_score,
 			m.team2_score,
 			CASE
+				WHEN m.forfeiting_team_id = m.team1_id THEN m.team2_id
+				WHEN m.forfeiting_team_id = m.team2_id THEN m.team1_id
+				WHEN m.result_type = 'walkover' AND m.forfeiting_team_id IS NULL THEN COALESCE(m.team1_id, m.team2_id)
 				WHEN m.team1_score > m.team2_score THEN m.team1_id
 				WHEN m.team2_score > m.team1_score THEN m.team2_id
 				ELSE NULL
@@ -191,9 +208,15 @@ func TestTournamentRepository_GetTournamentsByEventID_InferWinnerForTie(t *testi
 			m.is_loser_bracket_match,
 			m.loser_bracket_round,
 			m.loser_bracket_block,
-			m.rainy_mode_start_time
+			m.rainy_mode_start_time,
+			m.is_league_match,
+			m.league_group,
+			m.result_type,
+			m.forfeiting_team_id,
+			COALESCE(ed.is_rainy_mode, FALSE) AS is_rainy_day
 		FROM matches m
 		JOIN tournaments t ON m.tournament_id = t.id
+		LEFT JOIN event_days ed ON ed.id = m.event_day_id
 		WHERE t.event_id = ?
 		ORDER BY m.tournament_id, m.round, m.match_number_in_round
 	`)).
@@ -216,9 +239,14 @@ func TestTournamentRepository_GetTournamentsByEventID_InferWinnerForTie(t *testi
 			"loser_bracket_round",
 			"loser_bracket_block",
 			"rainy_mode_start_time",
+			"is_league_match",
+			"league_group",
+			"result_type",
+			"forfeiting_team_id",
+			"is_rainy_day",
 		}).
-			AddRow(100, 10, 0, 0, 1, 2, 5, 5, nil, "finished", 101, nil, false, false, nil, nil, nil).
-			AddRow(101, 10, 1, 0, 1, nil, nil, nil, nil, "pending", nil, nil, false, false, nil, nil, nil))
+			AddRow(100, 10, 0, 0, 1, 2, 5, 5, nil, "finished", 101, nil, false, false, nil, nil, nil, false, nil, "normal", nil, false).
+			AddRow(101, 10, 1, 0, 1, nil, nil, nil, nil, "pending", nil, nil, false, false, nil, nil, nil, false, nil, "normal", nil, false))
 
 	mock.ExpectQuery(regexp.QuoteMeta(`
 		SELECT t.id, t.name, t.class_id, t.sport_id, c.event_id
@@ -245,7 +273,7 @@ func tournamentIntPtr(v int) *int {
 }
 
 func TestTournamentRepository_SaveTournament_BulkInsertsMatchesAndBulkUpdatesNextMatch(t *testing.T) {
-	const insertMatchesSQL = "INSERT INTO matches (tournament_id, round, match_number_in_round, team1_id, team2_id, status, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?),(?, ?, ?, ?, ?, ?, ?, ?, ?, ?),(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	const insertMatchesSQL = "INSERT INTO matches (tournament_id, round, match_number_in_round, team1_id, team2_id, status, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, is_league_match, league_group) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?),(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?),(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 
 	t.Run("main bracket matches are inserted once and next links are updated once", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
@@ -272,14 +300,14 @@ func TestTournamentRepository_SaveTournament_BulkInsertsMatchesAndBulkUpdatesNex
 		}
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO tournaments (name, event_id, sport_id) VALUES (?, ?, ?)")).
-			WithArgs("Basketball Tournament", 1, 2).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO tournaments (name, event_id, sport_id, is_league_knockout) VALUES (?, ?, ?, ?)")).
+			WithArgs("Basketball Tournament", 1, 2, false).
 			WillReturnResult(sqlmock.NewResult(10, 1))
 		mock.ExpectExec(regexp.QuoteMeta(insertMatchesSQL)).
 			WithArgs(
-				int64(10), 0, 0, sql.NullInt64{Int64: 1, Valid: true}, sql.NullInt64{Int64: 2, Valid: true}, "pending", false, false, nil, nil,
-				int64(10), 0, 1, sql.NullInt64{Int64: 3, Valid: true}, sql.NullInt64{Int64: 4, Valid: true}, "pending", false, false, nil, nil,
-				int64(10), 1, 0, sql.NullInt64{}, sql.NullInt64{}, "pending", false, false, nil, nil,
+				int64(10), 0, 0, sql.NullInt64{Int64: 1, Valid: true}, sql.NullInt64{Int64: 2, Valid: true}, "pending", false, false, nil, nil, false, nil,
+				int64(10), 0, 1, sql.NullInt64{Int64: 3, Valid: true}, sql.NullInt64{Int64: 4, Valid: true}, "pending", false, false, nil, nil, false, nil,
+				int64(10), 1, 0, sql.NullInt64{}, sql.NullInt64{}, "pending", false, false, nil, nil, false, nil,
 			).
 			WillReturnResult(sqlmock.NewResult(100, 3))
 		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET next_match_id = CASE id WHEN ? THEN ? WHEN ? THEN ? END WHERE id IN (?,?)")).
@@ -311,14 +339,14 @@ func TestTournamentRepository_SaveTournament_BulkInsertsMatchesAndBulkUpdatesNex
 		}
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO tournaments (name, event_id, sport_id) VALUES (?, ?, ?)")).
-			WithArgs("Basketball Tournament - 敗者戦Aブロック", 1, 2).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO tournaments (name, event_id, sport_id, is_league_knockout) VALUES (?, ?, ?, ?)")).
+			WithArgs("Basketball Tournament - 敗者戦Aブロック", 1, 2, false).
 			WillReturnResult(sqlmock.NewResult(20, 1))
 		mock.ExpectExec(regexp.QuoteMeta(insertMatchesSQL)).
 			WithArgs(
-				int64(20), 0, 0, sql.NullInt64{}, sql.NullInt64{}, "pending", false, true, 1, "A",
-				int64(20), 0, 1, sql.NullInt64{}, sql.NullInt64{}, "pending", false, true, 1, "A",
-				int64(20), 1, 0, sql.NullInt64{}, sql.NullInt64{}, "pending", false, true, 2, "A",
+				int64(20), 0, 0, sql.NullInt64{}, sql.NullInt64{}, "pending", false, true, 1, "A", false, nil,
+				int64(20), 0, 1, sql.NullInt64{}, sql.NullInt64{}, "pending", false, true, 1, "A", false, nil,
+				int64(20), 1, 0, sql.NullInt64{}, sql.NullInt64{}, "pending", false, true, 2, "A", false, nil,
 			).
 			WillReturnResult(sqlmock.NewResult(200, 3))
 		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET next_match_id = CASE id WHEN ? THEN ? WHEN ? THEN ? END WHERE id IN (?,?)")).
@@ -331,6 +359,53 @@ func TestTournamentRepository_SaveTournament_BulkInsertsMatchesAndBulkUpdatesNex
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("bye recipients are advanced into the linked next match slot", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		defer db.Close()
+
+		r := repository.NewTournamentRepository(db)
+
+		tournamentData := &models.TournamentData{
+			Rounds: []models.Round{{Name: "準決勝"}, {Name: "決勝"}},
+			Matches: []models.Match{
+				{RoundIndex: 0, Order: 0, IsBye: true, Sides: []models.Side{{ContestantID: "c0"}, {}}},
+				{RoundIndex: 0, Order: 1, Sides: []models.Side{{ContestantID: "c1"}, {ContestantID: "c2"}}},
+				{RoundIndex: 1, Order: 0},
+			},
+		}
+		teams := []*models.Team{
+			{ID: 1},
+			{ID: 2},
+			{ID: 3},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO tournaments (name, event_id, sport_id, is_league_knockout) VALUES (?, ?, ?, ?)")).
+			WithArgs("Basketball Tournament", 1, 2, false).
+			WillReturnResult(sqlmock.NewResult(50, 1))
+		mock.ExpectExec(regexp.QuoteMeta(insertMatchesSQL)).
+			WithArgs(
+				int64(50), 0, 0, sql.NullInt64{Int64: 1, Valid: true}, sql.NullInt64{}, "bye", false, false, nil, nil, false, nil,
+				int64(50), 0, 1, sql.NullInt64{Int64: 2, Valid: true}, sql.NullInt64{Int64: 3, Valid: true}, "pending", false, false, nil, nil, false, nil,
+				int64(50), 1, 0, sql.NullInt64{}, sql.NullInt64{}, "pending", false, false, nil, nil, false, nil,
+			).
+			WillReturnResult(sqlmock.NewResult(500, 3))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET next_match_id = CASE id WHEN ? THEN ? WHEN ? THEN ? END WHERE id IN (?,?)")).
+			WithArgs(int64(500), int64(502), int64(501), int64(502), int64(500), int64(501)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_id = ? WHERE id = ?")).
+			WithArgs(int64(1), int64(502)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = r.SaveTournament(1, 2, "Basketball", tournamentData, teams)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("no matches inserts tournament only", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		if err != nil {
@@ -341,8 +416,8 @@ func TestTournamentRepository_SaveTournament_BulkInsertsMatchesAndBulkUpdatesNex
 		r := repository.NewTournamentRepository(db)
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO tournaments (name, event_id, sport_id) VALUES (?, ?, ?)")).
-			WithArgs("Empty Tournament", 1, 2).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO tournaments (name, event_id, sport_id, is_league_knockout) VALUES (?, ?, ?, ?)")).
+			WithArgs("Empty Tournament", 1, 2, false).
 			WillReturnResult(sqlmock.NewResult(30, 1))
 		mock.ExpectCommit()
 
@@ -369,11 +444,11 @@ func TestTournamentRepository_SaveTournament_BulkInsertsMatchesAndBulkUpdatesNex
 		}
 
 		mock.ExpectBegin()
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO tournaments (name, event_id, sport_id) VALUES (?, ?, ?)")).
-			WithArgs("Basketball Tournament", 1, 2).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO tournaments (name, event_id, sport_id, is_league_knockout) VALUES (?, ?, ?, ?)")).
+			WithArgs("Basketball Tournament", 1, 2, false).
 			WillReturnResult(sqlmock.NewResult(40, 1))
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO matches (tournament_id, round, match_number_in_round, team1_id, team2_id, status, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")).
-			WithArgs(int64(40), 0, 0, sql.NullInt64{}, sql.NullInt64{}, "pending", false, false, nil, nil).
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO matches (tournament_id, round, match_number_in_round, team1_id, team2_id, status, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, is_league_match, league_group) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")).
+			WithArgs(int64(40), 0, 0, sql.NullInt64{}, sql.NullInt64{}, "pending", false, false, nil, nil, false, nil).
 			WillReturnError(insertErr)
 		mock.ExpectRollback()
 
@@ -405,9 +480,9 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 		mock.ExpectBegin()
 
 		// Mock getMatchByID for the current match
-		rows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time"}).
-			AddRow(matchID, tournamentID, 1, 1, team1ID, team2ID, nil, "inprogress", nextMatchID, "", false, false, nil, nil, nil)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time FROM matches WHERE id = ?")).
+		rows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time", "is_league_match", "league_group", "result_type", "forfeiting_team_id"}).
+			AddRow(matchID, tournamentID, 1, 1, team1ID, team2ID, nil, "inprogress", nextMatchID, "", false, false, nil, nil, nil, false, nil, "normal", nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN forfeiting_team_id = team1_id THEN team2_id WHEN forfeiting_team_id = team2_id THEN team1_id WHEN result_type = 'walkover' AND forfeiting_team_id IS NULL THEN COALESCE(team1_id, team2_id) WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time, is_league_match, league_group, result_type, forfeiting_team_id FROM matches WHERE id = ?")).
 			WithArgs(matchID).WillReturnRows(rows)
 
 		// Mock for rainy mode check (happens right after getMatchByID)
@@ -415,18 +490,18 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 			WithArgs(tournamentID).
 			WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 1, "gym1"))
 
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT is_rainy_mode FROM events WHERE id = ?")).
-			WithArgs(1).
-			WillReturnRows(sqlmock.NewRows([]string{"is_rainy_mode"}).AddRow(false))
+		expectRainyModeCheck(mock, matchID, false)
+
+		expectMatchBaselines(mock, 1, 1)
 
 		// Mock update current match
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_score = ?, team2_score = ?, status = 'finished' WHERE id = ?")).
-			WithArgs(team1Score, team2Score, matchID).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_score = ?, team2_score = ?, result_type = ?, forfeiting_team_id = ?, status = 'finished' WHERE id = ?")).
+			WithArgs(team1Score, team2Score, "normal", nil, matchID).WillReturnResult(sqlmock.NewResult(1, 1))
 
 		// Mock getMatchByID for the next match
-		nextMatchRows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time"}).
-			AddRow(nextMatchID, tournamentID, 2, 1, nil, nil, nil, "pending", nil, "", false, false, nil, nil, nil)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time FROM matches WHERE id = ?")).
+		nextMatchRows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time", "is_league_match", "league_group", "result_type", "forfeiting_team_id"}).
+			AddRow(nextMatchID, tournamentID, 2, 1, nil, nil, nil, "pending", nil, "", false, false, nil, nil, nil, false, nil, "normal", nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN forfeiting_team_id = team1_id THEN team2_id WHEN forfeiting_team_id = team2_id THEN team1_id WHEN result_type = 'walkover' AND forfeiting_team_id IS NULL THEN COALESCE(team1_id, team2_id) WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time, is_league_match, league_group, result_type, forfeiting_team_id FROM matches WHERE id = ?")).
 			WithArgs(nextMatchID).WillReturnRows(nextMatchRows)
 
 		// Mock update next match
@@ -436,6 +511,8 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 		// Mock for bronze match logic (not a semi-final)
 		mock.ExpectQuery(regexp.QuoteMeta("SELECT MAX(round) FROM matches WHERE tournament_id = ?")).WithArgs(tournamentID).WillReturnRows(sqlmock.NewRows([]string{"MAX(round)"}).AddRow(3))
 
+		expectScoringRules(mock, 1, nil)
+
 		mock.ExpectQuery(regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")).
 			WithArgs(tournamentID).
 			WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 1, "gym1"))
@@ -448,13 +525,15 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 			WithArgs(team2ID).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "class_id", "sport_id", "event_id"}).AddRow(team2ID, "Loser Team", 102, 1, 1))
 
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO score_logs (event_id, class_id, points, reason, source_match_id) VALUES (?, ?, ?, ?, ?)")).
-			WithArgs(1, 101, 10, "gym1_win2_points", matchID).
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 101, 10, "gym1_win2_points", matchID, "match_result", "user-1").
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
+		expectMatchRevisions(mock, "match_result", "user-1", matchID, nextMatchID)
+
 		mock.ExpectCommit()
 
-		err = r.UpdateMatchResult(matchID, team1Score, team2Score, int(winnerID))
+		err = r.UpdateMatchResult(matchID, team1Score, team2Score, int(winnerID), "normal", 0, "user-1")
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -482,9 +561,9 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 		mock.ExpectBegin()
 
 		// Mock getMatchByID for the current match (semi-final)
-		rows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time"}).
-			AddRow(matchID, tournamentID, 2, 1, team1ID, team2ID, nil, "inprogress", nextMatchID, "", false, false, nil, nil, nil)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time FROM matches WHERE id = ?")).
+		rows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time", "is_league_match", "league_group", "result_type", "forfeiting_team_id"}).
+			AddRow(matchID, tournamentID, 2, 1, team1ID, team2ID, nil, "inprogress", nextMatchID, "", false, false, nil, nil, nil, false, nil, "normal", nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN forfeiting_team_id = team1_id THEN team2_id WHEN forfeiting_team_id = team2_id THEN team1_id WHEN result_type = 'walkover' AND forfeiting_team_id IS NULL THEN COALESCE(team1_id, team2_id) WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time, is_league_match, league_group, result_type, forfeiting_team_id FROM matches WHERE id = ?")).
 			WithArgs(matchID).WillReturnRows(rows)
 
 		// Mock for rainy mode check (happens right after getMatchByID)
@@ -492,18 +571,18 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 			WithArgs(tournamentID).
 			WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 1, "gym1"))
 
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT is_rainy_mode FROM events WHERE id = ?")).
-			WithArgs(1).
-			WillReturnRows(sqlmock.NewRows([]string{"is_rainy_mode"}).AddRow(false))
+		expectRainyModeCheck(mock, matchID, false)
+
+		expectMatchBaselines(mock, 1, 1)
 
 		// Mock update current match
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_score = ?, team2_score = ?, status = 'finished' WHERE id = ?")).
-			WithArgs(team1Score, team2Score, matchID).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_score = ?, team2_score = ?, result_type = ?, forfeiting_team_id = ?, status = 'finished' WHERE id = ?")).
+			WithArgs(team1Score, team2Score, "normal", nil, matchID).WillReturnResult(sqlmock.NewResult(1, 1))
 
 		// Mock getMatchByID for the next match (final)
-		nextMatchRows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time"}).
-			AddRow(nextMatchID, tournamentID, 3, 1, nil, nil, nil, "pending", nil, "", false, false, nil, nil, nil)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time FROM matches WHERE id = ?")).
+		nextMatchRows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time", "is_league_match", "league_group", "result_type", "forfeiting_team_id"}).
+			AddRow(nextMatchID, tournamentID, 3, 1, nil, nil, nil, "pending", nil, "", false, false, nil, nil, nil, false, nil, "normal", nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN forfeiting_team_id = team1_id THEN team2_id WHEN forfeiting_team_id = team2_id THEN team1_id WHEN result_type = 'walkover' AND forfeiting_team_id IS NULL THEN COALESCE(team1_id, team2_id) WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time, is_league_match, league_group, result_type, forfeiting_team_id FROM matches WHERE id = ?")).
 			WithArgs(nextMatchID).WillReturnRows(nextMatchRows)
 
 		// Mock update next match
@@ -515,15 +594,17 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 		mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM matches WHERE tournament_id = ? AND is_bronze_match = TRUE")).WithArgs(tournamentID).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(bronzeMatchID))
 
 		// Mock getMatchByID for the bronze match
-		bronzeMatchRows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time"}).
-			AddRow(bronzeMatchID, tournamentID, 3, 2, nil, nil, nil, "pending", nil, "", true, false, nil, nil, nil)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time FROM matches WHERE id = ?")).
+		bronzeMatchRows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time", "is_league_match", "league_group", "result_type", "forfeiting_team_id"}).
+			AddRow(bronzeMatchID, tournamentID, 3, 2, nil, nil, nil, "pending", nil, "", true, false, nil, nil, nil, false, nil, "normal", nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN forfeiting_team_id = team1_id THEN team2_id WHEN forfeiting_team_id = team2_id THEN team1_id WHEN result_type = 'walkover' AND forfeiting_team_id IS NULL THEN COALESCE(team1_id, team2_id) WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time, is_league_match, league_group, result_type, forfeiting_team_id FROM matches WHERE id = ?")).
 			WithArgs(bronzeMatchID).WillReturnRows(bronzeMatchRows)
 
 		// Mock update bronze match
 		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_id = ? WHERE id = ?")).
 			WithArgs(loserID, bronzeMatchID).WillReturnResult(sqlmock.NewResult(1, 1))
 
+		expectScoringRules(mock, 1, nil)
+
 		mock.ExpectQuery(regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")).
 			WithArgs(tournamentID).
 			WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 1, "gym1"))
@@ -536,13 +617,15 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 			WithArgs(loserID).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "class_id", "sport_id", "event_id"}).AddRow(loserID, "Loser Team", 201, 1, 1))
 
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO score_logs (event_id, class_id, points, reason, source_match_id) VALUES (?, ?, ?, ?, ?)")).
-			WithArgs(1, 202, 10, "gym1_win3_points", matchID).
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 202, 10, "gym1_win3_points", matchID, "match_result", "user-1").
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
+		expectMatchRevisions(mock, "match_result", "user-1", matchID, nextMatchID, bronzeMatchID)
+
 		mock.ExpectCommit()
 
-		err = r.UpdateMatchResult(matchID, team1Score, team2Score, int(winnerID))
+		err = r.UpdateMatchResult(matchID, team1Score, team2Score, int(winnerID), "normal", 0, "user-1")
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -572,9 +655,9 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 		mock.ExpectBegin()
 
 		// Mock getMatchByID for the loser bracket round 2 match
-		rows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time"}).
-			AddRow(matchID, tournamentID, 1, 0, team1ID, team2ID, nil, "inprogress", nil, "", false, true, loserBracketRound, loserBracketBlock, nil)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time FROM matches WHERE id = ?")).
+		rows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time", "is_league_match", "league_group", "result_type", "forfeiting_team_id"}).
+			AddRow(matchID, tournamentID, 1, 0, team1ID, team2ID, nil, "inprogress", nil, "", false, true, loserBracketRound, loserBracketBlock, nil, false, nil, "normal", nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN forfeiting_team_id = team1_id THEN team2_id WHEN forfeiting_team_id = team2_id THEN team1_id WHEN result_type = 'walkover' AND forfeiting_team_id IS NULL THEN COALESCE(team1_id, team2_id) WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time, is_league_match, league_group, result_type, forfeiting_team_id FROM matches WHERE id = ?")).
 			WithArgs(matchID).WillReturnRows(rows)
 
 		// Mock for rainy mode check
@@ -582,13 +665,13 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 			WithArgs(tournamentID).
 			WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(eventID, 2, "gym2"))
 
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT is_rainy_mode FROM events WHERE id = ?")).
-			WithArgs(eventID).
-			WillReturnRows(sqlmock.NewRows([]string{"is_rainy_mode"}).AddRow(false))
+		expectRainyModeCheck(mock, matchID, false)
+
+		expectMatchBaselines(mock, eventID, 2)
 
 		// Mock update current match
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_score = ?, team2_score = ?, status = 'finished' WHERE id = ?")).
-			WithArgs(team1Score, team2Score, matchID).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_score = ?, team2_score = ?, result_type = ?, forfeiting_team_id = ?, status = 'finished' WHERE id = ?")).
+			WithArgs(team1Score, team2Score, "normal", nil, matchID).WillReturnResult(sqlmock.NewResult(1, 1))
 
 		// NextMatchID is nil for loser bracket round 2, so no next match update
 
@@ -598,6 +681,8 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"MAX(round)"}).AddRow(1))
 
 		// Mock getTournamentMetadata for applyScoring
+		expectScoringRules(mock, eventID, nil)
+
 		mock.ExpectQuery(regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")).
 			WithArgs(tournamentID).
 			WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(eventID, 2, "gym2"))
@@ -613,13 +698,15 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "class_id", "sport_id", "event_id"}).AddRow(loserID, "Loser Team", 302, 2, eventID))
 
 		// Mock adding points to gym2_loser_bracket_champion_points
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO score_logs (event_id, class_id, points, reason, source_match_id) VALUES (?, ?, ?, ?, ?)")).
-			WithArgs(eventID, classID, 10, "gym2_loser_bracket_champion_points", matchID).
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(eventID, classID, 10, "gym2_loser_bracket_champion_points", matchID, "match_result", "user-1").
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
+		expectMatchRevisions(mock, "match_result", "user-1", matchID)
+
 		mock.ExpectCommit()
 
-		err = r.UpdateMatchResult(matchID, team1Score, team2Score, int(winnerID))
+		err = r.UpdateMatchResult(matchID, team1Score, team2Score, int(winnerID), "normal", 0, "user-1")
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -644,26 +731,28 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 
 		mock.ExpectBegin()
 
-		rows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time"}).
-			AddRow(matchID, tournamentID, 3, 1, team1ID, team2ID, nil, "inprogress", nil, "", false, false, nil, nil, nil)
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time FROM matches WHERE id = ?")).
+		rows := sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "winner_team_id", "status", "next_match_id", "start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time", "is_league_match", "league_group", "result_type", "forfeiting_team_id"}).
+			AddRow(matchID, tournamentID, 3, 1, team1ID, team2ID, nil, "inprogress", nil, "", false, false, nil, nil, nil, false, nil, "normal", nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, tournament_id, round, match_number_in_round, team1_id, team2_id, CASE WHEN forfeiting_team_id = team1_id THEN team2_id WHEN forfeiting_team_id = team2_id THEN team1_id WHEN result_type = 'walkover' AND forfeiting_team_id IS NULL THEN COALESCE(team1_id, team2_id) WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status, next_match_id, match_start_time, is_bronze_match, is_loser_bracket_match, loser_bracket_round, loser_bracket_block, rainy_mode_start_time, is_league_match, league_group, result_type, forfeiting_team_id FROM matches WHERE id = ?")).
 			WithArgs(matchID).WillReturnRows(rows)
 
 		mock.ExpectQuery(regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")).
 			WithArgs(tournamentID).
 			WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(eventID, 1, "gym1"))
 
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT is_rainy_mode FROM events WHERE id = ?")).
-			WithArgs(eventID).
-			WillReturnRows(sqlmock.NewRows([]string{"is_rainy_mode"}).AddRow(false))
+		expectRainyModeCheck(mock, matchID, false)
 
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_score = ?, team2_score = ?, status = 'finished' WHERE id = ?")).
-			WithArgs(team1Score, team2Score, matchID).WillReturnResult(sqlmock.NewResult(1, 1))
+		expectMatchBaselines(mock, eventID, 1)
+
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_score = ?, team2_score = ?, result_type = ?, forfeiting_team_id = ?, status = 'finished' WHERE id = ?")).
+			WithArgs(team1Score, team2Score, "normal", nil, matchID).WillReturnResult(sqlmock.NewResult(1, 1))
 
 		mock.ExpectQuery(regexp.QuoteMeta("SELECT MAX(round) FROM matches WHERE tournament_id = ?")).
 			WithArgs(tournamentID).
 			WillReturnRows(sqlmock.NewRows([]string{"MAX(round)"}).AddRow(3))
 
+		expectScoringRules(mock, eventID, nil)
+
 		mock.ExpectQuery(regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")).
 			WithArgs(tournamentID).
 			WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(eventID, 1, "gym1"))
@@ -676,16 +765,18 @@ func TestTournamentRepository_UpdateMatchResult(t *testing.T) {
 			WithArgs(team2ID).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "class_id", "sport_id", "event_id"}).AddRow(team2ID, "Loser Team", 402, 1, eventID))
 
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO score_logs (event_id, class_id, points, reason, source_match_id) VALUES (?, ?, ?, ?, ?)")).
-			WithArgs(eventID, 401, 50, "gym1_champion_points", matchID).
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(eventID, 401, 50, "gym1_champion_points", matchID, "match_result", "user-1").
 			WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO score_logs (event_id, class_id, points, reason, source_match_id) VALUES (?, ?, ?, ?, ?)")).
-			WithArgs(eventID, 402, 40, "gym1_champion_points", matchID).
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(eventID, 402, 40, "gym1_champion_points", matchID, "match_result", "user-1").
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
+		expectMatchRevisions(mock, "match_result", "user-1", matchID)
+
 		mock.ExpectCommit()
 
-		err = r.UpdateMatchResult(matchID, team1Score, team2Score, int(winnerID))
+		err = r.UpdateMatchResult(matchID, team1Score, team2Score, int(winnerID), "normal", 0, "user-1")
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -701,7 +792,7 @@ func TestTournamentRepository_IsMatchResultAlreadyEntered(t *testing.T) {
 
 	r := repository.NewTournamentRepository(db)
 
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT CASE WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status FROM matches WHERE id = ?")).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT CASE WHEN forfeiting_team_id = team1_id THEN team2_id WHEN forfeiting_team_id = team2_id THEN team1_id WHEN result_type = 'walkover' AND forfeiting_team_id IS NULL THEN COALESCE(team1_id, team2_id) WHEN team1_score > team2_score THEN team1_id WHEN team2_score > team1_score THEN team2_id ELSE NULL END AS winner_team_id, status FROM matches WHERE id = ?")).
 		WithArgs(42).
 		WillReturnRows(sqlmock.NewRows([]string{"winner_team_id", "status"}).AddRow(nil, "finished"))
 
@@ -710,3 +801,29 @@ func TestTournamentRepository_IsMatchResultAlreadyEntered(t *testing.T) {
 	assert.True(t, alreadyEntered)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestTournamentRepository_UpdateMatchResult_RainyDay(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	r := repository.NewTournamentRepository(db)
+
+	// 大会全体は晴天だが、試合の開催日だけが雨天時モードになっている
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(getLeagueMatchByIDSQL)).
+		WithArgs(50).
+		WillReturnRows(sqlmock.NewRows(leagueMatchCols).
+			AddRow(50, 7, 0, 0, 1, 2, nil, "scheduled", nil, "", false, false, nil, nil, nil, false, nil, "normal", nil))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 3, "ground"))
+	expectRainyModeCheck(mock, 50, true)
+	mock.ExpectRollback()
+
+	err = r.UpdateMatchResult(50, 2, 1, 0, "normal", 0, "user-1")
+	assert.EqualError(t, err, "雨天時モードでは、昼競技とグラウンド競技の試合結果を更新できません")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
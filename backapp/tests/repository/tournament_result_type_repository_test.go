@@ -0,0 +1,247 @@
+package repository_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"backapp/internal/models"
+	"backapp/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const saveMatchResultSQL = "UPDATE matches SET team1_score = ?, team2_score = ?, result_type = ?, forfeiting_team_id = ?, status = 'finished' WHERE id = ?"
+
+func expectResultTypeMatch(mock sqlmock.Sqlmock, matchID, round, matchNumber int, winnerID interface{}, status string, nextMatchID interface{}, resultType string, forfeitingTeamID interface{}) {
+	mock.ExpectQuery(regexp.QuoteMeta(getLeagueMatchByIDSQL)).
+		WithArgs(matchID).
+		WillReturnRows(sqlmock.NewRows(leagueMatchCols).
+			AddRow(matchID, 5, round, matchNumber, 1, 2, winnerID, status, nextMatchID, "", false, false, nil, nil, nil, false, nil, resultType, forfeitingTeamID))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 2, "gym1"))
+	expectRainyModeCheck(mock, matchID, false)
+}
+
+func TestTournamentRepository_UpdateMatchResult_ResultTypes(t *testing.T) {
+	selectTeam := regexp.QuoteMeta("SELECT t.id, t.name, t.class_id, t.sport_id, c.event_id FROM teams t JOIN classes c ON t.class_id = c.id WHERE t.id = ?")
+	teamCols := []string{"id", "name", "class_id", "sport_id", "event_id"}
+	maxRound := regexp.QuoteMeta("SELECT MAX(round) FROM matches WHERE tournament_id = ?")
+
+	t.Run("決勝の不戦勝はスコアを残さず、不出場のチームに準優勝の得点を付与しない", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		mock.ExpectBegin()
+		expectResultTypeMatch(mock, 30, 3, 0, nil, "scheduled", nil, "normal", nil)
+		expectMatchBaselines(mock, 1, 2)
+		mock.ExpectExec(regexp.QuoteMeta(saveMatchResultSQL)).
+			WithArgs(nil, nil, "walkover", 2, 30).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(maxRound).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"MAX(round)"}).AddRow(3))
+		expectScoringRules(mock, 1, nil)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 2, "gym1"))
+		mock.ExpectQuery(selectTeam).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows(teamCols).AddRow(1, "IE1", 101, 2, 1))
+		mock.ExpectQuery(selectTeam).WithArgs(int64(2)).WillReturnRows(sqlmock.NewRows(teamCols).AddRow(2, "IS1", 102, 2, 1))
+		mock.ExpectExec(regexp.QuoteMeta(insertScoreLogSQL)).
+			WithArgs(1, 101, 80, "gym1_champion_points", 30, "match_result", "user-1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectMatchRevisions(mock, "match_result", "user-1", 30)
+		mock.ExpectCommit()
+
+		require.NoError(t, r.UpdateMatchResult(30, 0, 0, 0, "walkover", 2, "user-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("準決勝の両者不戦敗は次の試合にも3位決定戦にも進めず、得点も付与しない", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		mock.ExpectBegin()
+		expectResultTypeMatch(mock, 31, 2, 0, nil, "scheduled", 32, "normal", nil)
+		expectMatchBaselines(mock, 1, 2)
+		mock.ExpectExec(regexp.QuoteMeta(saveMatchResultSQL)).
+			WithArgs(nil, nil, "double_forfeit", nil, 31).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(maxRound).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"MAX(round)"}).AddRow(3))
+		expectScoringRules(mock, 1, nil)
+		expectMatchRevisions(mock, "match_result", "user-1", 31)
+		mock.ExpectCommit()
+
+		require.NoError(t, r.UpdateMatchResult(31, 0, 0, 0, "double_forfeit", 0, "user-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("両者不戦敗で相手が来なくなった試合は残ったチームの不戦勝にして次の試合へ進める", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(getLeagueMatchByIDSQL)).
+			WithArgs(32).
+			WillReturnRows(sqlmock.NewRows(leagueMatchCols).
+				AddRow(32, 5, 2, 1, 1, nil, nil, "scheduled", 36, "", false, false, nil, nil, nil, false, nil, "normal", nil))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 2, "gym1"))
+		expectRainyModeCheck(mock, 32, false)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM matches WHERE next_match_id = ? AND status = 'finished' AND result_type = ?")).
+			WithArgs(32, "double_forfeit").
+			WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+		expectMatchBaselines(mock, 1, 2)
+		mock.ExpectExec(regexp.QuoteMeta(saveMatchResultSQL)).
+			WithArgs(nil, nil, "walkover", nil, 32).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(getLeagueMatchByIDSQL)).
+			WithArgs(36).
+			WillReturnRows(sqlmock.NewRows(leagueMatchCols).
+				AddRow(36, 5, 3, 0, nil, nil, nil, "scheduled", nil, "", false, false, nil, nil, nil, false, nil, "normal", nil))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_id = ? WHERE id = ?")).
+			WithArgs(int64(1), 36).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(maxRound).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"MAX(round)"}).AddRow(3))
+		expectScoringRules(mock, 1, nil)
+		expectMatchRevisions(mock, "match_result", "user-1", 32, 36)
+		mock.ExpectCommit()
+
+		require.NoError(t, r.UpdateMatchResult(32, 0, 0, 0, "walkover", 0, "user-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("前の試合が両者不戦敗でなければ片方のチームだけの試合に不戦勝は入力できない", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(getLeagueMatchByIDSQL)).
+			WithArgs(32).
+			WillReturnRows(sqlmock.NewRows(leagueMatchCols).
+				AddRow(32, 5, 2, 1, 1, nil, nil, "scheduled", 36, "", false, false, nil, nil, nil, false, nil, "normal", nil))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT t.event_id, t.sport_id, es.location FROM tournaments t LEFT JOIN event_sports es ON es.event_id = t.event_id AND es.sport_id = t.sport_id WHERE t.id = ?")).
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"event_id", "sport_id", "location"}).AddRow(1, 2, "gym1"))
+		expectRainyModeCheck(mock, 32, false)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM matches WHERE next_match_id = ? AND status = 'finished' AND result_type = ?")).
+			WithArgs(32, "double_forfeit").
+			WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+		mock.ExpectRollback()
+
+		err = r.UpdateMatchResult(32, 0, 0, 0, "walkover", 0, "user-1")
+		assert.ErrorIs(t, err, repository.ErrInvalidMatchResult)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("対戦チーム以外を失格にはできない", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		mock.ExpectBegin()
+		expectResultTypeMatch(mock, 33, 0, 0, nil, "scheduled", nil, "normal", nil)
+		mock.ExpectRollback()
+
+		err = r.UpdateMatchResult(33, 0, 0, 0, "disqualification", 9, "user-1")
+		assert.ErrorIs(t, err, repository.ErrInvalidMatchResult)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("不戦勝を両者不戦敗に修正すると得点を打ち消し、次の試合の枠を空ける", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		r := repository.NewTournamentRepository(db)
+
+		mock.ExpectBegin()
+		expectResultTypeMatch(mock, 34, 0, 0, 1, "finished", 35, "walkover", 2)
+		expectMatchBaselines(mock, 1, 2)
+		mock.ExpectExec(regexp.QuoteMeta(reverseScoreLogsSQL("l.source_match_id = ?"))).
+			WithArgs("match_correction", "user-1", 34).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(saveMatchResultSQL)).
+			WithArgs(nil, nil, "double_forfeit", nil, 34).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(getLeagueMatchByIDSQL)).
+			WithArgs(35).
+			WillReturnRows(sqlmock.NewRows(leagueMatchCols).
+				AddRow(35, 5, 1, 0, 1, 3, nil, "scheduled", nil, "", false, false, nil, nil, nil, false, nil, "normal", nil))
+		mock.ExpectQuery(regexp.QuoteMeta(getLeagueMatchByIDSQL)).
+			WithArgs(35).
+			WillReturnRows(sqlmock.NewRows(leagueMatchCols).
+				AddRow(35, 5, 1, 0, 1, 3, nil, "scheduled", nil, "", false, false, nil, nil, nil, false, nil, "normal", nil))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE matches SET team1_id = ? WHERE id = ?")).
+			WithArgs(nil, 35).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(maxRound).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"MAX(round)"}).AddRow(3))
+		expectScoringRules(mock, 1, nil)
+		expectMatchRevisions(mock, "match_correction", "user-1", 34, 35)
+		mock.ExpectCommit()
+
+		require.NoError(t, r.UpdateMatchResultForCorrection(34, 0, 0, 0, "double_forfeit", 0, "user-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTournamentRepository_GetTournamentsByEventID_ResultTypes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	r := repository.NewTournamentRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT is_rainy_mode FROM events WHERE id = ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"is_rainy_mode"}).AddRow(false))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, sport_id FROM tournaments WHERE event_id = ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "sport_id"}).AddRow(10, "Volleyball Tournament", 1))
+	mock.ExpectQuery("is_rainy_day\\s+FROM matches m").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tournament_id", "round", "match_number_in_round", "team1_id", "team2_id", "team1_score", "team2_score", "winner_team_id", "status", "next_match_id", "match_start_time", "is_bronze_match", "is_loser_bracket_match", "loser_bracket_round", "loser_bracket_block", "rainy_mode_start_time", "is_league_match", "league_group", "result_type", "forfeiting_team_id", "is_rainy_day"}).
+			AddRow(100, 10, 0, 0, 1, 2, nil, nil, 1, "finished", nil, nil, false, false, nil, nil, nil, false, nil, "walkover", 2, false).
+			AddRow(101, 10, 0, 1, 3, 4, nil, nil, nil, "finished", nil, nil, false, false, nil, nil, nil, false, nil, "double_forfeit", nil, false))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE c.event_id = ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "class_id", "sport_id", "event_id"}).
+			AddRow(1, "IE1", 101, 1, 1).
+			AddRow(2, "IS1", 102, 1, 1).
+			AddRow(3, "IT1", 103, 1, 1).
+			AddRow(4, "IE2", 104, 1, 1))
+
+	tournaments, err := r.GetTournamentsByEventID(1)
+	require.NoError(t, err)
+	require.Len(t, tournaments, 1)
+
+	var data models.TournamentData
+	require.NoError(t, json.Unmarshal(tournaments[0].Data, &data))
+	require.Len(t, data.Matches, 2)
+
+	walkover := data.Matches[0]
+	assert.Equal(t, "walkover", walkover.ResultType)
+	assert.Equal(t, "不戦勝", walkover.MatchStatus)
+	assert.True(t, walkover.Sides[0].IsWinner)
+	assert.Empty(t, walkover.Sides[0].ResultNote)
+	assert.False(t, walkover.Sides[1].IsWinner)
+	assert.Equal(t, "不出場", walkover.Sides[1].ResultNote)
+	assert.Empty(t, walkover.Sides[1].Scores)
+
+	doubleForfeit := data.Matches[1]
+	assert.Equal(t, "両者不戦敗", doubleForfeit.MatchStatus)
+	for _, side := range doubleForfeit.Sides {
+		assert.False(t, side.IsWinner)
+		assert.Equal(t, "不出場", side.ResultNote)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -11,9 +11,20 @@ export async function load({ fetch, request, url }) {
   const returnData = {
     requests: [],
     activeRequest: null,
+    roles: [],
     error: null
   };
 
+  try {
+    const rolesRes = await fetch(`${BACKEND_URL}/api/root/notifications/roles`, { headers });
+    if (rolesRes.ok) {
+      const { roles } = await rolesRes.json();
+      returnData.roles = roles ?? [];
+    }
+  } catch (error) {
+    console.error('Failed to load notification roles:', error);
+  }
+
   try {
     const res = await fetch(`${BACKEND_URL}/api/root/notification-requests`, { headers });
     if (res.ok) {